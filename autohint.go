@@ -0,0 +1,165 @@
+package ggk
+
+import "sort"
+
+const (
+	// Edges closer than this many pixels are treated as a single edge.
+	kAutohintEdgeMergeDistance = 1.0 / 16
+	// Edges this close to a blue zone are overshoots and snap onto the zone.
+	kAutohintOvershootLimit = 0.66
+	// Coordinates this close are considered equal when looking for flat runs.
+	kAutohintFlatEpsilon = 1.0 / 64
+)
+
+/** tAutohinter
+grid-fits glyph outlines without running the font's own instructions, in
+the spirit of FreeType's light autohinter.
+
+Along each hinted axis the edges of the outline (flat runs and on-curve
+extrema) are snapped to whole pixels, keeping edges at least a pixel apart
+so thin stems don't vanish, and edges slightly past a blue zone
+(the baseline, x-height and cap height) snap onto the zone so overshoots
+don't poke out by a blurry pixel. Every other point is interpolated
+between the edges around it, so curves keep their shape.
+
+The hinting level picks the axes:
+  KPaintHintingNo     -> the outline is left alone
+  KPaintHintingSlight -> vertical only, advances are not rounded
+  KPaintHintingNormal -> vertical only, like FreeType's light mode, or both
+                         axes if the paint is autohinted; never along the
+                         subpixels of LCD text
+  KPaintHintingFull   -> both axes, advances are rounded */
+type tAutohinter struct {
+	hintX         bool
+	hintY         bool
+	roundAdvances bool
+	zones         []Scalar // blue zones in device y.
+}
+
+// Return the autohinter for glyphs drawn with rec, or nil if they are not
+// hinted. Hinting only makes sense when the glyphs are axis aligned.
+func newAutohinter(rec *ScalerContextRec, zones []Scalar) *tAutohinter {
+	if rec.Hinting == KPaintHintingNo || rec.PreSkewX != 0 ||
+		rec.Post2x2[0][1] != 0 || rec.Post2x2[1][0] != 0 {
+		return nil
+	}
+	var hinter = &tAutohinter{
+		hintY: true,
+		zones: zones,
+	}
+	switch rec.Hinting {
+	case KPaintHintingNormal:
+		var lcd = rec.MaskFormat == KMaskFormatLCD16
+		var forced = rec.Flags&KScalerContextFlagForceAutohinting != 0
+		hinter.hintX = forced && (!lcd || rec.Flags&KScalerContextFlagLCDVertical != 0)
+		hinter.hintY = !lcd || rec.Flags&KScalerContextFlagLCDVertical == 0
+	case KPaintHintingFull:
+		hinter.hintX = true
+		hinter.roundAdvances = true
+	}
+	return hinter
+}
+
+func (hinter *tAutohinter) hint(outline *tGlyphOutline) {
+	if hinter.hintY {
+		hintAxis(outline, hinter.zones,
+			func(p *tGlyphPoint) *Scalar { return &p.Y })
+	}
+	if hinter.hintX {
+		hintAxis(outline, nil,
+			func(p *tGlyphPoint) *Scalar { return &p.X })
+	}
+}
+
+// tAutohintEdge maps the position of an edge in the outline to its
+// grid-fitted position.
+type tAutohintEdge struct {
+	orig    Scalar
+	snapped Scalar
+}
+
+// Grid-fit the coordinate of outline picked by coord.
+func hintAxis(outline *tGlyphOutline, zones []Scalar, coord func(p *tGlyphPoint) *Scalar) {
+	var positions []Scalar
+	var start = 0
+	for _, end := range outline.ends {
+		var contour = outline.points[start : end+1]
+		start = end + 1
+		var n = len(contour)
+		for i := range contour {
+			if !contour[i].OnCurve {
+				continue
+			}
+			var v = *coord(&contour[i])
+			var prev = *coord(&contour[(i+n-1)%n])
+			var next = *coord(&contour[(i+1)%n])
+			var flat = ScalarAbs(prev-v) < kAutohintFlatEpsilon || ScalarAbs(next-v) < kAutohintFlatEpsilon
+			var extremum = (prev-v)*(next-v) > 0
+			if flat || extremum {
+				positions = append(positions, v)
+			}
+		}
+	}
+	if len(positions) == 0 {
+		return
+	}
+
+	var edges = snapEdges(positions, zones)
+	for i := range outline.points {
+		var v = coord(&outline.points[i])
+		*v = interpolateEdges(edges, *v)
+	}
+}
+
+// Merge nearby positions into edges and snap them to the pixel grid.
+func snapEdges(positions []Scalar, zones []Scalar) []tAutohintEdge {
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+
+	var edges []tAutohintEdge
+	for _, v := range positions {
+		if n := len(edges); n > 0 && v-edges[n-1].orig < kAutohintEdgeMergeDistance {
+			continue
+		}
+		edges = append(edges, tAutohintEdge{orig: v, snapped: snapToZones(v, zones)})
+	}
+
+	// edges half a pixel or more apart stay at least a pixel apart, so stems
+	// and counters don't vanish. Closer edges keep their unhinted distance.
+	for i := 1; i < len(edges); i++ {
+		var prev = edges[i-1]
+		var gap = edges[i].orig - prev.orig
+		if gap < 0.5 {
+			edges[i].snapped = prev.snapped + gap
+		} else if edges[i].snapped < prev.snapped+1 {
+			edges[i].snapped = prev.snapped + 1
+		}
+	}
+	return edges
+}
+
+func snapToZones(v Scalar, zones []Scalar) Scalar {
+	for _, zone := range zones {
+		if ScalarAbs(v-zone) < kAutohintOvershootLimit {
+			return ScalarRound(zone)
+		}
+	}
+	return ScalarRound(v)
+}
+
+// Map v through the piecewise linear function defined by the edges. Past
+// the first and last edge, v moves with that edge.
+func interpolateEdges(edges []tAutohintEdge, v Scalar) Scalar {
+	var i = sort.Search(len(edges), func(i int) bool { return edges[i].orig >= v })
+	switch {
+	case i < len(edges) && edges[i].orig == v:
+		return edges[i].snapped
+	case i == 0:
+		return v + edges[0].snapped - edges[0].orig
+	case i == len(edges):
+		var last = edges[len(edges)-1]
+		return v + last.snapped - last.orig
+	}
+	var lo, hi = edges[i-1], edges[i]
+	var t = (v - lo.orig) / (hi.orig - lo.orig)
+	return lo.snapped + t*(hi.snapped-lo.snapped)
+}
@@ -0,0 +1,683 @@
+package ggk
+
+import "unicode"
+
+/** TextDirection
+The direction of a paragraph of text.
+KTextDirectionAuto -> taken from the first strong character, left to right if there is none.
+KTextDirectionLTR  -> left to right.
+KTextDirectionRTL  -> right to left. */
+type TextDirection int
+
+const (
+	KTextDirectionAuto = TextDirection(iota)
+	KTextDirectionLTR
+	KTextDirectionRTL
+)
+
+// tBidiClass is the bidirectional character type of the Unicode
+// Bidirectional Algorithm (UAX #9).
+type tBidiClass uint8
+
+const (
+	kBidiL   = tBidiClass(iota) // left to right
+	kBidiR                      // right to left
+	kBidiAL                     // Arabic letter
+	kBidiEN                     // European number
+	kBidiES                     // European separator
+	kBidiET                     // European terminator
+	kBidiAN                     // Arabic number
+	kBidiCS                     // common separator
+	kBidiNSM                    // nonspacing mark
+	kBidiBN                     // boundary neutral
+	kBidiB                      // paragraph separator
+	kBidiS                      // segment separator
+	kBidiWS                     // whitespace
+	kBidiON                     // other neutral
+	kBidiLRE
+	kBidiLRO
+	kBidiRLE
+	kBidiRLO
+	kBidiPDF
+	kBidiLRI
+	kBidiRLI
+	kBidiFSI
+	kBidiPDI
+)
+
+// The deepest embedding level explicit formatting characters can reach.
+const kBidiMaxDepth = 125
+
+type tBidiRange struct {
+	lo, hi Unichar
+	class  tBidiClass
+}
+
+// Bidi classes of the characters that are not marks, left to right
+// letters, or neutral punctuation and symbols. Ranges are inclusive,
+// and the first match wins, so exceptions come before their blocks.
+var gBidiRanges = []tBidiRange{
+	{0x0000, 0x0008, kBidiBN}, {0x0009, 0x0009, kBidiS}, {0x000A, 0x000A, kBidiB},
+	{0x000B, 0x000B, kBidiS}, {0x000C, 0x000C, kBidiWS}, {0x000D, 0x000D, kBidiB},
+	{0x000E, 0x001B, kBidiBN}, {0x001C, 0x001E, kBidiB}, {0x001F, 0x001F, kBidiS},
+	{0x0020, 0x0020, kBidiWS}, {0x0023, 0x0025, kBidiET}, {0x002B, 0x002B, kBidiES},
+	{0x002C, 0x002C, kBidiCS}, {0x002D, 0x002D, kBidiES}, {0x002E, 0x002F, kBidiCS},
+	{0x0030, 0x0039, kBidiEN}, {0x003A, 0x003A, kBidiCS}, {0x007F, 0x0084, kBidiBN},
+	{0x0085, 0x0085, kBidiB}, {0x0086, 0x009F, kBidiBN}, {0x00A0, 0x00A0, kBidiCS},
+	{0x00A2, 0x00A5, kBidiET}, {0x00AD, 0x00AD, kBidiBN}, {0x00B0, 0x00B1, kBidiET},
+	{0x00B2, 0x00B3, kBidiEN}, {0x00B9, 0x00B9, kBidiEN},
+	{0x0590, 0x05FF, kBidiR},
+	{0x0600, 0x0605, kBidiAN}, {0x0609, 0x060A, kBidiET}, {0x060C, 0x060C, kBidiCS},
+	{0x061C, 0x061C, kBidiAL}, {0x0660, 0x0669, kBidiAN}, {0x066A, 0x066A, kBidiET},
+	{0x066B, 0x066C, kBidiAN}, {0x06DD, 0x06DD, kBidiAN}, {0x06F0, 0x06F9, kBidiEN},
+	{0x0600, 0x07BF, kBidiAL},
+	{0x07C0, 0x085F, kBidiR}, {0x08E2, 0x08E2, kBidiAN}, {0x0860, 0x08FF, kBidiAL},
+	{0x1680, 0x1680, kBidiWS}, {0x2000, 0x200A, kBidiWS}, {0x200B, 0x200D, kBidiBN},
+	{0x200E, 0x200E, kBidiL}, {0x200F, 0x200F, kBidiR}, {0x2028, 0x2028, kBidiWS},
+	{0x2029, 0x2029, kBidiB}, {0x202A, 0x202A, kBidiLRE}, {0x202B, 0x202B, kBidiRLE},
+	{0x202C, 0x202C, kBidiPDF}, {0x202D, 0x202D, kBidiLRO}, {0x202E, 0x202E, kBidiRLO},
+	{0x202F, 0x202F, kBidiCS}, {0x2030, 0x2034, kBidiET}, {0x2044, 0x2044, kBidiCS},
+	{0x205F, 0x205F, kBidiWS}, {0x2060, 0x2064, kBidiBN}, {0x2066, 0x2066, kBidiLRI},
+	{0x2067, 0x2067, kBidiRLI}, {0x2068, 0x2068, kBidiFSI}, {0x2069, 0x2069, kBidiPDI},
+	{0x2070, 0x2079, kBidiEN}, {0x207A, 0x207B, kBidiES}, {0x2080, 0x2089, kBidiEN},
+	{0x208A, 0x208B, kBidiES}, {0x20A0, 0x20CF, kBidiET}, {0x3000, 0x3000, kBidiWS},
+	{0xFB1D, 0xFB28, kBidiR}, {0xFB29, 0xFB29, kBidiES}, {0xFB2A, 0xFB4F, kBidiR},
+	{0xFB50, 0xFDFF, kBidiAL}, {0xFE50, 0xFE50, kBidiCS}, {0xFE52, 0xFE52, kBidiCS},
+	{0xFE55, 0xFE55, kBidiCS}, {0xFE62, 0xFE63, kBidiES}, {0xFE70, 0xFEFE, kBidiAL},
+	{0xFEFF, 0xFEFF, kBidiBN}, {0xFF03, 0xFF05, kBidiET}, {0xFF0B, 0xFF0B, kBidiES},
+	{0xFF0C, 0xFF0C, kBidiCS}, {0xFF0D, 0xFF0D, kBidiES}, {0xFF0E, 0xFF0F, kBidiCS},
+	{0xFF10, 0xFF19, kBidiEN}, {0xFF1A, 0xFF1A, kBidiCS},
+	{0x10800, 0x10FFF, kBidiR}, {0x1E800, 0x1EDFF, kBidiR}, {0x1EE00, 0x1EEFF, kBidiAL},
+}
+
+func bidiClassOf(c Unichar) tBidiClass {
+	if unicode.In(rune(c), unicode.Mn, unicode.Me) {
+		return kBidiNSM
+	}
+	for _, r := range gBidiRanges {
+		if c >= r.lo && c <= r.hi {
+			return r.class
+		}
+	}
+	if unicode.IsPunct(rune(c)) || unicode.IsSymbol(rune(c)) {
+		return kBidiON
+	}
+	return kBidiL
+}
+
+func isBidiIsolateInitiator(class tBidiClass) bool {
+	return class == kBidiLRI || class == kBidiRLI || class == kBidiFSI
+}
+
+// Return whether the class is removed by rule X9: explicit embeddings and
+// overrides, and boundary neutrals.
+func isBidiRemoved(class tBidiClass) bool {
+	switch class {
+	case kBidiLRE, kBidiRLE, kBidiLRO, kBidiRLO, kBidiPDF, kBidiBN:
+		return true
+	}
+	return false
+}
+
+// Return whether the class is a neutral or isolate formatting character.
+func isBidiNeutral(class tBidiClass) bool {
+	switch class {
+	case kBidiB, kBidiS, kBidiWS, kBidiON, kBidiLRI, kBidiRLI, kBidiFSI, kBidiPDI:
+		return true
+	}
+	return false
+}
+
+// Return the strong direction (kBidiL or kBidiR) of the first strong
+// character of classes from start, skipping isolates, up to the PDI closing
+// the isolate it is in, or kBidiON if there is none.
+func bidiFirstStrong(classes []tBidiClass, start int) tBidiClass {
+	var depth = 0
+	for i := start; i < len(classes); i++ {
+		switch classes[i] {
+		case kBidiL:
+			if depth == 0 {
+				return kBidiL
+			}
+		case kBidiR, kBidiAL:
+			if depth == 0 {
+				return kBidiR
+			}
+		case kBidiLRI, kBidiRLI, kBidiFSI:
+			depth++
+		case kBidiPDI:
+			if depth == 0 {
+				return kBidiON
+			}
+			depth--
+		case kBidiB:
+			return kBidiON
+		}
+	}
+	return kBidiON
+}
+
+/** BidiParagraphLevel
+Return the embedding level of a paragraph of chars: 0 for left to right,
+1 for right to left. */
+func BidiParagraphLevel(chars []Unichar, direction TextDirection) uint8 {
+	switch direction {
+	case KTextDirectionLTR:
+		return 0
+	case KTextDirectionRTL:
+		return 1
+	}
+	var classes = make([]tBidiClass, len(chars))
+	for i, c := range chars {
+		classes[i] = bidiClassOf(c)
+	}
+	if bidiFirstStrong(classes, 0) == kBidiR {
+		return 1
+	}
+	return 0
+}
+
+/** BidiResolveLevels
+Return the embedding level of each character of a paragraph of chars by
+the Unicode Bidirectional Algorithm: odd levels are right to left, even
+levels left to right. Rule L1 (which resets trailing whitespace) depends
+on where lines break and is left to BidiLineLevels. */
+func BidiResolveLevels(chars []Unichar, direction TextDirection) []uint8 {
+	var b = &tBidiResolver{
+		classes:        make([]tBidiClass, len(chars)),
+		initialClasses: make([]tBidiClass, len(chars)),
+		levels:         make([]uint8, len(chars)),
+		chars:          chars,
+	}
+	for i, c := range chars {
+		b.classes[i] = bidiClassOf(c)
+		b.initialClasses[i] = b.classes[i]
+	}
+	b.paragraphLevel = BidiParagraphLevel(chars, direction)
+	b.resolveExplicit()
+	for _, seq := range b.isolatingRunSequences() {
+		b.resolveSequence(seq)
+	}
+	return b.levels
+}
+
+/** BidiLineLevels
+Apply rule L1 to the levels of a line of a paragraph: segment and
+paragraph separators, and whitespace before them or at the end of the
+line, go back to the paragraph level. */
+func BidiLineLevels(chars []Unichar, levels []uint8, paragraphLevel uint8) []uint8 {
+	var lineLevels = append([]uint8(nil), levels...)
+	var trailing = true
+	for i := len(chars) - 1; i >= 0; i-- {
+		switch class := bidiClassOf(chars[i]); {
+		case class == kBidiS || class == kBidiB:
+			lineLevels[i] = paragraphLevel
+			trailing = true
+		case class == kBidiWS || isBidiIsolateInitiator(class) || class == kBidiPDI || isBidiRemoved(class):
+			if trailing {
+				lineLevels[i] = paragraphLevel
+			}
+		default:
+			trailing = false
+		}
+	}
+	return lineLevels
+}
+
+/** BidiVisualOrder
+Return the logical indices of the characters of a line with levels in
+visual order, left to right (rule L2). */
+func BidiVisualOrder(levels []uint8) []int {
+	var order = make([]int, len(levels))
+	var highest, lowestOdd = uint8(0), uint8(kBidiMaxDepth + 2)
+	for i, level := range levels {
+		order[i] = i
+		if level > highest {
+			highest = level
+		}
+		if level&1 != 0 && level < lowestOdd {
+			lowestOdd = level
+		}
+	}
+	// from the highest level down to the lowest odd level, reverse every
+	// run of characters at that level or higher.
+	for level := highest; level >= lowestOdd && level > 0; level-- {
+		for i := 0; i < len(order); {
+			if levels[order[i]] < level {
+				i++
+				continue
+			}
+			var j = i
+			for j < len(order) && levels[order[j]] >= level {
+				j++
+			}
+			for lo, hi := i, j-1; lo < hi; lo, hi = lo+1, hi-1 {
+				order[lo], order[hi] = order[hi], order[lo]
+			}
+			i = j
+		}
+	}
+	return order
+}
+
+// Mirrored characters drawn in right to left runs.
+var gBidiMirrors = map[Unichar]Unichar{
+	'(': ')', ')': '(', '<': '>', '>': '<', '[': ']', ']': '[', '{': '}', '}': '{',
+	0x00AB: 0x00BB, 0x00BB: 0x00AB, 0x2039: 0x203A, 0x203A: 0x2039,
+	0x2045: 0x2046, 0x2046: 0x2045, 0x2264: 0x2265, 0x2265: 0x2264,
+}
+
+// Return the mirror image of c, as drawn at odd levels (rule L4).
+func bidiMirror(c Unichar) Unichar {
+	if m, ok := gBidiMirrors[c]; ok {
+		return m
+	}
+	return c
+}
+
+type tBidiResolver struct {
+	chars          []Unichar
+	classes        []tBidiClass
+	initialClasses []tBidiClass
+	levels         []uint8
+	paragraphLevel uint8
+	matchingPDI    map[int]int // isolate initiator -> its PDI.
+}
+
+type tBidiStackEntry struct {
+	level    uint8
+	override tBidiClass // kBidiON if none.
+	isolate  bool
+}
+
+// Apply the explicit levels and directions rules X1 to X8.
+func (b *tBidiResolver) resolveExplicit() {
+	b.matchingPDI = make(map[int]int)
+	var open []int
+	for i, class := range b.classes {
+		switch class {
+		case kBidiLRI, kBidiRLI, kBidiFSI:
+			open = append(open, i)
+		case kBidiPDI:
+			if len(open) > 0 {
+				b.matchingPDI[open[len(open)-1]] = i
+				open = open[:len(open)-1]
+			}
+		}
+	}
+
+	var stack = []tBidiStackEntry{{level: b.paragraphLevel, override: kBidiON}}
+	var overflowIsolates, overflowEmbeddings, validIsolates = 0, 0, 0
+	var nextLevel = func(rtl bool) uint8 {
+		var level = stack[len(stack)-1].level
+		if rtl {
+			return (level + 1) | 1
+		}
+		return (level + 2) &^ 1
+	}
+	var applyOverride = func(i int) {
+		var top = stack[len(stack)-1]
+		b.levels[i] = top.level
+		if top.override != kBidiON {
+			b.classes[i] = top.override
+		}
+	}
+
+	for i, class := range b.initialClasses {
+		switch class {
+		case kBidiRLE, kBidiLRE, kBidiRLO, kBidiLRO:
+			b.levels[i] = stack[len(stack)-1].level
+			var level = nextLevel(class == kBidiRLE || class == kBidiRLO)
+			if level <= kBidiMaxDepth && overflowIsolates == 0 && overflowEmbeddings == 0 {
+				var override = kBidiON
+				if class == kBidiRLO {
+					override = kBidiR
+				} else if class == kBidiLRO {
+					override = kBidiL
+				}
+				stack = append(stack, tBidiStackEntry{level, override, false})
+			} else if overflowIsolates == 0 {
+				overflowEmbeddings++
+			}
+
+		case kBidiRLI, kBidiLRI, kBidiFSI:
+			applyOverride(i)
+			var rtl = class == kBidiRLI
+			if class == kBidiFSI {
+				rtl = bidiFirstStrong(b.initialClasses, i+1) == kBidiR
+			}
+			var level = nextLevel(rtl)
+			if level <= kBidiMaxDepth && overflowIsolates == 0 && overflowEmbeddings == 0 {
+				validIsolates++
+				stack = append(stack, tBidiStackEntry{level, kBidiON, true})
+			} else {
+				overflowIsolates++
+			}
+
+		case kBidiPDI:
+			if overflowIsolates > 0 {
+				overflowIsolates--
+			} else if validIsolates > 0 {
+				overflowEmbeddings = 0
+				for !stack[len(stack)-1].isolate {
+					stack = stack[:len(stack)-1]
+				}
+				stack = stack[:len(stack)-1]
+				validIsolates--
+			}
+			applyOverride(i)
+
+		case kBidiPDF:
+			if overflowIsolates == 0 {
+				if overflowEmbeddings > 0 {
+					overflowEmbeddings--
+				} else if !stack[len(stack)-1].isolate && len(stack) >= 2 {
+					stack = stack[:len(stack)-1]
+				}
+			}
+			b.levels[i] = stack[len(stack)-1].level
+
+		case kBidiB:
+			b.levels[i] = b.paragraphLevel
+
+		case kBidiBN:
+			b.levels[i] = stack[len(stack)-1].level
+
+		default:
+			applyOverride(i)
+		}
+	}
+}
+
+// Split the paragraph into isolating run sequences (rule X10): runs of
+// characters at the same level, with the run ending in an isolate
+// initiator continued by the run starting with its PDI.
+func (b *tBidiResolver) isolatingRunSequences() [][]int {
+	var runs [][]int
+	var runOf = make([]int, len(b.classes))
+	for i := range b.classes {
+		if isBidiRemoved(b.initialClasses[i]) {
+			runOf[i] = -1
+			continue
+		}
+		var n = len(runs)
+		if n > 0 {
+			var last = runs[n-1][len(runs[n-1])-1]
+			if b.levels[last] == b.levels[i] {
+				runs[n-1] = append(runs[n-1], i)
+				runOf[i] = n - 1
+				continue
+			}
+		}
+		runs = append(runs, []int{i})
+		runOf[i] = n
+	}
+
+	var continued = make(map[int]bool) // runs that continue a sequence.
+	for initiator, pdi := range b.matchingPDI {
+		if runOf[initiator] >= 0 && runOf[pdi] >= 0 {
+			continued[runOf[pdi]] = true
+		}
+	}
+	var sequences [][]int
+	for r, run := range runs {
+		if continued[r] && b.classes[run[0]] == kBidiPDI {
+			continue
+		}
+		var seq = append([]int(nil), run...)
+		for {
+			var last = seq[len(seq)-1]
+			var pdi, ok = b.matchingPDI[last]
+			if !ok || !isBidiIsolateInitiator(b.initialClasses[last]) || runOf[pdi] < 0 {
+				break
+			}
+			seq = append(seq, runs[runOf[pdi]]...)
+		}
+		sequences = append(sequences, seq)
+	}
+	return sequences
+}
+
+func bidiDirectionOfLevel(level uint8) tBidiClass {
+	if level&1 != 0 {
+		return kBidiR
+	}
+	return kBidiL
+}
+
+// Return the level of the closest character around i in direction step
+// that isn't removed by X9, or the paragraph level.
+func (b *tBidiResolver) adjacentLevel(i, step int) uint8 {
+	for j := i + step; j >= 0 && j < len(b.levels); j += step {
+		if !isBidiRemoved(b.initialClasses[j]) {
+			return b.levels[j]
+		}
+	}
+	return b.paragraphLevel
+}
+
+// Resolve the weak types, neutrals and implicit levels of an isolating run
+// sequence (rules W1 to I2).
+func (b *tBidiResolver) resolveSequence(seq []int) {
+	var level = b.levels[seq[0]]
+	var first, last = seq[0], seq[len(seq)-1]
+	var before = b.adjacentLevel(first, -1)
+	var after = b.adjacentLevel(last, 1)
+	if isBidiIsolateInitiator(b.initialClasses[last]) {
+		after = b.paragraphLevel
+	}
+	if before < level {
+		before = level
+	}
+	if after < level {
+		after = level
+	}
+	var sos, eos = bidiDirectionOfLevel(before), bidiDirectionOfLevel(after)
+
+	var types = make([]tBidiClass, len(seq))
+	for k, i := range seq {
+		types[k] = b.classes[i]
+	}
+
+	// W1: nonspacing marks take the type of the character before them.
+	for k := range types {
+		if types[k] != kBidiNSM {
+			continue
+		}
+		switch {
+		case k == 0:
+			types[k] = sos
+		case isBidiIsolateInitiator(types[k-1]) || types[k-1] == kBidiPDI:
+			types[k] = kBidiON
+		default:
+			types[k] = types[k-1]
+		}
+	}
+	// W2: European numbers after Arabic letters are Arabic numbers. W3:
+	// Arabic letters are right to left.
+	var lastStrong = sos
+	for k, t := range types {
+		switch t {
+		case kBidiL, kBidiR, kBidiAL:
+			lastStrong = t
+		case kBidiEN:
+			if lastStrong == kBidiAL {
+				types[k] = kBidiAN
+			}
+		}
+	}
+	for k, t := range types {
+		if t == kBidiAL {
+			types[k] = kBidiR
+		}
+	}
+	// W4: a single separator between two numbers of the same kind joins them.
+	for k := 1; k+1 < len(types); k++ {
+		var prev, next = types[k-1], types[k+1]
+		switch {
+		case types[k] == kBidiES && prev == kBidiEN && next == kBidiEN:
+			types[k] = kBidiEN
+		case types[k] == kBidiCS && prev == next && (prev == kBidiEN || prev == kBidiAN):
+			types[k] = prev
+		}
+	}
+	// W5: terminators next to European numbers are European numbers.
+	for k := 0; k < len(types); {
+		if types[k] != kBidiET {
+			k++
+			continue
+		}
+		var end = k
+		for end < len(types) && types[end] == kBidiET {
+			end++
+		}
+		if (k > 0 && types[k-1] == kBidiEN) || (end < len(types) && types[end] == kBidiEN) {
+			for j := k; j < end; j++ {
+				types[j] = kBidiEN
+			}
+		}
+		k = end
+	}
+	// W6: remaining separators and terminators are neutral.
+	for k, t := range types {
+		if t == kBidiES || t == kBidiET || t == kBidiCS {
+			types[k] = kBidiON
+		}
+	}
+	// W7: European numbers in left to right context are left to right.
+	lastStrong = sos
+	for k, t := range types {
+		switch t {
+		case kBidiL, kBidiR:
+			lastStrong = t
+		case kBidiEN:
+			if lastStrong == kBidiL {
+				types[k] = kBidiL
+			}
+		}
+	}
+
+	var embedding = bidiDirectionOfLevel(level)
+	b.resolveBrackets(seq, types, sos, embedding)
+
+	// N1 and N2: runs of neutrals between characters of the same direction
+	// take that direction, the others the embedding direction. Numbers
+	// count as right to left.
+	var strong = func(t tBidiClass) tBidiClass {
+		if t == kBidiEN || t == kBidiAN {
+			return kBidiR
+		}
+		return t
+	}
+	for k := 0; k < len(types); {
+		if !isBidiNeutral(types[k]) {
+			k++
+			continue
+		}
+		var end = k
+		for end < len(types) && isBidiNeutral(types[end]) {
+			end++
+		}
+		var prev, next = sos, eos
+		if k > 0 {
+			prev = strong(types[k-1])
+		}
+		if end < len(types) {
+			next = strong(types[end])
+		}
+		var direction = embedding
+		if prev == next {
+			direction = prev
+		}
+		for j := k; j < end; j++ {
+			types[j] = direction
+		}
+		k = end
+	}
+
+	// I1 and I2: implicit levels.
+	for k, i := range seq {
+		switch t := types[k]; {
+		case level&1 == 0 && t == kBidiR:
+			b.levels[i]++
+		case level&1 == 0 && (t == kBidiAN || t == kBidiEN):
+			b.levels[i] += 2
+		case level&1 != 0 && (t == kBidiL || t == kBidiEN || t == kBidiAN):
+			b.levels[i]++
+		}
+	}
+}
+
+// Resolve paired brackets (rule N0): a pair enclosing text of the
+// embedding direction takes that direction; one enclosing only text of the
+// other direction takes it too if the context before the pair does.
+func (b *tBidiResolver) resolveBrackets(seq []int, types []tBidiClass, sos, embedding tBidiClass) {
+	type tPair struct{ open, close int }
+	var pairs []tPair
+	var stack []int
+	for k, i := range seq {
+		if types[k] != kBidiON {
+			continue
+		}
+		switch c := b.chars[i]; c {
+		case '(', '[', '{':
+			if len(stack) >= 63 {
+				return
+			}
+			stack = append(stack, k)
+		case ')', ']', '}':
+			for s := len(stack) - 1; s >= 0; s-- {
+				if bidiMirror(b.chars[seq[stack[s]]]) == c {
+					pairs = append(pairs, tPair{stack[s], k})
+					stack = stack[:s]
+					break
+				}
+			}
+		}
+	}
+	// pairs are processed in order of their opening brackets.
+	for i := 1; i < len(pairs); i++ {
+		for j := i; j > 0 && pairs[j].open < pairs[j-1].open; j-- {
+			pairs[j], pairs[j-1] = pairs[j-1], pairs[j]
+		}
+	}
+
+	var strong = func(t tBidiClass) tBidiClass {
+		switch t {
+		case kBidiL:
+			return kBidiL
+		case kBidiR, kBidiEN, kBidiAN:
+			return kBidiR
+		}
+		return kBidiON
+	}
+	for _, pair := range pairs {
+		var found = kBidiON
+		for k := pair.open + 1; k < pair.close; k++ {
+			var s = strong(types[k])
+			if s == embedding {
+				found = embedding
+				break
+			}
+			if s != kBidiON {
+				found = s
+			}
+		}
+		if found == kBidiON {
+			continue
+		}
+		if found != embedding {
+			var context = sos
+			for k := pair.open - 1; k >= 0; k-- {
+				if s := strong(types[k]); s != kBidiON {
+					context = s
+					break
+				}
+			}
+			if context != found {
+				found = embedding
+			}
+		}
+		types[pair.open], types[pair.close] = found, found
+	}
+}
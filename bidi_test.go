@@ -0,0 +1,77 @@
+package ggk
+
+import "testing"
+
+func TestBidiResolveLevels(t *testing.T) {
+	var tests = []struct {
+		name      string
+		text      string
+		direction TextDirection
+		want      []uint8
+	}{
+		{"left to right", "ab", KTextDirectionAuto, []uint8{0, 0}},
+		{"auto left to right", "ab אב", KTextDirectionAuto, []uint8{0, 0, 0, 1, 1}},
+		{"auto right to left", "אב ab", KTextDirectionAuto, []uint8{1, 1, 1, 2, 2}},
+		{"forced right to left", "ab", KTextDirectionRTL, []uint8{2, 2}},
+		{"numbers in right to left", "אב 12", KTextDirectionAuto, []uint8{1, 1, 1, 2, 2}},
+		{"separated numbers", "א 1.5", KTextDirectionAuto, []uint8{1, 1, 2, 2, 2}},
+		{"arabic numbers after arabic", "ب 12", KTextDirectionAuto, []uint8{1, 1, 2, 2}},
+		{"mark takes the letter's level", "בַ a", KTextDirectionAuto, []uint8{1, 1, 1, 2}},
+		{"neutral between opposite letters", "a-א", KTextDirectionLTR, []uint8{0, 0, 1}},
+		{"brackets around right to left", "a (א)", KTextDirectionLTR, []uint8{0, 0, 0, 1, 0}},
+		{"brackets in right to left", "א (a) ב", KTextDirectionRTL, []uint8{1, 1, 1, 2, 1, 1, 1}},
+		{"override", "\u202Eab\u202C", KTextDirectionLTR, []uint8{0, 1, 1, 0}},
+		{"embedding", "\u202Bab\u202C", KTextDirectionLTR, []uint8{0, 2, 2, 0}},
+		{"isolate", "א \u2066ab\u2069", KTextDirectionAuto, []uint8{1, 1, 1, 2, 2, 1}},
+	}
+	for _, test := range tests {
+		var chars []Unichar
+		for _, r := range test.text {
+			chars = append(chars, Unichar(r))
+		}
+		var levels = BidiResolveLevels(chars, test.direction)
+		if len(levels) != len(test.want) {
+			t.Errorf("%v want %v got %v", test.name, test.want, levels)
+			continue
+		}
+		for i := range levels {
+			if levels[i] != test.want[i] {
+				t.Errorf("%v want %v got %v", test.name, test.want, levels)
+				break
+			}
+		}
+	}
+}
+
+func TestBidiLineLevels(t *testing.T) {
+	var chars = []Unichar{0x05D0, ' ', 'a', ' ', ' '}
+	var levels = BidiLineLevels(chars, []uint8{1, 1, 2, 2, 2}, 1)
+	var want = []uint8{1, 1, 2, 1, 1}
+	for i := range want {
+		if levels[i] != want[i] {
+			t.Errorf("BidiLineLevels() want %v got %v", want, levels)
+			break
+		}
+	}
+}
+
+func TestBidiVisualOrder(t *testing.T) {
+	var tests = []struct {
+		levels []uint8
+		want   []int
+	}{
+		{[]uint8{0, 0, 0}, []int{0, 1, 2}},
+		{[]uint8{1, 1, 1}, []int{2, 1, 0}},
+		{[]uint8{0, 0, 1, 1, 0}, []int{0, 1, 3, 2, 4}},
+		{[]uint8{1, 1, 2, 2, 1}, []int{4, 2, 3, 1, 0}},
+	}
+	for _, test := range tests {
+		var order = BidiVisualOrder(test.levels)
+		for i := range order {
+			if order[i] != test.want[i] {
+				t.Errorf("BidiVisualOrder(%v) want %v got %v", test.levels, test.want, order)
+				break
+			}
+		}
+	}
+}
@@ -15,9 +15,19 @@ func NewBitmapDevice(bitmap *Bitmap, props *SurfaceProps) *BitmapDevice {
 		bitmap: bitmap,
 	}
 	device.Device = device
+	device.surfaceProps = props
 	return device
 }
 
+func (dev *BitmapDevice) OnCreateDevice(cinfo *DeviceCreateInfo, paint *Paint) Device {
+	var bmp = new(Bitmap)
+	if err := bmp.AllocPixels(cinfo.Info, 0); err != nil {
+		return nil
+	}
+	var props = NewSurfacePropsGeometry(dev.SurfaceProps().Flags(), cinfo.PixelGeometry)
+	return NewBitmapDevice(bmp, props)
+}
+
 func (dev *BitmapDevice) ImageInfo() *ImageInfo {
 	return dev.bitmap.Info()
 }
@@ -51,4 +61,26 @@ func (dev *BitmapDevice) DrawPoints(draw *Draw, mode CanvasPointMode, count int,
 
 func (bmpdev *BitmapDevice) DrawPaint(draw *Draw, paint *Paint) {
 	draw.DrawPaint(paint)
-}
\ No newline at end of file
+}
+
+func (dev *BitmapDevice) DrawPath(draw *Draw, path *Path, paint *Paint, prePathMatrix *Matrix, pathIsMutable bool) {
+	draw.DrawPath(path, paint, prePathMatrix, pathIsMutable)
+}
+
+func (dev *BitmapDevice) DrawText(draw *Draw, text string, x, y Scalar, paint *Paint) {
+	draw.DrawText(text, x, y, paint, dev.SurfaceProps())
+}
+
+func (dev *BitmapDevice) DrawPosText(draw *Draw, text string, pos []Scalar, scalarsPerPos int, offset Point, paint *Paint) {
+	draw.DrawPosText(text, pos, scalarsPerPos, offset, paint, dev.SurfaceProps())
+}
+
+func (dev *BitmapDevice) DrawVertices(draw *Draw, vmode CanvasVertexMode, vertexCount int, verts []Point, texs []Point,
+	colors []Color, xmode *Xfermode, indices []uint16, indexCount int, paint *Paint) {
+	draw.DrawVertices(vmode, vertexCount, verts, texs, colors, xmode, indices, indexCount, paint)
+}
+
+func (dev *BitmapDevice) DrawAtlas(draw *Draw, atlas *Image, xform []RSXform, tex []Rect, colors []Color, count int,
+	mode XfermodeMode, paint *Paint) {
+	draw.DrawAtlas(atlas, xform, tex, colors, count, mode, paint)
+}
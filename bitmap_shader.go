@@ -0,0 +1,60 @@
+package ggk
+
+/** tBitmapShader
+tiles the pixels of a bitmap, in N32 premultiplied colors. */
+type tBitmapShader struct {
+	bitmap *Bitmap
+	tmx    ShaderTileMode
+	tmy    ShaderTileMode
+}
+
+// Return a shader tiling bmp in tmx horizontally and tmy vertically, drawn
+// through localMatrix, which may be nil.
+func newBitmapShader(bmp *Bitmap, tmx, tmy ShaderTileMode, localMatrix *Matrix) *Shader {
+	return NewShader(&tBitmapShader{bitmap: bmp, tmx: tmx, tmy: tmy}, localMatrix)
+}
+
+func (impl *tBitmapShader) OnCreateContext(shader *Shader, rec *ShaderContextRec) *ShaderContext {
+	var inverse, ok = shader.totalMatrix(rec).Invert()
+	if !ok {
+		return nil
+	}
+	return NewShaderContext(shader, rec, newBitmapShaderContext(impl.bitmap, inverse, impl.tmx, impl.tmy,
+		rec.Paint.Alpha()))
+}
+
+/** tBitmapShaderContext
+shades the pixels of a bitmap, sampling the pixel nearest to the center of
+each pixel of the device. */
+type tBitmapShaderContext struct {
+	pixmap *Pixmap
+	matrix *Matrix // maps the device into the pixels of the bitmap.
+	tmx    ShaderTileMode
+	tmy    ShaderTileMode
+	scale  uint32
+}
+
+func newBitmapShaderContext(bmp *Bitmap, matrix *Matrix, tmx, tmy ShaderTileMode, alpha uint8) *tBitmapShaderContext {
+	return &tBitmapShaderContext{
+		pixmap: filterPixmap(bmp),
+		matrix: matrix,
+		tmx:    tmx,
+		tmy:    tmy,
+		scale:  Alpha255To256(uint32(alpha)),
+	}
+}
+
+func (impl *tBitmapShaderContext) OnShadeSpan(context *ShaderContext, x, y int, dst []PremulColor, count int) {
+	var width, height = int(impl.pixmap.Width()), int(impl.pixmap.Height())
+	for i := 0; i < count; i++ {
+		// sample the nearest pixel of the center of each pixel.
+		var pt = impl.matrix.MapXY(Scalar(x+i)+0.5, Scalar(y)+0.5)
+		var px = tileShaderCoord(impl.tmx, ScalarFloorToInt(pt.X), width)
+		var py = tileShaderCoord(impl.tmy, ScalarFloorToInt(pt.Y), height)
+		var color = impl.pixmap.Pixel32(px, py)
+		if impl.scale != 256 {
+			color = AlphaMulQ(color, impl.scale)
+		}
+		dst[i] = PremulColor(color)
+	}
+}
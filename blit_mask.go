@@ -0,0 +1,48 @@
+package ggk
+
+/** forEachMaskCoverage
+calls blend with the coverage of each covered pixel of mask inside clip.
+The mask must be BW, A8, 3D, whose alpha plane is used, or LCD16, whose
+coverage is the average of its subpixels, for destinations that can't
+blend each channel on its own. */
+func forEachMaskCoverage(mask *Mask, clip Rect, blend func(x, y int, coverage uint8)) {
+	var r = mask.Bounds
+	if !r.Intersect(clip) {
+		return
+	}
+	var left, top = int(r.Left), int(r.Top)
+	var right, bottom = int(r.R()), int(r.B())
+	for y := top; y < bottom; y++ {
+		for x := left; x < right; x++ {
+			var coverage uint8
+			if mask.Format == KMaskFormatLCD16 {
+				coverage = lcd16ToA8(mask.lcd16At(x, y))
+			} else {
+				coverage = mask.alphaAt(x, y)
+			}
+			if coverage != 0 {
+				blend(x, y, coverage)
+			}
+		}
+	}
+}
+
+// Return the average coverage of the subpixels of an LCD16 value.
+func lcd16ToA8(mask uint16) uint8 {
+	var r, g, b = unpackLCD16(mask)
+	return uint8(((r+g+b)*255 + 48) / 96)
+}
+
+// Return color as a premultiplied 32-bit pixel.
+func premulPixel32(color Color) uint32 {
+	var a, r, g, b = color.ARGB()
+	return PackARGB32(uint32(a), uint32(MulDiv255Round(r, a)), uint32(MulDiv255Round(g, a)), uint32(MulDiv255Round(b, a)))
+}
+
+// Blend the premultiplied pixel src, scaled by coverage, over dst.
+func blendARGB32(src, dst uint32, coverage uint8) uint32 {
+	if coverage != 0xff {
+		src = AlphaMulQ(src, Alpha255To256(uint32(coverage)))
+	}
+	return PMSrcOver(src, dst)
+}
@@ -0,0 +1,124 @@
+package ggk
+
+// LCD16 masks pack per-subpixel coverage as RGB565.
+const (
+	kLCD16ShiftR = 11
+	kLCD16ShiftG = 5
+	kLCD16ShiftB = 0
+)
+
+func packLCD16(r, g, b uint32) uint16 {
+	return uint16((r>>3)<<kLCD16ShiftR | (g>>2)<<kLCD16ShiftG | (b>>3)<<kLCD16ShiftB)
+}
+
+// Return the red, green and blue coverage of an LCD16 value, each in 0..32.
+func unpackLCD16(mask uint16) (r, g, b int) {
+	r = int(mask>>kLCD16ShiftR) & 0x1f
+	g = int(mask>>(kLCD16ShiftG+1)) & 0x1f // drop the low green bit.
+	b = int(mask>>kLCD16ShiftB) & 0x1f
+	return upscale31To32(r), upscale31To32(g), upscale31To32(b)
+}
+
+func upscale31To32(value int) int {
+	return value + (value >> 4)
+}
+
+func blend32(src, dst, scale int) int {
+	return dst + ((src - dst) * scale >> 5)
+}
+
+// blendLCD16 blends the unpremultiplied source color (with srcA in 0..256)
+// into dst using per-channel coverage. LCD blending assumes an opaque dst.
+func blendLCD16(srcA, srcR, srcG, srcB int, dst uint32, mask uint16) uint32 {
+	if mask == 0 {
+		return dst
+	}
+	var maskR, maskG, maskB = unpackLCD16(mask)
+	maskR = maskR * srcA >> 8
+	maskG = maskG * srcA >> 8
+	maskB = maskB * srcA >> 8
+
+	var dstR = int(GetPackedR32(dst))
+	var dstG = int(GetPackedG32(dst))
+	var dstB = int(GetPackedB32(dst))
+	return PackARGB32(0xff,
+		uint32(blend32(srcR, dstR, maskR)),
+		uint32(blend32(srcG, dstG, maskG)),
+		uint32(blend32(srcB, dstB, maskB)))
+}
+
+// blendLCD16Opaque is blendLCD16 for an opaque source color, returning
+// opaqueDst for full coverage.
+func blendLCD16Opaque(srcR, srcG, srcB int, dst uint32, mask uint16, opaqueDst uint32) uint32 {
+	if mask == 0 {
+		return dst
+	}
+	if mask == 0xffff {
+		return opaqueDst
+	}
+	var maskR, maskG, maskB = unpackLCD16(mask)
+	var dstR = int(GetPackedR32(dst))
+	var dstG = int(GetPackedG32(dst))
+	var dstB = int(GetPackedB32(dst))
+	return PackARGB32(0xff,
+		uint32(blend32(srcR, dstR, maskR)),
+		uint32(blend32(srcG, dstG, maskG)),
+		uint32(blend32(srcB, dstB, maskB)))
+}
+
+/** blitLCD16MaskD32
+blits the part of an LCD16 mask inside clip into a 32-bit dst with color,
+using per-channel coverage. The dst is treated as opaque: callers only
+request LCD masks for opaque destinations, or for layers whose owner
+promised to draw LCD text over opaque content. */
+func blitLCD16MaskD32(dst *Pixmap, mask *Mask, clip Rect, color Color) {
+	var r = mask.Bounds
+	if !r.Intersect(clip) {
+		return
+	}
+	var left, top = int(r.Left), int(r.Top)
+	var right, bottom = int(r.R()), int(r.B())
+
+	var srcA, srcR, srcG, srcB = color.ARGB()
+	if srcA == 0xff {
+		var opaqueDst = PackARGB32(0xff, uint32(srcR), uint32(srcG), uint32(srcB))
+		for y := top; y < bottom; y++ {
+			for x := left; x < right; x++ {
+				dst.SetPixel32(x, y, blendLCD16Opaque(int(srcR), int(srcG), int(srcB),
+					dst.Pixel32(x, y), mask.lcd16At(x, y), opaqueDst))
+			}
+		}
+		return
+	}
+
+	var scale = int(Alpha255To256(uint32(srcA)))
+	for y := top; y < bottom; y++ {
+		for x := left; x < right; x++ {
+			dst.SetPixel32(x, y, blendLCD16(scale, int(srcR), int(srcG), int(srcB),
+				dst.Pixel32(x, y), mask.lcd16At(x, y)))
+		}
+	}
+}
+
+/** blitARGB32MaskD32
+blends the part of a premultiplied 32-bit mask, such as a color glyph,
+inside clip over a 32-bit dst. The mask carries its own colors, only the
+paint's alpha applies. */
+func blitARGB32MaskD32(dst *Pixmap, mask *Mask, clip Rect, alpha uint8) {
+	var r = mask.Bounds
+	if !r.Intersect(clip) {
+		return
+	}
+	var left, top = int(r.Left), int(r.Top)
+	var right, bottom = int(r.R()), int(r.B())
+	var scale = Alpha255To256(uint32(alpha))
+	for y := top; y < bottom; y++ {
+		for x := left; x < right; x++ {
+			var src = mask.argb32At(x, y)
+			if src == 0 {
+				continue
+			}
+			dst.SetPixel32(x, y, PMSrcOver(AlphaMulQ(src, scale), dst.Pixel32(x, y)))
+		}
+	}
+}
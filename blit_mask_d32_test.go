@@ -0,0 +1,83 @@
+package ggk
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestBlendLCD16Opaque(t *testing.T) {
+	var white = PackARGB32(0xff, 0xff, 0xff, 0xff)
+	var black = PackARGB32(0xff, 0, 0, 0)
+
+	if got := blendLCD16Opaque(0, 0, 0, white, 0, black); got != white {
+		t.Errorf("blendLCD16Opaque with no coverage want %#x got %#x", white, got)
+	}
+	if got := blendLCD16Opaque(0, 0, 0, white, 0xffff, black); got != black {
+		t.Errorf("blendLCD16Opaque with full coverage want %#x got %#x", black, got)
+	}
+
+	// only the red subpixel is covered.
+	var got = blendLCD16Opaque(0, 0, 0, white, packLCD16(255, 0, 0), black)
+	if GetPackedR32(got) != 0 || GetPackedG32(got) != 0xff || GetPackedB32(got) != 0xff {
+		t.Errorf("blendLCD16Opaque with red coverage want %#x got %#x",
+			PackARGB32(0xff, 0, 0xff, 0xff), got)
+	}
+}
+
+func TestPackA8ToLCD16(t *testing.T) {
+	// a single subpixel column covered, three pixels wide at 3x resolution.
+	var src = make([]byte, 9)
+	src[3] = 0xff
+
+	var lcd = func(flags ScalerContextFlags) [3]uint16 {
+		var dst = make([]byte, 6)
+		packA8ToLCD16(src, 9, dst, 6, 3, 1, flags)
+		var pixels [3]uint16
+		for i := range pixels {
+			pixels[i] = binary.LittleEndian.Uint16(dst[i*2:])
+		}
+		return pixels
+	}
+
+	var rgb, bgr = lcd(0), lcd(KScalerContextFlagLCDBGR)
+	var r, g, b = unpackLCD16(rgb[1])
+	if r <= g || g <= b {
+		t.Errorf("packA8ToLCD16 RGB want coverage falling off from red got %v %v %v", r, g, b)
+	}
+	if rgb[0] == 0 {
+		t.Errorf("packA8ToLCD16 want the filter to spread into the left neighbour")
+	}
+	if r2, g2, b2 := unpackLCD16(bgr[1]); r2 != b || g2 != g || b2 != r {
+		t.Errorf("packA8ToLCD16 BGR want %v %v %v got %v %v %v", b, g, r, r2, g2, b2)
+	}
+}
+
+func TestBlitARGB32MaskD32(t *testing.T) {
+	var dst = NewPixmap()
+	dst.Reset(NewImageInfoN32Premul(3, 1, nil), make([]byte, 12), 12, nil)
+	var white = PackARGB32(0xff, 0xff, 0xff, 0xff)
+	for x := 0; x < 3; x++ {
+		dst.SetPixel32(x, 0, white)
+	}
+
+	// opaque red, half transparent blue, and nothing.
+	var image = make([]byte, 12)
+	binary.LittleEndian.PutUint32(image[0:], PackARGB32(0xff, 0xff, 0, 0))
+	binary.LittleEndian.PutUint32(image[4:], PackARGB32(0x80, 0, 0, 0x80))
+	var mask = &Mask{Image: image, Bounds: MakeRect(0, 0, 3, 1), RowBytes: 12, Format: KMaskFormatARGB32}
+
+	blitARGB32MaskD32(dst, mask, MakeRect(0, 0, 3, 1), 0xff)
+	var want = []uint32{PackARGB32(0xff, 0xff, 0, 0), PackARGB32(0xff, 0x7f, 0x7f, 0xff), white}
+	for x := range want {
+		if got := dst.Pixel32(x, 0); got != want[x] {
+			t.Errorf("blitARGB32MaskD32 pixel %v want %#x got %#x", x, want[x], got)
+		}
+	}
+
+	// the paint's alpha fades the mask.
+	dst.SetPixel32(0, 0, white)
+	blitARGB32MaskD32(dst, mask, MakeRect(0, 0, 1, 1), 0x7f)
+	if got := dst.Pixel32(0, 0); GetPackedR32(got) != 0xff || GetPackedG32(got) < 0x7f || GetPackedG32(got) > 0x81 {
+		t.Errorf("blitARGB32MaskD32 with alpha want %#x got %#x", PackARGB32(0xff, 0xff, 0x80, 0x80), got)
+	}
+}
@@ -0,0 +1,98 @@
+package ggk
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// newTestBlitMasks returns a 2x1 mask in each format that covers its left
+// pixel fully and its right pixel not at all, at (1, 0).
+func newTestBlitMasks() []*Mask {
+	var masks []*Mask
+	for _, format := range []MaskFormat{KMaskFormatBW, KMaskFormatA8, KMaskFormat3D, KMaskFormatARGB32, KMaskFormatLCD16} {
+		var mask = NewMask(MakeRect(1, 0, 2, 1), format)
+		switch format {
+		case KMaskFormatBW:
+			mask.Image[0] = 0x80
+		case KMaskFormatARGB32:
+			binary.LittleEndian.PutUint32(mask.Image, PackARGB32(0xff, 0xff, 0xff, 0xff))
+		case KMaskFormatLCD16:
+			binary.LittleEndian.PutUint16(mask.Image, 0xffff)
+		default:
+			mask.Image[0] = 0xff
+		}
+		masks = append(masks, mask)
+	}
+	return masks
+}
+
+func TestBlitMask(t *testing.T) {
+	var paint = NewPaint()
+	paint.SetColor(ColorWithARGB(0xff, 0xff, 0xff, 0xff))
+	var white = PackARGB32(0xff, 0xff, 0xff, 0xff)
+	var black = PackARGB32(0xff, 0, 0, 0)
+
+	for _, mask := range newTestBlitMasks() {
+		var d32 = NewPixmap()
+		d32.Reset(NewImageInfoN32Premul(4, 1, nil), make([]byte, 16), 16, nil)
+		for x := 0; x < 4; x++ {
+			d32.SetPixel32(x, 0, black)
+		}
+		NewARGB32Blitter(d32, paint).BlitMask(mask, MakeRect(0, 0, 4, 1))
+		if d32.Pixel32(0, 0) != black || d32.Pixel32(1, 0) != white || d32.Pixel32(2, 0) != black {
+			t.Errorf("ARGB32Blitter.BlitMask(%v) want black white black got %#x %#x %#x",
+				mask.Format, d32.Pixel32(0, 0), d32.Pixel32(1, 0), d32.Pixel32(2, 0))
+		}
+
+		var a8 = NewPixmap()
+		a8.Reset(NewImageInfoA8(4, 1), make([]byte, 4), 4, nil)
+		NewA8Blitter(a8, paint).BlitMask(mask, MakeRect(0, 0, 4, 1))
+		if a8.Pixel8(0, 0) != 0 || a8.Pixel8(1, 0) != 0xff || a8.Pixel8(2, 0) != 0 {
+			t.Errorf("A8Blitter.BlitMask(%v) want [0 255 0] got %v", mask.Format, a8.PixelBytes())
+		}
+
+		var d16 = NewPixmap()
+		d16.Reset(NewImageInfo(4, 1, KColorTypeRGB565, KAlphaTypeOpaque, nil), make([]byte, 8), 8, nil)
+		BlitterChooseD565(d16, paint, nil).BlitMask(mask, MakeRect(0, 0, 4, 1))
+		if d16.Pixel16(0, 0) != 0 || d16.Pixel16(1, 0) != 0xffff || d16.Pixel16(2, 0) != 0 {
+			t.Errorf("RGB16Blitter.BlitMask(%v) want [0 0xffff 0] got %#x %#x %#x",
+				mask.Format, d16.Pixel16(0, 0), d16.Pixel16(1, 0), d16.Pixel16(2, 0))
+		}
+
+		// nothing is drawn outside the clip.
+		a8.SetPixel8(1, 0, 0)
+		NewA8Blitter(a8, paint).BlitMask(mask, MakeRect(2, 0, 2, 1))
+		if a8.Pixel8(1, 0) != 0 {
+			t.Errorf("A8Blitter.BlitMask(%v) outside the clip want 0 got %v", mask.Format, a8.Pixel8(1, 0))
+		}
+	}
+}
+
+func TestBlitMaskPartialCoverage(t *testing.T) {
+	var paint = NewPaint()
+	paint.SetColor(ColorWithARGB(0x80, 0xff, 0, 0))
+	var mask = NewMask(MakeRect(0, 0, 1, 1), KMaskFormatA8)
+	mask.Image[0] = 0x80
+
+	var a8 = NewPixmap()
+	a8.Reset(NewImageInfoA8(1, 1), []byte{0x80}, 1, nil)
+	NewA8Blitter(a8, paint).BlitMask(mask, MakeRect(0, 0, 1, 1))
+	// a quarter over a half: 0x40 + 0x80 * 0xbf / 0xff.
+	if got := a8.Pixel8(0, 0); got != 0xa0 {
+		t.Errorf("A8Blitter.BlitMask() want 0xa0 got %#x", got)
+	}
+
+	var d16 = NewPixmap()
+	d16.Reset(NewImageInfo(1, 1, KColorTypeRGB565, KAlphaTypeOpaque, nil), make([]byte, 2), 2, nil)
+	NewRGB16Blitter(d16, paint).BlitMask(mask, MakeRect(0, 0, 1, 1))
+	if got := d16.Pixel16(0, 0); GetPackedR16(got) != 8 || GetPackedG16(got) != 0 || GetPackedB16(got) != 0 {
+		t.Errorf("RGB16Blitter.BlitMask() want a quarter red got %#x", got)
+	}
+
+	if got := lcd16ToA8(packLCD16(0xff, 0, 0)); got < 0x54 || got > 0x56 {
+		t.Errorf("lcd16ToA8() of red coverage want a third got %#x", got)
+	}
+	if got := Pixel16ToPixel32(uint16(Pixel32ToPixel16(PackARGB32(0xff, 0xff, 0x80, 0)))); got != PackARGB32(0xff, 0xff, 0x82, 0) {
+		t.Errorf("Pixel16ToPixel32() want %#x got %#x", PackARGB32(0xff, 0xff, 0x82, 0), got)
+	}
+}
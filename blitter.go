@@ -38,7 +38,7 @@ type Blitter interface {
 	width (zero or more) opaque pixels, and one alpha-blended column
 	on the right.
 	The result will always be at least two pixels wide. */
-	BlitAntiRect(x, y, width, height, leftAlpha Alpha, rightAlpha Alpha)
+	BlitAntiRect(x, y, width, height int, leftAlpha, rightAlpha Alpha)
 
 	/** BlitMask
 	Blit a pattern of pixels defined by a rectangle-clipped mask;
@@ -248,17 +248,18 @@ func BlitterChoose(device *Pixmap, matrix *Matrix, origPaint *Paint, drawCoverag
 	}
 
 	if shader == nil {
-		if mode != nil {
-			// xfermodes (and filters) require shaders for our current blitters
-			paint.SetShader(NewShader_Color(paint.Color()))
-			paint.SetAlpha(0xFF)
-		} else if colorFilter != nil {
-			// if no shader && no xfermode, we just apply the colorfilter to
-			// our color and move on.
+		if colorFilter != nil {
+			// if no shader, the colorfilter of our color is just another
+			// color, which we apply up front and move on.
 			paint.SetColor(colorFilter.FilterColor(paint.Color()))
 			paint.SetColorFilter(nil)
 			colorFilter = nil
 		}
+		if mode != nil {
+			// xfermodes require shaders for our current blitters
+			paint.SetShader(NewShader_Color(paint.Color()))
+			paint.SetAlpha(0xFF)
+		}
 	}
 
 	if colorFilter != nil {
@@ -272,20 +273,13 @@ func BlitterChoose(device *Pixmap, matrix *Matrix, origPaint *Paint, drawCoverag
 	 *  We create a SkShader::Context object, and store it on the blitter.
 	 */
 	var shaderContext *ShaderContext = nil
-	if shader == nil {
+	if shader != nil {
 		var rec = NewShaderContextRec(paint, matrix, nil, BlitterPreferredShaderDest(device.Info()))
-		var contextSize = shader.ContextSize(rec)
-		if contextSize != 0 {
-			// Try to create the ShaderContext.
-			shaderContext = shader.CreateContext(rec)
-			if shaderContext == nil {
-				return NewNullBlitter()
-			}
-		} else {
+		// Try to create the ShaderContext.
+		shaderContext = shader.CreateContext(rec)
+		if shaderContext == nil {
 			return NewNullBlitter()
 		}
-	} else {
-		return NewNullBlitter()
 	}
 
 	var blitter Blitter = nil
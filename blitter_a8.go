@@ -16,10 +16,48 @@ func NewA8ShaderBlitter(device *Pixmap, paint *Paint, shaderContext *ShaderConte
 	return nil
 }
 
+/** A8Blitter
+blits the alpha of a paint without a shader into an alpha only device. */
 type A8Blitter struct {
+	BaseBlitter
+	device *Pixmap
+	srcA   uint8
 }
 
 func NewA8Blitter(device *Pixmap, paint *Paint) Blitter {
-	toimpl()
-	return nil
-}
\ No newline at end of file
+	var blitter = &A8Blitter{
+		device: device,
+		srcA:   paint.Alpha(),
+	}
+	blitter.BaseBlitter.Blitter = blitter
+	return blitter
+}
+
+// Blend the paint's alpha, scaled by coverage, over the pixel at (x, y).
+func (blitter *A8Blitter) blend(x, y int, coverage uint8) {
+	var src = MulDiv255Round(blitter.srcA, coverage)
+	var dst = blitter.device.Pixel8(x, y)
+	blitter.device.SetPixel8(x, y, src+MulDiv255Round(dst, 255-src))
+}
+
+func (blitter *A8Blitter) BlitMask(mask *Mask, clip Rect) {
+	if blitter.srcA == 0 {
+		return
+	}
+	if mask.Format != KMaskFormatARGB32 {
+		// LCD16 masks fall back to grayscale.
+		forEachMaskCoverage(mask, clip, blitter.blend)
+		return
+	}
+	var r = mask.Bounds
+	if !r.Intersect(clip) {
+		return
+	}
+	for y := int(r.Top); y < int(r.B()); y++ {
+		for x := int(r.Left); x < int(r.R()); x++ {
+			if alpha := uint8(GetPackedA32(mask.argb32At(x, y))); alpha != 0 {
+				blitter.blend(x, y, alpha)
+			}
+		}
+	}
+}
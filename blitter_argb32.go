@@ -1,61 +1,153 @@
 package ggk
 
 type ARGB32Blitter struct {
+	BaseBlitter
+	device *Pixmap
+	color  Color
 }
 
 func NewARGB32Blitter(device *Pixmap, paint *Paint) Blitter {
-	toimpl()
-	return nil
+	var blitter = &ARGB32Blitter{
+		device: device,
+		color:  paint.Color(),
+	}
+	blitter.BaseBlitter.Blitter = blitter
+	return blitter
 }
 
+func (blitter *ARGB32Blitter) BlitMask(mask *Mask, clip Rect) {
+	switch mask.Format {
+	case KMaskFormatLCD16:
+		blitLCD16MaskD32(blitter.device, mask, clip, blitter.color)
+	case KMaskFormatARGB32:
+		blitARGB32MaskD32(blitter.device, mask, clip, blitter.color.Alpha())
+	case KMaskFormatBW, KMaskFormatA8, KMaskFormat3D:
+		var src = premulPixel32(blitter.color)
+		forEachMaskCoverage(mask, clip, func(x, y int, coverage uint8) {
+			blitter.device.SetPixel32(x, y, blendARGB32(src, blitter.device.Pixel32(x, y), coverage))
+		})
+	}
+}
+
+func (blitter *ARGB32Blitter) BlitH(x, y, width int) {
+	blitter.BlitRect(x, y, width, 1)
+}
+
+func (blitter *ARGB32Blitter) BlitV(x, y, height int, alpha Alpha) {
+	var src = premulPixel32(blitter.color)
+	for ; height > 0; height, y = height-1, y+1 {
+		blitter.device.SetPixel32(x, y, blendARGB32(src, blitter.device.Pixel32(x, y), uint8(alpha)))
+	}
+}
+
+func (blitter *ARGB32Blitter) BlitRect(x, y, width, height int) {
+	var src = premulPixel32(blitter.color)
+	for j := y; j < y+height; j++ {
+		for i := x; i < x+width; i++ {
+			blitter.device.SetPixel32(i, j, PMSrcOver(src, blitter.device.Pixel32(i, j)))
+		}
+	}
+}
+
+func (blitter *ARGB32Blitter) BlitAntiH(x, y int, antialias []Alpha, runs []int16) {
+	var src = premulPixel32(blitter.color)
+	for runs[0] > 0 {
+		var count = int(runs[0])
+		if coverage := uint8(antialias[0]); coverage != 0 {
+			for i := x; i < x+count; i++ {
+				blitter.device.SetPixel32(i, y, blendARGB32(src, blitter.device.Pixel32(i, y), coverage))
+			}
+		}
+		x += count
+		runs, antialias = runs[count:], antialias[count:]
+	}
+}
+
+/** ARGB32ShaderBlitter
+blits the colors of a shader over an ARGB32 device, scaled by coverage. */
 type ARGB32ShaderBlitter struct {
+	BaseBlitter
+	device        *Pixmap
+	shaderContext *ShaderContext
+	span          []PremulColor
 }
 
 func NewARGB32ShaderBlitter(device *Pixmap, paint *Paint, shaderContext *ShaderContext) Blitter {
-	toimpl()
-	return nil
-}
-
-func (blitter *ARGB32ShaderBlitter)BlitRect(x, y, width, height int) {
-	// assert(x >= 0 && y >= 0 && x + width <= blitter.device.Width() && y + height <= blitter.device.Height())
-	// var (
-	// 	device []uint32 = blitter.device.writable_addr32
-	// 	deviceRB int    = blitter.device.RowBytes()
-	// 	shaderContext *ShaderContext = blitter.shaderContext
-	// 	span *PMColor = blitter.buffer;
-	// )
-
-	// if blitter.constInY {
-	// 	if blitter.shadeDirectlyIntoDevice {
-	// 		// shade the first row directly into the device.
-	// 		shaderContext.shadeSpan(x, y, device, width)
-	// 		span = device
-	// 		for height--; height > 0; height-- {
-	// 			device = device[deviceRB:]
-	// 			memcpy(device, span, width << 2)
-	// 		}
-	// 	} else {
-	// 		shaderContext.shadeSpan(x, y, span, width)
-	// 		var xfer = blitter.xfermode
-	// 		if xfer != nil {
-				
-	// 		}
-	// 	}
-	// }
+	var blitter = &ARGB32ShaderBlitter{
+		device:        device,
+		shaderContext: shaderContext,
+		span:          make([]PremulColor, int(device.Width())),
+	}
+	blitter.BaseBlitter.Blitter = blitter
+	return blitter
+}
+
+func (blitter *ARGB32ShaderBlitter) GetShaderContext() *ShaderContext {
+	return blitter.shaderContext
+}
+
+// Shade the width pixels from (x, y) and blend them, scaled by coverage,
+// over the device.
+func (blitter *ARGB32ShaderBlitter) blitSpan(x, y, width int, coverage uint8) {
+	if width > len(blitter.span) {
+		blitter.span = make([]PremulColor, width)
+	}
+	var span = blitter.span[:width]
+	blitter.shaderContext.ShadeSpan(x, y, span, width)
+	for i, src := range span {
+		blitter.device.SetPixel32(x+i, y, blendARGB32(uint32(src), blitter.device.Pixel32(x+i, y), coverage))
+	}
+}
+
+func (blitter *ARGB32ShaderBlitter) BlitH(x, y, width int) {
+	blitter.blitSpan(x, y, width, 0xff)
+}
+
+func (blitter *ARGB32ShaderBlitter) BlitV(x, y, height int, alpha Alpha) {
+	for ; height > 0; height, y = height-1, y+1 {
+		blitter.blitSpan(x, y, 1, uint8(alpha))
+	}
+}
+
+func (blitter *ARGB32ShaderBlitter) BlitRect(x, y, width, height int) {
+	for j := y; j < y+height; j++ {
+		blitter.blitSpan(x, j, width, 0xff)
+	}
+}
+
+func (blitter *ARGB32ShaderBlitter) BlitAntiH(x, y int, antialias []Alpha, runs []int16) {
+	for runs[0] > 0 {
+		var count = int(runs[0])
+		if coverage := uint8(antialias[0]); coverage != 0 {
+			blitter.blitSpan(x, y, count, coverage)
+		}
+		x += count
+		runs, antialias = runs[count:], antialias[count:]
+	}
+}
+
+func (blitter *ARGB32ShaderBlitter) BlitMask(mask *Mask, clip Rect) {
+	forEachMaskCoverage(mask, clip, func(x, y int, coverage uint8) {
+		blitter.blitSpan(x, y, 1, coverage)
+	})
 }
 
 type ARGB32BlackBlitter struct {
+	ARGB32Blitter
 }
 
 func NewARGB32BlackBlitter(device *Pixmap, paint *Paint) Blitter {
-	toimpl()
-	return nil
+	var blitter = &ARGB32BlackBlitter{ARGB32Blitter{device: device, color: paint.Color()}}
+	blitter.BaseBlitter.Blitter = blitter
+	return blitter
 }
 
 type ARGB32OpaqueBlitter struct {
+	ARGB32Blitter
 }
 
 func NewARGB32OpaqueBlitter(device *Pixmap, paint *Paint) Blitter {
-	toimpl()
-	return nil
+	var blitter = &ARGB32OpaqueBlitter{ARGB32Blitter{device: device, color: paint.Color()}}
+	blitter.BaseBlitter.Blitter = blitter
+	return blitter
 }
\ No newline at end of file
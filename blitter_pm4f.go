@@ -1,6 +1,9 @@
 package ggk
 
 func BlitterARGB32Create(device *Pixmap, paint *Paint, shaderContext *ShaderContext) Blitter {
+	if shaderContext != nil {
+		return NewARGB32ShaderBlitter(device, paint, shaderContext)
+	}
 	toimpl()
 	return nil
 }
@@ -1,6 +1,76 @@
 package ggk
 
 func BlitterChooseD565(pixmap *Pixmap, paint *Paint, shaderContext *ShaderContext) Blitter {
-	toimpl()
-	return nil
-}
\ No newline at end of file
+	if shaderContext != nil {
+		toimpl()
+		return nil
+	}
+	return NewRGB16Blitter(pixmap, paint)
+}
+
+/** RGB16Blitter
+blits the color of a paint without a shader into an RGB565 device. The
+device is opaque, so blending happens on its pixels expanded to 32 bits. */
+type RGB16Blitter struct {
+	BaseBlitter
+	device *Pixmap
+	color  Color
+}
+
+func NewRGB16Blitter(device *Pixmap, paint *Paint) Blitter {
+	var blitter = &RGB16Blitter{
+		device: device,
+		color:  paint.Color(),
+	}
+	blitter.BaseBlitter.Blitter = blitter
+	return blitter
+}
+
+func (blitter *RGB16Blitter) BlitMask(mask *Mask, clip Rect) {
+	var device = blitter.device
+	var blend = func(x, y int, pixel32 func(dst uint32) uint32) {
+		var dst = Pixel16ToPixel32(device.Pixel16(x, y))
+		device.SetPixel16(x, y, uint16(Pixel32ToPixel16(pixel32(dst))))
+	}
+
+	var r = mask.Bounds
+	switch mask.Format {
+	case KMaskFormatLCD16:
+		if !r.Intersect(clip) {
+			return
+		}
+		var srcA, srcR, srcG, srcB = blitter.color.ARGB()
+		var scale = int(Alpha255To256(uint32(srcA)))
+		for y := int(r.Top); y < int(r.B()); y++ {
+			for x := int(r.Left); x < int(r.R()); x++ {
+				var lcd = mask.lcd16At(x, y)
+				blend(x, y, func(dst uint32) uint32 {
+					return blendLCD16(scale, int(srcR), int(srcG), int(srcB), dst, lcd)
+				})
+			}
+		}
+	case KMaskFormatARGB32:
+		if !r.Intersect(clip) {
+			return
+		}
+		var alpha = blitter.color.Alpha()
+		for y := int(r.Top); y < int(r.B()); y++ {
+			for x := int(r.Left); x < int(r.R()); x++ {
+				var src = mask.argb32At(x, y)
+				if src == 0 {
+					continue
+				}
+				blend(x, y, func(dst uint32) uint32 {
+					return blendARGB32(src, dst, alpha)
+				})
+			}
+		}
+	default:
+		var src = premulPixel32(blitter.color)
+		forEachMaskCoverage(mask, clip, func(x, y int, coverage uint8) {
+			blend(x, y, func(dst uint32) uint32 {
+				return blendARGB32(src, dst, coverage)
+			})
+		})
+	}
+}
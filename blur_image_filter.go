@@ -0,0 +1,121 @@
+package ggk
+
+import "math"
+
+/** tBlurImageFilter
+is the ImageFilterImpl that blurs each channel of its input with a
+gaussian of sigma, in local coordinates. */
+type tBlurImageFilter struct {
+	sigma Point
+}
+
+/** NewBlurImageFilter
+Return a filter that blurs input, or the source if input is nil, with a
+gaussian of sigmaX and sigmaY. Returns input if there is nothing to blur,
+or nil if a sigma is negative. */
+func NewBlurImageFilter(sigmaX, sigmaY Scalar, input *ImageFilter, cropRect *ImageFilterCropRect) *ImageFilter {
+	if sigmaX < 0 || sigmaY < 0 {
+		return nil
+	}
+	if sigmaX == 0 && sigmaY == 0 && cropRect == nil {
+		return input
+	}
+	return NewImageFilter(&tBlurImageFilter{Point{sigmaX, sigmaY}}, []*ImageFilter{input}, cropRect)
+}
+
+// Return sigma, in local coordinates, scaled to device pixels by ctm.
+func deviceBlurSigma(sigma Point, ctm *Matrix) Point {
+	var v = []Point{sigma}
+	ctm.MapVectors(v, v)
+	return Point{ScalarAbs(v[0].X), ScalarAbs(v[0].Y)}
+}
+
+// Return how far a blur of sigma spreads an image on each axis.
+func blurOutset(sigma Point) Point {
+	return Point{Scalar(blurMargin(sigma.X)), Scalar(blurMargin(sigma.Y))}
+}
+
+func (impl *tBlurImageFilter) OnFilterImage(filter *ImageFilter, src *Bitmap, ctx *ImageFilterContext) (*Bitmap, Point, bool) {
+	var input, inputOffset, ok = filter.filterInput(0, src, ctx)
+	if !ok {
+		return nil, PointZero, false
+	}
+	var sigma = deviceBlurSigma(impl.sigma, ctx.CTM)
+	var outset = blurOutset(sigma)
+	var srcBounds = filterBitmapBounds(input, inputOffset)
+	srcBounds.Outset(outset.X, outset.Y)
+	var dstBounds Rect
+	if dstBounds, ok = filter.applyCropRect(ctx, srcBounds); !ok {
+		return nil, PointZero, false
+	}
+
+	// the pixels next to the result reach into it.
+	var workBounds = dstBounds
+	workBounds.Outset(outset.X, outset.Y)
+	var work = copyFilterBitmap(input, inputOffset, workBounds)
+	blurFilterBitmap(work, sigma)
+	var dst = copyFilterBitmap(work, Point{workBounds.Left, workBounds.Top}, dstBounds)
+	return dst, Point{dstBounds.Left, dstBounds.Top}, true
+}
+
+func (impl *tBlurImageFilter) OnFilterBounds(filter *ImageFilter, src Rect, ctm *Matrix, direction ImageFilterMapDirection) Rect {
+	var outset = blurOutset(deviceBlurSigma(impl.sigma, ctm))
+	return filter.filterBoundsWithNode(src, ctm, direction, func(r Rect) Rect {
+		r.Outset(outset.X, outset.Y)
+		return r
+	})
+}
+
+func (impl *tBlurImageFilter) OnComputeFastBounds(filter *ImageFilter, src Rect) Rect {
+	var bounds = filter.inputsFastBounds(src)
+	bounds.Outset(3*impl.sigma.X, 3*impl.sigma.Y)
+	return bounds
+}
+
+func (impl *tBlurImageFilter) OnAffectsTransparentBlack() bool {
+	return false
+}
+
+func (impl *tBlurImageFilter) OnAsAColorFilter(filter *ImageFilter) (*ColorFilter, bool) {
+	return nil, false
+}
+
+/** blurFilterBitmap
+Blur each channel of the premultiplied pixels of bmp in place, with a
+gaussian of sigma.X across and sigma.Y down, treating the pixels outside
+of it as transparent. */
+func blurFilterBitmap(bmp *Bitmap, sigma Point) {
+	var width, height = int(bmp.Width()), int(bmp.Height())
+	var pixels, rowBytes = bmp.PixelBytes(), bmp.RowBytes()
+	var n = int(math.Max(float64(width), float64(height)))
+	var line, out = make([]byte, n), make([]byte, n)
+	if sigma.X > 0 {
+		var blur1D = newBlur1D(sigma.X, false)
+		for y := 0; y < height; y++ {
+			var row = pixels[y*rowBytes:]
+			for c := 0; c < 4; c++ {
+				for x := 0; x < width; x++ {
+					line[x] = row[x*4+c]
+				}
+				blur1D(out[:width], line[:width])
+				for x := 0; x < width; x++ {
+					row[x*4+c] = out[x]
+				}
+			}
+		}
+	}
+	if sigma.Y > 0 {
+		var blur1D = newBlur1D(sigma.Y, false)
+		for x := 0; x < width; x++ {
+			for c := 0; c < 4; c++ {
+				for y := 0; y < height; y++ {
+					line[y] = pixels[y*rowBytes+x*4+c]
+				}
+				blur1D(out[:height], line[:height])
+				for y := 0; y < height; y++ {
+					pixels[y*rowBytes+x*4+c] = out[y]
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package ggk
+
+import "math"
+
+/** BlurStyle
+tells which parts of a shape a blur mask filter blurs. */
+type BlurStyle int
+
+const (
+	KBlurStyleNormal BlurStyle = iota // fuzzy inside and outside
+	KBlurStyleSolid                   // solid inside, fuzzy outside
+	KBlurStyleOuter                   // nothing inside, fuzzy outside
+	KBlurStyleInner                   // fuzzy inside, nothing outside
+)
+
+// The factor of the legacy conversion between a blur radius and a sigma.
+const kBlurSigmaScale = 0.57735
+
+// Sigmas at least this large are blurred with three box blurs, which is
+// close enough to a gaussian and costs the same for any sigma.
+const kBlurMinSigmaForBoxes = 2
+
+// BlurRadiusToSigma converts a blur radius, as used by the legacy blur
+// APIs, to a sigma.
+func BlurRadiusToSigma(radius Scalar) Scalar {
+	if radius <= 0 {
+		return 0
+	}
+	return kBlurSigmaScale*radius + 0.5
+}
+
+// BlurSigmaToRadius converts a sigma back to a legacy blur radius.
+func BlurSigmaToRadius(sigma Scalar) Scalar {
+	if sigma <= 0.5 {
+		return 0
+	}
+	return (sigma - 0.5) / kBlurSigmaScale
+}
+
+// Return how many pixels a blur of sigma spreads a mask by on each side.
+func blurMargin(sigma Scalar) int {
+	return int(math.Ceil(float64(3 * sigma)))
+}
+
+/** blurMask
+Blur src, an A8 or BW mask, into dst, an A8 mask as big as src plus the
+blur margin on each side, or just as big as src for the inner style. The
+blur is gaussian with sigma, approximated by three box blurs for large
+sigmas unless highQuality is true. An empty src only sets the bounds of
+dst. Returns the margin, or false if src can't be blurred. */
+func blurMask(dst, src *Mask, sigma Scalar, style BlurStyle, highQuality bool) (margin int, ok bool) {
+	if src.Format != KMaskFormatA8 && src.Format != KMaskFormatBW {
+		return 0, false
+	}
+	var width, height = int(src.Bounds.Width), int(src.Bounds.Height)
+	if sigma > 0 {
+		margin = blurMargin(sigma)
+	}
+	if width <= 0 || height <= 0 {
+		*dst = Mask{Bounds: src.Bounds, Format: KMaskFormatA8}
+		if style != KBlurStyleInner {
+			dst.Bounds.Outset(Scalar(margin), Scalar(margin))
+		}
+		return margin, true
+	}
+
+	// blur a copy of the mask padded by the margin, first rows then columns.
+	var alpha = src.ToA8().Image
+	var w, h = width + 2*margin, height + 2*margin
+	var blurred = make([]byte, w*h)
+	for y := 0; y < height; y++ {
+		copy(blurred[(y+margin)*w+margin:], alpha[y*width:(y+1)*width])
+	}
+	if margin > 0 {
+		var blur1D = newBlur1D(sigma, highQuality)
+		var n = w
+		if h > n {
+			n = h
+		}
+		var line, out = make([]byte, n), make([]byte, n)
+		for y := 0; y < h; y++ {
+			blur1D(out[:w], blurred[y*w:(y+1)*w])
+			copy(blurred[y*w:], out[:w])
+		}
+		for x := 0; x < w; x++ {
+			for y := 0; y < h; y++ {
+				line[y] = blurred[y*w+x]
+			}
+			blur1D(out[:h], line[:h])
+			for y := 0; y < h; y++ {
+				blurred[y*w+x] = out[y]
+			}
+		}
+	}
+
+	// combine the blur with the original coverage for the style.
+	var srcAt = func(x, y int) uint8 {
+		x, y = x-margin, y-margin
+		if x < 0 || y < 0 || x >= width || y >= height {
+			return 0
+		}
+		return alpha[y*width+x]
+	}
+	switch style {
+	case KBlurStyleSolid:
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				var s = srcAt(x, y)
+				var d = &blurred[y*w+x]
+				*d = MulDiv255Round(*d, 255-s) + s
+			}
+		}
+	case KBlurStyleOuter:
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				var d = &blurred[y*w+x]
+				*d = MulDiv255Round(*d, 255-srcAt(x, y))
+			}
+		}
+	case KBlurStyleInner:
+		var inner = make([]byte, width*height)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				inner[y*width+x] = MulDiv255Round(blurred[(y+margin)*w+x+margin], alpha[y*width+x])
+			}
+		}
+		*dst = Mask{Image: inner, Bounds: src.Bounds, RowBytes: width, Format: KMaskFormatA8}
+		return margin, true
+	}
+
+	*dst = Mask{Image: blurred, Bounds: src.Bounds, RowBytes: w, Format: KMaskFormatA8}
+	dst.Bounds.Outset(Scalar(margin), Scalar(margin))
+	return margin, true
+}
+
+// Return a function that blurs a line of alpha with a gaussian of sigma,
+// approximated by box blurs for large sigmas unless highQuality is true.
+func newBlur1D(sigma Scalar, highQuality bool) func(dst, src []byte) {
+	if sigma >= kBlurMinSigmaForBoxes && !highQuality {
+		return boxBlur1D(sigma)
+	}
+	return gaussianBlur1D(sigma)
+}
+
+/** gaussianBlur1D
+Return a function that blurs a line of alpha with a gaussian of sigma,
+treating the alpha past the ends of the line as 0. The kernel reaches 3
+sigma to each side, with weights in 16.16 fixed point. */
+func gaussianBlur1D(sigma Scalar) func(dst, src []byte) {
+	var radius = blurMargin(sigma)
+	var weights = make([]float64, 2*radius+1)
+	var sum float64
+	for i := range weights {
+		var x = float64(i - radius)
+		weights[i] = math.Exp(-x * x / (2 * float64(sigma) * float64(sigma)))
+		sum += weights[i]
+	}
+	var kernel = make([]uint32, len(weights))
+	for i := range weights {
+		kernel[i] = uint32(weights[i]/sum*(1<<16) + 0.5)
+	}
+	return func(dst, src []byte) {
+		for i := range dst {
+			var acc uint32 = 1 << 15
+			var lo, hi = i - radius, i + radius
+			if lo < 0 {
+				lo = 0
+			}
+			if hi >= len(src) {
+				hi = len(src) - 1
+			}
+			for j := lo; j <= hi; j++ {
+				acc += uint32(src[j]) * kernel[j-i+radius]
+			}
+			if acc >= 256<<16 {
+				acc = 255 << 16
+			}
+			dst[i] = uint8(acc >> 16)
+		}
+	}
+}
+
+/** boxBlur1D
+Return a function that blurs a line of alpha with three box blurs, which
+together approach a gaussian of sigma. The box size d is that of the SVG
+filter effects spec: three boxes of d centered on the pixel when d is odd,
+otherwise two boxes of d centered half a pixel left and right of it and
+one of d+1 centered on it. */
+func boxBlur1D(sigma Scalar) func(dst, src []byte) {
+	var d = int(math.Floor(float64(sigma)*3*math.Sqrt(2*math.Pi)/4 + 0.5))
+	type tBox struct{ lo, hi int }
+	var boxes [3]tBox
+	if d&1 == 1 {
+		var r = d / 2
+		boxes = [3]tBox{{r, r}, {r, r}, {r, r}}
+	} else {
+		var r = d / 2
+		boxes = [3]tBox{{r, r - 1}, {r - 1, r}, {r, r}}
+	}
+	return func(dst, src []byte) {
+		var tmp = make([]byte, len(src))
+		boxBlurLine(tmp, src, boxes[0].lo, boxes[0].hi)
+		boxBlurLine(dst, tmp, boxes[1].lo, boxes[1].hi)
+		copy(tmp, dst)
+		boxBlurLine(dst, tmp, boxes[2].lo, boxes[2].hi)
+	}
+}
+
+// Set each alpha of dst to the average of src from lo before to hi after
+// it, with a running sum so the cost doesn't depend on the box size.
+func boxBlurLine(dst, src []byte, lo, hi int) {
+	var scale = uint64((1 << 24) / (lo + hi + 1))
+	var sum uint64
+	for j := 0; j < hi && j < len(src); j++ {
+		sum += uint64(src[j])
+	}
+	for i := range dst {
+		if j := i + hi; j < len(src) {
+			sum += uint64(src[j])
+		}
+		dst[i] = uint8((sum*scale + 1<<23) >> 24)
+		if j := i - lo; j >= 0 {
+			sum -= uint64(src[j])
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package ggk
+
+import "math"
+
+type BlurMaskFilterFlags uint32
+
+const (
+	// the sigma is in device pixels rather than scaled by the matrix.
+	KBlurMaskFilterFlagIgnoreTransform BlurMaskFilterFlags = 1 << iota
+	// blur with a true gaussian even when a box blur would do.
+	KBlurMaskFilterFlagHighQuality
+	KBlurMaskFilterFlagNone BlurMaskFilterFlags = 0
+)
+
+/** tBlurMaskFilter
+is the MaskFilterImpl that blurs masks with a gaussian of sigma, combined
+with the unblurred mask according to style. */
+type tBlurMaskFilter struct {
+	sigma Scalar
+	style BlurStyle
+	flags BlurMaskFilterFlags
+}
+
+/** NewBlurMaskFilter
+Return a mask filter that blurs with a gaussian of sigma, which is in the
+coordinates of what is drawn unless flags ignore the transform. Returns nil
+if sigma is not positive or style is unknown. */
+func NewBlurMaskFilter(style BlurStyle, sigma Scalar, flags BlurMaskFilterFlags) *MaskFilter {
+	if !(sigma > 0) || style < KBlurStyleNormal || style > KBlurStyleInner {
+		return nil
+	}
+	return NewMaskFilter(&tBlurMaskFilter{sigma: sigma, style: style, flags: flags})
+}
+
+// Return the sigma in device pixels for masks drawn through matrix.
+func (impl *tBlurMaskFilter) deviceSigma(matrix *Matrix) Scalar {
+	if impl.flags&KBlurMaskFilterFlagIgnoreTransform != 0 || matrix == nil {
+		return impl.sigma
+	}
+	// the geometric mean of how the matrix scales the two axes.
+	var v = []Point{{impl.sigma, 0}, {0, impl.sigma}}
+	matrix.MapVectors(v, v)
+	var x = math.Hypot(float64(v[0].X), float64(v[0].Y))
+	var y = math.Hypot(float64(v[1].X), float64(v[1].Y))
+	return Scalar(math.Sqrt(x * y))
+}
+
+func (impl *tBlurMaskFilter) OnGetFormat() MaskFormat {
+	return KMaskFormatA8
+}
+
+func (impl *tBlurMaskFilter) OnFilterMask(dst, src *Mask, matrix *Matrix, margin *Point) bool {
+	var highQuality = impl.flags&KBlurMaskFilterFlagHighQuality != 0
+	var blurred Mask
+	var pad, ok = blurMask(&blurred, src, impl.deviceSigma(matrix), impl.style, highQuality)
+	if !ok {
+		return false
+	}
+	*dst = blurred
+	if margin != nil {
+		*margin = Point{Scalar(pad), Scalar(pad)}
+	}
+	return true
+}
+
+func (impl *tBlurMaskFilter) OnComputeFastBounds(src Rect) Rect {
+	var pad = 3 * impl.sigma
+	src.Outset(pad, pad)
+	return src
+}
+
+func (impl *tBlurMaskFilter) OnAsABlur(rec *MaskFilterBlurRec) bool {
+	if impl.flags&KBlurMaskFilterFlagIgnoreTransform != 0 {
+		return false
+	}
+	if rec != nil {
+		*rec = MaskFilterBlurRec{Sigma: impl.sigma, Style: impl.style}
+	}
+	return true
+}
@@ -0,0 +1,165 @@
+package ggk
+
+import "testing"
+
+// newTestSquareMask returns an A8 mask of a size by size square fully
+// covered, at (10, 20).
+func newTestSquareMask(size int) *Mask {
+	var image = make([]byte, size*size)
+	for i := range image {
+		image[i] = 0xff
+	}
+	return &Mask{
+		Image:    image,
+		Bounds:   MakeRect(10, 20, Scalar(size), Scalar(size)),
+		RowBytes: size,
+		Format:   KMaskFormatA8,
+	}
+}
+
+func maskAlphaAt(mask *Mask, x, y int) uint8 {
+	x, y = x-int(mask.Bounds.Left), y-int(mask.Bounds.Top)
+	if x < 0 || y < 0 || x >= int(mask.Bounds.Width) || y >= int(mask.Bounds.Height) {
+		return 0
+	}
+	return mask.Image[y*mask.RowBytes+x]
+}
+
+func maskAlphaSum(mask *Mask) int {
+	var sum = 0
+	for y := 0; y < int(mask.Bounds.Height); y++ {
+		for x := 0; x < int(mask.Bounds.Width); x++ {
+			sum += int(mask.Image[y*mask.RowBytes+x])
+		}
+	}
+	return sum
+}
+
+func TestBlurMaskStyles(t *testing.T) {
+	var tests = []struct {
+		style  BlurStyle
+		bounds Rect
+		center func(a uint8) bool // of the square.
+		corner func(a uint8) bool // just outside the square.
+	}{
+		{KBlurStyleNormal, MakeRect(4, 14, 20, 20), func(a uint8) bool { return a > 128 && a < 255 }, func(a uint8) bool { return a > 0 }},
+		{KBlurStyleSolid, MakeRect(4, 14, 20, 20), func(a uint8) bool { return a == 255 }, func(a uint8) bool { return a > 0 }},
+		{KBlurStyleOuter, MakeRect(4, 14, 20, 20), func(a uint8) bool { return a == 0 }, func(a uint8) bool { return a > 0 }},
+		{KBlurStyleInner, MakeRect(10, 20, 8, 8), func(a uint8) bool { return a > 128 && a < 255 }, func(a uint8) bool { return a == 0 }},
+	}
+	for _, test := range tests {
+		var dst Mask
+		var margin, ok = blurMask(&dst, newTestSquareMask(8), 2, test.style, true)
+		if !ok || margin != 6 {
+			t.Errorf("blurMask(%v) want margin 6 got %v, %v", test.style, margin, ok)
+			continue
+		}
+		if dst.Bounds != test.bounds || dst.Format != KMaskFormatA8 {
+			t.Errorf("blurMask(%v) want bounds %v got %v", test.style, test.bounds, dst.Bounds)
+		}
+		if a := maskAlphaAt(&dst, 14, 24); !test.center(a) {
+			t.Errorf("blurMask(%v) unexpected center alpha %v", test.style, a)
+		}
+		if a := maskAlphaAt(&dst, 9, 19); !test.corner(a) {
+			t.Errorf("blurMask(%v) unexpected corner alpha %v", test.style, a)
+		}
+	}
+
+	// a normal blur spreads the coverage without losing it.
+	var dst Mask
+	blurMask(&dst, newTestSquareMask(8), 2, KBlurStyleNormal, true)
+	if sum, want := maskAlphaSum(&dst), 64*255; sum < want*98/100 || sum > want*102/100 {
+		t.Errorf("blurMask() want total coverage near %v got %v", want, sum)
+	}
+
+	// an empty mask only tells the bounds.
+	if margin, ok := blurMask(&dst, &Mask{Format: KMaskFormatA8}, 1.5, KBlurStyleNormal, false); !ok || margin != 5 ||
+		dst.Image != nil || dst.Bounds != MakeRect(-5, -5, 10, 10) {
+		t.Errorf("blurMask() of an empty mask want margin 5 got %v %v, %v", margin, dst.Bounds, ok)
+	}
+	if _, ok := blurMask(&dst, &Mask{Format: KMaskFormatARGB32}, 1, KBlurStyleNormal, false); ok {
+		t.Errorf("blurMask() of an ARGB32 mask want failure")
+	}
+}
+
+func TestBlurMaskBoxApproximation(t *testing.T) {
+	for _, sigma := range []Scalar{2, 3, 5.5} {
+		var gaussian, box Mask
+		blurMask(&gaussian, newTestSquareMask(12), sigma, KBlurStyleNormal, true)
+		blurMask(&box, newTestSquareMask(12), sigma, KBlurStyleNormal, false)
+		if gaussian.Bounds != box.Bounds {
+			t.Errorf("sigma %v box bounds %v want %v", sigma, box.Bounds, gaussian.Bounds)
+			continue
+		}
+		var worst = 0
+		for i := range gaussian.Image {
+			var d = int(gaussian.Image[i]) - int(box.Image[i])
+			if d < 0 {
+				d = -d
+			}
+			if d > worst {
+				worst = d
+			}
+		}
+		if worst > 16 {
+			t.Errorf("sigma %v box blur differs from gaussian by %v", sigma, worst)
+		}
+	}
+
+	// a BW mask blurs like the A8 mask of the same coverage.
+	var bw = &Mask{Image: make([]byte, 16), Bounds: MakeRect(10, 20, 12, 8), RowBytes: 2, Format: KMaskFormatBW}
+	var a8 = &Mask{Image: make([]byte, 96), Bounds: bw.Bounds, RowBytes: 12, Format: KMaskFormatA8}
+	for y := 0; y < 8; y++ {
+		bw.Image[y*2], bw.Image[y*2+1] = 0x0f, 0xf0
+		for x := 4; x < 12; x++ {
+			a8.Image[y*12+x] = 0xff
+		}
+	}
+	var fromBW, fromA8 Mask
+	blurMask(&fromBW, bw, 3, KBlurStyleNormal, false)
+	blurMask(&fromA8, a8, 3, KBlurStyleNormal, false)
+	if string(fromBW.Image) != string(fromA8.Image) {
+		t.Errorf("blurMask() of a BW mask differs from the A8 mask")
+	}
+}
+
+func TestBlurMaskFilter(t *testing.T) {
+	if NewBlurMaskFilter(KBlurStyleNormal, 0, KBlurMaskFilterFlagNone) != nil ||
+		NewBlurMaskFilter(BlurStyle(9), 1, KBlurMaskFilterFlagNone) != nil {
+		t.Errorf("NewBlurMaskFilter() of a bad sigma or style want nil")
+	}
+
+	var scale = NewMatrix()
+	scale.SetScale(2, 2)
+	var tests = []struct {
+		flags  BlurMaskFilterFlags
+		matrix *Matrix
+		margin Scalar
+		isBlur bool
+	}{
+		{KBlurMaskFilterFlagNone, nil, 5, true},
+		{KBlurMaskFilterFlagNone, scale, 9, true},
+		{KBlurMaskFilterFlagHighQuality, scale, 9, true},
+		{KBlurMaskFilterFlagIgnoreTransform, scale, 5, false},
+	}
+	for _, test := range tests {
+		var filter = NewBlurMaskFilter(KBlurStyleOuter, 1.5, test.flags)
+		if filter.Format() != KMaskFormatA8 {
+			t.Errorf("flags %v Format() want A8 got %v", test.flags, filter.Format())
+		}
+		var dst Mask
+		var margin Point
+		if !filter.FilterMask(&dst, newTestSquareMask(4), test.matrix, &margin) || margin != (Point{test.margin, test.margin}) {
+			t.Errorf("flags %v FilterMask() want margin %v got %v", test.flags, test.margin, margin)
+		}
+		var rec MaskFilterBlurRec
+		if filter.AsABlur(&rec) != test.isBlur || (test.isBlur && rec != (MaskFilterBlurRec{1.5, KBlurStyleOuter})) {
+			t.Errorf("flags %v AsABlur() want %v got %v", test.flags, test.isBlur, rec)
+		}
+	}
+
+	var filter = NewBlurMaskFilter(KBlurStyleNormal, 2, KBlurMaskFilterFlagNone)
+	if got := filter.ComputeFastBounds(MakeRect(0, 0, 10, 10)); got != MakeRect(-6, -6, 22, 22) {
+		t.Errorf("ComputeFastBounds() want %v got %v", MakeRect(-6, -6, 22, 22), got)
+	}
+}
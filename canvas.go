@@ -32,7 +32,7 @@ type CanvasImpl interface {
 	matrix and clip.
 	@param filter the new filter (or NULL)
 	@return the new filter */
-	SetDrawFilter(filter *DrawFilter)
+	SetDrawFilter(filter DrawFilter)
 
 	/** IsClipEmpty
 	Return true if the current clip is empty (i.e. nothing will draw).
@@ -49,7 +49,7 @@ type CanvasImpl interface {
 	OnGetProps() (*SurfaceProps, bool)
 
 	WillSave()
-	SaveLayerStrategy() CanvasSaveLayerStrategy
+	SaveLayerStrategy(rec *CanvasSaveLayerRec) CanvasSaveLayerStrategy
 	WillRestore()
 	DidRestore()
 	DidConcat(matrix *Matrix)
@@ -65,8 +65,8 @@ type CanvasImpl interface {
 	OnDrawTextOnPath(text string, path *Path, matrix *Matrix, paint *Paint)
 	OnDrawTextRSXform(text string, xform []RSXform, cullRect *Rect, paint *Paint)
 	OnDrawTextBlob(blob *TextBlob, x, y Scalar, paint *Paint)
-	OnDrawPatch(cubics [12]Point, colors [4]Color, texCoords [4]Point, xmode *Xfermode, paint *Paint)
-	OnDrawDrawable(drawable *Drawable, matrixe *Matrix)
+	OnDrawPatch(cubics [12]Point, colors *[4]Color, texCoords *[4]Point, xmode *Xfermode, paint *Paint)
+	OnDrawDrawable(drawable *Drawable, matrix *Matrix)
 	OnDrawPaint(paint *Paint)
 	OnDrawRect(rect Rect, paint *Paint)
 	OnDrawOval(oval Rect, paint *Paint)
@@ -138,6 +138,8 @@ type Canvas struct {
 
 	isScaleTranslate bool
 	deviceClipBounds Rect
+
+	lights *Lights
 }
 
 /**
@@ -195,8 +197,16 @@ Construct a canvas with the specified bitmap to draw into.
 				structure are copied to the canvas.
 @param props    New canvas surface properties. */
 func NewCanvasBitmapSurfaceProps(bmp *Bitmap, surfaceProps *SurfaceProps) *Canvas {
-	toimpl()
-	return &Canvas{}
+	var canvas = new(Canvas)
+	canvas.Impl = canvas
+	canvas.surfaceProps = surfaceProps
+	canvas.mcStack = list.New()
+	canvas.clipStack = NewClipStack()
+
+	var device = NewBitmapDevice(bmp, canvas.surfaceProps)
+	canvas.init(device.BaseDevice, KCanvasInitFlagDefault)
+
+	return canvas
 }
 
 func (canvas *Canvas) MetaData() *MetaData {
@@ -217,8 +227,7 @@ If the canvas is backed by pixels (cpu or gpu), this writes a copy of the Surfac
 for the canvas to the location supplied by the caller, and returns true. Otherwise,
 return false and leave the supplied props unchanged. */
 func (canvas *Canvas) SurfaceProps() *SurfaceProps {
-	toimpl()
-	return nil
+	return canvas.surfaceProps
 }
 
 /**
@@ -410,7 +419,8 @@ and drawFilter are restored.
 
 @return The value to pass to restoreToCount() to balance this save() */
 func (canvas *Canvas) Save() {
-	toimpl()
+	canvas.saveCount++
+	canvas.mcRec.DeferredSaveCount++
 }
 
 /**
@@ -426,17 +436,15 @@ the canvas (or the previous layer).
 			 offscreen when restore() is called
 @return The value to pass to restoreToCount() to balance this save() */
 func (canvas *Canvas) SaveLayer(bounds *Rect, paint *Paint) int {
-	toimpl()
-	return 0
+	return canvas.SaveLayerWithRec(NewCanvasSaveLayerRec(bounds, paint, nil, 0))
 }
 
 /**
 Temporary name.
 Will allow any requests for LCD text to be respected, so the caller must be careful to
 only draw on top of opaque sections of the layer to get good results. */
-func SaveLayerPreserveLCDTextRequests(bounds *Rect, paint *Paint) int {
-	toimpl()
-	return 0
+func (canvas *Canvas) SaveLayerPreserveLCDTextRequests(bounds *Rect, paint *Paint) int {
+	return canvas.SaveLayerWithRec(NewCanvasSaveLayerRec(bounds, paint, nil, KCanvasSaveLayerFlagPreserveLCDText))
 }
 
 /**
@@ -451,8 +459,12 @@ the canvas (or the previous layer).
 @param alpha  This is applied to the offscreen when restore() is called.
 @return The value to pass to restoreToCount() to balance this save() */
 func (canvas *Canvas) SaveLayerAlpha(bounds *Rect, alpha uint8) int {
-	toimpl()
-	return 0
+	if alpha == 0xff {
+		return canvas.SaveLayer(bounds, nil)
+	}
+	var paint = NewPaint()
+	paint.SetAlpha(alpha)
+	return canvas.SaveLayer(bounds, paint)
 }
 
 type CanvasSaveLayerFlags int
@@ -471,7 +483,11 @@ type CanvasSaveLayerRec struct {
 	saveLayerFlags CanvasSaveLayerFlags
 }
 
-func newCanvasSaveLayerRec(bounds *Rect, paint *Paint, backdrop *ImageFilter,
+/** NewCanvasSaveLayerRec
+Describe a layer. bounds and paint may be nil. If backdrop is not nil the
+layer starts with what is under it filtered by backdrop, otherwise it
+starts transparent. */
+func NewCanvasSaveLayerRec(bounds *Rect, paint *Paint, backdrop *ImageFilter,
 	saveLayerFlags CanvasSaveLayerFlags) *CanvasSaveLayerRec {
 	return &CanvasSaveLayerRec{
 		bounds:         bounds,
@@ -482,8 +498,11 @@ func newCanvasSaveLayerRec(bounds *Rect, paint *Paint, backdrop *ImageFilter,
 }
 
 func (canvas *Canvas) SaveLayerWithRec(rec *CanvasSaveLayerRec) int {
-	toimpl()
-	return 0
+	var strategy = canvas.Impl.SaveLayerStrategy(rec)
+	canvas.saveCount++
+	canvas.internalSave()
+	canvas.internalSaveLayer(rec, strategy)
+	return canvas.SaveCount() - 1
 }
 
 /**
@@ -492,7 +511,15 @@ modifications to the matrix/clip/drawFilter state since the last save
 call.
 It is an error to call restore() more times than save() was called. */
 func (canvas *Canvas) Restore() {
-	toimpl()
+	if canvas.mcRec.DeferredSaveCount > 0 {
+		canvas.saveCount--
+		canvas.mcRec.DeferredSaveCount--
+	} else if canvas.mcStack.Len() > 1 {
+		canvas.Impl.WillRestore()
+		canvas.saveCount--
+		canvas.internalRestore()
+		canvas.Impl.DidRestore()
+	}
 }
 
 /**
@@ -510,8 +537,12 @@ getSaveCount(). To pop all the way back to the initial matrix/clip context
 pass saveCount == 1.
 @param saveCount    The number of save() levels to restore from */
 func (canvas *Canvas) RestoreToCount(saveCount int) {
-	toimpl()
-	return
+	if saveCount < 1 {
+		saveCount = 1
+	}
+	for n := canvas.SaveCount() - saveCount; n > 0; n-- {
+		canvas.Restore()
+	}
 }
 
 /**
@@ -519,7 +550,14 @@ Preconcat the current matrix with the specified translation
 @param dx   The distance to translate in X
 @param dy   The distance to translate in Y */
 func (canvas *Canvas) Translate(dx, dy Scalar) {
-	toimpl()
+	if dx == 0 && dy == 0 {
+		return
+	}
+	canvas.checkForDeferredSave()
+	canvas.deviceCMDirty = true
+	canvas.mcRec.Matrix.PreTranslate(dx, dy)
+	canvas.isScaleTranslate = canvas.mcRec.Matrix.IsScaleTranslate()
+	canvas.Impl.DidTranslate(dx, dy)
 }
 
 /**
@@ -527,14 +565,18 @@ Preconcat the current matrix with the specified scale.
 @param sx   The amount to scale in X
 @param sy   The amount to scale in Y */
 func (canvas *Canvas) Scale(sx, sy Scalar) {
-	toimpl()
+	var matrix = NewMatrix()
+	matrix.SetScale(sx, sy)
+	canvas.Concat(matrix)
 }
 
 /**
 Preconcat the current matrix with the specified rotation about the origin.
 @param degrees  The amount to rotate, in degrees */
 func (canvas *Canvas) Rotate(degrees Scalar) {
-	toimpl()
+	var matrix = NewMatrix()
+	matrix.SetRotate(degrees)
+	canvas.Concat(matrix)
 }
 
 /**
@@ -543,7 +585,13 @@ Preconcat the current matrix with the specified rotation about a given point.
 @param px  The x coordinate of the point to rotate about.
 @param py  The y coordinate of the point to rotate about. */
 func (canvas *Canvas) RotateAt(degrees, px, py Scalar) {
-	toimpl()
+	var rotate = NewMatrix()
+	rotate.SetRotate(degrees)
+	var matrix = NewMatrix()
+	matrix.SetTranslate(px, py)
+	matrix.PreConcat(rotate)
+	matrix.PreTranslate(-px, -py)
+	canvas.Concat(matrix)
 }
 
 /**
@@ -558,20 +606,29 @@ func (canvas *Canvas) Skew(sx, sy Scalar) {
 Preconcat the current matrix with the specified matrix.
 @param matrix   The matrix to preconcatenate with the current matrix */
 func (canvas *Canvas) Concat(matrix *Matrix) {
-	toimpl()
+	if matrix.IsIdentity() {
+		return
+	}
+	canvas.checkForDeferredSave()
+	canvas.deviceCMDirty = true
+	canvas.mcRec.Matrix.PreConcat(matrix)
+	canvas.isScaleTranslate = canvas.mcRec.Matrix.IsScaleTranslate()
+	canvas.Impl.DidConcat(matrix)
 }
 
 /**
 Replace the current matrix with a copy of the specified matrix.
 @param matrix The matrix that will be copied into the current matrix. */
 func (canvas *Canvas) SetMatrix(matrix *Matrix) {
-	toimpl()
+	canvas.checkForDeferredSave()
+	canvas.internalSetMatrix(matrix)
+	canvas.Impl.DidSetMatrix(matrix)
 }
 
 /**
 Helper for setMatrix(identity). Sets the current matrix to identity. */
 func (canvas *Canvas) ResetMatrix() {
-	toimpl()
+	canvas.SetMatrix(NewMatrix())
 }
 
 /**
@@ -580,20 +637,22 @@ Add the specified translation to the current draw depth of the canvas.
 			Negative into screen, positive out of screen.
 			Without translation, the draw depth defaults to 0. */
 func (canvas *Canvas) TranslateZ(z Scalar) {
-	toimpl()
+	canvas.checkForDeferredSave()
+	canvas.mcRec.CurDrawDepth += z
+	canvas.Impl.DidTranslateZ(z)
 }
 
 /**
 Set the current set of lights in the canvas.
 @param lights   The lights that we want the canvas to have. */
 func (canvas *Canvas) SetLights(lights *Lights) {
-	toimpl()
+	canvas.lights = lights
 }
 
 /** Returns the current set of lights the canvas uses
  */
 func (canvas *Canvas) Lights() *Lights {
-	return nil
+	return canvas.lights
 }
 
 /**
@@ -657,8 +716,15 @@ therefore you can skip making the draw calls).
 @return true if the rect (transformed by the canvas' matrix) does not
 			 intersect with the canvas' clip */
 func (canvas *Canvas) QuickRejectRect(rect Rect) bool {
-	toimpl()
-	return false
+	var clip = canvas.mcRec.RasterClip
+	if clip == nil {
+		return false
+	}
+	if clip.IsEmpty() {
+		return true
+	}
+	var devRect = canvas.mcRec.Matrix.MapRect(rect).RoundOut()
+	return !devRect.Intersects(clip.Bounds())
 }
 
 /**
@@ -816,7 +882,7 @@ will be filled or stroked based on the Style in the paint.
 @param rect     The rect to be drawn
 @param paint    The paint used to draw the rect */
 func (canvas *Canvas) DrawRect(rect Rect, paint *Paint) {
-	toimpl()
+	canvas.Impl.OnDrawRect(rect, paint)
 }
 
 /** DrawRectCoords
@@ -891,7 +957,7 @@ filled or framed based on the Style in the paint.
 @param path     The path to be drawn
 @param paint    The paint used to draw the path */
 func (canvas *Canvas) DrawPath(path *Path, paint *Paint) {
-	toimpl()
+	canvas.Impl.OnDrawPath(path, paint)
 }
 
 /** DrawImage
@@ -1069,7 +1135,9 @@ The origin is interpreted based on the Align setting in the paint.
 @param y        The y-coordinate of the origin of the text being drawn
 @param paint    The paint used for the text (e.g. color, size, style) */
 func (canvas *Canvas) DrawText(text string, x, y Scalar, paint *Paint) {
-	toimpl()
+	if len(text) > 0 {
+		canvas.Impl.OnDrawText(text, x, y, paint)
+	}
 }
 
 /** DrawTextAt
@@ -1109,7 +1177,9 @@ path to start the text.
 					position the text
 @param paint        The paint used for the text */
 func (canvas *Canvas) DrawTextOnPathHV(text string, path *Path, hOffset, vOffset Scalar, paint *Paint) {
-	toimpl()
+	var matrix = NewMatrix()
+	matrix.SetTranslate(hOffset, vOffset)
+	canvas.DrawTextOnPath(text, path, matrix, paint)
 }
 
 /** DrawTextOnPath
@@ -1123,15 +1193,38 @@ path to start the text.
 					mapped onto the path
 @param paint        The paint used for the text */
 func (canvas *Canvas) DrawTextOnPath(text string, path *Path, matrix *Matrix, paint *Paint) {
-	toimpl()
+	if len(text) > 0 {
+		canvas.Impl.OnDrawTextOnPath(text, path, matrix, paint)
+	}
 }
 
 /** DrawTextRSXform
 Draw the text with each character/glyph individually transformed by its xform.
 If cullRect is not null, it is a conservative bounds of what will be drawn
-taking into account the xforms and the paint, and will be used to accelerate culling. */
+taking into account the xforms and the paint, and will be used to accelerate culling.
+An empty cullRect means there is no cull rect. */
 func (canvas *Canvas) DrawTextRSXform(text string, rsxform []RSXform, cullRect Rect, paint *Paint) {
-	toimpl()
+	if len(text) == 0 {
+		return
+	}
+	var cull *Rect
+	if cullRect.Width > 0 && cullRect.Height > 0 {
+		cull = &cullRect
+	}
+	canvas.Impl.OnDrawTextRSXform(text, rsxform, cull, paint)
+}
+
+/** DrawTextBlob
+Draw the text blob, offset by (x,y), using the specified paint.
+@param blob     The text blob to be drawn
+@param x        The x-offset of the text being drawn
+@param y        The y-offset of the text being drawn
+@param paint    The paint used for the text (e.g. color, size, style) */
+func (canvas *Canvas) DrawTextBlob(blob *TextBlob, x, y Scalar, paint *Paint) {
+	if blob == nil {
+		return
+	}
+	canvas.Impl.OnDrawTextBlob(blob, x, y, paint)
 }
 
 /** DrawPicture
@@ -1150,7 +1243,13 @@ alpha/colorfilter/imagefilter/xfermode to that buffer as it is drawn to the canv
 This is logically equivalent to
     saveLayer(paint)/drawPicture/restore */
 func (canvas *Canvas) DrawPicture(pic *Picture, matrix *Matrix, paint *Paint) {
-	toimpl()
+	if pic == nil {
+		return
+	}
+	if matrix != nil && matrix.IsIdentity() {
+		matrix = nil
+	}
+	canvas.Impl.OnDrawPicture(pic, matrix, paint)
 }
 
 /** DrawShadowedPicture
@@ -1169,7 +1268,13 @@ alpha/colorfilter/imagefilter/xfermode to that buffer as it is drawn to the canv
 This is logically equivalent to
     saveLayer(paint)/drawPicture/restore */
 func (canvas *Canvas) DrawShadowedPicture(picture *Picture, matrix *Matrix, paint *Paint) {
-	toimpl()
+	if picture == nil {
+		return
+	}
+	if matrix != nil && matrix.IsIdentity() {
+		matrix = nil
+	}
+	canvas.Impl.OnDrawShadowedPicture(picture, matrix, paint)
 }
 
 type CanvasVertexMode int
@@ -1183,9 +1288,8 @@ const (
 /** DrawVertices
 Draw the array of vertices, interpreted as triangles (based on mode).
 
-If both textures and vertex-colors are NULL, it strokes hairlines with
-the paint's color. This behavior is a useful debugging mode to visualize
-the mesh.
+If both textures and vertex-colors are NULL, the triangles are filled with
+the paint as is.
 
 @param vmode How to interpret the array of vertices
 @param vertexCount The number of points in the vertices array (and
@@ -1205,7 +1309,10 @@ the mesh.
 @param paint Specifies the shader/texture if present. */
 func (canvas *Canvas) DrawVertices(vmode CanvasVertexMode, vertexCount int, vertices []Point, texs []Point, colors []Color,
 	mode *Xfermode, indices []uint16, indexCount int, paint *Paint) {
-	toimpl()
+	if vertexCount < 3 {
+		return
+	}
+	canvas.Impl.OnDrawVertices(vmode, vertexCount, vertices, texs, colors, mode, indices, indexCount, paint)
 }
 
 /** DrawPatch
@@ -1214,14 +1321,14 @@ Draw a cubic coons patch
 @param cubic specifies the 4 bounding cubic bezier curves of a patch with clockwise order
 			   starting at the top left corner.
 @param colors specifies the colors for the corners which will be bilerp across the patch,
-			   their order is clockwise starting at the top left corner.
+			   their order is clockwise starting at the top left corner. May be nil.
 @param texCoords specifies the texture coordinates that will be bilerp across the patch,
-			   their order is the same as the colors.
+			   their order is the same as the colors. May be nil.
 @param xmode specifies how are the colors and the textures combined if both of them are
 			   present.
 @param paint Specifies the shader/texture if present. */
-func (canvas *Canvas) DrawPatch(cubics [12]Point, colors [4]Color, texCoords [4]Point, xmode *Xfermode, paint *Paint) {
-	toimpl()
+func (canvas *Canvas) DrawPatch(cubics [12]Point, colors *[4]Color, texCoords *[4]Point, xmode *Xfermode, paint *Paint) {
+	canvas.Impl.OnDrawPatch(cubics, colors, texCoords, xmode, paint)
 }
 
 /** DrawAtlas
@@ -1242,7 +1349,14 @@ The paint is optional. If specified, its antialiasing, alpha, color-filter, imag
 and xfermode are used to affect each of the quads. */
 func (canvas *Canvas) DrawAtlas(atlas *Image, form []RSXform, tex []Rect, colors []Color, count int, mode XfermodeMode,
 	cullRect Rect, paint *Paint) {
-	toimpl()
+	if atlas == nil || count <= 0 {
+		return
+	}
+	var cull *Rect
+	if !cullRect.IsEmpty() {
+		cull = &cullRect
+	}
+	canvas.Impl.OnDrawAtlas(atlas, form, tex, colors, count, mode, cull, paint)
 }
 
 /** DrawDrawable
@@ -1251,13 +1365,21 @@ Draw the contents of this drawable into the canvas. If the canvas is async
 to have its draw() method called when the picture is finalized.
 
 If the intent is to force the contents of the drawable into this canvas immediately,
-then drawable->draw(canvas) may be called. */
+then drawable.Draw(canvas, matrix) may be called. */
 func (canvas *Canvas) DrawDrawable(drawable *Drawable, matrix *Matrix) {
-	toimpl()
+	if drawable == nil {
+		return
+	}
+	if matrix != nil && matrix.IsIdentity() {
+		matrix = nil
+	}
+	canvas.Impl.OnDrawDrawable(drawable, matrix)
 }
 
 func (canvas *Canvas) DrawDrawableAt(drawable *Drawable, x, y Scalar) {
-	toimpl()
+	var matrix = NewMatrix()
+	matrix.SetTranslate(x, y)
+	canvas.DrawDrawable(drawable, matrix)
 }
 
 /** DrawAnnotation
@@ -1277,9 +1399,8 @@ func (canvas *Canvas) DrawAnnotation(rect Rect, key []byte, value *Data) {
 Get the current filter object. The filter's reference count is not
 affected. The filter is saved/restored, just like the matrix and clip.
 @return the canvas' filter (or NULL). */
-func (canvas *Canvas) DrawFilter() *DrawFilter {
-	toimpl()
-	return nil
+func (canvas *Canvas) DrawFilter() DrawFilter {
+	return canvas.mcRec.Filter
 }
 
 /** SetDrawFilter
@@ -1291,8 +1412,9 @@ matrix and clip.
 @param filter the new filter (or NULL)
 @return the new filter
 Impl CanvasImpl */
-func (canvas *Canvas) SetDrawFilter(filter *DrawFilter) {
-	toimpl()
+func (canvas *Canvas) SetDrawFilter(filter DrawFilter) {
+	canvas.checkForDeferredSave()
+	canvas.mcRec.Filter = filter
 }
 
 /** IsClipEmpty
@@ -1385,8 +1507,7 @@ func (canvas *Canvas) temporaryInternalDescribeTopLayer(matrix *Matrix, clipBoun
 }
 
 func (canvas *Canvas) Z() Scalar {
-	toimpl()
-	return 0
+	return canvas.mcRec.CurDrawDepth
 }
 
 /** OnNewSurface
@@ -1424,7 +1545,7 @@ func (canvas *Canvas) OnGetProps() (*SurfaceProps, bool) {
 
 /** WillSave Impl CanvasImpl */
 func (canvas *Canvas) WillSave() {
-	toimpl()
+	// nothing to do.
 }
 
 /**
@@ -1441,39 +1562,40 @@ const (
 /** SaveLayerStrategy
 Overriders should call the corresponding INHERITED method up the inheritance chain.
 Impl CanvasImpl */
-func (canvas *Canvas) SaveLayerStrategy() CanvasSaveLayerStrategy {
-	toimpl()
+func (canvas *Canvas) SaveLayerStrategy(rec *CanvasSaveLayerRec) CanvasSaveLayerStrategy {
 	return KCanvasSaveLayerStrategyFullLayer
 }
 
 /** WillRestore Impl CanvasImpl */
 func (canvas *Canvas) WillRestore() {
-	toimpl()
+	// nothing to do.
 }
 
 /** DidRestore Impl CanvasImpl */
 func (canvas *Canvas) DidRestore() {
-	toimpl()
+	// nothing to do.
 }
 
 /** DidConcat Impl CanvasImpl */
 func (canvas *Canvas) DidConcat(matrix *Matrix) {
-	toimpl()
+	// nothing to do.
 }
 
 /** DidSetMatrix Impl CanvasImpl */
 func (canvas *Canvas) DidSetMatrix(matrix *Matrix) {
-	toimpl()
+	// nothing to do.
 }
 
 /** DidTranslate Impl CanvasImpl */
 func (canvas *Canvas) DidTranslate(dx, dy Scalar) {
-	toimpl()
+	var matrix = NewMatrix()
+	matrix.SetTranslate(dx, dy)
+	canvas.Impl.DidConcat(matrix)
 }
 
 /** DidTranslateZ Impl CanvasImpl */
 func (canvas *Canvas) DidTranslateZ(z Scalar) {
-	toimpl()
+	// nothing to do.
 }
 
 /** OnDrawAnnotation Impl CanvasImpl */
@@ -1488,7 +1610,13 @@ func (canvas *Canvas) OnDrawDRect(outter, inner Rect, paint *Paint) {
 
 /** OnDrawText Impl CanvasImpl */
 func (canvas *Canvas) OnDrawText(text string, x, y Scalar, paint *Paint) {
-	toimpl()
+	var looper = newAutoDrawLooper(canvas, paint, false, nil)
+	for looper.Next(KDrawFilterTypeText) {
+		var it = NewDrawIterator(canvas)
+		for it.Next() {
+			it.Device().Device.DrawText(it.Draw, text, x, y, looper.Paint())
+		}
+	}
 }
 
 /** OnDrawTextAt Impl CanvasImpl */
@@ -1503,27 +1631,70 @@ func (canvas *Canvas) OnDrawTextAtH(text string, xpos []Point, constY Scalar, pa
 
 /** OnDrawTextOnPath Impl CanvasImpl */
 func (canvas *Canvas) OnDrawTextOnPath(text string, path *Path, matrix *Matrix, paint *Paint) {
-	toimpl()
+	var looper = newAutoDrawLooper(canvas, paint, false, nil)
+	for looper.Next(KDrawFilterTypeText) {
+		var it = NewDrawIterator(canvas)
+		for it.Next() {
+			it.Device().Device.DrawTextOnPath(it.Draw, text, path, matrix, looper.Paint())
+		}
+	}
 }
 
 /** OnDrawTextRSXform Impl CanvasImpl */
 func (canvas *Canvas) OnDrawTextRSXform(text string, xform []RSXform, cullRect *Rect, paint *Paint) {
-	toimpl()
+	if cullRect != nil && canvas.QuickRejectRect(*cullRect) {
+		return
+	}
+
+	var looper = newAutoDrawLooper(canvas, paint, false, nil)
+	for looper.Next(KDrawFilterTypeText) {
+		var it = NewDrawIterator(canvas)
+		for it.Next() {
+			it.Device().Device.DrawTextRSXform(it.Draw, text, xform, looper.Paint())
+		}
+	}
 }
 
 /** OnDrawTextBlob Impl CanvasImpl */
 func (canvas *Canvas) OnDrawTextBlob(blob *TextBlob, x, y Scalar, paint *Paint) {
-	toimpl()
+	var bounds = blob.Bounds()
+	bounds.Offset(x, y)
+	// the looper, mask filter, stroke and image filter of paint draw outside
+	// of the glyphs.
+	if paint.CanComputeFastBounds() && canvas.QuickRejectRect(paint.ComputeFastBounds(bounds, nil)) {
+		return
+	}
+
+	var looper = newAutoDrawLooper(canvas, paint, false, nil)
+	for looper.Next(KDrawFilterTypeText) {
+		var it = NewDrawIterator(canvas)
+		for it.Next() {
+			it.Device().Device.DrawTextBlob(it.Draw, blob, x, y, looper.Paint())
+		}
+	}
 }
 
 /** OnDrawPatch Impl CanvasImpl */
-func (canvas *Canvas) OnDrawPatch(cubics [12]Point, colors [4]Color, texCoords [4]Point, xmode *Xfermode, paint *Paint) {
-	toimpl()
+func (canvas *Canvas) OnDrawPatch(cubics [12]Point, colors *[4]Color, texCoords *[4]Point, xmode *Xfermode, paint *Paint) {
+	var looper = newAutoDrawLooper(canvas, paint, false, nil)
+	for looper.Next(KDrawFilterTypePath) {
+		var it = NewDrawIterator(canvas)
+		for it.Next() {
+			it.Device().Device.DrawPatch(it.Draw, &cubics, colors, texCoords, xmode, looper.Paint())
+		}
+	}
 }
 
 /** OnDrawDrawable Impl CanvasImpl */
-func (canvas *Canvas) OnDrawDrawable(drawable *Drawable, matrixe *Matrix) {
-	toimpl()
+func (canvas *Canvas) OnDrawDrawable(drawable *Drawable, matrix *Matrix) {
+	var bounds = drawable.Bounds()
+	if matrix != nil {
+		bounds = matrix.MapRect(bounds)
+	}
+	if canvas.QuickRejectRect(bounds) {
+		return
+	}
+	drawable.Draw(canvas, matrix)
 }
 
 /** OnDrawPaint Impl CanvasImpl */
@@ -1541,7 +1712,13 @@ func (canvas *Canvas) OnDrawPaint(paint *Paint) {
 
 /** OnDrawRect Impl CanvasImpl */
 func (canvas *Canvas) OnDrawRect(rect Rect, paint *Paint) {
-	toimpl()
+	var looper = newAutoDrawLooper(canvas, paint, false, &rect)
+	for looper.Next(KDrawFilterTypeRect) {
+		var it = NewDrawIterator(canvas)
+		for it.Next() {
+			it.Device().Device.DrawRect(it.Draw, rect, looper.Paint())
+		}
+	}
 }
 
 /** OnDrawOval Impl CanvasImpl */
@@ -1562,18 +1739,47 @@ func (canvas *Canvas) OnDrawPoints(mode CanvasPointMode, count int, pts []Point,
 /** OnDrawVertices Impl CanvasImpl */
 func (canvas *Canvas) OnDrawVertices(vertexMode CanvasVertexMode, vertexCount int, vertices []Point, texs []Point,
 	colors []Color, xfermode *Xfermode, indices []uint16, indexCount int, paint *Paint) {
-	toimpl()
+	var looper = newAutoDrawLooper(canvas, paint, false, nil)
+	for looper.Next(KDrawFilterTypePath) {
+		var it = NewDrawIterator(canvas)
+		for it.Next() {
+			it.Device().Device.DrawVertices(it.Draw, vertexMode, vertexCount, vertices, texs, colors, xfermode,
+				indices, indexCount, looper.Paint())
+		}
+	}
 }
 
 /** OnDrawAtlas Impl CanvasImpl */
 func (canvas *Canvas) OnDrawAtlas(atlas *Image, xform []RSXform, tex []Rect, colors []Color, count int,
 	mode XfermodeMode, cull *Rect, paint *Paint) {
-	toimpl()
+	if cull != nil && canvas.QuickRejectRect(*cull) {
+		return
+	}
+	if paint == nil {
+		paint = NewPaint()
+	}
+
+	var looper = newAutoDrawLooper(canvas, paint, false, nil)
+	for looper.Next(KDrawFilterTypePath) {
+		var it = NewDrawIterator(canvas)
+		for it.Next() {
+			it.Device().Device.DrawAtlas(it.Draw, atlas, xform, tex, colors, count, mode, looper.Paint())
+		}
+	}
 }
 
 /** OnDrawPath Impl CanvasImpl */
 func (canvas *Canvas) OnDrawPath(path *Path, paint *Paint) {
-	toimpl()
+	if path.IsEmpty() && !path.IsInverseFillType() {
+		return
+	}
+	var looper = newAutoDrawLooper(canvas, paint, false, nil)
+	for looper.Next(KDrawFilterTypePath) {
+		var it = NewDrawIterator(canvas)
+		for it.Next() {
+			it.Device().Device.DrawPath(it.Draw, path, looper.Paint(), nil, false)
+		}
+	}
 }
 
 /** OnDrawImage Impl CanvasImpl */
@@ -1642,12 +1848,54 @@ func (canvas *Canvas) OnDiscard() {
 
 /** OnDrawPicture Impl CanvasImpl */
 func (canvas *Canvas) OnDrawPicture(pic *Picture, matrix *Matrix, paint *Paint) {
-	toimpl()
+	var saveCount = canvas.SaveCount()
+	canvas.Save()
+	if matrix != nil {
+		canvas.Concat(matrix)
+	}
+	if paint != nil {
+		var bounds = pic.CullRect()
+		canvas.SaveLayer(&bounds, paint)
+	}
+	pic.Playback(canvas)
+	canvas.RestoreToCount(saveCount)
 }
 
 /** OnDrawShadowedPicture Impl CanvasImpl */
 func (canvas *Canvas) OnDrawShadowedPicture(pic *Picture, matrix *Matrix, paint *Paint) {
-	toimpl()
+	var lights = canvas.Lights()
+	if lights == nil {
+		canvas.OnDrawPicture(pic, matrix, paint)
+		return
+	}
+
+	var total = NewMatrixClone(canvas.TotalMatrix())
+	if matrix != nil {
+		total.PreConcat(matrix)
+	}
+	var bounds = total.MapRect(pic.CullRect()).RoundOut()
+	if !bounds.Intersect(canvas.mcRec.RasterClip.Bounds()) {
+		return
+	}
+	var shaded = shadePicture(pic, total, bounds, lights)
+	if shaded == nil {
+		return
+	}
+
+	// the shaded picture is in device space, drawn through a layer of paint
+	// as OnDrawPicture does.
+	var local = NewMatrix()
+	local.SetTranslate(bounds.Left, bounds.Top)
+	var image = NewPaint()
+	image.SetShader(NewImageFromBitmap(shaded).MakeShader(KShaderTileModeClamp, KShaderTileModeClamp, local))
+	var saveCount = canvas.SaveCount()
+	canvas.Save()
+	canvas.ResetMatrix()
+	if paint != nil {
+		canvas.SaveLayer(&bounds, paint)
+	}
+	canvas.DrawRect(bounds, image)
+	canvas.RestoreToCount(saveCount)
 }
 
 /** CanvasForDrawIterator Impl CanvasImpl */
@@ -1738,9 +1986,39 @@ func CanvasLegacySaveFlagsToSaveLayerFlags(legacySaveFlags uint32) CanvasSaveLay
 	return 0
 }
 
+/** CanvasDrawDeviceWithFilter
+Replace the pixels of dst with the pixels of src under it filtered by
+filter, as drawn with ctm. This is how a layer starts with its backdrop.
+Both devices must have N32 pixels. */
 func CanvasDrawDeviceWithFilter(src *BaseDevice, filter *ImageFilter, dst *BaseDevice, ctm *Matrix,
 	clipStack *ClipStack) {
-	toimpl()
+	var srcPixels, dstPixels Pixmap
+	if !src.Device.OnPeekPixels(&srcPixels) || !dst.Device.OnAccessPixels(&dstPixels) ||
+		srcPixels.ColorType() != KColorTypeN32 || dstPixels.ColorType() != KColorTypeN32 {
+		return
+	}
+	var srcBitmap, dstBitmap = new(Bitmap), new(Bitmap)
+	if !srcBitmap.InstallPixels(srcPixels.Info(), srcPixels.PixelBytes(), srcPixels.RowBytes(), nil) ||
+		!dstBitmap.InstallPixels(dstPixels.Info(), dstPixels.PixelBytes(), dstPixels.RowBytes(), nil) {
+		return
+	}
+
+	// filter in the space of src, whose top left is at its origin.
+	var srcOrigin, dstOrigin = src.Origin(), dst.Origin()
+	var dstBounds = dst.GlobalBounds()
+	dstBounds.Offset(-srcOrigin.X, -srcOrigin.Y)
+	var srcCTM = NewMatrixClone(ctm)
+	srcCTM.PostTranslate(-srcOrigin.X, -srcOrigin.Y)
+	var result, offset, ok = filter.FilterImage(srcBitmap, NewImageFilterContext(srcCTM, dstBounds))
+	if !ok {
+		return
+	}
+	// the backdrop replaces whatever the layer held.
+	var pixels = dstBitmap.PixelBytes()
+	for i := range pixels {
+		pixels[i] = 0
+	}
+	drawFilterBitmap(dstBitmap, result, offset.X+srcOrigin.X-dstOrigin.X, offset.Y+srcOrigin.Y-dstOrigin.Y, false)
 }
 
 type CanvasShaderOverrideOpacity int
@@ -1783,28 +2061,50 @@ func (canvas *Canvas) updateDeviceCMCache() {
 		var totalClip = canvas.mcRec.RasterClip
 		var layer = canvas.mcRec.TopLayer
 
+		if layer.Device == nil { // < not backed by a device.
+			return
+		}
 		if layer.Next == nil { // < only one layer.
 			layer.UpdateMC(totalMatrix, totalClip, canvas.clipStack, nil)
 		} else {
 			var clip = NewRasterClipClone(totalClip)
-			for layer.Next != nil {
+			for ; layer != nil; layer = layer.Next {
 				layer.UpdateMC(totalMatrix, clip, canvas.clipStack, clip)
-				layer = layer.Next
 			}
 		}
+		canvas.deviceCMDirty = false
+	}
+}
+
+// Push a copy of the current matrix, clip and depth on the stack.
+func (canvas *Canvas) internalSave() {
+	var rec = &tCanvasMCRec{
+		Filter:       canvas.mcRec.Filter,
+		TopLayer:     canvas.mcRec.TopLayer,
+		RasterClip:   NewRasterClipClone(canvas.mcRec.RasterClip),
+		Matrix:       NewMatrixClone(canvas.mcRec.Matrix),
+		CurDrawDepth: canvas.mcRec.CurDrawDepth,
 	}
+	canvas.mcRec = rec
+	canvas.mcStack.PushBack(rec)
 }
 
 func (canvas *Canvas) doSave() {
-	toimpl()
+	canvas.Impl.WillSave()
+	canvas.mcRec.DeferredSaveCount--
+	canvas.internalSave()
 }
 
 func (canvas *Canvas) checkForDeferredSave() {
-	toimpl()
+	if canvas.mcRec.DeferredSaveCount > 0 {
+		canvas.doSave()
+	}
 }
 
 func (canvas *Canvas) internalSetMatrix(matrix *Matrix) {
-	toimpl()
+	canvas.mcRec.Matrix = NewMatrixClone(matrix)
+	canvas.isScaleTranslate = matrix.IsScaleTranslate()
+	canvas.deviceCMDirty = true
 }
 
 type CanvasInitFlags int
@@ -1819,8 +2119,17 @@ Creates a canvas of the specified dimensions, but explicitly not backed
 by any device/pixels. Typically this use used by subclasses who handle
 the draw calls in some other way. */
 func NewCanvas(width, height int, surfaceProps *SurfaceProps) *Canvas {
-	toimpl()
-	return &Canvas{}
+	var canvas = new(Canvas)
+	canvas.Impl = canvas
+	canvas.surfaceProps = surfaceProps
+	if canvas.surfaceProps == nil {
+		canvas.surfaceProps = NewSurfaceProps(KSurfacePropsFlagNone, KSurfacePropsInitTypeLegacyFontHost)
+	}
+	canvas.mcStack = list.New()
+	canvas.clipStack = NewClipStack()
+	canvas.init(nil, KCanvasInitFlagDefault)
+	canvas.mcRec.RasterClip.SetRect(MakeRectWH(Scalar(width), Scalar(height)))
+	return canvas
 }
 
 /**
@@ -1881,11 +2190,84 @@ func (canvas *Canvas) getTopLayerBounds() Rect {
 }
 
 func (canvas *Canvas) internalSaveLayer(rec *CanvasSaveLayerRec, strategy CanvasSaveLayerStrategy) {
-	toimpl()
+	if strategy == KCanvasSaveLayerStrategyNoLayer {
+		return
+	}
+
+	var device = canvas.mcRec.TopLayer.Device
+	if device == nil {
+		return
+	}
+
+	// the layer covers the clip, and what its image filter reads to draw
+	// it, limited to bounds. Drawing into the layer is clipped to it.
+	var bounds = canvas.mcRec.RasterClip.Bounds()
+	if rec.paint != nil && rec.paint.ImageFilter() != nil {
+		bounds = rec.paint.ImageFilter().FilterBounds(bounds, canvas.mcRec.Matrix, KImageFilterMapDirectionReverse)
+	}
+	canvas.deviceCMDirty = true
+	if canvas.mcRec.RasterClip.IsEmpty() ||
+		(rec.bounds != nil && !bounds.Intersect(canvas.mcRec.Matrix.MapRect(*rec.bounds).RoundOut())) {
+		canvas.mcRec.RasterClip.SetRect(RectZero)
+		return
+	}
+	canvas.mcRec.RasterClip.Op(bounds, KRegionOpReplace)
+
+	var alphaType = KAlphaTypePremul
+	if rec.saveLayerFlags&KCanvasSaveLayerFlagIsOpaque != 0 {
+		alphaType = KAlphaTypeOpaque
+	}
+	var info = NewImageInfoN32(ScalarCeil(bounds.Width), ScalarCeil(bounds.Height), alphaType, nil)
+	var preserveLCDText = rec.saveLayerFlags&KCanvasSaveLayerFlagPreserveLCDText != 0
+	var cinfo = NewDeviceCreateInfo(info, device.SurfaceProps().PixelGeometry(), preserveLCDText)
+	var newDevice = device.Device.OnCreateDevice(cinfo, rec.paint)
+	if newDevice == nil {
+		return
+	}
+	newDevice.Base().origin = Point{bounds.Left, bounds.Top}
+	if rec.backdrop != nil {
+		CanvasDrawDeviceWithFilter(device, rec.backdrop, newDevice.Base(), canvas.mcRec.Matrix, canvas.clipStack)
+	}
+
+	// the layer is drawn into alone until it is restored, so it isn't
+	// chained to the layers under it.
+	var paint *Paint
+	if rec.paint != nil {
+		paint = rec.paint.Clone()
+	}
+	var layer = newDeivceCM(newDevice.Base(), paint, canvas, canvas.conservativeRasterClip, nil)
+	canvas.mcRec.Layer = layer
+	canvas.mcRec.TopLayer = layer
 }
 
 func (canvas *Canvas) internalRestore() {
-	toimpl()
+	canvas.deviceCMDirty = true
+	// the root level is never popped, so a layer here was made by saveLayer.
+	var layer = canvas.mcRec.Layer
+	canvas.mcStack.Remove(canvas.mcStack.Back())
+	canvas.mcRec = canvas.mcStack.Back().Value.(*tCanvasMCRec)
+	if layer != nil {
+		var origin = layer.Device.Origin()
+		canvas.internalDrawDevice(layer.Device, int(origin.X), int(origin.Y), layer.Paint)
+	}
+}
+
+/** internalDrawDevice
+Draw the pixels of the layer device src, whose top left is at (x, y) of the
+root device, into the devices of the current level with paint, which may be
+nil. */
+func (canvas *Canvas) internalDrawDevice(src *BaseDevice, x, y int, paint *Paint) {
+	if paint == nil {
+		paint = NewPaint()
+	}
+	var looper = newAutoDrawLooper(canvas, paint, true, nil)
+	for looper.Next(KDrawFilterTypeBitmap) {
+		var it = NewDrawIterator(canvas)
+		for it.Next() {
+			var origin = it.Device().Origin()
+			it.Device().Device.DrawDevice(it.Draw, src, x-int(origin.X), y-int(origin.Y), looper.Paint())
+		}
+	}
 }
 
 type LazyPaint Lazy
@@ -1943,9 +2325,9 @@ func (deviceCM *tDeviceCM) UpdateMC(totalMatrix *Matrix, totalClip *RasterClip,
 	clipStack *ClipStack, updateClip *RasterClip) {
 	var x, y = deviceCM.Device.Origin().X, deviceCM.Device.Origin().Y
 	var w, h = deviceCM.Device.Width(), deviceCM.Device.Height()
-	if x == 0 || y == 0 {
+	if x == 0 && y == 0 {
 		deviceCM.Matrix = totalMatrix
-		deviceCM.Clip = totalClip
+		deviceCM.Clip = NewRasterClipClone(totalClip)
 	} else {
 		deviceCM.Matrix = NewMatrixClone(totalMatrix)
 		deviceCM.Matrix.PostTranslate(-x, -y)
@@ -1969,7 +2351,7 @@ in the ...Storage field, and the pointer points to that. If the value is not
 copied for this level, we ignore ...Storage, and just point at the
 corresponding value in the previous level in the stack. */
 type tCanvasMCRec struct {
-	Filter *DrawFilter // the current filter (or nil)
+	Filter DrawFilter // the current filter (or nil)
 	Layer  *tDeviceCM
 
 	/** If there are any layers in the stack, this points to the top-most
@@ -1981,7 +2363,7 @@ type tCanvasMCRec struct {
 	RasterClip        *RasterClip
 	Matrix            *Matrix
 	DeferredSaveCount int
-	CurDrawDepth      int
+	CurDrawDepth      Scalar
 }
 
 func newCanvasMCRec(conservativeRasterClip bool) *tCanvasMCRec {
@@ -2012,16 +2394,17 @@ type tAutoDrawLooper struct {
 	tempLayerForImageFilter bool
 	done                    bool
 	isSimple                bool
-	looperContext           *tDrawLooperContext
+	looperContext           DrawLooperContext
 }
 
 func newAutoDrawLooper(canvas *Canvas, paint *Paint, skipLayerForImageFilter bool, rawBounds *Rect) *tAutoDrawLooper {
 	var looper = &tAutoDrawLooper{
+		lazyPaintInit:      NewLazy(),
 		lazyPaintPerLooper: NewLazy(),
 		canvas:                  canvas,
 		origPaint:               paint,
 		paint:                   paint,
-		filter:                  nil,
+		filter:                  canvas.mcRec.Filter,
 		saveCount:               canvas.SaveCount(),
 		tempLayerForImageFilter: false,
 		done: false,
@@ -2052,23 +2435,26 @@ func newAutoDrawLooper(canvas *Canvas, paint *Paint, skipLayerForImageFilter boo
 		var tmp = NewPaint()
 		tmp.SetImageFilter(looper.paint.ImageFilter())
 		tmp.SetXfermode(looper.paint.Xfermode())
-		var storage Rect
+		var bounds *Rect
 		if rawBounds != nil {
 			// Make rawBounds include all paint outsets except for those due to image filters.
-			*rawBounds = applyPaintToBoundsSansImageFilter(looper.paint, *rawBounds, &storage)
+			var storage Rect
+			var r = applyPaintToBoundsSansImageFilter(looper.paint, *rawBounds, &storage)
+			bounds = &r
 		}
-		canvas.internalSaveLayer(newCanvasSaveLayerRec(rawBounds, tmp, nil, KCanvasSaveLayerFlagIsOpaque), KCanvasSaveLayerStrategyFullLayer)
-		looper.tempLayerForImageFilter = false
+		canvas.internalSave()
+		canvas.internalSaveLayer(NewCanvasSaveLayerRec(bounds, tmp, nil, 0), KCanvasSaveLayerStrategyFullLayer)
+		looper.tempLayerForImageFilter = true
 		// we remove the imagefilter/xfermode inside doNext()
 	}
 
 	if paint.Looper() != nil {
-		// looper.looperContext = paint.Looper().CreateContext(canvas)
+		looper.looperContext = paint.Looper().CreateContext(canvas)
 		looper.isSimple = false
 	} else {
 		looper.looperContext = nil
 		// can we be marked as simple?
-		looper.isSimple = looper.filter != nil && !looper.tempLayerForImageFilter
+		looper.isSimple = looper.filter == nil && !looper.tempLayerForImageFilter
 	}
 
 	return looper
@@ -2078,15 +2464,23 @@ func (looper *tAutoDrawLooper) Paint() *Paint {
 	return looper.paint
 }
 
+/** Next
+Return true if there is another pass to draw, with the paint of Paint.
+When there is none, the layer of the image filter is drawn back. */
 func (looper *tAutoDrawLooper) Next(drawType DrawFilterType) bool {
+	var more bool
 	if looper.done {
-		return false
+		more = false
 	} else if looper.isSimple {
-		looper.done = false
-		return !looper.paint.NothingToDraw()
+		looper.done = true
+		more = !looper.paint.NothingToDraw()
 	} else {
-		return looper.doNext(drawType)
+		more = looper.doNext(drawType)
+	}
+	if !more {
+		looper.Finalizer()
 	}
+	return more
 }
 
 func (looper *tAutoDrawLooper) doNext(drawType DrawFilterType) bool {
@@ -2097,14 +2491,14 @@ func (looper *tAutoDrawLooper) doNext(drawType DrawFilterType) bool {
 		origPaint, _ = looper.lazyPaintInit.Get().(*Paint)
 	}
 
-	var paint, _ = looper.lazyPaintPerLooper.Set(origPaint).(*Paint)
+	var paint, _ = looper.lazyPaintPerLooper.Set(NewPaint_Clone(origPaint)).(*Paint)
 
 	if looper.tempLayerForImageFilter {
 		paint.SetImageFilter(nil)
 		paint.SetXfermode(nil)
 	}
 
-	if looper.looperContext != nil && looper.looperContext.Next(looper.canvas, paint) {
+	if looper.looperContext != nil && !looper.looperContext.Next(looper.canvas, paint) {
 		looper.done = true
 		return false
 	}
@@ -2136,7 +2530,10 @@ func (looper *tAutoDrawLooper) doNext(drawType DrawFilterType) bool {
 }
 
 func (looper *tAutoDrawLooper) Finalizer() {
+	// undo what the looper context saved if the draw filter stopped it.
+	looper.canvas.RestoreToCount(looper.saveCount)
 	if looper.tempLayerForImageFilter {
+		looper.tempLayerForImageFilter = false
 		looper.canvas.internalRestore()
 	}
 }
@@ -2209,13 +2606,19 @@ func imageToColorFilter(paint *Paint) *ColorFilter {
 }
 
 func setIfNeeded(lazyPaint *Lazy, paint *Paint) *Paint {
-	toimpl()
-	return nil
+	if lazyPaint.IsValid() {
+		return lazyPaint.Get().(*Paint)
+	}
+	return lazyPaint.Set(NewPaint_Clone(paint)).(*Paint)
 }
 
 func applyPaintToBoundsSansImageFilter(paint *Paint, rowBounds Rect, storage *Rect) Rect {
-	toimpl()
-	return RectZero
+	var tmpUnfiltered = NewPaint_Clone(paint)
+	tmpUnfiltered.SetImageFilter(nil)
+	if tmpUnfiltered.CanComputeFastBounds() {
+		return tmpUnfiltered.ComputeFastBounds(rowBounds, storage)
+	}
+	return rowBounds
 }
 
 func quickRejectClipBounds(bounds Rect) Rect {
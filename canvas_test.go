@@ -0,0 +1,156 @@
+package ggk
+
+import "testing"
+
+func TestCanvasSaveRestore(t *testing.T) {
+	var canvas = NewCanvas(10, 10, nil)
+	if canvas.SaveCount() != 1 || !canvas.TotalMatrix().IsIdentity() {
+		t.Fatalf("NewCanvas want a save count of 1 and the identity matrix")
+	}
+
+	canvas.Save()
+	canvas.Translate(2, 3)
+	canvas.Save()
+	canvas.Scale(2, 2)
+	if canvas.SaveCount() != 3 {
+		t.Errorf("Save want a save count of 3 got %v", canvas.SaveCount())
+	}
+	var pt = canvas.TotalMatrix().MapXY(1, 1)
+	if pt != (Point{4, 5}) {
+		t.Errorf("Translate then Scale want (1, 1) mapped to (4, 5) got %v", pt)
+	}
+
+	canvas.Restore()
+	if pt = canvas.TotalMatrix().MapXY(1, 1); pt != (Point{3, 4}) {
+		t.Errorf("Restore want (1, 1) mapped to (3, 4) got %v", pt)
+	}
+	var matrix = NewMatrix()
+	matrix.SetScale(3, 3)
+	canvas.SetMatrix(matrix)
+	if pt = canvas.TotalMatrix().MapXY(1, 1); pt != (Point{3, 3}) {
+		t.Errorf("SetMatrix want (1, 1) mapped to (3, 3) got %v", pt)
+	}
+
+	canvas.RestoreToCount(0)
+	if canvas.SaveCount() != 1 || !canvas.TotalMatrix().IsIdentity() {
+		t.Errorf("RestoreToCount want the initial state got a save count of %v", canvas.SaveCount())
+	}
+	canvas.Restore()
+	if canvas.SaveCount() != 1 {
+		t.Errorf("Restore without a save want a save count of 1 got %v", canvas.SaveCount())
+	}
+}
+
+func TestCanvasSaveLayer(t *testing.T) {
+	var red, blue = PackARGB32(0xff, 0xff, 0, 0), PackARGB32(0xff, 0, 0, 0xff)
+	var rect = MakeRect(0, 0, 10, 10)
+	var crop = NewImageFilterCropRect(MakeRect(0, 0, 5, 10), KImageFilterCropEdgeHasAll)
+	var filtered = func(filter *ImageFilter) *Paint {
+		var paint = newTestPaint(KColorRed)
+		paint.SetImageFilter(filter)
+		return paint
+	}
+
+	type tProbe struct {
+		x, y int
+		want uint32
+	}
+	var tests = []struct {
+		name   string
+		draw   func(canvas *Canvas)
+		probes []tProbe
+	}{
+		{"offset image filter", func(canvas *Canvas) {
+			canvas.DrawRect(rect, filtered(NewOffsetImageFilter(5, 5, nil, nil)))
+		}, []tProbe{{2, 2, 0}, {7, 7, red}, {12, 12, red}, {17, 17, 0}}},
+		{"color filter image filter", func(canvas *Canvas) {
+			var filter = NewModeColorFilter(KColorBlue, KXfermodeModeSrcIn)
+			canvas.DrawRect(rect, filtered(NewColorFilterImageFilter(filter, nil, nil)))
+		}, []tProbe{{2, 5, blue}, {7, 5, blue}, {12, 5, 0}}},
+		{"cropped color filter image filter", func(canvas *Canvas) {
+			var filter = NewModeColorFilter(KColorBlue, KXfermodeModeSrcIn)
+			canvas.DrawRect(rect, filtered(NewColorFilterImageFilter(filter, nil, crop)))
+		}, []tProbe{{2, 5, blue}, {7, 5, 0}}},
+		{"layer alpha", func(canvas *Canvas) {
+			canvas.SaveLayerAlpha(nil, 0x80)
+			canvas.DrawRect(rect, newTestPaint(KColorRed))
+			canvas.Restore()
+		}, []tProbe{{5, 5, PackARGB32(0x80, 0x80, 0, 0)}, {12, 12, 0}}},
+		{"layer bounds", func(canvas *Canvas) {
+			var bounds = MakeRect(5, 5, 5, 5)
+			canvas.SaveLayer(&bounds, nil)
+			canvas.DrawRect(MakeRect(0, 0, 20, 20), newTestPaint(KColorRed))
+			canvas.Restore()
+		}, []tProbe{{2, 2, 0}, {5, 5, red}, {9, 9, red}, {12, 12, 0}}},
+		{"layer image filter", func(canvas *Canvas) {
+			var bounds = MakeRect(0, 0, 10, 10)
+			canvas.SaveLayer(&bounds, filtered(NewOffsetImageFilter(5, 5, nil, nil)))
+			canvas.Translate(5, 0)
+			canvas.DrawRect(MakeRect(0, 0, 5, 10), newTestPaint(KColorBlue))
+			canvas.Restore()
+		}, []tProbe{{7, 7, 0}, {12, 12, blue}, {17, 12, 0}}},
+		{"backdrop", func(canvas *Canvas) {
+			canvas.DrawRect(rect, newTestPaint(KColorRed))
+			canvas.SaveLayerWithRec(NewCanvasSaveLayerRec(nil, nil, NewOffsetImageFilter(5, 5, nil, nil), 0))
+			canvas.DrawRect(MakeRect(15, 0, 5, 5), newTestPaint(KColorBlue))
+			canvas.Restore()
+		}, []tProbe{{2, 2, red}, {12, 12, red}, {17, 2, blue}}},
+	}
+	for _, test := range tests {
+		var canvas, pixels = newTestPictureCanvas(20, 20)
+		test.draw(canvas)
+		if canvas.SaveCount() != 1 || !canvas.TotalMatrix().IsIdentity() {
+			t.Errorf("%v want the initial state got a save count of %v", test.name, canvas.SaveCount())
+		}
+		for _, probe := range test.probes {
+			if got := pixels.Pixel32(probe.x, probe.y); got != probe.want {
+				t.Errorf("%v pixel (%v, %v) want %#x got %#x", test.name, probe.x, probe.y, probe.want, got)
+			}
+		}
+	}
+}
+
+func TestCanvasSaveLayerLCDText(t *testing.T) {
+	var paint = newTestPaint(KColorBlack)
+	paint.SetTypeface(newTestTypeface(t))
+	paint.SetTextSize(20)
+	paint.SetHinting(KPaintHintingNo)
+	paint.SetAnitAlias(true)
+	paint.SetLCDRenderText(true)
+
+	var tests = []struct {
+		name      string
+		saveLayer func(canvas *Canvas)
+		lcd       bool
+	}{
+		{"no layer", func(canvas *Canvas) {}, true},
+		{"layer", func(canvas *Canvas) { canvas.SaveLayer(nil, nil) }, false},
+		{"opaque layer", func(canvas *Canvas) {
+			canvas.SaveLayerWithRec(NewCanvasSaveLayerRec(nil, nil, nil, KCanvasSaveLayerFlagIsOpaque))
+		}, true},
+		{"preserve LCD text layer", func(canvas *Canvas) { canvas.SaveLayerPreserveLCDTextRequests(nil, nil) }, true},
+	}
+	for _, test := range tests {
+		var bmp = new(Bitmap)
+		bmp.AllocN32Pixels(20, 20, false)
+		var canvas = NewCanvasBitmapSurfaceProps(bmp, NewSurfacePropsGeometry(0, KPixelGeometryRGBH))
+		test.saveLayer(canvas)
+		// LCD text is only blended against opaque pixels.
+		canvas.DrawRect(MakeRect(0, 0, 20, 20), newTestPaint(KColorWhite))
+		// the edges of the stem of 'l' fall within pixels.
+		canvas.DrawText("l", 0.3, 18, paint)
+		canvas.RestoreToCount(1)
+
+		var pixels = filterPixmap(bmp)
+		var lcd = false
+		for y := 0; y < 20; y++ {
+			for x := 0; x < 20; x++ {
+				var pixel = pixels.Pixel32(x, y)
+				lcd = lcd || GetPackedR32(pixel) != GetPackedG32(pixel) || GetPackedG32(pixel) != GetPackedB32(pixel)
+			}
+		}
+		if lcd != test.lcd {
+			t.Errorf("%v want LCD text %v got %v", test.name, test.lcd, lcd)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package ggk
+
+import "testing"
+
+func TestDrawTextOnPathAndRSXform(t *testing.T) {
+	var red = PackARGB32(0xff, 0xff, 0, 0)
+	var paint = newTestPaint(KColorRed)
+	paint.SetTypeface(newTestTypeface(t))
+	paint.SetTextSize(20)
+	paint.SetHinting(KPaintHintingNo)
+
+	// the stem of 'l' spans 2 to 5.2 along the baseline and 14 above it.
+	var canvas, pixels = newTestPictureCanvas(100, 100)
+	var path = NewPath()
+	path.MoveTo(30, 10)
+	path.LineTo(30, 90)
+	canvas.DrawTextOnPath("l", path, nil, paint)
+
+	// rotated by 90 degrees and moved to (50, 50).
+	canvas.DrawTextRSXform("l", []RSXform{{0, 1, 50, 50}}, RectZero, paint)
+
+	var tests = []struct {
+		name  string
+		point Point
+		want  uint32
+	}{
+		// going down the path, above the baseline is to its right.
+		{"on path", Point{35, 13}, red},
+		{"on path left of the baseline", Point{25, 13}, 0},
+		{"on path past the stem", Point{35, 20}, 0},
+		{"rsxform", Point{57, 53}, red},
+		{"rsxform past the stem", Point{57, 58}, 0},
+		{"rsxform left of the baseline", Point{45, 53}, 0},
+	}
+	for _, test := range tests {
+		if got := pixels.Pixel32(int(test.point.X), int(test.point.Y)); got != test.want {
+			t.Errorf("%v at %v want %#x got %#x", test.name, test.point, test.want, got)
+		}
+	}
+}
@@ -118,14 +118,94 @@ func PremultiplyColor(c Color) (PremulColor, error) {
 	return PremultiplyARGB(a, r, g, b)
 }
 
+// RGB565 pixels pack 5 bits of red, 6 bits of green and 5 bits of blue.
+const (
+	KRGB16ShiftR = 11
+	KRGB16ShiftG = 5
+	KRGB16ShiftB = 0
+)
+
+// PackRGB16 packs r and b, which must be 0..31, and g, which must be 0..63,
+// into an RGB565 pixel.
+func PackRGB16(r, g, b uint32) uint16 {
+	return uint16(r<<KRGB16ShiftR | g<<KRGB16ShiftG | b<<KRGB16ShiftB)
+}
+
+func GetPackedR16(packed16 uint16) uint32 {
+	return uint32(packed16>>KRGB16ShiftR) & 0x1f
+}
+
+func GetPackedG16(packed16 uint16) uint32 {
+	return uint32(packed16>>KRGB16ShiftG) & 0x3f
+}
+
+func GetPackedB16(packed16 uint16) uint32 {
+	return uint32(packed16>>KRGB16ShiftB) & 0x1f
+}
+
+// Pixel32ToPixel16 drops the alpha and the low bits of the components of a
+// 32-bit pixel to make an RGB565 pixel.
 func Pixel32ToPixel16(pixel32 uint32) uint32 {
-	toimpl()
-	return 0
+	return uint32(PackRGB16(GetPackedR32(pixel32)>>3, GetPackedG32(pixel32)>>2, GetPackedB32(pixel32)>>3))
+}
+
+// Pixel16ToPixel32 expands an RGB565 pixel to an opaque 32-bit pixel,
+// repeating the high bits of each component in its low bits.
+func Pixel16ToPixel32(pixel16 uint16) uint32 {
+	var r, g, b = GetPackedR16(pixel16), GetPackedG16(pixel16), GetPackedB16(pixel16)
+	return PackARGB32(0xff, r<<3|r>>2, g<<2|g>>4, b<<3|b>>2)
 }
 
 func GetPackedA32(packed32 uint32) uint32 {
-	toimpl()
-	return 0
+	return (packed32 >> KARGB32ShiftA) & 0xff
+}
+
+func GetPackedR32(packed32 uint32) uint32 {
+	return (packed32 >> KARGB32ShiftR) & 0xff
+}
+
+func GetPackedG32(packed32 uint32) uint32 {
+	return (packed32 >> KARGB32ShiftG) & 0xff
+}
+
+func GetPackedB32(packed32 uint32) uint32 {
+	return (packed32 >> KARGB32ShiftB) & 0xff
+}
+
+// PackARGB32 packs the components, which must be 0..255, into a 32-bit pixel
+// in the configuration dependent order of ARGB32 bitmaps.
+func PackARGB32(a, r, g, b uint32) uint32 {
+	return (a << KARGB32ShiftA) | (r << KARGB32ShiftR) |
+		(g << KARGB32ShiftG) | (b << KARGB32ShiftB)
+}
+
+// Alpha255To256 converts a value in 0..255 to the range 0..256, so that
+// multiplying by it and shifting right by 8 is exact at both ends.
+func Alpha255To256(alpha uint32) uint32 {
+	return alpha + 1
+}
+
+// AlphaMulQ scales each component of the 32-bit pixel c by scale, which is
+// in 0..256.
+func AlphaMulQ(c uint32, scale uint32) uint32 {
+	var mask uint32 = 0xff00ff
+	var rb = ((c & mask) * scale) >> 8
+	var ag = ((c >> 8) & mask) * scale
+	return (rb & mask) | (ag &^ mask)
+}
+
+// PMSrcOver blends the premultiplied pixel src over dst.
+func PMSrcOver(src, dst uint32) uint32 {
+	return src + AlphaMulQ(dst, 256-GetPackedA32(src))
+}
+
+/** Color3f
+is an unpremultiplied color without alpha, or the intensity of a light in
+each channel. */
+type Color3f struct {
+	R float32
+	G float32
+	B float32
 }
 
 type Color4f struct {
@@ -140,11 +220,31 @@ func (color4f Color4f) Equal(other Color4f) bool {
 }
 
 func Color4fFromColor(color Color) Color4f {
-	// toimpl
-	return Color4f{}
+	var a, r, g, b = color.ARGB()
+	return Color4f{float32(r) / 255, float32(g) / 255, float32(b) / 255, float32(a) / 255}
+}
+
+// ToColor returns the color as a Color, with its components pinned to 0..1.
+func (color4f Color4f) ToColor() Color {
+	return ColorWithARGB(uint8(unitToByte(color4f.A)), uint8(unitToByte(color4f.R)), uint8(unitToByte(color4f.G)),
+		uint8(unitToByte(color4f.B)))
+}
+
+// Pin returns the color with its components pinned to 0..1.
+func (color4f Color4f) Pin() Color4f {
+	return Color4f{pinUnit(color4f.R), pinUnit(color4f.G), pinUnit(color4f.B), pinUnit(color4f.A)}
 }
 
 func (color4f Color4f) Premultipy() PM4f {
-	toimpl()
-	return PM4f{}
+	return PM4f{color4f.R * color4f.A, color4f.G * color4f.A, color4f.B * color4f.A, color4f.A}
+}
+
+func pinUnit(c float32) float32 {
+	if c < 0 {
+		return 0
+	}
+	if c > 1 {
+		return 1
+	}
+	return c
 }
\ No newline at end of file
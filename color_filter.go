@@ -1,10 +1,32 @@
 package ggk
 
-type ColorFilterFuncs interface {
-	FilterSpan(src []PremulColor, count int, result []PremulColor)
+/** ColorFilterImpl
+is implemented by each kind of color filter. Both methods must filter the
+same way: OnFilterColor4f one color at a time, and the stages appended by
+OnAppendStages each premultiplied source color of a pipeline. */
+type ColorFilterImpl interface {
+	// Return the unpremultiplied color filtered, pinned to 0..1.
+	OnFilterColor4f(color Color4f) Color4f
+	OnAppendStages(pipeline *RasterPipeline) bool
 }
 
+/** ColorFilter
+is the base class for objects that change the colors of what is drawn,
+after the shader and before the transfer mode. A ColorFilter without an
+Impl leaves colors as they are. */
 type ColorFilter struct {
+	Impl ColorFilterImpl
+}
+
+func NewColorFilter(impl ColorFilterImpl) *ColorFilter {
+	return &ColorFilter{Impl: impl}
+}
+
+/** tComposeColorFilter
+applies inner and then outer. */
+type tComposeColorFilter struct {
+	outer *ColorFilter
+	inner *ColorFilter
 }
 
 /** Construct a colorfilter whose effect is to first apply the inner filter and then apply
@@ -15,15 +37,71 @@ type ColorFilter struct {
  *  always check.
  */
 func NewColorFilterFromComposeFilter(outer, inner *ColorFilter) *ColorFilter {
-	return &ColorFilter{}
+	if outer == nil {
+		return inner
+	}
+	if inner == nil {
+		return outer
+	}
+	return NewColorFilter(&tComposeColorFilter{outer, inner})
+}
+
+func (impl *tComposeColorFilter) OnFilterColor4f(color Color4f) Color4f {
+	return impl.outer.FilterColor4f(impl.inner.FilterColor4f(color))
+}
+
+func (impl *tComposeColorFilter) OnAppendStages(pipeline *RasterPipeline) bool {
+	return impl.inner.AppendStages(pipeline) && impl.outer.AppendStages(pipeline)
 }
 
+// FilterColor returns the unpremultiplied color filtered.
 func (filter *ColorFilter) FilterColor(color Color) Color {
-	toimpl()
-	return KColorBlack
+	if filter.Impl == nil {
+		return color
+	}
+	return filter.FilterColor4f(Color4fFromColor(color)).ToColor()
 }
 
+// FilterColor4f returns the unpremultiplied color filtered.
+func (filter *ColorFilter) FilterColor4f(color Color4f) Color4f {
+	if filter.Impl == nil {
+		return color
+	}
+	return filter.Impl.OnFilterColor4f(color)
+}
+
+/** FilterSpan
+Filter count premultiplied colors of src into result, which may be src. */
+func (filter *ColorFilter) FilterSpan(src []PremulColor, count int, result []PremulColor) {
+	if filter.Impl == nil {
+		copy(result[:count], src[:count])
+		return
+	}
+	for i := 0; i < count; i++ {
+		var color = filter.Impl.OnFilterColor4f(PM4fFromPremulColor(src[i]).Unpremul())
+		result[i] = color.Premultipy().ToPremulColor()
+	}
+}
+
+/** AffectsTransparentBlack
+Return true if the filter turns transparent black into another color, so
+that it draws where nothing was drawn. */
+func (filter *ColorFilter) AffectsTransparentBlack() bool {
+	return filter.FilterColor(KColorTransparent) != KColorTransparent
+}
+
+/** AppendStages
+Append the stages that filter the premultiplied source color of pipeline.
+Returns false if the filter has no stages, in which case pipeline is left
+as it was. */
 func (filter *ColorFilter) AppendStages(pipeline *RasterPipeline) bool {
-	toimpl()
-	return false
-}
\ No newline at end of file
+	if filter.Impl == nil {
+		return true
+	}
+	var stages RasterPipeline
+	if !filter.Impl.OnAppendStages(&stages) {
+		return false
+	}
+	pipeline.Extend(&stages)
+	return true
+}
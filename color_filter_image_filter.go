@@ -0,0 +1,68 @@
+package ggk
+
+/** tColorFilterImageFilter
+is the ImageFilterImpl that filters the colors of its input with a
+ColorFilter. */
+type tColorFilterImageFilter struct {
+	colorFilter *ColorFilter
+}
+
+// NewColorFilterImageFilter returns a filter that filters the colors of
+// input, or of the source if input is nil, with colorFilter. Returns nil if
+// colorFilter is nil.
+func NewColorFilterImageFilter(colorFilter *ColorFilter, input *ImageFilter, cropRect *ImageFilterCropRect) *ImageFilter {
+	if colorFilter == nil {
+		return nil
+	}
+	return NewImageFilter(&tColorFilterImageFilter{colorFilter}, []*ImageFilter{input}, cropRect)
+}
+
+func (impl *tColorFilterImageFilter) OnFilterImage(filter *ImageFilter, src *Bitmap, ctx *ImageFilterContext) (*Bitmap, Point, bool) {
+	var input, inputOffset, ok = filter.filterInput(0, src, ctx)
+	if !ok {
+		return nil, PointZero, false
+	}
+	var bounds = filterBitmapBounds(input, inputOffset)
+	if impl.OnAffectsTransparentBlack() {
+		// transparent pixels change too, everywhere the result is needed.
+		bounds = ctx.ClipBounds
+	}
+	var dstBounds Rect
+	if dstBounds, ok = filter.applyCropRect(ctx, bounds); !ok {
+		return nil, PointZero, false
+	}
+
+	var dst = copyFilterBitmap(input, inputOffset, dstBounds)
+	var pixels = filterPixmap(dst)
+	var width = int(dstBounds.Width)
+	var span = make([]PremulColor, width)
+	for y := 0; y < int(dstBounds.Height); y++ {
+		for x := range span {
+			span[x] = PremulColor(pixels.Pixel32(x, y))
+		}
+		impl.colorFilter.FilterSpan(span, width, span)
+		for x := range span {
+			pixels.SetPixel32(x, y, uint32(span[x]))
+		}
+	}
+	return dst, Point{dstBounds.Left, dstBounds.Top}, true
+}
+
+func (impl *tColorFilterImageFilter) OnFilterBounds(filter *ImageFilter, src Rect, ctm *Matrix, direction ImageFilterMapDirection) Rect {
+	return filter.filterBoundsWithNode(src, ctm, direction, func(r Rect) Rect { return r })
+}
+
+func (impl *tColorFilterImageFilter) OnComputeFastBounds(filter *ImageFilter, src Rect) Rect {
+	return filter.inputsFastBounds(src)
+}
+
+func (impl *tColorFilterImageFilter) OnAffectsTransparentBlack() bool {
+	return impl.colorFilter.AffectsTransparentBlack()
+}
+
+func (impl *tColorFilterImageFilter) OnAsAColorFilter(filter *ImageFilter) (*ColorFilter, bool) {
+	if filter.Input(0) != nil {
+		return nil, false
+	}
+	return impl.colorFilter, true
+}
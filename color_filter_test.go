@@ -0,0 +1,180 @@
+package ggk
+
+import (
+	"math"
+	"testing"
+)
+
+func nearColor4f(a, b Color4f, tolerance float32) bool {
+	var near = func(x, y float32) bool { return math.Abs(float64(x-y)) <= float64(tolerance) }
+	return near(a.R, b.R) && near(a.G, b.G) && near(a.B, b.B) && near(a.A, b.A)
+}
+
+// Return color filtered by the pipeline stages of filter.
+func filterColorByStages(filter *ColorFilter, color Color4f) (Color4f, bool) {
+	var pipeline RasterPipeline
+	if !filter.AppendStages(&pipeline) {
+		return Color4f{}, false
+	}
+	var pixels = make([]RasterPipelinePixel, 5)
+	for i := range pixels {
+		pixels[i].SetSrc(color.Premultipy())
+	}
+	pipeline.Run(pixels)
+	return pixels[4].Src().Unpremul(), pixels[4] == pixels[0]
+}
+
+func TestColorFilters(t *testing.T) {
+	var invert [256]uint8
+	for i := range invert {
+		invert[i] = uint8(255 - i)
+	}
+	var saturation, hue, gray, scale = NewColorMatrix(), NewColorMatrix(), NewColorMatrix(), NewColorMatrix()
+	saturation.SetSaturation(2)
+	hue.SetHueRotate(120)
+	gray.SetGrayscale()
+	scale.SetScale(0.5, 1, 1, 1)
+	var grayThenScale = NewColorMatrix()
+	grayThenScale.SetConcat(scale, gray)
+
+	var red = Color4fFromColor(KColorRed)
+	var halfRed = Color4f{1, 0, 0, 0.5}
+	var tests = []struct {
+		name   string
+		filter *ColorFilter
+		color  Color4f
+		want   Color4f
+	}{
+		{"identity", NewColorMatrixFilter(NewColorMatrix()), halfRed, halfRed},
+		{"grayscale", NewColorMatrixFilter(gray), red, Color4f{0.213, 0.213, 0.213, 1}},
+		{"saturate", NewColorMatrixFilter(saturation), Color4f{0.6, 0.5, 0.5, 1}, Color4f{0.6787, 0.4787, 0.4787, 1}},
+		{"hue rotate", NewColorMatrixFilter(hue), Color4f{0.5, 0.5, 0.5, 1}, Color4f{0.5, 0.5, 0.5, 1}},
+		{"concat", NewColorMatrixFilter(grayThenScale), red, Color4f{0.1065, 0.213, 0.213, 1}},
+		{"translate", NewColorMatrixFilterRowMajor255([20]Scalar{1, 0, 0, 0, 0, 0, 1, 0, 0, 51, 0, 0, 1, 0, 0, 0, 0, 0, 1, 0}),
+			halfRed, Color4f{1, 0.2, 0, 0.5}},
+		{"lighting", NewLightingColorFilter(ColorWithRGB(0x80, 0xff, 0xff), ColorWithARGB(0, 0, 0, 0x33)), halfRed,
+			Color4f{0x80 / 255.0, 0, 0.2, 0.5}},
+		{"table", NewTableARGBColorFilter(nil, &invert, nil, nil), halfRed, Color4f{0, 0, 0, 0.5}},
+		{"table all", NewTableColorFilter(&invert), Color4f{0.2, 1, 0, 0.8}, Color4f{0.8, 0, 1, 0.2}},
+		{"mode src in", NewModeColorFilter(KColorBlue, KXfermodeModeSrcIn), halfRed, Color4f{0, 0, 1, 0.5}},
+		{"mode src over", NewModeColorFilter(ColorWithARGB(0x80, 0, 0, 0xff), KXfermodeModeSrcOver), red,
+			Color4f{127 / 255.0, 0, 128 / 255.0, 1}},
+		{"mode multiply", NewModeColorFilter(ColorWithRGB(0x80, 0x80, 0x80), KXfermodeModeMultiply), red,
+			Color4f{128 / 255.0, 0, 0, 1}},
+		{"mode clear", NewModeColorFilter(KColorRed, KXfermodeModeClear), red, Color4f{}},
+		{"compose", NewColorFilterFromComposeFilter(NewColorMatrixFilter(gray), NewTableARGBColorFilter(nil, &invert, nil, nil)),
+			Color4f{1, 1, 1, 1}, Color4f{0.787, 0.787, 0.787, 1}},
+	}
+	for _, test := range tests {
+		if got := test.filter.FilterColor4f(test.color); !nearColor4f(got, test.want, 0.002) {
+			t.Errorf("%v FilterColor4f(%v) want %v got %v", test.name, test.color, test.want, got)
+		}
+		var got, tailMatches = filterColorByStages(test.filter, test.color)
+		if !nearColor4f(got, test.want, 0.002) || !tailMatches {
+			t.Errorf("%v stages of %v want %v got %v", test.name, test.color, test.want, got)
+		}
+	}
+
+	var nilFilters = []*ColorFilter{
+		NewModeColorFilter(KColorRed, KXfermodeModeDst),
+		NewModeColorFilter(KColorTransparent, KXfermodeModeSrcOver),
+		NewModeColorFilter(KColorBlue, KXfermodeModeDstIn),
+		NewTableARGBColorFilter(nil, nil, nil, nil),
+	}
+	for i, filter := range nilFilters {
+		if filter != nil {
+			t.Errorf("filter %v leaving colors as they are want nil", i)
+		}
+	}
+}
+
+func TestColorFilterColors(t *testing.T) {
+	var gray = NewColorMatrix()
+	gray.SetGrayscale()
+	var filter = NewColorMatrixFilter(gray)
+	if got := filter.FilterColor(KColorWhite); got != KColorWhite {
+		t.Errorf("FilterColor(white) want white got %#x", got)
+	}
+	if filter.AffectsTransparentBlack() || !NewModeColorFilter(KColorRed, KXfermodeModeSrc).AffectsTransparentBlack() {
+		t.Errorf("AffectsTransparentBlack() want false for a matrix and true for a source color")
+	}
+
+	var src = []PremulColor{
+		PremulColor(PackARGB32(0xff, 0xff, 0, 0)),
+		PremulColor(PackARGB32(0x80, 0, 0x80, 0)),
+		0,
+	}
+	var result = make([]PremulColor, len(src))
+	NewModeColorFilter(KColorBlue, KXfermodeModeSrcIn).FilterSpan(src, len(src), result)
+	var want = []PremulColor{
+		PremulColor(PackARGB32(0xff, 0, 0, 0xff)),
+		PremulColor(PackARGB32(0x80, 0, 0, 0x80)),
+		0,
+	}
+	for i := range want {
+		if result[i] != want[i] {
+			t.Errorf("FilterSpan()[%v] want %#x got %#x", i, want[i], result[i])
+		}
+	}
+
+	// a filter without an Impl leaves colors as they are.
+	var none = &ColorFilter{}
+	none.FilterSpan(src, len(src), result)
+	if result[1] != src[1] || none.FilterColor(KColorRed) != KColorRed {
+		t.Errorf("an empty filter want colors unchanged")
+	}
+}
+
+func TestBlendPM4f(t *testing.T) {
+	var half = PM4f{0.5, 0.5, 0.5, 1}
+	var quarter = PM4f{0.25, 0.25, 0.25, 1}
+	var red, green = PM4f{1, 0, 0, 1}, PM4f{0, 0.5, 0, 0.5}
+	var tests = []struct {
+		mode XfermodeMode
+		s, d PM4f
+		want PM4f
+	}{
+		{KXfermodeModeClear, red, green, PM4f{}},
+		{KXfermodeModeSrc, red, green, red},
+		{KXfermodeModeDst, red, green, green},
+		{KXfermodeModeSrcOver, green, red, PM4f{0.5, 0.5, 0, 1}},
+		{KXfermodeModeDstOver, red, green, PM4f{0.5, 0.5, 0, 1}},
+		{KXfermodeModeSrcIn, red, green, PM4f{0.5, 0, 0, 0.5}},
+		{KXfermodeModeDstOut, green, red, PM4f{0.5, 0, 0, 0.5}},
+		{KXfermodeModeSrcATop, green, red, PM4f{0.5, 0.5, 0, 1}},
+		{KXfermodeModeXor, red, green, PM4f{0.5, 0, 0, 0.5}},
+		{KXfermodeModePlus, red, red, red},
+		{KXfermodeModeModulate, half, half, PM4f{0.25, 0.25, 0.25, 1}},
+		{KXfermodeModeScreen, half, half, PM4f{0.75, 0.75, 0.75, 1}},
+		{KXfermodeModeMultiply, half, half, PM4f{0.25, 0.25, 0.25, 1}},
+		{KXfermodeModeDarken, half, quarter, quarter},
+		{KXfermodeModeLighten, half, quarter, half},
+		{KXfermodeModeDifference, half, quarter, quarter},
+		{KXfermodeModeExclusion, half, half, half},
+		{KXfermodeModeOverlay, half, half, half},
+		{KXfermodeModeHardLight, quarter, half, quarter},
+		{KXfermodeModeColorDodge, half, quarter, half},
+		{KXfermodeModeColorBurn, half, half, PM4f{0, 0, 0, 1}},
+		{KXfermodeModeSoftLight, half, quarter, quarter},
+		{KXfermodeModeLuminosity, half, red, PM4f{1, 0.2857, 0.2857, 1}},
+		{KXfermodeModeColor, red, half, PM4f{1, 0.2857, 0.2857, 1}},
+		{KXfermodeModeSaturation, half, red, PM4f{0.3, 0.3, 0.3, 1}},
+		{KXfermodeModeHue, PM4f{0, 1, 0, 1}, red, PM4f{0, 0.5085, 0, 1}},
+	}
+	for _, test := range tests {
+		var got = blendPM4f(test.mode, test.s, test.d)
+		if !nearColor4f(Color4f(got), Color4f(test.want), 0.001) {
+			t.Errorf("blendPM4f(%v, %v, %v) want %v got %v", test.mode, test.s, test.d, test.want, got)
+		}
+	}
+}
+
+func TestDrawWithColorFilter(t *testing.T) {
+	var canvas, pixels = newTestPictureCanvas(4, 4)
+	var paint = newTestPaint(KColorRed)
+	paint.SetColorFilter(NewModeColorFilter(KColorBlue, KXfermodeModeSrcIn))
+	canvas.DrawRect(MakeRect(0, 0, 4, 4), paint)
+	if got, want := pixels.Pixel32(1, 1), PackARGB32(0xff, 0, 0, 0xff); got != want {
+		t.Errorf("DrawRect() with a color filter want %#x got %#x", want, got)
+	}
+}
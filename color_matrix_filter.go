@@ -0,0 +1,173 @@
+package ggk
+
+import "math"
+
+/** ColorMatrix
+is a 4x5 matrix, in row major order, that transforms an unpremultiplied
+color. Each row computes one of R, G, B and A from the components of the
+color and its fifth column, which is added in 0..255 units.
+
+	R' = Mat[0]*R + Mat[1]*G + Mat[2]*B + Mat[3]*A + Mat[4]
+	G' = Mat[5]*R + Mat[6]*G + Mat[7]*B + Mat[8]*A + Mat[9]
+	... */
+type ColorMatrix struct {
+	Mat [20]Scalar
+}
+
+// NewColorMatrix returns the identity color matrix.
+func NewColorMatrix() *ColorMatrix {
+	var matrix = new(ColorMatrix)
+	matrix.SetIdentity()
+	return matrix
+}
+
+// SetIdentity sets the matrix to leave colors as they are.
+func (matrix *ColorMatrix) SetIdentity() {
+	matrix.SetScale(1, 1, 1, 1)
+}
+
+// SetScale sets the matrix to scale each component.
+func (matrix *ColorMatrix) SetScale(rScale, gScale, bScale, aScale Scalar) {
+	matrix.Mat = [20]Scalar{}
+	matrix.Mat[0], matrix.Mat[6], matrix.Mat[12], matrix.Mat[18] = rScale, gScale, bScale, aScale
+}
+
+// The weights of the components of the luminance of a color.
+const (
+	kColorMatrixLumR = 0.213
+	kColorMatrixLumG = 0.715
+	kColorMatrixLumB = 0.072
+)
+
+/** SetSaturation
+Set the matrix to change the saturation of colors by sat, where 0 makes
+them gray, 1 leaves them as they are and more saturates them. */
+func (matrix *ColorMatrix) SetSaturation(sat Scalar) {
+	var r, g, b = kColorMatrixLumR * (1 - sat), kColorMatrixLumG * (1 - sat), kColorMatrixLumB * (1 - sat)
+	matrix.Mat = [20]Scalar{
+		r + sat, g, b, 0, 0,
+		r, g + sat, b, 0, 0,
+		r, g, b + sat, 0, 0,
+		0, 0, 0, 1, 0,
+	}
+}
+
+// SetGrayscale sets the matrix to turn colors into the gray of their
+// luminance.
+func (matrix *ColorMatrix) SetGrayscale() {
+	matrix.SetSaturation(0)
+}
+
+/** SetHueRotate
+Set the matrix to rotate the hue of colors by degrees, keeping their
+luminance. */
+func (matrix *ColorMatrix) SetHueRotate(degrees Scalar) {
+	var radians = float64(degrees) * math.Pi / 180
+	var c, s = Scalar(math.Cos(radians)), Scalar(math.Sin(radians))
+	matrix.Mat = [20]Scalar{
+		0.213 + c*0.787 - s*0.213, 0.715 - c*0.715 - s*0.715, 0.072 - c*0.072 + s*0.928, 0, 0,
+		0.213 - c*0.213 + s*0.143, 0.715 + c*0.285 + s*0.140, 0.072 - c*0.072 - s*0.283, 0, 0,
+		0.213 - c*0.213 - s*0.787, 0.715 - c*0.715 + s*0.715, 0.072 + c*0.928 + s*0.072, 0, 0,
+		0, 0, 0, 1, 0,
+	}
+}
+
+/** SetConcat
+Set the matrix to a * b, which transforms colors by b and then by a. The
+matrix may be a or b. */
+func (matrix *ColorMatrix) SetConcat(a, b *ColorMatrix) {
+	var result [20]Scalar
+	for j := 0; j < 20; j += 5 {
+		for i := 0; i < 4; i++ {
+			result[j+i] = a.Mat[j]*b.Mat[i] + a.Mat[j+1]*b.Mat[i+5] + a.Mat[j+2]*b.Mat[i+10] + a.Mat[j+3]*b.Mat[i+15]
+		}
+		result[j+4] = a.Mat[j]*b.Mat[4] + a.Mat[j+1]*b.Mat[9] + a.Mat[j+2]*b.Mat[14] + a.Mat[j+3]*b.Mat[19] + a.Mat[j+4]
+	}
+	matrix.Mat = result
+}
+
+// PreConcat sets the matrix to matrix * other, which transforms by other
+// first.
+func (matrix *ColorMatrix) PreConcat(other *ColorMatrix) {
+	matrix.SetConcat(matrix, other)
+}
+
+// PostConcat sets the matrix to other * matrix, which transforms by other
+// last.
+func (matrix *ColorMatrix) PostConcat(other *ColorMatrix) {
+	matrix.SetConcat(other, matrix)
+}
+
+/** tColorMatrixFilter
+transforms unpremultiplied colors by a color matrix, whose translations
+are kept in 0..1 units. */
+type tColorMatrixFilter struct {
+	mat [20]float32
+}
+
+// NewColorMatrixFilter returns a filter transforming colors by matrix.
+func NewColorMatrixFilter(matrix *ColorMatrix) *ColorFilter {
+	return NewColorMatrixFilterRowMajor255(matrix.Mat)
+}
+
+/** NewColorMatrixFilterRowMajor255
+Return a filter transforming colors by the 4x5 matrix mat, in row major
+order with its fifth column in 0..255 units, like the Mat of a
+ColorMatrix. */
+func NewColorMatrixFilterRowMajor255(mat [20]Scalar) *ColorFilter {
+	var impl = new(tColorMatrixFilter)
+	for i, v := range mat {
+		impl.mat[i] = float32(v)
+		if i%5 == 4 {
+			impl.mat[i] /= 255
+		}
+	}
+	return NewColorFilter(impl)
+}
+
+/** NewLightingColorFilter
+Return a filter that multiplies the color components by those of mul and
+adds those of add, both read as 0..1, leaving alpha as it is. */
+func NewLightingColorFilter(mul, add Color) *ColorFilter {
+	var m = Color4fFromColor(mul)
+	return NewColorMatrixFilterRowMajor255([20]Scalar{
+		Scalar(m.R), 0, 0, 0, Scalar(add.Red()),
+		0, Scalar(m.G), 0, 0, Scalar(add.Green()),
+		0, 0, Scalar(m.B), 0, Scalar(add.Blue()),
+		0, 0, 0, 1, 0,
+	})
+}
+
+func (impl *tColorMatrixFilter) OnFilterColor4f(color Color4f) Color4f {
+	return impl.transform(color).Pin()
+}
+
+// Return the color transformed by the matrix.
+func (impl *tColorMatrixFilter) transform(c Color4f) Color4f {
+	var m = &impl.mat
+	return Color4f{
+		m[0]*c.R + m[1]*c.G + m[2]*c.B + m[3]*c.A + m[4],
+		m[5]*c.R + m[6]*c.G + m[7]*c.B + m[8]*c.A + m[9],
+		m[10]*c.R + m[11]*c.G + m[12]*c.B + m[13]*c.A + m[14],
+		m[15]*c.R + m[16]*c.G + m[17]*c.B + m[18]*c.A + m[19],
+	}
+}
+
+func (impl *tColorMatrixFilter) OnAppendStages(pipeline *RasterPipeline) bool {
+	pipeline.AppendStage(unpremul, nil)
+	pipeline.AppendStage(colorMatrixStage, impl)
+	pipeline.AppendStage(clamp01, nil)
+	pipeline.AppendStage(premul, nil)
+	return true
+}
+
+// tColorMatrixStage transforms the unpremultiplied source color by its
+// *tColorMatrixFilter context.
+type tColorMatrixStage struct{}
+
+var colorMatrixStage = &tColorMatrixStage{}
+
+func (fn *tColorMatrixStage) Run(pixel *RasterPipelinePixel, context interface{}) {
+	var color = context.(*tColorMatrixFilter).transform(Color4f{pixel.R, pixel.G, pixel.B, pixel.A})
+	pixel.R, pixel.G, pixel.B, pixel.A = color.R, color.G, color.B, color.A
+}
@@ -0,0 +1,50 @@
+package ggk
+
+/** tColorShader
+shades everything with a color. */
+type tColorShader struct {
+	color Color
+}
+
+func (impl *tColorShader) OnCreateContext(shader *Shader, rec *ShaderContextRec) *ShaderContext {
+	// the paint's alpha scales the color's.
+	var color = impl.color
+	color.SetAlpha(MulDiv255Round(color.Alpha(), rec.Paint.Alpha()))
+	var pmcolor = PremulColor(premulPixel32(color))
+	return NewShaderContext(shader, rec, &tColorShaderContext{pmcolor})
+}
+
+type tColorShaderContext struct {
+	pmcolor PremulColor
+}
+
+func (impl *tColorShaderContext) OnShadeSpan(context *ShaderContext, x, y int, dst []PremulColor, count int) {
+	for i := 0; i < count; i++ {
+		dst[i] = impl.pmcolor
+	}
+}
+
+/** tColorFilterShader
+filters the colors of a shader with a color filter. */
+type tColorFilterShader struct {
+	shader *Shader
+	filter *ColorFilter
+}
+
+func (impl *tColorFilterShader) OnCreateContext(shader *Shader, rec *ShaderContextRec) *ShaderContext {
+	var inner = impl.shader.CreateContext(rec)
+	if inner == nil {
+		return nil
+	}
+	return NewShaderContext(shader, rec, &tColorFilterShaderContext{inner, impl.filter})
+}
+
+type tColorFilterShaderContext struct {
+	inner  *ShaderContext
+	filter *ColorFilter
+}
+
+func (impl *tColorFilterShaderContext) OnShadeSpan(context *ShaderContext, x, y int, dst []PremulColor, count int) {
+	impl.inner.ShadeSpan(x, y, dst, count)
+	impl.filter.FilterSpan(dst, count, dst)
+}
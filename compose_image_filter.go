@@ -0,0 +1,51 @@
+package ggk
+
+/** tComposeImageFilter
+is the ImageFilterImpl that applies its first input, the outer filter, to
+the result of its second, the inner filter. */
+type tComposeImageFilter struct {
+}
+
+// NewComposeImageFilter returns a filter that applies outer to the result
+// of inner. If either is nil the other is returned.
+func NewComposeImageFilter(outer, inner *ImageFilter) *ImageFilter {
+	if outer == nil {
+		return inner
+	}
+	if inner == nil {
+		return outer
+	}
+	return NewImageFilter(&tComposeImageFilter{}, []*ImageFilter{outer, inner}, nil)
+}
+
+func (impl *tComposeImageFilter) OnFilterImage(filter *ImageFilter, src *Bitmap, ctx *ImageFilterContext) (*Bitmap, Point, bool) {
+	var inner, innerOffset, ok = filter.filterInput(1, src, ctx)
+	if !ok {
+		return nil, PointZero, false
+	}
+	var outer, outerOffset, outerOK = filter.Input(0).FilterImage(inner, ctx.offset(innerOffset))
+	if !outerOK {
+		return nil, PointZero, false
+	}
+	return outer, Point{innerOffset.X + outerOffset.X, innerOffset.Y + outerOffset.Y}, true
+}
+
+func (impl *tComposeImageFilter) OnFilterBounds(filter *ImageFilter, src Rect, ctm *Matrix, direction ImageFilterMapDirection) Rect {
+	var outer, inner = filter.Input(0), filter.Input(1)
+	if direction == KImageFilterMapDirectionReverse {
+		return inner.FilterBounds(outer.FilterBounds(src, ctm, direction), ctm, direction)
+	}
+	return outer.FilterBounds(inner.FilterBounds(src, ctm, direction), ctm, direction)
+}
+
+func (impl *tComposeImageFilter) OnComputeFastBounds(filter *ImageFilter, src Rect) Rect {
+	return filter.Input(0).ComputeFastBounds(filter.Input(1).ComputeFastBounds(src))
+}
+
+func (impl *tComposeImageFilter) OnAffectsTransparentBlack() bool {
+	return false
+}
+
+func (impl *tComposeImageFilter) OnAsAColorFilter(filter *ImageFilter) (*ColorFilter, bool) {
+	return nil, false
+}
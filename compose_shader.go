@@ -0,0 +1,69 @@
+package ggk
+
+/** tComposeShader
+blends the colors of the src shader over those of the dst shader. */
+type tComposeShader struct {
+	dst  *Shader
+	src  *Shader
+	mode XfermodeMode
+}
+
+/** NewComposeShader
+Return a shader blending, for each pixel, the color of src with the color
+of dst through mode, the way a paint with the xfermode mode draws src on
+top of dst. */
+func NewComposeShader(dst, src *Shader, mode XfermodeMode) *Shader {
+	switch mode {
+	case KXfermodeModeClear:
+		return NewShader_Color(KColorTransparent)
+	case KXfermodeModeSrc:
+		return src
+	case KXfermodeModeDst:
+		return dst
+	}
+	return NewShader(&tComposeShader{dst, src, mode}, nil)
+}
+
+func (impl *tComposeShader) OnCreateContext(shader *Shader, rec *ShaderContextRec) *ShaderContext {
+	// the children shade opaque, the paint's alpha scales the blended colors.
+	var paint = NewPaint_Clone(rec.Paint)
+	paint.SetAlpha(0xff)
+	var childRec = NewShaderContextRec(paint, rec.Matrix, rec.LocalMatrix, rec.PreferredDstType)
+	var localMatrix = NewMatrixClone(shader.LocalMatrix())
+	if rec.LocalMatrix != nil {
+		localMatrix.PreConcat(rec.LocalMatrix)
+	}
+	childRec.LocalMatrix = localMatrix
+
+	var dst, src = impl.dst.CreateContext(childRec), impl.src.CreateContext(childRec)
+	if dst == nil || src == nil {
+		return nil
+	}
+	return NewShaderContext(shader, rec, &tComposeShaderContext{dst: dst, src: src, mode: impl.mode})
+}
+
+type tComposeShaderContext struct {
+	dst  *ShaderContext
+	src  *ShaderContext
+	mode XfermodeMode
+	span []PremulColor
+}
+
+func (impl *tComposeShaderContext) OnShadeSpan(context *ShaderContext, x, y int, dst []PremulColor, count int) {
+	if len(impl.span) < count {
+		impl.span = make([]PremulColor, count)
+	}
+	var src = impl.span[:count]
+	impl.dst.ShadeSpan(x, y, dst, count)
+	impl.src.ShadeSpan(x, y, src, count)
+
+	var scale = Alpha255To256(uint32(context.PaintAlpha()))
+	for i := 0; i < count; i++ {
+		var s, d = PM4fFromPremulColor(src[i]), PM4fFromPremulColor(dst[i])
+		var color = uint32(blendPM4f(impl.mode, s, d).ToPremulColor())
+		if scale != 256 {
+			color = AlphaMulQ(color, scale)
+		}
+		dst[i] = PremulColor(color)
+	}
+}
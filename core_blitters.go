@@ -2,5 +2,8 @@ package ggk
 
 /** Returns nullptr if no SkRasterPipeline blitter can be constructed for this paint. */
 func CreateRasterPipelineBlitter(dst *Pixmap, paint *Paint) Blitter {
-	return NewRasterPipelineBlitter(dst, paint)
+	if blitter := NewRasterPipelineBlitter(dst, paint); blitter != nil {
+		return blitter
+	}
+	return nil
 }
@@ -0,0 +1,109 @@
+package ggk
+
+/** tCornerPathEffect
+rounds the corners between the lines of a path with quads of radius. */
+type tCornerPathEffect struct {
+	radius Scalar
+}
+
+/** NewCornerPathEffect
+Return an effect rounding the sharp corners between lines of a path, with
+curves reaching radius along each line. Returns nil if radius is not
+positive. */
+func NewCornerPathEffect(radius Scalar) *PathEffect {
+	if radius <= 0 {
+		return nil
+	}
+	return NewPathEffect(&tCornerPathEffect{radius})
+}
+
+/** computeCornerStep
+Return the step from a towards b where the curve of a corner at a ends:
+radius along the line, or half of it when the line is too short to hold
+two curves, in which case ok is false since no line is left between them. */
+func computeCornerStep(a, b Point, radius Scalar) (step Point, ok bool) {
+	var dist = PointDistance(a, b)
+	step = Point{b.X - a.X, b.Y - a.Y}
+	if dist <= radius*2 {
+		step.Scale(KScalarHalf)
+		return step, false
+	}
+	step.Scale(radius / dist)
+	return step, true
+}
+
+func (impl *tCornerPathEffect) OnFilterPath(dst, src *Path, rec *StrokeRec, cullRect *Rect) bool {
+	var iter = NewPathIter(src, false)
+	var pts [4]Point
+	var prevVerb = PathVerb(-1)
+	var moveTo, lastCorner, firstStep, step Point
+	var prevIsValid = true
+	for {
+		var verb = iter.Next(pts[:])
+		switch verb {
+		case KPathVerbMove:
+			// close out the previous open contour.
+			if prevVerb == KPathVerbLine {
+				dst.LineTo(lastCorner.X, lastCorner.Y)
+			}
+			if iter.IsClosedContour() {
+				moveTo = pts[0]
+				prevIsValid = false
+			} else {
+				dst.MoveTo(pts[0].X, pts[0].Y)
+				prevIsValid = true
+			}
+
+		case KPathVerbLine:
+			var drawSegment bool
+			step, drawSegment = computeCornerStep(pts[0], pts[1], impl.radius)
+			if !prevIsValid {
+				dst.MoveTo(moveTo.X+step.X, moveTo.Y+step.Y)
+			} else {
+				dst.QuadTo(pts[0].X, pts[0].Y, pts[0].X+step.X, pts[0].Y+step.Y)
+			}
+			if drawSegment {
+				dst.LineTo(pts[1].X-step.X, pts[1].Y-step.Y)
+			}
+			lastCorner = pts[1]
+			prevIsValid = true
+
+		case KPathVerbQuad:
+			// curves are kept as they are.
+			if !prevIsValid {
+				dst.MoveTo(pts[0].X, pts[0].Y)
+				prevIsValid = true
+			}
+			dst.QuadTo(pts[1].X, pts[1].Y, pts[2].X, pts[2].Y)
+			lastCorner = pts[2]
+			firstStep = Point{}
+
+		case KPathVerbCubic:
+			if !prevIsValid {
+				dst.MoveTo(pts[0].X, pts[0].Y)
+				prevIsValid = true
+			}
+			dst.CubicTo(pts[1].X, pts[1].Y, pts[2].X, pts[2].Y, pts[3].X, pts[3].Y)
+			lastCorner = pts[3]
+			firstStep = Point{}
+
+		case KPathVerbClose:
+			if !firstStep.IsZero() {
+				dst.QuadTo(lastCorner.X, lastCorner.Y, lastCorner.X+firstStep.X, lastCorner.Y+firstStep.Y)
+			}
+			dst.Close()
+			prevIsValid = false
+
+		case KPathVerbDone:
+			if prevIsValid {
+				dst.LineTo(lastCorner.X, lastCorner.Y)
+			}
+			return true
+		}
+
+		if prevVerb == KPathVerbMove {
+			firstStep = step
+		}
+		prevVerb = verb
+	}
+}
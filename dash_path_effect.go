@@ -0,0 +1,129 @@
+package ggk
+
+// Contours taking more steps than this through the intervals are not
+// dashed, so tiny intervals can't take unbounded time and memory.
+const kDashMaxCount = 1000000
+
+/** tDashPathEffect
+draws the contours of a path as dashes. The even intervals are the
+lengths of the dashes and the odd ones of the gaps between them. */
+type tDashPathEffect struct {
+	intervals         []Scalar
+	phase             Scalar
+	intervalLength    Scalar
+	initialDashIndex  int
+	initialDashLength Scalar
+}
+
+/** NewDashPathEffect
+Return an effect dashing contours with intervals, which holds an even
+number, at least 2, of lengths: the on intervals at even indices and the
+off intervals at odd ones. phase is the distance into the intervals where
+each contour starts, so that a phase of intervals[0] starts it with the
+first gap. Returns nil if an interval is negative or they sum to 0.
+
+	// a dash of 10, a gap of 5 and again.
+	NewDashPathEffect([]Scalar{10, 5}, 0) */
+func NewDashPathEffect(intervals []Scalar, phase Scalar) *PathEffect {
+	var count = len(intervals)
+	if count < 2 || count&1 != 0 {
+		return nil
+	}
+	var impl = &tDashPathEffect{intervals: append([]Scalar(nil), intervals...)}
+	for _, interval := range intervals {
+		if interval < 0 {
+			return nil
+		}
+		impl.intervalLength += interval
+	}
+	if impl.intervalLength <= 0 || !ScalarIsFinite(phase) {
+		return nil
+	}
+
+	// wrap phase into the intervals, where a negative phase counts back
+	// from the end.
+	var length = impl.intervalLength
+	if phase < 0 {
+		phase = -phase
+		if phase > length {
+			phase = ScalarMod(phase, length)
+		}
+		phase = length - phase
+		if phase == length {
+			phase = 0
+		}
+	} else if phase >= length {
+		phase = ScalarMod(phase, length)
+	}
+	impl.phase = phase
+
+	// find the interval the phase falls in.
+	for i, gap := range intervals {
+		if phase > gap || (phase == gap && gap != 0) {
+			phase -= gap
+			continue
+		}
+		impl.initialDashIndex, impl.initialDashLength = i, gap-phase
+		return NewPathEffect(impl)
+	}
+	// the phase was at the very end.
+	impl.initialDashIndex, impl.initialDashLength = 0, intervals[0]
+	return NewPathEffect(impl)
+}
+
+/** OnFilterPath
+Dash each contour of src, continuing the dashes of an open contour past
+its end into the next one from the start of the intervals. The dash a
+closed contour ends in is joined to the one it starts with. Paths drawn
+filled are not dashed, nor are paths with a contour that would take more
+than kDashMaxCount dashes and gaps, in which case dst is reset. */
+func (impl *tDashPathEffect) OnFilterPath(dst, src *Path, rec *StrokeRec, cullRect *Rect) bool {
+	if rec.Style() == KStrokeRecStyleFill || rec.Style() == KStrokeRecStyleStrokeAndFill {
+		return false
+	}
+	var count = len(impl.intervals)
+	var measure = NewPathMeasure(src, false, 1)
+	for {
+		var length = measure.Length()
+		if length/impl.intervalLength*Scalar(count) > kDashMaxCount {
+			dst.Reset()
+			return false
+		}
+		var skipFirstSegment = measure.IsClosed()
+		var addedSegment = false
+		var distance Scalar
+		var index, dashLength = impl.initialDashIndex, impl.initialDashLength
+		for distance < length {
+			addedSegment = false
+			if index&1 == 0 && !skipFirstSegment {
+				addedSegment = true
+				measure.Segment(distance, distance+dashLength, dst, true)
+			}
+			distance += dashLength
+			skipFirstSegment = false
+			if index++; index == count {
+				index = 0
+			}
+			dashLength = impl.intervals[index]
+		}
+
+		// join the dash the contour ends in with the one skipped at its start.
+		if measure.IsClosed() && impl.initialDashIndex&1 == 0 && impl.initialDashLength >= 0 {
+			measure.Segment(0, impl.initialDashLength, dst, !addedSegment)
+		}
+		if !measure.NextContour() {
+			break
+		}
+	}
+	return true
+}
+
+// Intervals returns the intervals of the dashes.
+func (impl *tDashPathEffect) Intervals() []Scalar {
+	return impl.intervals
+}
+
+// Phase returns the phase wrapped into the intervals.
+func (impl *tDashPathEffect) Phase() Scalar {
+	return impl.phase
+}
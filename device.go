@@ -23,20 +23,23 @@ type Device interface {
 	// DrawOval(draw *Draw, oval Rect, paint *Paint)
 	// DrawRRect(draw *Draw, RRect, *Paint)
 	// DrawDRRect(*Draw, outer, inner RRect, *Paint)
-	// DrawPath(draw *Draw, path *Path, mat *Matrix, paint *Paint)
+	DrawPath(draw *Draw, path *Path, paint *Paint, prePathMatrix *Matrix, pathIsMutable bool)
 	// DrawSprite(draw *Draw, bmp *Bitmap, x, y int, paint *Paint)
 	// DrawBitmapRect(draw *Draw, bmp *Bitmap, srcOrNil *Rect, dst Rect, paint *Paint) (finalDst Rect)
 	// DrawBitmapNine(draw *Draw, bmp *Bitmap, center Rect, dst Rect, paint *Paint)
 	// DrawImage(draw *Draw, image *Image, x, y Scalar, paint *Paint)
 	// DrawImageRect(draw *Draw, image *Image, src Rect, dst Rect, paint *Paint, SrcRectConstraint)
-	// DrawText(draw *Draw, text string, x, y Scalar, paint *Paint)
-	// DrawPosText(draw *Draw, text string, pos []Scalar, paint *Paint)
-	// DrawVertices(Draw, VertexMode, vertexCount int, verts []Point, texs []Point, colors []Color, xmode *Xfermode, indices []uint16, indexCount int, Paint)
-	// DrawTextBlob(Draw, TextBlob, x, y Scalar, Paint, DrawFilter)
-	// DrawPatch(Draw, cubics [12]Point, colors []Color, texCoords [4]Point, xmode Xfermode, Paint)
-	// DrawAtlas(Draw, atlas Image, []RSXform, []Rect, []Color, count int, XfermodeMode, Paint)
-	// DrawDevice(draw *Draw, dev Device, x, y int, paint *Paint)
-	// DrawTextOnPath(draw *Draw, texts []string, len int, path *Path, mat *Matrix, paint *Paint)
+	DrawText(draw *Draw, text string, x, y Scalar, paint *Paint)
+	DrawPosText(draw *Draw, text string, pos []Scalar, scalarsPerPos int, offset Point, paint *Paint)
+	DrawVertices(draw *Draw, vmode CanvasVertexMode, vertexCount int, verts []Point, texs []Point, colors []Color,
+		xmode *Xfermode, indices []uint16, indexCount int, paint *Paint)
+	DrawTextBlob(draw *Draw, blob *TextBlob, x, y Scalar, paint *Paint)
+	DrawPatch(draw *Draw, cubics *[12]Point, colors *[4]Color, texCoords *[4]Point, xmode *Xfermode, paint *Paint)
+	DrawAtlas(draw *Draw, atlas *Image, xform []RSXform, tex []Rect, colors []Color, count int, mode XfermodeMode,
+		paint *Paint)
+	DrawDevice(draw *Draw, src *BaseDevice, x, y int, paint *Paint)
+	DrawTextOnPath(draw *Draw, text string, path *Path, matrix *Matrix, paint *Paint)
+	DrawTextRSXform(draw *Draw, text string, xform []RSXform, paint *Paint)
 
 	// OnAccessBitmap() *Bitmap
 	// CanHandleImageFilter(*ImageFilter) bool
@@ -45,7 +48,7 @@ type Device interface {
 	// OnReadPixels(imageInfo ImageInfo, pixelBytes []byte, x, y int)
 	// OnWritePixels(imageInfo ImageInfo, pixelBytes []byte, x, y int)
 	OnAccessPixels(pixmap *Pixmap) bool
-	// OnCreateDevice(CreateInfo, Paint) Device
+	OnCreateDevice(cinfo *DeviceCreateInfo, paint *Paint) Device
 	// Flush()
 	// GetImageFilterCache() *ImageFilterCache
 
@@ -63,8 +66,9 @@ type Device interface {
 }
 
 type BaseDevice struct {
-	Device Device
-	origin Point
+	Device       Device
+	origin       Point
+	surfaceProps *SurfaceProps
 }
 
 func NewBaseDevice() *BaseDevice {
@@ -75,6 +79,15 @@ func NewBaseDevice() *BaseDevice {
 	return baseDevice
 }
 
+// SurfaceProps returns the surface properties the device was created with,
+// which describe how its LCD subpixels are arranged.
+func (b *BaseDevice) SurfaceProps() *SurfaceProps {
+	if b.surfaceProps == nil {
+		return NewSurfaceProps(KSurfacePropsFlagNone, KSurfacePropsInitTypeNone)
+	}
+	return b.surfaceProps
+}
+
 func (b *BaseDevice) Width() Scalar {
 	return b.Device.ImageInfo().Width()
 }
@@ -122,8 +135,7 @@ func (b *BaseDevice) ReadPixels(info *ImageInfo, pixels []byte, rowBytes int,
 }
 
 func (b *BaseDevice) AccessPixels(pixmap *Pixmap) bool {
-	b.Device.OnAccessPixels(pixmap)
-	return false
+	return b.Device.OnAccessPixels(pixmap)
 }
 
 func (b *BaseDevice) OnAccessPixels(pixmap *Pixmap) bool {
@@ -134,6 +146,37 @@ func (b *BaseDevice) OnPeekPixels(pixmap *Pixmap) bool {
 	return false
 }
 
+/** DeviceCreateInfo
+describes the layer device a canvas asks its top device to create for
+saveLayer. */
+type DeviceCreateInfo struct {
+	Info          *ImageInfo
+	PixelGeometry PixelGeometry
+}
+
+func NewDeviceCreateInfo(info *ImageInfo, geo PixelGeometry, preserveLCDText bool) *DeviceCreateInfo {
+	return &DeviceCreateInfo{
+		Info:          info,
+		PixelGeometry: adjustLayerGeometry(info, geo, preserveLCDText),
+	}
+}
+
+// LCD text can only be blended against an opaque background. Unless the
+// caller promises to draw it on top of opaque content, text drawn into a
+// layer that isn't opaque is rendered as grayscale.
+func adjustLayerGeometry(info *ImageInfo, geo PixelGeometry, preserveLCDText bool) PixelGeometry {
+	if !info.IsOpaque() && !preserveLCDText {
+		return KPixelGeometryUnknown
+	}
+	return geo
+}
+
+// OnCreateDevice creates a compatible device for a layer, or returns nil if
+// the device can't create layers.
+func (b *BaseDevice) OnCreateDevice(cinfo *DeviceCreateInfo, paint *Paint) Device {
+	return nil
+}
+
 func (b *BaseDevice) DrawRect(draw *Draw, rect Rect, paint *Paint) {
 	toimpl()
 	return
@@ -148,6 +191,240 @@ func (b *BaseDevice) DrawPaint(draw *Draw, paint *Paint) {
 	toimpl()
 }
 
+func (b *BaseDevice) DrawPath(draw *Draw, path *Path, paint *Paint, prePathMatrix *Matrix, pathIsMutable bool) {
+	toimpl()
+}
+
+func (b *BaseDevice) DrawText(draw *Draw, text string, x, y Scalar, paint *Paint) {
+	toimpl()
+}
+
+func (b *BaseDevice) DrawPosText(draw *Draw, text string, pos []Scalar, scalarsPerPos int, offset Point, paint *Paint) {
+	toimpl()
+}
+
+func (b *BaseDevice) DrawVertices(draw *Draw, vmode CanvasVertexMode, vertexCount int, verts []Point, texs []Point,
+	colors []Color, xmode *Xfermode, indices []uint16, indexCount int, paint *Paint) {
+	toimpl()
+}
+
+/** DrawTextBlob
+Draw each run of blob, offset by (x, y), with paint and the text
+attributes the run was built with. */
+func (b *BaseDevice) DrawTextBlob(draw *Draw, blob *TextBlob, x, y Scalar, paint *Paint) {
+	var runPaint = paint.Clone()
+	for it := NewTextBlobRunIterator(blob); !it.Done(); it.Next() {
+		it.ApplyFontToPaint(runPaint)
+		var text = glyphsToText(it.Glyphs())
+		var offset = it.Offset()
+		offset.X += x
+		offset.Y += y
+		switch it.Positioning() {
+		case KTextBlobPositioningDefault:
+			b.Device.DrawText(draw, text, offset.X, offset.Y, runPaint)
+		default:
+			b.Device.DrawPosText(draw, text, it.Pos(), textBlobScalarsPerGlyph(it.Positioning()),
+				offset, runPaint)
+		}
+	}
+}
+
+/** DrawPatch
+Draw the Coons patch of cubics as the mesh it tessellates in, at the level
+of detail of its size in the device. */
+func (b *BaseDevice) DrawPatch(draw *Draw, cubics *[12]Point, colors *[4]Color, texCoords *[4]Point, xmode *Xfermode,
+	paint *Paint) {
+	var lodX, lodY = PatchUtilsLevelOfDetail(cubics, draw.matrix)
+	var data = NewPatchUtilsVertexData(cubics, colors, texCoords, lodX, lodY)
+	if data == nil {
+		return
+	}
+	b.Device.DrawVertices(draw, KCanvasVertexModeTriangles, data.VertexCount, data.Points, data.TexCoords,
+		data.Colors, xmode, data.Indices, data.IndexCount, paint)
+}
+
+/** DrawAtlas
+Draw each sprite of atlas as the path of its quad, filled with a shader of
+atlas mapped into it. */
+func (b *BaseDevice) DrawAtlas(draw *Draw, atlas *Image, xform []RSXform, tex []Rect, colors []Color, count int,
+	mode XfermodeMode, paint *Paint) {
+	for i := 0; i < count; i++ {
+		var color Color
+		if colors != nil {
+			color = colors[i]
+		}
+		var p = atlasSpritePaint(atlas, xform[i], tex[i], color, colors != nil, mode, paint)
+		b.Device.DrawPath(draw, atlasSpritePath(xform[i], tex[i]), p, nil, true)
+	}
+}
+
+/** DrawDevice
+Draw the pixels of src, a layer, with their top left at (x, y) of the
+device, blended with the alpha, color filter and xfermode of paint. If paint
+has an image filter, the pixels are filtered first, with the matrix and the
+clip of draw. */
+func (b *BaseDevice) DrawDevice(draw *Draw, src *BaseDevice, x, y int, paint *Paint) {
+	var pixels Pixmap
+	var bmp = new(Bitmap)
+	if !src.Device.OnPeekPixels(&pixels) ||
+		!bmp.InstallPixels(pixels.Info(), pixels.PixelBytes(), pixels.RowBytes(), nil) {
+		return
+	}
+	var left, top = Scalar(x), Scalar(y)
+	if filter := paint.ImageFilter(); filter != nil {
+		// filter in the space of src.
+		var ctm = NewMatrixClone(draw.matrix)
+		ctm.PostTranslate(-left, -top)
+		var clip = draw.rasterClip.Bounds()
+		clip.Offset(-left, -top)
+		var offset Point
+		var ok bool
+		if bmp, offset, ok = filter.FilterImage(bmp, NewImageFilterContext(ctm, clip)); !ok {
+			return
+		}
+		left, top = left+offset.X, top+offset.Y
+	}
+
+	var localMatrix = NewMatrix()
+	localMatrix.SetTranslate(left, top)
+	var p = paint.Clone()
+	p.SetImageFilter(nil)
+	p.SetStyle(KPaintStyleFill)
+	p.SetPathEffect(nil)
+	p.SetMaskFilter(nil)
+	p.SetAnitAlias(false)
+	p.SetShader(newBitmapShader(bmp, KShaderTileModeClamp, KShaderTileModeClamp, localMatrix))
+	// the pixels are placed in device space.
+	var sprite = *draw
+	sprite.matrix = NewMatrix()
+	b.Device.DrawRect(&sprite, MakeRect(left, top, bmp.Width(), bmp.Height()), p)
+}
+
+// Map the src points through matrix, then bend them along the measured
+// path: x becomes the distance along the path, y the offset from it.
+func morphPoints(dst, src []Point, meas *PathMeasure, matrix *Matrix) {
+	for i := range src {
+		var pos = matrix.MapXY(src[i].X, src[i].Y)
+		var sx, sy = pos.X, pos.Y
+
+		var p, tangent, ok = meas.PosTan(sx)
+		if !ok {
+			// set to 0 if the measure failed, so that we just set dst == pos
+			tangent.SetXY(0, 0)
+		} else {
+			pos = p
+		}
+
+		/*  This is the old way (that explains our approach but is way too slow
+		matrix.SetSinCos(tangent.Y, tangent.X)
+		matrix.PreTranslate(-sx, 0)
+		matrix.PostTranslate(pos.X, pos.Y)
+		matrix.MapPoints(dst[i:i+1], []Point{{sx, sy}}) */
+		dst[i].SetXY(pos.X-tangent.Y*sy, pos.Y+tangent.X*sy)
+	}
+}
+
+/*  TODO
+
+Need differentially more subdivisions when the follow-path is curvy. Not sure how to
+determine that, but we need it. I guess a cheap answer is let the caller tell us,
+but that seems like a cop-out. Another answer is to get Rob Johnson to figure it out.
+*/
+func morphPath(dst *Path, src *Path, meas *PathMeasure, matrix *Matrix) {
+	var iter = NewPathIter(src, false)
+	var srcP [4]Point
+	var dstP [3]Point
+
+	for {
+		switch iter.Next(srcP[:]) {
+		case KPathVerbMove:
+			morphPoints(dstP[:1], srcP[:1], meas, matrix)
+			dst.MoveTo(dstP[0].X, dstP[0].Y)
+		case KPathVerbLine:
+			// turn lines into quads to look bendy
+			srcP[0].X = ScalarAverage(srcP[0].X, srcP[1].X)
+			srcP[0].Y = ScalarAverage(srcP[0].Y, srcP[1].Y)
+			morphPoints(dstP[:2], srcP[:2], meas, matrix)
+			dst.QuadTo(dstP[0].X, dstP[0].Y, dstP[1].X, dstP[1].Y)
+		case KPathVerbQuad:
+			morphPoints(dstP[:2], srcP[1:3], meas, matrix)
+			dst.QuadTo(dstP[0].X, dstP[0].Y, dstP[1].X, dstP[1].Y)
+		case KPathVerbCubic:
+			morphPoints(dstP[:3], srcP[1:4], meas, matrix)
+			dst.CubicTo(dstP[0].X, dstP[0].Y, dstP[1].X, dstP[1].Y, dstP[2].X, dstP[2].Y)
+		case KPathVerbClose:
+			dst.Close()
+		default:
+			return
+		}
+	}
+}
+
+/** DrawTextOnPath
+Draw the glyph outlines of text bent along follow. The text is positioned
+along the path according to the paint's text align, and matrix (which may
+be nil) is applied to the glyphs before they are mapped onto the path. */
+func (b *BaseDevice) DrawTextOnPath(draw *Draw, text string, follow *Path, matrix *Matrix, paint *Paint) {
+	if len(text) == 0 {
+		return
+	}
+
+	var iter = newTextToPathIter(text, paint)
+	var meas = NewPathMeasure(follow, false, 1)
+	var hOffset Scalar = 0
+
+	// need to measure first
+	if paint.TextAlign() != KPaintAlignLeft {
+		var pathLen = meas.Length()
+		if paint.TextAlign() == KPaintAlignCenter {
+			pathLen = ScalarHalf(pathLen)
+		}
+		hOffset += pathLen
+	}
+
+	var scaledMatrix = NewMatrix()
+	scaledMatrix.SetScale(iter.PathScale(), iter.PathScale())
+
+	for {
+		var iterPath, xpos, ok = iter.Next()
+		if !ok {
+			break
+		}
+		if iterPath == nil {
+			continue
+		}
+
+		var tmp = NewPath()
+		var m = NewMatrixClone(scaledMatrix)
+		m.PostTranslate(xpos+hOffset, 0)
+		if matrix != nil {
+			m.PostConcat(matrix)
+		}
+		morphPath(tmp, iterPath, meas, m)
+		b.Device.DrawPath(draw, tmp, iter.Paint(), nil, true)
+	}
+}
+
+/** DrawTextRSXform
+Draw each glyph of text with its own scale-rotate-translate transform,
+concatenated with the draw's matrix. */
+func (b *BaseDevice) DrawTextRSXform(draw *Draw, text string, xform []RSXform, paint *Paint) {
+	var localDraw = *draw
+	var localMatrix = NewMatrix()
+	localDraw.matrix = localMatrix
+
+	var sizes = paint.textUnitSizes(text)
+	var offset = 0
+	for i := 0; i < len(sizes) && i < len(xform); i++ {
+		localMatrix.SetRSXform(xform[i])
+		if draw.matrix != nil {
+			localMatrix.PostConcat(draw.matrix)
+		}
+		b.Device.DrawText(&localDraw, text[offset:offset+sizes[i]], 0, 0, paint)
+		offset += sizes[i]
+	}
+}
+
 func (b *BaseDevice) forceConservativeRasterClip() bool {
 	return false
 }
@@ -0,0 +1,97 @@
+package ggk
+
+/** tDiscretePathEffect
+breaks the contours of a path into lines of about segLength, whose ends
+are moved randomly up to deviation away from the path. */
+type tDiscretePathEffect struct {
+	segLength  Scalar
+	deviation  Scalar
+	seedAssist uint32
+}
+
+// The most lines a contour is broken into.
+const kDiscretePathEffectMaxSegments = 100000
+
+/** NewDiscretePathEffect
+Return an effect breaking paths into lines of about segLength whose ends
+jitter by up to deviation across the path. The jitter is the same each
+time a path is drawn; seedAssist changes it. Returns nil if segLength is
+not positive. */
+func NewDiscretePathEffect(segLength, deviation Scalar, seedAssist uint32) *PathEffect {
+	if segLength <= 0 || !ScalarIsFinite(segLength) || !ScalarIsFinite(deviation) {
+		return nil
+	}
+	return NewPathEffect(&tDiscretePathEffect{segLength, deviation, seedAssist})
+}
+
+/** tLCGRandom
+is a linear congruential random generator, small and fast, for effects
+that must jitter the same way each time. */
+type tLCGRandom struct {
+	seed uint32
+}
+
+func (rand *tLCGRandom) next() uint32 {
+	rand.seed = rand.seed*1664525 + 1013904223
+	return rand.seed
+}
+
+// Return a random scalar in [-1, 1).
+func (rand *tLCGRandom) nextSScalar1() Scalar {
+	return Scalar(int32(rand.next())>>15) / 65536
+}
+
+// Move p by scale along the normal of tangent.
+func perterbPoint(p *Point, tangent Point, scale Scalar) {
+	var normal = Point{tangent.Y, -tangent.X}
+	if normal.Normalize() {
+		p.X += normal.X * scale
+		p.Y += normal.Y * scale
+	}
+}
+
+func (impl *tDiscretePathEffect) OnFilterPath(dst, src *Path, rec *StrokeRec, cullRect *Rect) bool {
+	var doFill = rec.IsFillStyle()
+	var measure = NewPathMeasure(src, doFill, 1)
+	var rand = tLCGRandom{uint32(ScalarRoundToInt(measure.Length())) ^ impl.seedAssist}
+	var fill Scalar
+	if doFill {
+		fill = 1
+	}
+	for {
+		var length = measure.Length()
+		if impl.segLength*(2+fill) > length {
+			// too short to break, but keep it.
+			measure.Segment(0, length, dst, true)
+		} else {
+			var n = ScalarRoundToInt(length / impl.segLength)
+			if n > kDiscretePathEffectMaxSegments {
+				n = kDiscretePathEffectMaxSegments
+			}
+			var delta = length / Scalar(n)
+			var distance Scalar
+			if measure.IsClosed() {
+				n--
+				distance += delta / 2
+			}
+			if p, v, ok := measure.PosTan(distance); ok {
+				perterbPoint(&p, v, rand.nextSScalar1()*impl.deviation)
+				dst.MoveTo(p.X, p.Y)
+			}
+			for ; n > 0; n-- {
+				distance += delta
+				if p, v, ok := measure.PosTan(distance); ok {
+					perterbPoint(&p, v, rand.nextSScalar1()*impl.deviation)
+					dst.LineTo(p.X, p.Y)
+				}
+			}
+			if measure.IsClosed() {
+				dst.Close()
+			}
+		}
+		if !measure.NextContour() {
+			break
+		}
+	}
+	return true
+}
@@ -81,7 +81,7 @@ func (draw *Draw) DrawPaint(paint *Paint) {
 	var devRect Rect
 	devRect.SetXYWH(0, 0, draw.dst.Width(), draw.dst.Height())
 
-	if draw.rasterClip.IsBW() {
+	if draw.rasterClip.IsBW() && draw.rasterClip.BWRgn() != nil {
 		/* If we don't have a shader (i.e. we're just a solid color) we may
 	    be faster to operate directly on the device bitmap, rather than invoking
 	    a blitter. Esp. true for xfermodes, which require a colorshader to be
@@ -109,9 +109,260 @@ func (draw *Draw) DrawPaint(paint *Paint) {
 	ScanFillRect(devRect, draw.rasterClip, chooser.Blitter())
 }
 
+// DrawRect draws rect with paint as a rectangular path.
 func (draw *Draw) DrawRect(rect Rect, paint *Paint) {
-	toimpl()
-	return
+	var path = NewPath()
+	path.AddRect(rect)
+	draw.DrawPath(path, paint, nil, true)
+}
+
+/** DrawPath
+Fill path, transformed by prePathMatrix (which may be nil) and the draw's
+matrix, with paint. Stroked paths and paths with a path effect are turned
+into the path to fill by the paint first. The path is scan converted into
+a coverage mask, which goes through the paint's mask filter if it has one
+before it is blitted. */
+func (draw *Draw) DrawPath(path *Path, paint *Paint, prePathMatrix *Matrix, pathIsMutable bool) {
+	if draw.rasterClip.IsEmpty() {
+		return
+	}
+
+	var matrix = draw.matrix
+	var doFill = paint.Style() == KPaintStyleFill && paint.PathEffect() == nil
+	if prePathMatrix != nil {
+		if doFill {
+			matrix = NewMatrix()
+			matrix.SetConcat(draw.matrix, prePathMatrix)
+		} else {
+			// strokes and path effects work in the space of the matrix.
+			var prePath = NewPath()
+			path.Transform(prePathMatrix, prePath)
+			path = prePath
+		}
+	}
+	var hairline bool
+	if !doFill {
+		var fillPath = NewPath()
+		hairline = !paint.FillPath(path, fillPath, nil, 1)
+		path = fillPath
+	}
+	var devPath = NewPath()
+	path.Transform(matrix, devPath)
+	if hairline {
+		// hairlines are a pixel wide whatever the matrix.
+		var rec = NewStrokeRec(KStrokeRecInitStyleHairline)
+		rec.SetStrokeStyle(KScalar1, false)
+		rec.SetStrokeParams(paint.StrokeCap(), paint.StrokeJoin(), paint.StrokeMiter())
+		devPath = strokePath(devPath, rec, 1)
+	}
+
+	var chooser = newAutoBlitterChooser(draw.dst, draw.matrix, paint, false)
+	var blitter = chooser.Blitter()
+	if filter := paint.MaskFilter(); filter != nil && filter.filterPath(devPath, draw.matrix, draw.rasterClip, blitter) {
+		return
+	}
+	var clip = draw.rasterClip.Bounds()
+	if mask := scanPathToMask(devPath, clip); mask != nil {
+		blitter.BlitMask(mask, clip)
+	}
+}
+
+// Blit the glyph mask, through the paint's mask filter if it has one that
+// can filter it.
+func blitGlyphMask(blitter Blitter, mask *Mask, clip Rect, paint *Paint, matrix *Matrix) {
+	if filter := paint.MaskFilter(); filter != nil {
+		var filtered Mask
+		if filter.FilterMask(&filtered, mask, matrix, nil) {
+			mask = &filtered
+		}
+	}
+	blitter.BlitMask(mask, clip)
+}
+
+// Glyphs bigger than this many pixels are drawn as paths rather than
+// cached as masks.
+const kMaxSizeForGlyphCache = 256
+
+// Return true if text drawn with paint through matrix should be drawn as
+// paths rather than as glyph masks.
+func shouldDrawTextAsPaths(paint *Paint, matrix *Matrix) bool {
+	if matrix.HasPerspective() {
+		return true
+	}
+	var scale = ScalarMax(
+		ScalarAbs(matrix.ScaleX())+ScalarAbs(matrix.SkewX()),
+		ScalarAbs(matrix.SkewY())+ScalarAbs(matrix.ScaleY()))
+	return paint.TextSize()*scale > kMaxSizeForGlyphCache
+}
+
+// LCD glyph masks can only be blended per channel into 32-bit pixels, so
+// text drawn into any other destination falls back to grayscale masks.
+func textSurfaceProps(dst *Pixmap, props *SurfaceProps) *SurfaceProps {
+	if props == nil || dst.ColorType() != KColorTypeN32 {
+		return nil
+	}
+	return props
+}
+
+func (draw *Draw) DrawText(text string, x, y Scalar, paint *Paint, props *SurfaceProps) {
+	// nothing to draw
+	if len(text) == 0 || draw.rasterClip.IsEmpty() {
+		return
+	}
+
+	if shouldDrawTextAsPaths(paint, draw.matrix) {
+		draw.drawTextAsPaths(text, x, y, paint)
+		return
+	}
+
+	var cache = paint.detachCache(textSurfaceProps(draw.dst, props), draw.matrix)
+
+	var glyphs = paint.textToFallbackGlyphs(cache, text)
+
+	// transform the starting point and apply the alignment in device space.
+	var origin = draw.matrix.MapXY(x, y)
+	if paint.TextAlign() != KPaintAlignLeft {
+		var stop Point
+		for _, g := range glyphs {
+			var glyph = g.metrics()
+			stop.X += glyph.AdvanceX
+			stop.Y += glyph.AdvanceY
+		}
+		var alignFactor = textAlignFactor(paint.TextAlign())
+		origin.X -= stop.X * alignFactor
+		origin.Y -= stop.Y * alignFactor
+	}
+
+	var chooser = newAutoBlitterChooser(draw.dst, draw.matrix, paint, false)
+	var blitter = chooser.Blitter()
+	var clip = draw.rasterClip.Bounds()
+	for _, g := range glyphs {
+		var glyph = g.metrics()
+		if !glyph.IsEmpty() {
+			var image = g.cache.FindImage(glyph)
+			if image != nil {
+				var mask = &Mask{
+					Image:    image,
+					Bounds:   glyph.Bounds(),
+					RowBytes: glyph.RowBytes(),
+					Format:   glyph.MaskFormat,
+				}
+				mask.Bounds.Offset(ScalarFloor(origin.X+KScalarHalf), ScalarFloor(origin.Y+KScalarHalf))
+				blitGlyphMask(blitter, mask, clip, paint, draw.matrix)
+			}
+		}
+		origin.X += glyph.AdvanceX
+		origin.Y += glyph.AdvanceY
+	}
+}
+
+/** DrawPosText
+Draw each glyph of text at its own position: pos holds one x per glyph
+(with offset.Y as the shared y) when scalarsPerPos is 1, or an (x, y) pair
+when it is 2. Positions are moved by offset, and each glyph is aligned on
+its position by the paint's text align. */
+func (draw *Draw) DrawPosText(text string, pos []Scalar, scalarsPerPos int, offset Point,
+	paint *Paint, props *SurfaceProps) {
+	// nothing to draw
+	if len(text) == 0 || draw.rasterClip.IsEmpty() {
+		return
+	}
+
+	if shouldDrawTextAsPaths(paint, draw.matrix) {
+		draw.drawPosTextAsPaths(text, pos, scalarsPerPos, offset, paint)
+		return
+	}
+
+	var cache = paint.detachCache(textSurfaceProps(draw.dst, props), draw.matrix)
+	var alignFactor = textAlignFactor(paint.TextAlign())
+
+	var chooser = newAutoBlitterChooser(draw.dst, draw.matrix, paint, false)
+	var blitter = chooser.Blitter()
+	var clip = draw.rasterClip.Bounds()
+	for i, g := range paint.textToFallbackGlyphs(cache, text) {
+		if (i+1)*scalarsPerPos > len(pos) {
+			break
+		}
+		var glyph = g.metrics()
+		if glyph.IsEmpty() {
+			continue
+		}
+		var image = g.cache.FindImage(glyph)
+		if image == nil {
+			continue
+		}
+		var origin = draw.matrix.MapXY(textPosAt(pos, scalarsPerPos, i, offset))
+		origin.X -= glyph.AdvanceX * alignFactor
+		origin.Y -= glyph.AdvanceY * alignFactor
+		var mask = &Mask{
+			Image:    image,
+			Bounds:   glyph.Bounds(),
+			RowBytes: glyph.RowBytes(),
+			Format:   glyph.MaskFormat,
+		}
+		mask.Bounds.Offset(ScalarFloor(origin.X+KScalarHalf), ScalarFloor(origin.Y+KScalarHalf))
+		blitGlyphMask(blitter, mask, clip, paint, draw.matrix)
+	}
+}
+
+// Return the position of the i-th glyph of positioned text.
+func textPosAt(pos []Scalar, scalarsPerPos int, i int, offset Point) (x, y Scalar) {
+	if scalarsPerPos == 1 {
+		return pos[i] + offset.X, offset.Y
+	}
+	return pos[2*i] + offset.X, pos[2*i+1] + offset.Y
+}
+
+func (draw *Draw) drawPosTextAsPaths(text string, pos []Scalar, scalarsPerPos int, offset Point, paint *Paint) {
+	var iter = newTextToPathIter(text, paint)
+	var alignFactor = textAlignFactor(paint.TextAlign())
+	for i := 0; ; i++ {
+		var path, _, ok = iter.Next()
+		if !ok || (i+1)*scalarsPerPos > len(pos) {
+			break
+		}
+		if path == nil {
+			continue
+		}
+		var x, y = textPosAt(pos, scalarsPerPos, i, offset)
+		var advance = iter.offset(iter.prevAdvance * iter.scale * alignFactor)
+		var matrix = NewMatrix()
+		matrix.SetScale(iter.PathScale(), iter.PathScale())
+		matrix.PostTranslate(x-advance.X, y-advance.Y)
+		draw.DrawPath(path, iter.Paint(), matrix, false)
+	}
+}
+
+// Return the sum of the advances of glyphs, in device space.
+func measureTextAdvance(cache *GlyphCache, glyphs []GlyphID) Point {
+	var stop Point
+	for _, id := range glyphs {
+		var glyph = cache.GlyphIDMetrics(id)
+		stop.X += glyph.AdvanceX
+		stop.Y += glyph.AdvanceY
+	}
+	return stop
+}
+
+func (draw *Draw) drawTextAsPaths(text string, x, y Scalar, paint *Paint) {
+	var iter = newTextToPathIter(text, paint)
+	var matrix = NewMatrix()
+	matrix.SetScale(iter.PathScale(), iter.PathScale())
+	matrix.PostTranslate(x, y)
+
+	var prevXPos Scalar = 0
+	for {
+		var path, xpos, ok = iter.Next()
+		if !ok {
+			break
+		}
+		if path != nil {
+			var delta = iter.offset(xpos - prevXPos)
+			matrix.PostTranslate(delta.X, delta.Y)
+			draw.DrawPath(path, iter.Paint(), matrix, false)
+			prevXPos = xpos
+		}
+	}
 }
 
 // each of these costs 8-bytes of stack space, so don't make it too large
@@ -125,7 +376,7 @@ func (draw *Draw) DrawPoints(mode CanvasPointMode, count int, pts []Point, paint
 		// temporarily mark the paint as filling.
 		var newPaint = paint.Clone()
 		newPaint.SetStyle(KPaintStyleFill)
-		var width = Scalar(newPaint.StrokeWidth())
+		var width = newPaint.StrokeWidth()
 		var radius = ScalarHalf(width)
 
 		for i := 0; i < count; i++ {
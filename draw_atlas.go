@@ -0,0 +1,125 @@
+package ggk
+
+// Return the quad the sprite of tex is drawn in by xform, as a path.
+func atlasSpritePath(xform RSXform, tex Rect) *Path {
+	var quad = xform.ToQuad(tex.Width, tex.Height)
+	var path = NewPath()
+	path.AddPoly(quad[:], true)
+	return path
+}
+
+/** atlasSpritePaint
+Return paint shading the sprite of atlas in tex, mapped into its quad by
+xform. If hasColor, the colors of the sprite are blended with color, as the
+source, through mode. */
+func atlasSpritePaint(atlas *Image, xform RSXform, tex Rect, color Color, hasColor bool, mode XfermodeMode,
+	paint *Paint) *Paint {
+	var localMatrix = NewMatrix()
+	localMatrix.SetRSXform(xform)
+	localMatrix.PreTranslate(-tex.Left, -tex.Top)
+
+	var p = paint.Clone()
+	p.SetShader(atlas.MakeShader(KShaderTileModeClamp, KShaderTileModeClamp, localMatrix))
+	if hasColor {
+		p.SetColorFilter(NewModeColorFilter(color, mode))
+	}
+	return p
+}
+
+// Return true if the edges of rect are integers.
+func rectIsIntegral(rect Rect) bool {
+	return ScalarIsInteger(rect.Left) && ScalarIsInteger(rect.Top) &&
+		ScalarIsInteger(rect.Width) && ScalarIsInteger(rect.Height)
+}
+
+/** drawAtlasSprite
+Blend the pixels of atlas in tex over the device, with their top left at
+(x, y) of the device and scaled by the alpha of paint, within clip. */
+func (draw *Draw) drawAtlasSprite(atlas *Image, tex Rect, x, y int, clip Rect, paint *Paint) {
+	var dst = MakeRect(Scalar(x), Scalar(y), tex.Width, tex.Height)
+	if !dst.Intersect(clip) {
+		return
+	}
+	var pixels = filterPixmap(atlas.bitmap)
+	var scale = Alpha255To256(uint32(paint.Alpha()))
+	var dx, dy = int(tex.Left) - x, int(tex.Top) - y
+	for py := int(dst.Top); py < int(dst.B()); py++ {
+		for px := int(dst.Left); px < int(dst.R()); px++ {
+			var src = pixels.Pixel32(px+dx, py+dy)
+			if scale != 256 {
+				src = AlphaMulQ(src, scale)
+			}
+			draw.dst.SetPixel32(px, py, PMSrcOver(src, draw.dst.Pixel32(px, py)))
+		}
+	}
+}
+
+/** DrawAtlas
+Draw count sprites of atlas: the i-th one is the rectangle tex[i] of atlas,
+mapped into a quad by xform[i] and the draw's matrix. If colors isn't nil,
+the pixels of the i-th sprite are blended with colors[i], as the source,
+through mode. The sprites are drawn with the antialiasing, alpha, color
+filter and xfermode of paint, which may be nil.
+
+Sprites that are only translated by a whole number of pixels are blended
+directly, and those drawn as rectangles are blitted as such; the others are
+filled as paths. */
+func (draw *Draw) DrawAtlas(atlas *Image, xform []RSXform, tex []Rect, colors []Color, count int,
+	mode XfermodeMode, paint *Paint) {
+	if atlas == nil || count <= 0 || draw.rasterClip.IsEmpty() {
+		return
+	}
+	if paint == nil {
+		paint = NewPaint()
+	}
+
+	var plain = paint.Style() == KPaintStyleFill && paint.PathEffect() == nil && paint.MaskFilter() == nil
+	var srcOver bool
+	if xfermode, ok := XfermodeAsMode(paint.Xfermode()); ok {
+		srcOver = xfermode == KXfermodeModeSrcOver
+	}
+	var canSprite = plain && srcOver && colors == nil && paint.ColorFilter() == nil &&
+		draw.matrix.TypeMask()&^KMatrixTypeMaskTranslate == 0
+	var canRect = plain && draw.matrix.IsScaleTranslate()
+	var atlasBounds = atlas.Bounds()
+	var clip = draw.rasterClip.Bounds()
+
+	for i := 0; i < count; i++ {
+		if tex[i].IsEmpty() {
+			continue
+		}
+		if canSprite && xform[i].SCos == 1 && xform[i].SSin == 0 && rectIsIntegral(tex[i]) {
+			var x = xform[i].Tx + draw.matrix.TranslateX()
+			var y = xform[i].Ty + draw.matrix.TranslateY()
+			var r = tex[i]
+			if ScalarIsInteger(x) && ScalarIsInteger(y) && r.Intersect(atlasBounds) && r == tex[i] {
+				draw.drawAtlasSprite(atlas, tex[i], int(x), int(y), clip, paint)
+				continue
+			}
+		}
+
+		var color Color
+		if colors != nil {
+			color = colors[i]
+		}
+		var p = atlasSpritePaint(atlas, xform[i], tex[i], color, colors != nil, mode, paint)
+		if canRect && xform[i].RectStaysRect() {
+			var quad = xform[i].ToQuad(tex[i].Width, tex[i].Height)
+			var bounds Rect
+			bounds.SetBoundsPoints(quad[:])
+			var devRect = draw.matrix.MapRect(bounds)
+			if !p.IsAntiAlias() || (rectIsIntegral(devRect) && draw.rasterClip.IsBW()) {
+				// fill the pixels whose centers are in the sprite.
+				var l, t = ScalarCeilToInt(devRect.Left - 0.5), ScalarCeilToInt(devRect.Top - 0.5)
+				var r, b = ScalarCeilToInt(devRect.R() - 0.5), ScalarCeilToInt(devRect.B() - 0.5)
+				var rect = MakeRect(Scalar(l), Scalar(t), Scalar(r-l), Scalar(b-t))
+				if rect.Intersect(clip) {
+					var blitter = newAutoBlitterChooser(draw.dst, draw.matrix, p, false).Blitter()
+					blitter.BlitRect(int(rect.Left), int(rect.Top), int(rect.Width), int(rect.Height))
+				}
+				continue
+			}
+		}
+		draw.DrawPath(atlasSpritePath(xform[i], tex[i]), p, nil, true)
+	}
+}
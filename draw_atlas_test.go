@@ -0,0 +1,104 @@
+package ggk
+
+import "testing"
+
+// Return an atlas of 8x4, red on the left half and blue on the right one.
+func newTestAtlas() (*Image, *Bitmap) {
+	var bmp = newFilterBitmap(MakeRect(0, 0, 8, 4))
+	var pixels = filterPixmap(bmp)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			if x < 4 {
+				pixels.SetPixel32(x, y, PackARGB32(0xff, 0xff, 0, 0))
+			} else {
+				pixels.SetPixel32(x, y, PackARGB32(0xff, 0, 0, 0xff))
+			}
+		}
+	}
+	return NewImageFromBitmap(bmp), bmp
+}
+
+func TestNewImageFromBitmap(t *testing.T) {
+	var image, bmp = newTestAtlas()
+	if image == nil || image.Width() != 8 || image.Height() != 4 || image.UniqueID() == 0 {
+		t.Fatalf("NewImageFromBitmap want an 8x4 image got %v", image)
+	}
+	if other, _ := newTestAtlas(); other.UniqueID() == image.UniqueID() {
+		t.Errorf("NewImageFromBitmap want unique ids got %v twice", image.UniqueID())
+	}
+	filterPixmap(bmp).SetPixel32(0, 0, 0)
+	if got := image.Pixel32(0, 0); got != PackARGB32(0xff, 0xff, 0, 0) {
+		t.Errorf("NewImageFromBitmap want a copy of the pixels got %#x", got)
+	}
+	if NewImageFromBitmap(new(Bitmap)) != nil {
+		t.Errorf("NewImageFromBitmap of an empty bitmap want nil")
+	}
+}
+
+func TestDrawAtlas(t *testing.T) {
+	var atlas, _ = newTestAtlas()
+	var red, blue = PackARGB32(0xff, 0xff, 0, 0), PackARGB32(0xff, 0, 0, 0xff)
+	var redTex, blueTex = MakeRect(0, 0, 4, 4), MakeRect(4, 0, 4, 4)
+	var translate = func(x, y Scalar) RSXform {
+		return RSXform{1, 0, x, y}
+	}
+
+	var tests = []struct {
+		name   string
+		xform  []RSXform
+		tex    []Rect
+		colors []Color
+		mode   XfermodeMode
+		alpha  uint8
+		cull   Rect
+		points []Point
+		want   []uint32
+	}{
+		{"sprites", []RSXform{translate(2, 3), translate(8, 8)}, []Rect{redTex, blueTex}, nil, KXfermodeModeSrc,
+			0xff, RectZero, []Point{{2, 3}, {5, 6}, {6, 3}, {1, 3}, {8, 8}, {11, 11}, {12, 12}},
+			[]uint32{red, red, 0, 0, blue, blue, 0}},
+		{"clipped sprite", []RSXform{translate(-2, -2)}, []Rect{redTex}, nil, KXfermodeModeSrc, 0xff, RectZero,
+			[]Point{{0, 0}, {1, 1}, {2, 2}}, []uint32{red, red, 0}},
+		{"scaled", []RSXform{{2, 0, 0, 0}}, []Rect{blueTex}, nil, KXfermodeModeSrc, 0xff, RectZero,
+			[]Point{{0, 0}, {7, 7}, {8, 8}}, []uint32{blue, blue, 0}},
+		{"fractional", []RSXform{translate(0.25, 0.25)}, []Rect{redTex}, nil, KXfermodeModeSrc, 0xff, RectZero,
+			[]Point{{0, 0}, {3, 3}, {4, 4}}, []uint32{red, red, 0}},
+		{"rotated", []RSXform{{2, 2, 8, 0}}, []Rect{redTex}, nil, KXfermodeModeSrc, 0xff, RectZero,
+			[]Point{{8, 8}, {8, 2}, {1, 1}, {14, 14}}, []uint32{red, red, 0, 0}},
+		{"colors", []RSXform{translate(0, 0), translate(8, 8)}, []Rect{redTex, redTex},
+			[]Color{KColorBlue, KColorWhite}, KXfermodeModeSrc, 0xff, RectZero, []Point{{1, 1}, {9, 9}},
+			[]uint32{blue, PackARGB32(0xff, 0xff, 0xff, 0xff)}},
+		{"modulate", []RSXform{translate(0, 0)}, []Rect{redTex}, []Color{KColorWhite}, KXfermodeModeModulate, 0xff,
+			RectZero, []Point{{1, 1}}, []uint32{red}},
+		{"paint alpha", []RSXform{translate(0, 0)}, []Rect{redTex}, nil, KXfermodeModeSrc, 0x80, RectZero,
+			[]Point{{1, 1}}, []uint32{PackARGB32(0x80, 0x80, 0, 0)}},
+		{"culled", []RSXform{translate(0, 0)}, []Rect{redTex}, nil, KXfermodeModeSrc, 0xff,
+			MakeRect(100, 100, 4, 4), []Point{{1, 1}}, []uint32{0}},
+		{"cull", []RSXform{translate(0, 0)}, []Rect{redTex}, nil, KXfermodeModeSrc, 0xff, MakeRect(0, 0, 4, 4),
+			[]Point{{1, 1}}, []uint32{red}},
+	}
+	for _, test := range tests {
+		var paint = NewPaint()
+		paint.SetAlpha(test.alpha)
+		var draw = func(canvas *Canvas) {
+			canvas.DrawAtlas(atlas, test.xform, test.tex, test.colors, len(test.xform), test.mode, test.cull, paint)
+		}
+		var canvas, pixels = newTestPictureCanvas(16, 16)
+		draw(canvas)
+		for i, point := range test.points {
+			if got := pixels.Pixel32(int(point.X), int(point.Y)); got != test.want[i] {
+				t.Errorf("%v at %v want %#x got %#x", test.name, point, test.want[i], got)
+			}
+		}
+
+		var recorder = NewPictureRecorder()
+		draw(recorder.BeginRecording(MakeRect(0, 0, 16, 16)))
+		var replay, replayed = newTestPictureCanvas(16, 16)
+		replay.DrawPicture(recorder.FinishRecordingAsPicture(), nil, nil)
+		for i, point := range test.points {
+			if got := replayed.Pixel32(int(point.X), int(point.Y)); got != test.want[i] {
+				t.Errorf("%v replayed at %v want %#x got %#x", test.name, point, test.want[i], got)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package ggk
+
+import "testing"
+
+// tTestDrawFilter records the types it filters, turns anti-aliasing off,
+// recolors the draws to color if it is not transparent and skips the draws
+// of type skip, and the draws after the first limit ones if limit is not 0.
+type tTestDrawFilter struct {
+	types []DrawFilterType
+	color Color
+	skip  DrawFilterType
+	limit int
+}
+
+func (filter *tTestDrawFilter) Filter(paint *Paint, drawType DrawFilterType) bool {
+	filter.types = append(filter.types, drawType)
+	paint.SetAnitAlias(false)
+	if filter.color != KColorTransparent {
+		paint.SetColor(filter.color)
+	}
+	if filter.limit != 0 && len(filter.types) > filter.limit {
+		return false
+	}
+	return drawType != filter.skip
+}
+
+func TestDrawFilter(t *testing.T) {
+	var rectPath = NewPath()
+	rectPath.AddRect(MakeRect(4, 4, 4, 4))
+	var tests = []struct {
+		name  string
+		draw  func(canvas *Canvas, paint *Paint)
+		skip  DrawFilterType
+		types []DrawFilterType
+		want  map[Point]uint32
+	}{
+		{"rect", func(canvas *Canvas, paint *Paint) {
+			canvas.DrawRect(MakeRect(0, 0, 4, 4), paint)
+		}, DrawFilterType(KDrawFilterTypeCount), []DrawFilterType{KDrawFilterTypeRect}, map[Point]uint32{
+			{1, 1}: PackARGB32(0xff, 0, 0, 0xff),
+			{5, 5}: 0,
+		}},
+		{"skip path", func(canvas *Canvas, paint *Paint) {
+			canvas.DrawRect(MakeRect(0, 0, 4, 4), paint)
+			canvas.DrawPath(rectPath, paint)
+		}, KDrawFilterTypePath, []DrawFilterType{KDrawFilterTypeRect, KDrawFilterTypePath}, map[Point]uint32{
+			{1, 1}: PackARGB32(0xff, 0, 0, 0xff),
+			{5, 5}: 0,
+		}},
+		{"paint", func(canvas *Canvas, paint *Paint) {
+			canvas.DrawPaint(paint)
+		}, DrawFilterType(KDrawFilterTypeCount), []DrawFilterType{KDrawFilterTypePaint}, nil},
+		{"picture", func(canvas *Canvas, paint *Paint) {
+			var recorder = NewPictureRecorder()
+			var recording = recorder.BeginRecording(MakeRect(0, 0, 8, 8))
+			recording.DrawRect(MakeRect(0, 0, 4, 4), paint)
+			recording.DrawPath(rectPath, paint)
+			canvas.DrawPicture(recorder.FinishRecordingAsPicture(), nil, nil)
+		}, KDrawFilterTypeRect, []DrawFilterType{KDrawFilterTypeRect, KDrawFilterTypePath}, map[Point]uint32{
+			{1, 1}: 0,
+			{5, 5}: PackARGB32(0xff, 0, 0, 0xff),
+		}},
+		{"looper", func(canvas *Canvas, paint *Paint) {
+			var builder = NewLayerDrawLooperBuilder()
+			builder.AddLayer(NewLayerDrawLooperLayerInfo())
+			builder.AddLayerXY(4, 4)
+			paint.SetLooper(builder.Detach())
+			canvas.DrawRect(MakeRect(0, 0, 4, 4), paint)
+		}, DrawFilterType(KDrawFilterTypeCount), []DrawFilterType{KDrawFilterTypeRect, KDrawFilterTypeRect}, map[Point]uint32{
+			{1, 1}: PackARGB32(0xff, 0, 0, 0xff),
+			{5, 5}: PackARGB32(0xff, 0, 0, 0xff),
+		}},
+	}
+	for _, test := range tests {
+		var canvas, pixels = newTestPictureCanvas(8, 8)
+		var filter = &tTestDrawFilter{color: KColorBlue, skip: test.skip}
+		canvas.SetDrawFilter(filter)
+		var paint = newTestPaint(KColorRed)
+		paint.SetAnitAlias(true)
+		test.draw(canvas, paint)
+		if len(filter.types) != len(test.types) {
+			t.Errorf("%v want filtered types %v got %v", test.name, test.types, filter.types)
+		} else {
+			for i := range filter.types {
+				if filter.types[i] != test.types[i] {
+					t.Errorf("%v want filtered types %v got %v", test.name, test.types, filter.types)
+					break
+				}
+			}
+		}
+		for pt, want := range test.want {
+			if got := pixels.Pixel32(int(pt.X), int(pt.Y)); got != want {
+				t.Errorf("%v pixel %v want %#x got %#x", test.name, pt, want, got)
+			}
+		}
+		if paint.Color() != KColorRed || !paint.IsAntiAlias() {
+			t.Errorf("%v want the filter to change a copy of the paint", test.name)
+		}
+	}
+}
+
+func TestDrawFilterStopsLooper(t *testing.T) {
+	// the filter stops the looper after its first layer, which is offset.
+	var canvas, pixels = newTestPictureCanvas(20, 20)
+	var filter = &tTestDrawFilter{skip: DrawFilterType(KDrawFilterTypeCount), limit: 1}
+	canvas.SetDrawFilter(filter)
+	var builder = NewLayerDrawLooperBuilder()
+	builder.AddLayerXY(5, 5)
+	builder.AddLayerXY(0, 0)
+	var paint = newTestPaint(KColorRed)
+	paint.SetLooper(builder.Detach())
+	canvas.DrawRect(MakeRect(0, 0, 2, 2), paint)
+	if len(filter.types) != 2 {
+		t.Errorf("want 2 filtered draws got %v", filter.types)
+	}
+	if canvas.SaveCount() != 1 || !canvas.TotalMatrix().IsIdentity() {
+		t.Errorf("want the looper undone got save count %v and matrix %v", canvas.SaveCount(), canvas.TotalMatrix())
+	}
+
+	canvas.SetDrawFilter(nil)
+	canvas.DrawRect(MakeRect(10, 10, 2, 2), newTestPaint(KColorBlue))
+	if got, want := pixels.Pixel32(10, 10), PackARGB32(0xff, 0, 0, 0xff); got != want {
+		t.Errorf("later draw want %#x at (10, 10) got %#x", want, got)
+	}
+	if got := pixels.Pixel32(15, 15); got != 0 {
+		t.Errorf("later draw want nothing at (15, 15) got %#x", got)
+	}
+}
+
+func TestDrawFilterSaveRestore(t *testing.T) {
+	var canvas, _ = newTestPictureCanvas(8, 8)
+	var filter = &tTestDrawFilter{}
+	canvas.Save()
+	canvas.SetDrawFilter(filter)
+	if canvas.DrawFilter() != filter {
+		t.Errorf("DrawFilter() want the filter set")
+	}
+	canvas.Save()
+	if canvas.DrawFilter() != filter {
+		t.Errorf("DrawFilter() want the filter kept by Save()")
+	}
+	canvas.Restore()
+	canvas.Restore()
+	if canvas.DrawFilter() != nil {
+		t.Errorf("DrawFilter() want no filter after Restore()")
+	}
+}
@@ -1,5 +1,17 @@
 package ggk
 
+/** DrawLooperImpl
+is implemented by each kind of DrawLooper. */
+type DrawLooperImpl interface {
+	// OnCreateContext returns the context iterating the passes of one draw
+	// to canvas.
+	OnCreateContext(canvas *Canvas) DrawLooperContext
+
+	// OnAsABlurShadow returns true and fills rec if the looper is a blurred
+	// shadow under the unmodified draw.
+	OnAsABlurShadow(rec *DrawLooperBlurShadowRec) bool
+}
+
 // DrawLooper
 // Subclasses of DrawLooper can be attached to a SkPaint. Where they are,  and
 // something is drawn to a canvas with that paint, the looper subclass will be
@@ -8,23 +20,23 @@ package ggk
 // invoked multiple times (hence the name loop-er), allow it to perform effects
 // like shadows or frame/fills, that require more than one pass.
 type DrawLooper struct {
+	Impl DrawLooperImpl
+}
 
+func NewDrawLooper(impl DrawLooperImpl) *DrawLooper {
+	return &DrawLooper{Impl: impl}
 }
 
 // Called right before something is being drawn. Returns a Context
 // whose next() method should be called until it returns false.
-// The caller has to ensure that the storage pointer provides enough
-// memory for the Context. The required size can be queried by calling
-// contextSize(). It is also the caller's responsibility to destroy the
-// object after use.
-// func (l *DrawLooper) CreateContext(canvas Canvas, storage interface{}) *DrawLooperContext {
-
-// }
+func (l *DrawLooper) CreateContext(canvas *Canvas) DrawLooperContext {
+	return l.Impl.OnCreateContext(canvas)
+}
 
 // Returns the number of bytes needed to store subclasses of Context (belonging to the
-// corresponding DrawLooper subclass).
+// corresponding DrawLooper subclass). Contexts are allocated by CreateContext,
+// so no storage is needed from the caller.
 func (l *DrawLooper) ContextSize() int {
-	toimpl()
 	return 0
 }
 
@@ -37,12 +49,37 @@ func (l *DrawLooper) ContextSize() int {
 // storage rect, where the storage rect is with the union of the src rect
 // and the looper's bounding rect.
 func (l *DrawLooper) CanComputeFastBounds(paint *Paint) bool {
-	toimpl()
-	return false
+	var canvas = NewCanvas(0, 0, nil)
+	var context = l.CreateContext(canvas)
+	for {
+		var p = NewPaint_Clone(paint)
+		if !context.Next(canvas, p) {
+			return true
+		}
+		p.SetLooper(nil)
+		if !p.CanComputeFastBounds() {
+			return false
+		}
+	}
 }
 
-func (l *DrawLooper) ComputeFastBounds(paint *Paint, src, dst Rect) {
-	toimpl()
+func (l *DrawLooper) ComputeFastBounds(paint *Paint, src Rect) Rect {
+	var canvas = NewCanvas(0, 0, nil)
+	var context = l.CreateContext(canvas)
+	var dst = src // catch the case where there are no passes.
+	for firstTime := true; ; firstTime = false {
+		var p = NewPaint_Clone(paint)
+		if !context.Next(canvas, p) {
+			return dst
+		}
+		p.SetLooper(nil)
+		var r = canvas.TotalMatrix().MapRect(p.ComputeFastBounds(src, nil))
+		if firstTime {
+			dst = r
+		} else {
+			dst.Join(r)
+		}
+	}
 }
 
 // If this looper can be interpreted as having two layers, such that
@@ -52,20 +89,23 @@ func (l *DrawLooper) ComputeFastBounds(paint *Paint, src, dst Rect) {
 // then return true, and if not null, fill out the BlurShadowRec).
 //
 // If any of the above are not met, return false and ignore the BlurShadowRec parameter.
-func (l *DrawLooper) AsABlurShadow(rec *tDrawLooperBlurShadowRec) bool {
-	toimpl()
-	return false
+func (l *DrawLooper) AsABlurShadow(rec *DrawLooperBlurShadowRec) bool {
+	return l.Impl.OnAsABlurShadow(rec)
 }
 
-type tDrawLooperContext struct {
-
+/** DrawLooperContext
+iterates the passes of one draw. Next is called before each pass, and may
+change the canvas and the paint for it. It returns false when there are no
+more passes, after restoring anything it changed on the canvas. */
+type DrawLooperContext interface {
+	Next(canvas *Canvas, paint *Paint) bool
 }
 
-func (context *tDrawLooperContext) Next(canvas *Canvas, paint *Paint) bool {
-	toimpl()
-	return false
+/** DrawLooperBlurShadowRec
+describes a looper drawing a blurred shadow offset under the draw. */
+type DrawLooperBlurShadowRec struct {
+	Sigma  Scalar
+	Offset Point
+	Color  Color
+	Style  BlurStyle
 }
-
-type tDrawLooperBlurShadowRec struct {
-
-}
\ No newline at end of file
@@ -0,0 +1,146 @@
+package ggk
+
+import "testing"
+
+func TestLayerDrawLooper(t *testing.T) {
+	var builder = NewLayerDrawLooperBuilder()
+	builder.AddLayer(NewLayerDrawLooperLayerInfo())
+	var info = NewLayerDrawLooperLayerInfo()
+	info.ColorMode = KXfermodeModeSrc
+	info.Offset = Point{4, 4}
+	builder.AddLayer(info).SetColor(KColorBlue)
+	var looper = builder.Detach()
+	if builder.Detach() != nil {
+		t.Errorf("Detach() want an empty builder after detaching")
+	}
+
+	var canvas, pixels = newTestPictureCanvas(10, 10)
+	var paint = newTestPaint(KColorRed)
+	paint.SetLooper(looper)
+	canvas.DrawRect(MakeRect(2, 2, 4, 4), paint)
+	var tests = []struct {
+		x, y  int
+		color uint32
+	}{
+		{3, 3, PackARGB32(0xff, 0xff, 0, 0)},
+		{5, 5, PackARGB32(0xff, 0xff, 0, 0)},
+		{7, 7, PackARGB32(0xff, 0, 0, 0xff)},
+		{9, 3, 0},
+		{0, 0, 0},
+	}
+	for _, test := range tests {
+		if got := pixels.Pixel32(test.x, test.y); got != test.color {
+			t.Errorf("pixel (%v, %v) want %#x got %#x", test.x, test.y, test.color, got)
+		}
+	}
+	if canvas.SaveCount() != 1 || !canvas.TotalMatrix().IsIdentity() {
+		t.Errorf("want the canvas restored after drawing got save count %v", canvas.SaveCount())
+	}
+}
+
+func TestLayerDrawLooperOrder(t *testing.T) {
+	var src = NewLayerDrawLooperLayerInfo()
+	src.ColorMode = KXfermodeModeSrc
+	var tests = []struct {
+		name  string
+		build func(builder *LayerDrawLooperBuilder)
+		want  uint32
+	}{
+		{"add", func(builder *LayerDrawLooperBuilder) {
+			builder.AddLayer(src).SetColor(KColorRed)
+			builder.AddLayer(src).SetColor(KColorGreen)
+		}, PackARGB32(0xff, 0xff, 0, 0)},
+		{"add on top", func(builder *LayerDrawLooperBuilder) {
+			builder.AddLayerOnTop(src).SetColor(KColorRed)
+			builder.AddLayerOnTop(src).SetColor(KColorGreen)
+		}, PackARGB32(0xff, 0, 0xff, 0)},
+	}
+	for _, test := range tests {
+		var builder = NewLayerDrawLooperBuilder()
+		test.build(builder)
+		var canvas, pixels = newTestPictureCanvas(4, 4)
+		var paint = newTestPaint(KColorBlue)
+		paint.SetLooper(builder.Detach())
+		canvas.DrawRect(MakeRect(0, 0, 4, 4), paint)
+		if got := pixels.Pixel32(1, 1); got != test.want {
+			t.Errorf("%v want %#x got %#x", test.name, test.want, got)
+		}
+	}
+}
+
+func TestApplyLayerInfo(t *testing.T) {
+	var src = newTestPaint(KColorRed)
+	src.SetStyle(KPaintStyleStroke)
+	src.SetTextSkewX(-0.25)
+	src.SetMaskFilter(NewBlurMaskFilter(KBlurStyleNormal, 2, KBlurMaskFilterFlagNone))
+	src.SetColorFilter(NewModeColorFilter(KColorBlue, KXfermodeModeSrcIn))
+	src.SetAnitAlias(true)
+	var tests = []struct {
+		name      string
+		bits      LayerDrawLooperBits
+		mode      XfermodeMode
+		color     Color
+		style     PaintStyle
+		skewX     Scalar
+		mask      bool
+		filter    bool
+		antiAlias bool
+	}{
+		{"none", KLayerDrawLooperBitsNone, KXfermodeModeDst, KColorGreen, KPaintStyleFill, 0, false, false, false},
+		{"src color", KLayerDrawLooperBitsNone, KXfermodeModeSrc, KColorRed, KPaintStyleFill, 0, false, false, false},
+		{"blend color", KLayerDrawLooperBitsNone, KXfermodeModePlus, KColorYellow, KPaintStyleFill, 0, false, false, false},
+		{"style", KLayerDrawLooperBitsStyle, KXfermodeModeDst, KColorGreen, KPaintStyleStroke, 0, false, false, false},
+		{"skew and mask", KLayerDrawLooperBitsTextSkewX | KLayerDrawLooperBitsMaskFilter, KXfermodeModeDst, KColorGreen, KPaintStyleFill, -0.25, true, false, false},
+		{"color filter", KLayerDrawLooperBitsColorFilter, KXfermodeModeDst, KColorGreen, KPaintStyleFill, 0, false, true, false},
+		{"entire paint", KLayerDrawLooperBitsEntirePaint, KXfermodeModeDst, KColorGreen, KPaintStyleStroke, -0.25, true, true, false},
+	}
+	for _, test := range tests {
+		var dst = newTestPaint(KColorGreen)
+		var info = LayerDrawLooperLayerInfo{PaintBits: test.bits, ColorMode: test.mode}
+		applyLayerInfo(dst, src, &info)
+		if dst.Color() != test.color || dst.Style() != test.style || dst.TextSkewX() != test.skewX ||
+			(dst.MaskFilter() != nil) != test.mask || (dst.ColorFilter() != nil) != test.filter || dst.IsAntiAlias() != test.antiAlias {
+			t.Errorf("%v got color %#x, style %v, skew %v, mask filter %v, color filter %v, anti alias %v",
+				test.name, dst.Color(), dst.Style(), dst.TextSkewX(), dst.MaskFilter() != nil, dst.ColorFilter() != nil, dst.IsAntiAlias())
+		}
+	}
+}
+
+func TestBlurDrawLooper(t *testing.T) {
+	var looper = NewBlurDrawLooper(KColorBlack, 1, 3, 3)
+	var rec DrawLooperBlurShadowRec
+	if !looper.AsABlurShadow(&rec) {
+		t.Fatalf("AsABlurShadow() want true")
+	}
+	if want := (DrawLooperBlurShadowRec{1, Point{3, 3}, KColorBlack, KBlurStyleNormal}); rec != want {
+		t.Errorf("AsABlurShadow() want %v got %v", want, rec)
+	}
+	if NewBlurDrawLooper(KColorBlack, 0, 3, 3).AsABlurShadow(nil) {
+		t.Errorf("AsABlurShadow() of an unblurred shadow want false")
+	}
+
+	var paint = newTestPaint(KColorRed)
+	paint.SetLooper(looper)
+	if !paint.CanComputeFastBounds() {
+		t.Errorf("CanComputeFastBounds() want true")
+	}
+	if got, want := paint.ComputeFastBounds(MakeRect(0, 0, 10, 10), nil), MakeRect(0, 0, 16, 16); got != want {
+		t.Errorf("ComputeFastBounds() want %v got %v", want, got)
+	}
+
+	var canvas, pixels = newTestPictureCanvas(20, 20)
+	canvas.DrawRect(MakeRect(2, 2, 8, 8), paint)
+	if got, want := pixels.Pixel32(5, 5), PackARGB32(0xff, 0xff, 0, 0); got != want {
+		t.Errorf("want the draw over the shadow %#x got %#x", want, got)
+	}
+	var shadow = pixels.Pixel32(11, 11)
+	if GetPackedA32(shadow) < 0x80 || GetPackedR32(shadow) != 0 {
+		t.Errorf("want a black shadow under the draw got %#x", shadow)
+	}
+	if edge := pixels.Pixel32(13, 13); GetPackedA32(edge) == 0 || GetPackedA32(edge) >= GetPackedA32(shadow) {
+		t.Errorf("want a blurred shadow edge got %#x", edge)
+	}
+	if got := pixels.Pixel32(18, 18); got != 0 {
+		t.Errorf("want nothing past the shadow got %#x", got)
+	}
+}
@@ -0,0 +1,286 @@
+package ggk
+
+/** tVertexState
+walks the triangles of a mesh, given by vertices or by indices into
+them. */
+type tVertexState struct {
+	mode    CanvasVertexMode
+	count   int
+	indices []uint16
+	current int
+
+	f0, f1, f2 int // the vertices of the current triangle.
+}
+
+func newVertexState(mode CanvasVertexMode, vertexCount int, indices []uint16, indexCount int) *tVertexState {
+	var state = &tVertexState{mode: mode, count: vertexCount}
+	if indices != nil {
+		state.count, state.indices = indexCount, indices
+	}
+	return state
+}
+
+func (state *tVertexState) vertex(i int) int {
+	if state.indices != nil {
+		return int(state.indices[i])
+	}
+	return i
+}
+
+// Move to the next triangle, returning false if there is none.
+func (state *tVertexState) next() bool {
+	var i = state.current
+	if i+2 >= state.count {
+		return false
+	}
+	switch state.mode {
+	case KCanvasVertexModeTriangles:
+		state.f0, state.f1, state.f2 = state.vertex(i), state.vertex(i+1), state.vertex(i+2)
+		state.current += 3
+	case KCanvasVertexModeTriangleStrip:
+		state.f0, state.f1, state.f2 = state.vertex(i), state.vertex(i+1), state.vertex(i+2)
+		state.current++
+	case KCanvasVertexModeTriangleFan:
+		state.f0, state.f1, state.f2 = state.vertex(0), state.vertex(i+1), state.vertex(i+2)
+		state.current++
+	default:
+		return false
+	}
+	return true
+}
+
+func (state *tVertexState) points(pts []Point) [3]Point {
+	return [3]Point{pts[state.f0], pts[state.f1], pts[state.f2]}
+}
+
+/** triangleMatrix
+Return the matrix mapping (0, 0), (1, 0) and (0, 1) to the points of
+triangle. */
+func triangleMatrix(triangle [3]Point) *Matrix {
+	var matrix = NewMatrix()
+	matrix.SetAll(
+		triangle[1].X-triangle[0].X, triangle[2].X-triangle[0].X, triangle[0].X,
+		triangle[1].Y-triangle[0].Y, triangle[2].Y-triangle[0].Y, triangle[0].Y,
+		0, 0, 1)
+	return matrix
+}
+
+/** tVerticesShader
+shades the current triangle of a mesh: the colors of its vertices are
+interpolated across it, and the texture shader is mapped into it from its
+texture coordinates. When there are both, the colors of the texture are
+blended, as the source, with the colors of the vertices through mode.
+
+The draw changes the triangle with setTriangle, so the shader is made for
+a single draw. */
+type tVerticesShader struct {
+	texture *Shader // nil without texture coordinates.
+	mode    XfermodeMode
+
+	// the current triangle.
+	hasColors  bool
+	colors     [3]PM4f
+	deviceToUV *Matrix // maps the device into the triangle's unit space.
+	texMatrix  *Matrix // maps the texture into the triangle.
+	contexts   []*tVerticesShaderContext
+}
+
+/** setTriangle
+Set the triangle of the next spans: devPts are its vertices in the device,
+colors the colors of its vertices and texMatrix maps the texture into it.
+Returns false if the triangle is degenerate. */
+func (impl *tVerticesShader) setTriangle(devPts [3]Point, colors [3]Color, texMatrix *Matrix) bool {
+	var inverse, ok = triangleMatrix(devPts).Invert()
+	if !ok {
+		return false
+	}
+	impl.deviceToUV = inverse
+	for i := range colors {
+		impl.colors[i] = Color4fFromColor(colors[i]).Premultipy()
+	}
+	impl.texMatrix = texMatrix
+	for _, context := range impl.contexts {
+		if !context.update() {
+			return false
+		}
+	}
+	return true
+}
+
+func (impl *tVerticesShader) OnCreateContext(shader *Shader, rec *ShaderContextRec) *ShaderContext {
+	// the texture shades opaque, the paint's alpha scales the blended colors.
+	var paint = NewPaint_Clone(rec.Paint)
+	paint.SetAlpha(0xff)
+	var textureRec = NewShaderContextRec(paint, rec.Matrix, nil, rec.PreferredDstType)
+	var context = &tVerticesShaderContext{shader: impl, rec: textureRec}
+	impl.contexts = append(impl.contexts, context)
+	return NewShaderContext(shader, rec, context)
+}
+
+type tVerticesShaderContext struct {
+	shader  *tVerticesShader
+	rec     *ShaderContextRec
+	texture *ShaderContext
+	span    []PremulColor
+}
+
+// Recreate the context of the texture for the current triangle, returning
+// false if there is none.
+func (impl *tVerticesShaderContext) update() bool {
+	if impl.shader.texture == nil {
+		return true
+	}
+	impl.rec.LocalMatrix = impl.shader.texMatrix
+	impl.texture = impl.shader.texture.CreateContext(impl.rec)
+	return impl.texture != nil
+}
+
+// Return the color of the vertices interpolated at the center of the pixel
+// (x, y).
+func (impl *tVerticesShaderContext) vertexColor(x, y int) PM4f {
+	var uv = impl.shader.deviceToUV.MapXY(Scalar(x)+0.5, Scalar(y)+0.5)
+	var s1, s2 = pinUnit(float32(uv.X)), pinUnit(float32(uv.Y))
+	var s0 = pinUnit(1 - s1 - s2)
+	var c = &impl.shader.colors
+	return PM4f{
+		c[0].R*s0 + c[1].R*s1 + c[2].R*s2,
+		c[0].G*s0 + c[1].G*s1 + c[2].G*s2,
+		c[0].B*s0 + c[1].B*s1 + c[2].B*s2,
+		c[0].A*s0 + c[1].A*s1 + c[2].A*s2,
+	}
+}
+
+func (impl *tVerticesShaderContext) OnShadeSpan(context *ShaderContext, x, y int, dst []PremulColor, count int) {
+	var texture = dst[:count]
+	if impl.texture != nil {
+		if impl.shader.hasColors {
+			if len(impl.span) < count {
+				impl.span = make([]PremulColor, count)
+			}
+			texture = impl.span[:count]
+		}
+		impl.texture.ShadeSpan(x, y, texture, count)
+	}
+
+	for i := 0; i < count; i++ {
+		var color PM4f
+		switch {
+		case impl.texture == nil:
+			color = impl.vertexColor(x+i, y)
+		case impl.shader.hasColors:
+			color = blendPM4f(impl.shader.mode, PM4fFromPremulColor(texture[i]), impl.vertexColor(x+i, y))
+		default:
+			color = PM4fFromPremulColor(texture[i])
+		}
+		dst[i] = color.ToPremulColor()
+	}
+	if scale := Alpha255To256(uint32(context.PaintAlpha())); scale != 256 {
+		for i := 0; i < count; i++ {
+			dst[i] = PremulColor(AlphaMulQ(uint32(dst[i]), scale))
+		}
+	}
+}
+
+/** fillTriangle
+Fill the pixels of the device whose centers are in the triangle pts,
+within clip. The left and top edges of the triangle are inside it and the
+right and bottom ones outside, so triangles sharing an edge don't both
+fill the pixels along it. */
+func fillTriangle(pts [3]Point, clip Rect, blitter Blitter) {
+	var top = ScalarMin(pts[0].Y, ScalarMin(pts[1].Y, pts[2].Y))
+	var bottom = ScalarMax(pts[0].Y, ScalarMax(pts[1].Y, pts[2].Y))
+	var y0 = ScalarCeilToInt(ScalarMax(top-0.5, clip.Top))
+	var y1 = ScalarCeilToInt(ScalarMin(bottom-0.5, clip.B()))
+	for y := y0; y < y1; y++ {
+		var cy = Scalar(y) + 0.5
+		var xs [2]Scalar
+		var n = 0
+		for i := 0; i < 3 && n < 2; i++ {
+			var p0, p1 = pts[i], pts[(i+1)%3]
+			if p0.Y > p1.Y {
+				p0, p1 = p1, p0
+			}
+			if cy < p0.Y || cy >= p1.Y {
+				continue
+			}
+			xs[n] = p0.X + (cy-p0.Y)*(p1.X-p0.X)/(p1.Y-p0.Y)
+			n++
+		}
+		if n < 2 {
+			continue
+		}
+		var left, right = ScalarMin(xs[0], xs[1]), ScalarMax(xs[0], xs[1])
+		var x0 = ScalarCeilToInt(ScalarMax(left-0.5, clip.Left))
+		var x1 = ScalarCeilToInt(ScalarMin(right-0.5, clip.R()))
+		if x0 < x1 {
+			blitter.BlitH(x0, y, x1-x0)
+		}
+	}
+}
+
+/** DrawVertices
+Fill the triangles of a mesh with paint. The vertices are interpreted as
+triangles according to vmode, taking them in order or in the order of
+indices if it isn't nil.
+
+colors, if not nil, are interpolated across each triangle, instead of the
+paint's color. texs, if not nil, map the paint's shader into each triangle:
+they are the points of the shader drawn at each vertex. With both, the
+shader's colors are blended with the vertex colors through xmode, or
+KXfermodeModeModulate if xmode is nil. Without either, the triangles are
+filled with the paint as is. */
+func (draw *Draw) DrawVertices(vmode CanvasVertexMode, vertexCount int, vertices []Point, texs []Point,
+	colors []Color, xmode *Xfermode, indices []uint16, indexCount int, paint *Paint) {
+	if vertexCount < 3 || (indices != nil && indexCount < 3) || draw.rasterClip.IsEmpty() {
+		return
+	}
+
+	// transform out vertices into device coordinates.
+	var devVerts = make([]Point, vertexCount)
+	draw.matrix.MapPoints(devVerts, vertices[:vertexCount])
+
+	var p = paint.Clone()
+	if texs != nil && paint.Shader() == nil {
+		// nothing to map the texture coordinates into.
+		texs = nil
+	}
+	var shader *tVerticesShader
+	if texs != nil || colors != nil {
+		shader = &tVerticesShader{hasColors: colors != nil, mode: KXfermodeModeModulate}
+		if texs != nil {
+			shader.texture = paint.Shader()
+		}
+		if xmode != nil {
+			if mode, ok := XfermodeAsMode(xmode); ok {
+				shader.mode = mode
+			}
+		}
+		p.SetShader(NewShader(shader, nil))
+	}
+
+	var blitter = newAutoBlitterChooser(draw.dst, draw.matrix, p, false).Blitter()
+	var clip = draw.rasterClip.Bounds()
+	var state = newVertexState(vmode, vertexCount, indices, indexCount)
+	for state.next() {
+		var devPts = state.points(devVerts)
+		if shader != nil {
+			var triColors [3]Color
+			if colors != nil {
+				triColors = [3]Color{colors[state.f0], colors[state.f1], colors[state.f2]}
+			}
+			var texMatrix *Matrix
+			if texs != nil {
+				var inverse, ok = triangleMatrix(state.points(texs)).Invert()
+				if !ok {
+					continue
+				}
+				texMatrix = triangleMatrix(state.points(vertices))
+				texMatrix.PreConcat(inverse)
+			}
+			if !shader.setTriangle(devPts, triColors, texMatrix) {
+				continue
+			}
+		}
+		fillTriangle(devPts, clip, blitter)
+	}
+}
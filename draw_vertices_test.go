@@ -0,0 +1,94 @@
+package ggk
+
+import "testing"
+
+func TestDrawVertices(t *testing.T) {
+	// a square of 8 from (0, 0), as a fan, a strip and indexed triangles.
+	var square = []Point{{0, 0}, {8, 0}, {8, 8}, {0, 8}}
+	var strip = []Point{{0, 0}, {8, 0}, {0, 8}, {8, 8}}
+	var red, blue = PackARGB32(0xff, 0xff, 0, 0), PackARGB32(0xff, 0, 0, 0xff)
+	var halfRed, halfRedPM = ColorWithARGB(0x80, 0xff, 0, 0), PackARGB32(0x80, 0x80, 0, 0)
+
+	var recorder = NewPictureRecorder()
+	recorder.BeginRecording(MakeRect(0, 0, 4, 4)).DrawRect(MakeRect(0, 0, 2, 2), newTestPaint(KColorRed))
+	var texture = NewPictureShader(recorder.FinishRecordingAsPicture(), KShaderTileModeClamp, KShaderTileModeClamp,
+		nil, nil)
+	var texs = []Point{{0, 0}, {4, 0}, {4, 4}, {0, 4}}
+
+	var tests = []struct {
+		name    string
+		mode    CanvasVertexMode
+		verts   []Point
+		texs    []Point
+		colors  []Color
+		xmode   *Xfermode
+		indices []uint16
+		shader  *Shader
+		alpha   uint8
+		points  []Point
+		want    []uint32
+	}{
+		{"fan", KCanvasVertexModeTriangleFan, square, nil, []Color{KColorRed, KColorRed, KColorRed, KColorRed}, nil,
+			nil, nil, 0xff, []Point{{0, 0}, {7, 7}, {4, 4}, {8, 4}, {4, 8}}, []uint32{red, red, red, 0, 0}},
+		{"strip", KCanvasVertexModeTriangleStrip, strip, nil, []Color{KColorBlue, KColorBlue, KColorBlue, KColorBlue},
+			nil, nil, nil, 0xff, []Point{{0, 7}, {7, 0}, {3, 4}}, []uint32{blue, blue, blue}},
+		{"indices", KCanvasVertexModeTriangles, square, nil, []Color{halfRed, halfRed, halfRed, halfRed}, nil,
+			[]uint16{0, 1, 2, 0, 2, 3}, nil, 0xff, []Point{{1, 6}, {4, 4}, {3, 3}, {6, 1}},
+			[]uint32{halfRedPM, halfRedPM, halfRedPM, halfRedPM}},
+		{"no colors", KCanvasVertexModeTriangleFan, square, nil, nil, nil, nil, nil, 0xff,
+			[]Point{{2, 5}, {9, 5}}, []uint32{PackARGB32(0xff, 0, 0, 0), 0}},
+		{"paint alpha", KCanvasVertexModeTriangleFan, square, nil, []Color{KColorRed, KColorRed, KColorRed, KColorRed},
+			nil, nil, nil, 0x80, []Point{{2, 5}}, []uint32{PackARGB32(0x80, 0x80, 0, 0)}},
+		{"texture", KCanvasVertexModeTriangleFan, []Point{{0, 0}, {16, 0}, {16, 16}, {0, 16}}, texs, nil, nil, nil,
+			texture, 0xff, []Point{{1, 1}, {7, 7}, {9, 2}, {2, 9}, {12, 12}}, []uint32{red, red, 0, 0, 0}},
+		{"modulate", KCanvasVertexModeTriangleFan, square, texs, []Color{KColorBlue, KColorBlue, KColorBlue, KColorBlue},
+			nil, nil, NewShader_Color(KColorWhite), 0xff, []Point{{4, 4}}, []uint32{blue}},
+		{"xfermode", KCanvasVertexModeTriangleFan, square, texs, []Color{KColorBlue, KColorBlue, KColorBlue, KColorBlue},
+			NewXfermodeWithMode(KXfermodeModeSrc), nil, NewShader_Color(KColorRed), 0xff, []Point{{4, 4}}, []uint32{red}},
+	}
+	for _, test := range tests {
+		var paint = NewPaint()
+		paint.SetShader(test.shader)
+		paint.SetAlpha(test.alpha)
+		var draw = func(canvas *Canvas) {
+			canvas.DrawVertices(test.mode, len(test.verts), test.verts, test.texs, test.colors, test.xmode,
+				test.indices, len(test.indices), paint)
+		}
+		var canvas, pixels = newTestPictureCanvas(16, 16)
+		draw(canvas)
+		for i, point := range test.points {
+			if got := pixels.Pixel32(int(point.X), int(point.Y)); got != test.want[i] {
+				t.Errorf("%v at %v want %#x got %#x", test.name, point, test.want[i], got)
+			}
+		}
+
+		var recorder = NewPictureRecorder()
+		draw(recorder.BeginRecording(MakeRect(0, 0, 16, 16)))
+		var replay, replayed = newTestPictureCanvas(16, 16)
+		replay.DrawPicture(recorder.FinishRecordingAsPicture(), nil, nil)
+		for i, point := range test.points {
+			if got := replayed.Pixel32(int(point.X), int(point.Y)); got != test.want[i] {
+				t.Errorf("%v replayed at %v want %#x got %#x", test.name, point, test.want[i], got)
+			}
+		}
+	}
+}
+
+func TestDrawVerticesColorInterpolation(t *testing.T) {
+	// red on the left and blue on the right.
+	var verts = []Point{{0, 0}, {16, 0}, {0, 4}, {16, 4}}
+	var colors = []Color{KColorRed, KColorBlue, KColorRed, KColorBlue}
+	var canvas, pixels = newTestPictureCanvas(16, 4)
+	canvas.DrawVertices(KCanvasVertexModeTriangleStrip, 4, verts, nil, colors, nil, nil, 0, NewPaint())
+	for y := 0; y < 4; y++ {
+		for x := 1; x < 16; x++ {
+			var prev, p = pixels.Pixel32(x-1, y), pixels.Pixel32(x, y)
+			if GetPackedA32(p) != 0xff || GetPackedR32(p) >= GetPackedR32(prev) || GetPackedB32(p) <= GetPackedB32(prev) {
+				t.Fatalf("at (%v, %v) want more blue and less red than %#x got %#x", x, y, prev, p)
+			}
+		}
+	}
+	if mid := pixels.Pixel32(8, 2); GetPackedR32(mid) != 0x78 || GetPackedB32(mid) != 0x87 {
+		t.Errorf("at the middle want half red and half blue got %#x", mid)
+	}
+}
@@ -1,5 +1,18 @@
 package ggk
 
+import "sync/atomic"
+
+/** DrawableImpl
+is implemented by each kind of Drawable. */
+type DrawableImpl interface {
+	// OnDraw draws the contents of the drawable into canvas, in the
+	// coordinates of the drawable.
+	OnDraw(drawable *Drawable, canvas *Canvas)
+	// OnBounds returns the bounds of what the drawable draws, in its own
+	// coordinates.
+	OnBounds(drawable *Drawable) Rect
+}
+
 /**
  *  Base-class for objects that draw into SkCanvas.
  *
@@ -8,5 +21,65 @@ package ggk
  *  change its generation ID whenever its internal state changes such that it will draw differently.
  */
 type Drawable struct {
+	Impl         DrawableImpl
+	generationID uint32
+}
+
+var gDrawableGenerationID uint32
+
+func NewDrawable(impl DrawableImpl) *Drawable {
+	return &Drawable{Impl: impl}
+}
+
+/** Draw
+Draw the contents of the drawable into canvas, through matrix, which may be
+nil, concatenated with the matrix of canvas. The matrix and the clip of
+canvas are left as they were. */
+func (drawable *Drawable) Draw(canvas *Canvas, matrix *Matrix) {
+	var saveCount = canvas.SaveCount()
+	canvas.Save()
+	if matrix != nil {
+		canvas.Concat(matrix)
+	}
+	drawable.Impl.OnDraw(drawable, canvas)
+	canvas.RestoreToCount(saveCount)
+}
+
+// DrawAt draws the contents of the drawable into canvas, offset by (x, y).
+func (drawable *Drawable) DrawAt(canvas *Canvas, x, y Scalar) {
+	var matrix = NewMatrix()
+	matrix.SetTranslate(x, y)
+	drawable.Draw(canvas, matrix)
+}
+
+/** NewPictureSnapshot
+Return a picture recording what the drawable draws now, so later changes to
+it don't change the picture. */
+func (drawable *Drawable) NewPictureSnapshot() *Picture {
+	var recorder = NewPictureRecorder()
+	drawable.Impl.OnDraw(drawable, recorder.BeginRecording(drawable.Bounds()))
+	return recorder.FinishRecordingAsPicture()
+}
+
+/** GenerationID
+Return a non-zero value unique to the current contents of the drawable. It
+stays the same until NotifyDrawingChanged is called. */
+func (drawable *Drawable) GenerationID() uint32 {
+	if drawable.generationID == 0 {
+		drawable.generationID = atomic.AddUint32(&gDrawableGenerationID, 1)
+	}
+	return drawable.generationID
+}
+
+// Bounds returns the bounds of what the drawable draws, in its own
+// coordinates.
+func (drawable *Drawable) Bounds() Rect {
+	return drawable.Impl.OnBounds(drawable)
+}
 
+/** NotifyDrawingChanged
+Tell the drawable that what it draws has changed, so it gets a new
+generation ID. Implementations call it whenever their state changes. */
+func (drawable *Drawable) NotifyDrawingChanged() {
+	drawable.generationID = 0
 }
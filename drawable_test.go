@@ -0,0 +1,98 @@
+package ggk
+
+import "testing"
+
+// tTestDrawable fills rect with color.
+type tTestDrawable struct {
+	rect  Rect
+	color Color
+}
+
+func (impl *tTestDrawable) OnDraw(drawable *Drawable, canvas *Canvas) {
+	canvas.DrawRect(impl.rect, newTestPaint(impl.color))
+}
+
+func (impl *tTestDrawable) OnBounds(drawable *Drawable) Rect {
+	return impl.rect
+}
+
+func (impl *tTestDrawable) setColor(drawable *Drawable, color Color) {
+	impl.color = color
+	drawable.NotifyDrawingChanged()
+}
+
+func TestDrawableGenerationID(t *testing.T) {
+	var impl = &tTestDrawable{MakeRect(0, 0, 4, 4), KColorRed}
+	var drawable, other = NewDrawable(impl), NewDrawable(&tTestDrawable{})
+	var id = drawable.GenerationID()
+	if id == 0 || id != drawable.GenerationID() {
+		t.Errorf("GenerationID want a stable non-zero id got %v then %v", id, drawable.GenerationID())
+	}
+	if other.GenerationID() == id {
+		t.Errorf("GenerationID want unique ids got %v twice", id)
+	}
+	impl.setColor(drawable, KColorBlue)
+	if changed := drawable.GenerationID(); changed == 0 || changed == id {
+		t.Errorf("GenerationID after a change want a new id got %v", changed)
+	}
+}
+
+func TestDrawDrawable(t *testing.T) {
+	var red, blue = PackARGB32(0xff, 0xff, 0, 0), PackARGB32(0xff, 0, 0, 0xff)
+	var impl = &tTestDrawable{MakeRect(0, 0, 4, 4), KColorRed}
+	var drawable = NewDrawable(impl)
+	if bounds := drawable.Bounds(); bounds != impl.rect {
+		t.Errorf("Bounds want %v got %v", impl.rect, bounds)
+	}
+
+	// drawn directly, offset and scaled.
+	var canvas, pixels = newTestPictureCanvas(16, 16)
+	canvas.DrawDrawableAt(drawable, 8, 0)
+	var matrix = NewMatrix()
+	matrix.SetScale(2, 2)
+	canvas.DrawDrawable(drawable, matrix)
+	var tests = []struct {
+		point Point
+		want  uint32
+	}{
+		{Point{8, 0}, red}, {Point{11, 3}, red}, {Point{12, 4}, 0}, {Point{7, 7}, red}, {Point{8, 8}, 0},
+	}
+	for _, test := range tests {
+		if got := pixels.Pixel32(int(test.point.X), int(test.point.Y)); got != test.want {
+			t.Errorf("DrawDrawable at %v want %#x got %#x", test.point, test.want, got)
+		}
+	}
+	if canvas.SaveCount() != 1 || !canvas.TotalMatrix().IsIdentity() {
+		t.Errorf("DrawDrawable want the canvas state restored")
+	}
+
+	// a snapshot keeps what the drawable draws when it's taken.
+	var snapshot = drawable.NewPictureSnapshot()
+	impl.setColor(drawable, KColorBlue)
+	canvas, pixels = newTestPictureCanvas(16, 16)
+	canvas.DrawPicture(snapshot, nil, nil)
+	if got := pixels.Pixel32(1, 1); got != red {
+		t.Errorf("NewPictureSnapshot want %#x got %#x", red, got)
+	}
+
+	// a recording draws the drawable as it is when the recording finishes.
+	var recorder = NewPictureRecorder()
+	recorder.BeginRecording(MakeRect(0, 0, 16, 16)).DrawDrawableAt(drawable, 4, 4)
+	impl.setColor(drawable, KColorRed)
+	var picture = recorder.FinishRecordingAsPicture()
+	impl.setColor(drawable, KColorBlue)
+	canvas, pixels = newTestPictureCanvas(16, 16)
+	canvas.DrawPicture(picture, nil, nil)
+	if got := pixels.Pixel32(5, 5); got != red {
+		t.Errorf("recorded DrawDrawable want %#x got %#x", red, got)
+	}
+	if got := pixels.Pixel32(1, 1); got != 0 {
+		t.Errorf("recorded DrawDrawable outside of it want 0 got %#x", got)
+	}
+
+	canvas, pixels = newTestPictureCanvas(16, 16)
+	canvas.DrawDrawable(drawable, nil)
+	if got := pixels.Pixel32(1, 1); got != blue {
+		t.Errorf("DrawDrawable after a change want %#x got %#x", blue, got)
+	}
+}
@@ -0,0 +1,113 @@
+package ggk
+
+type DropShadowImageFilterShadowMode int
+
+const (
+	KDropShadowImageFilterShadowModeDrawShadowAndForeground DropShadowImageFilterShadowMode = iota
+	KDropShadowImageFilterShadowModeDrawShadowOnly
+)
+
+/** tDropShadowImageFilter
+is the ImageFilterImpl that draws a blurred copy of the alpha of its input,
+offset and in color, under the input unless the mode draws only the
+shadow. */
+type tDropShadowImageFilter struct {
+	offset Point
+	sigma  Point
+	color  Color
+	mode   DropShadowImageFilterShadowMode
+}
+
+/** NewDropShadowImageFilter
+Return a filter that draws the shadow of input, or of the source if input
+is nil, offset by (dx, dy) and blurred with sigmaX and sigmaY. */
+func NewDropShadowImageFilter(dx, dy, sigmaX, sigmaY Scalar, color Color, mode DropShadowImageFilterShadowMode,
+	input *ImageFilter, cropRect *ImageFilterCropRect) *ImageFilter {
+	if sigmaX < 0 || sigmaY < 0 {
+		return nil
+	}
+	var impl = &tDropShadowImageFilter{
+		offset: Point{dx, dy},
+		sigma:  Point{sigmaX, sigmaY},
+		color:  color,
+		mode:   mode,
+	}
+	return NewImageFilter(impl, []*ImageFilter{input}, cropRect)
+}
+
+// Return the offset of the shadow in whole device pixels.
+func (impl *tDropShadowImageFilter) deviceOffset(ctm *Matrix) Point {
+	var v = []Point{impl.offset}
+	ctm.MapVectors(v, v)
+	return Point{ScalarFloor(v[0].X + KScalarHalf), ScalarFloor(v[0].Y + KScalarHalf)}
+}
+
+// Return the bounds of the shadow of src and of src itself unless only
+// the shadow is drawn.
+func (impl *tDropShadowImageFilter) shadowBounds(src Rect, offset, outset Point) Rect {
+	var dst = src
+	dst.Offset(offset.X, offset.Y)
+	dst.Outset(outset.X, outset.Y)
+	if impl.mode == KDropShadowImageFilterShadowModeDrawShadowAndForeground {
+		dst.Join(src)
+	}
+	return dst
+}
+
+func (impl *tDropShadowImageFilter) OnFilterImage(filter *ImageFilter, src *Bitmap, ctx *ImageFilterContext) (*Bitmap, Point, bool) {
+	var input, inputOffset, ok = filter.filterInput(0, src, ctx)
+	if !ok {
+		return nil, PointZero, false
+	}
+	var sigma = deviceBlurSigma(impl.sigma, ctx.CTM)
+	var outset = blurOutset(sigma)
+	var offset = impl.deviceOffset(ctx.CTM)
+	var dstBounds Rect
+	if dstBounds, ok = filter.applyCropRect(ctx, impl.shadowBounds(filterBitmapBounds(input, inputOffset), offset, outset)); !ok {
+		return nil, PointZero, false
+	}
+
+	// color the alpha of the offset input, then blur it.
+	var workBounds = dstBounds
+	workBounds.Outset(outset.X, outset.Y)
+	var shadowOffset = Point{inputOffset.X + offset.X, inputOffset.Y + offset.Y}
+	var shadow = copyFilterBitmap(input, shadowOffset, workBounds)
+	var pixels = filterPixmap(shadow)
+	var color = premulPixel32(impl.color)
+	for y := 0; y < int(workBounds.Height); y++ {
+		for x := 0; x < int(workBounds.Width); x++ {
+			var alpha = GetPackedA32(pixels.Pixel32(x, y))
+			pixels.SetPixel32(x, y, AlphaMulQ(color, Alpha255To256(alpha)))
+		}
+	}
+	blurFilterBitmap(shadow, sigma)
+
+	var dst = copyFilterBitmap(shadow, Point{workBounds.Left, workBounds.Top}, dstBounds)
+	if impl.mode == KDropShadowImageFilterShadowModeDrawShadowAndForeground {
+		drawFilterBitmap(dst, input, inputOffset.X-dstBounds.Left, inputOffset.Y-dstBounds.Top, true)
+	}
+	return dst, Point{dstBounds.Left, dstBounds.Top}, true
+}
+
+func (impl *tDropShadowImageFilter) OnFilterBounds(filter *ImageFilter, src Rect, ctm *Matrix, direction ImageFilterMapDirection) Rect {
+	var outset = blurOutset(deviceBlurSigma(impl.sigma, ctm))
+	var offset = impl.deviceOffset(ctm)
+	if direction == KImageFilterMapDirectionReverse {
+		offset = Point{-offset.X, -offset.Y}
+	}
+	return filter.filterBoundsWithNode(src, ctm, direction, func(r Rect) Rect {
+		return impl.shadowBounds(r, offset, outset)
+	})
+}
+
+func (impl *tDropShadowImageFilter) OnComputeFastBounds(filter *ImageFilter, src Rect) Rect {
+	return impl.shadowBounds(filter.inputsFastBounds(src), impl.offset, Point{3 * impl.sigma.X, 3 * impl.sigma.Y})
+}
+
+func (impl *tDropShadowImageFilter) OnAffectsTransparentBlack() bool {
+	return false
+}
+
+func (impl *tDropShadowImageFilter) OnAsAColorFilter(filter *ImageFilter) (*ColorFilter, bool) {
+	return nil, false
+}
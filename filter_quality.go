@@ -7,5 +7,8 @@ package ggk
 type FilterQuality int
 
 const (
-	KFilterQualityNone = FilterQuality(iota)//< fastest but lowest quality, typically nearest-neighbor
+	KFilterQualityNone   = FilterQuality(iota) //< fastest but lowest quality, typically nearest-neighbor
+	KFilterQualityLow                          //< typically bilerp
+	KFilterQualityMedium                       //< typically bilerp + mipmaps for down-scaling
+	KFilterQualityHigh                         //< slowest but highest quality, typically bicubic or better
 )
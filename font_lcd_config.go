@@ -1,6 +1,9 @@
 package ggk
 
-var gLCDOrientation LCDOrientation = KLCDOrientationHorizontal
+var (
+	gLCDOrder       LCDOrder       = KLCDOrderRGB
+	gLCDOrientation LCDOrientation = KLCDOrientationHorizontal
+)
 
 /** LCD color elements can vary in order. For subpixel text we need to know
 	the order which the LCDs uses so that the color fringes are in the
@@ -17,7 +20,7 @@ var gLCDOrientation LCDOrientation = KLCDOrientationHorizontal
 type LCDOrder int
 
 const (
-	KLCDOrderRGB = iota //< this is the default.
+	KLCDOrderRGB = LCDOrder(iota) //< this is the default.
 	KLCDOrderBGR
 	KLCDOrderNone
 )
@@ -34,7 +37,7 @@ const (
 type LCDOrientation int
 
 const (
-	KLCDOrientationHorizontal = iota // this is the default
+	KLCDOrientationHorizontal = LCDOrientation(iota) // this is the default
 	KLCDOrientationVertical
 )
 
@@ -43,10 +46,20 @@ type FontLCDConfig struct {
 
 /** @deprecated set on Device creation. */
 func FontLCDConfigSetSubpixelOrientation(orientation LCDOrientation) {
-	toimpl()
+	gLCDOrientation = orientation
 }
 
 /** @deprecated get from Device. */
 func FontLCDConfigSubpixelOrientation() LCDOrientation {
 	return gLCDOrientation
-}
\ No newline at end of file
+}
+
+/** @deprecated set on Device creation. */
+func FontLCDConfigSetSubpixelOrder(order LCDOrder) {
+	gLCDOrder = order
+}
+
+/** @deprecated get from Device. */
+func FontLCDConfigSubpixelOrder() LCDOrder {
+	return gLCDOrder
+}
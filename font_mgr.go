@@ -0,0 +1,321 @@
+package ggk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+/** FontStyleSet
+is the typefaces of one font family, e.g. the regular, bold and italic
+faces of "DejaVu Sans". */
+type FontStyleSet struct {
+	familyName string
+	typefaces  []*Typeface
+}
+
+func NewFontStyleSet(familyName string, typefaces []*Typeface) *FontStyleSet {
+	return &FontStyleSet{
+		familyName: familyName,
+		typefaces:  typefaces,
+	}
+}
+
+func (set *FontStyleSet) FamilyName() string {
+	return set.familyName
+}
+
+func (set *FontStyleSet) Count() int {
+	return len(set.typefaces)
+}
+
+// Style returns the style of the index-th typeface of the set.
+func (set *FontStyleSet) Style(index int) FontStyle {
+	return set.typefaces[index].FontStyle()
+}
+
+// CreateTypeface returns the index-th typeface of the set.
+func (set *FontStyleSet) CreateTypeface(index int) *Typeface {
+	return set.typefaces[index]
+}
+
+/** MatchStyle
+Return the typeface of the set whose style is closest to pattern, by the
+font matching algorithm of CSS: the closest width first, then the closest
+slant, then the closest weight. Returns nil if the set is empty. */
+func (set *FontStyleSet) MatchStyle(pattern FontStyle) *Typeface {
+	var best *Typeface
+	var bestScore = -1
+	for _, typeface := range set.typefaces {
+		if score := typeface.FontStyle().matchScore(pattern); score > bestScore {
+			best, bestScore = typeface, score
+		}
+	}
+	return best
+}
+
+// Families that are picked as the default family when a font manager has
+// them, in order of preference. Otherwise the first family added is.
+var gFontMgrDefaultFamilies = []string{
+	"Arial", "Helvetica", "Verdana", "Roboto", "Noto Sans", "DejaVu Sans", "Liberation Sans",
+}
+
+type tFontFallbackKey struct {
+	familyName string
+	style      FontStyle
+	character  Unichar
+}
+
+/** FontMgr
+finds typefaces by family name and style among the fonts it is given, and
+the typeface to fall back to for a character a font has no glyph for.
+Text is drawn with fallback typefaces from the default manager. */
+type FontMgr struct {
+	mutex     sync.Mutex
+	families  []*FontStyleSet
+	fallbacks map[tFontFallbackKey]*Typeface // memoized fallback lookups.
+}
+
+// NewFontMgr returns a font manager with the typefaces, which may be none.
+func NewFontMgr(typefaces ...*Typeface) *FontMgr {
+	var mgr = &FontMgr{}
+	for _, typeface := range typefaces {
+		mgr.AddTypeface(typeface)
+	}
+	return mgr
+}
+
+/** NewFontMgrFromDirectory
+Return a font manager with the fonts of the font files in dir and its
+subdirectories, including each font of font collections. Files that are not
+fonts or fail to load are skipped, and so are OpenType fonts with CFF
+outlines, which many .otf files and CJK collections have. */
+func NewFontMgrFromDirectory(dir string) (*FontMgr, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	var mgr = NewFontMgr()
+	mgr.addDirectory(dir)
+	return mgr, nil
+}
+
+func (mgr *FontMgr) addDirectory(dir string) {
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".ttf", ".otf", ".ttc", ".otc":
+			var data, err = ioutil.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			// fonts with CFF outlines fail with ErrTrueTypeCFF.
+			var typefaces, _ = NewTypefacesFromData(data)
+			for _, typeface := range typefaces {
+				mgr.AddTypeface(typeface)
+			}
+		}
+		return nil
+	})
+}
+
+// AddTypeface adds typeface to the family of its family name.
+func (mgr *FontMgr) AddTypeface(typeface *Typeface) {
+	mgr.mutex.Lock()
+	defer mgr.mutex.Unlock()
+
+	mgr.fallbacks = nil
+	var familyName = typeface.FamilyName()
+	for _, family := range mgr.families {
+		if strings.EqualFold(family.familyName, familyName) {
+			family.typefaces = append(family.typefaces, typeface)
+			return
+		}
+	}
+	mgr.families = append(mgr.families, NewFontStyleSet(familyName, []*Typeface{typeface}))
+}
+
+func (mgr *FontMgr) CountFamilies() int {
+	mgr.mutex.Lock()
+	defer mgr.mutex.Unlock()
+	return len(mgr.families)
+}
+
+func (mgr *FontMgr) FamilyName(index int) string {
+	mgr.mutex.Lock()
+	defer mgr.mutex.Unlock()
+	return mgr.families[index].familyName
+}
+
+// CreateStyleSet returns the typefaces of the index-th family.
+func (mgr *FontMgr) CreateStyleSet(index int) *FontStyleSet {
+	mgr.mutex.Lock()
+	defer mgr.mutex.Unlock()
+	return mgr.families[index].clone()
+}
+
+func (set *FontStyleSet) clone() *FontStyleSet {
+	return NewFontStyleSet(set.familyName, append([]*Typeface(nil), set.typefaces...))
+}
+
+/** MatchFamily
+Return the typefaces of the family named familyName, compared without
+case, or of the default family if familyName is "". Returns nil if there
+is no such family. */
+func (mgr *FontMgr) MatchFamily(familyName string) *FontStyleSet {
+	mgr.mutex.Lock()
+	defer mgr.mutex.Unlock()
+	if family := mgr.findFamily(familyName); family != nil {
+		return family.clone()
+	}
+	return nil
+}
+
+// MatchFamilyStyle returns the typeface of the family named familyName (or
+// the default family if it is "") that best matches style, or nil if there
+// is no such family.
+func (mgr *FontMgr) MatchFamilyStyle(familyName string, style FontStyle) *Typeface {
+	mgr.mutex.Lock()
+	defer mgr.mutex.Unlock()
+	if family := mgr.findFamily(familyName); family != nil {
+		return family.MatchStyle(style)
+	}
+	return nil
+}
+
+/** MatchFamilyStyleCharacter
+Return a typeface that has a glyph for character, to draw it with when the
+font of familyName and style has none. The typefaces of familyName are
+tried first, then those of the other families in the order they were
+added, picking the one that best matches style within a family. Returns
+nil if no typeface has the character. */
+func (mgr *FontMgr) MatchFamilyStyleCharacter(familyName string, style FontStyle, character Unichar) *Typeface {
+	mgr.mutex.Lock()
+	defer mgr.mutex.Unlock()
+
+	var key = tFontFallbackKey{strings.ToLower(familyName), style, character}
+	if typeface, ok := mgr.fallbacks[key]; ok {
+		return typeface
+	}
+	var families = mgr.families
+	if family := mgr.findFamily(familyName); family != nil {
+		families = append([]*FontStyleSet{family}, families...)
+	}
+	var match *Typeface
+	for _, family := range families {
+		var candidates = NewFontStyleSet(family.familyName, nil)
+		for _, typeface := range family.typefaces {
+			if typeface.HasChar(character) {
+				candidates.typefaces = append(candidates.typefaces, typeface)
+			}
+		}
+		if match = candidates.MatchStyle(style); match != nil {
+			break
+		}
+	}
+	if mgr.fallbacks == nil {
+		mgr.fallbacks = make(map[tFontFallbackKey]*Typeface)
+	}
+	mgr.fallbacks[key] = match
+	return match
+}
+
+/** LegacyMakeTypeface
+Return the typeface that best matches familyName and style, using the
+default family if there is no such family. Returns nil if the manager has
+no fonts. */
+func (mgr *FontMgr) LegacyMakeTypeface(familyName string, style FontStyle) *Typeface {
+	mgr.mutex.Lock()
+	defer mgr.mutex.Unlock()
+	var family = mgr.findFamily(familyName)
+	if family == nil {
+		family = mgr.defaultFamily()
+	}
+	if family == nil {
+		return nil
+	}
+	return family.MatchStyle(style)
+}
+
+// Return the family named familyName, or the default family if it is "".
+func (mgr *FontMgr) findFamily(familyName string) *FontStyleSet {
+	if familyName == "" {
+		return mgr.defaultFamily()
+	}
+	for _, family := range mgr.families {
+		if strings.EqualFold(family.familyName, familyName) {
+			return family
+		}
+	}
+	return nil
+}
+
+func (mgr *FontMgr) defaultFamily() *FontStyleSet {
+	for _, name := range gFontMgrDefaultFamilies {
+		for _, family := range mgr.families {
+			if strings.EqualFold(family.familyName, name) {
+				return family
+			}
+		}
+	}
+	if len(mgr.families) > 0 {
+		return mgr.families[0]
+	}
+	return nil
+}
+
+var gDefaultFontMgr struct {
+	sync.Mutex
+	mgr *FontMgr
+}
+
+/** FontMgrDefault
+Return the default font manager. Unless one is set with SetFontMgrDefault
+it holds the fonts installed on the system, which are loaded on first use. */
+func FontMgrDefault() *FontMgr {
+	gDefaultFontMgr.Lock()
+	defer gDefaultFontMgr.Unlock()
+	if gDefaultFontMgr.mgr == nil {
+		var mgr = NewFontMgr()
+		for _, dir := range systemFontDirectories() {
+			mgr.addDirectory(dir)
+		}
+		gDefaultFontMgr.mgr = mgr
+	}
+	return gDefaultFontMgr.mgr
+}
+
+// SetFontMgrDefault replaces the default font manager, or restores the
+// system one if mgr is nil.
+func SetFontMgrDefault(mgr *FontMgr) {
+	gDefaultFontMgr.Lock()
+	defer gDefaultFontMgr.Unlock()
+	gDefaultFontMgr.mgr = mgr
+}
+
+// Return the directories fonts are installed in on this platform.
+func systemFontDirectories() []string {
+	var dirs, userDirs []string
+	switch runtime.GOOS {
+	case "windows":
+		dirs = []string{filepath.Join(os.Getenv("WINDIR"), "Fonts")}
+	case "darwin", "ios":
+		dirs = []string{"/System/Library/Fonts", "/Library/Fonts"}
+		userDirs = []string{filepath.Join("Library", "Fonts")}
+	case "android":
+		dirs = []string{"/system/fonts"}
+	default:
+		dirs = []string{"/usr/share/fonts", "/usr/local/share/fonts"}
+		userDirs = []string{".fonts", filepath.Join(".local", "share", "fonts")}
+	}
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		for _, dir := range userDirs {
+			dirs = append(dirs, filepath.Join(home, dir))
+		}
+	}
+	return dirs
+}
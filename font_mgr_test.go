@@ -0,0 +1,310 @@
+package ggk
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func init() {
+	// the tests lay out text with their own fonts only, whatever fonts are
+	// installed on the machine.
+	SetFontMgrDefault(NewFontMgr())
+}
+
+// makeTestNameTable builds a name table with the family name records.
+func makeTestNameTable(records ...tTestNameRecord) []byte {
+	var storage []byte
+	var table = putU16(putU16(putU16(nil, 0), len(records)), 6+12*len(records))
+	for _, r := range records {
+		var data []byte
+		if r.platform == 1 {
+			data = []byte(r.name)
+		} else {
+			for _, c := range r.name {
+				data = putU16(data, int(c))
+			}
+		}
+		table = putU16(putU16(putU16(table, r.platform), r.encoding), r.language)
+		table = putU16(putU16(putU16(table, r.nameID), len(data)), len(storage))
+		storage = append(storage, data...)
+	}
+	return append(table, storage...)
+}
+
+type tTestNameRecord struct {
+	platform, encoding, language, nameID int
+	name                                 string
+}
+
+// newFontMgrTestTypeface returns a test font of family with the style and
+// a 600 unit wide glyph for each of chars.
+func newFontMgrTestTypeface(t *testing.T, family string, style FontStyle, chars ...Unichar) *Typeface {
+	var glyphs []tTestGlyph
+	for _, c := range chars {
+		glyphs = append(glyphs, tTestGlyph{char: c, advance: 600, vAdvance: 1000,
+			contours: [][][2]int{{{0, 0}, {0, 500}, {500, 500}, {500, 0}}}})
+	}
+	var os2 = make([]byte, 96)
+	binary.BigEndian.PutUint16(os2[0:], 2)
+	binary.BigEndian.PutUint16(os2[4:], uint16(style.Weight()))
+	binary.BigEndian.PutUint16(os2[6:], uint16(style.Width()))
+	switch style.Slant() {
+	case KFontStyleSlantItalic:
+		binary.BigEndian.PutUint16(os2[62:], 1)
+	case KFontStyleSlantOblique:
+		binary.BigEndian.PutUint16(os2[62:], 1<<9)
+	}
+	var name = makeTestNameTable(tTestNameRecord{3, 1, 0x0409, kTrueTypeNameFamily, family})
+	var typeface, err = NewTypefaceFromData(makeTestTrueTypeFont(glyphs,
+		tTestTable{"OS/2", os2}, tTestTable{"name", name}))
+	if err != nil {
+		t.Fatalf("NewTypefaceFromData() failed: %v", err)
+	}
+	return typeface
+}
+
+func TestTypefaceFamilyName(t *testing.T) {
+	var tests = []struct {
+		name    string
+		records []tTestNameRecord
+		family  string
+	}{
+		{"windows", []tTestNameRecord{{3, 1, 0x0409, 1, "Test Sans"}}, "Test Sans"},
+		{"macintosh", []tTestNameRecord{{1, 0, 0, 1, "Test Mac"}}, "Test Mac"},
+		{"prefer unicode", []tTestNameRecord{{1, 0, 0, 1, "Test Mac"}, {3, 1, 0x0409, 1, "Test Sans"}},
+			"Test Sans"},
+		{"prefer english", []tTestNameRecord{{3, 1, 0x0407, 1, "Test Deutsch"}, {3, 1, 0x0409, 1, "Test Sans"}},
+			"Test Sans"},
+		{"typographic family", []tTestNameRecord{{3, 1, 0x0409, 1, "Test Sans Light"},
+			{3, 1, 0x0409, 16, "Test Sans"}}, "Test Sans"},
+		{"no family", []tTestNameRecord{{3, 1, 0x0409, 4, "Test Sans Bold"}}, ""},
+	}
+	for _, test := range tests {
+		var typeface, err = NewTypefaceFromData(makeTestTrueTypeFont(gTestGlyphs,
+			tTestTable{"name", makeTestNameTable(test.records...)}))
+		if err != nil {
+			t.Fatalf("NewTypefaceFromData() failed: %v", err)
+		}
+		if name := typeface.FamilyName(); name != test.family {
+			t.Errorf("%v FamilyName() want %q got %q", test.name, test.family, name)
+		}
+	}
+}
+
+func TestTypefaceFontStyle(t *testing.T) {
+	var tests = []FontStyle{
+		FontStyleNormal(),
+		FontStyleBoldItalic(),
+		NewFontStyle(KFontStyleWeightLight, KFontStyleWidthCondensed, KFontStyleSlantOblique),
+	}
+	for _, style := range tests {
+		var typeface = newFontMgrTestTypeface(t, "Test", style, 'a')
+		if got := typeface.FontStyle(); got != style {
+			t.Errorf("FontStyle() want %+v got %+v", style, got)
+		}
+	}
+	if typeface := newTestTypeface(t); typeface.FontStyle() != FontStyleNormal() {
+		t.Errorf("FontStyle() of a font without weight want normal got %+v", typeface.FontStyle())
+	}
+}
+
+func TestFontMgrMatchFamilyStyle(t *testing.T) {
+	var regular = newFontMgrTestTypeface(t, "Test Sans", FontStyleNormal(), 'a')
+	var bold = newFontMgrTestTypeface(t, "Test Sans", FontStyleBold(), 'a')
+	var italic = newFontMgrTestTypeface(t, "Test Sans", FontStyleItalic(), 'a')
+	var condensed = newFontMgrTestTypeface(t, "Test Sans",
+		NewFontStyle(KFontStyleWeightNormal, KFontStyleWidthCondensed, KFontStyleSlantUpright), 'a')
+	var serif = newFontMgrTestTypeface(t, "Test Serif", FontStyleNormal(), 'a')
+	var mgr = NewFontMgr(serif, regular, bold, italic, condensed)
+
+	if n := mgr.CountFamilies(); n != 2 {
+		t.Fatalf("CountFamilies() want 2 got %v", n)
+	}
+	if set := mgr.MatchFamily("test sans"); set == nil || set.Count() != 4 {
+		t.Errorf("MatchFamily(test sans) want 4 typefaces got %v", set)
+	}
+
+	var tests = []struct {
+		name   string
+		family string
+		style  FontStyle
+		want   *Typeface
+	}{
+		{"regular", "Test Sans", FontStyleNormal(), regular},
+		{"bold", "Test Sans", FontStyleBold(), bold},
+		{"italic", "Test Sans", FontStyleItalic(), italic},
+		{"bold italic prefers slant", "Test Sans", FontStyleBoldItalic(), italic},
+		{"black prefers heavier", "Test Sans", NewFontStyle(KFontStyleWeightBlack,
+			KFontStyleWidthNormal, KFontStyleSlantUpright), bold},
+		{"medium prefers lighter", "Test Sans", NewFontStyle(KFontStyleWeightMedium,
+			KFontStyleWidthNormal, KFontStyleSlantUpright), regular},
+		{"condensed", "Test Sans", NewFontStyle(KFontStyleWeightBold,
+			KFontStyleWidthExtraCondensed, KFontStyleSlantUpright), condensed},
+		{"other family", "Test Serif", FontStyleBold(), serif},
+		{"default family", "", FontStyleNormal(), serif},
+		{"unknown family", "Test Mono", FontStyleNormal(), nil},
+	}
+	for _, test := range tests {
+		if got := mgr.MatchFamilyStyle(test.family, test.style); got != test.want {
+			t.Errorf("%v MatchFamilyStyle() want %v got %v", test.name, test.want, got)
+		}
+	}
+	if got := mgr.LegacyMakeTypeface("Test Mono", FontStyleBold()); got != serif {
+		t.Errorf("LegacyMakeTypeface() want the default family got %v", got)
+	}
+}
+
+func TestFontMgrMatchFamilyStyleCharacter(t *testing.T) {
+	var latin = newFontMgrTestTypeface(t, "Test Latin", FontStyleNormal(), 'a', 'b')
+	var latinBold = newFontMgrTestTypeface(t, "Test Latin", FontStyleBold(), 'a', 'b', 'c')
+	var greek = newFontMgrTestTypeface(t, "Test Greek", FontStyleNormal(), 'a', 0x03B1)
+	var greekBold = newFontMgrTestTypeface(t, "Test Greek", FontStyleBold(), 'a', 0x03B1)
+	var mgr = NewFontMgr(greek, greekBold, latin, latinBold)
+
+	var tests = []struct {
+		name   string
+		family string
+		style  FontStyle
+		char   Unichar
+		want   *Typeface
+	}{
+		{"same family first", "Test Latin", FontStyleNormal(), 'a', latin},
+		{"same family other style", "Test Latin", FontStyleNormal(), 'c', latinBold},
+		{"other family", "Test Latin", FontStyleNormal(), 0x03B1, greek},
+		{"other family style", "Test Latin", FontStyleBold(), 0x03B1, greekBold},
+		{"families in order", "Test Mono", FontStyleNormal(), 'a', greek},
+		{"missing", "Test Latin", FontStyleNormal(), 0x05D0, nil},
+	}
+	for _, test := range tests {
+		for i := 0; i < 2; i++ { // once more from the memo.
+			if got := mgr.MatchFamilyStyleCharacter(test.family, test.style, test.char); got != test.want {
+				t.Errorf("%v MatchFamilyStyleCharacter() want %v got %v", test.name, test.want, got)
+			}
+		}
+	}
+
+	// adding a typeface forgets the memoized misses.
+	var hebrew = newFontMgrTestTypeface(t, "Test Hebrew", FontStyleNormal(), 0x05D0)
+	mgr.AddTypeface(hebrew)
+	if got := mgr.MatchFamilyStyleCharacter("Test Latin", FontStyleNormal(), 0x05D0); got != hebrew {
+		t.Errorf("MatchFamilyStyleCharacter() after AddTypeface want %v got %v", hebrew, got)
+	}
+}
+
+func TestFontMgrFromDirectory(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "ggk-fonts")
+	if err != nil {
+		t.Fatalf("TempDir() failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var fontNamed = func(family string) []byte {
+		return makeTestTrueTypeFont(gTestGlyphs, tTestTable{"name",
+			makeTestNameTable(tTestNameRecord{3, 1, 0x0409, kTrueTypeNameFamily, family})})
+	}
+	var files = map[string][]byte{
+		"a.ttf":          fontNamed("Test A"),
+		"sub/b.TTF":      fontNamed("Test B"),
+		"sub/c.ttc":      makeTestTrueTypeCollection(fontNamed("Test C"), fontNamed("Test D")),
+		"sub/broken.ttf": []byte("not a font"),
+		"sub/readme.txt": []byte("fonts"),
+	}
+	for name, data := range files {
+		var path = filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("WriteFile() failed: %v", err)
+		}
+	}
+
+	var mgr *FontMgr
+	if mgr, err = NewFontMgrFromDirectory(dir); err != nil {
+		t.Fatalf("NewFontMgrFromDirectory() failed: %v", err)
+	}
+	if n := mgr.CountFamilies(); n != 4 {
+		t.Fatalf("CountFamilies() want 4 got %v", n)
+	}
+	for _, family := range []string{"Test A", "Test B", "Test C", "Test D"} {
+		if mgr.MatchFamilyStyle(family, FontStyleNormal()) == nil {
+			t.Errorf("MatchFamilyStyle(%v) want a typeface got nil", family)
+		}
+	}
+	if _, err = NewFontMgrFromDirectory(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("NewFontMgrFromDirectory() of a missing directory want an error")
+	}
+}
+
+func TestTextFallback(t *testing.T) {
+	var typeface = newTestTypeface(t)
+	var fallback = newFontMgrTestTypeface(t, "Test Fallback", FontStyleNormal(), 'x')
+	defer SetFontMgrDefault(FontMgrDefault())
+	SetFontMgrDefault(NewFontMgr(fallback))
+
+	var paint = NewPaint()
+	paint.SetTypeface(typeface)
+	paint.SetTextSize(10)
+	paint.SetHinting(KPaintHintingNo)
+
+	// l is 3.6 wide, o 6, x 6 from the fallback, and the missing y is the
+	// 5 wide notdef of the paint's typeface.
+	var tests = []struct {
+		text   string
+		widths []Scalar
+	}{
+		{"lo", []Scalar{3.6, 6}},
+		{"xl", []Scalar{6, 3.6}},
+		{"lyx", []Scalar{3.6, 5, 6}},
+	}
+	for _, test := range tests {
+		var widths = make([]Scalar, len(test.widths))
+		paint.TextWidths(test.text, len(test.text), widths, nil)
+		var sum Scalar = 0
+		for i := range widths {
+			if ScalarAbs(widths[i]-test.widths[i]) > 1e-3 {
+				t.Errorf("%q TextWidths() want %v got %v", test.text, test.widths, widths)
+				break
+			}
+			sum += test.widths[i]
+		}
+		if width := paint.MeasureText(test.text, len(test.text), nil); ScalarAbs(width-sum) > 1e-3 {
+			t.Errorf("%q MeasureText() want %v got %v", test.text, sum, width)
+		}
+	}
+
+	var cache = paint.detachCache(nil, nil)
+	var glyphs = paint.textToFallbackGlyphs(cache, "xl")
+	if glyphs[0].cache != FindGlyphCache(fallback, cache.Rec()) || glyphs[1].cache != cache {
+		t.Errorf("textToFallbackGlyphs() want x from the fallback and l from the paint's typeface")
+	}
+	// x spans 0 to 5, and the stem of l 7 to 8.6.
+	var intervals = make([]Scalar, 4)
+	var want = []Scalar{0, 5, 7, 8.6}
+	if n := paint.TextIntercepts("xl", 2, 0, 0, [2]Scalar{-2, -1}, intervals); n != 4 {
+		t.Fatalf("TextIntercepts() want 4 got %v", n)
+	}
+	for i := range want {
+		if ScalarAbs(intervals[i]-want[i]) > 1e-3 {
+			t.Errorf("TextIntercepts() want %v got %v", want, intervals)
+			break
+		}
+	}
+
+	// paragraphs draw the fallback glyphs, not the paint's empty .notdef:
+	// on the baseline at 8, x covers 0 to 5 and the stem of l 7 to 8.6.
+	var paragraph = NewParagraph("xl", paint, ParagraphStyle{})
+	paragraph.Layout(100)
+	var canvas, pixels = newTestPictureCanvas(20, 20)
+	paragraph.Draw(canvas, 0, 0)
+	var black = PackARGB32(0xff, 0, 0, 0)
+	for _, pt := range []Point{{2, 5}, {7, 5}} {
+		if got := pixels.Pixel32(int(pt.X), int(pt.Y)); got != black {
+			t.Errorf("Paragraph.Draw() at %v want %#x got %#x", pt, black, got)
+		}
+	}
+	if got := pixels.Pixel32(6, 5); got != 0 {
+		t.Errorf("Paragraph.Draw() between the glyphs want 0 got %#x", got)
+	}
+}
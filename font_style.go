@@ -0,0 +1,160 @@
+package ggk
+
+/** FontStyleSlant
+tells whether the glyphs of a font are upright or slanted. */
+type FontStyleSlant int
+
+const (
+	KFontStyleSlantUpright FontStyleSlant = iota
+	KFontStyleSlantItalic
+	KFontStyleSlantOblique
+)
+
+// The weights of a font, as in the usWeightClass of the OS/2 table.
+const (
+	KFontStyleWeightInvisible  = 0
+	KFontStyleWeightThin       = 100
+	KFontStyleWeightExtraLight = 200
+	KFontStyleWeightLight      = 300
+	KFontStyleWeightNormal     = 400
+	KFontStyleWeightMedium     = 500
+	KFontStyleWeightSemiBold   = 600
+	KFontStyleWeightBold       = 700
+	KFontStyleWeightExtraBold  = 800
+	KFontStyleWeightBlack      = 900
+	KFontStyleWeightExtraBlack = 1000
+)
+
+// The widths of a font, as in the usWidthClass of the OS/2 table.
+const (
+	KFontStyleWidthUltraCondensed = 1
+	KFontStyleWidthExtraCondensed = 2
+	KFontStyleWidthCondensed      = 3
+	KFontStyleWidthSemiCondensed  = 4
+	KFontStyleWidthNormal         = 5
+	KFontStyleWidthSemiExpanded   = 6
+	KFontStyleWidthExpanded       = 7
+	KFontStyleWidthExtraExpanded  = 8
+	KFontStyleWidthUltraExpanded  = 9
+)
+
+/** FontStyle
+is the weight, width and slant of a font, which together with the family
+name pick a typeface from a font manager. */
+type FontStyle struct {
+	weight int
+	width  int
+	slant  FontStyleSlant
+}
+
+// NewFontStyle returns the style, with weight pinned to 0...1000 and
+// width to 1...9.
+func NewFontStyle(weight, width int, slant FontStyleSlant) FontStyle {
+	if weight < KFontStyleWeightInvisible {
+		weight = KFontStyleWeightInvisible
+	} else if weight > KFontStyleWeightExtraBlack {
+		weight = KFontStyleWeightExtraBlack
+	}
+	if width < KFontStyleWidthUltraCondensed {
+		width = KFontStyleWidthUltraCondensed
+	} else if width > KFontStyleWidthUltraExpanded {
+		width = KFontStyleWidthUltraExpanded
+	}
+	if slant < KFontStyleSlantUpright || slant > KFontStyleSlantOblique {
+		slant = KFontStyleSlantUpright
+	}
+	return FontStyle{weight: weight, width: width, slant: slant}
+}
+
+func FontStyleNormal() FontStyle {
+	return NewFontStyle(KFontStyleWeightNormal, KFontStyleWidthNormal, KFontStyleSlantUpright)
+}
+
+func FontStyleBold() FontStyle {
+	return NewFontStyle(KFontStyleWeightBold, KFontStyleWidthNormal, KFontStyleSlantUpright)
+}
+
+func FontStyleItalic() FontStyle {
+	return NewFontStyle(KFontStyleWeightNormal, KFontStyleWidthNormal, KFontStyleSlantItalic)
+}
+
+func FontStyleBoldItalic() FontStyle {
+	return NewFontStyle(KFontStyleWeightBold, KFontStyleWidthNormal, KFontStyleSlantItalic)
+}
+
+func (style FontStyle) Weight() int {
+	return style.weight
+}
+
+func (style FontStyle) Width() int {
+	return style.width
+}
+
+func (style FontStyle) Slant() FontStyleSlant {
+	return style.slant
+}
+
+// Slant scores of the CSS font matching algorithm, indexed by the slant
+// asked for and the slant of the candidate.
+var gFontStyleSlantScores = [3][3]int{
+	//  Upright Italic Oblique
+	{3, 1, 2}, // Upright
+	{1, 3, 2}, // Italic
+	{1, 2, 3}, // Oblique
+}
+
+// Return how well style matches pattern by the CSS Fonts Level 3 matching
+// rules: width matters most, then slant, then weight, each score shifted
+// past the largest of the next. Higher is better.
+func (style FontStyle) matchScore(pattern FontStyle) int {
+	var score = 0
+
+	// narrower widths are preferred for a normal or narrower pattern, and
+	// wider ones for a wider pattern.
+	if pattern.width <= KFontStyleWidthNormal {
+		if style.width <= pattern.width {
+			score += 10 - pattern.width + style.width
+		} else {
+			score += 10 - style.width
+		}
+	} else {
+		if style.width > pattern.width {
+			score += 10 + pattern.width - style.width
+		} else {
+			score += style.width
+		}
+	}
+	score <<= 12
+
+	score += gFontStyleSlantScores[pattern.slant][style.slant]
+	score <<= 12
+
+	switch {
+	case style.weight == pattern.weight:
+		score += 1000
+	case pattern.weight < KFontStyleWeightNormal:
+		// below 400 prefer lighter weights.
+		if style.weight <= pattern.weight {
+			score += 1000 - pattern.weight + style.weight
+		} else {
+			score += 1000 - style.weight
+		}
+	case pattern.weight <= KFontStyleWeightMedium:
+		// from 400 to 500 prefer heavier weights up to 500, then lighter.
+		if style.weight >= pattern.weight && style.weight <= KFontStyleWeightMedium {
+			score += 1000 + pattern.weight - style.weight
+		} else if style.weight <= pattern.weight {
+			score += 500 + style.weight
+		} else {
+			score += 1000 - style.weight
+		}
+	default:
+		// above 500 prefer heavier weights.
+		if style.weight > pattern.weight {
+			score += 1000 + pattern.weight - style.weight
+		} else {
+			score += style.weight
+		}
+	}
+	return score
+}
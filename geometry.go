@@ -0,0 +1,80 @@
+package ggk
+
+// Evaluate the quadratic bezier src[3] at t, returning the point and the
+// (unnormalized) tangent at that point.
+func EvalQuadAt(src []Point, t Scalar) (pt Point, tangent Point) {
+	var mt = 1 - t
+	pt.X = mt*mt*src[0].X + 2*mt*t*src[1].X + t*t*src[2].X
+	pt.Y = mt*mt*src[0].Y + 2*mt*t*src[1].Y + t*t*src[2].Y
+
+	// The derivative is degenerate at the ends if the control point
+	// coincides with an end point, so fall back to the chord.
+	if (t == 0 && src[0].Equal(src[1])) || (t == 1 && src[1].Equal(src[2])) {
+		tangent = MakePoint(src[2].X-src[0].X, src[2].Y-src[0].Y)
+		return
+	}
+	tangent.X = 2 * (mt*(src[1].X-src[0].X) + t*(src[2].X-src[1].X))
+	tangent.Y = 2 * (mt*(src[1].Y-src[0].Y) + t*(src[2].Y-src[1].Y))
+	return
+}
+
+// Evaluate the cubic bezier src[4] at t, returning the point and the
+// (unnormalized) tangent at that point.
+func EvalCubicAt(src []Point, t Scalar) (pt Point, tangent Point) {
+	var mt = 1 - t
+	var a, b, c, d = mt * mt * mt, 3 * mt * mt * t, 3 * mt * t * t, t * t * t
+	pt.X = a*src[0].X + b*src[1].X + c*src[2].X + d*src[3].X
+	pt.Y = a*src[0].Y + b*src[1].Y + c*src[2].Y + d*src[3].Y
+
+	if (t == 0 && src[0].Equal(src[1])) || (t == 1 && src[2].Equal(src[3])) {
+		var p0, p1 Point
+		if t == 0 {
+			p0, p1 = src[0], src[2]
+		} else {
+			p0, p1 = src[1], src[3]
+		}
+		if p0.Equal(p1) {
+			p0, p1 = src[0], src[3]
+		}
+		tangent = MakePoint(p1.X-p0.X, p1.Y-p0.Y)
+		return
+	}
+	tangent.X = 3 * (mt*mt*(src[1].X-src[0].X) + 2*mt*t*(src[2].X-src[1].X) + t*t*(src[3].X-src[2].X))
+	tangent.Y = 3 * (mt*mt*(src[1].Y-src[0].Y) + 2*mt*t*(src[2].Y-src[1].Y) + t*t*(src[3].Y-src[2].Y))
+	return
+}
+
+func interpPoint(a, b Point, t Scalar) Point {
+	return MakePoint(ScalarInterpolate(a.X, b.X, t), ScalarInterpolate(a.Y, b.Y, t))
+}
+
+// Given a src quad, chop it at the specified t value, returning the two
+// new quads in dst[0..2] and dst[2..4].
+func ChopQuadAt(src []Point, t Scalar) (dst [5]Point) {
+	var p01 = interpPoint(src[0], src[1], t)
+	var p12 = interpPoint(src[1], src[2], t)
+	dst[0] = src[0]
+	dst[1] = p01
+	dst[2] = interpPoint(p01, p12, t)
+	dst[3] = p12
+	dst[4] = src[2]
+	return
+}
+
+// Given a src cubic, chop it at the specified t value, returning the two
+// new cubics in dst[0..3] and dst[3..6].
+func ChopCubicAt(src []Point, t Scalar) (dst [7]Point) {
+	var ab = interpPoint(src[0], src[1], t)
+	var bc = interpPoint(src[1], src[2], t)
+	var cd = interpPoint(src[2], src[3], t)
+	var abc = interpPoint(ab, bc, t)
+	var bcd = interpPoint(bc, cd, t)
+	dst[0] = src[0]
+	dst[1] = ab
+	dst[2] = abc
+	dst[3] = interpPoint(abc, bcd, t)
+	dst[4] = bcd
+	dst[5] = cd
+	dst[6] = src[3]
+	return
+}
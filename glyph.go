@@ -1,5 +1,38 @@
 package ggk
 
+// Glyph holds the metrics, and lazily the image and outline, of a single
+// glyph for a particular scaler context.
 type Glyph struct {
+	ID GlyphID
 
+	AdvanceX Scalar
+	AdvanceY Scalar
+
+	// Bounds of the glyph's image, relative to its origin.
+	Width  int
+	Height int
+	Top    int
+	Left   int
+
+	MaskFormat MaskFormat
+
+	Image []byte
+	Path  *Path
+
+	pathGenerated  bool
+	imageGenerated bool
+}
+
+func (glyph *Glyph) IsEmpty() bool {
+	return glyph.Width == 0 || glyph.Height == 0
+}
+
+// Return the row bytes of the glyph's image in its mask format.
+func (glyph *Glyph) RowBytes() int {
+	return maskRowBytes(glyph.MaskFormat, glyph.Width)
+}
+
+// Return the bounds of the glyph's image relative to its origin.
+func (glyph *Glyph) Bounds() Rect {
+	return MakeRect(Scalar(glyph.Left), Scalar(glyph.Top), Scalar(glyph.Width), Scalar(glyph.Height))
 }
@@ -1,5 +1,232 @@
 package ggk
 
+import "sync"
+
+/** GlyphCache
+caches the metrics, images and outlines of the glyphs produced by one
+scaler context. Caches are shared between draws (see FindGlyphCache), so
+all methods are safe for concurrent use. */
 type GlyphCache struct {
+	mutex         sync.Mutex
+	rec           ScalerContextRec
+	scalerContext ScalerContext
+	glyphs        map[GlyphID]*Glyph
+	charToGlyph   map[Unichar]GlyphID
+	intercepts    map[tGlyphInterceptKey]tGlyphIntercept
+	fontMetrics   *PaintFontMetrics
+}
+
+func NewGlyphCache(typeface *Typeface, rec *ScalerContextRec) *GlyphCache {
+	if typeface == nil {
+		typeface = TypefaceDefault()
+	}
+	var cache = &GlyphCache{
+		rec:           *rec,
+		scalerContext: typeface.CreateScalerContext(rec),
+		glyphs:        make(map[GlyphID]*Glyph),
+		charToGlyph:   make(map[Unichar]GlyphID),
+		intercepts:    make(map[tGlyphInterceptKey]tGlyphIntercept),
+	}
+	return cache
+}
+
+// tGlyphCacheKey identifies a strike: everything that changes the glyphs,
+// e.g. the size, transform, mask format or hinting level, is in the rec.
+type tGlyphCacheKey struct {
+	typefaceID uint32
+	rec        ScalerContextRec
+}
+
+// When there are more strikes than this, the cache starts over.
+const kGlyphCacheCountLimit = 256
+
+var gGlyphCaches = struct {
+	sync.Mutex
+	caches map[tGlyphCacheKey]*GlyphCache
+}{
+	caches: make(map[tGlyphCacheKey]*GlyphCache),
+}
+
+// FindGlyphCache returns the shared cache for glyphs of typeface (which may
+// be nil for the default typeface) described by rec, creating it if needed.
+func FindGlyphCache(typeface *Typeface, rec *ScalerContextRec) *GlyphCache {
+	if typeface == nil {
+		typeface = TypefaceDefault()
+	}
+	var key = tGlyphCacheKey{typeface.UniqueID(), *rec}
+
+	gGlyphCaches.Lock()
+	defer gGlyphCaches.Unlock()
+	if cache, ok := gGlyphCaches.caches[key]; ok {
+		return cache
+	}
+	if len(gGlyphCaches.caches) >= kGlyphCacheCountLimit {
+		gGlyphCaches.caches = make(map[tGlyphCacheKey]*GlyphCache)
+	}
+	var cache = NewGlyphCache(typeface, rec)
+	gGlyphCaches.caches[key] = cache
+	return cache
+}
+
+// Return the rec this cache's glyphs are generated for.
+func (cache *GlyphCache) Rec() *ScalerContextRec {
+	return &cache.rec
+}
+
+func (cache *GlyphCache) ScalerContext() ScalerContext {
+	return cache.scalerContext
+}
+
+// Map a character code to a glyphID. If the character is not supported,
+// return 0.
+func (cache *GlyphCache) UnicharToGlyph(uni Unichar) GlyphID {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if id, ok := cache.charToGlyph[uni]; ok {
+		return id
+	}
+	var id = cache.scalerContext.CharToGlyphID(uni)
+	cache.charToGlyph[uni] = id
+	return id
+}
+
+// Return the glyph with its advance and bounds computed.
+func (cache *GlyphCache) GlyphIDMetrics(id GlyphID) *Glyph {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return cache.lookupMetrics(id)
+}
+
+func (cache *GlyphCache) lookupMetrics(id GlyphID) *Glyph {
+	if glyph, ok := cache.glyphs[id]; ok {
+		return glyph
+	}
+	var glyph = &Glyph{ID: id, MaskFormat: cache.rec.MaskFormat}
+	cache.scalerContext.GenerateMetrics(glyph)
+	if glyph.MaskFormat == KMaskFormatLCD16 && !glyph.IsEmpty() {
+		// leave room for the LCD filter to spread into the neighbours.
+		if cache.rec.Flags&KScalerContextFlagLCDVertical != 0 {
+			glyph.Top, glyph.Height = glyph.Top-1, glyph.Height+2
+		} else {
+			glyph.Left, glyph.Width = glyph.Left-1, glyph.Width+2
+		}
+	}
+	cache.glyphs[id] = glyph
+	return glyph
+}
+
+// Return the glyph for the character, with its advance and bounds computed.
+func (cache *GlyphCache) UnicharMetrics(uni Unichar) *Glyph {
+	return cache.GlyphIDMetrics(cache.UnicharToGlyph(uni))
+}
+
+// Return the glyph's image, generating it if needed. The image is in the
+// glyph's MaskFormat, or nil if the glyph is empty.
+func (cache *GlyphCache) FindImage(glyph *Glyph) []byte {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if !glyph.imageGenerated && !glyph.IsEmpty() {
+		glyph.imageGenerated = true
+		cache.scalerContext.GenerateImage(glyph)
+		if glyph.Image == nil {
+			generateImageFromPath(glyph, cache.findPath(glyph), cache.rec.Flags)
+		}
+	}
+	return glyph.Image
+}
+
+// Return the glyph's outline, generating it if needed, or nil if the
+// glyph is empty.
+func (cache *GlyphCache) FindPath(glyph *Glyph) *Path {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return cache.findPath(glyph)
+}
+
+func (cache *GlyphCache) findPath(glyph *Glyph) *Path {
+	if !glyph.pathGenerated {
+		glyph.pathGenerated = true
+		var path = NewPath()
+		cache.scalerContext.GeneratePath(glyph, path)
+		if !path.IsEmpty() {
+			glyph.Path = path
+		}
+	}
+	return glyph.Path
+}
+
+type tGlyphInterceptKey struct {
+	id     GlyphID
+	bounds [2]Scalar
+}
+
+// tGlyphIntercept is the span of a glyph's outline within a band, valid
+// only if the outline crosses the band.
+type tGlyphIntercept struct {
+	lo, hi Scalar
+	valid  bool
+}
+
+// Return the leftmost and rightmost x where the glyph's outline is inside
+// the band between the horizontal lines at bounds[0] and bounds[1], and
+// false if the outline misses the band.
+func (cache *GlyphCache) FindIntercepts(glyph *Glyph, bounds [2]Scalar) (lo, hi Scalar, ok bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	var key = tGlyphInterceptKey{glyph.ID, bounds}
+	if intercept, ok := cache.intercepts[key]; ok {
+		return intercept.lo, intercept.hi, intercept.valid
+	}
+	var intercept tGlyphIntercept
+	if path := cache.findPath(glyph); path != nil {
+		intercept.lo, intercept.hi, intercept.valid = pathIntercepts(path, bounds)
+	}
+	cache.intercepts[key] = intercept
+	return intercept.lo, intercept.hi, intercept.valid
+}
+
+// Return the vertical metrics for this strike.
+func (cache *GlyphCache) FontMetrics() *PaintFontMetrics {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.fontMetrics == nil {
+		cache.fontMetrics = new(PaintFontMetrics)
+		cache.scalerContext.GenerateFontMetrics(cache.fontMetrics)
+	}
+	return cache.fontMetrics
+}
+
+// Return the x extent of the parts of path between the horizontal lines at
+// bounds[0] and bounds[1]. Curves are flattened the same way they are when
+// rasterized, so the intercepts match what is drawn.
+func pathIntercepts(path *Path, bounds [2]Scalar) (lo, hi Scalar, ok bool) {
+	var top, bottom = ScalarMin(bounds[0], bounds[1]), ScalarMax(bounds[0], bounds[1])
+	var pathBounds = path.Bounds()
+	if pathBounds.B() < top || pathBounds.T() > bottom {
+		return 0, 0, false
+	}
+
+	var add = func(x Scalar) {
+		if !ok {
+			lo, hi, ok = x, x, true
+		} else {
+			lo, hi = ScalarMin(lo, x), ScalarMax(hi, x)
+		}
+	}
+	var line = func(p0, p1 Point) {
+		if p0.Y >= top && p0.Y <= bottom {
+			add(p0.X)
+		}
+		if p1.Y >= top && p1.Y <= bottom {
+			add(p1.X)
+		}
+		for _, y := range [2]Scalar{top, bottom} {
+			if (p0.Y-y)*(p1.Y-y) < 0 {
+				add(p0.X + (p1.X-p0.X)*(y-p0.Y)/(p1.Y-p0.Y))
+			}
+		}
+	}
 
-}
\ No newline at end of file
+	flattenPath(path, line)
+	return lo, hi, ok
+}
@@ -1,4 +1,58 @@
 package ggk
 
+import "sync/atomic"
+
+/** Image
+is an immutable array of N32 premultiplied pixels to draw. */
 type Image struct {
+	bitmap   *Bitmap
+	uniqueID uint32
+}
+
+var gImageUniqueID uint32
+
+/** NewImageFromBitmap
+Return an image of a copy of the pixels of bmp, so that later changes to
+bmp don't change the image. Returns nil if bmp is empty or its pixels
+aren't N32 premultiplied. */
+func NewImageFromBitmap(bmp *Bitmap) *Image {
+	if bmp == nil || bmp.IsNull() || bmp.IsEmpty() || bmp.ColorType() != KColorTypeN32 ||
+		bmp.AlphaType() != KAlphaTypePremul {
+		return nil
+	}
+	var pixels = copyFilterBitmap(bmp, PointZero, MakeRect(0, 0, bmp.Width(), bmp.Height()))
+	if pixels == nil {
+		return nil
+	}
+	pixels.SetIsImmutable()
+	return &Image{bitmap: pixels, uniqueID: atomic.AddUint32(&gImageUniqueID, 1)}
+}
+
+func (image *Image) Width() int {
+	return int(image.bitmap.Width())
+}
+
+func (image *Image) Height() int {
+	return int(image.bitmap.Height())
+}
+
+func (image *Image) Bounds() Rect {
+	return MakeRect(0, 0, image.bitmap.Width(), image.bitmap.Height())
+}
+
+// Return a non-zero, unique value representing the image.
+func (image *Image) UniqueID() uint32 {
+	return image.uniqueID
+}
+
+// Pixel32 returns the premultiplied pixel at (x, y).
+func (image *Image) Pixel32(x, y int) uint32 {
+	return filterPixmap(image.bitmap).Pixel32(x, y)
+}
+
+/** MakeShader
+Return a shader tiling the image in tmx horizontally and tmy vertically,
+drawn through localMatrix, which may be nil. */
+func (image *Image) MakeShader(tmx, tmy ShaderTileMode, localMatrix *Matrix) *Shader {
+	return newBitmapShader(image.bitmap, tmx, tmy, localMatrix)
 }
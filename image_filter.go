@@ -1,21 +1,331 @@
 package ggk
 
+/** ImageFilterImpl
+is implemented by each kind of image filter. The ImageFilter that owns the
+impl holds its inputs and crop rect, and is passed back to the impl so it
+can filter its inputs and crop its result. */
+type ImageFilterImpl interface {
+	/** OnFilterImage
+	Filter src, whose top left is at the origin of the space of ctx, and
+	return the result and the offset of its top left in that space. Returns
+	false if the result is empty. */
+	OnFilterImage(filter *ImageFilter, src *Bitmap, ctx *ImageFilterContext) (*Bitmap, Point, bool)
+
+	/** OnFilterBounds
+	Return the device bounds the filter writes for src, or the bounds it
+	reads for src in the reverse direction, including those of its
+	inputs. */
+	OnFilterBounds(filter *ImageFilter, src Rect, ctm *Matrix, direction ImageFilterMapDirection) Rect
+
+	/** OnComputeFastBounds
+	Return a conservative bounds of what the filter draws for src, in
+	local coordinates, including what its inputs draw. */
+	OnComputeFastBounds(filter *ImageFilter, src Rect) Rect
+
+	/** OnAffectsTransparentBlack
+	Return true if the filter draws over transparent pixels, in which case
+	what it draws is not bounded by its source. */
+	OnAffectsTransparentBlack() bool
+
+	/** OnAsAColorFilter
+	Return the color filter the filter is equivalent to, if any. */
+	OnAsAColorFilter(filter *ImageFilter) (*ColorFilter, bool)
+}
+
+/** ImageFilter
+is a node in a graph of filters that operate on offscreen images. A nil
+input stands for the source image the graph is applied to. */
 type ImageFilter struct {
+	Impl     ImageFilterImpl
+	inputs   []*ImageFilter
+	cropRect *ImageFilterCropRect
+}
+
+func NewImageFilter(impl ImageFilterImpl, inputs []*ImageFilter, cropRect *ImageFilterCropRect) *ImageFilter {
+	return &ImageFilter{
+		Impl:     impl,
+		inputs:   inputs,
+		cropRect: cropRect,
+	}
 }
 
-func NewImageFilter() *ImageFilter {
-	return &ImageFilter{}
+type ImageFilterMapDirection int
+
+const (
+	KImageFilterMapDirectionForward ImageFilterMapDirection = iota // the bounds written for a source
+	KImageFilterMapDirectionReverse                                // the bounds read for a destination
+)
+
+/** ImageFilterContext
+tells a filter the matrix the image was drawn with, and the device bounds,
+relative to the source image, of the part of the result that is needed. */
+type ImageFilterContext struct {
+	CTM        *Matrix
+	ClipBounds Rect
 }
 
-func (imageFilter *ImageFilter) CanComputeFastBounds() bool {
-	toimpl()
-	return false
+func NewImageFilterContext(ctm *Matrix, clipBounds Rect) *ImageFilterContext {
+	if ctm == nil {
+		ctm = NewMatrix()
+	}
+	return &ImageFilterContext{
+		CTM:        ctm,
+		ClipBounds: clipBounds,
+	}
 }
 
-func (ImageFilter *ImageFilter) AsAColorFilter() (*ColorFilter, bool) {
-	toimpl()
-	return new(ColorFilter), false
+// Return the context as seen from an image whose top left is at offset.
+func (ctx *ImageFilterContext) offset(offset Point) *ImageFilterContext {
+	var ctm = NewMatrixClone(ctx.CTM)
+	ctm.PostTranslate(-offset.X, -offset.Y)
+	var clip = ctx.ClipBounds
+	clip.Offset(-offset.X, -offset.Y)
+	return NewImageFilterContext(ctm, clip)
 }
 
-type ImageFilterContext struct {
+type ImageFilterCropEdge uint32
+
+const (
+	KImageFilterCropEdgeHasLeft ImageFilterCropEdge = 1 << iota
+	KImageFilterCropEdgeHasTop
+	KImageFilterCropEdgeHasWidth
+	KImageFilterCropEdgeHasHeight
+	KImageFilterCropEdgeHasAll = KImageFilterCropEdgeHasLeft | KImageFilterCropEdgeHasTop |
+		KImageFilterCropEdgeHasWidth | KImageFilterCropEdgeHasHeight
+)
+
+/** ImageFilterCropRect
+limits the result of a filter to Rect, in local coordinates. Edges whose
+flag is not set don't limit the result. */
+type ImageFilterCropRect struct {
+	Rect  Rect
+	Flags ImageFilterCropEdge
+}
+
+func NewImageFilterCropRect(rect Rect, flags ImageFilterCropEdge) *ImageFilterCropRect {
+	return &ImageFilterCropRect{Rect: rect, Flags: flags}
+}
+
+/** applyTo
+Return imageBounds with the edges that the crop rect, mapped by ctm, has
+replaced. Unless embiggen is true the crop rect only shrinks the bounds. */
+func (crop *ImageFilterCropRect) applyTo(imageBounds Rect, ctm *Matrix, embiggen bool) Rect {
+	if crop == nil {
+		return imageBounds
+	}
+	var cropBounds = ctm.MapRect(crop.Rect).RoundOut()
+	var l, t, r, b = imageBounds.L(), imageBounds.T(), imageBounds.R(), imageBounds.B()
+	if crop.Flags&KImageFilterCropEdgeHasLeft != 0 && (embiggen || cropBounds.L() > l) {
+		l = cropBounds.L()
+	}
+	if crop.Flags&KImageFilterCropEdgeHasTop != 0 && (embiggen || cropBounds.T() > t) {
+		t = cropBounds.T()
+	}
+	if crop.Flags&KImageFilterCropEdgeHasWidth != 0 && (embiggen || cropBounds.R() < r) {
+		r = cropBounds.R()
+	}
+	if crop.Flags&KImageFilterCropEdgeHasHeight != 0 && (embiggen || cropBounds.B() < b) {
+		b = cropBounds.B()
+	}
+	var dst Rect
+	dst.SetLTRB(l, t, r, b)
+	return dst
+}
+
+func (filter *ImageFilter) CountInputs() int {
+	return len(filter.inputs)
+}
+
+// Input returns the i-th input, which is nil for the source image.
+func (filter *ImageFilter) Input(i int) *ImageFilter {
+	return filter.inputs[i]
+}
+
+func (filter *ImageFilter) CropRect() *ImageFilterCropRect {
+	return filter.cropRect
+}
+
+/** FilterImage
+Apply the filter graph to src, whose top left is at the origin of the
+space of ctx. Returns the result, the offset of its top left in that space,
+and false if nothing is left to draw. */
+func (filter *ImageFilter) FilterImage(src *Bitmap, ctx *ImageFilterContext) (*Bitmap, Point, bool) {
+	if filter.Impl == nil {
+		return src, PointZero, true
+	}
+	return filter.Impl.OnFilterImage(filter, src, ctx)
+}
+
+/** FilterBounds
+Return the device bounds the graph writes when src is filtered, or in the
+reverse direction the bounds of the source it reads to write src. */
+func (filter *ImageFilter) FilterBounds(src Rect, ctm *Matrix, direction ImageFilterMapDirection) Rect {
+	if filter.Impl == nil {
+		return src
+	}
+	return filter.Impl.OnFilterBounds(filter, src, ctm, direction)
+}
+
+/** ComputeFastBounds
+Return a conservative bounds, in local coordinates, of what the graph
+draws for a source drawn in src. It only makes sense if
+CanComputeFastBounds returns true. */
+func (filter *ImageFilter) ComputeFastBounds(src Rect) Rect {
+	if filter.Impl == nil {
+		return src
+	}
+	return filter.Impl.OnComputeFastBounds(filter, src)
+}
+
+/** CanComputeFastBounds
+Return true if what the graph draws is bounded by its source, that is if
+no filter in it draws over transparent pixels. */
+func (filter *ImageFilter) CanComputeFastBounds() bool {
+	if filter.affectsTransparentBlack() {
+		return false
+	}
+	for _, input := range filter.inputs {
+		if input != nil && !input.CanComputeFastBounds() {
+			return false
+		}
+	}
+	return true
+}
+
+func (filter *ImageFilter) affectsTransparentBlack() bool {
+	return filter.Impl != nil && filter.Impl.OnAffectsTransparentBlack()
+}
+
+/** AsAColorFilter
+Return the color filter the graph is equivalent to, so that drawing with
+it can skip the offscreen image. */
+func (filter *ImageFilter) AsAColorFilter() (*ColorFilter, bool) {
+	if filter.Impl == nil || filter.cropRect != nil {
+		return nil, false
+	}
+	return filter.Impl.OnAsAColorFilter(filter)
+}
+
+/** filterInput
+Filter src with the i-th input, returning src itself for a nil input. */
+func (filter *ImageFilter) filterInput(i int, src *Bitmap, ctx *ImageFilterContext) (*Bitmap, Point, bool) {
+	if i >= len(filter.inputs) || filter.inputs[i] == nil {
+		return src, PointZero, src != nil
+	}
+	return filter.inputs[i].FilterImage(src, ctx)
+}
+
+/** applyCropRect
+Return the bounds of a result of srcBounds limited by the crop rect and
+the clip bounds of ctx, or false if nothing is left. */
+func (filter *ImageFilter) applyCropRect(ctx *ImageFilterContext, srcBounds Rect) (Rect, bool) {
+	var dstBounds = filter.cropRect.applyTo(srcBounds, ctx.CTM, filter.affectsTransparentBlack())
+	if dstBounds.Width <= 0 || dstBounds.Height <= 0 || !dstBounds.Intersect(ctx.ClipBounds) {
+		return RectZero, false
+	}
+	return dstBounds, true
+}
+
+/** filterBoundsWithNode
+Return the bounds of FilterBounds for a filter whose own node maps bounds
+with node: the node's bounds of the union of the inputs' forward, and the
+union of the inputs' bounds of the node's in reverse. */
+func (filter *ImageFilter) filterBoundsWithNode(src Rect, ctm *Matrix, direction ImageFilterMapDirection,
+	node func(src Rect) Rect) Rect {
+	if direction == KImageFilterMapDirectionReverse {
+		return filter.inputsFilterBounds(node(src), ctm, direction)
+	}
+	var dst = node(filter.inputsFilterBounds(src, ctm, direction))
+	return filter.cropRect.applyTo(dst, ctm, filter.affectsTransparentBlack())
+}
+
+// Return the union of the bounds of the inputs, or src without inputs.
+func (filter *ImageFilter) inputsFilterBounds(src Rect, ctm *Matrix, direction ImageFilterMapDirection) Rect {
+	if len(filter.inputs) == 0 {
+		return src
+	}
+	var bounds Rect
+	for _, input := range filter.inputs {
+		if input == nil {
+			bounds.Join(src)
+		} else {
+			bounds.Join(input.FilterBounds(src, ctm, direction))
+		}
+	}
+	return bounds
+}
+
+// Return the union of the fast bounds of the inputs, or src without inputs.
+func (filter *ImageFilter) inputsFastBounds(src Rect) Rect {
+	if len(filter.inputs) == 0 {
+		return src
+	}
+	var bounds Rect
+	for _, input := range filter.inputs {
+		if input == nil {
+			bounds.Join(src)
+		} else {
+			bounds.Join(input.ComputeFastBounds(src))
+		}
+	}
+	return bounds
+}
+
+// Return a transparent N32 premultiplied bitmap of bounds' size, or nil if
+// bounds is empty.
+func newFilterBitmap(bounds Rect) *Bitmap {
+	var width, height = int(bounds.Width), int(bounds.Height)
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+	var bmp = new(Bitmap)
+	if bmp.AllocN32Pixels(width, height, false) != nil {
+		return nil
+	}
+	return bmp
+}
+
+// Return a pixmap of the pixels of bmp.
+func filterPixmap(bmp *Bitmap) *Pixmap {
+	var pixmap = &Pixmap{}
+	pixmap.Reset(bmp.Info(), bmp.PixelBytes(), bmp.RowBytes(), nil)
+	return pixmap
+}
+
+// Return the bounds of bmp when its top left is at offset.
+func filterBitmapBounds(bmp *Bitmap, offset Point) Rect {
+	return MakeRect(offset.X, offset.Y, bmp.Width(), bmp.Height())
+}
+
+/** copyFilterBitmap
+Return a bitmap of bounds holding the pixels of src, whose top left is at
+offset, and transparent pixels where src doesn't reach. */
+func copyFilterBitmap(src *Bitmap, offset Point, bounds Rect) *Bitmap {
+	var dst = newFilterBitmap(bounds)
+	if dst == nil {
+		return nil
+	}
+	drawFilterBitmap(dst, src, offset.X-bounds.Left, offset.Y-bounds.Top, false)
+	return dst
+}
+
+/** drawFilterBitmap
+Draw src with its top left at (dx, dy) of dst, replacing the pixels of dst
+or blending over them. */
+func drawFilterBitmap(dst, src *Bitmap, dx, dy Scalar, blend bool) {
+	var srcPixels, dstPixels = filterPixmap(src), filterPixmap(dst)
+	var r = filterBitmapBounds(src, Point{dx, dy})
+	if !r.Intersect(MakeRect(0, 0, dst.Width(), dst.Height())) {
+		return
+	}
+	var x0, y0 = int(dx), int(dy)
+	for y := int(r.Top); y < int(r.B()); y++ {
+		for x := int(r.Left); x < int(r.R()); x++ {
+			var pixel = srcPixels.Pixel32(x-x0, y-y0)
+			if blend {
+				pixel = PMSrcOver(pixel, dstPixels.Pixel32(x, y))
+			}
+			dstPixels.SetPixel32(x, y, pixel)
+		}
+	}
 }
@@ -0,0 +1,190 @@
+package ggk
+
+import "testing"
+
+// newTestFilterSource returns a transparent 10x10 bitmap with an opaque
+// red 4x4 square at (3, 3).
+func newTestFilterSource() *Bitmap {
+	var bmp = newFilterBitmap(MakeRect(0, 0, 10, 10))
+	var pixels = filterPixmap(bmp)
+	for y := 3; y < 7; y++ {
+		for x := 3; x < 7; x++ {
+			pixels.SetPixel32(x, y, PackARGB32(0xff, 0xff, 0, 0))
+		}
+	}
+	return bmp
+}
+
+// Return the alpha of the result at device (x, y), or 0 outside of it.
+func filterResultAlpha(result *Bitmap, offset Point, x, y int) uint32 {
+	x, y = x-int(offset.X), y-int(offset.Y)
+	if x < 0 || y < 0 || x >= int(result.Width()) || y >= int(result.Height()) {
+		return 0
+	}
+	return GetPackedA32(filterPixmap(result).Pixel32(x, y))
+}
+
+func TestImageFilterBounds(t *testing.T) {
+	var scale = NewMatrix()
+	scale.SetScale(2, 2)
+	var src = MakeRect(10, 10, 20, 20)
+	var tests = []struct {
+		name    string
+		filter  *ImageFilter
+		ctm     *Matrix
+		forward Rect
+		reverse Rect
+	}{
+		{"blur", NewBlurImageFilter(2, 1, nil, nil), NewMatrix(), MakeRect(4, 7, 32, 26), MakeRect(4, 7, 32, 26)},
+		{"scaled blur", NewBlurImageFilter(2, 1, nil, nil), scale, MakeRect(-2, 4, 44, 32), MakeRect(-2, 4, 44, 32)},
+		{"offset", NewOffsetImageFilter(5, -3, nil, nil), NewMatrix(), MakeRect(15, 7, 20, 20), MakeRect(5, 13, 20, 20)},
+		{"drop shadow", NewDropShadowImageFilter(5, 0, 0, 0, KColorBlack, KDropShadowImageFilterShadowModeDrawShadowAndForeground, nil, nil),
+			NewMatrix(), MakeRect(10, 10, 25, 20), MakeRect(5, 10, 25, 20)},
+		{"shadow only", NewDropShadowImageFilter(5, 0, 0, 0, KColorBlack, KDropShadowImageFilterShadowModeDrawShadowOnly, nil, nil),
+			NewMatrix(), MakeRect(15, 10, 20, 20), MakeRect(5, 10, 20, 20)},
+		{"dilate", NewDilateImageFilter(1, 2, nil, nil), NewMatrix(), MakeRect(9, 8, 22, 24), MakeRect(9, 8, 22, 24)},
+		{"crop", NewDilateImageFilter(1, 2, nil, NewImageFilterCropRect(MakeRect(0, 0, 15, 100), KImageFilterCropEdgeHasAll)),
+			NewMatrix(), MakeRect(9, 8, 6, 24), MakeRect(9, 8, 22, 24)},
+		{"merge", NewMergeImageFilter([]*ImageFilter{NewOffsetImageFilter(10, 0, nil, nil), nil}, nil),
+			NewMatrix(), MakeRect(10, 10, 30, 20), MakeRect(0, 10, 30, 20)},
+		{"compose", NewComposeImageFilter(NewOffsetImageFilter(0, 4, nil, nil), NewOffsetImageFilter(3, 0, nil, nil)),
+			NewMatrix(), MakeRect(13, 14, 20, 20), MakeRect(7, 6, 20, 20)},
+		{"matrix", NewMatrixImageFilter(scale, KFilterQualityNone, nil), NewMatrix(), MakeRect(20, 20, 40, 40), MakeRect(5, 5, 10, 10)},
+		{"tile", NewTileImageFilter(MakeRect(0, 0, 5, 5), MakeRect(0, 0, 100, 50), nil), NewMatrix(), MakeRect(0, 0, 100, 50), MakeRect(0, 0, 5, 5)},
+	}
+	for _, test := range tests {
+		if got := test.filter.FilterBounds(src, test.ctm, KImageFilterMapDirectionForward); got != test.forward {
+			t.Errorf("%v FilterBounds() forward want %v got %v", test.name, test.forward, got)
+		}
+		if got := test.filter.FilterBounds(src, test.ctm, KImageFilterMapDirectionReverse); got != test.reverse {
+			t.Errorf("%v FilterBounds() reverse want %v got %v", test.name, test.reverse, got)
+		}
+	}
+
+	if got := NewBlurImageFilter(2, 1, nil, nil).ComputeFastBounds(src); got != MakeRect(4, 7, 32, 26) {
+		t.Errorf("ComputeFastBounds() of a blur want %v got %v", MakeRect(4, 7, 32, 26), got)
+	}
+	if NewBlurImageFilter(0, 0, nil, nil) != nil || NewBlurImageFilter(-1, 1, nil, nil) != nil {
+		t.Errorf("NewBlurImageFilter() with nothing to blur want the input")
+	}
+}
+
+func TestImageFilterImage(t *testing.T) {
+	var crop = NewImageFilterCropRect(MakeRect(0, 0, 7, 100), KImageFilterCropEdgeHasAll)
+	var scale = NewMatrix()
+	scale.SetScale(2, 2)
+	var tests = []struct {
+		name   string
+		filter *ImageFilter
+		bounds Rect
+		opaque []Point // pixels that must be opaque.
+		clear  []Point // pixels that must be transparent.
+	}{
+		{"offset", NewOffsetImageFilter(3, 4, nil, nil), MakeRect(3, 4, 10, 10), []Point{{6, 7}, {9, 10}}, []Point{{5, 6}, {10, 11}}},
+		{"cropped offset", NewOffsetImageFilter(3, 4, nil, crop), MakeRect(3, 4, 4, 10), []Point{{6, 7}}, []Point{{5, 7}, {7, 7}}},
+		{"dilate", NewDilateImageFilter(1, 0, nil, nil), MakeRect(-1, 0, 12, 10), []Point{{2, 3}, {7, 6}}, []Point{{1, 3}, {3, 2}}},
+		{"erode", NewErodeImageFilter(1, 1, nil, nil), MakeRect(0, 0, 10, 10), []Point{{4, 4}, {5, 5}}, []Point{{3, 3}, {6, 4}}},
+		{"shadow only", NewDropShadowImageFilter(10, 0, 0, 0, KColorBlack, KDropShadowImageFilterShadowModeDrawShadowOnly, nil, nil),
+			MakeRect(10, 0, 10, 10), []Point{{13, 3}}, []Point{{3, 3}}},
+		{"drop shadow", NewDropShadowImageFilter(10, 0, 0, 0, KColorBlack, KDropShadowImageFilterShadowModeDrawShadowAndForeground, nil, nil),
+			MakeRect(0, 0, 20, 10), []Point{{3, 3}, {13, 3}}, []Point{{10, 3}}},
+		{"merge", NewMergeImageFilter([]*ImageFilter{NewOffsetImageFilter(0, 10, nil, nil), nil}, nil),
+			MakeRect(0, 0, 10, 20), []Point{{3, 3}, {3, 13}}, []Point{{3, 10}}},
+		{"compose", NewComposeImageFilter(NewOffsetImageFilter(0, 4, nil, nil), NewOffsetImageFilter(3, 0, nil, nil)),
+			MakeRect(3, 4, 10, 10), []Point{{6, 7}}, []Point{{5, 7}}},
+		{"matrix", NewMatrixImageFilter(scale, KFilterQualityNone, nil), MakeRect(0, 0, 20, 20), []Point{{6, 6}, {13, 13}}, []Point{{5, 6}, {14, 13}}},
+		{"tile", NewTileImageFilter(MakeRect(3, 3, 5, 4), MakeRect(0, 0, 15, 15), nil), MakeRect(0, 0, 15, 15),
+			[]Point{{3, 3}, {8, 7}, {1, 10}}, []Point{{2, 3}, {7, 3}}},
+	}
+	for _, test := range tests {
+		var ctx = NewImageFilterContext(nil, MakeRect(-50, -50, 100, 100))
+		var result, offset, ok = test.filter.FilterImage(newTestFilterSource(), ctx)
+		if !ok {
+			t.Errorf("%v FilterImage() failed", test.name)
+			continue
+		}
+		if bounds := filterBitmapBounds(result, offset); bounds != test.bounds {
+			t.Errorf("%v FilterImage() want bounds %v got %v", test.name, test.bounds, bounds)
+		}
+		for _, p := range test.opaque {
+			if a := filterResultAlpha(result, offset, int(p.X), int(p.Y)); a != 0xff {
+				t.Errorf("%v FilterImage() want %v opaque got alpha %v", test.name, p, a)
+			}
+		}
+		for _, p := range test.clear {
+			if a := filterResultAlpha(result, offset, int(p.X), int(p.Y)); a != 0 {
+				t.Errorf("%v FilterImage() want %v transparent got alpha %v", test.name, p, a)
+			}
+		}
+	}
+
+	// the clip bounds limit the result.
+	var ctx = NewImageFilterContext(nil, MakeRect(0, 0, 5, 5))
+	if result, offset, ok := NewOffsetImageFilter(1, 1, nil, nil).FilterImage(newTestFilterSource(), ctx); !ok ||
+		filterBitmapBounds(result, offset) != MakeRect(1, 1, 4, 4) {
+		t.Errorf("FilterImage() with clip bounds want bounds %v", MakeRect(1, 1, 4, 4))
+	}
+	if _, _, ok := NewOffsetImageFilter(20, 0, nil, nil).FilterImage(newTestFilterSource(), ctx); ok {
+		t.Errorf("FilterImage() of a result outside the clip want failure")
+	}
+}
+
+func TestBlurImageFilter(t *testing.T) {
+	var filter = NewBlurImageFilter(1.5, 1.5, nil, nil)
+	var ctx = NewImageFilterContext(nil, MakeRect(-50, -50, 100, 100))
+	var result, offset, ok = filter.FilterImage(newTestFilterSource(), ctx)
+	if !ok || filterBitmapBounds(result, offset) != MakeRect(-5, -5, 20, 20) {
+		t.Fatalf("FilterImage() want bounds %v got %v, %v", MakeRect(-5, -5, 20, 20), filterBitmapBounds(result, offset), ok)
+	}
+	var pixels = filterPixmap(result)
+	var sum uint32
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			var pixel = pixels.Pixel32(x, y)
+			if GetPackedR32(pixel) != GetPackedA32(pixel) || GetPackedG32(pixel) != 0 {
+				t.Fatalf("FilterImage() want premultiplied red at (%v, %v) got %#x", x, y, pixel)
+			}
+			sum += GetPackedA32(pixel)
+		}
+	}
+	if sum < 16*255*98/100 || sum > 16*255*102/100 {
+		t.Errorf("FilterImage() want total alpha near %v got %v", 16*255, sum)
+	}
+	if a := filterResultAlpha(result, offset, 5, 5); a <= 0x80 || a >= 0xff {
+		t.Errorf("FilterImage() want the blurred center partly covered got %v", a)
+	}
+	if !filter.CanComputeFastBounds() {
+		t.Errorf("CanComputeFastBounds() of a blur want true")
+	}
+
+	var colorFilter = &ColorFilter{}
+	if cf, ok := NewColorFilterImageFilter(colorFilter, nil, nil).AsAColorFilter(); !ok || cf != colorFilter {
+		t.Errorf("AsAColorFilter() of a color filter want it")
+	}
+	if _, ok := NewColorFilterImageFilter(colorFilter, filter, nil).AsAColorFilter(); ok {
+		t.Errorf("AsAColorFilter() of a color filter with an input want false")
+	}
+	if _, ok := filter.AsAColorFilter(); ok {
+		t.Errorf("AsAColorFilter() of a blur want false")
+	}
+}
+
+func TestCanvasDrawDeviceWithFilter(t *testing.T) {
+	var src = NewBitmapDevice(newTestFilterSource(), nil)
+	var layer = new(Bitmap)
+	layer.AllocN32Pixels(6, 6, false)
+	var dst = NewBitmapDevice(layer, nil)
+	dst.origin = Point{4, 4}
+
+	CanvasDrawDeviceWithFilter(src.BaseDevice, NewOffsetImageFilter(1, 1, nil, nil), dst.BaseDevice, NewMatrix(), nil)
+	var pixels = filterPixmap(layer)
+	// the square moves to (4, 4) to (8, 8), which is (0, 0) to (4, 4) of the layer.
+	for _, test := range []struct {
+		x, y  int
+		alpha uint32
+	}{{0, 0, 0xff}, {3, 3, 0xff}, {4, 4, 0}, {5, 0, 0}} {
+		if a := GetPackedA32(pixels.Pixel32(test.x, test.y)); a != test.alpha {
+			t.Errorf("CanvasDrawDeviceWithFilter() pixel (%v, %v) want alpha %v got %v", test.x, test.y, test.alpha, a)
+		}
+	}
+}
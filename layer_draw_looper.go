@@ -0,0 +1,255 @@
+package ggk
+
+/** LayerDrawLooperBits
+selects which parts of a layer's paint replace those of the paint drawn
+with. The color is always combined through the layer's ColorMode. */
+type LayerDrawLooperBits int
+
+const (
+	KLayerDrawLooperBitsStyle       LayerDrawLooperBits = 1 << iota // use the layer's style and stroke settings
+	KLayerDrawLooperBitsTextSkewX                                   // use the layer's text skew
+	KLayerDrawLooperBitsPathEffect                                  // use the layer's path effect
+	KLayerDrawLooperBitsMaskFilter                                  // use the layer's mask filter
+	KLayerDrawLooperBitsShader                                      // use the layer's shader
+	KLayerDrawLooperBitsColorFilter                                 // use the layer's color filter
+	KLayerDrawLooperBitsXfermode                                    // use the layer's xfermode
+
+	// Use the draw's paint as is, only combining the color.
+	KLayerDrawLooperBitsNone LayerDrawLooperBits = 0
+	// Use the layer's paint for everything except the flags, and the color
+	// combined through the ColorMode.
+	KLayerDrawLooperBitsEntirePaint LayerDrawLooperBits = -1
+)
+
+/** LayerDrawLooperLayerInfo
+describes how a layer changes the draw. */
+type LayerDrawLooperLayerInfo struct {
+	PaintBits LayerDrawLooperBits
+	// ColorMode combines the layer's color, as the source, with the color of
+	// the draw: KXfermodeModeDst keeps the draw's color, KXfermodeModeSrc
+	// uses the layer's.
+	ColorMode XfermodeMode
+	// Offset translates the layer, in the coordinates of what is drawn
+	// unless PostTranslate is true, in which case it is in device pixels.
+	Offset        Point
+	PostTranslate bool
+}
+
+/** NewLayerDrawLooperLayerInfo
+Return the info of a layer that draws the draw unchanged. */
+func NewLayerDrawLooperLayerInfo() LayerDrawLooperLayerInfo {
+	return LayerDrawLooperLayerInfo{
+		PaintBits: KLayerDrawLooperBitsNone,
+		ColorMode: KXfermodeModeDst,
+	}
+}
+
+type tLayerDrawLooperRec struct {
+	info  LayerDrawLooperLayerInfo
+	paint *Paint
+}
+
+/** tLayerDrawLooper
+draws each of its layers in turn, from the bottom one to the top one. */
+type tLayerDrawLooper struct {
+	recs []*tLayerDrawLooperRec // from bottom to top.
+}
+
+/** LayerDrawLooperBuilder
+collects the layers of a layer draw looper. */
+type LayerDrawLooperBuilder struct {
+	recs []*tLayerDrawLooperRec // from bottom to top.
+}
+
+func NewLayerDrawLooperBuilder() *LayerDrawLooperBuilder {
+	return &LayerDrawLooperBuilder{}
+}
+
+/** AddLayer
+Add a layer below the layers added so far, so layers are added from the
+top to the bottom. Returns the paint of the layer, to set the parts
+selected by info.PaintBits. */
+func (builder *LayerDrawLooperBuilder) AddLayer(info LayerDrawLooperLayerInfo) *Paint {
+	var rec = &tLayerDrawLooperRec{info: info, paint: NewPaint()}
+	builder.recs = append([]*tLayerDrawLooperRec{rec}, builder.recs...)
+	return rec.paint
+}
+
+// AddLayerXY adds a layer below the others drawing the draw unchanged,
+// offset by (dx, dy).
+func (builder *LayerDrawLooperBuilder) AddLayerXY(dx, dy Scalar) {
+	var info = NewLayerDrawLooperLayerInfo()
+	info.Offset = Point{dx, dy}
+	builder.AddLayer(info)
+}
+
+/** AddLayerOnTop
+Add a layer above the layers added so far. Returns the paint of the
+layer. */
+func (builder *LayerDrawLooperBuilder) AddLayerOnTop(info LayerDrawLooperLayerInfo) *Paint {
+	var rec = &tLayerDrawLooperRec{info: info, paint: NewPaint()}
+	builder.recs = append(builder.recs, rec)
+	return rec.paint
+}
+
+/** Detach
+Return the looper drawing the layers added so far, and empty the builder.
+Returns nil if no layer was added. */
+func (builder *LayerDrawLooperBuilder) Detach() *DrawLooper {
+	if len(builder.recs) == 0 {
+		return nil
+	}
+	var impl = &tLayerDrawLooper{recs: builder.recs}
+	builder.recs = nil
+	return NewDrawLooper(impl)
+}
+
+func (impl *tLayerDrawLooper) OnCreateContext(canvas *Canvas) DrawLooperContext {
+	canvas.Save()
+	return &tLayerDrawLooperContext{recs: impl.recs}
+}
+
+func (impl *tLayerDrawLooper) OnAsABlurShadow(rec *DrawLooperBlurShadowRec) bool {
+	if len(impl.recs) != 2 {
+		return false
+	}
+	// the bottom layer needs to be just a blur.
+	var bottom = impl.recs[0]
+	if bottom.info.PaintBits&^KLayerDrawLooperBitsMaskFilter != 0 || bottom.info.ColorMode != KXfermodeModeSrc {
+		return false
+	}
+	var maskBlur MaskFilterBlurRec
+	if bottom.paint.MaskFilter() == nil || !bottom.paint.MaskFilter().AsABlur(&maskBlur) {
+		return false
+	}
+	// the top layer needs to be plain.
+	var top = impl.recs[1]
+	if top.info.PaintBits != 0 || top.info.ColorMode != KXfermodeModeDst || !top.info.Offset.IsZero() {
+		return false
+	}
+	if rec != nil {
+		*rec = DrawLooperBlurShadowRec{
+			Sigma:  maskBlur.Sigma,
+			Offset: bottom.info.Offset,
+			Color:  bottom.paint.Color(),
+			Style:  maskBlur.Style,
+		}
+	}
+	return true
+}
+
+/** tLayerDrawLooperContext
+draws a layer per pass. The canvas is saved when the context is created and
+around each layer, so each layer's offset starts from the draw's matrix. */
+type tLayerDrawLooperContext struct {
+	recs []*tLayerDrawLooperRec
+}
+
+func (context *tLayerDrawLooperContext) Next(canvas *Canvas, paint *Paint) bool {
+	canvas.Restore() // the previous layer's offset.
+	if len(context.recs) == 0 {
+		return false
+	}
+	var rec = context.recs[0]
+	context.recs = context.recs[1:]
+
+	applyLayerInfo(paint, rec.paint, &rec.info)
+	canvas.Save()
+	if rec.info.PostTranslate {
+		var matrix = NewMatrixClone(canvas.TotalMatrix())
+		matrix.PostTranslate(rec.info.Offset.X, rec.info.Offset.Y)
+		canvas.SetMatrix(matrix)
+	} else {
+		canvas.Translate(rec.info.Offset.X, rec.info.Offset.Y)
+	}
+	return true
+}
+
+// Return the color of a layer, combining the layer's color src with the
+// draw's color dst through mode.
+func xferLayerColor(src, dst Color, mode XfermodeMode) Color {
+	switch mode {
+	case KXfermodeModeSrc:
+		return src
+	case KXfermodeModeDst:
+		return dst
+	}
+	var s = Color4fFromColor(src).Premultipy()
+	var d = Color4fFromColor(dst).Premultipy()
+	return blendPM4f(mode, s, d).Pin().Unpremul().ToColor()
+}
+
+/** applyLayerInfo
+Change dst, the paint of the draw, into the paint of the layer drawn with
+src and info. */
+func applyLayerInfo(dst, src *Paint, info *LayerDrawLooperLayerInfo) {
+	dst.SetColor(xferLayerColor(src.Color(), dst.Color(), info.ColorMode))
+
+	var bits = info.PaintBits
+	if bits == KLayerDrawLooperBitsNone {
+		return
+	}
+	if bits == KLayerDrawLooperBitsEntirePaint {
+		// keep what was already computed.
+		var flags, color = dst.Flags(), dst.Color()
+		*dst = *src
+		dst.SetFlags(flags)
+		dst.SetColor(color)
+		return
+	}
+
+	if bits&KLayerDrawLooperBitsStyle != 0 {
+		dst.SetStyle(src.Style())
+		dst.SetStrokeWidth(src.StrokeWidth())
+		dst.SetStrokeMiter(src.StrokeMiter())
+		dst.SetStrokeCap(src.StrokeCap())
+		dst.SetStrokeJoin(src.StrokeJoin())
+	}
+	if bits&KLayerDrawLooperBitsTextSkewX != 0 {
+		dst.SetTextSkewX(src.TextSkewX())
+	}
+	if bits&KLayerDrawLooperBitsPathEffect != 0 {
+		dst.SetPathEffect(src.PathEffect())
+	}
+	if bits&KLayerDrawLooperBitsMaskFilter != 0 {
+		dst.SetMaskFilter(src.MaskFilter())
+	}
+	if bits&KLayerDrawLooperBitsShader != 0 {
+		dst.SetShader(src.Shader())
+	}
+	if bits&KLayerDrawLooperBitsColorFilter != 0 {
+		dst.SetColorFilter(src.ColorFilter())
+	}
+	if bits&KLayerDrawLooperBitsXfermode != 0 {
+		dst.SetXfermode(src.Xfermode())
+	}
+	// we don't override these
+	//	dst.SetTypeface(src.Typeface())
+	//	dst.SetTextSize(src.TextSize())
+	//	dst.SetTextScaleX(src.TextScaleX())
+	//	dst.SetRasterizer(src.Rasterizer())
+	//	dst.SetLooper(src.Looper())
+	//	dst.SetTextEncoding(src.TextEncoding())
+	//	dst.SetHinting(src.Hinting())
+}
+
+/** NewBlurDrawLooper
+Return a looper drawing a shadow of color under the draw, blurred with
+sigma and offset by (dx, dy). The shadow isn't blurred if sigma is not
+positive. */
+func NewBlurDrawLooper(color Color, sigma, dx, dy Scalar) *DrawLooper {
+	var builder = NewLayerDrawLooperBuilder()
+	// the draw itself.
+	builder.AddLayer(NewLayerDrawLooperLayerInfo())
+	// the shadow under it.
+	var info = NewLayerDrawLooperLayerInfo()
+	info.ColorMode = KXfermodeModeSrc
+	info.PaintBits = KLayerDrawLooperBitsMaskFilter
+	info.Offset = Point{dx, dy}
+	var paint = builder.AddLayer(info)
+	if sigma > 0 {
+		paint.SetMaskFilter(NewBlurMaskFilter(KBlurStyleNormal, sigma, KBlurMaskFilterFlagNone))
+	}
+	paint.SetColor(color)
+	return builder.Detach()
+}
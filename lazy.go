@@ -24,27 +24,24 @@ func (lazy *Lazy) Set(src Lazier) Lazier {
 
 // Destroy the lazy object (if it was created via init() or set())
 func (lazy *Lazy) Reset() {
-	toimpl()
+	lazy.ptr = nil
 }
 
 /** IsValid
 Returns true if a valid object has been initialized in the SkTLazy,
 false otherwise. */
 func (lazy *Lazy) IsValid() bool {
-	toimpl()
-	return false
+	return lazy.ptr != nil
 }
 
 // Returns the object. This version should only be called when the caller
 // knows that the object has been initialized.
 func (lazy *Lazy) Get() Lazier {
-	toimpl()
-	return nil
+	return lazy.ptr
 }
 
 // Like above but doesn't assert if object isn't initialized (in which case
 // nullptr is returned).
 func (lazy *Lazy) GetMaybeNull() Lazier {
-	toimpl()
-	return nil
+	return lazy.ptr
 }
\ No newline at end of file
@@ -0,0 +1,373 @@
+package ggk
+
+/** tImageFilterLight
+is the light source of a lighting image filter. Colors are from 0 to 255
+in each channel. */
+type tImageFilterLight interface {
+	// Return the unit vector from the surface point to the light.
+	surfaceToLight(surface Point3) Point3
+	// Return the color of the light that reaches the surface from the
+	// direction surfaceToLight.
+	lightColor(surfaceToLight Point3) Color3f
+	// Return the light in the coordinates ctm maps to.
+	transform(ctm *Matrix) tImageFilterLight
+}
+
+func color3fFromColor(color Color) Color3f {
+	return Color3f{float32(color.Red()), float32(color.Green()), float32(color.Blue())}
+}
+
+// Map the location p by ctm, scaling its Z by the average of the scales of
+// X and Y.
+func mapLightLocation(p Point3, ctm *Matrix) Point3 {
+	var xy = ctm.MapXY(p.X, p.Y)
+	var z = []Point{{p.Z, p.Z}}
+	ctm.MapVectors(z, z)
+	return Point3{xy.X, xy.Y, (z[0].X + z[0].Y) / 2}
+}
+
+/** tDistantLight
+shines along the same direction everywhere, as if infinitely far. */
+type tDistantLight struct {
+	direction Point3 // towards the light.
+	color     Color3f
+}
+
+func (light *tDistantLight) surfaceToLight(surface Point3) Point3 {
+	return light.direction
+}
+
+func (light *tDistantLight) lightColor(surfaceToLight Point3) Color3f {
+	return light.color
+}
+
+func (light *tDistantLight) transform(ctm *Matrix) tImageFilterLight {
+	return light
+}
+
+/** tPointLight
+shines the same in every direction from its location. */
+type tPointLight struct {
+	location Point3
+	color    Color3f
+}
+
+func (light *tPointLight) surfaceToLight(surface Point3) Point3 {
+	var direction = Point3Sub(light.location, surface)
+	direction.Normalize()
+	return direction
+}
+
+func (light *tPointLight) lightColor(surfaceToLight Point3) Color3f {
+	return light.color
+}
+
+func (light *tPointLight) transform(ctm *Matrix) tImageFilterLight {
+	return &tPointLight{mapLightLocation(light.location, ctm), light.color}
+}
+
+// The angle over which the edge of a spot light cone fades, as a cosine.
+const kSpotLightAntiAliasThreshold Scalar = 0.016
+
+/** tSpotLight
+shines from its location towards its target, falling off with the
+specular exponent away from the axis and cut off outside a cone. */
+type tSpotLight struct {
+	location         Point3
+	target           Point3
+	specularExponent Scalar
+	cosOuterCone     Scalar
+	color            Color3f
+	s                Point3 // the unit axis of the cone, towards the target.
+}
+
+func newSpotLight(location, target Point3, specularExponent, cosOuterCone Scalar, color Color3f) *tSpotLight {
+	var light = &tSpotLight{
+		location:         location,
+		target:           target,
+		specularExponent: specularExponent,
+		cosOuterCone:     cosOuterCone,
+		color:            color,
+		s:                Point3Sub(target, location),
+	}
+	light.s.Normalize()
+	return light
+}
+
+func (light *tSpotLight) surfaceToLight(surface Point3) Point3 {
+	var direction = Point3Sub(light.location, surface)
+	direction.Normalize()
+	return direction
+}
+
+func (light *tSpotLight) lightColor(surfaceToLight Point3) Color3f {
+	var cosAngle = -Point3Dot(surfaceToLight, light.s)
+	if cosAngle < light.cosOuterCone {
+		return Color3f{}
+	}
+	var scale = ScalarPow(cosAngle, light.specularExponent)
+	if cosAngle < light.cosOuterCone+kSpotLightAntiAliasThreshold {
+		scale *= (cosAngle - light.cosOuterCone) / kSpotLightAntiAliasThreshold
+	}
+	var s = float32(scale)
+	return Color3f{light.color.R * s, light.color.G * s, light.color.B * s}
+}
+
+func (light *tSpotLight) transform(ctm *Matrix) tImageFilterLight {
+	return newSpotLight(mapLightLocation(light.location, ctm), mapLightLocation(light.target, ctm),
+		light.specularExponent, light.cosOuterCone, light.color)
+}
+
+/** tLighting
+computes the lit pixel of a surface from its unit normal, the unit vector
+to the light and the color of the light. */
+type tLighting interface {
+	shade(normal, surfaceToLight Point3, color Color3f) uint32
+}
+
+// Return the premultiplied pixel of alpha a and colors in 0 to 255, which
+// must not exceed a.
+func packLightingPixel(a, r, g, b Scalar) uint32 {
+	var pin = func(v Scalar) uint32 { return uint32(ScalarPin(v, 0, 255) + KScalarHalf) }
+	return PackARGB32(pin(a), pin(r), pin(g), pin(b))
+}
+
+/** tDiffuseLighting
+lights with the Lambertian reflection kd * N.L, leaving the surface
+opaque. */
+type tDiffuseLighting struct {
+	kd Scalar
+}
+
+func (lighting *tDiffuseLighting) shade(normal, surfaceToLight Point3, color Color3f) uint32 {
+	var scale = ScalarPin(lighting.kd*Point3Dot(normal, surfaceToLight), 0, 1)
+	return packLightingPixel(255, Scalar(color.R)*scale, Scalar(color.G)*scale, Scalar(color.B)*scale)
+}
+
+/** tSpecularLighting
+lights with the Phong reflection ks * pow(N.H, shininess), where H is the
+half vector between the light and the eye straight above, and the alpha
+is the brightest channel. */
+type tSpecularLighting struct {
+	ks        Scalar
+	shininess Scalar
+}
+
+func (lighting *tSpecularLighting) shade(normal, surfaceToLight Point3, color Color3f) uint32 {
+	var halfDir = surfaceToLight
+	halfDir.Z++
+	halfDir.Normalize()
+	var scale = ScalarPin(lighting.ks*ScalarPow(Point3Dot(normal, halfDir), lighting.shininess), 0, 1)
+	var r, g, b = Scalar(color.R) * scale, Scalar(color.G) * scale, Scalar(color.B) * scale
+	return packLightingPixel(ScalarMax(r, ScalarMax(g, b)), r, g, b)
+}
+
+/** tLightingImageFilter
+is the ImageFilterImpl that lights the surface whose height is the alpha
+of its input times surfaceScale. */
+type tLightingImageFilter struct {
+	light        tImageFilterLight
+	lighting     tLighting
+	surfaceScale Scalar
+}
+
+/** NewDistantLitDiffuseImageFilter
+Return a filter that diffusely lights input, or the source if input is
+nil, with a light of lightColor from direction, as if infinitely far.
+Returns nil for a negative kd. */
+func NewDistantLitDiffuseImageFilter(direction Point3, lightColor Color, surfaceScale, kd Scalar,
+	input *ImageFilter, cropRect *ImageFilterCropRect) *ImageFilter {
+	if kd < 0 {
+		return nil
+	}
+	return newLightingImageFilter(newDistantLight(direction, lightColor), &tDiffuseLighting{kd}, surfaceScale, input, cropRect)
+}
+
+/** NewPointLitDiffuseImageFilter
+Return a filter that diffusely lights input, or the source if input is
+nil, with a light of lightColor at location. Returns nil for a negative
+kd. */
+func NewPointLitDiffuseImageFilter(location Point3, lightColor Color, surfaceScale, kd Scalar,
+	input *ImageFilter, cropRect *ImageFilterCropRect) *ImageFilter {
+	if kd < 0 {
+		return nil
+	}
+	var light = &tPointLight{location, color3fFromColor(lightColor)}
+	return newLightingImageFilter(light, &tDiffuseLighting{kd}, surfaceScale, input, cropRect)
+}
+
+/** NewSpotLitDiffuseImageFilter
+Return a filter that diffusely lights input, or the source if input is
+nil, with a spot light of lightColor at location pointing at target, whose
+cone is cutoffAngle degrees from its axis. Returns nil for a negative
+kd. */
+func NewSpotLitDiffuseImageFilter(location, target Point3, specularExponent, cutoffAngle Scalar, lightColor Color,
+	surfaceScale, kd Scalar, input *ImageFilter, cropRect *ImageFilterCropRect) *ImageFilter {
+	if kd < 0 {
+		return nil
+	}
+	var light = newSpotLightDegrees(location, target, specularExponent, cutoffAngle, lightColor)
+	return newLightingImageFilter(light, &tDiffuseLighting{kd}, surfaceScale, input, cropRect)
+}
+
+/** NewDistantLitSpecularImageFilter
+Return a filter that specularly lights input, or the source if input is
+nil, with a light of lightColor from direction, as if infinitely far.
+Returns nil for a negative ks. */
+func NewDistantLitSpecularImageFilter(direction Point3, lightColor Color, surfaceScale, ks, shininess Scalar,
+	input *ImageFilter, cropRect *ImageFilterCropRect) *ImageFilter {
+	if ks < 0 {
+		return nil
+	}
+	var lighting = &tSpecularLighting{ks, shininess}
+	return newLightingImageFilter(newDistantLight(direction, lightColor), lighting, surfaceScale, input, cropRect)
+}
+
+/** NewPointLitSpecularImageFilter
+Return a filter that specularly lights input, or the source if input is
+nil, with a light of lightColor at location. Returns nil for a negative
+ks. */
+func NewPointLitSpecularImageFilter(location Point3, lightColor Color, surfaceScale, ks, shininess Scalar,
+	input *ImageFilter, cropRect *ImageFilterCropRect) *ImageFilter {
+	if ks < 0 {
+		return nil
+	}
+	var light = &tPointLight{location, color3fFromColor(lightColor)}
+	return newLightingImageFilter(light, &tSpecularLighting{ks, shininess}, surfaceScale, input, cropRect)
+}
+
+/** NewSpotLitSpecularImageFilter
+Return a filter that specularly lights input, or the source if input is
+nil, with a spot light of lightColor at location pointing at target, whose
+cone is cutoffAngle degrees from its axis. Returns nil for a negative
+ks. */
+func NewSpotLitSpecularImageFilter(location, target Point3, specularExponent, cutoffAngle Scalar, lightColor Color,
+	surfaceScale, ks, shininess Scalar, input *ImageFilter, cropRect *ImageFilterCropRect) *ImageFilter {
+	if ks < 0 {
+		return nil
+	}
+	var light = newSpotLightDegrees(location, target, specularExponent, cutoffAngle, lightColor)
+	return newLightingImageFilter(light, &tSpecularLighting{ks, shininess}, surfaceScale, input, cropRect)
+}
+
+func newDistantLight(direction Point3, lightColor Color) *tDistantLight {
+	direction.Normalize()
+	return &tDistantLight{direction, color3fFromColor(lightColor)}
+}
+
+// Return a spot light whose specular exponent is pinned to 1 to 128 and
+// whose cutoff angle is in degrees.
+func newSpotLightDegrees(location, target Point3, specularExponent, cutoffAngle Scalar, lightColor Color) *tSpotLight {
+	var cosOuterCone = ScalarCos(Scalar(DegreesToRadians(float32(cutoffAngle))))
+	return newSpotLight(location, target, ScalarPin(specularExponent, 1, 128), cosOuterCone, color3fFromColor(lightColor))
+}
+
+func newLightingImageFilter(light tImageFilterLight, lighting tLighting, surfaceScale Scalar,
+	input *ImageFilter, cropRect *ImageFilterCropRect) *ImageFilter {
+	var impl = &tLightingImageFilter{
+		light:        light,
+		lighting:     lighting,
+		surfaceScale: surfaceScale,
+	}
+	return NewImageFilter(impl, []*ImageFilter{input}, cropRect)
+}
+
+func (impl *tLightingImageFilter) OnFilterImage(filter *ImageFilter, src *Bitmap, ctx *ImageFilterContext) (*Bitmap, Point, bool) {
+	var input, inputOffset, ok = filter.filterInput(0, src, ctx)
+	if !ok {
+		return nil, PointZero, false
+	}
+	var dstBounds Rect
+	if dstBounds, ok = filter.applyCropRect(ctx, filterBitmapBounds(input, inputOffset)); !ok {
+		return nil, PointZero, false
+	}
+
+	var heights = filterPixmap(copyFilterBitmap(input, inputOffset, dstBounds))
+	var dst = newFilterBitmap(dstBounds)
+	var pixels = filterPixmap(dst)
+	var light = impl.light.transform(ctx.CTM)
+	var surfaceScale = impl.surfaceScale / 255
+	var width, height = int(dstBounds.Width), int(dstBounds.Height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var alpha = Scalar(GetPackedA32(heights.Pixel32(x, y)))
+			var surface = Point3{dstBounds.Left + Scalar(x), dstBounds.Top + Scalar(y), surfaceScale * alpha}
+			var surfaceToLight = light.surfaceToLight(surface)
+			var normal = lightingNormal(heights, x, y, width, height, surfaceScale)
+			pixels.SetPixel32(x, y, impl.lighting.shade(normal, surfaceToLight, light.lightColor(surfaceToLight)))
+		}
+	}
+	return dst, Point{dstBounds.Left, dstBounds.Top}, true
+}
+
+/** lightingNormal
+Return the unit normal at (x, y) of the surface whose height is the alpha
+of the width by height pixels times surfaceScale. The slopes are the Sobel
+kernels of the SVG lighting filters, which fall back to one sided
+differences and fewer rows or columns at the edges. */
+func lightingNormal(pixels *Pixmap, x, y, width, height int, surfaceScale Scalar) Point3 {
+	var alpha = func(x, y int) Scalar {
+		return Scalar(GetPackedA32(pixels.Pixel32(x, y)))
+	}
+	var left, right, top, bottom = x, x, y, y
+	if x > 0 {
+		left--
+	}
+	if x < width-1 {
+		right++
+	}
+	if y > 0 {
+		top--
+	}
+	if y < height-1 {
+		bottom++
+	}
+
+	// the differences across x, weighted 1, 2, 1 along y, and across y,
+	// weighted along x.
+	var nx, ny, wx, wy Scalar
+	for j := top; j <= bottom; j++ {
+		var w Scalar = 1
+		if j == y {
+			w = 2
+		}
+		nx += w * (alpha(right, j) - alpha(left, j))
+		wx += w
+	}
+	for i := left; i <= right; i++ {
+		var w Scalar = 1
+		if i == x {
+			w = 2
+		}
+		ny += w * (alpha(i, bottom) - alpha(i, top))
+		wy += w
+	}
+	nx /= wx
+	ny /= wy
+	if right-left == 1 {
+		nx *= 2
+	}
+	if bottom-top == 1 {
+		ny *= 2
+	}
+
+	var normal = Point3{-surfaceScale * nx, -surfaceScale * ny, 1}
+	normal.Normalize()
+	return normal
+}
+
+func (impl *tLightingImageFilter) OnFilterBounds(filter *ImageFilter, src Rect, ctm *Matrix, direction ImageFilterMapDirection) Rect {
+	return filter.filterBoundsWithNode(src, ctm, direction, func(r Rect) Rect { return r })
+}
+
+func (impl *tLightingImageFilter) OnComputeFastBounds(filter *ImageFilter, src Rect) Rect {
+	return filter.inputsFastBounds(src)
+}
+
+func (impl *tLightingImageFilter) OnAffectsTransparentBlack() bool {
+	return false
+}
+
+func (impl *tLightingImageFilter) OnAsAColorFilter(filter *ImageFilter) (*ColorFilter, bool) {
+	return nil, false
+}
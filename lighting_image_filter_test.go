@@ -0,0 +1,94 @@
+package ggk
+
+import "testing"
+
+// newTestHeightSource returns an opaque 10x10 bitmap, or a transparent one
+// with an opaque 4x4 square at (3, 3).
+func newTestHeightSource(flat bool) *Bitmap {
+	if !flat {
+		return newTestFilterSource()
+	}
+	var bmp = newFilterBitmap(MakeRect(0, 0, 10, 10))
+	var pixels = filterPixmap(bmp)
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			pixels.SetPixel32(x, y, PackARGB32(0xff, 0, 0, 0))
+		}
+	}
+	return bmp
+}
+
+func TestLightingImageFilter(t *testing.T) {
+	var white = ColorWithRGB(0xff, 0xff, 0xff)
+	var red = ColorWithRGB(0xff, 0, 0)
+	var tests = []struct {
+		name   string
+		filter *ImageFilter
+		flat   bool
+		x, y   int
+		want   uint32
+	}{
+		{"diffuse from above", NewDistantLitDiffuseImageFilter(MakePoint3(0, 0, 1), white, 1, 1, nil, nil), true, 5, 5,
+			PackARGB32(0xff, 0xff, 0xff, 0xff)},
+		{"diffuse at 45 degrees", NewDistantLitDiffuseImageFilter(MakePoint3(1, 0, 1), white, 1, 1, nil, nil), true, 0, 9,
+			PackARGB32(0xff, 180, 180, 180)},
+		{"half diffuse", NewDistantLitDiffuseImageFilter(MakePoint3(0, 0, 1), red, 1, 0.5, nil, nil), true, 5, 5,
+			PackARGB32(0xff, 128, 0, 0)},
+		{"lit slope", NewDistantLitDiffuseImageFilter(MakePoint3(-1, 0, 1), white, 1, 1, nil, nil), false, 2, 5,
+			PackARGB32(0xff, 0xff, 0xff, 0xff)},
+		{"dark slope", NewDistantLitDiffuseImageFilter(MakePoint3(-1, 0, 1), white, 1, 1, nil, nil), false, 7, 5,
+			PackARGB32(0xff, 0, 0, 0)},
+		{"point below", NewPointLitDiffuseImageFilter(MakePoint3(2, 3, 10), white, 1, 1, nil, nil), true, 2, 3,
+			PackARGB32(0xff, 0xff, 0xff, 0xff)},
+		{"spot inside", NewSpotLitDiffuseImageFilter(MakePoint3(2, 2, 10), MakePoint3(2, 2, 0), 1, 30, white, 1, 1, nil, nil), true, 2, 2,
+			PackARGB32(0xff, 0xff, 0xff, 0xff)},
+		{"spot outside", NewSpotLitDiffuseImageFilter(MakePoint3(2, 2, 10), MakePoint3(2, 2, 0), 1, 30, white, 1, 1, nil, nil), true, 8, 8,
+			PackARGB32(0xff, 0, 0, 0)},
+		{"specular from above", NewDistantLitSpecularImageFilter(MakePoint3(0, 0, 1), red, 1, 1, 1, nil, nil), true, 5, 5,
+			PackARGB32(0xff, 0xff, 0, 0)},
+		{"specular at 45 degrees", NewDistantLitSpecularImageFilter(MakePoint3(1, 0, 1), white, 1, 1, 1, nil, nil), true, 5, 5,
+			PackARGB32(236, 236, 236, 236)},
+		{"shiny specular", NewPointLitSpecularImageFilter(MakePoint3(5, 5, 1), white, 1, 1, 20, nil, nil), true, 0, 5,
+			PackARGB32(0, 0, 0, 0)},
+		{"spot specular", NewSpotLitSpecularImageFilter(MakePoint3(2, 2, 10), MakePoint3(2, 2, 0), 1, 30, white, 1, 1, 1, nil, nil), true, 2, 2,
+			PackARGB32(0xff, 0xff, 0xff, 0xff)},
+	}
+	for _, test := range tests {
+		var ctx = NewImageFilterContext(nil, MakeRect(-50, -50, 100, 100))
+		var result, offset, ok = test.filter.FilterImage(newTestHeightSource(test.flat), ctx)
+		if !ok || filterBitmapBounds(result, offset) != MakeRect(0, 0, 10, 10) {
+			t.Errorf("%v FilterImage() want bounds %v got %v, %v", test.name, MakeRect(0, 0, 10, 10), filterBitmapBounds(result, offset), ok)
+			continue
+		}
+		if got := filterPixmap(result).Pixel32(test.x, test.y); got != test.want {
+			t.Errorf("%v FilterImage() at (%v, %v) want %#x got %#x", test.name, test.x, test.y, test.want, got)
+		}
+	}
+
+	if NewDistantLitDiffuseImageFilter(MakePoint3(0, 0, 1), white, 1, -1, nil, nil) != nil ||
+		NewSpotLitSpecularImageFilter(MakePoint3(0, 0, 1), MakePoint3(0, 0, 0), 1, 10, white, 1, -1, 1, nil, nil) != nil {
+		t.Errorf("lighting filters with negative constants want nil")
+	}
+}
+
+func TestLightingNormal(t *testing.T) {
+	var pixels = filterPixmap(newTestFilterSource())
+	var tests = []struct {
+		x, y int
+		want Point3
+	}{
+		{5, 5, Point3{0, 0, 1}},  // the flat top of the square.
+		{0, 0, Point3{0, 0, 1}},  // the flat ground in a corner.
+		{2, 5, Point3{-1, 0, 1}}, // the slope up to the square.
+		{7, 3, Point3{3, -1, 4}}, // the slope down from the corner.
+		{6, 9, Point3{0, 0, 1}},  // the edge row below the square.
+	}
+	for _, test := range tests {
+		var want = test.want
+		want.Normalize()
+		var got = lightingNormal(pixels, test.x, test.y, 10, 10, 1.0/255)
+		if ScalarAbs(got.X-want.X) > 1e-4 || ScalarAbs(got.Y-want.Y) > 1e-4 || ScalarAbs(got.Z-want.Z) > 1e-4 {
+			t.Errorf("lightingNormal(%v, %v) want %v got %v", test.x, test.y, want, got)
+		}
+	}
+}
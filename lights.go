@@ -1,5 +1,96 @@
 package ggk
 
+type LightType int
+
+const (
+	KLightTypeAmbient     LightType = iota // lights everything the same
+	KLightTypeDirectional                  // lights along a direction from infinitely far
+)
+
+/** Light
+is one light of Lights. Color is its intensity in each channel, usually
+from 0 to 1. Dir is the unit direction towards a directional light, with
+Z pointing out of the screen, and is zero for an ambient light. */
+type Light struct {
+	Type  LightType
+	Color Color3f
+	Dir   Point3
+}
+
+// NewAmbientLight returns an ambient light of color.
+func NewAmbientLight(color Color3f) Light {
+	return Light{Type: KLightTypeAmbient, Color: color}
+}
+
+// NewDirectionalLight returns a light of color shining from dir, which is
+// normalized.
+func NewDirectionalLight(color Color3f, dir Point3) Light {
+	dir.Normalize()
+	return Light{Type: KLightTypeDirectional, Color: color, Dir: dir}
+}
+
+/** Lights
+is the set of lights a canvas shades with: the sum of its ambient lights
+and a list of directional lights. Lights is immutable once built. */
 type Lights struct {
+	ambient Color3f
+	lights  []Light
+}
+
+/** NewLights
+Return the set of lights. The ambient lights are summed into one and the
+directional lights are kept in order. */
+func NewLights(lights ...Light) *Lights {
+	var set = new(Lights)
+	for _, light := range lights {
+		if light.Type == KLightTypeAmbient {
+			set.ambient = Color3f{
+				set.ambient.R + light.Color.R,
+				set.ambient.G + light.Color.G,
+				set.ambient.B + light.Color.B,
+			}
+			continue
+		}
+		set.lights = append(set.lights, light)
+	}
+	return set
+}
+
+// AmbientLight returns the color of the sum of the ambient lights.
+func (lights *Lights) AmbientLight() Color3f {
+	return lights.ambient
+}
+
+// NumLights returns the number of directional lights.
+func (lights *Lights) NumLights() int {
+	return len(lights.lights)
+}
+
+// Light returns the directional light at index.
+func (lights *Lights) Light(index int) Light {
+	return lights.lights[index]
+}
+
+/** Shade
+Return the color of a surface of color whose unit normal is normal, lit by
+the ambient light plus each directional light in proportion to how much
+the surface faces it. */
+func (lights *Lights) Shade(color Color3f, normal Point3) Color3f {
+	return lights.shade(color, normal, nil)
+}
 
+// Shade like Shade, but skip the directional lights at the indices for
+// which shadowed returns true. A nil shadowed shadows nothing.
+func (lights *Lights) shade(color Color3f, normal Point3, shadowed func(index int) bool) Color3f {
+	var r, g, b = lights.ambient.R, lights.ambient.G, lights.ambient.B
+	for i, light := range lights.lights {
+		var nDotL = float32(Point3Dot(normal, light.Dir))
+		if nDotL <= 0 || (shadowed != nil && shadowed(i)) {
+			continue
+		}
+		r += light.Color.R * nDotL
+		g += light.Color.G * nDotL
+		b += light.Color.B * nDotL
+	}
+	return Color3f{color.R * r, color.G * g, color.B * b}
 }
@@ -0,0 +1,50 @@
+package ggk
+
+import "testing"
+
+func TestLights(t *testing.T) {
+	var lights = NewLights(
+		NewAmbientLight(Color3f{0.1, 0.2, 0.3}),
+		NewDirectionalLight(Color3f{1, 1, 1}, MakePoint3(0, 0, 2)),
+		NewAmbientLight(Color3f{0.1, 0, 0}),
+		NewDirectionalLight(Color3f{0, 0.5, 0}, MakePoint3(1, 0, 0)),
+	)
+	if got := lights.AmbientLight(); got != (Color3f{0.2, 0.2, 0.3}) {
+		t.Errorf("AmbientLight() want %v got %v", Color3f{0.2, 0.2, 0.3}, got)
+	}
+	if lights.NumLights() != 2 || lights.Light(0).Dir != MakePoint3(0, 0, 1) || lights.Light(1).Color != (Color3f{0, 0.5, 0}) {
+		t.Errorf("NumLights() want the 2 directional lights in order got %v", lights.lights)
+	}
+
+	var tests = []struct {
+		normal Point3
+		want   Color3f
+	}{
+		{MakePoint3(0, 0, 1), Color3f{0.6, 0.6, 0.65}},  // facing the light from above.
+		{MakePoint3(1, 0, 0), Color3f{0.1, 0.35, 0.15}}, // facing the light from the side.
+		{MakePoint3(0, 0, -1), Color3f{0.1, 0.1, 0.15}}, // facing away, lit only by the ambient.
+	}
+	var near = func(a, b float32) bool { return a-b < 1e-5 && b-a < 1e-5 }
+	for _, test := range tests {
+		var got = lights.Shade(Color3f{0.5, 0.5, 0.5}, test.normal)
+		if !near(got.R, test.want.R) || !near(got.G, test.want.G) || !near(got.B, test.want.B) {
+			t.Errorf("Shade(%v) want %v got %v", test.normal, test.want, got)
+		}
+	}
+
+	var bmp = new(Bitmap)
+	bmp.AllocN32Pixels(4, 4, false)
+	var canvas = NewCanvasBitmap(bmp)
+	if canvas.Lights() != nil {
+		t.Errorf("Lights() of a new canvas want nil")
+	}
+	canvas.SetLights(lights)
+	if canvas.Lights() != lights {
+		t.Errorf("Lights() want the lights set")
+	}
+	canvas.TranslateZ(2)
+	canvas.TranslateZ(-0.5)
+	if canvas.Z() != 1.5 {
+		t.Errorf("Z() want 1.5 got %v", canvas.Z())
+	}
+}
@@ -0,0 +1,33 @@
+package ggk
+
+/** tLocalMatrixShader
+draws a shader through an extra local matrix. */
+type tLocalMatrixShader struct {
+	proxy *Shader
+}
+
+/** NewLocalMatrixShader
+Return a shader drawing shader through matrix, applied before the local
+matrix of shader. Returns shader if matrix is the identity. */
+func NewLocalMatrixShader(shader *Shader, matrix *Matrix) *Shader {
+	if matrix == nil || matrix.IsIdentity() {
+		return shader
+	}
+	return NewShader(&tLocalMatrixShader{shader}, matrix)
+}
+
+/** MakeWithLocalMatrix
+Return a shader drawing this shader through matrix, applied before its
+local matrix. */
+func (shader *Shader) MakeWithLocalMatrix(matrix *Matrix) *Shader {
+	return NewLocalMatrixShader(shader, matrix)
+}
+
+func (impl *tLocalMatrixShader) OnCreateContext(shader *Shader, rec *ShaderContextRec) *ShaderContext {
+	var localMatrix = NewMatrixClone(shader.LocalMatrix())
+	if rec.LocalMatrix != nil {
+		localMatrix.PreConcat(rec.LocalMatrix)
+	}
+	var proxyRec = NewShaderContextRec(rec.Paint, rec.Matrix, localMatrix, rec.PreferredDstType)
+	return impl.proxy.CreateContext(proxyRec)
+}
@@ -1,5 +1,190 @@
 package ggk
 
+import "encoding/binary"
+
+/** Mask
+is used to describe alpha bitmaps, either 1bit, 8bit, 3D or LCD16 masks,
+mostly as glyph images. Bounds is in device coordinates and Image holds
+RowBytes per row of the mask in Format. A 3D mask is an A8 mask followed by
+two more planes of the same size, the mul and the add planes, that a 3D
+shader uses to light the color. */
 type Mask struct {
+	Image    []byte
+	Bounds   Rect
+	RowBytes int
+	Format   MaskFormat
+}
+
+/** NewMask
+Return a mask of format covering bounds, with the smallest row bytes for
+its width and a zeroed image. */
+func NewMask(bounds Rect, format MaskFormat) *Mask {
+	var mask = &Mask{
+		Bounds:   bounds,
+		RowBytes: maskRowBytes(format, int(bounds.Width)),
+		Format:   format,
+	}
+	mask.AllocImage()
+	return mask
+}
+
+// Return the smallest row bytes of a mask width pixels wide in format.
+func maskRowBytes(format MaskFormat, width int) int {
+	switch format {
+	case KMaskFormatBW:
+		return (width + 7) >> 3
+	case KMaskFormatARGB32:
+		return width * 4
+	case KMaskFormatLCD16:
+		return width * 2
+	}
+	return width
+}
+
+// IsEmpty returns true if the mask covers no pixels.
+func (mask *Mask) IsEmpty() bool {
+	return mask.Bounds.Width <= 0 || mask.Bounds.Height <= 0
+}
+
+// ComputeImageSize returns the size in bytes of one plane of the image.
+func (mask *Mask) ComputeImageSize() int {
+	if mask.IsEmpty() {
+		return 0
+	}
+	return mask.RowBytes * int(mask.Bounds.Height)
+}
+
+// ComputeTotalImageSize returns the size in bytes of the image, which is
+// three planes for 3D masks.
+func (mask *Mask) ComputeTotalImageSize() int {
+	var size = mask.ComputeImageSize()
+	if mask.Format == KMaskFormat3D {
+		size *= 3
+	}
+	return size
+}
+
+// AllocImage sets Image to a zeroed buffer of the total image size.
+func (mask *Mask) AllocImage() {
+	mask.Image = make([]byte, mask.ComputeTotalImageSize())
+}
+
+// Return the offset in Image of the byte holding device pixel (x, y) of a
+// mask whose pixels are bitsPerPixel wide.
+func (mask *Mask) offset(x, y int, bitsPerPixel uint) int {
+	var dx = x - int(mask.Bounds.Left)
+	return (y-int(mask.Bounds.Top))*mask.RowBytes + (dx*int(bitsPerPixel))>>3
+}
+
+/** Addr1
+Return the image from the byte holding device pixel (x, y) on. The mask
+must be BW and (x, y) must be inside its bounds. The pixel is the bit
+0x80 >> ((x - Bounds.Left) & 7) of the byte. */
+func (mask *Mask) Addr1(x, y int) []byte {
+	return mask.Image[mask.offset(x, y, 1):]
+}
+
+/** Addr8
+Return the image from device pixel (x, y) on. The mask must be A8 or 3D,
+in which case this is the alpha plane, and (x, y) must be inside its
+bounds. */
+func (mask *Mask) Addr8(x, y int) []byte {
+	return mask.Image[mask.offset(x, y, 8):]
+}
+
+/** AddrLCD16
+Return the image from device pixel (x, y) on. The mask must be LCD16 and
+(x, y) must be inside its bounds. */
+func (mask *Mask) AddrLCD16(x, y int) []byte {
+	return mask.Image[mask.offset(x, y, 16):]
+}
+
+/** Addr32
+Return the image from device pixel (x, y) on. The mask must be ARGB32 and
+(x, y) must be inside its bounds. */
+func (mask *Mask) Addr32(x, y int) []byte {
+	return mask.Image[mask.offset(x, y, 32):]
+}
+
+// Return the alpha at device coordinate (x, y). The mask must be BW, A8 or
+// 3D and (x, y) must be inside its bounds.
+func (mask *Mask) alphaAt(x, y int) uint8 {
+	if mask.Format == KMaskFormatBW {
+		if mask.Addr1(x, y)[0]&(0x80>>uint((x-int(mask.Bounds.Left))&7)) != 0 {
+			return 0xff
+		}
+		return 0
+	}
+	return mask.Addr8(x, y)[0]
+}
+
+// Return the LCD16 value at device coordinate (x, y). The mask must be LCD16
+// and (x, y) must be inside its bounds.
+func (mask *Mask) lcd16At(x, y int) uint16 {
+	return binary.LittleEndian.Uint16(mask.AddrLCD16(x, y))
+}
+
+// Return the premultiplied pixel at device coordinate (x, y). The mask must
+// be ARGB32 and (x, y) must be inside its bounds.
+func (mask *Mask) argb32At(x, y int) uint32 {
+	return binary.LittleEndian.Uint32(mask.Addr32(x, y))
+}
+
+/** ToA8
+Return a copy of a BW, A8 or 3D mask as an A8 mask with the same bounds
+and rows packed tightly, in which BW pixels are either 0 or 0xff and only
+the alpha plane of a 3D mask is kept. Returns nil for other formats. */
+func (mask *Mask) ToA8() *Mask {
+	if mask.Format != KMaskFormatBW && mask.Format != KMaskFormatA8 && mask.Format != KMaskFormat3D {
+		return nil
+	}
+	var a8 = NewMask(mask.Bounds, KMaskFormatA8)
+	var width, height = int(mask.Bounds.Width), int(mask.Bounds.Height)
+	for y := 0; y < height; y++ {
+		var src = mask.Image[y*mask.RowBytes:]
+		var dst = a8.Image[y*a8.RowBytes : y*a8.RowBytes+width]
+		if mask.Format != KMaskFormatBW {
+			copy(dst, src)
+			continue
+		}
+		for x := range dst {
+			if src[x>>3]&(0x80>>uint(x&7)) != 0 {
+				dst[x] = 0xff
+			}
+		}
+	}
+	return a8
+}
+
+/** ToBW
+Return a copy of a BW, A8 or 3D mask as a BW mask with the same bounds and
+rows packed tightly, in which the pixels that are at least half covered
+are set. Returns nil for other formats. */
+func (mask *Mask) ToBW() *Mask {
+	if mask.Format == KMaskFormatBW {
+		var bw = NewMask(mask.Bounds, KMaskFormatBW)
+		for y := 0; y < int(mask.Bounds.Height); y++ {
+			copy(bw.Image[y*bw.RowBytes:(y+1)*bw.RowBytes], mask.Image[y*mask.RowBytes:])
+		}
+		return bw
+	}
+	if mask.Format != KMaskFormatA8 && mask.Format != KMaskFormat3D {
+		return nil
+	}
+	var bw = NewMask(mask.Bounds, KMaskFormatBW)
+	packA8ToBW(mask.Image, mask.RowBytes, bw.Image, bw.RowBytes, int(mask.Bounds.Width), int(mask.Bounds.Height))
+	return bw
+}
 
-}
\ No newline at end of file
+// Set the bits of the BW image dst for the pixels of the A8 image src that
+// are at least half covered. dst must be zeroed.
+func packA8ToBW(src []byte, srcRowBytes int, dst []byte, dstRowBytes int, width, height int) {
+	for y := 0; y < height; y++ {
+		var srcRow, dstRow = src[y*srcRowBytes:], dst[y*dstRowBytes:]
+		for x := 0; x < width; x++ {
+			if srcRow[x] >= 0x80 {
+				dstRow[x>>3] |= 0x80 >> uint(x&7)
+			}
+		}
+	}
+}
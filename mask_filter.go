@@ -10,10 +10,97 @@ const (
 	KMaskFormatLCD16
 )
 
+/** MaskFilterImpl
+is implemented by each kind of mask filter. */
+type MaskFilterImpl interface {
+	OnGetFormat() MaskFormat
+	OnFilterMask(dst, src *Mask, matrix *Matrix, margin *Point) bool
+	OnComputeFastBounds(src Rect) Rect
+	OnAsABlur(rec *MaskFilterBlurRec) bool
+}
+
+/** MaskFilter
+is the base class for objects that transform the coverage mask of what is
+drawn before it is blitted. When a paint has a mask filter, each shape or
+glyph drawn with it is first scan converted into a mask, which the filter
+turns into the mask that is blitted with the paint's color. */
 type MaskFilter struct {
+	Impl MaskFilterImpl
+}
 
+func NewMaskFilter(impl MaskFilterImpl) *MaskFilter {
+	return &MaskFilter{Impl: impl}
 }
 
+/** MaskFilterBlurRec
+describes a mask filter that is a plain blur. */
+type MaskFilterBlurRec struct {
+	Sigma Scalar
+	Style BlurStyle
+}
+
+// Format returns the format of the masks the filter makes.
 func (filter *MaskFilter) Format() MaskFormat {
-	return KMaskFormatARGB32
+	if filter.Impl == nil {
+		return KMaskFormatA8
+	}
+	return filter.Impl.OnGetFormat()
+}
+
+/** FilterMask
+Filter src into dst, with matrix the transform of what the mask was drawn
+from. If margin is not nil it is set to how far the filter reaches past
+the left and top of src. Returns false if the filter can't filter src, in which
+case dst is unchanged. */
+func (filter *MaskFilter) FilterMask(dst, src *Mask, matrix *Matrix, margin *Point) bool {
+	if filter.Impl == nil {
+		return false
+	}
+	return filter.Impl.OnFilterMask(dst, src, matrix, margin)
+}
+
+// ComputeFastBounds returns the bounds of the filtered mask of a shape
+// with the device bounds src.
+func (filter *MaskFilter) ComputeFastBounds(src Rect) Rect {
+	if filter.Impl == nil {
+		return src
+	}
+	return filter.Impl.OnComputeFastBounds(src)
+}
+
+// AsABlur returns true if the filter is a plain blur, and if rec is not
+// nil sets it to the blur.
+func (filter *MaskFilter) AsABlur(rec *MaskFilterBlurRec) bool {
+	if filter.Impl == nil {
+		return false
+	}
+	return filter.Impl.OnAsABlur(rec)
+}
+
+/** filterPath
+Draw devPath, which is in device space, through the filter: scan convert
+it into an A8 mask covering the clip, plus what the filter pulls in from
+outside it, filter the mask and blit the result. Returns false if the
+filter couldn't filter the mask. */
+func (filter *MaskFilter) filterPath(devPath *Path, matrix *Matrix, clip *RasterClip, blitter Blitter) bool {
+	// an empty mask tells how far the filter reaches.
+	var margin Point
+	var probe Mask
+	if !filter.FilterMask(&probe, &Mask{Format: KMaskFormatA8}, matrix, &margin) {
+		return false
+	}
+	var clipBounds = clip.Bounds()
+	var reach = clipBounds
+	reach.Outset(margin.X, margin.Y)
+	var src = scanPathToMask(devPath, reach)
+	if src == nil {
+		return true
+	}
+
+	var dst Mask
+	if !filter.FilterMask(&dst, src, matrix, nil) {
+		return false
+	}
+	blitter.BlitMask(&dst, clipBounds)
+	return true
 }
@@ -0,0 +1,71 @@
+package ggk
+
+import "testing"
+
+func TestNewMask(t *testing.T) {
+	var tests = []struct {
+		format    MaskFormat
+		rowBytes  int
+		imageSize int
+	}{
+		{KMaskFormatBW, 2, 6},
+		{KMaskFormatA8, 10, 30},
+		{KMaskFormat3D, 10, 90},
+		{KMaskFormatARGB32, 40, 120},
+		{KMaskFormatLCD16, 20, 60},
+	}
+	for _, test := range tests {
+		var mask = NewMask(MakeRect(-4, 7, 10, 3), test.format)
+		if mask.RowBytes != test.rowBytes || len(mask.Image) != test.imageSize || mask.ComputeTotalImageSize() != test.imageSize {
+			t.Errorf("NewMask(%v) want row bytes %v and size %v got %v and %v",
+				test.format, test.rowBytes, test.imageSize, mask.RowBytes, len(mask.Image))
+		}
+	}
+
+	var empty = NewMask(MakeRect(0, 0, 0, 5), KMaskFormatA8)
+	if !empty.IsEmpty() || empty.ComputeImageSize() != 0 {
+		t.Errorf("NewMask() of an empty rect want an empty mask got %v", empty.ComputeImageSize())
+	}
+
+	// the addresses of device pixels are relative to the bounds.
+	var bw = NewMask(MakeRect(-4, 7, 10, 3), KMaskFormatBW)
+	bw.Addr1(5, 8)[0] |= 0x80 >> uint((5+4)&7)
+	if bw.Image[3] != 0x40 || bw.alphaAt(5, 8) != 0xff || bw.alphaAt(4, 8) != 0 {
+		t.Errorf("Addr1(5, 8) want bit 0x40 of byte 3 got %v", bw.Image)
+	}
+	var lcd = NewMask(MakeRect(-4, 7, 10, 3), KMaskFormatLCD16)
+	lcd.AddrLCD16(-3, 9)[0] = 0x12
+	if lcd.Image[42] != 0x12 || lcd.lcd16At(-3, 9) != 0x12 {
+		t.Errorf("AddrLCD16(-3, 9) want byte 42 got %v", lcd.Image)
+	}
+}
+
+func TestMaskConvert(t *testing.T) {
+	var a8 = &Mask{
+		Image:    []byte{0, 0x7f, 0x80, 0xff, 9, 9, 0xff, 0, 0x80, 0x10, 9, 9},
+		Bounds:   MakeRect(3, 4, 4, 2),
+		RowBytes: 6,
+		Format:   KMaskFormatA8,
+	}
+	var bw = a8.ToBW()
+	if bw.Format != KMaskFormatBW || bw.Bounds != a8.Bounds || bw.RowBytes != 1 || bw.Image[0] != 0x30 || bw.Image[1] != 0xa0 {
+		t.Errorf("ToBW() want [0x30 0xa0] got %#v", bw.Image)
+	}
+
+	var back = bw.ToA8()
+	var want = []byte{0, 0, 0xff, 0xff, 0xff, 0, 0xff, 0}
+	if back.Format != KMaskFormatA8 || back.Bounds != a8.Bounds || back.RowBytes != 4 || string(back.Image) != string(want) {
+		t.Errorf("ToA8() want %v got %v", want, back.Image)
+	}
+
+	var packed = a8.ToA8()
+	want = []byte{0, 0x7f, 0x80, 0xff, 0xff, 0, 0x80, 0x10}
+	if string(packed.Image) != string(want) {
+		t.Errorf("ToA8() of an A8 mask want %v got %v", want, packed.Image)
+	}
+
+	var lcd = NewMask(a8.Bounds, KMaskFormatLCD16)
+	if lcd.ToA8() != nil || lcd.ToBW() != nil {
+		t.Errorf("ToA8() and ToBW() of an LCD16 mask want nil")
+	}
+}
@@ -24,12 +24,13 @@ type Matrix struct {
 
 func NewMatrix() *Matrix {
 	var matrix = new(Matrix)
+	matrix.Reset()
 	return matrix
 }
 
 func NewMatrixClone(otr *Matrix) *Matrix {
 	var matrix = &Matrix{}
-	toimpl()
+	*matrix = *otr
 	return matrix
 }
 
@@ -62,12 +63,84 @@ func (m *Matrix) SetTypeMask(mask uint32) {
 
 func (m *Matrix) TypeMask() MatrixTypeMask {
 	if (m.typeMask & KMatrixTypeMaskUnknown) != 0 {
-		// m.typeMask = m.ComputeTypeMask()
+		m.typeMask = m.computeTypeMask()
 	}
 	// only return the public masks.
 	return MatrixTypeMask(m.typeMask & 0xF)
 }
 
+func (m *Matrix) computeTypeMask() uint32 {
+	var mask uint32 = 0
+
+	if m.mat[KMPersp0] != 0 || m.mat[KMPersp1] != 0 || m.mat[KMPersp2] != 1 {
+		// Once it is determined that that this is a perspective transform,
+		// all other flags are moot as far as optimizations are concerned.
+		return KMatrixTypeMaskTranslate | KMatrixTypeMaskScale |
+			KMatrixTypeMaskAffine | KMatrixTypeMaskPerspective
+	}
+
+	if m.mat[KMTransX] != 0 || m.mat[KMTransY] != 0 {
+		mask |= KMatrixTypeMaskTranslate
+	}
+
+	if m.mat[KMSkewX] != 0 || m.mat[KMSkewY] != 0 {
+		mask |= KMatrixTypeMaskAffine | KMatrixTypeMaskScale
+	} else if m.mat[KMScaleX] != 1 || m.mat[KMScaleY] != 1 {
+		mask |= KMatrixTypeMaskScale
+	}
+
+	return mask
+}
+
+// IsIdentity returns true if the matrix is identity.
+func (m *Matrix) IsIdentity() bool {
+	return m.TypeMask() == KMatrixTypeMaskIdentity
+}
+
+// IsScaleTranslate returns true if the matrix contains only translation and
+// scale (no skew, rotation or perspective).
+func (m *Matrix) IsScaleTranslate() bool {
+	return m.TypeMask()&^(KMatrixTypeMaskScale|KMatrixTypeMaskTranslate) == 0
+}
+
+// HasPerspective returns true if the matrix contains perspective elements.
+func (m *Matrix) HasPerspective() bool {
+	return m.TypeMask()&KMatrixTypeMaskPerspective != 0
+}
+
+func (m *Matrix) Get(index int) Scalar {
+	return m.mat[index]
+}
+
+func (m *Matrix) Set(index int, value Scalar) {
+	m.mat[index] = value
+	m.typeMask = KMatrixTypeMaskUnknown
+}
+
+func (m *Matrix) ScaleX() Scalar {
+	return m.mat[KMScaleX]
+}
+
+func (m *Matrix) ScaleY() Scalar {
+	return m.mat[KMScaleY]
+}
+
+func (m *Matrix) SkewX() Scalar {
+	return m.mat[KMSkewX]
+}
+
+func (m *Matrix) SkewY() Scalar {
+	return m.mat[KMSkewY]
+}
+
+func (m *Matrix) TranslateX() Scalar {
+	return m.mat[KMTransX]
+}
+
+func (m *Matrix) TranslateY() Scalar {
+	return m.mat[KMTransY]
+}
+
 // [scale-x    skew-x      trans-x]   [X]   [X']
 // [skew-y     scale-y     trans-y] * [Y] = [Y']
 // [persp-0    persp-1     persp-2]   [1]   [1 ]
@@ -75,9 +148,177 @@ func (m *Matrix) Reset() {
 	m.mat[KMScaleX], m.mat[KMSkewX ], m.mat[KMTransX] = 1, 0, 0
 	m.mat[KMSkewY ], m.mat[KMScaleY], m.mat[KMTransY] = 0, 1, 0
 	m.mat[KMPersp0], m.mat[KMPersp1], m.mat[KMPersp2] = 0, 0, 1
-	// setTypeMask(kIdentity_Mask | kRectStaysRect_Mask)
+	m.typeMask = KMatrixTypeMaskIdentity
+}
+
+func (m *Matrix) SetAll(scaleX, skewX, transX, skewY, scaleY, transY, persp0, persp1, persp2 Scalar) {
+	m.mat[KMScaleX], m.mat[KMSkewX ], m.mat[KMTransX] = scaleX, skewX, transX
+	m.mat[KMSkewY ], m.mat[KMScaleY], m.mat[KMTransY] = skewY, scaleY, transY
+	m.mat[KMPersp0], m.mat[KMPersp1], m.mat[KMPersp2] = persp0, persp1, persp2
+	m.typeMask = KMatrixTypeMaskUnknown
+}
+
+// Set the matrix to translate by (dx, dy).
+func (m *Matrix) SetTranslate(dx, dy Scalar) {
+	m.Reset()
+	m.mat[KMTransX], m.mat[KMTransY] = dx, dy
+	m.typeMask = KMatrixTypeMaskUnknown
+}
+
+// Set the matrix to scale by sx and sy.
+func (m *Matrix) SetScale(sx, sy Scalar) {
+	m.Reset()
+	m.mat[KMScaleX], m.mat[KMScaleY] = sx, sy
+	m.typeMask = KMatrixTypeMaskUnknown
 }
 
+// Set the matrix to rotate by the specified sine and cosine values.
+func (m *Matrix) SetSinCos(sinV, cosV Scalar) {
+	m.SetAll(cosV, -sinV, 0, sinV, cosV, 0, 0, 0, 1)
+}
+
+// Set the matrix to rotate about (0,0) by the specified number of degrees.
+func (m *Matrix) SetRotate(degrees Scalar) {
+	var radians = Scalar(DegreesToRadians(float32(degrees)))
+	m.SetSinCos(ScalarSin(radians), ScalarCos(radians))
+}
+
+// Set the matrix to the scale-rotate-translate described by xform.
+func (m *Matrix) SetRSXform(xform RSXform) {
+	m.SetAll(xform.SCos, -xform.SSin, xform.Tx, xform.SSin, xform.SCos, xform.Ty, 0, 0, 1)
+}
+
+// Set the matrix to the concatenation of the two specified matrices.
+// Either of the two matrices may also be the target matrix.
+// *this = a * b;
+func (m *Matrix) SetConcat(a, b *Matrix) {
+	var tmp [9]Scalar
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			tmp[row*3+col] = a.mat[row*3+0]*b.mat[0*3+col] +
+				a.mat[row*3+1]*b.mat[1*3+col] +
+				a.mat[row*3+2]*b.mat[2*3+col]
+		}
+	}
+	m.mat = tmp
+	m.typeMask = KMatrixTypeMaskUnknown
+}
+
+// Preconcats the matrix with the specified matrix.
+// M' = M * other
+func (m *Matrix) PreConcat(other *Matrix) {
+	m.SetConcat(m, other)
+}
+
+// Postconcats the matrix with the specified matrix.
+// M' = other * M
+func (m *Matrix) PostConcat(other *Matrix) {
+	m.SetConcat(other, m)
+}
+
+// Preconcats the matrix with the specified translation.
+// M' = M * T(dx, dy)
+func (m *Matrix) PreTranslate(dx, dy Scalar) {
+	var t Matrix
+	t.SetTranslate(dx, dy)
+	m.PreConcat(&t)
+}
+
+// Preconcats the matrix with the specified scale.
+// M' = M * S(sx, sy)
+func (m *Matrix) PreScale(sx, sy Scalar) {
+	var s Matrix
+	s.SetScale(sx, sy)
+	m.PreConcat(&s)
+}
+
+// Postconcats the matrix with the specified translation.
+// M' = T(dx, dy) * M
 func (mat *Matrix) PostTranslate(x, y Scalar) {
-	toimpl()
-}
\ No newline at end of file
+	if mat.HasPerspective() {
+		var t Matrix
+		t.SetTranslate(x, y)
+		mat.PostConcat(&t)
+		return
+	}
+	mat.mat[KMTransX] += x
+	mat.mat[KMTransY] += y
+	mat.typeMask = KMatrixTypeMaskUnknown
+}
+
+// Postconcats the matrix with the specified scale.
+// M' = S(sx, sy) * M
+func (m *Matrix) PostScale(sx, sy Scalar) {
+	var s Matrix
+	s.SetScale(sx, sy)
+	m.PostConcat(&s)
+}
+
+// Invert returns the inverse of the matrix, and false if the matrix is
+// not invertible.
+func (m *Matrix) Invert() (*Matrix, bool) {
+	var a = m.mat
+	var det = float64(a[0])*(float64(a[4])*float64(a[8])-float64(a[5])*float64(a[7])) -
+		float64(a[1])*(float64(a[3])*float64(a[8])-float64(a[5])*float64(a[6])) +
+		float64(a[2])*(float64(a[3])*float64(a[7])-float64(a[4])*float64(a[6]))
+	if ScalarNearlyZero(Scalar(det), KScalarNearlyZero*KScalarNearlyZero*KScalarNearlyZero) {
+		return nil, false
+	}
+	var invDet = 1 / det
+	var inv = new(Matrix)
+	inv.mat[0] = Scalar((float64(a[4])*float64(a[8]) - float64(a[5])*float64(a[7])) * invDet)
+	inv.mat[1] = Scalar((float64(a[2])*float64(a[7]) - float64(a[1])*float64(a[8])) * invDet)
+	inv.mat[2] = Scalar((float64(a[1])*float64(a[5]) - float64(a[2])*float64(a[4])) * invDet)
+	inv.mat[3] = Scalar((float64(a[5])*float64(a[6]) - float64(a[3])*float64(a[8])) * invDet)
+	inv.mat[4] = Scalar((float64(a[0])*float64(a[8]) - float64(a[2])*float64(a[6])) * invDet)
+	inv.mat[5] = Scalar((float64(a[2])*float64(a[3]) - float64(a[0])*float64(a[5])) * invDet)
+	inv.mat[6] = Scalar((float64(a[3])*float64(a[7]) - float64(a[4])*float64(a[6])) * invDet)
+	inv.mat[7] = Scalar((float64(a[1])*float64(a[6]) - float64(a[0])*float64(a[7])) * invDet)
+	inv.mat[8] = Scalar((float64(a[0])*float64(a[4]) - float64(a[1])*float64(a[3])) * invDet)
+	inv.typeMask = KMatrixTypeMaskUnknown
+	return inv, true
+}
+
+// MapXY applies this matrix to the point (x, y) and returns the result.
+func (m *Matrix) MapXY(x, y Scalar) Point {
+	var dx = m.mat[KMScaleX]*x + m.mat[KMSkewX]*y + m.mat[KMTransX]
+	var dy = m.mat[KMSkewY]*x + m.mat[KMScaleY]*y + m.mat[KMTransY]
+	if m.HasPerspective() {
+		var z = m.mat[KMPersp0]*x + m.mat[KMPersp1]*y + m.mat[KMPersp2]
+		if z != 0 {
+			z = 1 / z
+		}
+		dx, dy = dx*z, dy*z
+	}
+	return Point{dx, dy}
+}
+
+// MapPoints applies this matrix to the src points, and writes the
+// transformed points into dst. dst and src may be the same slice.
+func (m *Matrix) MapPoints(dst, src []Point) {
+	for i := range src {
+		dst[i] = m.MapXY(src[i].X, src[i].Y)
+	}
+}
+
+// MapVectors applies this matrix to the src vectors, ignoring the
+// translation component of the matrix.
+func (m *Matrix) MapVectors(dst, src []Point) {
+	for i := range src {
+		var x, y = src[i].X, src[i].Y
+		dst[i].X = m.mat[KMScaleX]*x + m.mat[KMSkewX]*y
+		dst[i].Y = m.mat[KMSkewY]*x + m.mat[KMScaleY]*y
+	}
+}
+
+// MapRect returns the bounds of the rectangle mapped through this matrix.
+func (m *Matrix) MapRect(src Rect) Rect {
+	var quad = [4]Point{
+		{src.L(), src.T()}, {src.R(), src.T()},
+		{src.R(), src.B()}, {src.L(), src.B()},
+	}
+	m.MapPoints(quad[:], quad[:])
+	var dst Rect
+	dst.SetBoundsPoints(quad[:])
+	return dst
+}
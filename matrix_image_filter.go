@@ -0,0 +1,117 @@
+package ggk
+
+/** tMatrixImageFilter
+is the ImageFilterImpl that transforms its input by a matrix in local
+coordinates, sampling it with a filter quality. */
+type tMatrixImageFilter struct {
+	transform *Matrix
+	quality   FilterQuality
+}
+
+// NewMatrixImageFilter returns a filter that transforms input, or the
+// source if input is nil, by transform.
+func NewMatrixImageFilter(transform *Matrix, quality FilterQuality, input *ImageFilter) *ImageFilter {
+	var impl = &tMatrixImageFilter{
+		transform: NewMatrixClone(transform),
+		quality:   quality,
+	}
+	return NewImageFilter(impl, []*ImageFilter{input}, nil)
+}
+
+// Return the transform in device space: into local coordinates, through
+// the transform and back to device space.
+func (impl *tMatrixImageFilter) deviceTransform(ctm *Matrix) (*Matrix, bool) {
+	var inverse, ok = ctm.Invert()
+	if !ok {
+		return nil, false
+	}
+	var matrix = NewMatrix()
+	matrix.SetConcat(ctm, impl.transform)
+	matrix.PreConcat(inverse)
+	return matrix, true
+}
+
+func (impl *tMatrixImageFilter) OnFilterImage(filter *ImageFilter, src *Bitmap, ctx *ImageFilterContext) (*Bitmap, Point, bool) {
+	var input, inputOffset, ok = filter.filterInput(0, src, ctx)
+	if !ok {
+		return nil, PointZero, false
+	}
+	var matrix, invertible = impl.deviceTransform(ctx.CTM)
+	var inverse *Matrix
+	if invertible {
+		inverse, invertible = matrix.Invert()
+	}
+	if !invertible {
+		return nil, PointZero, false
+	}
+	var dstBounds Rect
+	if dstBounds, ok = filter.applyCropRect(ctx, matrix.MapRect(filterBitmapBounds(input, inputOffset)).RoundOut()); !ok {
+		return nil, PointZero, false
+	}
+
+	var dst = newFilterBitmap(dstBounds)
+	var srcPixels, dstPixels = filterPixmap(input), filterPixmap(dst)
+	for y := 0; y < int(dstBounds.Height); y++ {
+		for x := 0; x < int(dstBounds.Width); x++ {
+			var p = inverse.MapXY(dstBounds.Left+Scalar(x)+KScalarHalf, dstBounds.Top+Scalar(y)+KScalarHalf)
+			p.X, p.Y = p.X-inputOffset.X, p.Y-inputOffset.Y
+			dstPixels.SetPixel32(x, y, sampleFilterPixmap(srcPixels, p, impl.quality))
+		}
+	}
+	return dst, Point{dstBounds.Left, dstBounds.Top}, true
+}
+
+/** sampleFilterPixmap
+Return the premultiplied color of pixmap at p, which is in pixel
+coordinates whose pixel centers are at half integers. Nearest neighbor is
+used for KFilterQualityNone, otherwise the four nearest pixels are
+interpolated. The pixels outside of pixmap are transparent. */
+func sampleFilterPixmap(pixmap *Pixmap, p Point, quality FilterQuality) uint32 {
+	var width, height = int(pixmap.Width()), int(pixmap.Height())
+	var pixelAt = func(x, y int) uint32 {
+		if x < 0 || y < 0 || x >= width || y >= height {
+			return 0
+		}
+		return pixmap.Pixel32(x, y)
+	}
+	if quality == KFilterQualityNone {
+		return pixelAt(ScalarFloorToInt(p.X), ScalarFloorToInt(p.Y))
+	}
+
+	var fx, fy = p.X - KScalarHalf, p.Y - KScalarHalf
+	var x0, y0 = ScalarFloorToInt(fx), ScalarFloorToInt(fy)
+	// weights of the right and bottom pixels, in 0..256.
+	var wx = uint32((fx-Scalar(x0))*256 + KScalarHalf)
+	var wy = uint32((fy-Scalar(y0))*256 + KScalarHalf)
+	var lerp = func(a, b uint32, w uint32) uint32 {
+		return AlphaMulQ(a, 256-w) + AlphaMulQ(b, w)
+	}
+	var top = lerp(pixelAt(x0, y0), pixelAt(x0+1, y0), wx)
+	var bottom = lerp(pixelAt(x0, y0+1), pixelAt(x0+1, y0+1), wx)
+	return lerp(top, bottom, wy)
+}
+
+func (impl *tMatrixImageFilter) OnFilterBounds(filter *ImageFilter, src Rect, ctm *Matrix, direction ImageFilterMapDirection) Rect {
+	return filter.filterBoundsWithNode(src, ctm, direction, func(r Rect) Rect {
+		var matrix, ok = impl.deviceTransform(ctm)
+		if ok && direction == KImageFilterMapDirectionReverse {
+			matrix, ok = matrix.Invert()
+		}
+		if !ok {
+			return r
+		}
+		return matrix.MapRect(r).RoundOut()
+	})
+}
+
+func (impl *tMatrixImageFilter) OnComputeFastBounds(filter *ImageFilter, src Rect) Rect {
+	return impl.transform.MapRect(filter.inputsFastBounds(src))
+}
+
+func (impl *tMatrixImageFilter) OnAffectsTransparentBlack() bool {
+	return false
+}
+
+func (impl *tMatrixImageFilter) OnAsAColorFilter(filter *ImageFilter) (*ColorFilter, bool) {
+	return nil, false
+}
@@ -0,0 +1,54 @@
+package ggk
+
+/** tMergeImageFilter
+is the ImageFilterImpl that draws the results of its inputs over each
+other, in order. */
+type tMergeImageFilter struct {
+}
+
+// NewMergeImageFilter returns a filter that draws the results of filters
+// over each other in order. A nil filter draws the source.
+func NewMergeImageFilter(filters []*ImageFilter, cropRect *ImageFilterCropRect) *ImageFilter {
+	return NewImageFilter(&tMergeImageFilter{}, filters, cropRect)
+}
+
+func (impl *tMergeImageFilter) OnFilterImage(filter *ImageFilter, src *Bitmap, ctx *ImageFilterContext) (*Bitmap, Point, bool) {
+	var inputs = make([]*Bitmap, filter.CountInputs())
+	var offsets = make([]Point, len(inputs))
+	var bounds Rect
+	for i := range inputs {
+		var ok bool
+		if inputs[i], offsets[i], ok = filter.filterInput(i, src, ctx); ok {
+			bounds.Join(filterBitmapBounds(inputs[i], offsets[i]))
+		} else {
+			inputs[i] = nil
+		}
+	}
+	var dstBounds, ok = filter.applyCropRect(ctx, bounds)
+	if !ok {
+		return nil, PointZero, false
+	}
+	var dst = newFilterBitmap(dstBounds)
+	for i, input := range inputs {
+		if input != nil {
+			drawFilterBitmap(dst, input, offsets[i].X-dstBounds.Left, offsets[i].Y-dstBounds.Top, true)
+		}
+	}
+	return dst, Point{dstBounds.Left, dstBounds.Top}, true
+}
+
+func (impl *tMergeImageFilter) OnFilterBounds(filter *ImageFilter, src Rect, ctm *Matrix, direction ImageFilterMapDirection) Rect {
+	return filter.filterBoundsWithNode(src, ctm, direction, func(r Rect) Rect { return r })
+}
+
+func (impl *tMergeImageFilter) OnComputeFastBounds(filter *ImageFilter, src Rect) Rect {
+	return filter.inputsFastBounds(src)
+}
+
+func (impl *tMergeImageFilter) OnAffectsTransparentBlack() bool {
+	return false
+}
+
+func (impl *tMergeImageFilter) OnAsAColorFilter(filter *ImageFilter) (*ColorFilter, bool) {
+	return nil, false
+}
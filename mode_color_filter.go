@@ -0,0 +1,67 @@
+package ggk
+
+/** tModeColorFilter
+blends a constant color over colors in a transfer mode, the constant
+color being the source and the filtered color the destination. */
+type tModeColorFilter struct {
+	color Color
+	mode  XfermodeMode
+	pm    PM4f
+}
+
+/** NewModeColorFilter
+Return a filter blending color, as the source, with colors in mode. Modes
+that can be done more simply with color are, and nil is returned for the
+ones that leave colors as they are. */
+func NewModeColorFilter(color Color, mode XfermodeMode) *ColorFilter {
+	var alpha = color.Alpha()
+	// first collapse some modes if possible.
+	if mode == KXfermodeModeClear {
+		color, mode = 0, KXfermodeModeSrc
+	} else if mode == KXfermodeModeSrcOver {
+		if alpha == 0 {
+			mode = KXfermodeModeDst
+		} else if alpha == 0xff {
+			mode = KXfermodeModeSrc
+		}
+	}
+
+	// weed out the combinations that leave colors as they are.
+	if mode == KXfermodeModeDst || (alpha == 0xff && mode == KXfermodeModeDstIn) {
+		return nil
+	}
+	if alpha == 0 {
+		switch mode {
+		case KXfermodeModeSrcOver, KXfermodeModeDstOver, KXfermodeModeDstOut, KXfermodeModeSrcATop,
+			KXfermodeModeXor, KXfermodeModeDarken:
+			return nil
+		}
+	}
+	return NewColorFilter(&tModeColorFilter{
+		color: color,
+		mode:  mode,
+		pm:    Color4fFromColor(color).Premultipy(),
+	})
+}
+
+// Color returns the color blended with colors.
+func (impl *tModeColorFilter) Color() Color {
+	return impl.color
+}
+
+// Mode returns the mode colors are blended in.
+func (impl *tModeColorFilter) Mode() XfermodeMode {
+	return impl.mode
+}
+
+func (impl *tModeColorFilter) OnFilterColor4f(color Color4f) Color4f {
+	return blendPM4f(impl.mode, impl.pm, color.Premultipy()).Pin().Unpremul()
+}
+
+func (impl *tModeColorFilter) OnAppendStages(pipeline *RasterPipeline) bool {
+	pipeline.AppendStage(moveSrcToDst, nil)
+	pipeline.AppendStage(constantColor, impl.pm)
+	pipeline.AppendStage(xfermodeStage, impl.mode)
+	pipeline.AppendStage(clamp01, nil)
+	return true
+}
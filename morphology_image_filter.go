@@ -0,0 +1,130 @@
+package ggk
+
+type MorphologyType int
+
+const (
+	KMorphologyTypeDilate MorphologyType = iota // each channel takes its largest value around it
+	KMorphologyTypeErode                        // each channel takes its smallest value around it
+)
+
+/** tMorphologyImageFilter
+is the ImageFilterImpl that dilates or erodes each channel of its input
+over a rectangle of radius, in local coordinates. */
+type tMorphologyImageFilter struct {
+	morphType MorphologyType
+	radius    Point
+}
+
+// NewDilateImageFilter returns a filter that dilates input, or the source
+// if input is nil, by radiusX and radiusY. Returns nil for negative radii.
+func NewDilateImageFilter(radiusX, radiusY int, input *ImageFilter, cropRect *ImageFilterCropRect) *ImageFilter {
+	return newMorphologyImageFilter(KMorphologyTypeDilate, radiusX, radiusY, input, cropRect)
+}
+
+// NewErodeImageFilter returns a filter that erodes input, or the source if
+// input is nil, by radiusX and radiusY. Returns nil for negative radii.
+func NewErodeImageFilter(radiusX, radiusY int, input *ImageFilter, cropRect *ImageFilterCropRect) *ImageFilter {
+	return newMorphologyImageFilter(KMorphologyTypeErode, radiusX, radiusY, input, cropRect)
+}
+
+func newMorphologyImageFilter(morphType MorphologyType, radiusX, radiusY int, input *ImageFilter,
+	cropRect *ImageFilterCropRect) *ImageFilter {
+	if radiusX < 0 || radiusY < 0 {
+		return nil
+	}
+	var impl = &tMorphologyImageFilter{
+		morphType: morphType,
+		radius:    Point{Scalar(radiusX), Scalar(radiusY)},
+	}
+	return NewImageFilter(impl, []*ImageFilter{input}, cropRect)
+}
+
+// Return the radius in whole device pixels.
+func (impl *tMorphologyImageFilter) deviceRadius(ctm *Matrix) (int, int) {
+	var v = []Point{impl.radius}
+	ctm.MapVectors(v, v)
+	return ScalarFloorToInt(ScalarAbs(v[0].X) + KScalarHalf), ScalarFloorToInt(ScalarAbs(v[0].Y) + KScalarHalf)
+}
+
+func (impl *tMorphologyImageFilter) OnFilterImage(filter *ImageFilter, src *Bitmap, ctx *ImageFilterContext) (*Bitmap, Point, bool) {
+	var input, inputOffset, ok = filter.filterInput(0, src, ctx)
+	if !ok {
+		return nil, PointZero, false
+	}
+	var rx, ry = impl.deviceRadius(ctx.CTM)
+	var srcBounds = filterBitmapBounds(input, inputOffset)
+	if impl.morphType == KMorphologyTypeDilate {
+		srcBounds.Outset(Scalar(rx), Scalar(ry))
+	}
+	var dstBounds Rect
+	if dstBounds, ok = filter.applyCropRect(ctx, srcBounds); !ok {
+		return nil, PointZero, false
+	}
+
+	var workBounds = dstBounds
+	workBounds.Outset(Scalar(rx), Scalar(ry))
+	var work = copyFilterBitmap(input, inputOffset, workBounds)
+	var width, height = int(workBounds.Width), int(workBounds.Height)
+	var pixels, rowBytes = work.PixelBytes(), work.RowBytes()
+	var line = make([]byte, width+height)
+	if rx > 0 {
+		for y := 0; y < height; y++ {
+			impl.morph(pixels[y*rowBytes:], 4, width, rx, line)
+		}
+	}
+	if ry > 0 {
+		for x := 0; x < width; x++ {
+			impl.morph(pixels[x*4:], rowBytes, height, ry, line)
+		}
+	}
+	var dst = copyFilterBitmap(work, Point{workBounds.Left, workBounds.Top}, dstBounds)
+	return dst, Point{dstBounds.Left, dstBounds.Top}, true
+}
+
+/** morph
+Dilate or erode each channel of count pixels, stride bytes apart, from
+pixels on, over radius pixels on each side. The pixels past the ends are
+transparent. line is scratch space for at least count bytes. */
+func (impl *tMorphologyImageFilter) morph(pixels []byte, stride, count, radius int, line []byte) {
+	for c := 0; c < 4; c++ {
+		for i := 0; i < count; i++ {
+			line[i] = pixels[i*stride+c]
+		}
+		for i := 0; i < count; i++ {
+			var value = line[i]
+			for j := i - radius; j <= i+radius; j++ {
+				var v uint8
+				if j >= 0 && j < count {
+					v = line[j]
+				}
+				if impl.morphType == KMorphologyTypeDilate && v > value ||
+					impl.morphType == KMorphologyTypeErode && v < value {
+					value = v
+				}
+			}
+			pixels[i*stride+c] = value
+		}
+	}
+}
+
+func (impl *tMorphologyImageFilter) OnFilterBounds(filter *ImageFilter, src Rect, ctm *Matrix, direction ImageFilterMapDirection) Rect {
+	var rx, ry = impl.deviceRadius(ctm)
+	return filter.filterBoundsWithNode(src, ctm, direction, func(r Rect) Rect {
+		r.Outset(Scalar(rx), Scalar(ry))
+		return r
+	})
+}
+
+func (impl *tMorphologyImageFilter) OnComputeFastBounds(filter *ImageFilter, src Rect) Rect {
+	var bounds = filter.inputsFastBounds(src)
+	bounds.Outset(impl.radius.X, impl.radius.Y)
+	return bounds
+}
+
+func (impl *tMorphologyImageFilter) OnAffectsTransparentBlack() bool {
+	return false
+}
+
+func (impl *tMorphologyImageFilter) OnAsAColorFilter(filter *ImageFilter) (*ColorFilter, bool) {
+	return nil, false
+}
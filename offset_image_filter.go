@@ -0,0 +1,60 @@
+package ggk
+
+/** tOffsetImageFilter
+is the ImageFilterImpl that moves its input by an offset in local
+coordinates. */
+type tOffsetImageFilter struct {
+	offset Point
+}
+
+// NewOffsetImageFilter returns a filter that moves input, or the source if
+// input is nil, by (dx, dy).
+func NewOffsetImageFilter(dx, dy Scalar, input *ImageFilter, cropRect *ImageFilterCropRect) *ImageFilter {
+	return NewImageFilter(&tOffsetImageFilter{Point{dx, dy}}, []*ImageFilter{input}, cropRect)
+}
+
+// Return the offset in whole device pixels.
+func (impl *tOffsetImageFilter) deviceOffset(ctm *Matrix) Point {
+	var v = []Point{impl.offset}
+	ctm.MapVectors(v, v)
+	return Point{ScalarFloor(v[0].X + KScalarHalf), ScalarFloor(v[0].Y + KScalarHalf)}
+}
+
+func (impl *tOffsetImageFilter) OnFilterImage(filter *ImageFilter, src *Bitmap, ctx *ImageFilterContext) (*Bitmap, Point, bool) {
+	var input, inputOffset, ok = filter.filterInput(0, src, ctx)
+	if !ok {
+		return nil, PointZero, false
+	}
+	var offset = impl.deviceOffset(ctx.CTM)
+	inputOffset = Point{inputOffset.X + offset.X, inputOffset.Y + offset.Y}
+	var dstBounds Rect
+	if dstBounds, ok = filter.applyCropRect(ctx, filterBitmapBounds(input, inputOffset)); !ok {
+		return nil, PointZero, false
+	}
+	return copyFilterBitmap(input, inputOffset, dstBounds), Point{dstBounds.Left, dstBounds.Top}, true
+}
+
+func (impl *tOffsetImageFilter) OnFilterBounds(filter *ImageFilter, src Rect, ctm *Matrix, direction ImageFilterMapDirection) Rect {
+	var offset = impl.deviceOffset(ctm)
+	if direction == KImageFilterMapDirectionReverse {
+		offset = Point{-offset.X, -offset.Y}
+	}
+	return filter.filterBoundsWithNode(src, ctm, direction, func(r Rect) Rect {
+		r.Offset(offset.X, offset.Y)
+		return r
+	})
+}
+
+func (impl *tOffsetImageFilter) OnComputeFastBounds(filter *ImageFilter, src Rect) Rect {
+	var bounds = filter.inputsFastBounds(src)
+	bounds.Offset(impl.offset.X, impl.offset.Y)
+	return bounds
+}
+
+func (impl *tOffsetImageFilter) OnAffectsTransparentBlack() bool {
+	return false
+}
+
+func (impl *tOffsetImageFilter) OnAsAColorFilter(filter *ImageFilter) (*ColorFilter, bool) {
+	return nil, false
+}
@@ -0,0 +1,817 @@
+package ggk
+
+import "sort"
+
+// Lookup flags of OpenType layout lookups.
+const (
+	kOTLookupFlagIgnoreBaseGlyphs    = 0x0002
+	kOTLookupFlagIgnoreLigatures     = 0x0004
+	kOTLookupFlagIgnoreMarks         = 0x0008
+	kOTLookupFlagUseMarkFilteringSet = 0x0010
+	kOTLookupFlagMarkAttachmentType  = 0xff00
+)
+
+// Glyph classes of the GDEF table.
+const (
+	kOTGlyphClassUnknown   = 0
+	kOTGlyphClassBase      = 1
+	kOTGlyphClassLigature  = 2
+	kOTGlyphClassMark      = 3
+	kOTGlyphClassComponent = 4
+)
+
+// Nested contextual lookups deeper than this are not applied.
+const kOTMaxNestingLevel = 8
+
+// The readers below return 0 (or nil) instead of panicking when a broken
+// font points outside of its tables.
+
+func otU16(b []byte, offset int) int {
+	if offset < 0 || offset+2 > len(b) {
+		return 0
+	}
+	return ttU16(b, offset)
+}
+
+func otI16(b []byte, offset int) int {
+	if offset < 0 || offset+2 > len(b) {
+		return 0
+	}
+	return ttI16(b, offset)
+}
+
+func otU32(b []byte, offset int) int {
+	if offset < 0 || offset+4 > len(b) {
+		return 0
+	}
+	return ttU32(b, offset)
+}
+
+func otTag(b []byte, offset int) FontTableTag {
+	return FontTableTag(otU32(b, offset))
+}
+
+// Return the table at offset within b, or nil if there is none.
+func otSub(b []byte, offset int) []byte {
+	if offset <= 0 || offset >= len(b) {
+		return nil
+	}
+	return b[offset:]
+}
+
+// Return the coverage index of id, or -1 if the coverage table does not
+// cover it.
+func otCoverageIndex(coverage []byte, id GlyphID) int {
+	var g = int(id)
+	switch otU16(coverage, 0) {
+	case 1:
+		var count = otU16(coverage, 2)
+		var i = sort.Search(count, func(i int) bool { return otU16(coverage, 4+2*i) >= g })
+		if i < count && otU16(coverage, 4+2*i) == g {
+			return i
+		}
+	case 2:
+		var count = otU16(coverage, 2)
+		var i = sort.Search(count, func(i int) bool { return otU16(coverage, 4+6*i+2) >= g })
+		if i < count && otU16(coverage, 4+6*i) <= g {
+			return otU16(coverage, 4+6*i+4) + g - otU16(coverage, 4+6*i)
+		}
+	}
+	return -1
+}
+
+// Return the class of id in the class definition table, 0 if it has none.
+func otClass(classDef []byte, id GlyphID) int {
+	var g = int(id)
+	switch otU16(classDef, 0) {
+	case 1:
+		var start, count = otU16(classDef, 2), otU16(classDef, 4)
+		if g >= start && g < start+count {
+			return otU16(classDef, 6+2*(g-start))
+		}
+	case 2:
+		var count = otU16(classDef, 2)
+		var i = sort.Search(count, func(i int) bool { return otU16(classDef, 4+6*i+2) >= g })
+		if i < count && otU16(classDef, 4+6*i) <= g {
+			return otU16(classDef, 4+6*i+4)
+		}
+	}
+	return 0
+}
+
+/** tOTGDEF
+holds the glyph definition table, which classifies glyphs as bases,
+ligatures and marks for the lookups that skip some of them. */
+type tOTGDEF struct {
+	glyphClassDef      []byte
+	markAttachClassDef []byte
+	markGlyphSets      []byte
+}
+
+func parseOTGDEF(data []byte) *tOTGDEF {
+	if data == nil {
+		return nil
+	}
+	var gdef = &tOTGDEF{
+		glyphClassDef:      otSub(data, otU16(data, 4)),
+		markAttachClassDef: otSub(data, otU16(data, 10)),
+	}
+	if otU32(data, 0) >= 0x00010002 {
+		gdef.markGlyphSets = otSub(data, otU16(data, 12))
+	}
+	return gdef
+}
+
+func (gdef *tOTGDEF) glyphClass(id GlyphID) int {
+	if gdef == nil {
+		return kOTGlyphClassUnknown
+	}
+	return otClass(gdef.glyphClassDef, id)
+}
+
+func (gdef *tOTGDEF) markAttachClass(id GlyphID) int {
+	if gdef == nil {
+		return 0
+	}
+	return otClass(gdef.markAttachClassDef, id)
+}
+
+func (gdef *tOTGDEF) inMarkGlyphSet(set int, id GlyphID) bool {
+	if gdef == nil || set >= otU16(gdef.markGlyphSets, 2) {
+		return false
+	}
+	var coverage = otSub(gdef.markGlyphSets, otU32(gdef.markGlyphSets, 4+4*set))
+	return otCoverageIndex(coverage, id) >= 0
+}
+
+/** tOTLayoutTable
+is a GSUB or GPOS table: scripts select language systems, which select
+features, which select the lookups that do the work. */
+type tOTLayoutTable struct {
+	isGSUB   bool
+	scripts  []byte
+	features []byte
+	lookups  []byte
+}
+
+func parseOTLayoutTable(data []byte, isGSUB bool) *tOTLayoutTable {
+	if len(data) < 10 {
+		return nil
+	}
+	return &tOTLayoutTable{
+		isGSUB:   isGSUB,
+		scripts:  otSub(data, otU16(data, 4)),
+		features: otSub(data, otU16(data, 6)),
+		lookups:  otSub(data, otU16(data, 8)),
+	}
+}
+
+// Return the default language system of the first script of scriptTags the
+// table has, or nil if it has none of them.
+func (table *tOTLayoutTable) langSys(scriptTags []FontTableTag) []byte {
+	if table == nil {
+		return nil
+	}
+	var count = otU16(table.scripts, 0)
+	for _, tag := range scriptTags {
+		for i := 0; i < count; i++ {
+			if otTag(table.scripts, 2+6*i) == tag {
+				var script = otSub(table.scripts, otU16(table.scripts, 2+6*i+4))
+				return otSub(script, otU16(script, 0))
+			}
+		}
+	}
+	return nil
+}
+
+// Return the indices of the lookups of the feature with tag in langSys.
+func (table *tOTLayoutTable) featureLookups(langSys []byte, tag FontTableTag) []int {
+	if langSys == nil {
+		return nil
+	}
+	var lookups []int
+	var count = otU16(langSys, 4)
+	for i := 0; i < count; i++ {
+		var index = otU16(langSys, 6+2*i)
+		if otTag(table.features, 2+6*index) != tag {
+			continue
+		}
+		var feature = otSub(table.features, otU16(table.features, 2+6*index+4))
+		var lookupCount = otU16(feature, 2)
+		for j := 0; j < lookupCount; j++ {
+			lookups = append(lookups, otU16(feature, 4+2*j))
+		}
+	}
+	return lookups
+}
+
+func (table *tOTLayoutTable) lookup(index int) []byte {
+	if index >= otU16(table.lookups, 0) {
+		return nil
+	}
+	return otSub(table.lookups, otU16(table.lookups, 2+2*index))
+}
+
+// tOTValue is a GPOS value record, in font units.
+type tOTValue struct {
+	xPlacement, yPlacement int
+	xAdvance, yAdvance     int
+}
+
+// Read the value record with format at offset, returning it and its size.
+// Device tables are ignored.
+func otReadValue(b []byte, offset int, format int) (value tOTValue, size int) {
+	var fields = [4]*int{&value.xPlacement, &value.yPlacement, &value.xAdvance, &value.yAdvance}
+	for bit := uint(0); bit < 8; bit++ {
+		if format&(1<<bit) == 0 {
+			continue
+		}
+		if bit < 4 {
+			*fields[bit] = otI16(b, offset+size)
+		}
+		size += 2
+	}
+	return value, size
+}
+
+func otValueSize(format int) int {
+	var _, size = otReadValue(nil, 0, format)
+	return size
+}
+
+// Return the coordinates of an anchor table, in font units.
+func otAnchor(anchor []byte) (x, y int) {
+	return otI16(anchor, 2), otI16(anchor, 4)
+}
+
+/** tOTApplyContext
+applies the lookups of a layout table to a shape buffer. */
+type tOTApplyContext struct {
+	table *tOTLayoutTable
+	gdef  *tOTGDEF
+	buf   *tShapeBuffer
+
+	mask             uint32
+	lookupFlag       int
+	markFilteringSet int
+	nesting          int
+}
+
+// Apply the lookup to the glyphs of the buffer enabled by mask.
+func (c *tOTApplyContext) applyLookup(index int, mask uint32) {
+	var lookup = c.table.lookup(index)
+	if lookup == nil {
+		return
+	}
+	c.mask = mask
+	c.setLookupFlag(lookup)
+	var lookupType = otU16(lookup, 0)
+
+	if c.table.isGSUB && lookupType == 8 {
+		// reverse chaining substitutions are applied from the end.
+		for i := len(c.buf.info) - 1; i >= 0; i-- {
+			if c.buf.info[i].mask&mask != 0 && !c.ignored(i) {
+				c.applySubtables(lookup, i)
+			}
+		}
+		return
+	}
+
+	for i := 0; i < len(c.buf.info); {
+		if c.buf.info[i].mask&mask == 0 || c.ignored(i) {
+			i++
+			continue
+		}
+		if next, ok := c.applySubtables(lookup, i); ok {
+			i = next
+		} else {
+			i++
+		}
+	}
+}
+
+func (c *tOTApplyContext) setLookupFlag(lookup []byte) {
+	c.lookupFlag = otU16(lookup, 2)
+	c.markFilteringSet = 0
+	if c.lookupFlag&kOTLookupFlagUseMarkFilteringSet != 0 {
+		var count = otU16(lookup, 4)
+		c.markFilteringSet = otU16(lookup, 6+2*count)
+	}
+}
+
+// Apply a lookup nested in a contextual lookup at position i.
+func (c *tOTApplyContext) applyNestedLookup(index int, i int) {
+	var lookup = c.table.lookup(index)
+	if lookup == nil || c.nesting >= kOTMaxNestingLevel || i >= len(c.buf.info) {
+		return
+	}
+	var savedFlag, savedSet = c.lookupFlag, c.markFilteringSet
+	c.nesting++
+	c.setLookupFlag(lookup)
+	c.applySubtables(lookup, i)
+	c.nesting--
+	c.lookupFlag, c.markFilteringSet = savedFlag, savedSet
+}
+
+// Try the subtables of lookup at position i until one applies, returning
+// the position to continue from.
+func (c *tOTApplyContext) applySubtables(lookup []byte, i int) (int, bool) {
+	var lookupType = otU16(lookup, 0)
+	var count = otU16(lookup, 4)
+	for s := 0; s < count; s++ {
+		var subtable = otSub(lookup, otU16(lookup, 6+2*s))
+		if subtable == nil {
+			continue
+		}
+		var next int
+		var ok bool
+		if c.table.isGSUB {
+			next, ok = c.applySubst(lookupType, subtable, i)
+		} else {
+			next, ok = c.applyPos(lookupType, subtable, i)
+		}
+		if ok {
+			return next, true
+		}
+	}
+	return i + 1, false
+}
+
+// Return whether the lookup skips the glyph at position i.
+func (c *tOTApplyContext) ignored(i int) bool {
+	var info = &c.buf.info[i]
+	switch info.glyphClass {
+	case kOTGlyphClassBase:
+		return c.lookupFlag&kOTLookupFlagIgnoreBaseGlyphs != 0
+	case kOTGlyphClassLigature:
+		return c.lookupFlag&kOTLookupFlagIgnoreLigatures != 0
+	case kOTGlyphClassMark:
+		if c.lookupFlag&kOTLookupFlagIgnoreMarks != 0 {
+			return true
+		}
+		if c.lookupFlag&kOTLookupFlagUseMarkFilteringSet != 0 {
+			return !c.gdef.inMarkGlyphSet(c.markFilteringSet, info.id)
+		}
+		if attachType := (c.lookupFlag & kOTLookupFlagMarkAttachmentType) >> 8; attachType != 0 {
+			return c.gdef.markAttachClass(info.id) != attachType
+		}
+	}
+	return false
+}
+
+// Return the position of the next glyph after i the lookup does not skip,
+// or -1.
+func (c *tOTApplyContext) nextIndex(i int) int {
+	for i++; i < len(c.buf.info); i++ {
+		if !c.ignored(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Return the position of the previous glyph before i the lookup does not
+// skip, or -1.
+func (c *tOTApplyContext) prevIndex(i int) int {
+	for i--; i >= 0; i-- {
+		if !c.ignored(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// tOTMatchFunc tells whether glyph id matches the k-th element of a
+// sequence in a contextual rule.
+type tOTMatchFunc func(id GlyphID, k int) bool
+
+func otMatchGlyphs(b []byte, offset int) tOTMatchFunc {
+	return func(id GlyphID, k int) bool { return int(id) == otU16(b, offset+2*k) }
+}
+
+func otMatchClasses(classDef []byte, b []byte, offset int) tOTMatchFunc {
+	return func(id GlyphID, k int) bool { return otClass(classDef, id) == otU16(b, offset+2*k) }
+}
+
+func otMatchCoverages(table []byte, offset int) tOTMatchFunc {
+	return func(id GlyphID, k int) bool {
+		return otCoverageIndex(otSub(table, otU16(table, offset+2*k)), id) >= 0
+	}
+}
+
+// Match count glyphs starting at position i, returning their positions.
+// The glyph at i is matched against element skip (0 or 1) onwards, so
+// rules whose first glyph is implied by their coverage pass 1.
+func (c *tOTApplyContext) matchInput(i, count int, skip int, match tOTMatchFunc) ([]int, bool) {
+	if skip == 0 && !match(c.buf.info[i].id, 0) {
+		return nil, false
+	}
+	var positions = []int{i}
+	var j = i
+	for k := 1; k < count; k++ {
+		j = c.nextIndex(j)
+		if j < 0 || !match(c.buf.info[j].id, k-skip) {
+			return nil, false
+		}
+		positions = append(positions, j)
+	}
+	return positions, true
+}
+
+func (c *tOTApplyContext) matchBacktrack(i, count int, match tOTMatchFunc) bool {
+	var j = i
+	for k := 0; k < count; k++ {
+		j = c.prevIndex(j)
+		if j < 0 || !match(c.buf.info[j].id, k) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *tOTApplyContext) matchLookahead(last, count int, match tOTMatchFunc) bool {
+	var j = last
+	for k := 0; k < count; k++ {
+		j = c.nextIndex(j)
+		if j < 0 || !match(c.buf.info[j].id, k) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply the sequence lookup records of a matched contextual rule, returning
+// the position after the matched input.
+func (c *tOTApplyContext) applySequenceLookups(positions []int, b []byte, offset, count int) int {
+	for r := 0; r < count; r++ {
+		var seqIndex = otU16(b, offset+4*r)
+		var lookupIndex = otU16(b, offset+4*r+2)
+		if seqIndex >= len(positions) {
+			continue
+		}
+		var before = len(c.buf.info)
+		c.applyNestedLookup(lookupIndex, positions[seqIndex])
+		var delta = len(c.buf.info) - before
+		for k := seqIndex + 1; k < len(positions); k++ {
+			positions[k] += delta
+		}
+	}
+	return positions[len(positions)-1] + 1
+}
+
+// Apply a (non-chaining) context subtable, shared by GSUB and GPOS.
+func (c *tOTApplyContext) applyContext(subtable []byte, i int) (int, bool) {
+	var id = c.buf.info[i].id
+	switch otU16(subtable, 0) {
+	case 1:
+		var index = otCoverageIndex(otSub(subtable, otU16(subtable, 2)), id)
+		if index < 0 {
+			return 0, false
+		}
+		var ruleSet = otSub(subtable, otU16(subtable, 6+2*index))
+		for r := 0; r < otU16(ruleSet, 0); r++ {
+			var rule = otSub(ruleSet, otU16(ruleSet, 2+2*r))
+			var glyphCount, lookupCount = otU16(rule, 0), otU16(rule, 2)
+			if positions, ok := c.matchInput(i, glyphCount, 1, otMatchGlyphs(rule, 4)); ok {
+				return c.applySequenceLookups(positions, rule, 4+2*(glyphCount-1), lookupCount), true
+			}
+		}
+	case 2:
+		if otCoverageIndex(otSub(subtable, otU16(subtable, 2)), id) < 0 {
+			return 0, false
+		}
+		var classDef = otSub(subtable, otU16(subtable, 4))
+		var class = otClass(classDef, id)
+		if class >= otU16(subtable, 6) {
+			return 0, false
+		}
+		var ruleSet = otSub(subtable, otU16(subtable, 8+2*class))
+		for r := 0; r < otU16(ruleSet, 0); r++ {
+			var rule = otSub(ruleSet, otU16(ruleSet, 2+2*r))
+			var glyphCount, lookupCount = otU16(rule, 0), otU16(rule, 2)
+			if positions, ok := c.matchInput(i, glyphCount, 1, otMatchClasses(classDef, rule, 4)); ok {
+				return c.applySequenceLookups(positions, rule, 4+2*(glyphCount-1), lookupCount), true
+			}
+		}
+	case 3:
+		var glyphCount, lookupCount = otU16(subtable, 2), otU16(subtable, 4)
+		if glyphCount == 0 {
+			return 0, false
+		}
+		if positions, ok := c.matchInput(i, glyphCount, 0, otMatchCoverages(subtable, 6)); ok {
+			return c.applySequenceLookups(positions, subtable, 6+2*glyphCount, lookupCount), true
+		}
+	}
+	return 0, false
+}
+
+// Apply a chaining context subtable, shared by GSUB and GPOS.
+func (c *tOTApplyContext) applyChainContext(subtable []byte, i int) (int, bool) {
+	var id = c.buf.info[i].id
+	switch otU16(subtable, 0) {
+	case 1, 2:
+		var format = otU16(subtable, 0)
+		if otCoverageIndex(otSub(subtable, otU16(subtable, 2)), id) < 0 {
+			return 0, false
+		}
+		var backtrackClassDef, inputClassDef, lookaheadClassDef []byte
+		var ruleSet []byte
+		if format == 1 {
+			var index = otCoverageIndex(otSub(subtable, otU16(subtable, 2)), id)
+			ruleSet = otSub(subtable, otU16(subtable, 6+2*index))
+		} else {
+			backtrackClassDef = otSub(subtable, otU16(subtable, 4))
+			inputClassDef = otSub(subtable, otU16(subtable, 6))
+			lookaheadClassDef = otSub(subtable, otU16(subtable, 8))
+			var class = otClass(inputClassDef, id)
+			if class >= otU16(subtable, 10) {
+				return 0, false
+			}
+			ruleSet = otSub(subtable, otU16(subtable, 12+2*class))
+		}
+		var matcher = func(classDef []byte, rule []byte, offset int) tOTMatchFunc {
+			if format == 1 {
+				return otMatchGlyphs(rule, offset)
+			}
+			return otMatchClasses(classDef, rule, offset)
+		}
+		for r := 0; r < otU16(ruleSet, 0); r++ {
+			var rule = otSub(ruleSet, otU16(ruleSet, 2+2*r))
+			var offset = 0
+			var backtrackCount = otU16(rule, offset)
+			var backtrack = offset + 2
+			offset = backtrack + 2*backtrackCount
+			var inputCount = otU16(rule, offset)
+			var input = offset + 2
+			offset = input + 2*(inputCount-1)
+			var lookaheadCount = otU16(rule, offset)
+			var lookahead = offset + 2
+			offset = lookahead + 2*lookaheadCount
+			var lookupCount = otU16(rule, offset)
+			if inputCount == 0 {
+				continue
+			}
+			var positions, ok = c.matchInput(i, inputCount, 1, matcher(inputClassDef, rule, input))
+			if !ok || !c.matchBacktrack(i, backtrackCount, matcher(backtrackClassDef, rule, backtrack)) ||
+				!c.matchLookahead(positions[len(positions)-1], lookaheadCount,
+					matcher(lookaheadClassDef, rule, lookahead)) {
+				continue
+			}
+			return c.applySequenceLookups(positions, rule, offset+2, lookupCount), true
+		}
+	case 3:
+		var offset = 2
+		var backtrackCount = otU16(subtable, offset)
+		var backtrack = offset + 2
+		offset = backtrack + 2*backtrackCount
+		var inputCount = otU16(subtable, offset)
+		var input = offset + 2
+		offset = input + 2*inputCount
+		var lookaheadCount = otU16(subtable, offset)
+		var lookahead = offset + 2
+		offset = lookahead + 2*lookaheadCount
+		var lookupCount = otU16(subtable, offset)
+		if inputCount == 0 {
+			return 0, false
+		}
+		var positions, ok = c.matchInput(i, inputCount, 0, otMatchCoverages(subtable, input))
+		if !ok || !c.matchBacktrack(i, backtrackCount, otMatchCoverages(subtable, backtrack)) ||
+			!c.matchLookahead(positions[len(positions)-1], lookaheadCount, otMatchCoverages(subtable, lookahead)) {
+			return 0, false
+		}
+		return c.applySequenceLookups(positions, subtable, offset+2, lookupCount), true
+	}
+	return 0, false
+}
+
+// Apply a GSUB subtable of lookupType at position i.
+func (c *tOTApplyContext) applySubst(lookupType int, subtable []byte, i int) (int, bool) {
+	var buf = c.buf
+	var id = buf.info[i].id
+	switch lookupType {
+	case 1: // single
+		var index = otCoverageIndex(otSub(subtable, otU16(subtable, 2)), id)
+		if index < 0 {
+			return 0, false
+		}
+		if otU16(subtable, 0) == 1 {
+			buf.substitute(i, GlyphID((int(id)+otI16(subtable, 4))&0xffff), c.gdef)
+		} else if index < otU16(subtable, 4) {
+			buf.substitute(i, GlyphID(otU16(subtable, 6+2*index)), c.gdef)
+		} else {
+			return 0, false
+		}
+		return i + 1, true
+
+	case 2, 3: // multiple, alternate
+		var index = otCoverageIndex(otSub(subtable, otU16(subtable, 2)), id)
+		if index < 0 || index >= otU16(subtable, 4) {
+			return 0, false
+		}
+		var sequence = otSub(subtable, otU16(subtable, 6+2*index))
+		var count = otU16(sequence, 0)
+		if lookupType == 3 {
+			// without a way to pick an alternate, take the first.
+			if count == 0 {
+				return 0, false
+			}
+			buf.substitute(i, GlyphID(otU16(sequence, 2)), c.gdef)
+			return i + 1, true
+		}
+		var glyphs = make([]GlyphID, count)
+		for k := range glyphs {
+			glyphs[k] = GlyphID(otU16(sequence, 2+2*k))
+		}
+		buf.replace(i, glyphs, c.gdef)
+		return i + count, true
+
+	case 4: // ligature
+		var index = otCoverageIndex(otSub(subtable, otU16(subtable, 2)), id)
+		if index < 0 || index >= otU16(subtable, 4) {
+			return 0, false
+		}
+		var ligatureSet = otSub(subtable, otU16(subtable, 6+2*index))
+		for l := 0; l < otU16(ligatureSet, 0); l++ {
+			var ligature = otSub(ligatureSet, otU16(ligatureSet, 2+2*l))
+			var compCount = otU16(ligature, 2)
+			if compCount == 0 {
+				continue
+			}
+			if positions, ok := c.matchInput(i, compCount, 1, otMatchGlyphs(ligature, 4)); ok {
+				buf.ligate(positions, GlyphID(otU16(ligature, 0)), c.gdef)
+				return i + 1, true
+			}
+		}
+
+	case 5:
+		return c.applyContext(subtable, i)
+
+	case 6:
+		return c.applyChainContext(subtable, i)
+
+	case 7: // extension
+		return c.applySubst(otU16(subtable, 2), otSub(subtable, otU32(subtable, 4)), i)
+
+	case 8: // reverse chaining single
+		var index = otCoverageIndex(otSub(subtable, otU16(subtable, 2)), id)
+		if index < 0 {
+			return 0, false
+		}
+		var offset = 4
+		var backtrackCount = otU16(subtable, offset)
+		var backtrack = offset + 2
+		offset = backtrack + 2*backtrackCount
+		var lookaheadCount = otU16(subtable, offset)
+		var lookahead = offset + 2
+		offset = lookahead + 2*lookaheadCount
+		if index >= otU16(subtable, offset) ||
+			!c.matchBacktrack(i, backtrackCount, otMatchCoverages(subtable, backtrack)) ||
+			!c.matchLookahead(i, lookaheadCount, otMatchCoverages(subtable, lookahead)) {
+			return 0, false
+		}
+		buf.substitute(i, GlyphID(otU16(subtable, offset+2+2*index)), c.gdef)
+		return i + 1, true
+	}
+	return 0, false
+}
+
+// Apply a GPOS subtable of lookupType at position i.
+func (c *tOTApplyContext) applyPos(lookupType int, subtable []byte, i int) (int, bool) {
+	var buf = c.buf
+	var id = buf.info[i].id
+	switch lookupType {
+	case 1: // single adjustment
+		var index = otCoverageIndex(otSub(subtable, otU16(subtable, 2)), id)
+		if index < 0 {
+			return 0, false
+		}
+		var format = otU16(subtable, 4)
+		var value tOTValue
+		if otU16(subtable, 0) == 1 {
+			value, _ = otReadValue(subtable, 6, format)
+		} else {
+			value, _ = otReadValue(subtable, 8+index*otValueSize(format), format)
+		}
+		buf.adjust(i, value)
+		return i + 1, true
+
+	case 2: // pair adjustment
+		var index = otCoverageIndex(otSub(subtable, otU16(subtable, 2)), id)
+		if index < 0 {
+			return 0, false
+		}
+		var j = c.nextIndex(i)
+		if j < 0 {
+			return 0, false
+		}
+		var second = buf.info[j].id
+		var format1, format2 = otU16(subtable, 4), otU16(subtable, 6)
+		var size1, size2 = otValueSize(format1), otValueSize(format2)
+		var record = -1
+		var values []byte
+		if otU16(subtable, 0) == 1 {
+			values = otSub(subtable, otU16(subtable, 10+2*index))
+			var recordSize = 2 + size1 + size2
+			var count = otU16(values, 0)
+			var k = sort.Search(count, func(k int) bool { return otU16(values, 2+k*recordSize) >= int(second) })
+			if k < count && otU16(values, 2+k*recordSize) == int(second) {
+				record = 2 + k*recordSize + 2
+			}
+		} else {
+			var class1 = otClass(otSub(subtable, otU16(subtable, 8)), id)
+			var class2 = otClass(otSub(subtable, otU16(subtable, 10)), second)
+			var class1Count, class2Count = otU16(subtable, 12), otU16(subtable, 14)
+			if class1 < class1Count && class2 < class2Count {
+				values = subtable
+				record = 16 + (class1*class2Count+class2)*(size1+size2)
+			}
+		}
+		if record < 0 {
+			return 0, false
+		}
+		var value1, _ = otReadValue(values, record, format1)
+		var value2, _ = otReadValue(values, record+size1, format2)
+		buf.adjust(i, value1)
+		buf.adjust(j, value2)
+		if format2 != 0 {
+			return j + 1, true
+		}
+		return j, true
+
+	case 4, 5: // mark to base, mark to ligature
+		var markIndex = otCoverageIndex(otSub(subtable, otU16(subtable, 2)), id)
+		if markIndex < 0 {
+			return 0, false
+		}
+		// the base is the closest preceding glyph that isn't a mark.
+		var j = i - 1
+		for j >= 0 && buf.info[j].glyphClass == kOTGlyphClassMark {
+			j--
+		}
+		if j < 0 {
+			return 0, false
+		}
+		var baseIndex = otCoverageIndex(otSub(subtable, otU16(subtable, 4)), buf.info[j].id)
+		if baseIndex < 0 {
+			return 0, false
+		}
+		var classCount = otU16(subtable, 6)
+		var markArray = otSub(subtable, otU16(subtable, 8))
+		var class = otU16(markArray, 2+4*markIndex)
+		var markAnchor = otSub(markArray, otU16(markArray, 2+4*markIndex+2))
+		var baseArray = otSub(subtable, otU16(subtable, 10))
+		var baseAnchor []byte
+		if lookupType == 4 {
+			baseAnchor = otSub(baseArray, otU16(baseArray, 2+2*(baseIndex*classCount+class)))
+		} else {
+			// without tracking ligature components, attach to the last one.
+			var attach = otSub(baseArray, otU16(baseArray, 2+2*baseIndex))
+			var components = otU16(attach, 0)
+			if components == 0 {
+				return 0, false
+			}
+			baseAnchor = otSub(attach, otU16(attach, 2+2*((components-1)*classCount+class)))
+		}
+		if class >= classCount || markAnchor == nil || baseAnchor == nil {
+			return 0, false
+		}
+		buf.attach(i, j, markAnchor, baseAnchor)
+		return i + 1, true
+
+	case 6: // mark to mark
+		var markIndex = otCoverageIndex(otSub(subtable, otU16(subtable, 2)), id)
+		if markIndex < 0 {
+			return 0, false
+		}
+		var j = c.prevIndex(i)
+		if j < 0 || buf.info[j].glyphClass != kOTGlyphClassMark {
+			return 0, false
+		}
+		var mark2Index = otCoverageIndex(otSub(subtable, otU16(subtable, 4)), buf.info[j].id)
+		if mark2Index < 0 {
+			return 0, false
+		}
+		var classCount = otU16(subtable, 6)
+		var mark1Array = otSub(subtable, otU16(subtable, 8))
+		var class = otU16(mark1Array, 2+4*markIndex)
+		var markAnchor = otSub(mark1Array, otU16(mark1Array, 2+4*markIndex+2))
+		var mark2Array = otSub(subtable, otU16(subtable, 10))
+		var baseAnchor = otSub(mark2Array, otU16(mark2Array, 2+2*(mark2Index*classCount+class)))
+		if class >= classCount || markAnchor == nil || baseAnchor == nil {
+			return 0, false
+		}
+		buf.attach(i, j, markAnchor, baseAnchor)
+		return i + 1, true
+
+	case 7:
+		return c.applyContext(subtable, i)
+
+	case 8:
+		return c.applyChainContext(subtable, i)
+
+	case 9: // extension
+		return c.applyPos(otU16(subtable, 2), otSub(subtable, otU32(subtable, 4)), i)
+	}
+	// cursive attachment (3) is not supported.
+	return 0, false
+}
@@ -9,23 +9,49 @@ type Paint struct {
 	xfermode    *Xfermode
 	looper      *DrawLooper
 	imageFilter *ImageFilter
+	maskFilter  *MaskFilter
 
 	colorFilter *ColorFilter
+	pathEffect  *PathEffect
+	shader      *Shader
 	style       PaintStyle
 	color       Color
+
+	strokeWidth Scalar
+	miterLimit  Scalar
+	cap         PaintCap
+	join        PaintJoin
+
+	typeface     *Typeface
+	textSize     Scalar
+	textScaleX   Scalar
+	textSkewX    Scalar
+	textAlign    PaintAlign
+	textEncoding PaintTextEncoding
 }
 
+// Default text size, matching the legacy font host.
+const kPaintDefaultTextSize Scalar = 12
+
+// Default miter limit, the one of SVG.
+const kPaintDefaultMiterLimit Scalar = 4
+
 func NewPaint() *Paint {
 	var paint = &Paint{
+		color:       KColorBlack,
 		xfermode:    NewXfermode(),
-		imageFilter: NewImageFilter(),
+		imageFilter: nil,
+		textSize:    kPaintDefaultTextSize,
+		textScaleX:  KScalar1,
+		miterLimit:  kPaintDefaultMiterLimit,
+		hinting:     uint8(KPaintHintingNormal),
 	}
 	return paint
 }
 
 func NewPaint_Clone(otr *Paint) *Paint {
-	toimpl()
-	return nil
+	var paint = *otr
+	return &paint
 }
 
 /** Equal may give false negatives: two paints that draw equivalently
@@ -44,8 +70,7 @@ func (paint *Paint) Hash() uint32 {
 }
 
 func (paint *Paint) Clone() *Paint {
-	toimpl()
-	return nil
+	return NewPaint_Clone(paint)
 }
 
 func (paint *Paint) Flatten(buffer *WriteBuffer) {
@@ -124,6 +149,14 @@ func (paint *Paint) SetFlags(flags PaintFlags) {
 	paint.flags = uint32(flags)
 }
 
+func (paint *Paint) setFlag(flag PaintFlags, on bool) {
+	if on {
+		paint.flags |= uint32(flag)
+	} else {
+		paint.flags &^= uint32(flag)
+	}
+}
+
 /** Helper for getFlags(), returning true if kAntiAlias_Flag bit is set
 @return true if the antialias bit is set in the paint's flags. */
 func (paint *Paint) IsAntiAlias() bool {
@@ -133,7 +166,7 @@ func (paint *Paint) IsAntiAlias() bool {
 /** Helper for setFlags(), setting or clearing the kAntiAlias_Flag bit
 @param aa   true to enable antialiasing, false to disable it */
 func (paint *Paint) SetAnitAlias(aa bool) {
-	toimpl()
+	paint.setFlag(KPaintFlagAntiAlias, aa)
 }
 
 /** Helper for getFlags(), returning true if kDither_Flag bit is set
@@ -145,7 +178,7 @@ func (paint *Paint) IsDither() bool {
 /** Helper for setFlags(), setting or clearing the kDither_Flag bit
 @param dither   true to enable dithering, false to disable it */
 func (paint *Paint) SetDither(dither bool) {
-	toimpl()
+	paint.setFlag(KPaintFlagDither, dither)
 }
 
 /** Helper for getFlags(), returning true if kLinearText_Flag bit is set
@@ -158,7 +191,7 @@ func (paint *Paint) IsLinearText() bool {
 @param linearText true to set the linearText bit in the paint's flags,
 				  false to clear it. */
 func (paint *Paint) SetLinearText(linearText bool) {
-	toimpl()
+	paint.setFlag(KPaintFlagLinearText, linearText)
 }
 
 /** Helper for getFlags(), returning true if kSubpixelText_Flag bit is set
@@ -173,7 +206,7 @@ func (paint *Paint) IsSubpixelText() bool {
  *                      flags, false to clear it.
  */
 func (paint *Paint) SetSubpixelText(subpixelText bool) {
-	toimpl()
+	paint.setFlag(KPaintFlagSubpixelText, subpixelText)
 }
 
 func (paint *Paint) IsLCDRenderText() bool {
@@ -187,12 +220,11 @@ func (paint *Paint) IsLCDRenderText() bool {
  *                 false to clear it.
  */
 func (paint *Paint) SetLCDRenderText(lcdRencderText bool) {
-	toimpl()
+	paint.setFlag(KPaintFlagLCDRenderText, lcdRencderText)
 }
 
 func (paint *Paint) IsEmbeddedBitmapText() bool {
-	toimpl()
-	return false
+	return paint.flags&uint32(KPaintFlagEmbeddedBitmapText) != 0
 }
 
 /** Helper for setFlags(), setting or clearing the kEmbeddedBitmapText_Flag bit
@@ -200,26 +232,27 @@ func (paint *Paint) IsEmbeddedBitmapText() bool {
 							 false to clear it.
 */
 func (paint *Paint) SetEmbeddedBitmapText(useEmbeddedBitmapText bool) {
-	toimpl()
+	paint.setFlag(KPaintFlagEmbeddedBitmapText, useEmbeddedBitmapText)
 }
 
+/** IsAutohinted
+Returns true if glyphs drawn with KPaintHintingNormal are grid-fitted along
+both axes, rather than only vertically. */
 func (paint *Paint) IsAutohinted() bool {
-	toimpl()
-	return false
+	return paint.flags&uint32(KPaintFlagAutoHinting) != 0
 }
 
 /** Helper for setFlags(), setting or clearing the kAutoHinting_Flag bit
-@param useAutohinter true to set the kEmbeddedBitmapText bit in the
-						  paint's flags,
+@param useAutohinter true to set the kAutoHinting bit in the paint's flags,
+					 so KPaintHintingNormal also hints horizontally,
 					 false to clear it.
 */
 func (paint *Paint) SetAutohinted(useAutohinted bool) {
-	toimpl()
+	paint.setFlag(KPaintFlagAutoHinting, useAutohinted)
 }
 
 func (paint *Paint) IsVerticalText() bool {
-	toimpl()
-	return false
+	return paint.flags&uint32(KPaintFlagVerticalText) != 0
 }
 
 /**
@@ -231,43 +264,40 @@ func (paint *Paint) IsVerticalText() bool {
  *  horizontally.
  */
 func (paint *Paint) SetVerticalText(useVerticalText bool) {
-	toimpl()
+	paint.setFlag(KPaintFlagVerticalText, useVerticalText)
 }
 
 /** Helper for getFlags(), returning true if kUnderlineText_Flag bit is set
 @return true if the underlineText bit is set in the paint's flags. */
 func (paint *Paint) IsUnderlineText() bool {
-	toimpl()
-	return false
+	return paint.flags&uint32(KPaintFlagUnderline) != 0
 }
 
 /** Helper for setFlags(), setting or clearing the kUnderlineText_Flag bit
 @param underlineText true to set the underlineText bit in the paint's
 					 flags, false to clear it. */
 func (paint *Paint) SetUnderlineText(underlineText bool) {
-	toimpl()
+	paint.setFlag(KPaintFlagUnderline, underlineText)
 }
 
 /** Helper for getFlags(), returns true if kStrikeThruText_Flag bit is set
 @return true if the strikeThruText bit is set in the paint's flags. */
 func (paint *Paint) IsStrikeThruText() bool {
-	toimpl()
-	return false
+	return paint.flags&uint32(KPaintFlagStrikeThruText) != 0
 }
 
 /** Helper for setFlags(), setting or clearing the kStrikeThruText_Flag bit
 @param strikeThruText   true to set the strikeThruText bit in the
 						paint's flags, false to clear it. */
 func (paint *Paint) SetStrikeThruText(strikeThruText bool) {
-	toimpl()
+	paint.setFlag(KPaintFlagStrikeThruText, strikeThruText)
 }
 
 /** Helper for getFlags(), returns true if kFakeBoldText_Flag bit is set
 @return true if the kFakeBoldText_Flag bit is set in the paint's flags.
 */
 func (paint *Paint) IsFakeBoldText() bool {
-	toimpl()
-	return false
+	return paint.flags&uint32(KPaintFlagFakeBoldText) != 0
 }
 
 /** Helper for setFlags(), setting or clearing the kFakeBoldText_Flag bit
@@ -275,15 +305,14 @@ func (paint *Paint) IsFakeBoldText() bool {
 					flags, false to clear it.
 */
 func (paint *Paint) SetFakeBoldText(fakeBoldText bool) {
-	toimpl()
+	paint.setFlag(KPaintFlagFakeBoldText, fakeBoldText)
 }
 
 /** Helper for getFlags(), returns true if kDevKernText_Flag bit is set
 @return true if the kernText bit is set in the paint's flags.
 */
 func (paint *Paint) IsDevKernText() bool {
-	toimpl()
-	return false
+	return paint.flags&uint32(KPaintFlagDevKernText) != 0
 }
 
 /** Helper for setFlags(), setting or clearing the kKernText_Flag bit
@@ -291,7 +320,7 @@ func (paint *Paint) IsDevKernText() bool {
 					flags, false to clear it.
 */
 func (paint *Paint) SetDevKernText(devKernText bool) {
-	toimpl()
+	paint.setFlag(KPaintFlagDevKernText, devKernText)
 }
 
 /**
@@ -335,8 +364,7 @@ kFill_Style).
 @return the paint's Style
 */
 func (paint *Paint) Style() PaintStyle {
-	toimpl()
-	return KPaintStyleFill
+	return paint.style
 }
 
 /** Set the paint's style, used for controlling how primitives'
@@ -353,8 +381,7 @@ premultiplied, meaning that its alpha can be any value, regardless of
 the values of r,g,b.
 @return the paint's color (and alpha). */
 func (paint *Paint) Color() Color {
-	toimpl()
-	return KColorBlack
+	return paint.color
 }
 
 /** Set the paint's color. Note that the color is a 32bit value containing
@@ -368,15 +395,14 @@ func (paint *Paint) SetColor(color Color) {
 /** Helper to getColor() that just returns the color's alpha value.
 @return the alpha component of the paint's color. */
 func (paint *Paint) Alpha() uint8 {
-	toimpl()
-	return 0
+	return paint.color.Alpha()
 }
 
 /** Helper to setColor(), that only assigns the color's alpha value,
 leaving its r,g,b values unchanged.
 @param a    set the alpha component (0..255) of the paint's color. */
 func (paint *Paint) SetAlpha(alpha uint8) {
-	toimpl()
+	paint.color.SetAlpha(alpha)
 }
 
 /** Helper to setColor(), that takes a,r,g,b and constructs the color value
@@ -387,7 +413,7 @@ using SkColorSetARGB()
 @param b    The new blue component (0..255) of the paint's color.
 */
 func (paint *Paint) SetARGB(a, r, g, b uint8) {
-	toimpl()
+	paint.color = ColorWithARGB(a, r, g, b)
 }
 
 /** Return the width for stroking.
@@ -396,9 +422,8 @@ A value of 0 strokes in hairline mode.
 Hairlines always draw 1-pixel wide, regardless of the matrix.
 @return the paint's stroke width, used whenever the paint's style is
 		Stroke or StrokeAndFill. */
-func (paint *Paint) StrokeWidth() int {
-	toimpl()
-	return 0
+func (paint *Paint) StrokeWidth() Scalar {
+	return paint.strokeWidth
 }
 
 /** Set the width for stroking.
@@ -407,7 +432,9 @@ Hairlines always draw 1-pixel wide, regardless of the matrix.
 @param width set the paint's stroke width, used whenever the paint's
 			 style is Stroke or StrokeAndFill. */
 func (paint *Paint) SetStrokeWidth(width Scalar) {
-	toimpl()
+	if width >= 0 {
+		paint.strokeWidth = width
+	}
 }
 
 /** Return the paint's stroke miter value. This is used to control the
@@ -415,8 +442,7 @@ behavior of miter joins when the joins angle is sharp.
 @return the paint's miter limit, used whenever the paint's style is
 		Stroke or StrokeAndFill. */
 func (paint *Paint) StrokeMiter() Scalar {
-	toimpl()
-	return Scalar(0)
+	return paint.miterLimit
 }
 
 /** Set the paint's stroke miter value. This is used to control the
@@ -426,7 +452,9 @@ be >= 0.
 				paint's style is Stroke or StrokeAndFill.
 */
 func (paint *Paint) SetStrokeMiter(miter Scalar) {
-	toimpl()
+	if miter >= 0 {
+		paint.miterLimit = miter
+	}
 }
 
 /** Cap enum specifies the settings for the paint's strokecap. This is the
@@ -459,15 +487,16 @@ of stroked lines and paths are treated.
 		style is Stroke or StrokeAndFill.
 */
 func (paint *Paint) StrokeCap() PaintCap {
-	toimpl()
-	return KPaintCapDefault
+	return paint.cap
 }
 
 /** Set the paint's stroke cap type.
 @param cap  set the paint's line cap style, used whenever the paint's
 			style is Stroke or StrokeAndFill. */
 func (paint *Paint) SetStrokeCap(cap PaintCap) {
-	toimpl()
+	if cap >= 0 && cap < KPaintCapCount {
+		paint.cap = cap
+	}
 }
 
 type PaintJoin int
@@ -486,15 +515,16 @@ const (
 @return the paint's line join style, used whenever the paint's style is
 		Stroke or StrokeAndFill. */
 func (paint *Paint) StrokeJoin() PaintJoin {
-	toimpl()
-	return KPaintJoinDefault
+	return paint.join
 }
 
 /** Set the paint's stroke join type.
 @param join set the paint's line join style, used whenever the paint's
 			style is Stroke or StrokeAndFill. */
 func (paint *Paint) SetStrokeJoin(join PaintJoin) {
-	toimpl()
+	if join >= 0 && join < KPaintJoinCount {
+		paint.join = join
+	}
 }
 
 /**
@@ -511,8 +541,22 @@ func (paint *Paint) SetStrokeJoin(join PaintJoin) {
  *  @return     true if the path should be filled, or false if it should be
  *              drawn with a hairline (width == 0)
  */
-func (paint *Paint) FillPath(src *Path, dst *Path, cullRect *Rect, resScale Scalar) {
-	toimpl()
+func (paint *Paint) FillPath(src *Path, dst *Path, cullRect *Rect, resScale Scalar) bool {
+	var rec = NewStrokeRecFromPaint(paint)
+	var effected = NewPath()
+	if effect := paint.PathEffect(); effect != nil && effect.FilterPath(effected, src, rec, cullRect) {
+		src = effected
+	}
+	switch rec.Style() {
+	case KStrokeRecStyleFill:
+		*dst = *NewPathClone(src)
+	case KStrokeRecStyleHairline:
+		*dst = *NewPathClone(src)
+		return false
+	default:
+		*dst = *strokePath(src, rec, resScale)
+	}
+	return true
 }
 
 /** Get the paint's shader object.
@@ -521,8 +565,7 @@ func (paint *Paint) FillPath(src *Path, dst *Path, cullRect *Rect, resScale Scal
 	@return the paint's shader (or NULL)
 */
 func (paint *Paint) Shader() *Shader {
-	toimpl()
-	return nil
+	return paint.shader
 }
 
 /** Set or clear the shader object.
@@ -547,7 +590,7 @@ func (paint *Paint) Shader() *Shader {
  *  @return         shader
  */
 func (paint *Paint) SetShader(shader *Shader) {
-	toimpl()
+	paint.shader = shader
 }
 
 /** Get the paint's colorfilter. If there is a colorfilter, its reference
@@ -566,7 +609,7 @@ If filter is not NULL, its reference count is incremented.
 @return         filter
 */
 func (paint *Paint) SetColorFilter(colorFilter *ColorFilter) {
-	toimpl()
+	paint.colorFilter = colorFilter
 }
 
 /** Get the paint's xfermode object.
@@ -607,8 +650,7 @@ func (paint *Paint) SetXfermodeMode(mode XfermodeMode) *Xfermode {
 	@return the paint's patheffect (or NULL)
 */
 func (paint *Paint) PathEffect() *PathEffect {
-	toimpl()
-	return nil
+	return paint.pathEffect
 }
 
 /** Set or clear the patheffect object.
@@ -622,7 +664,7 @@ If patheffect is not NULL, its reference count is incremented.
 @return         effect
 */
 func (paint *Paint) SetPathEffect(effect *PathEffect) {
-	toimpl()
+	paint.pathEffect = effect
 }
 
 /** Get the paint's maskfilter object.
@@ -631,8 +673,7 @@ func (paint *Paint) SetPathEffect(effect *PathEffect) {
 	@return the paint's maskfilter (or NULL)
 */
 func (paint *Paint) MaskFilter() *MaskFilter {
-	toimpl()
-	return nil
+	return paint.maskFilter
 }
 
 /** Set or clear the maskfilter object.
@@ -645,8 +686,8 @@ If maskfilter is not NULL, its reference count is incremented.
 					the paint
 @return             maskfilter
 */
-func (paint *Paint) SetMastFilter(maskfilter *MaskFilter) {
-	toimpl()
+func (paint *Paint) SetMaskFilter(maskfilter *MaskFilter) {
+	paint.maskFilter = maskfilter
 }
 
 // These attributes are for text/fonts
@@ -658,8 +699,7 @@ measuring text. The typeface reference count is not affected.
 @return the paint's typeface (or NULL)
 */
 func (paint *Paint) Typeface() *Typeface {
-	toimpl()
-	return nil
+	return paint.typeface
 }
 
 /** Set or clear the typeface object.
@@ -673,7 +713,7 @@ If typeface is not NULL, its reference count is incremented.
 @return         typeface
 */
 func (paint *Paint) SetTypeface(typeface *Typeface) {
-	toimpl()
+	paint.typeface = typeface
 }
 
 /** Get the paint's rasterizer (or NULL).
@@ -706,7 +746,7 @@ func (paint *Paint) ImageFilter() *ImageFilter {
 }
 
 func (paint *Paint) SetImageFilter(imageFilter *ImageFilter) {
-	toimpl()
+	paint.imageFilter = imageFilter
 }
 
 /**
@@ -745,30 +785,32 @@ const (
 @return the paint's Align value for drawing text.
 */
 func (paint *Paint) TextAlign() PaintAlign {
-	toimpl()
-	return KPaintAlignLeft
+	return paint.textAlign
 }
 
 /** Set the paint's text alignment.
 @param align set the paint's Align value for drawing text.
 */
 func (paint *Paint) SetTextAlign(align PaintAlign) {
-	toimpl()
+	if align < KPaintAlignCount {
+		paint.textAlign = align
+	}
 }
 
 /** Return the paint's text size.
 @return the paint's text size.
 */
 func (paint *Paint) TextSize() Scalar {
-	toimpl()
-	return Scalar(0)
+	return paint.textSize
 }
 
 /** Set the paint's text size. This value must be > 0
 @param textSize set the paint's text size.
 */
 func (paint *Paint) SetTextSize(textSize Scalar) {
-	toimpl()
+	if textSize >= 0 {
+		paint.textSize = textSize
+	}
 }
 
 /** Return the paint's horizontal scale factor for text. The default value
@@ -776,8 +818,7 @@ is 1.0.
 @return the paint's scale factor in X for drawing/measuring text
 */
 func (paint *Paint) TextScaleX() Scalar {
-	toimpl()
-	return Scalar(0)
+	return paint.textScaleX
 }
 
 /** Set the paint's horizontal scale factor for text. The default value
@@ -787,7 +828,7 @@ stretch the text narrower.
 				text.
 */
 func (paint *Paint) SetTextScaleX(scaleX Scalar) {
-	toimpl()
+	paint.textScaleX = scaleX
 }
 
 /** Return the paint's horizontal skew factor for text. The default value
@@ -795,8 +836,7 @@ is 0.
 @return the paint's skew factor in X for drawing text.
 */
 func (paint *Paint) TextSkewX() Scalar {
-	toimpl()
-	return Scalar(0)
+	return paint.textSkewX
 }
 
 /** Set the paint's horizontal skew factor for text. The default value
@@ -804,7 +844,7 @@ is 0. For approximating oblique text, use values around -0.25.
 @param skewX set the paint's skew factor in X for drawing text.
 */
 func (paint *Paint) SetTextSkewX(skewX Scalar) {
-	toimpl()
+	paint.textSkewX = skewX
 }
 
 /** Describes how to interpret the text parameters that are passed to paint
@@ -820,12 +860,13 @@ const (
 )
 
 func (paint *Paint) TextEncoding() PaintTextEncoding {
-	toimpl()
-	return KPaintTextEncodingUTF8
+	return paint.textEncoding
 }
 
 func (paint *Paint) SetTextEncoding(encoding PaintTextEncoding) {
-	toimpl()
+	if encoding <= KPaintTextEncodingGlyphID {
+		paint.textEncoding = encoding
+	}
 }
 
 /** Flags which indicate the confidence level of various metrics.
@@ -891,25 +932,47 @@ func (metrics *PaintFontMetrics) HasUnderlinePosition(position *Scalar) bool {
 	@param return the recommended spacing between lines
 */
 func (paint *Paint) FontMetrics(metrics *PaintFontMetrics, scale Scalar) Scalar {
-	toimpl()
-	return Scalar(0)
+	var p = paint
+	if scale != 0 && scale != 1 {
+		p = paint.Clone()
+		p.textSize *= scale
+	}
+	var fm = *p.detachCache(nil, nil).FontMetrics()
+	if p != paint {
+		var invScale = 1 / scale
+		for _, v := range []*Scalar{&fm.Top, &fm.Ascent, &fm.Descent, &fm.Bottom,
+			&fm.Leading, &fm.AvgCharWidth, &fm.MaxCharWidth, &fm.XMin, &fm.XMax,
+			&fm.XHeight, &fm.CapHeight, &fm.UnderlineThickness, &fm.UnderlinePosition} {
+			*v *= invScale
+		}
+	}
+	if metrics != nil {
+		*metrics = fm
+	}
+	return fm.Descent - fm.Ascent + fm.Leading
 }
 
 /** Return the recommend line spacing. This will be
 	fDescent - fAscent + fLeading
 */
 func (paint *Paint) FontSpacing() Scalar {
-	toimpl()
-	return Scalar(0)
+	return paint.FontMetrics(nil, 0)
 }
 
 /** Convert the specified text into glyph IDs, returning the number of
 	glyphs ID written. If glyphs is NULL, it is ignore and only the count
 	is returned.
+
+	The IDs are those of the paint's typeface: characters it has no glyph
+	for are 0, even though they are measured and drawn as text from a
+	fallback typeface. Glyph runs drawing such text need a run per
+	typeface, as Paragraph does.
 */
 func (paint *Paint) TextToGlyphs(text string, byteLength int, glyphs []GlyphID) int {
-	toimpl()
-	return 0
+	var cache = paint.detachCache(nil, nil)
+	var ids = paint.textToGlyphIDs(cache, text[:byteLength])
+	copy(glyphs, ids)
+	return len(ids)
 }
 
 /** Return true if all of the specified text has a corresponding non-zero
@@ -939,8 +1002,7 @@ func (paint *Paint) GlyphsToUnichars(glyphs []GlyphID, count int, text []Unichar
 	instead.
 */
 func (paint *Paint) CountText(text string, byteLength int) int {
-	toimpl()
-	return 0
+	return len(paint.textToUnichars(text[:byteLength]))
 }
 
 /** Return the width of the text. This will return the vertical measure
@@ -954,8 +1016,9 @@ func (paint *Paint) CountText(text string, byteLength int) int {
  *  @return             The advance width of the text
  */
 func (paint *Paint) MeasureText(text string, length int, bounds *Rect) Scalar {
-	toimpl()
-	return Scalar(0)
+	var cache = paint.detachCache(nil, nil)
+	var width, _ = paint.measureText(cache, text[:length], bounds)
+	return width
 }
 
 /** Return the number of bytes of text that were measured. If
@@ -972,8 +1035,26 @@ func (paint *Paint) MeasureText(text string, length int, bounds *Rect) Scalar {
  *                  <= length.
  */
 func (paint *Paint) BreakText(text string, length int, maxWidth Scalar, measuredWidth *Scalar) int {
-	toimpl()
-	return 0
+	var width Scalar = 0
+	var measured = 0
+	if length > 0 && maxWidth > 0 {
+		var cache = paint.detachCache(nil, nil)
+		text = text[:length]
+		var sizes = paint.textUnitSizes(text)
+		var vertical = paint.IsVerticalText()
+		for i, g := range paint.textToFallbackGlyphs(cache, text) {
+			var advance = glyphAdvance(g.metrics(), vertical)
+			if i >= len(sizes) || width+advance > maxWidth {
+				break
+			}
+			width += advance
+			measured += sizes[i]
+		}
+	}
+	if measuredWidth != nil {
+		*measuredWidth = width
+	}
+	return measured
 }
 
 /** Return the advances for the text. These will be vertical advances if
@@ -989,8 +1070,18 @@ func (paint *Paint) BreakText(text string, length int, maxWidth Scalar, measured
  *  @return the number of unichars in the specified text.
  */
 func (paint *Paint) TextWidths(text string, byteLength int, widths []Scalar, bounds []Rect) int {
-	toimpl()
-	return 0
+	var cache = paint.detachCache(nil, nil)
+	var glyphs = paint.textToFallbackGlyphs(cache, text[:byteLength])
+	for i, g := range glyphs {
+		var glyph = g.metrics()
+		if i < len(widths) {
+			widths[i] = glyphAdvance(glyph, paint.IsVerticalText())
+		}
+		if i < len(bounds) {
+			bounds[i] = glyph.Bounds()
+		}
+	}
+	return len(glyphs)
 }
 
 /** Return the path (outline) for the specified text.
@@ -1036,9 +1127,17 @@ func (paint *Paint) PosTextPath(text string, length int, pos []Point, path *Path
  *
  *  @return             The number of intersections, which may be zero.
  */
-func (paint *Paint) TextIntercepts(text string, length int, x, y Scalar, bounds [2]Scalar, intervals *Scalar) int {
-	toimpl()
-	return 0
+func (paint *Paint) TextIntercepts(text string, length int, x, y Scalar, bounds [2]Scalar, intervals []Scalar) int {
+	var iter = newTextToPathIter(text[:length], paint)
+	var count = 0
+	for {
+		var glyph, xpos, ok = iter.nextGlyph()
+		if !ok {
+			return count
+		}
+		var offset = iter.offset(xpos)
+		count = iter.appendIntercepts(glyph, x+offset.X, y+offset.Y, bounds, intervals, count)
+	}
 }
 
 /** Return the number of intervals that intersect the intercept along the axis of the advance.
@@ -1056,9 +1155,20 @@ func (paint *Paint) TextIntercepts(text string, length int, x, y Scalar, bounds
  *
  *  @return             The number of intersections, which may be zero.
  */
-func (paint *Paint) PosTextIntercepts(text string, length int, pos []Point, bounds [2]Scalar, intervals *Scalar) int {
-	toimpl()
-	return 0
+func (paint *Paint) PosTextIntercepts(text string, length int, pos []Point, bounds [2]Scalar, intervals []Scalar) int {
+	var iter = newTextToPathIter(text[:length], paint)
+	var alignFactor = textAlignFactor(paint.TextAlign())
+	var count = 0
+	for i := 0; i < len(pos); i++ {
+		var glyph, _, ok = iter.nextGlyph()
+		if !ok {
+			break
+		}
+		var x = pos[i].X - glyph.AdvanceX*iter.scale*alignFactor
+		var y = pos[i].Y - glyph.AdvanceY*iter.scale*alignFactor
+		count = iter.appendIntercepts(glyph, x, y, bounds, intervals, count)
+	}
+	return count
 }
 
 /** Return the number of intervals that intersect the intercept along the axis of the advance.
@@ -1077,9 +1187,12 @@ func (paint *Paint) PosTextIntercepts(text string, length int, pos []Point, boun
  *
  *  @return             The number of intersections, which may be zero.
  */
-func (paint *Paint) PosTextHIntercepts(text string, length int, xpos []Scalar, constY Scalar, bounds [2]Scalar, intervals *Scalar) int {
-	toimpl()
-	return 0
+func (paint *Paint) PosTextHIntercepts(text string, length int, xpos []Scalar, constY Scalar, bounds [2]Scalar, intervals []Scalar) int {
+	var pos = make([]Point, len(xpos))
+	for i, x := range xpos {
+		pos[i] = Point{x, constY}
+	}
+	return paint.PosTextIntercepts(text, length, pos, bounds, intervals)
 }
 
 /** Return the number of intervals that intersect the intercept along the axis of the advance.
@@ -1094,9 +1207,30 @@ func (paint *Paint) PosTextHIntercepts(text string, length int, xpos []Scalar, c
  *
  *  @return             The number of intersections, which may be zero.
  */
-func (paint *Paint) TextBlobIntercepts(blob *TextBlob, bounds [2]Scalar, intervals *Scalar) int {
-	toimpl()
-	return 0
+func (paint *Paint) TextBlobIntercepts(blob *TextBlob, bounds [2]Scalar, intervals []Scalar) int {
+	var runPaint = paint.Clone()
+	var count = 0
+	for it := NewTextBlobRunIterator(blob); !it.Done(); it.Next() {
+		it.ApplyFontToPaint(runPaint)
+		var text = glyphsToText(it.Glyphs())
+		var runIntervals []Scalar
+		if intervals != nil {
+			runIntervals = intervals[count:]
+		}
+		switch it.Positioning() {
+		case KTextBlobPositioningDefault:
+			count += runPaint.TextIntercepts(text, len(text), it.Offset().X, it.Offset().Y, bounds, runIntervals)
+		case KTextBlobPositioningHorizontal:
+			count += runPaint.PosTextHIntercepts(text, len(text), it.Pos(), it.Offset().Y, bounds, runIntervals)
+		case KTextBlobPositioningFull:
+			var pos = make([]Point, it.GlyphCount())
+			for i := range pos {
+				pos[i] = Point{it.Pos()[2*i], it.Pos()[2*i+1]}
+			}
+			count += runPaint.PosTextIntercepts(text, len(text), pos, bounds, runIntervals)
+		}
+	}
+	return count
 }
 
 /**
@@ -1125,8 +1259,13 @@ func (paint *Paint) NothingToDraw() bool {
  the bounds computation expensive.
  */
 func (paint *Paint) CanComputeFastBounds() bool {
-	toimpl()
-	return false
+	if paint.Looper() != nil {
+		return paint.Looper().CanComputeFastBounds(paint)
+	}
+	if paint.ImageFilter() != nil && !paint.ImageFilter().CanComputeFastBounds() {
+		return false
+	}
+	return true
 }
 
 /** Only call this if canComputeFastBounds() returned true. This takes a
@@ -1151,8 +1290,11 @@ func (paint *Paint) CanComputeFastBounds() bool {
  }
  */
 func (paint *Paint) ComputeFastBounds(orig Rect, strong *Rect) Rect {
-	toimpl()
-	return RectZero
+	if paint.Style() == KPaintStyleFill && paint.Looper() == nil && paint.MaskFilter() == nil &&
+		paint.PathEffect() == nil && paint.ImageFilter() == nil {
+		return orig
+	}
+	return paint.doComputeFastBounds(orig, strong, paint.Style())
 }
 
 func (paint *Paint) ComputeFastStrokeBounds(orig Rect, storage *Rect) Rect {
@@ -1162,8 +1304,28 @@ func (paint *Paint) ComputeFastStrokeBounds(orig Rect, storage *Rect) Rect {
 // Take the style explicitly, so the caller can force us to be stroked
 // without having to make a copy of the paint just to change that field.
 func (paint *Paint) doComputeFastBounds(orig Rect, storage *Rect, style PaintStyle) Rect {
-	toimpl()
-	return RectZero
+	var bounds = orig
+	if paint.Looper() != nil {
+		bounds = paint.Looper().ComputeFastBounds(paint, orig)
+	} else {
+		var rec = NewStrokeRec(KStrokeRecInitStyleFill)
+		if style != KPaintStyleFill {
+			rec.SetStrokeStyle(paint.StrokeWidth(), style == KPaintStyleStrokeAndFill)
+			rec.SetStrokeParams(paint.StrokeCap(), paint.StrokeJoin(), paint.StrokeMiter())
+		}
+		var radius = rec.InflationRadius()
+		bounds.Outset(radius, radius)
+		if paint.MaskFilter() != nil {
+			bounds = paint.MaskFilter().ComputeFastBounds(bounds)
+		}
+		if paint.ImageFilter() != nil {
+			bounds = paint.ImageFilter().ComputeFastBounds(bounds)
+		}
+	}
+	if storage != nil {
+		*storage = bounds
+	}
+	return bounds
 }
 
 /**
@@ -1180,3 +1342,371 @@ func SetTextMatrix(matrix *Matrix) *Matrix {
 }
 
 type GlyphCacheProc func (*GlyphCache, **byte) *Glyph
+
+// The text size that glyph outlines are generated at when text is drawn as
+// paths. The outlines are then scaled to the paint's text size.
+const kCanonicalTextSizeForPaths Scalar = 64
+
+// Decode text into unichars according to the paint's text encoding. For
+// KPaintTextEncodingGlyphID the values are the glyph IDs themselves.
+func (paint *Paint) textToUnichars(text string) []Unichar {
+	var unichars []Unichar
+	switch paint.textEncoding {
+	case KPaintTextEncodingUTF8:
+		for _, r := range text {
+			unichars = append(unichars, Unichar(r))
+		}
+	case KPaintTextEncodingUTF16:
+		for i := 0; i+1 < len(text); i += 2 {
+			var c = Unichar(text[i]) | Unichar(text[i+1])<<8
+			if c >= 0xD800 && c < 0xDC00 && i+3 < len(text) {
+				var low = Unichar(text[i+2]) | Unichar(text[i+3])<<8
+				if low >= 0xDC00 && low < 0xE000 {
+					c = 0x10000 + ((c - 0xD800) << 10) + (low - 0xDC00)
+					i += 2
+				}
+			}
+			unichars = append(unichars, c)
+		}
+	case KPaintTextEncodingUTF32:
+		for i := 0; i+3 < len(text); i += 4 {
+			unichars = append(unichars, Unichar(text[i])|Unichar(text[i+1])<<8|
+				Unichar(text[i+2])<<16|Unichar(text[i+3])<<24)
+		}
+	case KPaintTextEncodingGlyphID:
+		for i := 0; i+1 < len(text); i += 2 {
+			unichars = append(unichars, Unichar(text[i])|Unichar(text[i+1])<<8)
+		}
+	}
+	return unichars
+}
+
+// Return the number of bytes of each character of text in the paint's
+// text encoding.
+func (paint *Paint) textUnitSizes(text string) []int {
+	var sizes []int
+	switch paint.textEncoding {
+	case KPaintTextEncodingUTF8:
+		var prev = -1
+		for i := range text {
+			if prev >= 0 {
+				sizes = append(sizes, i-prev)
+			}
+			prev = i
+		}
+		if prev >= 0 {
+			sizes = append(sizes, len(text)-prev)
+		}
+	case KPaintTextEncodingUTF16:
+		for i := 0; i+1 < len(text); i += 2 {
+			var c = int(text[i]) | int(text[i+1])<<8
+			if c >= 0xD800 && c < 0xDC00 && i+3 < len(text) {
+				sizes = append(sizes, 4)
+				i += 2
+			} else {
+				sizes = append(sizes, 2)
+			}
+		}
+	case KPaintTextEncodingUTF32:
+		for i := 0; i+3 < len(text); i += 4 {
+			sizes = append(sizes, 4)
+		}
+	case KPaintTextEncodingGlyphID:
+		for i := 0; i+1 < len(text); i += 2 {
+			sizes = append(sizes, 2)
+		}
+	}
+	return sizes
+}
+
+// Convert text into glyph IDs using the cache's character map.
+func (paint *Paint) textToGlyphIDs(cache *GlyphCache, text string) []GlyphID {
+	var unichars = paint.textToUnichars(text)
+	var glyphs = make([]GlyphID, len(unichars))
+	for i, uni := range unichars {
+		if paint.textEncoding == KPaintTextEncodingGlyphID {
+			glyphs[i] = GlyphID(uni)
+		} else {
+			glyphs[i] = cache.UnicharToGlyph(uni)
+		}
+	}
+	return glyphs
+}
+
+/** tTextGlyph
+is a glyph of text and the cache it is drawn from: the paint's own cache,
+or that of a fallback typeface for a character the paint's typeface has no
+glyph for. typeface is the typeface id is a glyph of, which is the paint's,
+possibly nil, unless the glyph is a fallback. */
+type tTextGlyph struct {
+	cache    *GlyphCache
+	id       GlyphID
+	typeface *Typeface
+}
+
+func (g tTextGlyph) metrics() *Glyph {
+	return g.cache.GlyphIDMetrics(g.id)
+}
+
+/** textToFallbackGlyphs
+Convert text into glyphs like textToGlyphIDs, but take the characters
+that cache has no glyph for from the typeface the default font manager
+falls back to, so they are not drawn as the missing glyph box. */
+func (paint *Paint) textToFallbackGlyphs(cache *GlyphCache, text string) []tTextGlyph {
+	var ids = paint.textToGlyphIDs(cache, text)
+	var glyphs = make([]tTextGlyph, len(ids))
+	for i, id := range ids {
+		glyphs[i] = tTextGlyph{cache, id, paint.typeface}
+	}
+	if paint.textEncoding == KPaintTextEncodingGlyphID {
+		return glyphs
+	}
+
+	var typeface = paint.typeface
+	if typeface == nil {
+		typeface = TypefaceDefault()
+	}
+	var fallbacks map[*Typeface]*GlyphCache
+	for i, uni := range paint.textToUnichars(text) {
+		if ids[i] != 0 || isUnicharControl(uni) {
+			continue
+		}
+		var fallback = FontMgrDefault().MatchFamilyStyleCharacter(
+			typeface.FamilyName(), typeface.FontStyle(), uni)
+		if fallback == nil || fallback == typeface {
+			continue
+		}
+		var fallbackCache, ok = fallbacks[fallback]
+		if !ok {
+			if fallbacks == nil {
+				fallbacks = make(map[*Typeface]*GlyphCache)
+			}
+			fallbackCache = FindGlyphCache(fallback, cache.Rec())
+			fallbacks[fallback] = fallbackCache
+		}
+		if id := fallbackCache.UnicharToGlyph(uni); id != 0 {
+			glyphs[i] = tTextGlyph{fallbackCache, id, fallback}
+		}
+	}
+	return glyphs
+}
+
+// Return true for the C0 and C1 control characters, which are never drawn
+// so need no fallback.
+func isUnicharControl(uni Unichar) bool {
+	return uni < 0x20 || (uni >= 0x7F && uni < 0xA0)
+}
+
+// Sum the advances of the glyphs of text, returning the total width (or
+// height for vertical text) and the number of glyphs. If bounds is not nil
+// it receives the union of the glyph bounds, relative to the origin of the
+// text.
+func (paint *Paint) measureText(cache *GlyphCache, text string, bounds *Rect) (Scalar, int) {
+	var glyphs = paint.textToFallbackGlyphs(cache, text)
+	var vertical = paint.IsVerticalText()
+	var pos Scalar = 0
+	var union Rect
+	for _, g := range glyphs {
+		var glyph = g.metrics()
+		if bounds != nil && !glyph.IsEmpty() {
+			var r = glyph.Bounds()
+			if vertical {
+				r.Offset(0, pos)
+			} else {
+				r.Offset(pos, 0)
+			}
+			union.Join(r)
+		}
+		pos += glyphAdvance(glyph, vertical)
+	}
+	if bounds != nil {
+		*bounds = union
+	}
+	return pos, len(glyphs)
+}
+
+// Return the advance of glyph along the direction text is laid out in.
+func glyphAdvance(glyph *Glyph, vertical bool) Scalar {
+	if vertical {
+		return glyph.AdvanceY
+	}
+	return glyph.AdvanceX
+}
+
+// Build the scaler context rec that describes how this paint's glyphs
+// look when drawn through deviceMatrix (which may be nil).
+func (paint *Paint) makeScalerContextRec(props *SurfaceProps, deviceMatrix *Matrix) *ScalerContextRec {
+	var rec = &ScalerContextRec{
+		TextSize:   paint.textSize,
+		PreScaleX:  paint.textScaleX,
+		PreSkewX:   paint.textSkewX,
+		Hinting:    paint.Hinting(),
+		MaskFormat: KMaskFormatA8,
+	}
+	rec.Post2x2[0][0], rec.Post2x2[1][1] = 1, 1
+	if paint.IsLinearText() {
+		// linear text is laid out at any scale the same way, so it can't be
+		// grid-fitted.
+		rec.Hinting = KPaintHintingNo
+		rec.Flags |= KScalerContextFlagLinearMetrics
+	}
+	if paint.IsAutohinted() {
+		rec.Flags |= KScalerContextFlagForceAutohinting
+	}
+	if paint.IsVerticalText() {
+		rec.Flags |= KScalerContextFlagVertical
+	}
+	var typeface = paint.typeface
+	if typeface == nil {
+		typeface = TypefaceDefault()
+	}
+	if typeface.TableData(SetFourByteTag('C', 'O', 'L', 'R')) != nil {
+		// the alpha of the paint is applied when the glyphs are blitted.
+		rec.ForegroundColor = paint.color
+		rec.ForegroundColor.SetAlpha(0xff)
+	}
+	if deviceMatrix != nil {
+		rec.Post2x2[0][0], rec.Post2x2[0][1] = deviceMatrix.ScaleX(), deviceMatrix.SkewX()
+		rec.Post2x2[1][0], rec.Post2x2[1][1] = deviceMatrix.SkewY(), deviceMatrix.ScaleY()
+	}
+	if !paint.IsAntiAlias() {
+		rec.MaskFormat = KMaskFormatBW
+	} else if paint.IsLCDRenderText() && props != nil && paint.maskFilter == nil {
+		// LCD text needs to know how the subpixels are laid out. Mask
+		// filters only take coverage masks, so they get A8 glyphs.
+		var geo = props.PixelGeometry()
+		if (PixelGeometryIsH(geo) || PixelGeometryIsV(geo)) && !rec.tooBigForLCD() &&
+			(deviceMatrix == nil || !deviceMatrix.HasPerspective()) {
+			rec.MaskFormat = KMaskFormatLCD16
+			if PixelGeometryIsV(geo) {
+				rec.Flags |= KScalerContextFlagLCDVertical
+			}
+			if PixelGeometryIsBGR(geo) {
+				rec.Flags |= KScalerContextFlagLCDBGR
+			}
+		}
+	}
+	return rec
+}
+
+// Return a glyph cache for drawing this paint's text through deviceMatrix
+// (which may be nil) onto a surface with props (which may be nil).
+func (paint *Paint) detachCache(props *SurfaceProps, deviceMatrix *Matrix) *GlyphCache {
+	return FindGlyphCache(paint.typeface, paint.makeScalerContextRec(props, deviceMatrix))
+}
+
+// Return how far, as a fraction of its advance, text is moved to the left
+// for align.
+func textAlignFactor(align PaintAlign) Scalar {
+	switch align {
+	case KPaintAlignCenter:
+		return KScalarHalf
+	case KPaintAlignRight:
+		return 1
+	}
+	return 0
+}
+
+/** tTextToPathIter
+walks the glyphs of a run of text, returning each glyph's outline and its
+position along the baseline, which runs down instead of across for
+vertical text. Outlines are generated at a canonical size and must be
+scaled by PathScale(). */
+type tTextToPathIter struct {
+	paint       *Paint
+	cache       *GlyphCache // the cache of the current glyph.
+	glyphs      []tTextGlyph
+	index       int
+	scale       Scalar
+	vertical    bool
+	xPos        Scalar
+	prevAdvance Scalar
+}
+
+func newTextToPathIter(text string, paint *Paint) *tTextToPathIter {
+	var iter = &tTextToPathIter{
+		paint: paint.Clone(),
+	}
+
+	// can't use our canonical size if we need to apply patheffects
+	iter.paint.SetLinearText(true)
+	iter.paint.SetTextSize(kCanonicalTextSizeForPaths)
+	iter.scale = paint.TextSize() / kCanonicalTextSizeForPaths
+	iter.vertical = paint.IsVerticalText()
+	iter.cache = iter.paint.detachCache(nil, nil)
+	iter.glyphs = iter.paint.textToFallbackGlyphs(iter.cache, text)
+
+	var xOffset Scalar = 0
+	if paint.TextAlign() != KPaintAlignLeft { // need to measure first
+		var width, _ = iter.paint.measureText(iter.cache, text, nil)
+		width *= iter.scale
+		if paint.TextAlign() == KPaintAlignCenter {
+			width = ScalarHalf(width)
+		}
+		xOffset = -width
+	}
+	iter.xPos = xOffset
+	return iter
+}
+
+func (iter *tTextToPathIter) PathScale() Scalar {
+	return iter.scale
+}
+
+func (iter *tTextToPathIter) Paint() *Paint {
+	return iter.paint
+}
+
+// Return the offset of a glyph at xpos along the baseline.
+func (iter *tTextToPathIter) offset(xpos Scalar) Point {
+	if iter.vertical {
+		return Point{0, xpos}
+	}
+	return Point{xpos, 0}
+}
+
+// Next returns the outline of the next glyph (nil if it has none) and its
+// position along the baseline, and false when all glyphs have been visited.
+func (iter *tTextToPathIter) Next() (path *Path, xpos Scalar, ok bool) {
+	var glyph *Glyph
+	if glyph, xpos, ok = iter.nextGlyph(); ok && !glyph.IsEmpty() {
+		path = iter.cache.FindPath(glyph)
+	}
+	return path, xpos, ok
+}
+
+// nextGlyph returns the next glyph, at the canonical size, and its
+// position along the baseline, and false when all glyphs have been visited.
+func (iter *tTextToPathIter) nextGlyph() (glyph *Glyph, xpos Scalar, ok bool) {
+	if iter.index >= len(iter.glyphs) {
+		return nil, 0, false
+	}
+	iter.cache = iter.glyphs[iter.index].cache
+	glyph = iter.glyphs[iter.index].metrics()
+	iter.index++
+
+	iter.xPos += iter.prevAdvance * iter.scale
+	iter.prevAdvance = glyphAdvance(glyph, iter.vertical)
+	return glyph, iter.xPos, true
+}
+
+// Add the span where glyph, with its origin at (x, y), crosses the band
+// between the lines at bounds[0] and bounds[1] to intervals (which may be
+// nil, to only count the spans), returning the new count. Spans past the end
+// of intervals are counted but not stored.
+func (iter *tTextToPathIter) appendIntercepts(glyph *Glyph, x, y Scalar, bounds [2]Scalar,
+	intervals []Scalar, count int) int {
+	if glyph.IsEmpty() {
+		return count
+	}
+	var scaledBounds = [2]Scalar{(bounds[0] - y) / iter.scale, (bounds[1] - y) / iter.scale}
+	var lo, hi, ok = iter.cache.FindIntercepts(glyph, scaledBounds)
+	if !ok {
+		return count
+	}
+	if count+2 <= len(intervals) {
+		intervals[count] = x + lo*iter.scale
+		intervals[count+1] = x + hi*iter.scale
+	}
+	return count + 2
+}
@@ -0,0 +1,535 @@
+package ggk
+
+import "math"
+
+/** ParagraphAlign
+How the lines of a paragraph are aligned within its width.
+KParagraphAlignJustify stretches the spaces of every line but the last of
+each paragraph to fill the width; the last line is aligned to the start
+of the text direction. */
+type ParagraphAlign int
+
+const (
+	KParagraphAlignLeft = ParagraphAlign(iota)
+	KParagraphAlignRight
+	KParagraphAlignCenter
+	KParagraphAlignJustify
+)
+
+/** ParagraphStyle
+Direction sets the base direction of the text, KTextDirectionAuto takes it
+from the first strong character of each paragraph. MaxLines limits the
+number of lines laid out, 0 for no limit. */
+type ParagraphStyle struct {
+	Align     ParagraphAlign
+	Direction TextDirection
+	MaxLines  int
+}
+
+/** ParagraphLineMetrics
+describes a laid out line. StartIndex and EndIndex are the byte offsets of
+the text of the line, including its trailing whitespace and line break.
+Ascent and Descent are distances above and below the baseline, Left and
+Width the extent of the line without trailing whitespace. */
+type ParagraphLineMetrics struct {
+	StartIndex int
+	EndIndex   int
+	HardBreak  bool
+	Ascent     Scalar
+	Descent    Scalar
+	Height     Scalar
+	Width      Scalar
+	Left       Scalar
+	Baseline   Scalar
+	LineNumber int
+}
+
+type tParagraphChar struct {
+	char        Unichar
+	offset      int // byte offset in the text.
+	size        int // size in bytes.
+	glyph       tTextGlyph
+	mirrorGlyph tTextGlyph // glyph drawn at odd levels.
+	advance     Scalar
+	level       uint8
+	hidden      bool // formatting characters take no space and aren't drawn.
+	breakBefore bool // a line may break before the char.
+}
+
+// tParagraphRange is a paragraph of the text ended by a paragraph
+// separator (or the end of the text), with its base level.
+type tParagraphRange struct {
+	start, contentEnd, end int // char indices; [contentEnd, end) are the separator.
+	level                  uint8
+}
+
+type tParagraphLine struct {
+	start, end int     // char indices, end includes the line break.
+	levels     []uint8 // the levels of the chars without the line break.
+	visual     []int   // the chars without the line break, in visual order.
+	x          []Scalar
+	width      Scalar
+	left       Scalar
+	baseline   Scalar
+	hardBreak  bool
+}
+
+/** Paragraph
+lays out text in lines within a width: lines break at line break
+opportunities (after spaces and hyphens, and between ideographs) or at
+paragraph separators, each paragraph is ordered by the Unicode
+Bidirectional Algorithm, and lines are aligned as the style asks. Text
+is measured and drawn with a single paint.
+
+	var paragraph = NewParagraph(text, paint, ParagraphStyle{Align: KParagraphAlignJustify})
+	paragraph.Layout(width)
+	paragraph.Draw(canvas, x, y) */
+type Paragraph struct {
+	text       string
+	paint      *Paint
+	style      ParagraphStyle
+	chars      []tParagraphChar
+	paragraphs []tParagraphRange
+	metrics    PaintFontMetrics
+
+	lines            []tParagraphLine
+	width            Scalar
+	longestLine      Scalar
+	exceededMaxLines bool
+}
+
+func NewParagraph(text string, paint *Paint, style ParagraphStyle) *Paragraph {
+	var p = &Paragraph{
+		text:  text,
+		paint: paint.Clone(),
+		style: style,
+	}
+	p.paint.SetTextEncoding(KPaintTextEncodingUTF8)
+	p.paint.SetTextAlign(KPaintAlignLeft)
+	p.paint.SetVerticalText(false)
+	p.paint.FontMetrics(&p.metrics, 0)
+
+	var widths = make([]Scalar, len(text))
+	var count = p.paint.TextWidths(text, len(text), widths, nil)
+	// glyphs missing from the paint's typeface come from fallbacks, like
+	// their widths.
+	var cache = p.paint.detachCache(nil, nil)
+	var glyphs = p.paint.textToFallbackGlyphs(cache, text)
+	for offset, r := range text {
+		var c = Unichar(r)
+		var i = len(p.chars)
+		var class = bidiClassOf(c)
+		var char = tParagraphChar{
+			char:        c,
+			offset:      offset,
+			glyph:       glyphs[i],
+			mirrorGlyph: glyphs[i],
+			hidden:      isBidiRemoved(class) || class == kBidiB || isBidiIsolateInitiator(class) || class == kBidiPDI,
+		}
+		if i < count && !char.hidden {
+			char.advance = widths[i]
+		}
+		if m := bidiMirror(c); m != c {
+			char.mirrorGlyph = p.paint.textToFallbackGlyphs(cache, string(rune(m)))[0]
+		}
+		if i > 0 {
+			p.chars[i-1].size = offset - p.chars[i-1].offset
+			char.breakBefore = lineBreakAllowed(p.chars[i-1].char, c)
+		}
+		p.chars = append(p.chars, char)
+	}
+	if n := len(p.chars); n > 0 {
+		p.chars[n-1].size = len(text) - p.chars[n-1].offset
+	}
+	p.resolveParagraphs()
+	return p
+}
+
+// Split the text at paragraph separators and resolve the bidi levels of
+// each paragraph.
+func (p *Paragraph) resolveParagraphs() {
+	for start := 0; ; {
+		var i = start
+		for i < len(p.chars) && bidiClassOf(p.chars[i].char) != kBidiB {
+			i++
+		}
+		var end = i
+		if i < len(p.chars) {
+			end++
+			if p.chars[i].char == '\r' && end < len(p.chars) && p.chars[end].char == '\n' {
+				end++
+			}
+		}
+		var chars = make([]Unichar, end-start)
+		for k := range chars {
+			chars[k] = p.chars[start+k].char
+		}
+		var levels = BidiResolveLevels(chars, p.style.Direction)
+		for k, level := range levels {
+			p.chars[start+k].level = level
+		}
+		p.paragraphs = append(p.paragraphs, tParagraphRange{
+			start:      start,
+			contentEnd: i,
+			end:        end,
+			level:      BidiParagraphLevel(chars, p.style.Direction),
+		})
+		if i == len(p.chars) {
+			break
+		}
+		start = end
+	}
+}
+
+func isParagraphSpace(c Unichar) bool {
+	var class = bidiClassOf(c)
+	return class == kBidiWS || class == kBidiS
+}
+
+func isIdeographic(c Unichar) bool {
+	return (c >= 0x2E80 && c <= 0x9FFF) || (c >= 0xAC00 && c <= 0xD7AF) ||
+		(c >= 0xF900 && c <= 0xFAFF) || (c >= 0x20000 && c <= 0x3FFFF)
+}
+
+// Punctuation that must not start or end a line next to ideographs.
+const (
+	kLineBreakClosing = ")]}>,.!?:;%、。，．！？：；）」』〉》】"
+	kLineBreakOpening = "([{<「『〈《【（"
+)
+
+func containsUnichar(s string, c Unichar) bool {
+	for _, r := range s {
+		if Unichar(r) == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Return whether a line may break between prev and c, a simplification of
+// the Unicode line breaking algorithm (UAX #14).
+func lineBreakAllowed(prev, c Unichar) bool {
+	switch {
+	case isParagraphSpace(c):
+		return false
+	case isParagraphSpace(prev), prev == 0x200B:
+		return true
+	case prev == '-' || prev == 0x2010 || prev == 0x2013 || prev == 0x00AD:
+		// not inside numbers like -1.
+		var class = bidiClassOf(c)
+		return class != kBidiEN && class != kBidiCS && !containsUnichar(kLineBreakClosing, c)
+	case isIdeographic(prev) || isIdeographic(c):
+		return !containsUnichar(kLineBreakClosing, c) && !containsUnichar(kLineBreakOpening, prev)
+	}
+	return false
+}
+
+/** Layout
+Break the text into lines no wider than width, and align them. Pass an
+infinite width for lines broken only at paragraph separators, aligned
+within the longest line. */
+func (p *Paragraph) Layout(width Scalar) {
+	p.lines = p.lines[:0]
+	p.width = width
+	p.longestLine = 0
+	p.exceededMaxLines = false
+
+	for _, para := range p.paragraphs {
+		var start = para.start
+		for {
+			if p.style.MaxLines > 0 && len(p.lines) == p.style.MaxLines {
+				p.exceededMaxLines = true
+				break
+			}
+			var brk = p.breakLine(start, para.contentEnd, width)
+			var line = tParagraphLine{start: start, end: brk}
+			if brk == para.contentEnd {
+				line.end = para.end
+				line.hardBreak = para.end > para.contentEnd
+			}
+			p.lines = append(p.lines, line)
+			p.orderLine(&p.lines[len(p.lines)-1], para)
+			start = brk
+			if start >= para.contentEnd {
+				break
+			}
+		}
+		if p.exceededMaxLines {
+			break
+		}
+	}
+
+	var alignWidth = width
+	if math.IsInf(float64(width), 1) {
+		alignWidth = p.longestLine
+	}
+	var spacing = p.metrics.Descent - p.metrics.Ascent + p.metrics.Leading
+	for i := range p.lines {
+		var line = &p.lines[i]
+		var para = p.paragraphOf(line.start)
+		var align = p.style.Align
+		if align == KParagraphAlignJustify && line.end >= para.end {
+			align = KParagraphAlignLeft
+			if para.level&1 != 0 {
+				align = KParagraphAlignRight
+			}
+		}
+		switch align {
+		case KParagraphAlignRight:
+			line.left = alignWidth - line.width
+		case KParagraphAlignCenter:
+			line.left = (alignWidth - line.width) / 2
+		case KParagraphAlignJustify:
+			p.justifyLine(line, alignWidth)
+		}
+		line.baseline = Scalar(i)*spacing - p.metrics.Ascent
+	}
+}
+
+// Return the end of the line starting at start, in a paragraph whose
+// content ends at end: the last break opportunity that fits width, after
+// any whitespace there, which may hang past width.
+func (p *Paragraph) breakLine(start, end int, width Scalar) int {
+	var fit = start
+	var w Scalar = 0
+	for fit < end && w+p.chars[fit].advance <= width {
+		w += p.chars[fit].advance
+		fit++
+	}
+	if fit == end {
+		return end
+	}
+	if isParagraphSpace(p.chars[fit].char) {
+		for fit < end && isParagraphSpace(p.chars[fit].char) {
+			fit++
+		}
+		return fit
+	}
+	for brk := fit; brk > start; brk-- {
+		if p.chars[brk].breakBefore {
+			return brk
+		}
+	}
+	// a word longer than the line breaks anywhere.
+	if fit == start {
+		return start + 1
+	}
+	return fit
+}
+
+func (p *Paragraph) paragraphOf(char int) tParagraphRange {
+	for _, para := range p.paragraphs {
+		if char < para.end {
+			return para
+		}
+	}
+	return p.paragraphs[len(p.paragraphs)-1]
+}
+
+// Return the end of the line's content without trailing whitespace.
+func (p *Paragraph) trimmedEnd(line *tParagraphLine, contentEnd int) int {
+	var end = contentEnd
+	for end > line.start && (isParagraphSpace(p.chars[end-1].char) || p.chars[end-1].hidden) {
+		end--
+	}
+	return end
+}
+
+// Order the chars of line visually and position them from 0. Trailing
+// whitespace takes no space.
+func (p *Paragraph) orderLine(line *tParagraphLine, para tParagraphRange) {
+	var contentEnd = line.end
+	if contentEnd > para.contentEnd {
+		contentEnd = para.contentEnd
+	}
+	var chars = make([]Unichar, contentEnd-line.start)
+	var levels = make([]uint8, len(chars))
+	for k := range chars {
+		chars[k] = p.chars[line.start+k].char
+		levels[k] = p.chars[line.start+k].level
+	}
+	line.levels = BidiLineLevels(chars, levels, para.level)
+	line.visual = BidiVisualOrder(line.levels)
+	for k := range line.visual {
+		line.visual[k] += line.start
+	}
+	p.positionLine(line, contentEnd, 0)
+	if line.width > p.longestLine {
+		p.longestLine = line.width
+	}
+}
+
+// Position the visual chars of line, adding extra to the advance of each
+// space before the trailing whitespace.
+func (p *Paragraph) positionLine(line *tParagraphLine, contentEnd int, extra Scalar) {
+	var trimmed = p.trimmedEnd(line, contentEnd)
+	line.x = make([]Scalar, len(line.visual))
+	var x Scalar = 0
+	for k, i := range line.visual {
+		line.x[k] = x
+		if i < trimmed {
+			x += p.chars[i].advance
+			if isParagraphSpace(p.chars[i].char) {
+				x += extra
+			}
+		}
+	}
+	line.width = x
+}
+
+// Stretch the inner spaces of line so it fills width.
+func (p *Paragraph) justifyLine(line *tParagraphLine, width Scalar) {
+	var contentEnd = line.start + len(line.visual)
+	var trimmed = p.trimmedEnd(line, contentEnd)
+	var spaces = 0
+	for i := line.start; i < trimmed; i++ {
+		if isParagraphSpace(p.chars[i].char) {
+			spaces++
+		}
+	}
+	if spaces == 0 || line.width >= width {
+		return
+	}
+	p.positionLine(line, contentEnd, (width-line.width)/Scalar(spaces))
+}
+
+// Width returns the width the paragraph was laid out in.
+func (p *Paragraph) Width() Scalar {
+	return p.width
+}
+
+// Height returns the height of the laid out lines.
+func (p *Paragraph) Height() Scalar {
+	return Scalar(len(p.lines)) * (p.metrics.Descent - p.metrics.Ascent + p.metrics.Leading)
+}
+
+// LongestLine returns the width of the widest line, without its trailing
+// whitespace.
+func (p *Paragraph) LongestLine() Scalar {
+	return p.longestLine
+}
+
+func (p *Paragraph) LineCount() int {
+	return len(p.lines)
+}
+
+// DidExceedMaxLines returns whether lines were left out because of the
+// style's MaxLines.
+func (p *Paragraph) DidExceedMaxLines() bool {
+	return p.exceededMaxLines
+}
+
+func (p *Paragraph) charOffset(i int) int {
+	if i >= len(p.chars) {
+		return len(p.text)
+	}
+	return p.chars[i].offset
+}
+
+func (p *Paragraph) LineMetrics() []ParagraphLineMetrics {
+	var metrics = make([]ParagraphLineMetrics, len(p.lines))
+	for i, line := range p.lines {
+		metrics[i] = ParagraphLineMetrics{
+			StartIndex: p.charOffset(line.start),
+			EndIndex:   p.charOffset(line.end),
+			HardBreak:  line.hardBreak,
+			Ascent:     -p.metrics.Ascent,
+			Descent:    p.metrics.Descent,
+			Height:     p.metrics.Descent - p.metrics.Ascent + p.metrics.Leading,
+			Width:      line.width,
+			Left:       line.left,
+			Baseline:   line.baseline,
+			LineNumber: i,
+		}
+	}
+	return metrics
+}
+
+/** Draw
+Draw the laid out lines on canvas, with the top left of the paragraph at
+(x, y). Right to left characters with mirror images, like parentheses,
+are drawn mirrored. */
+func (p *Paragraph) Draw(canvas *Canvas, x, y Scalar) {
+	var builder = NewTextBlobBuilder()
+	for _, line := range p.lines {
+		var glyphs []tTextGlyph
+		var xs []Scalar
+		for v, i := range line.visual {
+			var char = &p.chars[i]
+			if char.hidden {
+				continue
+			}
+			var glyph = char.glyph
+			if line.levels[i-line.start]&1 != 0 {
+				glyph = char.mirrorGlyph
+			}
+			glyphs = append(glyphs, glyph)
+			xs = append(xs, line.left+line.x[v])
+		}
+
+		// a run per typeface, as fallback glyphs are those of their own
+		// typeface.
+		for start := 0; start < len(glyphs); {
+			var typeface = glyphs[start].typeface
+			var end = start + 1
+			for end < len(glyphs) && glyphs[end].typeface == typeface {
+				end++
+			}
+			var runPaint = p.paint
+			if typeface != p.paint.Typeface() {
+				runPaint = p.paint.Clone()
+				runPaint.SetTypeface(typeface)
+			}
+			var run = builder.AllocRunPosH(runPaint, end-start, line.baseline, nil)
+			for k := start; k < end; k++ {
+				run.Glyphs[k-start] = glyphs[k].id
+				run.Pos[k-start] = xs[k]
+			}
+			start = end
+		}
+	}
+	if blob := builder.Make(); blob != nil {
+		canvas.DrawTextBlob(blob, x, y, p.paint)
+	}
+}
+
+/** OffsetForPosition
+Return the byte offset in the text of the caret position closest to
+(x, y), relative to the top left of the paragraph. */
+func (p *Paragraph) OffsetForPosition(x, y Scalar) int {
+	if len(p.lines) == 0 {
+		return 0
+	}
+	var spacing = p.metrics.Descent - p.metrics.Ascent + p.metrics.Leading
+	var index = int(ScalarFloor(y / spacing))
+	if index < 0 {
+		index = 0
+	} else if index >= len(p.lines) {
+		index = len(p.lines) - 1
+	}
+	var line = &p.lines[index]
+	if len(line.visual) == 0 {
+		return p.charOffset(line.start)
+	}
+	x -= line.left
+
+	// the caret goes before the char under x if x is in its first half,
+	// where "before" depends on the char's direction.
+	var caret = func(i int, after bool) int {
+		var rtl = line.levels[i-line.start]&1 != 0
+		if after != rtl {
+			return p.chars[i].offset + p.chars[i].size
+		}
+		return p.chars[i].offset
+	}
+	for k, i := range line.visual {
+		var advance = p.chars[i].advance
+		if k+1 < len(line.x) {
+			advance = line.x[k+1] - line.x[k]
+		}
+		if x < line.x[k]+advance/2 {
+			return caret(i, false)
+		}
+	}
+	return caret(line.visual[len(line.visual)-1], true)
+}
@@ -0,0 +1,134 @@
+package ggk
+
+import (
+	"math"
+	"testing"
+)
+
+func newParagraphTestPaint(t *testing.T) *Paint {
+	var paint = NewPaint()
+	paint.SetTypeface(newTestTypeface(t))
+	paint.SetTextSize(10)
+	paint.SetHinting(KPaintHintingNo)
+	return paint
+}
+
+// The test font has no space or Hebrew glyphs; they are drawn as .notdef,
+// which is 5 pixels wide at size 10. 'l' is 3.6 and 'o' 6 pixels wide.
+func TestParagraphLayout(t *testing.T) {
+	var paint = newParagraphTestPaint(t)
+	type tLine struct {
+		start, end  int
+		hardBreak   bool
+		left, width Scalar
+	}
+	var tests = []struct {
+		name  string
+		text  string
+		style ParagraphStyle
+		width Scalar
+		lines []tLine
+	}{
+		{"left", "lo lo lo", ParagraphStyle{}, 25,
+			[]tLine{{0, 6, false, 0, 24.2}, {6, 8, false, 0, 9.6}}},
+		{"right", "lo lo lo", ParagraphStyle{Align: KParagraphAlignRight}, 25,
+			[]tLine{{0, 6, false, 0.8, 24.2}, {6, 8, false, 15.4, 9.6}}},
+		{"center", "lo lo lo", ParagraphStyle{Align: KParagraphAlignCenter}, 25,
+			[]tLine{{0, 6, false, 0.4, 24.2}, {6, 8, false, 7.7, 9.6}}},
+		{"justify", "lo lo lo", ParagraphStyle{Align: KParagraphAlignJustify}, 25,
+			[]tLine{{0, 6, false, 0, 25}, {6, 8, false, 0, 9.6}}},
+		{"justify right to left", "lo lo lo", ParagraphStyle{Align: KParagraphAlignJustify,
+			Direction: KTextDirectionRTL}, 25,
+			[]tLine{{0, 6, false, 0, 25}, {6, 8, false, 15.4, 9.6}}},
+		{"hard break", "lo\nlo", ParagraphStyle{}, Scalar(math.Inf(1)),
+			[]tLine{{0, 3, true, 0, 9.6}, {3, 5, false, 0, 9.6}}},
+		{"trailing hard break", "lo\n", ParagraphStyle{}, 100,
+			[]tLine{{0, 3, true, 0, 9.6}, {3, 3, false, 0, 0}}},
+		{"hyphen", "lo-lo", ParagraphStyle{}, 15,
+			[]tLine{{0, 3, false, 0, 14.6}, {3, 5, false, 0, 9.6}}},
+		{"long word", "llllll", ParagraphStyle{}, 10,
+			[]tLine{{0, 2, false, 0, 7.2}, {2, 4, false, 0, 7.2}, {4, 6, false, 0, 7.2}}},
+		{"max lines", "llllll", ParagraphStyle{MaxLines: 2}, 10,
+			[]tLine{{0, 2, false, 0, 7.2}, {2, 4, false, 0, 7.2}}},
+		{"empty", "", ParagraphStyle{}, 10,
+			[]tLine{{0, 0, false, 0, 0}}},
+	}
+	for _, test := range tests {
+		var paragraph = NewParagraph(test.text, paint, test.style)
+		paragraph.Layout(test.width)
+		var metrics = paragraph.LineMetrics()
+		if len(metrics) != len(test.lines) {
+			t.Errorf("%v lines want %v got %v", test.name, len(test.lines), len(metrics))
+			continue
+		}
+		for i, want := range test.lines {
+			var got = metrics[i]
+			if got.StartIndex != want.start || got.EndIndex != want.end || got.HardBreak != want.hardBreak ||
+				ScalarAbs(got.Left-want.left) > 1e-3 || ScalarAbs(got.Width-want.width) > 1e-3 {
+				t.Errorf("%v line %v want %+v got %+v", test.name, i, want, got)
+			}
+			if ScalarAbs(got.Baseline-(8+11*Scalar(i))) > 1e-3 || got.Ascent != 8 || got.Descent != 2 {
+				t.Errorf("%v line %v want baseline %v ascent 8 descent 2 got %+v", test.name, i,
+					8+11*Scalar(i), got)
+			}
+		}
+		if ScalarAbs(paragraph.Height()-11*Scalar(len(test.lines))) > 1e-3 {
+			t.Errorf("%v height want %v got %v", test.name, 11*len(test.lines), paragraph.Height())
+		}
+		if paragraph.DidExceedMaxLines() != (test.style.MaxLines > 0) {
+			t.Errorf("%v DidExceedMaxLines() want %v", test.name, test.style.MaxLines > 0)
+		}
+	}
+}
+
+func TestParagraphOffsetForPosition(t *testing.T) {
+	var paint = newParagraphTestPaint(t)
+	var tests = []struct {
+		name string
+		text string
+		x, y Scalar
+		want int
+	}{
+		{"before text", "lo lo", -5, 5, 0},
+		{"first half", "lo lo", 1, 5, 0},
+		{"second half", "lo lo", 3, 5, 1},
+		{"before space", "lo lo", 7, 5, 2},
+		{"after text", "lo lo", 100, 5, 5},
+		{"below text", "lo lo", 100, 100, 5},
+		{"second line", "lo\nlo", 5, 15, 4},
+		{"right to left start", "אב", 9, 5, 0},
+		{"right to left middle", "אב", 6, 5, 2},
+		{"right to left end", "אב", 1, 5, 4},
+		{"mixed", "lo אב", 16, 5, 7},
+	}
+	for _, test := range tests {
+		var paragraph = NewParagraph(test.text, paint, ParagraphStyle{})
+		paragraph.Layout(100)
+		if got := paragraph.OffsetForPosition(test.x, test.y); got != test.want {
+			t.Errorf("%v OffsetForPosition(%v, %v) want %v got %v", test.name, test.x, test.y, test.want, got)
+		}
+	}
+}
+
+func TestParagraphBreakText(t *testing.T) {
+	var paint = newParagraphTestPaint(t)
+	var tests = []struct {
+		text     string
+		maxWidth Scalar
+		want     int
+		width    Scalar
+	}{
+		{"lolo", 0, 0, 0},
+		{"lolo", 10, 2, 9.6},
+		{"lolo", 100, 4, 19.2},
+		{"lo", 3.7, 1, 3.6},
+	}
+	for _, test := range tests {
+		var width Scalar
+		var n = paint.BreakText(test.text, len(test.text), test.maxWidth, &width)
+		if n != test.want || ScalarAbs(width-test.width) > 1e-3 {
+			t.Errorf("BreakText(%q, %v) want %v, %v got %v, %v", test.text, test.maxWidth,
+				test.want, test.width, n, width)
+		}
+	}
+}
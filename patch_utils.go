@@ -0,0 +1,201 @@
+package ggk
+
+// The control points of a patch: 4 cubics in clockwise order starting at
+// the top left corner, which share their end points.
+const (
+	KPatchUtilsNumCtrlPts  = 12
+	KPatchUtilsNumCorners  = 4
+	KPatchUtilsNumPtsCubic = 4
+)
+
+// The corners of a patch, for its colors and texture coordinates.
+const (
+	KPatchUtilsCornerTopLeft = iota
+	KPatchUtilsCornerTopRight
+	KPatchUtilsCornerBottomRight
+	KPatchUtilsCornerBottomLeft
+)
+
+// The length in device pixels of the segments a patch is tessellated in,
+// and the fewest segments per side.
+const (
+	kPatchUtilsPartitionSize    = 10
+	kPatchUtilsMinLevelOfDetail = 8
+)
+
+// The most vertices of a mesh, so that its indices fit in uint16.
+const kPatchUtilsMaxVertexCount = 1 << 16
+
+// PatchUtilsTopCubic returns the top cubic of the patch, from left to right.
+func PatchUtilsTopCubic(cubics *[12]Point) [4]Point {
+	return [4]Point{cubics[0], cubics[1], cubics[2], cubics[3]}
+}
+
+// PatchUtilsBottomCubic returns the bottom cubic of the patch, from left
+// to right.
+func PatchUtilsBottomCubic(cubics *[12]Point) [4]Point {
+	return [4]Point{cubics[9], cubics[8], cubics[7], cubics[6]}
+}
+
+// PatchUtilsLeftCubic returns the left cubic of the patch, from top to
+// bottom.
+func PatchUtilsLeftCubic(cubics *[12]Point) [4]Point {
+	return [4]Point{cubics[0], cubics[11], cubics[10], cubics[9]}
+}
+
+// PatchUtilsRightCubic returns the right cubic of the patch, from top to
+// bottom.
+func PatchUtilsRightCubic(cubics *[12]Point) [4]Point {
+	return [4]Point{cubics[3], cubics[4], cubics[5], cubics[6]}
+}
+
+// Approximate the length of a cubic by the length of its control polygon.
+func approxCubicLength(pts [4]Point) Scalar {
+	var length Scalar
+	for i := 1; i < len(pts); i++ {
+		length += PointDistance(pts[i-1], pts[i])
+	}
+	return length
+}
+
+/** PatchUtilsLevelOfDetail
+Return the number of segments to tessellate the patch in, horizontally and
+vertically, from the size of the patch drawn through matrix, which may be
+nil. */
+func PatchUtilsLevelOfDetail(cubics *[12]Point, matrix *Matrix) (lodX, lodY int) {
+	var lengths [4]Scalar
+	var sides = [4][4]Point{
+		PatchUtilsTopCubic(cubics), PatchUtilsBottomCubic(cubics),
+		PatchUtilsLeftCubic(cubics), PatchUtilsRightCubic(cubics),
+	}
+	for i := range sides {
+		if matrix != nil {
+			matrix.MapPoints(sides[i][:], sides[i][:])
+		}
+		lengths[i] = approxCubicLength(sides[i])
+	}
+
+	// based on the longer of the opposite sides.
+	lodX = int(ScalarMax(lengths[0], lengths[1]) / kPatchUtilsPartitionSize)
+	lodY = int(ScalarMax(lengths[2], lengths[3]) / kPatchUtilsPartitionSize)
+	if lodX < kPatchUtilsMinLevelOfDetail {
+		lodX = kPatchUtilsMinLevelOfDetail
+	}
+	if lodY < kPatchUtilsMinLevelOfDetail {
+		lodY = kPatchUtilsMinLevelOfDetail
+	}
+	return lodX, lodY
+}
+
+/** PatchUtilsVertexData
+is the mesh of triangles a patch is tessellated in, to draw with
+Canvas.DrawVertices in KCanvasVertexModeTriangles. Colors and TexCoords are
+nil if the patch has none. */
+type PatchUtilsVertexData struct {
+	VertexCount int
+	Points      []Point
+	Colors      []Color
+	TexCoords   []Point
+	IndexCount  int
+	Indices     []uint16
+}
+
+// Return the bilinear interpolation of the corners of a patch at (u, v).
+func bilerpScalar(u, v Scalar, topLeft, topRight, bottomLeft, bottomRight Scalar) Scalar {
+	return (1-v)*((1-u)*topLeft+u*topRight) + v*((1-u)*bottomLeft+u*bottomRight)
+}
+
+func bilerpPoint(u, v Scalar, corners *[4]Point) Point {
+	return Point{
+		bilerpScalar(u, v, corners[KPatchUtilsCornerTopLeft].X, corners[KPatchUtilsCornerTopRight].X,
+			corners[KPatchUtilsCornerBottomLeft].X, corners[KPatchUtilsCornerBottomRight].X),
+		bilerpScalar(u, v, corners[KPatchUtilsCornerTopLeft].Y, corners[KPatchUtilsCornerTopRight].Y,
+			corners[KPatchUtilsCornerBottomLeft].Y, corners[KPatchUtilsCornerBottomRight].Y),
+	}
+}
+
+func bilerpColor(u, v Scalar, corners *[4]Color) Color {
+	var channel = func(shift uint) uint8 {
+		var c = func(corner int) Scalar {
+			return Scalar((corners[corner] >> shift) & 0xff)
+		}
+		var value = bilerpScalar(u, v, c(KPatchUtilsCornerTopLeft), c(KPatchUtilsCornerTopRight),
+			c(KPatchUtilsCornerBottomLeft), c(KPatchUtilsCornerBottomRight))
+		return uint8(ScalarRoundToInt(ScalarPin(value, 0, 255)))
+	}
+	return ColorWithARGB(channel(24), channel(16), channel(8), channel(0))
+}
+
+/** NewPatchUtilsVertexData
+Return the mesh tessellating the Coons patch of cubics in lodX by lodY
+quads, each made of 2 triangles. The colors and the texture coordinates of
+the corners, which may be nil, are interpolated across the patch. The
+level of detail is lowered if the mesh has too many vertices for uint16
+indices. Returns nil if lodX or lodY is less than 1. */
+func NewPatchUtilsVertexData(cubics *[12]Point, colors *[4]Color, texCoords *[4]Point, lodX, lodY int) *PatchUtilsVertexData {
+	if lodX < 1 || lodY < 1 || cubics == nil {
+		return nil
+	}
+	if count := (lodX + 1) * (lodY + 1); count > kPatchUtilsMaxVertexCount {
+		// keep the proportions of the level of detail.
+		var scale = ScalarSqrt(Scalar(kPatchUtilsMaxVertexCount) / Scalar(count))
+		lodX = int(Scalar(lodX+1)*scale) - 1
+		lodY = int(Scalar(lodY+1)*scale) - 1
+		if lodX < 1 || lodY < 1 {
+			return nil
+		}
+	}
+
+	var data = &PatchUtilsVertexData{
+		VertexCount: (lodX + 1) * (lodY + 1),
+		IndexCount:  lodX * lodY * 6,
+	}
+	data.Points = make([]Point, data.VertexCount)
+	data.Indices = make([]uint16, data.IndexCount)
+	if colors != nil {
+		data.Colors = make([]Color, data.VertexCount)
+	}
+	if texCoords != nil {
+		data.TexCoords = make([]Point, data.VertexCount)
+	}
+
+	var top, bottom = PatchUtilsTopCubic(cubics), PatchUtilsBottomCubic(cubics)
+	var left, right = PatchUtilsLeftCubic(cubics), PatchUtilsRightCubic(cubics)
+	var corners = [4]Point{cubics[0], cubics[3], cubics[6], cubics[9]}
+	var stride = lodY + 1
+	for x := 0; x <= lodX; x++ {
+		var u = Scalar(x) / Scalar(lodX)
+		var topPt, _ = EvalCubicAt(top[:], u)
+		var bottomPt, _ = EvalCubicAt(bottom[:], u)
+		for y := 0; y <= lodY; y++ {
+			var v = Scalar(y) / Scalar(lodY)
+			var leftPt, _ = EvalCubicAt(left[:], v)
+			var rightPt, _ = EvalCubicAt(right[:], v)
+
+			// the Coons patch adds the lerps between opposite sides, minus
+			// the bilerp of the corners they both count.
+			var s0 = interpPoint(topPt, bottomPt, v)
+			var s1 = interpPoint(leftPt, rightPt, u)
+			var s2 = bilerpPoint(u, v, &corners)
+			var index = x*stride + y
+			data.Points[index] = Point{s0.X + s1.X - s2.X, s0.Y + s1.Y - s2.Y}
+			if colors != nil {
+				data.Colors[index] = bilerpColor(u, v, colors)
+			}
+			if texCoords != nil {
+				data.TexCoords[index] = bilerpPoint(u, v, texCoords)
+			}
+
+			if x < lodX && y < lodY {
+				var i = 6 * (x*lodY + y)
+				data.Indices[i] = uint16(x*stride + y)
+				data.Indices[i+1] = uint16(x*stride + 1 + y)
+				data.Indices[i+2] = uint16((x+1)*stride + 1 + y)
+				data.Indices[i+3] = data.Indices[i]
+				data.Indices[i+4] = data.Indices[i+2]
+				data.Indices[i+5] = uint16((x+1)*stride + y)
+			}
+		}
+	}
+	return data
+}
@@ -0,0 +1,126 @@
+package ggk
+
+import "testing"
+
+// Return the cubics of a patch of the rect with straight sides.
+func newTestPatchCubics(rect Rect) [12]Point {
+	var l, t, r, b = rect.Left, rect.Top, rect.R(), rect.B()
+	var w, h = rect.Width / 3, rect.Height / 3
+	return [12]Point{
+		{l, t}, {l + w, t}, {l + 2*w, t}, // top
+		{r, t}, {r, t + h}, {r, t + 2*h}, // right
+		{r, b}, {r - w, b}, {r - 2*w, b}, // bottom
+		{l, b}, {l, b - h}, {l, b - 2*h}, // left
+	}
+}
+
+func TestPatchUtilsLevelOfDetail(t *testing.T) {
+	var scale = NewMatrix()
+	scale.SetScale(2, 3)
+	var tests = []struct {
+		rect       Rect
+		matrix     *Matrix
+		lodX, lodY int
+	}{
+		{MakeRect(0, 0, 20, 20), nil, 8, 8},
+		{MakeRect(0, 0, 200, 100), nil, 20, 10},
+		{MakeRect(10, 10, 200, 100), scale, 40, 30},
+	}
+	for _, test := range tests {
+		var cubics = newTestPatchCubics(test.rect)
+		if lodX, lodY := PatchUtilsLevelOfDetail(&cubics, test.matrix); lodX != test.lodX || lodY != test.lodY {
+			t.Errorf("PatchUtilsLevelOfDetail(%v) want %v, %v got %v, %v", test.rect, test.lodX, test.lodY, lodX, lodY)
+		}
+	}
+}
+
+func TestPatchUtilsVertexData(t *testing.T) {
+	var cubics = newTestPatchCubics(MakeRect(0, 0, 30, 60))
+	if NewPatchUtilsVertexData(&cubics, nil, nil, 0, 4) != nil {
+		t.Errorf("want no vertex data without level of detail")
+	}
+	var colors = [4]Color{KColorRed, KColorRed, KColorBlue, KColorBlue}
+	var texs = [4]Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+	var data = NewPatchUtilsVertexData(&cubics, &colors, &texs, 3, 2)
+	if data.VertexCount != 12 || data.IndexCount != 36 || len(data.Indices) != 36 || len(data.Colors) != 12 {
+		t.Fatalf("want 12 vertices and 36 indices got %v and %v", data.VertexCount, data.IndexCount)
+	}
+	// the vertex at u = 1/3, v = 1/2.
+	var stride = 3
+	var i = 1*stride + 1
+	if got := data.Points[i]; ScalarAbs(got.X-10) > 1e-4 || ScalarAbs(got.Y-30) > 1e-4 {
+		t.Errorf("want the vertex at (10, 30) got %v", got)
+	}
+	if got := data.TexCoords[i]; ScalarAbs(got.X-Scalar(1)/3) > 1e-4 || got.Y != 0.5 {
+		t.Errorf("want the texture coordinate (1/3, 1/2) got %v", got)
+	}
+	var half = func(c uint8) bool { return c == 0x7f || c == 0x80 }
+	if got := data.Colors[i]; got.Alpha() != 0xff || !half(got.Red()) || got.Green() != 0 || !half(got.Blue()) {
+		t.Errorf("want half red and half blue got %#x", got)
+	}
+	for _, index := range data.Indices {
+		if int(index) >= data.VertexCount {
+			t.Fatalf("want indices of vertices got %v", index)
+		}
+	}
+
+	var huge = NewPatchUtilsVertexData(&cubics, nil, nil, 1000, 1000)
+	if huge.VertexCount > 1<<16 || huge.Colors != nil || huge.TexCoords != nil {
+		t.Errorf("want at most %v vertices got %v", 1<<16, huge.VertexCount)
+	}
+}
+
+func TestDrawPatch(t *testing.T) {
+	var red = PackARGB32(0xff, 0xff, 0, 0)
+	var square = newTestPatchCubics(MakeRect(2, 2, 12, 12))
+	// the top side bulges up by 3 pixels at its middle.
+	var bulge = square
+	bulge[1].Y, bulge[2].Y = -2, -2
+
+	var allRed = [4]Color{KColorRed, KColorRed, KColorRed, KColorRed}
+	var topRed = [4]Color{KColorRed, KColorRed, KColorBlue, KColorBlue}
+	var tests = []struct {
+		name   string
+		cubics [12]Point
+		colors *[4]Color
+		points []Point
+		want   []uint32
+	}{
+		{"square", square, &allRed, []Point{{2, 2}, {13, 13}, {8, 1}, {14, 8}, {1, 8}}, []uint32{red, red, 0, 0, 0}},
+		{"bulge", bulge, &allRed, []Point{{8, 0}, {8, 1}, {2, 1}}, []uint32{red, red, 0}},
+		{"paint color", square, nil, []Point{{8, 8}}, []uint32{PackARGB32(0xff, 0, 0xff, 0)}},
+	}
+	for _, test := range tests {
+		var draw = func(canvas *Canvas) {
+			canvas.DrawPatch(test.cubics, test.colors, nil, nil, newTestPaint(KColorGreen))
+		}
+		var canvas, pixels = newTestPictureCanvas(16, 16)
+		draw(canvas)
+		var recorder = NewPictureRecorder()
+		draw(recorder.BeginRecording(MakeRect(0, 0, 16, 16)))
+		var replay, replayed = newTestPictureCanvas(16, 16)
+		replay.DrawPicture(recorder.FinishRecordingAsPicture(), nil, nil)
+		for i, point := range test.points {
+			var x, y = int(point.X), int(point.Y)
+			if got := pixels.Pixel32(x, y); got != test.want[i] {
+				t.Errorf("%v at %v want %#x got %#x", test.name, point, test.want[i], got)
+			}
+			if got := replayed.Pixel32(x, y); got != test.want[i] {
+				t.Errorf("%v replayed at %v want %#x got %#x", test.name, point, test.want[i], got)
+			}
+		}
+	}
+
+	// the colors blend smoothly from the top to the bottom.
+	var canvas, pixels = newTestPictureCanvas(16, 16)
+	canvas.DrawPatch(square, &topRed, nil, nil, NewPaint())
+	for y := 3; y < 14; y++ {
+		var prev, p = pixels.Pixel32(8, y-1), pixels.Pixel32(8, y)
+		if GetPackedR32(p) > GetPackedR32(prev) || GetPackedB32(p) < GetPackedB32(prev) {
+			t.Errorf("at y %v want more blue than %#x got %#x", y, prev, p)
+		}
+	}
+	if top, bottom := pixels.Pixel32(8, 2), pixels.Pixel32(8, 13); GetPackedR32(top) < 0xf0 || GetPackedB32(bottom) < 0xf0 {
+		t.Errorf("want red at the top and blue at the bottom got %#x and %#x", top, bottom)
+	}
+}
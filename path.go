@@ -1,4 +1,351 @@
 package ggk
 
+// PathFillType tells how the interior of a path is computed.
+type PathFillType int
+
+const (
+	KPathFillTypeWinding        = PathFillType(iota) //< "inside" is computed by a non-zero sum of signed edge crossings
+	KPathFillTypeEvenOdd                             //< "inside" is computed by an odd number of edge crossings
+	KPathFillTypeInverseWinding                      //< same as Winding, but draws outside of the path, rather than inside
+	KPathFillTypeInverseEvenOdd                      //< same as EvenOdd, but draws outside of the path, rather than inside
+)
+
+// PathVerb is the verb of each segment of a path. Each verb consumes a fixed
+// number of points from the path's point array.
+type PathVerb int
+
+const (
+	KPathVerbMove  = PathVerb(iota) //< iter.next returns 1 point
+	KPathVerbLine                   //< iter.next returns 2 points
+	KPathVerbQuad                   //< iter.next returns 3 points
+	KPathVerbCubic                  //< iter.next returns 4 points
+	KPathVerbClose                  //< iter.next returns 1 point (contour's moveTo pt)
+	KPathVerbDone                   //< iter.next returns 0 points
+)
+
+/** Path
+The Path class encapsulates compound (multiple contour) geometric paths
+consisting of straight line segments, quadratic curves, and cubic curves. */
 type Path struct {
+	verbs    []PathVerb
+	pts      []Point
+	fillType PathFillType
+
+	// index of the most recent MoveTo point, or -1 if the last contour has
+	// been closed and a new MoveTo is required.
+	lastMoveToIndex int
+}
+
+func NewPath() *Path {
+	var path = &Path{}
+	path.Reset()
+	return path
+}
+
+func NewPathClone(otr *Path) *Path {
+	var path = &Path{
+		verbs:           append([]PathVerb(nil), otr.verbs...),
+		pts:             append([]Point(nil), otr.pts...),
+		fillType:        otr.fillType,
+		lastMoveToIndex: otr.lastMoveToIndex,
+	}
+	return path
+}
+
+// Clear any lines and curves from the path, making it empty.
+func (path *Path) Reset() {
+	path.verbs = path.verbs[:0]
+	path.pts = path.pts[:0]
+	path.fillType = KPathFillTypeWinding
+	path.lastMoveToIndex = ^0
+}
+
+func (path *Path) FillType() PathFillType {
+	return path.fillType
+}
+
+func (path *Path) SetFillType(fillType PathFillType) {
+	path.fillType = fillType
+}
+
+// Returns true if the filltype is one of the Inverse variants.
+func (path *Path) IsInverseFillType() bool {
+	return path.fillType&2 != 0
+}
+
+// Returns true if the path is empty (contains no lines or curves).
+func (path *Path) IsEmpty() bool {
+	return len(path.verbs) == 0
+}
+
+func (path *Path) CountPoints() int {
+	return len(path.pts)
+}
+
+func (path *Path) CountVerbs() int {
+	return len(path.verbs)
+}
+
+func (path *Path) Points() []Point {
+	return path.pts
+}
+
+func (path *Path) Verbs() []PathVerb {
+	return path.verbs
+}
+
+// Returns the last point of the path, and false if the path is empty.
+func (path *Path) LastPoint() (Point, bool) {
+	if len(path.pts) == 0 {
+		return PointZero, false
+	}
+	return path.pts[len(path.pts)-1], true
+}
+
+// Returns the bounds of the path's points. If the path contains 0 or 1
+// points, the bounds is set to (0,0,0,0).
+func (path *Path) Bounds() Rect {
+	var bounds Rect
+	if len(path.pts) > 1 {
+		bounds.SetBoundsPoints(path.pts)
+	}
+	return bounds
+}
+
+func (path *Path) injectMoveToIfNeeded() {
+	if path.lastMoveToIndex < 0 {
+		var pt Point
+		if len(path.pts) > 0 {
+			pt = path.pts[^path.lastMoveToIndex]
+		}
+		path.MoveTo(pt.X, pt.Y)
+	}
+}
+
+// Set the beginning of the next contour to the point (x,y).
+func (path *Path) MoveTo(x, y Scalar) {
+	path.lastMoveToIndex = len(path.pts)
+	path.verbs = append(path.verbs, KPathVerbMove)
+	path.pts = append(path.pts, Point{x, y})
+}
+
+// Add a line from the last point to the specified point (x,y). If no
+// MoveTo() call has been made for this contour, the first point is
+// automatically set to (0,0).
+func (path *Path) LineTo(x, y Scalar) {
+	path.injectMoveToIfNeeded()
+	path.verbs = append(path.verbs, KPathVerbLine)
+	path.pts = append(path.pts, Point{x, y})
+}
+
+// Add a quadratic bezier from the last point, approaching control point
+// (x1,y1), and ending at (x2,y2).
+func (path *Path) QuadTo(x1, y1, x2, y2 Scalar) {
+	path.injectMoveToIfNeeded()
+	path.verbs = append(path.verbs, KPathVerbQuad)
+	path.pts = append(path.pts, Point{x1, y1}, Point{x2, y2})
+}
+
+// Add a cubic bezier from the last point, approaching control points
+// (x1,y1) and (x2,y2), and ending at (x3,y3).
+func (path *Path) CubicTo(x1, y1, x2, y2, x3, y3 Scalar) {
+	path.injectMoveToIfNeeded()
+	path.verbs = append(path.verbs, KPathVerbCubic)
+	path.pts = append(path.pts, Point{x1, y1}, Point{x2, y2}, Point{x3, y3})
+}
+
+// Close the current contour. If the current point is not equal to the
+// first point of the contour, a line segment is automatically added.
+func (path *Path) Close() {
+	var count = len(path.verbs)
+	if count > 0 {
+		switch path.verbs[count-1] {
+		case KPathVerbLine, KPathVerbQuad, KPathVerbCubic, KPathVerbMove:
+			path.verbs = append(path.verbs, KPathVerbClose)
+		}
+	}
+
+	// signal that we need a moveTo to follow us (unless we're done).
+	if path.lastMoveToIndex >= 0 {
+		path.lastMoveToIndex = ^path.lastMoveToIndex
+	}
+}
+
+// Add a closed rectangle contour to the path.
+func (path *Path) AddRect(rect Rect) {
+	path.MoveTo(rect.L(), rect.T())
+	path.LineTo(rect.R(), rect.T())
+	path.LineTo(rect.R(), rect.B())
+	path.LineTo(rect.L(), rect.B())
+	path.Close()
+}
+
+// Add a closed polygon contour to the path.
+func (path *Path) AddPoly(pts []Point, close bool) {
+	if len(pts) == 0 {
+		return
+	}
+	path.MoveTo(pts[0].X, pts[0].Y)
+	for _, pt := range pts[1:] {
+		path.LineTo(pt.X, pt.Y)
+	}
+	if close {
+		path.Close()
+	}
+}
+
+// Add a copy of src to the path, transformed by matrix (which may be nil).
+func (path *Path) AddPath(src *Path, matrix *Matrix) {
+	var offset = len(path.pts)
+	path.verbs = append(path.verbs, src.verbs...)
+	path.pts = append(path.pts, src.pts...)
+	if matrix != nil {
+		matrix.MapPoints(path.pts[offset:], path.pts[offset:])
+	}
+	if src.lastMoveToIndex >= 0 {
+		path.lastMoveToIndex = offset + src.lastMoveToIndex
+	} else if len(src.pts) > 0 {
+		path.lastMoveToIndex = ^(offset + ^src.lastMoveToIndex)
+	}
+}
+
+// Offset the path by (dx,dy), returning the result in dst. If dst is nil,
+// the path itself is modified.
+func (path *Path) Offset(dx, dy Scalar, dst *Path) {
+	var matrix Matrix
+	matrix.SetTranslate(dx, dy)
+	path.Transform(&matrix, dst)
+}
+
+// Transform the points in this path by matrix, and write the answer into
+// dst. If dst is nil, the path itself is modified.
+func (path *Path) Transform(matrix *Matrix, dst *Path) {
+	if dst == nil {
+		dst = path
+	} else if dst != path {
+		*dst = *NewPathClone(path)
+	}
+	matrix.MapPoints(dst.pts, dst.pts)
+}
+
+/** PathIter
+Iterate through all of the segments (lines, quadratics, cubics) of each
+contour in a path. The iterator cleans up the segments along the way,
+injecting the implicit closing line of a contour when forceClose is set. */
+type PathIter struct {
+	path       *Path
+	verbIndex  int
+	ptIndex    int
+	moveTo     Point
+	lastPt     Point
+	forceClose bool
+	needClose  bool
+}
+
+func NewPathIter(path *Path, forceClose bool) *PathIter {
+	var iter = &PathIter{
+		path:       path,
+		forceClose: forceClose,
+	}
+	return iter
+}
+
+// If IsClosedContour returns true, then this contour was either closed with
+// Close(), or the iterator was created with forceClose.
+func (iter *PathIter) IsClosedContour() bool {
+	if iter.forceClose {
+		return true
+	}
+	for i := iter.verbIndex; i < len(iter.path.verbs); i++ {
+		switch iter.path.verbs[i] {
+		case KPathVerbMove:
+			if i > iter.verbIndex {
+				return false
+			}
+		case KPathVerbClose:
+			return true
+		}
+	}
+	return false
+}
+
+func (iter *PathIter) autoClose(pts []Point) PathVerb {
+	if !iter.lastPt.Equal(iter.moveTo) {
+		pts[0] = iter.lastPt
+		pts[1] = iter.moveTo
+		iter.lastPt = iter.moveTo
+		return KPathVerbLine
+	}
+	pts[0] = iter.moveTo
+	return KPathVerbClose
+}
+
+// Next returns the next verb in this iteration of the path. When all
+// segments have been visited, return KPathVerbDone. pts must have room for
+// 4 points; the returned points include the segment's start point.
+func (iter *PathIter) Next(pts []Point) PathVerb {
+	var path = iter.path
+	if iter.verbIndex >= len(path.verbs) {
+		// Close the curve if requested and if there is some curve to close
+		if iter.needClose {
+			if iter.autoClose(pts) == KPathVerbLine {
+				return KPathVerbLine
+			}
+			iter.needClose = false
+			return KPathVerbClose
+		}
+		return KPathVerbDone
+	}
+
+	var verb = path.verbs[iter.verbIndex]
+	switch verb {
+	case KPathVerbMove:
+		if iter.needClose {
+			if iter.autoClose(pts) == KPathVerbLine {
+				return KPathVerbLine
+			}
+			iter.needClose = false
+			return KPathVerbClose
+		}
+		iter.verbIndex++
+		iter.moveTo = path.pts[iter.ptIndex]
+		iter.lastPt = iter.moveTo
+		iter.ptIndex++
+		iter.needClose = iter.forceClose
+		pts[0] = iter.moveTo
+		return KPathVerbMove
+
+	case KPathVerbLine:
+		iter.verbIndex++
+		pts[0], pts[1] = iter.lastPt, path.pts[iter.ptIndex]
+		iter.lastPt = pts[1]
+		iter.ptIndex++
+		return KPathVerbLine
+
+	case KPathVerbQuad:
+		iter.verbIndex++
+		pts[0] = iter.lastPt
+		copy(pts[1:3], path.pts[iter.ptIndex:iter.ptIndex+2])
+		iter.lastPt = pts[2]
+		iter.ptIndex += 2
+		return KPathVerbQuad
+
+	case KPathVerbCubic:
+		iter.verbIndex++
+		pts[0] = iter.lastPt
+		copy(pts[1:4], path.pts[iter.ptIndex:iter.ptIndex+3])
+		iter.lastPt = pts[3]
+		iter.ptIndex += 3
+		return KPathVerbCubic
+
+	case KPathVerbClose:
+		if iter.autoClose(pts) == KPathVerbLine {
+			return KPathVerbLine
+		}
+		iter.verbIndex++
+		iter.needClose = false
+		return KPathVerbClose
+	}
+
+	return KPathVerbDone
 }
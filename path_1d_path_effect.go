@@ -0,0 +1,81 @@
+package ggk
+
+type Path1DPathEffectStyle int
+
+const (
+	KPath1DPathEffectStyleTranslate Path1DPathEffectStyle = iota // translate the shape to each position
+	KPath1DPathEffectStyleRotate                                 // rotate the shape about its origin
+	KPath1DPathEffectStyleMorph                                  // transform each point, and turn lines into curves
+)
+
+/** tPath1DPathEffect
+stamps a shape along the contours of a path every advance. */
+type tPath1DPathEffect struct {
+	path          *Path
+	advance       Scalar
+	initialOffset Scalar
+	style         Path1DPathEffectStyle
+}
+
+/** NewPath1DPathEffect
+Return an effect stamping path along contours every advance, starting at
+phase. The origin of path is placed on the contour, with its x axis along
+the contour for the rotate and morph styles. Returns nil if advance is not
+positive or path is empty. */
+func NewPath1DPathEffect(path *Path, advance, phase Scalar, style Path1DPathEffectStyle) *PathEffect {
+	if advance <= 0 || !ScalarIsFinite(advance) || !ScalarIsFinite(phase) || path == nil || path.IsEmpty() {
+		return nil
+	}
+	// wrap phase into advance, where a positive phase moves the shapes back.
+	if phase < 0 {
+		phase = -phase
+		if phase > advance {
+			phase = ScalarMod(phase, advance)
+		}
+	} else {
+		if phase > advance {
+			phase = ScalarMod(phase, advance)
+		}
+		phase = advance - phase
+	}
+	if phase >= advance {
+		phase = 0
+	}
+	return NewPathEffect(&tPath1DPathEffect{NewPathClone(path), advance, phase, style})
+}
+
+func (impl *tPath1DPathEffect) OnFilterPath(dst, src *Path, rec *StrokeRec, cullRect *Rect) bool {
+	// the shapes are filled, whatever the source was drawn with.
+	rec.SetFillStyle()
+	var measure = NewPathMeasure(src, false, 1)
+	for {
+		var length = measure.Length()
+		for distance := impl.initialOffset; distance < length; distance += impl.advance {
+			impl.stamp(dst, distance, measure)
+		}
+		if !measure.NextContour() {
+			break
+		}
+	}
+	return true
+}
+
+// Stamp the shape at distance along the current contour of measure.
+func (impl *tPath1DPathEffect) stamp(dst *Path, distance Scalar, measure *PathMeasure) {
+	switch impl.style {
+	case KPath1DPathEffectStyleTranslate:
+		if pos, _, ok := measure.PosTan(distance); ok {
+			var matrix = NewMatrix()
+			matrix.SetTranslate(pos.X, pos.Y)
+			dst.AddPath(impl.path, matrix)
+		}
+	case KPath1DPathEffectStyleRotate:
+		if matrix, ok := measure.Matrix(distance, KPathMeasureMatrixFlagGetPosAndTan); ok {
+			dst.AddPath(impl.path, matrix)
+		}
+	case KPath1DPathEffectStyleMorph:
+		var matrix = NewMatrix()
+		matrix.SetTranslate(distance, 0)
+		morphPath(dst, impl.path, measure, matrix)
+	}
+}
@@ -0,0 +1,103 @@
+package ggk
+
+/** tLatticePathEffect
+is the base of the 2D path effects, which fill the inside of a path with
+the points of a lattice: the points (u, v), with u and v integers, mapped
+by a matrix. nextSpan is called with each row of count lattice points from
+(u, v) whose cells are inside the path. */
+type tLatticePathEffect struct {
+	matrix   *Matrix
+	inverse  *Matrix
+	nextSpan func(u, v, count int, dst *Path)
+}
+
+// Return the lattice of matrix, or false if matrix can't be inverted.
+func newLatticePathEffect(matrix *Matrix) (tLatticePathEffect, bool) {
+	var inverse, ok = matrix.Invert()
+	return tLatticePathEffect{matrix: NewMatrixClone(matrix), inverse: inverse}, ok
+}
+
+func (impl *tLatticePathEffect) OnFilterPath(dst, src *Path, rec *StrokeRec, cullRect *Rect) bool {
+	// find the lattice cells whose centers are inside the path.
+	var tmp = NewPath()
+	src.Transform(impl.inverse, tmp)
+	var mask = scanPathToMask(tmp, tmp.Bounds().RoundOut())
+	if mask == nil {
+		return true
+	}
+	var left, top = int(mask.Bounds.Left), int(mask.Bounds.Top)
+	var width, height = int(mask.Bounds.Width), int(mask.Bounds.Height)
+	for y := top; y < top+height; y++ {
+		for x := left; x < left+width; {
+			if mask.alphaAt(x, y) < 0x80 {
+				x++
+				continue
+			}
+			var start = x
+			for x < left+width && mask.alphaAt(x, y) >= 0x80 {
+				x++
+			}
+			impl.nextSpan(start, y, x-start, dst)
+		}
+	}
+	return true
+}
+
+/** NewPath2DPathEffect
+Return an effect stamping path at each point of the lattice of matrix
+inside the drawn path, filling it with copies of path. Returns nil if
+matrix can't be inverted. */
+func NewPath2DPathEffect(matrix *Matrix, path *Path) *PathEffect {
+	var impl, ok = newLatticePathEffect(matrix)
+	if !ok || path == nil {
+		return nil
+	}
+	path = NewPathClone(path)
+	impl.nextSpan = func(u, v, count int, dst *Path) {
+		var translate = NewMatrix()
+		for i := 0; i < count; i++ {
+			var loc = impl.matrix.MapXY(Scalar(u+i), Scalar(v))
+			translate.SetTranslate(loc.X, loc.Y)
+			dst.AddPath(path, translate)
+		}
+	}
+	return NewPathEffect(&impl)
+}
+
+/** tLine2DPathEffect
+hatches the inside of a path with the rows of a lattice, as lines stroked
+with width. */
+type tLine2DPathEffect struct {
+	tLatticePathEffect
+	width Scalar
+}
+
+/** NewLine2DPathEffect
+Return an effect hatching the drawn path with lines of width along the
+rows of the lattice of matrix, whose v axis spaces them. Returns nil if
+width is negative or matrix can't be inverted. */
+func NewLine2DPathEffect(width Scalar, matrix *Matrix) *PathEffect {
+	var lattice, ok = newLatticePathEffect(matrix)
+	if !ok || width < 0 {
+		return nil
+	}
+	var impl = &tLine2DPathEffect{lattice, width}
+	impl.nextSpan = func(u, v, count int, dst *Path) {
+		if count <= 1 {
+			return
+		}
+		var start = impl.matrix.MapXY(Scalar(u)+KScalarHalf, Scalar(v)+KScalarHalf)
+		var stop = impl.matrix.MapXY(Scalar(u+count)+KScalarHalf, Scalar(v)+KScalarHalf)
+		dst.MoveTo(start.X, start.Y)
+		dst.LineTo(stop.X, stop.Y)
+	}
+	return NewPathEffect(impl)
+}
+
+func (impl *tLine2DPathEffect) OnFilterPath(dst, src *Path, rec *StrokeRec, cullRect *Rect) bool {
+	if !impl.tLatticePathEffect.OnFilterPath(dst, src, rec, cullRect) {
+		return false
+	}
+	rec.SetStrokeStyle(impl.width, false)
+	return true
+}
@@ -1,5 +1,13 @@
 package ggk
 
+/** PathEffectImpl
+is implemented by each kind of path effect. OnFilterPath appends the
+effect of src to dst and returns true, or returns false if it does
+nothing to src. */
+type PathEffectImpl interface {
+	OnFilterPath(dst, src *Path, rec *StrokeRec, cullRect *Rect) bool
+}
+
 /** \class SkPathEffect
 
     SkPathEffect is the base class for objects in the SkPaint that affect
@@ -9,5 +17,75 @@ package ggk
     Dashing is implemented as a subclass of SkPathEffect.
 */
 type PathEffect struct {
+	Impl PathEffectImpl
+}
+
+func NewPathEffect(impl PathEffectImpl) *PathEffect {
+	return &PathEffect{Impl: impl}
+}
+
+/** FilterPath
+Append to dst the path that drawing src with rec becomes, and return true,
+or return false if the effect does nothing to src, in which case dst is
+unchanged. The effect may change rec, like making lines into a stroke of
+a width. If cullRect is not nil, the parts of the result outside of it may
+be dropped. */
+func (effect *PathEffect) FilterPath(dst, src *Path, rec *StrokeRec, cullRect *Rect) bool {
+	if effect.Impl == nil {
+		return false
+	}
+	return effect.Impl.OnFilterPath(dst, src, rec, cullRect)
+}
+
+/** tComposePathEffect
+applies inner and then outer to the result. */
+type tComposePathEffect struct {
+	outer *PathEffect
+	inner *PathEffect
+}
+
+/** NewComposePathEffect
+Return the effect of applying inner and then outer to the result. If one
+is nil the other is returned. */
+func NewComposePathEffect(outer, inner *PathEffect) *PathEffect {
+	if outer == nil {
+		return inner
+	}
+	if inner == nil {
+		return outer
+	}
+	return NewPathEffect(&tComposePathEffect{outer, inner})
+}
+
+func (impl *tComposePathEffect) OnFilterPath(dst, src *Path, rec *StrokeRec, cullRect *Rect) bool {
+	var tmp = NewPath()
+	if impl.inner.FilterPath(tmp, src, rec, cullRect) {
+		src = tmp
+	}
+	return impl.outer.FilterPath(dst, src, rec, cullRect)
+}
+
+/** tSumPathEffect
+applies first and second each to the source, and draws both results. */
+type tSumPathEffect struct {
+	first  *PathEffect
+	second *PathEffect
+}
+
+/** NewSumPathEffect
+Return the effect of drawing the results of first and second, each applied
+to the source. If one is nil the other is returned. */
+func NewSumPathEffect(first, second *PathEffect) *PathEffect {
+	if first == nil {
+		return second
+	}
+	if second == nil {
+		return first
+	}
+	return NewPathEffect(&tSumPathEffect{first, second})
+}
 
+func (impl *tSumPathEffect) OnFilterPath(dst, src *Path, rec *StrokeRec, cullRect *Rect) bool {
+	var filtered = impl.first.FilterPath(dst, src, rec, cullRect)
+	return impl.second.FilterPath(dst, src, rec, cullRect) || filtered
 }
@@ -0,0 +1,283 @@
+package ggk
+
+import "testing"
+
+// Return the length of each contour of path.
+func contourLengths(path *Path) []Scalar {
+	var lengths []Scalar
+	var measure = NewPathMeasure(path, false, 1)
+	for {
+		if length := measure.Length(); length > 0 {
+			lengths = append(lengths, length)
+		}
+		if !measure.NextContour() {
+			return lengths
+		}
+	}
+}
+
+func countVerbs(path *Path, verb PathVerb) int {
+	var count = 0
+	for _, v := range path.Verbs() {
+		if v == verb {
+			count++
+		}
+	}
+	return count
+}
+
+func newTestLinePath(x0, y0, x1, y1 Scalar) *Path {
+	var path = NewPath()
+	path.MoveTo(x0, y0)
+	path.LineTo(x1, y1)
+	return path
+}
+
+func newTestRectPath(rect Rect) *Path {
+	var path = NewPath()
+	path.AddRect(rect)
+	return path
+}
+
+func TestDashPathEffect(t *testing.T) {
+	var line = newTestLinePath(0, 0, 100, 0)
+	var square = newTestRectPath(MakeRect(0, 0, 40, 40))
+	var tests = []struct {
+		name      string
+		src       *Path
+		intervals []Scalar
+		phase     Scalar
+		lengths   []Scalar
+	}{
+		{"line", line, []Scalar{10, 5}, 0, []Scalar{10, 10, 10, 10, 10, 10, 10}},
+		{"phase", line, []Scalar{10, 5}, 5, []Scalar{5, 10, 10, 10, 10, 10, 10}},
+		{"phase in gap", line, []Scalar{10, 5}, 12, []Scalar{10, 10, 10, 10, 10, 10, 7}},
+		{"negative phase", line, []Scalar{10, 5}, -5, []Scalar{10, 10, 10, 10, 10, 10, 5}},
+		{"closed", square, []Scalar{30, 10}, 0, []Scalar{30, 30, 30, 30}},
+		{"closed joined", square, []Scalar{30, 10}, 20, []Scalar{30, 30, 30, 30}},
+	}
+	for _, test := range tests {
+		var dst = NewPath()
+		var effect = NewDashPathEffect(test.intervals, test.phase)
+		if !effect.FilterPath(dst, test.src, NewStrokeRec(KStrokeRecInitStyleHairline), nil) {
+			t.Errorf("%v FilterPath() want true", test.name)
+			continue
+		}
+		var lengths = contourLengths(dst)
+		if len(lengths) != len(test.lengths) {
+			t.Errorf("%v want dashes %v got %v", test.name, test.lengths, lengths)
+			continue
+		}
+		for i := range lengths {
+			if !ScalarNearlyEqual(lengths[i], test.lengths[i], 0.01) {
+				t.Errorf("%v want dashes %v got %v", test.name, test.lengths, lengths)
+				break
+			}
+		}
+	}
+
+	var dst = NewPath()
+	if NewDashPathEffect([]Scalar{10, 5}, 0).FilterPath(dst, line, NewStrokeRec(KStrokeRecInitStyleFill), nil) || !dst.IsEmpty() {
+		t.Errorf("FilterPath() of a filled path want false")
+	}
+	// a short line that is dashed, then one needing a hundred million dashes
+	// and gaps.
+	var long = newTestLinePath(0, 0, 0.1, 0)
+	long.MoveTo(0, 100)
+	long.LineTo(100, 100)
+	dst = NewPath()
+	if NewDashPathEffect([]Scalar{1e-6, 1e-6}, 0).FilterPath(dst, long, NewStrokeRec(KStrokeRecInitStyleHairline),
+		nil) || !dst.IsEmpty() {
+		t.Errorf("FilterPath() of too many dashes want false and an empty path")
+	}
+	for _, intervals := range [][]Scalar{{10}, {10, 5, 5}, {10, -1}, {0, 0}} {
+		if NewDashPathEffect(intervals, 0) != nil {
+			t.Errorf("NewDashPathEffect(%v) want nil", intervals)
+		}
+	}
+}
+
+func TestCornerPathEffect(t *testing.T) {
+	var dst = NewPath()
+	var src = newTestRectPath(MakeRect(0, 0, 100, 100))
+	if !NewCornerPathEffect(10).FilterPath(dst, src, NewStrokeRec(KStrokeRecInitStyleFill), nil) {
+		t.Fatalf("FilterPath() want true")
+	}
+	if countVerbs(dst, KPathVerbQuad) != 4 || countVerbs(dst, KPathVerbLine) != 4 || countVerbs(dst, KPathVerbClose) != 1 {
+		t.Errorf("want 4 rounded corners got verbs %v", dst.Verbs())
+	}
+	if dst.Points()[0] != (Point{10, 0}) || dst.Bounds() != MakeRect(0, 0, 100, 100) {
+		t.Errorf("want the contour to start past the corner got %v, bounds %v", dst.Points()[0], dst.Bounds())
+	}
+
+	// an open contour keeps its ends, starting with a degenerate corner.
+	var open = NewPath()
+	open.MoveTo(0, 0)
+	open.LineTo(50, 0)
+	open.LineTo(50, 50)
+	dst = NewPath()
+	NewCornerPathEffect(10).FilterPath(dst, open, NewStrokeRec(KStrokeRecInitStyleHairline), nil)
+	var pts = dst.Points()
+	if pts[0] != (Point{0, 0}) || pts[len(pts)-1] != (Point{50, 50}) || countVerbs(dst, KPathVerbQuad) != 2 {
+		t.Errorf("want an open contour with a rounded corner got %v %v", dst.Verbs(), pts)
+	}
+	if NewCornerPathEffect(0) != nil {
+		t.Errorf("NewCornerPathEffect(0) want nil")
+	}
+}
+
+func TestDiscretePathEffect(t *testing.T) {
+	var src = newTestLinePath(0, 0, 100, 0)
+	var filter = func(seedAssist uint32) *Path {
+		var dst = NewPath()
+		if !NewDiscretePathEffect(10, 3, seedAssist).FilterPath(dst, src, NewStrokeRec(KStrokeRecInitStyleHairline), nil) {
+			t.Fatalf("FilterPath() want true")
+		}
+		return dst
+	}
+	var dst = filter(0)
+	if dst.CountPoints() != 11 || countVerbs(dst, KPathVerbLine) != 10 {
+		t.Errorf("want 10 segments got verbs %v", dst.Verbs())
+	}
+	var moved = false
+	for _, pt := range dst.Points() {
+		if pt.Y < -3 || pt.Y > 3 || pt.X < -3 || pt.X > 103 {
+			t.Errorf("want points within the deviation got %v", pt)
+		}
+		moved = moved || pt.Y != 0
+	}
+	if !moved {
+		t.Errorf("want jittered points got %v", dst.Points())
+	}
+	var same = filter(0)
+	for i, pt := range same.Points() {
+		if pt != dst.Points()[i] {
+			t.Errorf("want the same points for the same seed got %v and %v", pt, dst.Points()[i])
+		}
+	}
+	if NewDiscretePathEffect(0, 3, 0) != nil {
+		t.Errorf("NewDiscretePathEffect(0, ...) want nil")
+	}
+}
+
+func TestPath1DPathEffect(t *testing.T) {
+	var shape = newTestRectPath(MakeRect(-1, -1, 2, 2))
+	var tests = []struct {
+		name   string
+		src    *Path
+		style  Path1DPathEffectStyle
+		phase  Scalar
+		stamps int
+		bounds Rect
+	}{
+		{"translate", newTestLinePath(0, 0, 100, 0), KPath1DPathEffectStyleTranslate, 0, 4, MakeRectLTRB(-1, -1, 76, 1)},
+		{"phase", newTestLinePath(0, 0, 100, 0), KPath1DPathEffectStyleTranslate, 5, 4, MakeRectLTRB(19, -1, 96, 1)},
+		{"rotate", newTestLinePath(0, 0, 0, 100), KPath1DPathEffectStyleRotate, 0, 4, MakeRectLTRB(-1, -1, 1, 76)},
+		{"morph", newTestLinePath(0, 0, 100, 0), KPath1DPathEffectStyleMorph, 0, 4, MakeRectLTRB(0, -1, 76, 1)},
+	}
+	for _, test := range tests {
+		var dst = NewPath()
+		var rec = NewStrokeRec(KStrokeRecInitStyleHairline)
+		var effect = NewPath1DPathEffect(shape, 25, test.phase, test.style)
+		if !effect.FilterPath(dst, test.src, rec, nil) || !rec.IsFillStyle() {
+			t.Errorf("%v FilterPath() want true and a fill style", test.name)
+			continue
+		}
+		if stamps := countVerbs(dst, KPathVerbMove); stamps != test.stamps {
+			t.Errorf("%v want %v stamps got %v", test.name, test.stamps, stamps)
+		}
+		var bounds = dst.Bounds()
+		if !ScalarNearlyEqual(bounds.Left, test.bounds.Left, 0.01) || !ScalarNearlyEqual(bounds.Top, test.bounds.Top, 0.01) ||
+			!ScalarNearlyEqual(bounds.Width, test.bounds.Width, 0.01) || !ScalarNearlyEqual(bounds.Height, test.bounds.Height, 0.01) {
+			t.Errorf("%v want bounds %v got %v", test.name, test.bounds, bounds)
+		}
+	}
+	if NewPath1DPathEffect(shape, 0, 0, KPath1DPathEffectStyleTranslate) != nil {
+		t.Errorf("NewPath1DPathEffect() with a zero advance want nil")
+	}
+}
+
+func TestPath2DPathEffect(t *testing.T) {
+	var matrix = NewMatrix()
+	matrix.SetScale(10, 10)
+	var src = newTestRectPath(MakeRect(0, 0, 40, 20))
+
+	var dst = NewPath()
+	var effect = NewPath2DPathEffect(matrix, newTestRectPath(MakeRect(0, 0, 1, 1)))
+	if !effect.FilterPath(dst, src, NewStrokeRec(KStrokeRecInitStyleFill), nil) {
+		t.Fatalf("FilterPath() want true")
+	}
+	if stamps := countVerbs(dst, KPathVerbMove); stamps != 8 {
+		t.Errorf("want 8 stamps got %v", stamps)
+	}
+
+	dst = NewPath()
+	var rec = NewStrokeRec(KStrokeRecInitStyleFill)
+	if !NewLine2DPathEffect(1, matrix).FilterPath(dst, src, rec, nil) {
+		t.Fatalf("FilterPath() want true")
+	}
+	if lines := countVerbs(dst, KPathVerbLine); lines != 2 || rec.Style() != KStrokeRecStyleStroke || rec.Width() != 1 {
+		t.Errorf("want 2 lines stroked with width 1 got %v lines, style %v, width %v", lines, rec.Style(), rec.Width())
+	}
+
+	var singular = NewMatrix()
+	singular.SetScale(0, 10)
+	if NewPath2DPathEffect(singular, src) != nil || NewLine2DPathEffect(1, singular) != nil {
+		t.Errorf("want nil effects for a singular matrix")
+	}
+}
+
+func TestComposeAndSumPathEffect(t *testing.T) {
+	var src = newTestLinePath(0, 0, 100, 0)
+	var dash = NewDashPathEffect([]Scalar{10, 5}, 0)
+	var stamp = NewPath1DPathEffect(newTestRectPath(MakeRect(-1, -1, 2, 2)), 25, 0, KPath1DPathEffectStyleTranslate)
+
+	// stamp every dash.
+	var dst = NewPath()
+	if !NewComposePathEffect(stamp, dash).FilterPath(dst, src, NewStrokeRec(KStrokeRecInitStyleHairline), nil) {
+		t.Fatalf("compose FilterPath() want true")
+	}
+	if stamps := countVerbs(dst, KPathVerbMove); stamps != 7 {
+		t.Errorf("compose want 7 stamps got %v", stamps)
+	}
+
+	dst = NewPath()
+	if !NewSumPathEffect(dash, stamp).FilterPath(dst, src, NewStrokeRec(KStrokeRecInitStyleHairline), nil) {
+		t.Fatalf("sum FilterPath() want true")
+	}
+	if contours := countVerbs(dst, KPathVerbMove); contours != 11 {
+		t.Errorf("sum want 11 contours got %v", contours)
+	}
+
+	if NewComposePathEffect(nil, dash) != dash || NewSumPathEffect(dash, nil) != dash {
+		t.Errorf("want the other effect when one is nil")
+	}
+}
+
+func TestPaintPathEffect(t *testing.T) {
+	var paint = newTestPaint(KColorRed)
+	var effect = NewDashPathEffect([]Scalar{10, 5}, 0)
+	paint.SetPathEffect(effect)
+	if paint.PathEffect() != effect {
+		t.Errorf("PathEffect() want the effect set")
+	}
+	paint.SetStyle(KPaintStyleStroke)
+	paint.SetStrokeWidth(4)
+
+	// dashes of 10 every 15 along y = 10, 4 pixels wide.
+	var canvas, pixels = newTestPictureCanvas(40, 20)
+	canvas.DrawPath(newTestLinePath(0, 10, 100, 10), paint)
+	var red = PackARGB32(0xff, 0xff, 0, 0)
+	var checks = []struct {
+		x, y int
+		want uint32
+	}{
+		{0, 10, red}, {9, 8, red}, {9, 11, red}, {5, 7, 0}, {5, 12, 0},
+		{10, 10, 0}, {14, 10, 0}, {15, 10, red}, {24, 9, red}, {27, 10, 0}, {30, 10, red},
+	}
+	for _, check := range checks {
+		if got := pixels.Pixel32(check.x, check.y); got != check.want {
+			t.Errorf("dashed stroke at (%v, %v) want %#x got %#x", check.x, check.y, check.want, got)
+		}
+	}
+}
@@ -0,0 +1,469 @@
+package ggk
+
+type PathMeasureMatrixFlags int
+
+const (
+	KPathMeasureMatrixFlagGetPosition  = PathMeasureMatrixFlags(0x01)
+	KPathMeasureMatrixFlagGetTangent   = PathMeasureMatrixFlags(0x02)
+	KPathMeasureMatrixFlagGetPosAndTan = KPathMeasureMatrixFlagGetPosition | KPathMeasureMatrixFlagGetTangent
+)
+
+// The max number of times a segment is recursively subdivided while measuring.
+const kPathMeasureMaxTValue = 0x3FFFFFFF
+
+// kPathMeasureCheapDistLimit is the distance (in pixels) a chord may deviate
+// from its curve before it is subdivided further.
+const kPathMeasureCheapDistLimit Scalar = 0.5
+
+type tPathMeasureSegment struct {
+	distance Scalar // total distance up to this point
+	ptIndex  int    // index into the measure's pts
+	tValue   int    // fixed point t in [0 .. kPathMeasureMaxTValue]
+	verb     PathVerb
+}
+
+func (seg *tPathMeasureSegment) scalarT() Scalar {
+	return Scalar(seg.tValue) / kPathMeasureMaxTValue
+}
+
+/** PathMeasure
+Computes the length of the contours of a path, and evaluates the position
+and tangent at any distance along them. The measure walks one contour at a
+time; call NextContour() to move to the next one. */
+type PathMeasure struct {
+	iter        *PathIter
+	path        *Path
+	tolerance   Scalar
+	length      Scalar // relative to the current contour
+	firstPtIdx  int    // index of the current contour's moveTo in pts
+	segments    []tPathMeasureSegment
+	pts         []Point // Points used to define the segments
+	forceClosed bool
+	isClosed    bool // relative to the current contour
+}
+
+/** NewPathMeasure
+Initialize the pathmeasure with the specified path. The path must remain
+valid for the lifetime of the measure object, or until SetPath() is called
+with a different path (or nil), since the measure object keeps a pointer
+to the path object (does not copy its data).
+
+resScale controls the precision of the measure. values > 1 increase the
+precision (and possible slow down the computation). */
+func NewPathMeasure(path *Path, forceClosed bool, resScale Scalar) *PathMeasure {
+	var measure = &PathMeasure{}
+	if resScale <= 0 {
+		resScale = 1
+	}
+	measure.tolerance = kPathMeasureCheapDistLimit / resScale
+	measure.SetPath(path, forceClosed)
+	return measure
+}
+
+/** SetPath
+Reset the pathmeasure with the specified path. The path must remain valid
+for the lifetime of the measure object, or until SetPath() is called with a
+different path (or nil), since the measure object keeps a pointer to the
+path object (does not copy its data). */
+func (measure *PathMeasure) SetPath(path *Path, forceClosed bool) {
+	measure.path = path
+	measure.forceClosed = forceClosed
+	measure.iter = nil
+	if path != nil {
+		measure.iter = NewPathIter(path, forceClosed)
+	}
+	measure.length = -1 // signal we need to compute it
+	measure.firstPtIdx = -1
+	measure.segments = measure.segments[:0]
+	measure.pts = measure.pts[:0]
+}
+
+/** Length
+Return the total length of the current contour, or 0 if no path is
+associated (e.g. resetPath(null)) */
+func (measure *PathMeasure) Length() Scalar {
+	if measure.path == nil {
+		return 0
+	}
+	if measure.length < 0 {
+		measure.buildSegments()
+	}
+	return measure.length
+}
+
+/** IsClosed
+Return true if the current contour is closed() */
+func (measure *PathMeasure) IsClosed() bool {
+	measure.Length()
+	return measure.isClosed
+}
+
+/** NextContour
+Move to the next contour in the path. Return true if one exists, or false
+if we're done with the path. */
+func (measure *PathMeasure) NextContour() bool {
+	measure.length = -1
+	return measure.Length() > 0
+}
+
+func tspanBigEnough(tSpan int) bool {
+	return (tSpan >> 10) != 0
+}
+
+func cheapDistExceedsLimit(pt Point, x, y, tolerance Scalar) bool {
+	var dist = ScalarMax(ScalarAbs(x-pt.X), ScalarAbs(y-pt.Y))
+	// just made up the 1/2
+	return dist > tolerance
+}
+
+func quadTooCurvy(pts []Point, tolerance Scalar) bool {
+	// diff = (a/4 + b/2 + c/4) - (a/2 + c/2)
+	// diff = -a/4 + b/2 - c/4
+	var dx = ScalarHalf(pts[1].X) - ScalarHalf(ScalarHalf(pts[0].X+pts[2].X))
+	var dy = ScalarHalf(pts[1].Y) - ScalarHalf(ScalarHalf(pts[0].Y+pts[2].Y))
+	var dist = ScalarMax(ScalarAbs(dx), ScalarAbs(dy))
+	return dist > tolerance
+}
+
+func cubicTooCurvy(pts []Point, tolerance Scalar) bool {
+	var third = KScalar1 / 3
+	var twoThirds = 2 * third
+	return cheapDistExceedsLimit(pts[1],
+		ScalarInterpolate(pts[0].X, pts[3].X, third),
+		ScalarInterpolate(pts[0].Y, pts[3].Y, third), tolerance) ||
+		cheapDistExceedsLimit(pts[2],
+			ScalarInterpolate(pts[0].X, pts[3].X, twoThirds),
+			ScalarInterpolate(pts[0].Y, pts[3].Y, twoThirds), tolerance)
+}
+
+func (measure *PathMeasure) computeQuadSegs(pts []Point, distance Scalar, mint, maxt, ptIndex int) Scalar {
+	if tspanBigEnough(maxt-mint) && quadTooCurvy(pts, measure.tolerance) {
+		var tmp = ChopQuadAt(pts, KScalarHalf)
+		var halft = (mint + maxt) >> 1
+
+		distance = measure.computeQuadSegs(tmp[0:3], distance, mint, halft, ptIndex)
+		distance = measure.computeQuadSegs(tmp[2:5], distance, halft, maxt, ptIndex)
+	} else {
+		var d = PointDistance(pts[0], pts[2])
+		var prevD = distance
+		distance += d
+		if distance > prevD {
+			measure.segments = append(measure.segments, tPathMeasureSegment{
+				distance: distance,
+				ptIndex:  ptIndex,
+				tValue:   maxt,
+				verb:     KPathVerbQuad,
+			})
+		}
+	}
+	return distance
+}
+
+func (measure *PathMeasure) computeCubicSegs(pts []Point, distance Scalar, mint, maxt, ptIndex int) Scalar {
+	if tspanBigEnough(maxt-mint) && cubicTooCurvy(pts, measure.tolerance) {
+		var tmp = ChopCubicAt(pts, KScalarHalf)
+		var halft = (mint + maxt) >> 1
+
+		distance = measure.computeCubicSegs(tmp[0:4], distance, mint, halft, ptIndex)
+		distance = measure.computeCubicSegs(tmp[3:7], distance, halft, maxt, ptIndex)
+	} else {
+		var d = PointDistance(pts[0], pts[3])
+		var prevD = distance
+		distance += d
+		if distance > prevD {
+			measure.segments = append(measure.segments, tPathMeasureSegment{
+				distance: distance,
+				ptIndex:  ptIndex,
+				tValue:   maxt,
+				verb:     KPathVerbCubic,
+			})
+		}
+	}
+	return distance
+}
+
+func (measure *PathMeasure) buildSegments() {
+	var pts [4]Point
+	var ptIndex = measure.firstPtIdx
+	var distance Scalar = 0
+	var isClosed = measure.forceClosed
+	var firstMoveTo = ptIndex < 0
+	var done = false
+
+	measure.segments = measure.segments[:0]
+	if measure.iter == nil {
+		measure.length = 0
+		return
+	}
+
+	for !done {
+		switch measure.iter.Next(pts[:]) {
+		case KPathVerbMove:
+			ptIndex += 1
+			measure.pts = append(measure.pts, pts[0])
+			if !firstMoveTo {
+				done = true
+				break
+			}
+			firstMoveTo = false
+
+		case KPathVerbLine:
+			var d = PointDistance(pts[0], pts[1])
+			var prevD = distance
+			distance += d
+			if distance > prevD {
+				measure.segments = append(measure.segments, tPathMeasureSegment{
+					distance: distance,
+					ptIndex:  ptIndex,
+					tValue:   kPathMeasureMaxTValue,
+					verb:     KPathVerbLine,
+				})
+				measure.pts = append(measure.pts, pts[1])
+				ptIndex++
+			}
+
+		case KPathVerbQuad:
+			var prevD = distance
+			distance = measure.computeQuadSegs(pts[:3], distance, 0, kPathMeasureMaxTValue, ptIndex)
+			if distance > prevD {
+				measure.pts = append(measure.pts, pts[1], pts[2])
+				ptIndex += 2
+			}
+
+		case KPathVerbCubic:
+			var prevD = distance
+			distance = measure.computeCubicSegs(pts[:4], distance, 0, kPathMeasureMaxTValue, ptIndex)
+			if distance > prevD {
+				measure.pts = append(measure.pts, pts[1], pts[2], pts[3])
+				ptIndex += 3
+			}
+
+		case KPathVerbClose:
+			isClosed = true
+
+		case KPathVerbDone:
+			done = true
+		}
+	}
+
+	measure.length = distance
+	measure.isClosed = isClosed
+	measure.firstPtIdx = ptIndex
+}
+
+// The position (and tangent) at t along the segment whose first point is
+// pts[ptIndex].
+func computePosTan(pts []Point, verb PathVerb, t Scalar) (pos, tangent Point) {
+	switch verb {
+	case KPathVerbLine:
+		pos = interpPoint(pts[0], pts[1], t)
+		tangent = MakePoint(pts[1].X-pts[0].X, pts[1].Y-pts[0].Y)
+	case KPathVerbQuad:
+		pos, tangent = EvalQuadAt(pts, t)
+	case KPathVerbCubic:
+		pos, tangent = EvalCubicAt(pts, t)
+	}
+	tangent.Normalize()
+	return
+}
+
+// Find the index of the segment that contains distance, and the t value
+// within it.
+func (measure *PathMeasure) distanceToSegment(distance Scalar) (int, Scalar) {
+	var segs = measure.segments
+	var lo, hi = 0, len(segs) - 1
+	for lo < hi {
+		var mid = (lo + hi) >> 1
+		if segs[mid].distance < distance {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	var index = lo
+	var seg = &segs[index]
+
+	// now interpolate t-values with the prev segment (if possible)
+	var startT, startD Scalar = 0, 0
+	// check if the prev segment is legal, and references the same set of points
+	if index > 0 {
+		startD = segs[index-1].distance
+		if segs[index-1].ptIndex == seg.ptIndex {
+			startT = segs[index-1].scalarT()
+		}
+	}
+
+	var t = startT + (seg.scalarT()-startT)*(distance-startD)/(seg.distance-startD)
+	return index, t
+}
+
+// The points of the curve that seg is part of.
+func (measure *PathMeasure) segmentPoints(seg *tPathMeasureSegment) []Point {
+	var n = 2
+	switch seg.verb {
+	case KPathVerbQuad:
+		n = 3
+	case KPathVerbCubic:
+		n = 4
+	}
+	return measure.pts[seg.ptIndex : seg.ptIndex+n]
+}
+
+/** PosTan
+Pins distance to 0 <= distance <= Length(), and then computes the
+corresponding position and tangent. Returns false if there is no path,
+or a zero-length path was specified, in which case position and tangent
+are unchanged. */
+func (measure *PathMeasure) PosTan(distance Scalar) (pos, tangent Point, ok bool) {
+	var length = measure.Length() // call this to force computing it
+	var count = len(measure.segments)
+
+	if count == 0 || length == 0 {
+		return
+	}
+
+	// pin the distance to a legal range
+	if distance < 0 {
+		distance = 0
+	} else if distance > length {
+		distance = length
+	}
+
+	var index, t = measure.distanceToSegment(distance)
+	var seg = &measure.segments[index]
+	pos, tangent = computePosTan(measure.segmentPoints(seg), seg.verb, t)
+	ok = true
+	return
+}
+
+/** Matrix
+Pins distance to 0 <= distance <= Length(), and then computes the
+corresponding matrix (by calling PosTan). Returns false if there is no path,
+or a zero-length path was specified, in which case matrix is unchanged. */
+func (measure *PathMeasure) Matrix(distance Scalar, flags PathMeasureMatrixFlags) (*Matrix, bool) {
+	var pos, tangent, ok = measure.PosTan(distance)
+	if !ok {
+		return nil, false
+	}
+
+	var matrix = NewMatrix()
+	if flags&KPathMeasureMatrixFlagGetTangent != 0 {
+		matrix.SetSinCos(tangent.Y, tangent.X)
+	}
+	if flags&KPathMeasureMatrixFlagGetPosition != 0 {
+		matrix.PostTranslate(pos.X, pos.Y)
+	}
+	return matrix, true
+}
+
+// Append the part of the segment's curve between startT and stopT to dst.
+func segmentTo(pts []Point, verb PathVerb, startT, stopT Scalar, dst *Path) {
+	if startT == stopT {
+		// if the dash as a zero-length on segment, add a corresponding zero-length line.
+		if last, ok := dst.LastPoint(); ok {
+			dst.LineTo(last.X, last.Y)
+		}
+		return
+	}
+
+	switch verb {
+	case KPathVerbLine:
+		if stopT == KScalar1 {
+			dst.LineTo(pts[1].X, pts[1].Y)
+		} else {
+			var pt = interpPoint(pts[0], pts[1], stopT)
+			dst.LineTo(pt.X, pt.Y)
+		}
+
+	case KPathVerbQuad:
+		var tmp0, tmp1 [5]Point
+		if startT == 0 {
+			if stopT == KScalar1 {
+				dst.QuadTo(pts[1].X, pts[1].Y, pts[2].X, pts[2].Y)
+			} else {
+				tmp0 = ChopQuadAt(pts, stopT)
+				dst.QuadTo(tmp0[1].X, tmp0[1].Y, tmp0[2].X, tmp0[2].Y)
+			}
+		} else {
+			tmp0 = ChopQuadAt(pts, startT)
+			if stopT == KScalar1 {
+				dst.QuadTo(tmp0[3].X, tmp0[3].Y, tmp0[4].X, tmp0[4].Y)
+			} else {
+				tmp1 = ChopQuadAt(tmp0[2:5], (stopT-startT)/(1-startT))
+				dst.QuadTo(tmp1[1].X, tmp1[1].Y, tmp1[2].X, tmp1[2].Y)
+			}
+		}
+
+	case KPathVerbCubic:
+		var tmp0, tmp1 [7]Point
+		if startT == 0 {
+			if stopT == KScalar1 {
+				dst.CubicTo(pts[1].X, pts[1].Y, pts[2].X, pts[2].Y, pts[3].X, pts[3].Y)
+			} else {
+				tmp0 = ChopCubicAt(pts, stopT)
+				dst.CubicTo(tmp0[1].X, tmp0[1].Y, tmp0[2].X, tmp0[2].Y, tmp0[3].X, tmp0[3].Y)
+			}
+		} else {
+			tmp0 = ChopCubicAt(pts, startT)
+			if stopT == KScalar1 {
+				dst.CubicTo(tmp0[4].X, tmp0[4].Y, tmp0[5].X, tmp0[5].Y, tmp0[6].X, tmp0[6].Y)
+			} else {
+				tmp1 = ChopCubicAt(tmp0[3:7], (stopT-startT)/(1-startT))
+				dst.CubicTo(tmp1[1].X, tmp1[1].Y, tmp1[2].X, tmp1[2].Y, tmp1[3].X, tmp1[3].Y)
+			}
+		}
+	}
+}
+
+/** Segment
+Given a start and stop distance, return in dst the intervening segment(s).
+If the segment is zero-length, return false, else return true.
+startD and stopD are pinned to legal values (0..Length()). If startD > stopD
+then return false (and leave dst untouched).
+Begin the segment with a moveTo if startWithMoveTo is true */
+func (measure *PathMeasure) Segment(startD, stopD Scalar, dst *Path, startWithMoveTo bool) bool {
+	var length = measure.Length() // ensure we have built our segments
+
+	if startD < 0 {
+		startD = 0
+	}
+	if stopD > length {
+		stopD = length
+	}
+	if startD > stopD || len(measure.segments) == 0 {
+		return false
+	}
+
+	var segIndex, startT = measure.distanceToSegment(startD)
+	var stopIndex, stopT = measure.distanceToSegment(stopD)
+	var seg, stopSeg = &measure.segments[segIndex], &measure.segments[stopIndex]
+
+	if startWithMoveTo {
+		var p, _ = computePosTan(measure.segmentPoints(seg), seg.verb, startT)
+		dst.MoveTo(p.X, p.Y)
+	}
+
+	if seg.ptIndex == stopSeg.ptIndex {
+		segmentTo(measure.segmentPoints(seg), seg.verb, startT, stopT, dst)
+	} else {
+		for seg.ptIndex < stopSeg.ptIndex {
+			segmentTo(measure.segmentPoints(seg), seg.verb, startT, KScalar1, dst)
+			segIndex = measure.nextSegment(segIndex)
+			seg = &measure.segments[segIndex]
+			startT = 0
+		}
+		segmentTo(measure.segmentPoints(seg), seg.verb, 0, stopT, dst)
+	}
+	return true
+}
+
+// Skip over the remaining segments that share the current segment's points.
+func (measure *PathMeasure) nextSegment(index int) int {
+	var ptIndex = measure.segments[index].ptIndex
+	for index++; index < len(measure.segments)-1; index++ {
+		if measure.segments[index].ptIndex != ptIndex {
+			break
+		}
+	}
+	return index
+}
@@ -0,0 +1,132 @@
+package ggk_test
+
+import (
+	"testing"
+
+	"github.com/amendgit/ggk"
+)
+
+func newLinePath(pts ...ggk.Point) *ggk.Path {
+	var path = ggk.NewPath()
+	path.AddPoly(pts, false)
+	return path
+}
+
+func newRectPath(r ggk.Rect) *ggk.Path {
+	var path = ggk.NewPath()
+	path.AddRect(r)
+	return path
+}
+
+func newQuadPath() *ggk.Path {
+	var path = ggk.NewPath()
+	path.MoveTo(0, 0)
+	path.QuadTo(50, 100, 100, 0)
+	return path
+}
+
+var pathMeasureLengthTests = []struct {
+	path        *ggk.Path
+	forceClosed bool
+	length      ggk.Scalar
+	tolerance   ggk.Scalar
+}{
+	{newLinePath(ggk.Point{0, 0}, ggk.Point{100, 0}), false, 100, 0.001},
+	{newLinePath(ggk.Point{0, 0}, ggk.Point{30, 40}), false, 50, 0.001},
+	{newLinePath(ggk.Point{0, 0}, ggk.Point{100, 0}, ggk.Point{100, 100}), false, 200, 0.001},
+	{newLinePath(ggk.Point{0, 0}, ggk.Point{100, 0}, ggk.Point{100, 100}), true, 341.421, 0.01},
+	{newRectPath(ggk.MakeRect(10, 10, 100, 50)), false, 300, 0.001},
+	// the arc length of the quad is ~147.89
+	{newQuadPath(), false, 147.89, 0.5},
+}
+
+func TestPathMeasureLength(t *testing.T) {
+	for _, tt := range pathMeasureLengthTests {
+		var meas = ggk.NewPathMeasure(tt.path, tt.forceClosed, 1)
+		var length = meas.Length()
+		if !ggk.ScalarNearlyEqual(length, tt.length, tt.tolerance) {
+			t.Errorf("PathMeasure(%v).Length() want %v got %v", tt.path.Points(), tt.length, length)
+		}
+	}
+}
+
+var pathMeasurePosTanTests = []struct {
+	distance ggk.Scalar
+	pos      ggk.Point
+	tangent  ggk.Point
+}{
+	{-10, ggk.Point{0, 0}, ggk.Point{1, 0}},
+	{0, ggk.Point{0, 0}, ggk.Point{1, 0}},
+	{50, ggk.Point{50, 0}, ggk.Point{1, 0}},
+	{150, ggk.Point{100, 50}, ggk.Point{0, 1}},
+	{500, ggk.Point{100, 100}, ggk.Point{0, 1}},
+}
+
+func TestPathMeasurePosTan(t *testing.T) {
+	var path = newLinePath(ggk.Point{0, 0}, ggk.Point{100, 0}, ggk.Point{100, 100})
+	var meas = ggk.NewPathMeasure(path, false, 1)
+	for _, tt := range pathMeasurePosTanTests {
+		var pos, tangent, ok = meas.PosTan(tt.distance)
+		if !ok {
+			t.Errorf("PosTan(%v) failed", tt.distance)
+			continue
+		}
+		if !ggk.ScalarNearlyEqual(pos.X, tt.pos.X, 0.001) || !ggk.ScalarNearlyEqual(pos.Y, tt.pos.Y, 0.001) ||
+			!ggk.ScalarNearlyEqual(tangent.X, tt.tangent.X, 0.001) || !ggk.ScalarNearlyEqual(tangent.Y, tt.tangent.Y, 0.001) {
+			t.Errorf("PosTan(%v) want %v %v got %v %v", tt.distance, tt.pos, tt.tangent, pos, tangent)
+		}
+	}
+}
+
+func TestPathMeasureNextContour(t *testing.T) {
+	var path = newLinePath(ggk.Point{0, 0}, ggk.Point{10, 0})
+	path.AddPoly([]ggk.Point{{0, 10}, {0, 40}}, false)
+
+	var meas = ggk.NewPathMeasure(path, false, 1)
+	var lengths = []ggk.Scalar{10, 30}
+	for i, want := range lengths {
+		if i > 0 && !meas.NextContour() {
+			t.Fatalf("NextContour() want contour %v", i)
+		}
+		if got := meas.Length(); !ggk.ScalarNearlyEqual(got, want, 0.001) {
+			t.Errorf("contour %v Length() want %v got %v", i, want, got)
+		}
+	}
+	if meas.NextContour() {
+		t.Errorf("NextContour() want false after the last contour")
+	}
+}
+
+func TestPathMeasureSegment(t *testing.T) {
+	var path = newLinePath(ggk.Point{0, 0}, ggk.Point{100, 0}, ggk.Point{100, 100})
+	var meas = ggk.NewPathMeasure(path, false, 1)
+
+	var dst = ggk.NewPath()
+	if !meas.Segment(50, 150, dst, true) {
+		t.Fatalf("Segment(50, 150) want true")
+	}
+	var want = []ggk.Point{{50, 0}, {100, 0}, {100, 50}}
+	var got = dst.Points()
+	if len(got) != len(want) {
+		t.Fatalf("Segment(50, 150) want %v got %v", want, got)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("Segment(50, 150) want %v got %v", want, got)
+			break
+		}
+	}
+
+	if meas.Segment(80, 20, ggk.NewPath(), true) {
+		t.Errorf("Segment(80, 20) want false")
+	}
+}
+
+func TestRSXformToQuad(t *testing.T) {
+	var xform = ggk.MakeRSXform(0, 2, 10, 20) // rotate 90 degrees, scale 2
+	var quad = xform.ToQuad(5, 3)
+	var want = [4]ggk.Point{{10, 20}, {10, 30}, {4, 30}, {4, 20}}
+	if quad != want {
+		t.Errorf("ToQuad(5, 3) want %v got %v", want, quad)
+	}
+}
@@ -0,0 +1,333 @@
+package ggk
+
+/** PerlinNoiseShaderType
+selects how the octaves of noise add up, following feTurbulence of SVG. */
+type PerlinNoiseShaderType int
+
+const (
+	KPerlinNoiseShaderTypeFractalNoise PerlinNoiseShaderType = iota // sum of the noise, centered on half
+	KPerlinNoiseShaderTypeTurbulence                                // sum of the absolute noise
+)
+
+const (
+	kPerlinNoiseBlockSize   = 256
+	kPerlinNoiseBlockMask   = kPerlinNoiseBlockSize - 1
+	kPerlinNoise            = 4096
+	kPerlinNoiseRandMaximum = 1<<31 - 1
+	kPerlinNoiseMaxOctaves  = 255
+)
+
+/** tPerlinNoiseShader
+shades with the Perlin noise of the SVG feTurbulence filter. The noise only
+depends on the seed and the coordinates, using integer random numbers and
+float32 arithmetic, so the same shader draws the same pixels every time. */
+type tPerlinNoiseShader struct {
+	noiseType      PerlinNoiseShaderType
+	baseFrequencyX Scalar
+	baseFrequencyY Scalar
+	numOctaves     int
+	seed           Scalar
+	tileWidth      int
+	tileHeight     int
+	stitchTiles    bool
+}
+
+/** NewFractalNoiseShader
+Return a shader of fractal noise, the SVG feTurbulence of type fractalNoise.
+baseFrequencyX and baseFrequencyY are the frequencies of the noise, usually
+in (0, 1), numOctaves is the number of octaves added, in [0, 255], and seed
+selects the noise, truncated to an integer. If tileSize is not nil and not
+empty, the frequencies are adjusted so that the noise tiles seamlessly with
+that size. Returns nil if a frequency is negative or numOctaves is out of
+range. */
+func NewFractalNoiseShader(baseFrequencyX, baseFrequencyY Scalar, numOctaves int, seed Scalar,
+	tileSize *Size) *Shader {
+	return newPerlinNoiseShader(KPerlinNoiseShaderTypeFractalNoise, baseFrequencyX, baseFrequencyY,
+		numOctaves, seed, tileSize)
+}
+
+/** NewTurbulenceShader
+Return a shader of turbulence, the SVG feTurbulence of type turbulence. See
+NewFractalNoiseShader for the parameters. */
+func NewTurbulenceShader(baseFrequencyX, baseFrequencyY Scalar, numOctaves int, seed Scalar,
+	tileSize *Size) *Shader {
+	return newPerlinNoiseShader(KPerlinNoiseShaderTypeTurbulence, baseFrequencyX, baseFrequencyY,
+		numOctaves, seed, tileSize)
+}
+
+func newPerlinNoiseShader(noiseType PerlinNoiseShaderType, baseFrequencyX, baseFrequencyY Scalar,
+	numOctaves int, seed Scalar, tileSize *Size) *Shader {
+	if !(baseFrequencyX >= 0 && baseFrequencyY >= 0) || numOctaves < 0 || numOctaves > kPerlinNoiseMaxOctaves ||
+		!ScalarIsFinite(seed) {
+		return nil
+	}
+	var impl = &tPerlinNoiseShader{
+		noiseType:      noiseType,
+		baseFrequencyX: baseFrequencyX,
+		baseFrequencyY: baseFrequencyY,
+		numOctaves:     numOctaves,
+		seed:           seed,
+	}
+	if tileSize != nil && !tileSize.IsEmpty() {
+		impl.tileWidth, impl.tileHeight = int(tileSize.Width()), int(tileSize.Height())
+		impl.stitchTiles = impl.tileWidth > 0 && impl.tileHeight > 0
+	}
+	return NewShader(impl, nil)
+}
+
+func (impl *tPerlinNoiseShader) OnCreateContext(shader *Shader, rec *ShaderContextRec) *ShaderContext {
+	var total = shader.totalMatrix(rec)
+	var context = &tPerlinNoiseShaderContext{shader: impl, matrix: NewMatrix()}
+	// the (1, 1) translation is due to the 1 based coordinates of WebKit's
+	// noise.
+	context.matrix.SetTranslate(-total.TranslateX()+KScalar1, -total.TranslateY()+KScalar1)
+	context.paintingData = newPerlinNoisePaintingData(impl, total)
+	return NewShaderContext(shader, rec, context)
+}
+
+/** tPerlinNoiseStitchData
+tells how to wrap the lattice when stitching tiles. */
+type tPerlinNoiseStitchData struct {
+	width  int // how much to subtract to wrap.
+	wrapX  int // minimum value to wrap.
+	height int
+	wrapY  int
+}
+
+/** tPerlinNoisePaintingData
+holds the lattice and gradients of the noise for a seed, and the
+frequencies and tile of the noise in device space. */
+type tPerlinNoisePaintingData struct {
+	seed            int
+	latticeSelector [kPerlinNoiseBlockSize]uint8
+	noise           [4][kPerlinNoiseBlockSize][2]uint16
+	gradient        [4][kPerlinNoiseBlockSize]Point
+	tileWidth       int
+	tileHeight      int
+	baseFrequency   Point
+	stitchDataInit  tPerlinNoiseStitchData
+}
+
+func newPerlinNoisePaintingData(shader *tPerlinNoiseShader, matrix *Matrix) *tPerlinNoisePaintingData {
+	var vec = []Point{
+		{ScalarInvert(shader.baseFrequencyX), ScalarInvert(shader.baseFrequencyY)},
+		{Scalar(shader.tileWidth), Scalar(shader.tileHeight)},
+	}
+	matrix.MapVectors(vec, vec)
+
+	var data = &tPerlinNoisePaintingData{}
+	data.baseFrequency = Point{ScalarInvert(vec[0].X), ScalarInvert(vec[0].Y)}
+	data.tileWidth, data.tileHeight = ScalarRoundToInt(vec[1].X), ScalarRoundToInt(vec[1].Y)
+	data.init(shader.seed)
+	if data.tileWidth > 0 && data.tileHeight > 0 {
+		data.stitch()
+	}
+	return data
+}
+
+// Return the next number of the Park-Miller minimal standard generator.
+func (data *tPerlinNoisePaintingData) random() int {
+	const (
+		randAmplitude = 16807  // 7**5; primitive root of m
+		randQ         = 127773 // m / a
+		randR         = 2836   // m % a
+	)
+	var result = randAmplitude*(data.seed%randQ) - randR*(data.seed/randQ)
+	if result <= 0 {
+		result += kPerlinNoiseRandMaximum
+	}
+	data.seed = result
+	return result
+}
+
+func (data *tPerlinNoisePaintingData) init(seed Scalar) {
+	const invBlockSize Scalar = 1.0 / kPerlinNoiseBlockSize
+	// half of the largest 16 bit unsigned int.
+	const halfMax16bits Scalar = 32767.5
+
+	// according to the SVG spec, the seed is truncated, not rounded, and
+	// clamped to [1, kPerlinNoiseRandMaximum - 1].
+	data.seed = ScalarTruncToInt(seed)
+	if data.seed <= 0 {
+		data.seed = -(data.seed % (kPerlinNoiseRandMaximum - 1)) + 1
+	}
+	if data.seed > kPerlinNoiseRandMaximum-1 {
+		data.seed = kPerlinNoiseRandMaximum - 1
+	}
+	for channel := 0; channel < 4; channel++ {
+		for i := 0; i < kPerlinNoiseBlockSize; i++ {
+			data.latticeSelector[i] = uint8(i)
+			data.noise[channel][i][0] = uint16(data.random() % (2 * kPerlinNoiseBlockSize))
+			data.noise[channel][i][1] = uint16(data.random() % (2 * kPerlinNoiseBlockSize))
+		}
+	}
+	for i := kPerlinNoiseBlockSize - 1; i > 0; i-- {
+		var k = data.latticeSelector[i]
+		var j = data.random() % kPerlinNoiseBlockSize
+		data.latticeSelector[i] = data.latticeSelector[j]
+		data.latticeSelector[j] = k
+	}
+
+	// permute the noise.
+	var noise = data.noise
+	for i := 0; i < kPerlinNoiseBlockSize; i++ {
+		for channel := 0; channel < 4; channel++ {
+			data.noise[channel][i] = noise[channel][data.latticeSelector[i]]
+		}
+	}
+
+	// compute the gradients from the permuted noise, and put the normalized
+	// gradients back into the noise.
+	for channel := 0; channel < 4; channel++ {
+		for i := 0; i < kPerlinNoiseBlockSize; i++ {
+			var gradient = Point{
+				Scalar(int(data.noise[channel][i][0])-kPerlinNoiseBlockSize) * invBlockSize,
+				Scalar(int(data.noise[channel][i][1])-kPerlinNoiseBlockSize) * invBlockSize,
+			}
+			gradient.Normalize()
+			data.gradient[channel][i] = gradient
+			data.noise[channel][i][0] = uint16(ScalarRoundToInt((gradient.X + KScalar1) * halfMax16bits))
+			data.noise[channel][i][1] = uint16(ScalarRoundToInt((gradient.Y + KScalar1) * halfMax16bits))
+		}
+	}
+}
+
+// Adjust the frequencies so that the tile borders are continuous, and set
+// up the initial stitch values.
+func (data *tPerlinNoisePaintingData) stitch() {
+	var tileWidth, tileHeight = Scalar(data.tileWidth), Scalar(data.tileHeight)
+	var adjust = func(frequency, tileSize Scalar) Scalar {
+		if frequency == 0 {
+			return frequency
+		}
+		var low = ScalarFloor(tileSize*frequency) / tileSize
+		var high = ScalarCeil(tileSize*frequency) / tileSize
+		if frequency/low < high/frequency {
+			return low
+		}
+		return high
+	}
+	data.baseFrequency.X = adjust(data.baseFrequency.X, tileWidth)
+	data.baseFrequency.Y = adjust(data.baseFrequency.Y, tileHeight)
+	data.stitchDataInit.width = ScalarRoundToInt(tileWidth * data.baseFrequency.X)
+	data.stitchDataInit.wrapX = kPerlinNoise + data.stitchDataInit.width
+	data.stitchDataInit.height = ScalarRoundToInt(tileHeight * data.baseFrequency.Y)
+	data.stitchDataInit.wrapY = kPerlinNoise + data.stitchDataInit.height
+}
+
+type tPerlinNoiseShaderContext struct {
+	shader       *tPerlinNoiseShader
+	matrix       *Matrix
+	paintingData *tPerlinNoisePaintingData
+}
+
+// Return t * t * (3 - 2 * t).
+func perlinNoiseSmoothCurve(t Scalar) Scalar {
+	return t * t * (3 - 2*t)
+}
+
+// Wrap noiseValue around if it is out of the current tile.
+func perlinNoiseCheckNoise(noiseValue, limitValue, newValue int) int {
+	if noiseValue >= limitValue {
+		noiseValue -= newValue
+	}
+	return noiseValue
+}
+
+func (impl *tPerlinNoiseShaderContext) noise2D(channel int, stitchData *tPerlinNoiseStitchData, noiseVector Point) Scalar {
+	var noise = func(component Scalar) (integer, next int, fraction Scalar) {
+		var position = component + kPerlinNoise
+		integer = ScalarFloorToInt(position)
+		return integer, integer + 1, position - Scalar(integer)
+	}
+	var x0, x1, fx = noise(noiseVector.X)
+	var y0, y1, fy = noise(noiseVector.Y)
+	// adjust the lattice points when stitching.
+	if impl.shader.stitchTiles {
+		x0 = perlinNoiseCheckNoise(x0, stitchData.wrapX, stitchData.width)
+		y0 = perlinNoiseCheckNoise(y0, stitchData.wrapY, stitchData.height)
+		x1 = perlinNoiseCheckNoise(x1, stitchData.wrapX, stitchData.width)
+		y1 = perlinNoiseCheckNoise(y1, stitchData.wrapY, stitchData.height)
+	}
+	x0, y0 = x0&kPerlinNoiseBlockMask, y0&kPerlinNoiseBlockMask
+	x1, y1 = x1&kPerlinNoiseBlockMask, y1&kPerlinNoiseBlockMask
+
+	var data = impl.paintingData
+	var i = int(data.latticeSelector[x0])
+	var j = int(data.latticeSelector[x1])
+	var b00 = (i + y0) & kPerlinNoiseBlockMask
+	var b10 = (j + y0) & kPerlinNoiseBlockMask
+	var b01 = (i + y1) & kPerlinNoiseBlockMask
+	var b11 = (j + y1) & kPerlinNoiseBlockMask
+	var sx = perlinNoiseSmoothCurve(fx)
+	var sy = perlinNoiseSmoothCurve(fy)
+	var gradient = &data.gradient[channel]
+	// taken 1:1 from the SVG spec of feTurbulence.
+	var fraction = Point{fx, fy} // offset (0, 0)
+	var u = PointDot(gradient[b00], fraction)
+	fraction.X -= KScalar1 // offset (-1, 0)
+	var v = PointDot(gradient[b10], fraction)
+	var a = ScalarInterpolate(u, v, sx)
+	fraction.Y -= KScalar1 // offset (-1, -1)
+	v = PointDot(gradient[b11], fraction)
+	fraction.X = fx // offset (0, -1)
+	u = PointDot(gradient[b01], fraction)
+	var b = ScalarInterpolate(u, v, sx)
+	return ScalarInterpolate(a, b, sy)
+}
+
+func (impl *tPerlinNoiseShaderContext) turbulenceValueForPoint(context *ShaderContext, channel int,
+	point Point) Scalar {
+	var stitchData tPerlinNoiseStitchData
+	if impl.shader.stitchTiles {
+		stitchData = impl.paintingData.stitchDataInit
+	}
+	var result Scalar
+	var noiseVector = Point{point.X * impl.paintingData.baseFrequency.X, point.Y * impl.paintingData.baseFrequency.Y}
+	var ratio = KScalar1
+	for octave := 0; octave < impl.shader.numOctaves; octave++ {
+		var noise = impl.noise2D(channel, &stitchData, noiseVector)
+		if impl.shader.noiseType != KPerlinNoiseShaderTypeFractalNoise {
+			noise = ScalarAbs(noise)
+		}
+		result += noise / ratio
+		noiseVector.X *= 2
+		noiseVector.Y *= 2
+		ratio *= 2
+
+		if impl.shader.stitchTiles {
+			stitchData.width *= 2
+			stitchData.wrapX = stitchData.width + kPerlinNoise
+			stitchData.height *= 2
+			stitchData.wrapY = stitchData.height + kPerlinNoise
+		}
+	}
+
+	// fractal noise is (result + 1) / 2, turbulence the result itself.
+	if impl.shader.noiseType == KPerlinNoiseShaderTypeFractalNoise {
+		result = result*KScalarHalf + KScalarHalf
+	}
+	if channel == 3 { // scale alpha by the paint's.
+		result *= Scalar(context.PaintAlpha()) / 255
+	}
+	return ScalarPin(result, 0, KScalar1)
+}
+
+func (impl *tPerlinNoiseShaderContext) shade(context *ShaderContext, point Point) PremulColor {
+	point = impl.matrix.MapXY(point.X, point.Y)
+	point.X, point.Y = ScalarRound(point.X), ScalarRound(point.Y)
+
+	var rgba [4]uint8
+	for channel := 3; channel >= 0; channel-- {
+		rgba[channel] = uint8(ScalarFloorToInt(255 * impl.turbulenceValueForPoint(context, channel, point)))
+	}
+	return PremulColor(premulPixel32(ColorWithARGB(rgba[3], rgba[0], rgba[1], rgba[2])))
+}
+
+func (impl *tPerlinNoiseShaderContext) OnShadeSpan(context *ShaderContext, x, y int, dst []PremulColor, count int) {
+	var point = Point{Scalar(x), Scalar(y)}
+	for i := 0; i < count; i++ {
+		dst[i] = impl.shade(context, point)
+		point.X += KScalar1
+	}
+}
@@ -1,5 +1,47 @@
 package ggk
 
+/** tPictureRecord
+replays one recorded canvas call into canvas. initialMatrix is the matrix
+of canvas when the playback started, which recorded matrices are relative
+to. */
+type tPictureRecord func(canvas *Canvas, initialMatrix *Matrix)
+
+/** Picture
+is an immutable recording of canvas calls, made with a PictureRecorder,
+that can be played back into any canvas. */
 type Picture struct {
+	cullRect Rect
+	records  []tPictureRecord
+	uniqueID uint32
+}
+
+var gPictureUniqueID uint32
+
+// CullRect returns the bounds given when the picture was recorded, which
+// are a hint of where it draws.
+func (pic *Picture) CullRect() Rect {
+	return pic.cullRect
+}
+
+// Return a non-zero, unique value representing the picture.
+func (pic *Picture) UniqueID() uint32 {
+	return pic.uniqueID
+}
+
+// ApproximateOpCount returns the number of recorded calls.
+func (pic *Picture) ApproximateOpCount() int {
+	return len(pic.records)
+}
 
+/** Playback
+Replay the recorded calls into canvas, on top of its current matrix and
+clip. The recorded saves are balanced, so canvas ends up in the state it
+started in. */
+func (pic *Picture) Playback(canvas *Canvas) {
+	var initialMatrix = NewMatrixClone(canvas.TotalMatrix())
+	var saveCount = canvas.SaveCount()
+	for _, record := range pic.records {
+		record(canvas, initialMatrix)
+	}
+	canvas.RestoreToCount(saveCount)
 }
@@ -0,0 +1,261 @@
+package ggk
+
+import (
+	"sync/atomic"
+)
+
+/** PictureRecorder
+records the calls made to the canvas returned by BeginRecording into a
+Picture.
+
+	var recorder = NewPictureRecorder()
+	var canvas = recorder.BeginRecording(bounds)
+	canvas.DrawPath(path, paint)
+	var picture = recorder.FinishRecordingAsPicture() */
+type PictureRecorder struct {
+	recorder *tRecordingCanvas
+	cullRect Rect
+}
+
+func NewPictureRecorder() *PictureRecorder {
+	return new(PictureRecorder)
+}
+
+/** BeginRecording
+Return the canvas that records into a new picture. bounds is the cull
+rect of the picture. Any recording in progress is dropped. */
+func (recorder *PictureRecorder) BeginRecording(bounds Rect) *Canvas {
+	recorder.recorder = newRecordingCanvas(bounds)
+	recorder.cullRect = bounds
+	return recorder.recorder.Canvas
+}
+
+// RecordingCanvas returns the recording canvas, or nil if not recording.
+func (recorder *PictureRecorder) RecordingCanvas() *Canvas {
+	if recorder.recorder == nil {
+		return nil
+	}
+	return recorder.recorder.Canvas
+}
+
+/** FinishRecordingAsPicture
+Return the picture of the calls recorded since BeginRecording, or nil if
+not recording. The saves left unbalanced are restored, and the drawables
+drawn while recording are recorded as they draw now. */
+func (recorder *PictureRecorder) FinishRecordingAsPicture() *Picture {
+	if recorder.recorder == nil {
+		return nil
+	}
+	recorder.recorder.RestoreToCount(1)
+	for _, snapshot := range recorder.recorder.drawables {
+		snapshot.picture = snapshot.drawable.NewPictureSnapshot()
+		snapshot.drawable = nil
+	}
+	var pic = &Picture{
+		cullRect: recorder.cullRect,
+		records:  recorder.recorder.records,
+		uniqueID: atomic.AddUint32(&gPictureUniqueID, 1),
+	}
+	recorder.recorder = nil
+	return pic
+}
+
+/** tRecordingCanvas
+is the CanvasImpl of a recording canvas. It keeps the matrix and depth
+like any canvas, and turns the calls that reach it into picture records
+instead of drawing. */
+type tRecordingCanvas struct {
+	*Canvas
+	records   []tPictureRecord
+	drawables []*tDrawableSnapshot
+}
+
+/** tDrawableSnapshot
+is a drawable drawn while recording. The picture draws what the drawable
+draws when the recording finishes. */
+type tDrawableSnapshot struct {
+	drawable *Drawable
+	picture  *Picture
+}
+
+func newRecordingCanvas(bounds Rect) *tRecordingCanvas {
+	var r = bounds.RoundOut()
+	var recorder = &tRecordingCanvas{
+		Canvas: NewCanvas(int(r.R()), int(r.B()), nil),
+	}
+	recorder.Canvas.Impl = recorder
+	return recorder
+}
+
+func (recorder *tRecordingCanvas) append(record tPictureRecord) {
+	recorder.records = append(recorder.records, record)
+}
+
+func (recorder *tRecordingCanvas) WillSave() {
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		canvas.Save()
+	})
+}
+
+func (recorder *tRecordingCanvas) SaveLayerStrategy(rec *CanvasSaveLayerRec) CanvasSaveLayerStrategy {
+	var saved = *rec
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		var rec = saved
+		canvas.SaveLayerWithRec(&rec)
+	})
+	return KCanvasSaveLayerStrategyNoLayer
+}
+
+func (recorder *tRecordingCanvas) WillRestore() {
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		canvas.Restore()
+	})
+}
+
+func (recorder *tRecordingCanvas) DidConcat(matrix *Matrix) {
+	var m = NewMatrixClone(matrix)
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		canvas.Concat(m)
+	})
+}
+
+func (recorder *tRecordingCanvas) DidSetMatrix(matrix *Matrix) {
+	var m = NewMatrixClone(matrix)
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		var total = NewMatrix()
+		total.SetConcat(initialMatrix, m)
+		canvas.SetMatrix(total)
+	})
+}
+
+func (recorder *tRecordingCanvas) DidTranslateZ(z Scalar) {
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		canvas.TranslateZ(z)
+	})
+}
+
+func (recorder *tRecordingCanvas) OnDrawPaint(paint *Paint) {
+	paint = paint.Clone()
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		canvas.DrawPaint(paint)
+	})
+}
+
+func (recorder *tRecordingCanvas) OnDrawRect(rect Rect, paint *Paint) {
+	paint = paint.Clone()
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		canvas.DrawRect(rect, paint)
+	})
+}
+
+func (recorder *tRecordingCanvas) OnDrawPath(path *Path, paint *Paint) {
+	path, paint = NewPathClone(path), paint.Clone()
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		canvas.DrawPath(path, paint)
+	})
+}
+
+func (recorder *tRecordingCanvas) OnDrawText(text string, x, y Scalar, paint *Paint) {
+	paint = paint.Clone()
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		canvas.DrawText(text, x, y, paint)
+	})
+}
+
+func (recorder *tRecordingCanvas) OnDrawTextBlob(blob *TextBlob, x, y Scalar, paint *Paint) {
+	paint = paint.Clone()
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		canvas.DrawTextBlob(blob, x, y, paint)
+	})
+}
+
+func (recorder *tRecordingCanvas) OnDrawVertices(vertexMode CanvasVertexMode, vertexCount int, vertices []Point,
+	texs []Point, colors []Color, xfermode *Xfermode, indices []uint16, indexCount int, paint *Paint) {
+	vertices, texs = append([]Point(nil), vertices[:vertexCount]...), clonePointsOrNil(texs, vertexCount)
+	if colors != nil {
+		colors = append([]Color(nil), colors[:vertexCount]...)
+	}
+	if indices != nil {
+		indices = append([]uint16(nil), indices[:indexCount]...)
+	}
+	paint = paint.Clone()
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		canvas.DrawVertices(vertexMode, vertexCount, vertices, texs, colors, xfermode, indices, indexCount, paint)
+	})
+}
+
+func (recorder *tRecordingCanvas) OnDrawPatch(cubics [12]Point, colors *[4]Color, texCoords *[4]Point,
+	xfermode *Xfermode, paint *Paint) {
+	if colors != nil {
+		var c = *colors
+		colors = &c
+	}
+	if texCoords != nil {
+		var t = *texCoords
+		texCoords = &t
+	}
+	paint = paint.Clone()
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		canvas.DrawPatch(cubics, colors, texCoords, xfermode, paint)
+	})
+}
+
+func (recorder *tRecordingCanvas) OnDrawAtlas(atlas *Image, xform []RSXform, tex []Rect, colors []Color, count int,
+	mode XfermodeMode, cull *Rect, paint *Paint) {
+	xform, tex = append([]RSXform(nil), xform[:count]...), append([]Rect(nil), tex[:count]...)
+	if colors != nil {
+		colors = append([]Color(nil), colors[:count]...)
+	}
+	var cullRect Rect
+	if cull != nil {
+		cullRect = *cull
+	}
+	paint = clonePaintOrNil(paint)
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		canvas.DrawAtlas(atlas, xform, tex, colors, count, mode, cullRect, paint)
+	})
+}
+
+func (recorder *tRecordingCanvas) OnDrawDrawable(drawable *Drawable, matrix *Matrix) {
+	var snapshot = &tDrawableSnapshot{drawable: drawable}
+	recorder.drawables = append(recorder.drawables, snapshot)
+	matrix = cloneMatrixOrNil(matrix)
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		canvas.DrawPicture(snapshot.picture, matrix, nil)
+	})
+}
+
+func (recorder *tRecordingCanvas) OnDrawPicture(pic *Picture, matrix *Matrix, paint *Paint) {
+	matrix, paint = cloneMatrixOrNil(matrix), clonePaintOrNil(paint)
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		canvas.DrawPicture(pic, matrix, paint)
+	})
+}
+
+func (recorder *tRecordingCanvas) OnDrawShadowedPicture(pic *Picture, matrix *Matrix, paint *Paint) {
+	matrix, paint = cloneMatrixOrNil(matrix), clonePaintOrNil(paint)
+	recorder.append(func(canvas *Canvas, initialMatrix *Matrix) {
+		canvas.DrawShadowedPicture(pic, matrix, paint)
+	})
+}
+
+func cloneMatrixOrNil(matrix *Matrix) *Matrix {
+	if matrix == nil {
+		return nil
+	}
+	return NewMatrixClone(matrix)
+}
+
+func clonePaintOrNil(paint *Paint) *Paint {
+	if paint == nil {
+		return nil
+	}
+	return paint.Clone()
+}
+
+func clonePointsOrNil(pts []Point, count int) []Point {
+	if pts == nil {
+		return nil
+	}
+	return append([]Point(nil), pts[:count]...)
+}
@@ -0,0 +1,68 @@
+package ggk
+
+import "math"
+
+// The most pixels of the bitmap of a tile.
+const kPictureShaderMaxTileArea = 2048 * 2048
+
+/** tPictureShader
+tiles a picture. The picture is drawn in a bitmap at the resolution of each
+draw, which is then tiled like an image. */
+type tPictureShader struct {
+	picture *Picture
+	tmx     ShaderTileMode
+	tmy     ShaderTileMode
+	tile    Rect
+}
+
+/** NewPictureShader
+Return a shader tiling picture in tmx horizontally and tmy vertically. The
+tile is the part of picture repeated, which is its cull rect if tile is
+nil. Returns nil if the picture is nil or the tile is empty. */
+func NewPictureShader(picture *Picture, tmx, tmy ShaderTileMode, localMatrix *Matrix, tile *Rect) *Shader {
+	if picture == nil {
+		return nil
+	}
+	var impl = &tPictureShader{picture: picture, tmx: tmx, tmy: tmy, tile: picture.CullRect()}
+	if tile != nil {
+		impl.tile = *tile
+	}
+	if impl.tile.IsEmpty() {
+		return nil
+	}
+	return NewShader(impl, localMatrix)
+}
+
+func (impl *tPictureShader) OnCreateContext(shader *Shader, rec *ShaderContextRec) *ShaderContext {
+	// draw the tile at the scale of the draw so that it isn't blurry.
+	var total = shader.totalMatrix(rec)
+	var inverse, ok = total.Invert()
+	if !ok {
+		return nil
+	}
+	var scaleX = Scalar(math.Hypot(float64(total.ScaleX()), float64(total.SkewY())))
+	var scaleY = Scalar(math.Hypot(float64(total.SkewX()), float64(total.ScaleY())))
+	// don't let a large scale allocate too much.
+	if area := impl.tile.Width * scaleX * impl.tile.Height * scaleY; area > kPictureShaderMaxTileArea {
+		var clampScale = ScalarSqrt(kPictureShaderMaxTileArea / area)
+		scaleX, scaleY = scaleX*clampScale, scaleY*clampScale
+	}
+	var width = ScalarCeilToInt(impl.tile.Width * scaleX)
+	var height = ScalarCeilToInt(impl.tile.Height * scaleY)
+	var bmp = newFilterBitmap(MakeRect(0, 0, Scalar(width), Scalar(height)))
+	if bmp == nil {
+		return nil
+	}
+	var canvas = NewCanvasBitmap(bmp)
+	var tileScaleX, tileScaleY = Scalar(width) / impl.tile.Width, Scalar(height) / impl.tile.Height
+	canvas.Scale(tileScaleX, tileScaleY)
+	canvas.Translate(-impl.tile.Left, -impl.tile.Top)
+	impl.picture.Playback(canvas)
+
+	// map the device into the pixels of the tile.
+	var matrix = NewMatrix()
+	matrix.SetScale(tileScaleX, tileScaleY)
+	matrix.PreTranslate(-impl.tile.Left, -impl.tile.Top)
+	matrix.PreConcat(inverse)
+	return NewShaderContext(shader, rec, newBitmapShaderContext(bmp, matrix, impl.tmx, impl.tmy, rec.Paint.Alpha()))
+}
@@ -0,0 +1,237 @@
+package ggk
+
+import "testing"
+
+// newTestPictureCanvas returns a canvas drawing into a transparent
+// width by height bitmap, and the pixels of the bitmap.
+func newTestPictureCanvas(width, height int) (*Canvas, *Pixmap) {
+	var bmp = new(Bitmap)
+	bmp.AllocN32Pixels(width, height, false)
+	return NewCanvasBitmap(bmp), filterPixmap(bmp)
+}
+
+func newTestPaint(color Color) *Paint {
+	var paint = NewPaint()
+	paint.SetColor(color)
+	return paint
+}
+
+func TestPictureRecorder(t *testing.T) {
+	var recorder = NewPictureRecorder()
+	if recorder.RecordingCanvas() != nil || recorder.FinishRecordingAsPicture() != nil {
+		t.Errorf("a new recorder want no recording")
+	}
+	var recording = recorder.BeginRecording(MakeRect(0, 0, 20, 20))
+	recording.Save()
+	recording.Translate(10, 0)
+	recording.TranslateZ(5)
+	recording.DrawRect(MakeRect(0, 0, 4, 4), newTestPaint(KColorRed))
+	recording.Restore()
+	recording.DrawRect(MakeRect(0, 10, 4, 4), newTestPaint(KColorBlue))
+	recording.Save()
+	recording.Translate(100, 100)
+	var pic = recorder.FinishRecordingAsPicture()
+	if pic.CullRect() != MakeRect(0, 0, 20, 20) || pic.ApproximateOpCount() != 9 || pic.UniqueID() == 0 {
+		t.Errorf("unexpected picture %v, %v ops, id %v", pic.CullRect(), pic.ApproximateOpCount(), pic.UniqueID())
+	}
+	if recorder.RecordingCanvas() != nil {
+		t.Errorf("RecordingCanvas() after finishing want nil")
+	}
+
+	var translate = NewMatrix()
+	translate.SetTranslate(0, 5)
+	var tests = []struct {
+		name   string
+		matrix *Matrix
+		dy     int
+	}{
+		{"playback", nil, 0},
+		{"translated", translate, 5},
+	}
+	for _, test := range tests {
+		var canvas, pixels = newTestPictureCanvas(20, 20)
+		if test.matrix == nil {
+			pic.Playback(canvas)
+		} else {
+			canvas.DrawPicture(pic, test.matrix, nil)
+		}
+		if canvas.SaveCount() != 1 || canvas.Z() != 0 || !canvas.TotalMatrix().IsIdentity() {
+			t.Errorf("%v left save count %v, z %v", test.name, canvas.SaveCount(), canvas.Z())
+		}
+		var checks = []struct {
+			x, y int
+			want uint32
+		}{
+			{12, 2 + test.dy, PackARGB32(0xff, 0xff, 0, 0)},
+			{2, 2 + test.dy, 0},
+			{2, 12 + test.dy, PackARGB32(0xff, 0, 0, 0xff)},
+			{12, 12 + test.dy, 0},
+		}
+		for _, check := range checks {
+			if got := pixels.Pixel32(check.x, check.y); got != check.want {
+				t.Errorf("%v pixel (%v, %v) want %#x got %#x", test.name, check.x, check.y, check.want, got)
+			}
+		}
+	}
+
+	var other = NewPictureRecorder()
+	other.BeginRecording(MakeRect(0, 0, 1, 1))
+	if id := other.FinishRecordingAsPicture().UniqueID(); id == pic.UniqueID() {
+		t.Errorf("UniqueID() want unique ids got %v twice", id)
+	}
+}
+
+func TestDrawShadowedPicture(t *testing.T) {
+	// a white ground and a white square raised above it, lit from the right.
+	var recorder = NewPictureRecorder()
+	var recording = recorder.BeginRecording(MakeRect(0, 0, 40, 20))
+	recording.DrawRect(MakeRect(0, 0, 40, 20), newTestPaint(KColorWhite))
+	recording.TranslateZ(10)
+	recording.DrawRect(MakeRect(20, 5, 10, 10), newTestPaint(KColorWhite))
+	var pic = recorder.FinishRecordingAsPicture()
+
+	var lights = NewLights(
+		NewAmbientLight(Color3f{0.2, 0.2, 0.2}),
+		NewDirectionalLight(Color3f{1, 1, 1}, Point3{1, 0, 1}),
+	)
+	var tests = []struct {
+		name   string
+		lights *Lights
+		x      int
+		want   uint32
+	}{
+		{"unlit ground", nil, 15, 0xff},
+		{"unlit square", nil, 25, 0xff},
+		{"lit ground", lights, 5, 231},
+		{"shadowed ground", lights, 15, 51},
+		{"lit square", lights, 25, 231},
+		{"ground past the square", lights, 35, 231},
+	}
+	for _, test := range tests {
+		var canvas, pixels = newTestPictureCanvas(40, 20)
+		canvas.SetLights(test.lights)
+		canvas.DrawShadowedPicture(pic, nil, nil)
+		var pixel = pixels.Pixel32(test.x, 10)
+		if got := GetPackedR32(pixel); got != test.want || GetPackedA32(pixel) != 0xff {
+			t.Errorf("%v want red %v got %#x", test.name, test.want, pixel)
+		}
+	}
+}
+
+func TestDrawShadowedPictureWithPaint(t *testing.T) {
+	var recorder = NewPictureRecorder()
+	var recording = recorder.BeginRecording(MakeRect(0, 0, 10, 10))
+	recording.DrawRect(MakeRect(0, 0, 4, 4), newTestPaint(KColorWhite))
+	var pic = recorder.FinishRecordingAsPicture()
+	var lights = NewLights(NewAmbientLight(Color3f{1, 1, 1}))
+
+	var alpha = NewPaint()
+	alpha.SetAlpha(0x80)
+	var filter = NewPaint()
+	filter.SetColorFilter(NewModeColorFilter(KColorBlue, KXfermodeModeSrcIn))
+	var tests = []struct {
+		name  string
+		paint *Paint
+		want  uint32
+	}{
+		{"no paint", nil, PackARGB32(0xff, 0xff, 0xff, 0xff)},
+		{"alpha", alpha, PackARGB32(0x80, 0x80, 0x80, 0x80)},
+		{"color filter", filter, PackARGB32(0xff, 0, 0, 0xff)},
+	}
+	for _, test := range tests {
+		var canvas, pixels = newTestPictureCanvas(10, 10)
+		var drawFilter = &tTestDrawFilter{skip: DrawFilterType(KDrawFilterTypeCount)}
+		canvas.SetDrawFilter(drawFilter)
+		canvas.SetLights(lights)
+		canvas.DrawShadowedPicture(pic, nil, test.paint)
+		if canvas.SaveCount() != 1 || !canvas.TotalMatrix().IsIdentity() {
+			t.Errorf("%v left save count %v", test.name, canvas.SaveCount())
+		}
+		if len(drawFilter.types) == 0 {
+			t.Errorf("%v want the draw filtered", test.name)
+		}
+		if got := pixels.Pixel32(2, 2); got != test.want {
+			t.Errorf("%v want %#x got %#x", test.name, test.want, got)
+		}
+		if got := pixels.Pixel32(6, 6); got != 0 {
+			t.Errorf("%v outside the picture want 0 got %#x", test.name, got)
+		}
+	}
+}
+
+func TestDrawPictureWithPaint(t *testing.T) {
+	var recorder = NewPictureRecorder()
+	var recording = recorder.BeginRecording(MakeRect(0, 0, 10, 10))
+	recording.DrawRect(MakeRect(0, 0, 4, 4), newTestPaint(KColorRed))
+	var pic = recorder.FinishRecordingAsPicture()
+
+	var alpha = NewPaint()
+	alpha.SetAlpha(0x80)
+	var filter = NewPaint()
+	filter.SetColorFilter(NewModeColorFilter(KColorBlue, KXfermodeModeSrcIn))
+	var tests = []struct {
+		name  string
+		paint *Paint
+		want  uint32
+	}{
+		{"no paint", nil, PackARGB32(0xff, 0xff, 0, 0)},
+		{"alpha", alpha, PackARGB32(0x80, 0x80, 0, 0)},
+		{"color filter", filter, PackARGB32(0xff, 0, 0, 0xff)},
+	}
+	for _, test := range tests {
+		var canvas, pixels = newTestPictureCanvas(10, 10)
+		canvas.DrawPicture(pic, nil, test.paint)
+		if canvas.SaveCount() != 1 || !canvas.TotalMatrix().IsIdentity() {
+			t.Errorf("%v left save count %v", test.name, canvas.SaveCount())
+		}
+		if got := pixels.Pixel32(2, 2); got != test.want {
+			t.Errorf("%v want %#x got %#x", test.name, test.want, got)
+		}
+		if got := pixels.Pixel32(6, 6); got != 0 {
+			t.Errorf("%v outside the picture want 0 got %#x", test.name, got)
+		}
+	}
+}
+
+func TestDrawShadowMapDepth(t *testing.T) {
+	// vertices, a sprite and an image shaded rect, all raised by 10.
+	var atlas, _ = newTestAtlas()
+	var recorder = NewPictureRecorder()
+	var recording = recorder.BeginRecording(MakeRect(0, 0, 30, 10))
+	recording.TranslateZ(10)
+	var verts = []Point{{0, 0}, {10, 0}, {0, 10}, {10, 10}}
+	var colors = []Color{KColorRed, KColorRed, KColorRed, KColorRed}
+	recording.DrawVertices(KCanvasVertexModeTriangleStrip, 4, verts, nil, colors, nil, nil, 0, NewPaint())
+	recording.DrawAtlas(atlas, []RSXform{{1, 0, 10, 0}}, []Rect{MakeRect(0, 0, 4, 4)}, []Color{KColorBlue}, 1,
+		KXfermodeModeSrc, RectZero, nil)
+	var shaded = NewPaint()
+	var local = NewMatrix()
+	local.SetTranslate(20, 0)
+	shaded.SetShader(atlas.MakeShader(KShaderTileModeClamp, KShaderTileModeClamp, local))
+	recording.DrawRect(MakeRect(20, 0, 4, 4), shaded)
+	var pic = recorder.FinishRecordingAsPicture()
+
+	var depth = PackARGB32(0xff, 10, 10, 10)
+	var tests = []struct {
+		name  string
+		x, y  int
+		color uint32
+	}{
+		{"vertices", 5, 5, PackARGB32(0xff, 0xff, 0, 0)},
+		{"sprite", 12, 2, PackARGB32(0xff, 0, 0, 0xff)},
+		{"image shader", 22, 2, PackARGB32(0xff, 0xff, 0, 0)},
+	}
+	var colorMap = filterPixmap(drawShadowMap(pic, NewMatrix(), MakeRect(0, 0, 30, 10), false, nil))
+	var depthMap = filterPixmap(drawShadowMap(pic, NewMatrix(), MakeRect(0, 0, 30, 10), true, nil))
+	for _, test := range tests {
+		if got := colorMap.Pixel32(test.x, test.y); got != test.color {
+			t.Errorf("%v color want %#x got %#x", test.name, test.color, got)
+		}
+		if got := depthMap.Pixel32(test.x, test.y); got != depth {
+			t.Errorf("%v depth want %#x got %#x", test.name, depth, got)
+		}
+	}
+	if got := depthMap.Pixel32(16, 8); got != 0 {
+		t.Errorf("depth outside the draws want 0 got %#x", got)
+	}
+}
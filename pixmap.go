@@ -1,5 +1,7 @@
 package ggk
 
+import "encoding/binary"
+
 // Pixmap pairs ImageInfo with actual pixels and rowbytes. This class does not
 // try to manage the lifetime of the pixel memory (nor the colortable if
 // provided).
@@ -11,9 +13,7 @@ type Pixmap struct {
 }
 
 func NewPixmap() *Pixmap {
-	var pixmap = &Pixmap{}
-	toimpl()
-	return pixmap
+	return &Pixmap{}
 }
 
 func (pixmap *Pixmap) Width() Scalar {
@@ -32,13 +32,53 @@ func (pixmap *Pixmap) Reset(imageInfo *ImageInfo, pixelbytes []byte, rowBytes in
 }
 
 func (pixmap *Pixmap) ColorType() ColorType {
-	toimpl()
-	return KColorTypeN32
+	return pixmap.imageInfo.ColorType()
+}
+
+func (pixmap *Pixmap) AlphaType() AlphaType {
+	return pixmap.imageInfo.AlphaType()
 }
 
 func (pixmap *Pixmap) Info() *ImageInfo {
-	toimpl()
-	return nil
+	return pixmap.imageInfo
+}
+
+func (pixmap *Pixmap) RowBytes() int {
+	return pixmap.rowBytes
+}
+
+func (pixmap *Pixmap) PixelBytes() []byte {
+	return pixmap.pixels
+}
+
+// Return the 32-bit pixel at (x, y). The pixmap must be 32 bits per pixel.
+func (pixmap *Pixmap) Pixel32(x, y int) uint32 {
+	return binary.LittleEndian.Uint32(pixmap.pixels[y*pixmap.rowBytes+x*4:])
+}
+
+// Set the 32-bit pixel at (x, y). The pixmap must be 32 bits per pixel.
+func (pixmap *Pixmap) SetPixel32(x, y int, pixel uint32) {
+	binary.LittleEndian.PutUint32(pixmap.pixels[y*pixmap.rowBytes+x*4:], pixel)
+}
+
+// Return the 8-bit pixel at (x, y). The pixmap must be 8 bits per pixel.
+func (pixmap *Pixmap) Pixel8(x, y int) uint8 {
+	return pixmap.pixels[y*pixmap.rowBytes+x]
+}
+
+// Set the 8-bit pixel at (x, y). The pixmap must be 8 bits per pixel.
+func (pixmap *Pixmap) SetPixel8(x, y int, pixel uint8) {
+	pixmap.pixels[y*pixmap.rowBytes+x] = pixel
+}
+
+// Return the 16-bit pixel at (x, y). The pixmap must be 16 bits per pixel.
+func (pixmap *Pixmap) Pixel16(x, y int) uint16 {
+	return binary.LittleEndian.Uint16(pixmap.pixels[y*pixmap.rowBytes+x*2:])
+}
+
+// Set the 16-bit pixel at (x, y). The pixmap must be 16 bits per pixel.
+func (pixmap *Pixmap) SetPixel16(x, y int, pixel uint16) {
+	binary.LittleEndian.PutUint16(pixmap.pixels[y*pixmap.rowBytes+x*2:], pixel)
 }
 
 type AutoPixmapUnlock struct {
@@ -1,5 +1,72 @@
 package ggk
 
+import "math"
+
+/** PM4f
+is a premultiplied color with float components from 0 to 1. */
 type PM4f struct {
+	R float32
+	G float32
+	B float32
+	A float32
+}
+
+// PM4fFromPremulColor returns the premultiplied 32-bit color as a PM4f.
+func PM4fFromPremulColor(color PremulColor) PM4f {
+	var c = uint32(color)
+	return PM4f{
+		float32(GetPackedR32(c)) / 255,
+		float32(GetPackedG32(c)) / 255,
+		float32(GetPackedB32(c)) / 255,
+		float32(GetPackedA32(c)) / 255,
+	}
+}
+
+/** ToPremulColor
+Return the color as a premultiplied 32-bit color, with its components
+pinned to 0..1 and its color pinned to its alpha. */
+func (pm PM4f) ToPremulColor() PremulColor {
+	var a = unitToByte(pm.A)
+	var r, g, b = unitToByte(pm.R), unitToByte(pm.G), unitToByte(pm.B)
+	return PremulColor(PackARGB32(a, minUint32(r, a), minUint32(g, a), minUint32(b, a)))
+}
+
+// Pin returns the color with its components pinned to 0..1 and its color
+// pinned to its alpha.
+func (pm PM4f) Pin() PM4f {
+	var a = pinUnit(pm.A)
+	return PM4f{
+		float32(math.Min(float64(pinUnit(pm.R)), float64(a))),
+		float32(math.Min(float64(pinUnit(pm.G)), float64(a))),
+		float32(math.Min(float64(pinUnit(pm.B)), float64(a))),
+		a,
+	}
+}
+
+// Unpremul returns the color unpremultiplied, which is transparent black
+// if the color is transparent.
+func (pm PM4f) Unpremul() Color4f {
+	if pm.A <= 0 {
+		return Color4f{}
+	}
+	var invA = 1 / pm.A
+	return Color4f{pm.R * invA, pm.G * invA, pm.B * invA, pm.A}
+}
+
+// Return the component c from 0 to 1 as a byte, rounded and pinned.
+func unitToByte(c float32) uint32 {
+	if c <= 0 {
+		return 0
+	}
+	if c >= 1 {
+		return 255
+	}
+	return uint32(c*255 + 0.5)
+}
 
-}
\ No newline at end of file
+func minUint32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
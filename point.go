@@ -9,7 +9,7 @@ var PointZero Point
 
 // Returns true iff X and Y are both zero.
 func (p *Point) IsZero() bool {
-	return p.X == 0.0 || p.Y == 0.0
+	return p.X == 0.0 && p.Y == 0.0
 }
 
 func (p *Point) SetXY(x, y Scalar) {
@@ -23,3 +23,45 @@ func (p *Point) Negate() {
 func (p *Point) Equal(otr Point) bool {
 	return p.X == otr.X && p.Y == otr.Y
 }
+
+func MakePoint(x, y Scalar) Point {
+	return Point{x, y}
+}
+
+// Returns the euclidian distance from (0,0) to (x,y).
+func (p Point) Length() Scalar {
+	return ScalarSqrt(p.X*p.X + p.Y*p.Y)
+}
+
+// Returns the euclidian distance between a and b.
+func PointDistance(a, b Point) Scalar {
+	return MakePoint(a.X-b.X, a.Y-b.Y).Length()
+}
+
+// Scale the point's coordinates by scale.
+func (p *Point) Scale(scale Scalar) {
+	p.X, p.Y = p.X*scale, p.Y*scale
+}
+
+// Set the point (vector) to be unit-length in the same direction as it
+// already points. If the point has a degenerate length (i.e. nearly 0)
+// then set it to (0,0) and return false; otherwise return true.
+func (p *Point) Normalize() bool {
+	var length = p.Length()
+	if length <= KScalarNearlyZero {
+		p.X, p.Y = 0, 0
+		return false
+	}
+	p.Scale(1 / length)
+	return true
+}
+
+// Returns the dot product of a and b, treating them as 2D vectors.
+func PointDot(a, b Point) Scalar {
+	return a.X*b.X + a.Y*b.Y
+}
+
+// Returns the cross product of a and b, treating them as 2D vectors.
+func PointCross(a, b Point) Scalar {
+	return a.X*b.Y - a.Y*b.X
+}
@@ -0,0 +1,47 @@
+package ggk
+
+/** Point3
+is a point or a vector in 3D, as used by lights, whose Z points out of the
+screen. */
+type Point3 struct {
+	X Scalar
+	Y Scalar
+	Z Scalar
+}
+
+func MakePoint3(x, y, z Scalar) Point3 {
+	return Point3{x, y, z}
+}
+
+// Returns the euclidian distance from (0,0,0) to (x,y,z).
+func (p Point3) Length() Scalar {
+	return ScalarSqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z)
+}
+
+// Scale the point's coordinates by scale.
+func (p *Point3) Scale(scale Scalar) {
+	p.X, p.Y, p.Z = p.X*scale, p.Y*scale, p.Z*scale
+}
+
+// Set the point (vector) to be unit-length in the same direction as it
+// already points. If the point has a degenerate length (i.e. nearly 0)
+// then set it to (0,0,0) and return false; otherwise return true.
+func (p *Point3) Normalize() bool {
+	var length = p.Length()
+	if length <= KScalarNearlyZero {
+		p.X, p.Y, p.Z = 0, 0, 0
+		return false
+	}
+	p.Scale(1 / length)
+	return true
+}
+
+// Returns a - b.
+func Point3Sub(a, b Point3) Point3 {
+	return Point3{a.X - b.X, a.Y - b.Y, a.Z - b.Z}
+}
+
+// Returns the dot product of a and b.
+func Point3Dot(a, b Point3) Scalar {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
+}
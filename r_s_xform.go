@@ -8,5 +8,52 @@ package ggk
  *  [     0          0      1 ]
  */
 type RSXform struct {
+	SCos Scalar
+	SSin Scalar
+	Tx   Scalar
+	Ty   Scalar
+}
+
+func MakeRSXform(scos, ssin, tx, ty Scalar) RSXform {
+	return RSXform{scos, ssin, tx, ty}
+}
+
+/**
+ *  Initialize a new xform based on the scale, rotation (in radians), final tx,ty location
+ *  and center-point (ax,ay) within the src quad.
+ *
+ *  Note: the anchor point is not normalized (e.g. 0...1) but is in pixels of the src image.
+ */
+func MakeRSXformFromRadians(scale, radians, tx, ty, ax, ay Scalar) RSXform {
+	var s = ScalarSin(radians) * scale
+	var c = ScalarCos(radians) * scale
+	return MakeRSXform(c, s, tx+-c*ax+s*ay, ty+-s*ax-c*ay)
+}
+
+// Returns true if the xform only scales and translates, so an axis aligned
+// rect maps to an axis aligned rect.
+func (xform RSXform) RectStaysRect() bool {
+	return xform.SCos == 0 || xform.SSin == 0
+}
+
+func (xform *RSXform) SetIdentity() {
+	xform.SCos, xform.SSin, xform.Tx, xform.Ty = 1, 0, 0, 0
+}
+
+func (xform *RSXform) Set(scos, ssin, tx, ty Scalar) {
+	xform.SCos, xform.SSin, xform.Tx, xform.Ty = scos, ssin, tx, ty
+}
+
+// ToQuad maps the rect [0, 0, width, height] through the xform and returns
+// the resulting quad in clockwise order starting at the top left corner.
+func (xform RSXform) ToQuad(width, height Scalar) [4]Point {
+	var m00, m01, m10, m11 = xform.SCos, -xform.SSin, xform.SSin, xform.SCos
+	var m02, m12 = xform.Tx, xform.Ty
 
+	var quad [4]Point
+	quad[0].SetXY(m02, m12)
+	quad[1].SetXY(m00*width+m02, m10*width+m12)
+	quad[2].SetXY(m00*width+m01*height+m02, m10*width+m11*height+m12)
+	quad[3].SetXY(m01*height+m02, m11*height+m12)
+	return quad
 }
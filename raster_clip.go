@@ -11,8 +11,10 @@ type RasterClip struct {
 }
 
 func NewRasterClipClone(otr *RasterClip) *RasterClip {
-	toimpl()
-	return &RasterClip{}
+	var clip = *otr
+	var bw, aaclip = *otr.bw, *otr.aaclip
+	clip.bw, clip.aaclip = &bw, &aaclip
+	return &clip
 }
 
 func NewRasterClip(forceConservativeRects bool) *RasterClip {
@@ -36,6 +38,13 @@ func (clip *RasterClip) IsBW() bool {
 	return clip.isBW
 }
 
+func (clip *RasterClip) Bounds() Rect {
+	if clip.isBW {
+		return clip.bw.Bounds()
+	}
+	return clip.aaclip.Bounds()
+}
+
 func (clip *RasterClip) SetRect(rect Rect) bool {
 	clip.isBW = true
 	clip.aaclip.SetEmpty()
@@ -49,12 +58,35 @@ func (clip *RasterClip) BWRgn() *Region {
 	return nil
 }
 
+/** Translate
+Set otr, which may be clip itself, to clip moved by (x, y). Only clips
+that are rectangles can be moved. */
 func (clip *RasterClip) Translate(x, y Scalar, otr *RasterClip) {
-	toimpl()
+	if !clip.isEmpty && !clip.isRect {
+		toimpl()
+		return
+	}
+	var bounds = clip.Bounds()
+	bounds.Offset(x, y)
+	otr.SetRect(bounds)
 }
 
+/** Op
+Combine rect into the clip. Clips stay rectangles: they can be replaced
+or intersected with rect, the other ops are not supported. */
 func (clip *RasterClip) Op(rect Rect, op RegionOp) {
-	toimpl()
+	switch op {
+	case KRegionOpReplace:
+		clip.SetRect(rect)
+	case KRegionOpIntersect:
+		var bounds = clip.Bounds()
+		if clip.isEmpty || !bounds.Intersect(rect) {
+			bounds = RectZero
+		}
+		clip.SetRect(bounds)
+	default:
+		toimpl()
+	}
 }
 
 func (clip *RasterClip) ForceGetBW() *Region {
@@ -37,35 +37,158 @@ package ggk
  * TODO: explain EasyFn and SK_RASTER_STAGE
  */
 type RasterPipeline struct {
+	body []RasterPipelineStage
+	tail []RasterPipelineStage
 }
 
+/** Append
+Add a stage to the pipeline. bodyFunc runs on the pixels in groups of 4 and
+tailFunc on the pixels left over, each with its own context. */
 func (pipeline *RasterPipeline) Append(bodyFunc RasterPipelineFunc, bodyContext interface{},
 	tailFunc RasterPipelineFunc, tailContext interface{}) {
-	toimpl()
+	pipeline.body = append(pipeline.body, RasterPipelineStage{bodyFunc, bodyContext})
+	pipeline.tail = append(pipeline.tail, RasterPipelineStage{tailFunc, tailContext})
+}
+
+// AppendStage adds a stage running fn with context on all pixels.
+func (pipeline *RasterPipeline) AppendStage(fn RasterPipelineFunc, context interface{}) {
+	pipeline.Append(fn, context, fn, context)
+}
+
+// Extend adds the stages of other to the pipeline.
+func (pipeline *RasterPipeline) Extend(other *RasterPipeline) {
+	pipeline.body = append(pipeline.body, other.body...)
+	pipeline.tail = append(pipeline.tail, other.tail...)
+}
+
+// NumStages returns the number of stages of the pipeline.
+func (pipeline *RasterPipeline) NumStages() int {
+	return len(pipeline.body)
+}
+
+/** Run
+Run the stages of the pipeline in order on each of pixels. */
+func (pipeline *RasterPipeline) Run(pixels []RasterPipelinePixel) {
+	var bodyCount = len(pixels) &^ 3
+	for i := range pixels {
+		var stages = pipeline.body
+		if i >= bodyCount {
+			stages = pipeline.tail
+		}
+		for _, stage := range stages {
+			stage.Func.Run(&pixels[i], stage.Context)
+		}
+	}
 }
 
 type RasterPipelineStage struct {
-	// It makes Next() a good bit cheaper if we hold the next function to call here,
-	// rather than logically simpler choice of the function implementing this stage.
-	Next    *RasterPipelineStage
+	Func    RasterPipelineFunc
 	Context interface{}
 }
 
-// toimpl
+/** RasterPipelinePixel
+holds the registers of a pixel going through a pipeline: the source color
+R, G, B, A, which the shader stages set, and the destination color DR, DG,
+DB, DA, which the transfer modes blend with. Colors are premultiplied
+between stages unless a stage says otherwise. */
+type RasterPipelinePixel struct {
+	R, G, B, A     float32
+	DR, DG, DB, DA float32
+}
+
+// Src returns the source color.
+func (pixel *RasterPipelinePixel) Src() PM4f {
+	return PM4f{pixel.R, pixel.G, pixel.B, pixel.A}
+}
+
+// SetSrc sets the source color.
+func (pixel *RasterPipelinePixel) SetSrc(color PM4f) {
+	pixel.R, pixel.G, pixel.B, pixel.A = color.R, color.G, color.B, color.A
+}
+
+// Dst returns the destination color.
+func (pixel *RasterPipelinePixel) Dst() PM4f {
+	return PM4f{pixel.DR, pixel.DG, pixel.DB, pixel.DA}
+}
+
+// SetDst sets the destination color.
+func (pixel *RasterPipelinePixel) SetDst(color PM4f) {
+	pixel.DR, pixel.DG, pixel.DB, pixel.DA = color.R, color.G, color.B, color.A
+}
+
+/** RasterPipelineFunc
+is what a stage of a pipeline does to each pixel, with context the context
+the stage was appended with. */
 type RasterPipelineFunc interface {
-	// empty
+	Run(pixel *RasterPipelinePixel, context interface{})
 }
 
-// toimpl
+// ConstantColor sets the source color to its PM4f context.
 type ConstantColor struct {
 	// empty
 }
 
 var constantColor *ConstantColor = &ConstantColor{}
 
-// toimpl
+func (fn *ConstantColor) Run(pixel *RasterPipelinePixel, context interface{}) {
+	pixel.SetSrc(context.(PM4f))
+}
+
+// SrcOver blends the source color over the destination color into the
+// source color.
 type SrcOver struct {
 	// empty
 }
 
-var srcOver *SrcOver = &SrcOver{}
\ No newline at end of file
+var srcOver *SrcOver = &SrcOver{}
+
+func (fn *SrcOver) Run(pixel *RasterPipelinePixel, context interface{}) {
+	pixel.SetSrc(blendPM4f(KXfermodeModeSrcOver, pixel.Src(), pixel.Dst()))
+}
+
+// tMoveSrcToDst copies the source color to the destination color.
+type tMoveSrcToDst struct{}
+
+var moveSrcToDst = &tMoveSrcToDst{}
+
+func (fn *tMoveSrcToDst) Run(pixel *RasterPipelinePixel, context interface{}) {
+	pixel.SetDst(pixel.Src())
+}
+
+// tUnpremul unpremultiplies the source color.
+type tUnpremul struct{}
+
+var unpremul = &tUnpremul{}
+
+func (fn *tUnpremul) Run(pixel *RasterPipelinePixel, context interface{}) {
+	var color = pixel.Src().Unpremul()
+	pixel.SetSrc(PM4f{color.R, color.G, color.B, color.A})
+}
+
+// tPremul premultiplies the unpremultiplied source color.
+type tPremul struct{}
+
+var premul = &tPremul{}
+
+func (fn *tPremul) Run(pixel *RasterPipelinePixel, context interface{}) {
+	pixel.R, pixel.G, pixel.B = pixel.R*pixel.A, pixel.G*pixel.A, pixel.B*pixel.A
+}
+
+// tClamp pins each component of the source color to 0..1.
+type tClamp struct{}
+
+var clamp01 = &tClamp{}
+
+func (fn *tClamp) Run(pixel *RasterPipelinePixel, context interface{}) {
+	pixel.R, pixel.G, pixel.B, pixel.A = pinUnit(pixel.R), pinUnit(pixel.G), pinUnit(pixel.B), pinUnit(pixel.A)
+}
+
+// tXfermodeStage blends the source color with the destination color in
+// its XfermodeMode context into the source color.
+type tXfermodeStage struct{}
+
+var xfermodeStage = &tXfermodeStage{}
+
+func (fn *tXfermodeStage) Run(pixel *RasterPipelinePixel, context interface{}) {
+	pixel.SetSrc(blendPM4f(context.(XfermodeMode), pixel.Src(), pixel.Dst()))
+}
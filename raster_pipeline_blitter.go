@@ -14,7 +14,7 @@ type Effect interface {
 }
 
 func appendEffectStages(effect Effect, pipeline *RasterPipeline) bool {
-	return effect != nil || effect.AppendStages(pipeline)
+	return effect == nil || effect.AppendStages(pipeline)
 }
 
 func support(info *ImageInfo) bool {
@@ -36,7 +36,7 @@ func support(info *ImageInfo) bool {
 }
 
 func NewRasterPipelineBlitter(dst *Pixmap, paint *Paint) *RasterPipelineBlitter {
-	if support(dst.Info()) {
+	if !support(dst.Info()) {
 		return nil
 	}
 
@@ -44,8 +44,11 @@ func NewRasterPipelineBlitter(dst *Pixmap, paint *Paint) *RasterPipelineBlitter
 		return nil // TODO: need to work out how shaders and their contexts work.
 	}
 
-	var shader, colorFilter, xfermode *RasterPipeline
-	if !appendEffectStages(paint.ColorFilter(), colorFilter) || !appendEffectStages(paint.Xfermode(), xfermode) {
+	var shader, colorFilter, xfermode = new(RasterPipeline), new(RasterPipeline), new(RasterPipeline)
+	if cf := paint.ColorFilter(); cf != nil && !appendEffectStages(cf, colorFilter) {
+		return nil
+	}
+	if !appendEffectStages(paint.Xfermode(), xfermode) {
 		return nil
 	}
 
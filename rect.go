@@ -83,7 +83,7 @@ func (rect Rect) CenterY() Scalar {
 
 // Return true if the rectangle's width or height are <= 0
 func (rect Rect) IsEmpty() bool {
-	return rect.Left <= 0 || rect.Height <= 0
+	return rect.Width <= 0 || rect.Height <= 0
 }
 
 func (rect Rect) SetEmpty() {
@@ -113,8 +113,18 @@ func (rect *Rect) SetLTRBPoint(lt, rb Point) {
 }
 
 func (rect *Rect) IntersectXYWH(x, y, w, h Scalar) bool {
-	toimpl()
-	return false
+	return rect.Intersect(MakeRect(x, y, w, h))
+}
+
+// If the rectangle intersects r, set it to the intersection and return
+// true. Otherwise return false and leave the rectangle unchanged.
+func (rect *Rect) Intersect(r Rect) bool {
+	if !rect.Intersects(r) {
+		return false
+	}
+	rect.SetLTRB(ScalarMax(rect.L(), r.L()), ScalarMax(rect.T(), r.T()),
+		ScalarMin(rect.R(), r.R()), ScalarMin(rect.B(), r.B()))
+	return true
 }
 
 func (rect *Rect) InsetLTRB(left, top, right, bottom Scalar) {
@@ -127,3 +137,64 @@ func (rect *Rect) InsetLTRB(left, top, right, bottom Scalar) {
 func (rect Rect) ToGoRect() image.Rectangle {
 	return image.Rect(int(rect.Left), int(rect.Top), int(rect.Width), int(rect.Height))
 }
+
+// Set the rectangle to the bounds of the points. If the slice is empty the
+// rectangle is set to empty.
+func (rect *Rect) SetBoundsPoints(pts []Point) {
+	if len(pts) == 0 {
+		rect.SetXYWH(0, 0, 0, 0)
+		return
+	}
+	var l, t, r, b = pts[0].X, pts[0].Y, pts[0].X, pts[0].Y
+	for _, pt := range pts[1:] {
+		l, t = ScalarMin(l, pt.X), ScalarMin(t, pt.Y)
+		r, b = ScalarMax(r, pt.X), ScalarMax(b, pt.Y)
+	}
+	rect.SetLTRB(l, t, r, b)
+}
+
+// Offset the rectangle by adding dx to its left and right edges, and
+// adding dy to its top and bottom edges.
+func (rect *Rect) Offset(dx, dy Scalar) {
+	rect.Left, rect.Top = rect.Left+dx, rect.Top+dy
+}
+
+// RoundOut returns the smallest rectangle with integer edges that contains
+// the rectangle.
+func (rect Rect) RoundOut() Rect {
+	var r Rect
+	r.SetLTRB(ScalarFloor(rect.L()), ScalarFloor(rect.T()), ScalarCeil(rect.R()), ScalarCeil(rect.B()))
+	return r
+}
+
+// Outset the rectangle by dx on the left and right, and dy on the top and
+// bottom.
+func (rect *Rect) Outset(dx, dy Scalar) {
+	rect.Left, rect.Top = rect.Left-dx, rect.Top-dy
+	rect.Width, rect.Height = rect.Width+2*dx, rect.Height+2*dy
+}
+
+// Update the rectangle to enclose itself and the specified rectangle. If
+// the specified rectangle is empty, do nothing. If this rectangle is empty,
+// just set it to the specified rectangle.
+func (rect *Rect) Join(r Rect) {
+	if r.Width <= 0 || r.Height <= 0 {
+		return
+	}
+	if rect.Width <= 0 || rect.Height <= 0 {
+		*rect = r
+		return
+	}
+	rect.SetLTRB(ScalarMin(rect.L(), r.L()), ScalarMin(rect.T(), r.T()),
+		ScalarMax(rect.R(), r.R()), ScalarMax(rect.B(), r.B()))
+}
+
+// Returns true if the two rectangles intersect.
+func (rect Rect) Intersects(r Rect) bool {
+	return rect.L() < r.R() && r.L() < rect.R() && rect.T() < r.B() && r.T() < rect.B()
+}
+
+// Returns true if (x, y) is inside the rectangle.
+func (rect Rect) Contains(x, y Scalar) bool {
+	return x >= rect.L() && x < rect.R() && y >= rect.T() && y < rect.B()
+}
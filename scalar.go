@@ -212,6 +212,11 @@ func ScalarMin(a, b Scalar) Scalar {
 	return a
 }
 
+// ScalarPin returns x clamped to the range [min, max].
+func ScalarPin(x, min, max Scalar) Scalar {
+	return ScalarMax(ScalarMin(x, max), min)
+}
+
 func ScalarIsInteger(x Scalar) bool {
 	return x == Scalar(int(x))
 }
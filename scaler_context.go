@@ -0,0 +1,188 @@
+package ggk
+
+import "encoding/binary"
+
+type ScalerContextFlags uint32
+
+const (
+	KScalerContextFlagFrameAndFill = ScalerContextFlags(1 << iota)
+	KScalerContextFlagDevKernText
+	KScalerContextFlagEmbeddedBitmapText
+	KScalerContextFlagEmbolden
+	KScalerContextFlagSubpixelPositioning
+	KScalerContextFlagForceAutohinting
+	KScalerContextFlagVertical
+	KScalerContextFlagLinearMetrics
+	KScalerContextFlagLCDVertical // LCD subpixels are stacked vertically.
+	KScalerContextFlagLCDBGR      // LCD subpixels are ordered blue, green, red.
+)
+
+/** ScalerContextRec
+describes everything a scaler context needs to know to produce glyphs
+for a typeface: the text size, the text and device transform, the mask
+format and the rendering flags. */
+type ScalerContextRec struct {
+	TextSize  Scalar
+	PreScaleX Scalar
+	PreSkewX  Scalar
+	Post2x2   [2][2]Scalar
+
+	Flags      ScalerContextFlags
+	Hinting    PaintHinting
+	MaskFormat MaskFormat
+
+	// ForegroundColor fills the foreground layers of COLR glyphs. It is
+	// the opaque color of the paint for typefaces with such glyphs, and
+	// zero otherwise so the glyphs of other colors share a cache.
+	ForegroundColor Color
+}
+
+// Matrix returns the full transform from font units (one em at size 1) to
+// device space described by the rec.
+func (rec *ScalerContextRec) Matrix() *Matrix {
+	var matrix = NewMatrix()
+	matrix.SetAll(rec.TextSize*rec.PreScaleX, rec.TextSize*rec.PreSkewX, 0,
+		0, rec.TextSize, 0,
+		0, 0, 1)
+	var post = NewMatrix()
+	post.SetAll(rec.Post2x2[0][0], rec.Post2x2[0][1], 0,
+		rec.Post2x2[1][0], rec.Post2x2[1][1], 0,
+		0, 0, 1)
+	matrix.PostConcat(post)
+	return matrix
+}
+
+// Glyphs larger than this many pixels are not worth rendering with LCD
+// subpixels; they are drawn as A8 instead.
+const kMaxSizeForLCDText = 256
+
+// Return true if glyphs drawn with rec are too big for LCD text.
+func (rec *ScalerContextRec) tooBigForLCD() bool {
+	var scale = ScalarMax(
+		ScalarAbs(rec.Post2x2[0][0])+ScalarAbs(rec.Post2x2[0][1]),
+		ScalarAbs(rec.Post2x2[1][0])+ScalarAbs(rec.Post2x2[1][1]))
+	return rec.TextSize*scale > kMaxSizeForLCDText
+}
+
+/** ScalerContext
+is implemented by font backends to turn glyph IDs into metrics, images and
+outlines for the settings described by a ScalerContextRec. */
+type ScalerContext interface {
+	GlyphCount() int
+	CharToGlyphID(uni Unichar) GlyphID
+	GenerateMetrics(glyph *Glyph)
+	GenerateImage(glyph *Glyph)
+	GeneratePath(glyph *Glyph, path *Path)
+	GenerateFontMetrics(metrics *PaintFontMetrics)
+}
+
+// tEmptyScalerContext is used for typefaces without a backend. It has no
+// glyphs, so everything it draws is empty.
+type tEmptyScalerContext struct {
+}
+
+func (ctx *tEmptyScalerContext) GlyphCount() int {
+	return 0
+}
+
+func (ctx *tEmptyScalerContext) CharToGlyphID(uni Unichar) GlyphID {
+	return 0
+}
+
+func (ctx *tEmptyScalerContext) GenerateMetrics(glyph *Glyph) {
+	glyph.AdvanceX, glyph.AdvanceY = 0, 0
+	glyph.Width, glyph.Height = 0, 0
+}
+
+func (ctx *tEmptyScalerContext) GenerateImage(glyph *Glyph) {
+	// empty
+}
+
+func (ctx *tEmptyScalerContext) GeneratePath(glyph *Glyph, path *Path) {
+	path.Reset()
+}
+
+func (ctx *tEmptyScalerContext) GenerateFontMetrics(metrics *PaintFontMetrics) {
+	*metrics = PaintFontMetrics{}
+}
+
+// The FIR filter applied across the subpixels of an LCD mask to reduce color
+// fringing. Its taps sum to 256.
+var gLCDFilter = [5]int{0x08, 0x4d, 0x56, 0x4d, 0x08}
+
+/** packA8ToLCD16
+downsamples src, a coverage mask rendered at three times the resolution
+along the subpixel axis of flags (horizontal unless kLCDVertical is set), to
+the width x height LCD16 image dst. */
+func packA8ToLCD16(src []byte, srcRowBytes int, dst []byte, dstRowBytes int, width, height int,
+	flags ScalerContextFlags) {
+	var vertical = flags&KScalerContextFlagLCDVertical != 0
+	var sample = func(x, y, sub int) int {
+		if vertical {
+			y = 3*y + sub
+			if y < 0 || y >= 3*height {
+				return 0
+			}
+		} else {
+			x = 3*x + sub
+			if x < 0 || x >= 3*width {
+				return 0
+			}
+		}
+		return int(src[y*srcRowBytes+x])
+	}
+	var filter = func(x, y, sub int) uint32 {
+		var sum = 0
+		for i, c := range gLCDFilter {
+			sum += c * sample(x, y, sub+i-2)
+		}
+		return uint32(sum >> 8)
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var r, g, b = filter(x, y, 0), filter(x, y, 1), filter(x, y, 2)
+			if flags&KScalerContextFlagLCDBGR != 0 {
+				r, b = b, r
+			}
+			binary.LittleEndian.PutUint16(dst[y*dstRowBytes+x*2:], packLCD16(r, g, b))
+		}
+	}
+}
+
+/** generateImageFromPath
+renders the glyph's outline, which is in device space relative to the
+glyph's origin, into its image in the glyph's mask format. It is used for
+scaler contexts which only generate outlines. */
+func generateImageFromPath(glyph *Glyph, path *Path, flags ScalerContextFlags) {
+	var rowBytes = glyph.RowBytes()
+	glyph.Image = make([]byte, rowBytes*glyph.Height)
+	if path == nil {
+		return
+	}
+
+	var matrix = NewMatrix()
+	matrix.SetTranslate(-Scalar(glyph.Left), -Scalar(glyph.Top))
+	switch glyph.MaskFormat {
+	case KMaskFormatLCD16:
+		var width, height = glyph.Width, glyph.Height
+		if flags&KScalerContextFlagLCDVertical != 0 {
+			matrix.PostScale(1, 3)
+			height *= 3
+		} else {
+			matrix.PostScale(3, 1)
+			width *= 3
+		}
+		var a8 = make([]byte, width*height)
+		scanFillPathToA8(path, matrix, a8, width, height, width)
+		packA8ToLCD16(a8, width, glyph.Image, rowBytes, glyph.Width, glyph.Height, flags)
+
+	case KMaskFormatBW:
+		var a8 = make([]byte, glyph.Width*glyph.Height)
+		scanFillPathToA8(path, matrix, a8, glyph.Width, glyph.Height, glyph.Width)
+		packA8ToBW(a8, glyph.Width, glyph.Image, rowBytes, glyph.Width, glyph.Height)
+
+	case KMaskFormatA8:
+		scanFillPathToA8(path, matrix, glyph.Image, glyph.Width, glyph.Height, rowBytes)
+	}
+}
@@ -0,0 +1,216 @@
+package ggk
+
+import "math"
+
+// Curves are flattened until each line segment deviates from the curve by
+// no more than this many pixels.
+const kScanPathFlattenTolerance = 0.25
+
+/** tCoverageAccumulator
+computes exact area coverage of a path by accumulating the signed area each
+edge contributes to the cells it crosses, then integrating the cells of a
+row from left to right. Each row has two extra cells so the edges touching
+the right border can spill without wrapping into the next row. */
+type tCoverageAccumulator struct {
+	cells  []float32
+	width  int
+	height int
+	stride int
+}
+
+func newCoverageAccumulator(width, height int) *tCoverageAccumulator {
+	var stride = width + 2
+	return &tCoverageAccumulator{
+		cells:  make([]float32, stride*height),
+		width:  width,
+		height: height,
+		stride: stride,
+	}
+}
+
+func (acc *tCoverageAccumulator) clampX(x Scalar) Scalar {
+	return ScalarPin(x, 0, Scalar(acc.width))
+}
+
+func (acc *tCoverageAccumulator) line(p0, p1 Point) {
+	if p0.Y == p1.Y {
+		return
+	}
+	var dir float32 = 1
+	if p0.Y > p1.Y {
+		p0, p1 = p1, p0
+		dir = -1
+	}
+	var dxdy = (p1.X - p0.X) / (p1.Y - p0.Y)
+	var x = p0.X
+	var y0 = ScalarMax(p0.Y, 0)
+	if p0.Y < 0 {
+		x -= p0.Y * dxdy
+	}
+	var yEnd = ScalarMin(p1.Y, Scalar(acc.height))
+	for y := ScalarFloorToInt(y0); Scalar(y) < yEnd; y++ {
+		var row = acc.cells[y*acc.stride : (y+1)*acc.stride]
+		var dy = ScalarMin(Scalar(y+1), p1.Y) - ScalarMax(Scalar(y), p0.Y)
+		var xNext = x + dxdy*dy
+		var d = float32(dy) * dir
+
+		var xa, xb = acc.clampX(x), acc.clampX(xNext)
+		if xa > xb {
+			xa, xb = xb, xa
+		}
+		var xaFloor = ScalarFloor(xa)
+		var xai = int(xaFloor)
+		var xbCeil = ScalarCeil(xb)
+		var xbi = int(xbCeil)
+		if xbi <= xai+1 {
+			// the edge stays within a single cell.
+			var xmf = float32(0.5*(xa+xb) - xaFloor)
+			row[xai] += d - d*xmf
+			row[xai+1] += d * xmf
+		} else {
+			var s = float32(1 / (xb - xa))
+			var xaf = float32(xa - xaFloor)
+			var a0 = 0.5 * s * (1 - xaf) * (1 - xaf)
+			var xbf = float32(xb - xbCeil + 1)
+			var am = 0.5 * s * xbf * xbf
+			row[xai] += d * a0
+			if xbi == xai+2 {
+				row[xai+1] += d * (1 - a0 - am)
+			} else {
+				var a1 = s * (1.5 - xaf)
+				row[xai+1] += d * (a1 - a0)
+				for xi := xai + 2; xi < xbi-1; xi++ {
+					row[xi] += d * s
+				}
+				var a2 = a1 + float32(xbi-xai-3)*s
+				row[xbi-1] += d * (1 - a2 - am)
+			}
+			row[xbi] += d * am
+		}
+		x = xNext
+	}
+}
+
+// Return how many lines a curve is flattened into, given how far its
+// control points are from a straight line and the length of its chord.
+func quadOrCubicSubdivisions(dev, chord Scalar) int {
+	var count = int(ScalarCeil(ScalarSqrt(dev / kScanPathFlattenTolerance)))
+	if count < 1 {
+		count = 1
+	}
+	if limit := int(chord) + 16; count > limit {
+		count = limit
+	}
+	return count
+}
+
+// Integrate the accumulated cells into coverage values in [0, 1] according
+// to fillType and write them to dst as 8-bit alpha.
+func (acc *tCoverageAccumulator) resolve(fillType PathFillType, dst []byte, rowBytes int) {
+	var evenOdd = fillType == KPathFillTypeEvenOdd || fillType == KPathFillTypeInverseEvenOdd
+	var inverse = fillType == KPathFillTypeInverseWinding || fillType == KPathFillTypeInverseEvenOdd
+	for y := 0; y < acc.height; y++ {
+		var row = acc.cells[y*acc.stride:]
+		var out = dst[y*rowBytes:]
+		var sum float32
+		for x := 0; x < acc.width; x++ {
+			sum += row[x]
+			var coverage = float32(math.Abs(float64(sum)))
+			if evenOdd {
+				coverage = float32(math.Mod(float64(coverage), 2))
+				if coverage > 1 {
+					coverage = 2 - coverage
+				}
+			} else if coverage > 1 {
+				coverage = 1
+			}
+			if inverse {
+				coverage = 1 - coverage
+			}
+			out[x] = byte(coverage*255 + 0.5)
+		}
+	}
+}
+
+/** scanFillPathToA8
+rasterizes path, transformed by matrix (which may be nil), into an A8 image
+of width x height pixels. Pixels outside the image are ignored. */
+func scanFillPathToA8(path *Path, matrix *Matrix, dst []byte, width, height, rowBytes int) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	var devPath = path
+	if matrix != nil && !matrix.IsIdentity() {
+		devPath = NewPath()
+		path.Transform(matrix, devPath)
+	}
+
+	var acc = newCoverageAccumulator(width, height)
+	flattenPath(devPath, acc.line)
+	acc.resolve(path.FillType(), dst, rowBytes)
+}
+
+/** scanPathToMask
+Return the A8 coverage mask of devPath, which is in device space, over the
+whole pixels of its bounds that are inside clip, or over all of clip for
+inverse fills. Returns nil if the path covers none of clip. */
+func scanPathToMask(devPath *Path, clip Rect) *Mask {
+	var bounds = clip
+	if !devPath.IsInverseFillType() {
+		bounds = devPath.Bounds()
+		bounds.SetLTRB(ScalarFloor(bounds.L()), ScalarFloor(bounds.T()), ScalarCeil(bounds.R()), ScalarCeil(bounds.B()))
+		if bounds.Width <= 0 || bounds.Height <= 0 || !bounds.Intersect(clip) {
+			return nil
+		}
+	}
+	var width, height = int(bounds.Width), int(bounds.Height)
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+	var mask = &Mask{
+		Image:    make([]byte, width*height),
+		Bounds:   bounds,
+		RowBytes: width,
+		Format:   KMaskFormatA8,
+	}
+	var toMask = NewMatrix()
+	toMask.SetTranslate(-bounds.Left, -bounds.Top)
+	scanFillPathToA8(devPath, toMask, mask.Image, width, height, mask.RowBytes)
+	return mask
+}
+
+// Call line for each line of path, with curves flattened and contours
+// closed.
+func flattenPath(path *Path, line func(p0, p1 Point)) {
+	var iter = NewPathIter(path, true)
+	var pts [4]Point
+	for {
+		var verb = iter.Next(pts[:])
+		switch verb {
+		case KPathVerbLine:
+			line(pts[0], pts[1])
+		case KPathVerbQuad:
+			var count = quadOrCubicSubdivisions(ScalarAbs(pts[0].X-2*pts[1].X+pts[2].X)+
+				ScalarAbs(pts[0].Y-2*pts[1].Y+pts[2].Y), PointDistance(pts[0], pts[2]))
+			var last = pts[0]
+			for i := 1; i <= count; i++ {
+				var pt, _ = EvalQuadAt(pts[:3], Scalar(i)/Scalar(count))
+				line(last, pt)
+				last = pt
+			}
+		case KPathVerbCubic:
+			var d1 = ScalarAbs(pts[0].X-2*pts[1].X+pts[2].X) + ScalarAbs(pts[0].Y-2*pts[1].Y+pts[2].Y)
+			var d2 = ScalarAbs(pts[1].X-2*pts[2].X+pts[3].X) + ScalarAbs(pts[1].Y-2*pts[2].Y+pts[3].Y)
+			var count = quadOrCubicSubdivisions(ScalarMax(d1, d2), PointDistance(pts[0], pts[3]))
+			var last = pts[0]
+			for i := 1; i <= count; i++ {
+				var pt, _ = EvalCubicAt(pts[:4], Scalar(i)/Scalar(count))
+				line(last, pt)
+				last = pt
+			}
+		case KPathVerbDone:
+			return
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package ggk
+
+import "testing"
+
+func TestScanFillPathToA8(t *testing.T) {
+	var path = NewPath()
+	path.AddRect(MakeRect(1, 0.5, 2, 2))
+
+	var a8 = make([]byte, 4*3)
+	scanFillPathToA8(path, nil, a8, 4, 3, 4)
+	var want = []byte{
+		0, 128, 128, 0,
+		0, 255, 255, 0,
+		0, 128, 128, 0,
+	}
+	for i := range want {
+		if a8[i] != want[i] {
+			t.Errorf("scanFillPathToA8(%v) want %v got %v", path.Points(), want, a8)
+			break
+		}
+	}
+}
@@ -1,5 +1,13 @@
 package ggk
 
+/** ShaderImpl
+is implemented by each kind of Shader. */
+type ShaderImpl interface {
+	// OnCreateContext returns the context shading spans of the shader for
+	// rec, or nil if the shader can't draw with it.
+	OnCreateContext(shader *Shader, rec *ShaderContextRec) *ShaderContext
+}
+
 /** \class Shader
  *
  *  Shaders specify the source color(s) for what is being drawn. If a paint
@@ -11,30 +19,119 @@ package ggk
  *  to be modified.
  */
 type Shader struct {
+	Impl        ShaderImpl
+	localMatrix *Matrix
+}
+
+/** NewShader
+Return a shader of impl, drawn through localMatrix, which maps the
+coordinates of the shader into those of what is drawn. localMatrix may be
+nil for the identity. */
+func NewShader(impl ShaderImpl, localMatrix *Matrix) *Shader {
+	var shader = &Shader{Impl: impl, localMatrix: NewMatrix()}
+	if localMatrix != nil {
+		shader.localMatrix = NewMatrixClone(localMatrix)
+	}
+	return shader
 }
 
 func NewShader_Color(color Color) *Shader {
-	toimpl()
-	return nil
+	return NewShader(&tColorShader{color}, nil)
 }
 
+// LocalMatrix returns the matrix mapping the shader into what is drawn.
+func (shader *Shader) LocalMatrix() *Matrix {
+	return shader.localMatrix
+}
+
+/** MakeWithColorFilter
+Return a shader filtering the colors of this shader with filter. */
 func (shader *Shader) MakeWithColorFilter(filter *ColorFilter) *Shader {
-	toimpl()
-	return nil
+	if filter == nil {
+		return shader
+	}
+	return NewShader(&tColorFilterShader{shader, filter}, nil)
 }
 
+// Returns the number of bytes needed to store the contexts of the shader.
+// Contexts are allocated by CreateContext, so no storage is needed from the
+// caller.
 func (shader *Shader) ContextSize(rec *ShaderContextRec) int {
-	toimpl()
 	return 0
 }
 
+/** CreateContext
+Return the context shading spans for rec, or nil if the shader can't draw
+with it, like when its matrices can't be inverted. */
 func (shader *Shader) CreateContext(rec *ShaderContextRec) *ShaderContext {
-	toimpl()
-	return nil
+	if _, ok := shader.totalMatrix(rec).Invert(); !ok {
+		return nil
+	}
+	return shader.Impl.OnCreateContext(shader, rec)
 }
 
+// Return the matrix mapping the shader into the device for rec.
+func (shader *Shader) totalMatrix(rec *ShaderContextRec) *Matrix {
+	var total = NewMatrix()
+	total.SetConcat(rec.Matrix, shader.localMatrix)
+	if rec.LocalMatrix != nil {
+		total.PreConcat(rec.LocalMatrix)
+	}
+	return total
+}
+
+/** ShaderTileMode
+tells how a shader draws outside of its original bounds. */
+type ShaderTileMode int
+
+const (
+	// Replicate the edge color if the shader draws outside of its original
+	// bounds.
+	KShaderTileModeClamp = ShaderTileMode(iota)
+	// Repeat the shader's image horizontally and vertically.
+	KShaderTileModeRepeat
+	// Repeat the shader's image horizontally and vertically, alternating
+	// mirror images so that adjacent images always seam.
+	KShaderTileModeMirror
+	KShaderTileModeCount
+)
+
+// Return the coordinate c of a pixel inside of an image of size pixels,
+// tiled in mode.
+func tileShaderCoord(mode ShaderTileMode, c, size int) int {
+	switch mode {
+	case KShaderTileModeRepeat:
+		c %= size
+		if c < 0 {
+			c += size
+		}
+	case KShaderTileModeMirror:
+		c %= 2 * size
+		if c < 0 {
+			c += 2 * size
+		}
+		if c >= size {
+			c = 2*size - 1 - c
+		}
+	default:
+		if c < 0 {
+			c = 0
+		} else if c >= size {
+			c = size - 1
+		}
+	}
+	return c
+}
 
+/** ShaderContextRec
+describes what a shader context is created for: the paint drawn with, the
+matrix of the draw and an optional local matrix applied before the
+shader's own. */
 type ShaderContextRec struct {
+	Paint            *Paint
+	Matrix           *Matrix
+	LocalMatrix      *Matrix
+	PreferredDstType ShaderDstType
 }
 
 type ShaderDstType int
@@ -45,14 +142,69 @@ const (
 )
 
 func NewShaderContextRec(paint *Paint, matrix *Matrix, localM *Matrix, dstType ShaderDstType) *ShaderContextRec {
-	toimpl()
-	return nil
+	return &ShaderContextRec{
+		Paint:            paint,
+		Matrix:           matrix,
+		LocalMatrix:      localM,
+		PreferredDstType: dstType,
+	}
 }
 
 func BlitterPreferredShaderDest(dstInfo *ImageInfo) ShaderDstType {
-	toimpl()
 	return KShaderDstTypePMColor
 }
 
+/** ShaderContextImpl
+is implemented by the context of each kind of Shader. */
+type ShaderContextImpl interface {
+	// OnShadeSpan shades the count pixels from (x, y) to the right, in
+	// device coordinates, into dst.
+	OnShadeSpan(context *ShaderContext, x, y int, dst []PremulColor, count int)
+}
+
+/** ShaderContext
+shades the pixels of a shader for one draw. */
 type ShaderContext struct {
+	Impl         ShaderContextImpl
+	shader       *Shader
+	totalInverse *Matrix
+	paintAlpha   uint8
+}
+
+/** NewShaderContext
+Return the context of shader for rec, shading spans with impl. Returns nil
+if the matrices of rec and shader can't be inverted. */
+func NewShaderContext(shader *Shader, rec *ShaderContextRec, impl ShaderContextImpl) *ShaderContext {
+	var inverse, ok = shader.totalMatrix(rec).Invert()
+	if !ok {
+		return nil
+	}
+	return &ShaderContext{
+		Impl:         impl,
+		shader:       shader,
+		totalInverse: inverse,
+		paintAlpha:   rec.Paint.Alpha(),
+	}
+}
+
+func (context *ShaderContext) Shader() *Shader {
+	return context.shader
+}
+
+// TotalInverse returns the matrix mapping the device into the shader.
+func (context *ShaderContext) TotalInverse() *Matrix {
+	return context.totalInverse
+}
+
+// PaintAlpha returns the alpha of the paint, which scales the colors of
+// the shader.
+func (context *ShaderContext) PaintAlpha() uint8 {
+	return context.paintAlpha
+}
+
+/** ShadeSpan
+Shade the count pixels from (x, y) to the right, in device coordinates,
+into dst. */
+func (context *ShaderContext) ShadeSpan(x, y int, dst []PremulColor, count int) {
+	context.Impl.OnShadeSpan(context, x, y, dst, count)
 }
@@ -0,0 +1,216 @@
+package ggk
+
+import (
+	"hash/fnv"
+	"testing"
+)
+
+// Draw a rect of the size of the canvas with shader and return the pixels.
+func drawTestShader(width, height int, shader *Shader, alpha uint8) *Pixmap {
+	var canvas, pixels = newTestPictureCanvas(width, height)
+	var paint = newTestPaint(KColorBlack)
+	paint.SetAlpha(alpha)
+	paint.SetShader(shader)
+	canvas.DrawRect(MakeRect(0, 0, Scalar(width), Scalar(height)), paint)
+	return pixels
+}
+
+func TestColorShader(t *testing.T) {
+	var tests = []struct {
+		name   string
+		shader *Shader
+		alpha  uint8
+		want   uint32
+	}{
+		{"opaque", NewShader_Color(KColorRed), 0xff, PackARGB32(0xff, 0xff, 0, 0)},
+		{"paint alpha", NewShader_Color(KColorRed), 0x80, PackARGB32(0x80, 0x80, 0, 0)},
+		{"color filter", NewShader_Color(KColorRed).MakeWithColorFilter(NewModeColorFilter(KColorBlue, KXfermodeModeSrcIn)),
+			0xff, PackARGB32(0xff, 0, 0, 0xff)},
+	}
+	for _, test := range tests {
+		var pixels = drawTestShader(4, 4, test.shader, test.alpha)
+		if got := pixels.Pixel32(2, 1); got != test.want {
+			t.Errorf("%v want %#x got %#x", test.name, test.want, got)
+		}
+	}
+}
+
+func TestShaderContext(t *testing.T) {
+	var singular = NewMatrix()
+	singular.SetScale(0, 1)
+	var shader = NewShader(&tColorShader{KColorRed}, singular)
+	var rec = NewShaderContextRec(NewPaint(), NewMatrix(), nil, KShaderDstTypePMColor)
+	if shader.CreateContext(rec) != nil {
+		t.Errorf("CreateContext() with a singular local matrix want nil")
+	}
+
+	var local = NewMatrix()
+	local.SetTranslate(2, 3)
+	var ctm = NewMatrix()
+	ctm.SetScale(2, 2)
+	var context = NewShader(&tColorShader{KColorRed}, local).CreateContext(NewShaderContextRec(NewPaint(), ctm, nil, KShaderDstTypePMColor))
+	if context == nil {
+		t.Fatalf("CreateContext() want a context")
+	}
+	if got := context.TotalInverse().MapXY(8, 10); got != (Point{2, 2}) {
+		t.Errorf("TotalInverse() want to map (8, 10) to (2, 2) got %v", got)
+	}
+}
+
+func TestPerlinNoiseShader(t *testing.T) {
+	var tile = MakeSize(16, 16)
+	if NewFractalNoiseShader(-0.1, 0.1, 2, 0, nil) != nil || NewTurbulenceShader(0.1, 0.1, 256, 0, nil) != nil ||
+		NewTurbulenceShader(0.1, 0.1, -1, 0, nil) != nil {
+		t.Errorf("want nil shaders for invalid parameters")
+	}
+
+	// no octave is half gray for fractal noise, and nothing for turbulence.
+	if got, want := drawTestShader(4, 4, NewFractalNoiseShader(0.1, 0.1, 0, 0, nil), 0xff).Pixel32(1, 1),
+		PackARGB32(127, 63, 63, 63); got != want {
+		t.Errorf("fractal noise without octaves want %#x got %#x", want, got)
+	}
+	if got := drawTestShader(4, 4, NewTurbulenceShader(0.1, 0.1, 0, 0, nil), 0xff).Pixel32(1, 1); got != 0 {
+		t.Errorf("turbulence without octaves want transparent got %#x", got)
+	}
+
+	var hash = func(pixels *Pixmap) uint32 {
+		var h = fnv.New32a()
+		for y := 0; y < int(pixels.Height()); y++ {
+			for x := 0; x < int(pixels.Width()); x++ {
+				var p = pixels.Pixel32(x, y)
+				h.Write([]byte{byte(p), byte(p >> 8), byte(p >> 16), byte(p >> 24)})
+			}
+		}
+		return h.Sum32()
+	}
+	var tests = []struct {
+		name   string
+		shader func() *Shader
+	}{
+		{"fractal noise", func() *Shader { return NewFractalNoiseShader(0.05, 0.1, 3, 2, nil) }},
+		{"turbulence", func() *Shader { return NewTurbulenceShader(0.05, 0.05, 4, 7, nil) }},
+		{"stitched", func() *Shader { return NewTurbulenceShader(0.1, 0.1, 2, 1, &tile) }},
+	}
+	var hashes = make(map[uint32]string)
+	for _, test := range tests {
+		var pixels = drawTestShader(32, 32, test.shader(), 0xff)
+		var h = hash(pixels)
+		if again := hash(drawTestShader(32, 32, test.shader(), 0xff)); again != h {
+			t.Errorf("%v want the same pixels for each draw got %#x and %#x", test.name, h, again)
+		}
+		if other, ok := hashes[h]; ok {
+			t.Errorf("%v want different pixels than %v", test.name, other)
+		}
+		hashes[h] = test.name
+		var opaque = 0
+		for y := 0; y < 32; y++ {
+			for x := 0; x < 32; x++ {
+				var p = pixels.Pixel32(x, y)
+				if a := GetPackedA32(p); GetPackedR32(p) > a || GetPackedG32(p) > a || GetPackedB32(p) > a {
+					t.Fatalf("%v want premultiplied pixels got %#x", test.name, p)
+				}
+				if p != 0 {
+					opaque++
+				}
+			}
+		}
+		if opaque == 0 {
+			t.Errorf("%v want noise got nothing", test.name)
+		}
+	}
+
+	// the right and bottom edges of a stitched tile continue its left and top
+	// edges. The pixels at x of the device shade the point x + 1.
+	var rec = NewShaderContextRec(NewPaint(), NewMatrix(), nil, KShaderDstTypePMColor)
+	var context = NewTurbulenceShader(0.1, 0.1, 2, 1, &tile).CreateContext(rec)
+	var shade = func(x, y int) PremulColor {
+		var dst = make([]PremulColor, 1)
+		context.ShadeSpan(x, y, dst, 1)
+		return dst[0]
+	}
+	for i := 0; i < 15; i++ {
+		if left, right := shade(-1, i), shade(15, i); left != right {
+			t.Errorf("want the same color at the left and right of row %v got %#x and %#x", i, left, right)
+		}
+		if top, bottom := shade(i, -1), shade(i, 15); top != bottom {
+			t.Errorf("want the same color at the top and bottom of column %v got %#x and %#x", i, top, bottom)
+		}
+	}
+}
+
+func TestComposeShader(t *testing.T) {
+	var red, blue = NewShader_Color(KColorRed), NewShader_Color(KColorBlue)
+	if NewComposeShader(red, blue, KXfermodeModeSrc) != blue || NewComposeShader(red, blue, KXfermodeModeDst) != red {
+		t.Errorf("want the src or dst shader for the src and dst modes")
+	}
+	var tests = []struct {
+		name  string
+		src   Color
+		mode  XfermodeMode
+		alpha uint8
+		want  uint32
+	}{
+		{"clear", KColorBlue, KXfermodeModeClear, 0xff, 0},
+		{"src over", KColorBlue, KXfermodeModeSrcOver, 0xff, PackARGB32(0xff, 0, 0, 0xff)},
+		{"src over half", ColorWithARGB(0x80, 0, 0, 0xff), KXfermodeModeSrcOver, 0xff, PackARGB32(0xff, 0x7f, 0, 0x80)},
+		{"dst over", KColorBlue, KXfermodeModeDstOver, 0xff, PackARGB32(0xff, 0xff, 0, 0)},
+		{"xor", KColorBlue, KXfermodeModeXor, 0xff, 0},
+		{"paint alpha", KColorBlue, KXfermodeModeSrcIn, 0x80, PackARGB32(0x80, 0, 0, 0x80)},
+	}
+	for _, test := range tests {
+		var shader = NewComposeShader(red, NewShader_Color(test.src), test.mode)
+		if got := drawTestShader(4, 4, shader, test.alpha).Pixel32(1, 2); got != test.want {
+			t.Errorf("%v want %#x got %#x", test.name, test.want, got)
+		}
+	}
+}
+
+func TestPictureShader(t *testing.T) {
+	// a 4x4 tile with red in its top left quarter.
+	var recorder = NewPictureRecorder()
+	recorder.BeginRecording(MakeRect(0, 0, 4, 4)).DrawRect(MakeRect(0, 0, 2, 2), newTestPaint(KColorRed))
+	var pic = recorder.FinishRecordingAsPicture()
+	if NewPictureShader(nil, KShaderTileModeRepeat, KShaderTileModeRepeat, nil, nil) != nil ||
+		NewPictureShader(pic, KShaderTileModeRepeat, KShaderTileModeRepeat, nil, &Rect{}) != nil {
+		t.Errorf("want nil shaders without picture or tile")
+	}
+
+	var red = PackARGB32(0xff, 0xff, 0, 0)
+	var translate, scale = NewMatrix(), NewMatrix()
+	translate.SetTranslate(2, 0)
+	scale.SetScale(2, 2)
+	var tests = []struct {
+		name   string
+		tm     ShaderTileMode
+		local  *Matrix
+		ctm    *Matrix
+		points []Point
+		want   []uint32
+	}{
+		{"repeat", KShaderTileModeRepeat, nil, nil,
+			[]Point{{0, 0}, {2, 0}, {4, 0}, {5, 5}, {6, 1}}, []uint32{red, 0, red, red, 0}},
+		{"mirror", KShaderTileModeMirror, nil, nil,
+			[]Point{{1, 1}, {4, 0}, {6, 1}, {7, 7}, {9, 0}}, []uint32{red, 0, red, red, red}},
+		{"clamp", KShaderTileModeClamp, nil, nil,
+			[]Point{{1, 1}, {6, 0}, {0, 6}, {8, 8}}, []uint32{red, 0, 0, 0}},
+		{"local matrix", KShaderTileModeRepeat, translate, nil,
+			[]Point{{0, 0}, {2, 0}, {3, 1}, {6, 0}}, []uint32{0, red, red, red}},
+		{"scaled draw", KShaderTileModeRepeat, nil, scale,
+			[]Point{{3, 3}, {4, 0}, {8, 0}, {12, 12}}, []uint32{red, 0, red, 0}},
+	}
+	for _, test := range tests {
+		var shader = NewPictureShader(pic, test.tm, test.tm, nil, nil).MakeWithLocalMatrix(test.local)
+		var canvas, pixels = newTestPictureCanvas(16, 16)
+		var paint = NewPaint()
+		paint.SetShader(shader)
+		if test.ctm != nil {
+			canvas.Concat(test.ctm)
+		}
+		canvas.DrawRect(MakeRect(0, 0, 16, 16), paint)
+		for i, point := range test.points {
+			if got := pixels.Pixel32(int(point.X), int(point.Y)); got != test.want[i] {
+				t.Errorf("%v at %v want %#x got %#x", test.name, point, test.want[i], got)
+			}
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package ggk
+
+/** tShadowMapCanvas
+is the canvas a picture plays back into to make the maps that a shadowed
+picture is shaded with. It draws the picture as it is, or the depth of each
+draw, set with TranslateZ, as a gray level from 0 to 255. A depth map made
+for a light also shifts each draw away from the light, by how high it is,
+so that it lands where its shadow falls on the ground. Later draws cover
+earlier ones, as they do in the picture. */
+type tShadowMapCanvas struct {
+	*Canvas
+	depth    bool
+	lightDir *Point3
+}
+
+/** newShadowMapCanvas
+Return a canvas drawing into bmp. It draws depths if depth is true, shifted
+away from the light of lightDir if that is not nil. */
+func newShadowMapCanvas(bmp *Bitmap, depth bool, lightDir *Point3) *tShadowMapCanvas {
+	var canvas = &tShadowMapCanvas{
+		Canvas:   NewCanvasBitmap(bmp),
+		depth:    depth,
+		lightDir: lightDir,
+	}
+	canvas.Canvas.Impl = canvas
+	return canvas
+}
+
+// Return paint, which may be nil, or a copy of it drawing the current depth
+// when drawing depths. The depth replaces the colors of the shader, of
+// images and of sprites but keeps their alpha. Depths are not antialiased
+// so that edges keep their depth.
+func (canvas *tShadowMapCanvas) mapPaint(paint *Paint) *Paint {
+	if !canvas.depth {
+		return paint
+	}
+	if paint == nil {
+		paint = NewPaint()
+	}
+	var d = uint8(ScalarRoundToInt(ScalarPin(canvas.Z(), 0, 255)))
+	var color = ColorWithARGB(0xff, d, d, d)
+	paint = paint.Clone()
+	paint.SetColor(color)
+	paint.SetShader(nil)
+	paint.SetColorFilter(NewModeColorFilter(color, KXfermodeModeSrcIn))
+	paint.SetAnitAlias(false)
+	return paint
+}
+
+// Call draw with the matrix shifted away from the light by the current
+// depth, if the map is made for a light.
+func (canvas *tShadowMapCanvas) shifted(draw func()) {
+	if canvas.lightDir == nil {
+		draw()
+		return
+	}
+	var dir, z = canvas.lightDir, canvas.Z()
+	var matrix = NewMatrixClone(canvas.TotalMatrix())
+	matrix.PostTranslate(-dir.X*z/dir.Z, -dir.Y*z/dir.Z)
+	canvas.Save()
+	canvas.SetMatrix(matrix)
+	draw()
+	canvas.Restore()
+}
+
+func (canvas *tShadowMapCanvas) OnDrawPaint(paint *Paint) {
+	canvas.Canvas.OnDrawPaint(canvas.mapPaint(paint))
+}
+
+func (canvas *tShadowMapCanvas) OnDrawRect(rect Rect, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawRect(rect, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawDRect(outer, inner Rect, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawDRect(outer, inner, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawOval(oval Rect, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawOval(oval, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawArc(oval Rect, startAngle, sweepAngle Scalar, useCenter bool, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawArc(oval, startAngle, sweepAngle, useCenter, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawPoints(mode CanvasPointMode, count int, pts []Point, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawPoints(mode, count, pts, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawPath(path *Path, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawPath(path, paint) })
+}
+
+// The colors and the texture of the vertices are dropped when drawing
+// depths, so that the triangles are filled with the depth.
+func (canvas *tShadowMapCanvas) OnDrawVertices(vertexMode CanvasVertexMode, vertexCount int, vertices []Point,
+	texs []Point, colors []Color, xfermode *Xfermode, indices []uint16, indexCount int, paint *Paint) {
+	if canvas.depth {
+		texs, colors, xfermode = nil, nil, nil
+	}
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() {
+		canvas.Canvas.OnDrawVertices(vertexMode, vertexCount, vertices, texs, colors, xfermode, indices, indexCount,
+			paint)
+	})
+}
+
+func (canvas *tShadowMapCanvas) OnDrawPatch(cubics [12]Point, colors *[4]Color, texCoords *[4]Point,
+	xmode *Xfermode, paint *Paint) {
+	if canvas.depth {
+		colors, texCoords, xmode = nil, nil, nil
+	}
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawPatch(cubics, colors, texCoords, xmode, paint) })
+}
+
+// The colors of the sprites are dropped when drawing depths, so that only
+// the alpha of the atlas is kept.
+func (canvas *tShadowMapCanvas) OnDrawAtlas(atlas *Image, xform []RSXform, tex []Rect, colors []Color, count int,
+	mode XfermodeMode, cull *Rect, paint *Paint) {
+	if canvas.depth {
+		colors = nil
+	}
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawAtlas(atlas, xform, tex, colors, count, mode, cull, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawImage(image *Image, dx, dy Scalar, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawImage(image, dx, dy, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawImageRect(image *Image, src *Rect, dst Rect, paint *Paint,
+	constraint CanvasSrcRectConstraint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawImageRect(image, src, dst, paint, constraint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawImageNine(image *Image, center Rect, dst Rect, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawImageNine(image, center, dst, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawImageLattice(image *Image, lattice *CanvasLattice, dst Rect, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawImageLattice(image, lattice, dst, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawBitmap(bmp *Bitmap, dx, dy Scalar, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawBitmap(bmp, dx, dy, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawBitmapRect(bmp *Bitmap, src *Rect, dst Rect, paint *Paint,
+	constraint CanvasSrcRectConstraint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawBitmapRect(bmp, src, dst, paint, constraint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawBitmapNine(bmp *Bitmap, center Rect, dst Rect, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawBitmapNine(bmp, center, dst, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawText(text string, x, y Scalar, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawText(text, x, y, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawTextAt(text string, xpos []Point, constY Scalar, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawTextAt(text, xpos, constY, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawTextAtH(text string, xpos []Point, constY Scalar, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawTextAtH(text, xpos, constY, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawTextOnPath(text string, path *Path, matrix *Matrix, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawTextOnPath(text, path, matrix, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawTextRSXform(text string, xform []RSXform, cullRect *Rect, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawTextRSXform(text, xform, cullRect, paint) })
+}
+
+func (canvas *tShadowMapCanvas) OnDrawTextBlob(blob *TextBlob, x, y Scalar, paint *Paint) {
+	paint = canvas.mapPaint(paint)
+	canvas.shifted(func() { canvas.Canvas.OnDrawTextBlob(blob, x, y, paint) })
+}
+
+// A shadowed picture inside a shadowed picture is part of the same scene.
+func (canvas *tShadowMapCanvas) OnDrawShadowedPicture(pic *Picture, matrix *Matrix, paint *Paint) {
+	canvas.Canvas.OnDrawPicture(pic, matrix, paint)
+}
+
+/** drawShadowMap
+Return a bitmap of the size of bounds into which pic is played back with
+matrix, which maps it into device space, moved so that the top left of
+bounds is at the origin. See tShadowMapCanvas for depth and lightDir. */
+func drawShadowMap(pic *Picture, matrix *Matrix, bounds Rect, depth bool, lightDir *Point3) *Bitmap {
+	var bmp = newFilterBitmap(bounds)
+	if bmp == nil {
+		return nil
+	}
+	var canvas = newShadowMapCanvas(bmp, depth, lightDir)
+	var total = NewMatrix()
+	total.SetTranslate(-bounds.Left, -bounds.Top)
+	total.PreConcat(matrix)
+	canvas.SetMatrix(total)
+	pic.Playback(canvas.Canvas)
+	return bmp
+}
+
+/** shadePicture
+Return the picture drawn with matrix into bounds of the device, shaded by
+lights. A surface is lit by the ambient light and by each directional
+light that no higher surface hides it from, and faces the viewer. */
+func shadePicture(pic *Picture, matrix *Matrix, bounds Rect, lights *Lights) *Bitmap {
+	var diffuse = drawShadowMap(pic, matrix, bounds, false, nil)
+	if diffuse == nil {
+		return nil
+	}
+	var povDepth = filterPixmap(drawShadowMap(pic, matrix, bounds, true, nil))
+	var lightDepths = make([]*Pixmap, lights.NumLights())
+	for i := range lightDepths {
+		var dir = lights.Light(i).Dir
+		if dir.Z > 0 {
+			lightDepths[i] = filterPixmap(drawShadowMap(pic, matrix, bounds, true, &dir))
+		}
+	}
+
+	var pixels = filterPixmap(diffuse)
+	var width, height = int(pixels.Width()), int(pixels.Height())
+	var normal = Point3{0, 0, 1}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var pixel = pixels.Pixel32(x, y)
+			var a = GetPackedA32(pixel)
+			if a == 0 {
+				continue
+			}
+			var z = Scalar(GetPackedR32(povDepth.Pixel32(x, y)))
+			var shadowed = func(i int) bool {
+				var depths = lightDepths[i]
+				if depths == nil {
+					return false
+				}
+				var dir = lights.Light(i).Dir
+				var lx = x + ScalarRoundToInt(-dir.X*z/dir.Z)
+				var ly = y + ScalarRoundToInt(-dir.Y*z/dir.Z)
+				if lx < 0 || ly < 0 || lx >= width || ly >= height {
+					return false
+				}
+				return Scalar(GetPackedR32(depths.Pixel32(lx, ly))) > z
+			}
+			var color = Color3f{
+				float32(GetPackedR32(pixel)),
+				float32(GetPackedG32(pixel)),
+				float32(GetPackedB32(pixel)),
+			}
+			color = lights.shade(color, normal, shadowed)
+			pixels.SetPixel32(x, y, PackARGB32(a, pinShadedChannel(color.R, a), pinShadedChannel(color.G, a),
+				pinShadedChannel(color.B, a)))
+		}
+	}
+	return diffuse
+}
+
+// Return the premultiplied channel c rounded and pinned to alpha a.
+func pinShadedChannel(c float32, a uint32) uint32 {
+	if c <= 0 {
+		return 0
+	}
+	if c >= float32(a) {
+		return a
+	}
+	return uint32(c + 0.5)
+}
@@ -0,0 +1,451 @@
+package ggk
+
+import (
+	"sort"
+	"unicode"
+)
+
+// Feature masks select which glyphs a feature applies to. Global features
+// apply to every glyph, the others only to glyphs the script shaper marked.
+const (
+	kShapeMaskGlobal = uint32(1) << iota
+	kShapeMaskIsol
+	kShapeMaskFina
+	kShapeMaskMedi
+	kShapeMaskInit
+	kShapeMaskRphf
+	kShapeMaskHalf
+	kShapeMaskPostBase
+)
+
+// tShapeScript tells how a run of one script is shaped.
+type tShapeScript int
+
+const (
+	kShapeScriptCommon = tShapeScript(iota) // inherits the script around it.
+	kShapeScriptLatin
+	kShapeScriptArabic
+	kShapeScriptDevanagari
+)
+
+// The OpenType script tags of each script, most preferred first.
+var gShapeScriptTags = map[tShapeScript][]FontTableTag{
+	kShapeScriptLatin:      {SetFourByteTag('l', 'a', 't', 'n')},
+	kShapeScriptArabic:     {SetFourByteTag('a', 'r', 'a', 'b')},
+	kShapeScriptDevanagari: {SetFourByteTag('d', 'e', 'v', '2'), SetFourByteTag('d', 'e', 'v', 'a')},
+}
+
+// Scripts fall back on these when the font doesn't know them.
+var gShapeFallbackScriptTags = []FontTableTag{
+	SetFourByteTag('D', 'F', 'L', 'T'),
+	SetFourByteTag('d', 'f', 'l', 't'),
+	SetFourByteTag('l', 'a', 't', 'n'),
+}
+
+func shapeScriptOf(c Unichar) tShapeScript {
+	switch {
+	case c >= 0x0600 && c <= 0x06FF, c >= 0x0750 && c <= 0x077F, c >= 0x08A0 && c <= 0x08FF,
+		c >= 0xFB50 && c <= 0xFDFF, c >= 0xFE70 && c <= 0xFEFF:
+		if c == 0x060C || c == 0x061B || c == 0x061F || c == 0x0640 {
+			return kShapeScriptCommon
+		}
+		return kShapeScriptArabic
+	case c >= 0x0900 && c <= 0x097F, c >= 0xA8E0 && c <= 0xA8FF:
+		if c == 0x0964 || c == 0x0965 {
+			return kShapeScriptCommon
+		}
+		return kShapeScriptDevanagari
+	case unicode.IsLetter(rune(c)):
+		return kShapeScriptLatin
+	}
+	return kShapeScriptCommon
+}
+
+// tShapeFeature is a feature applied to the glyphs enabled by mask.
+type tShapeFeature struct {
+	tag  string
+	mask uint32
+}
+
+// tShapeStage is a group of features whose lookups are applied together,
+// in lookup order. Stages are applied one after the other.
+type tShapeStage []tShapeFeature
+
+func globalShapeStage(tags ...string) tShapeStage {
+	var stage tShapeStage
+	for _, tag := range tags {
+		stage = append(stage, tShapeFeature{tag, kShapeMaskGlobal})
+	}
+	return stage
+}
+
+func shapeFeatureTag(tag string) FontTableTag {
+	return SetFourByteTag(tag[0], tag[1], tag[2], tag[3])
+}
+
+// tShapePlan holds the GSUB stages of a script, split around the point
+// where the script shaper reorders the glyphs again, and its GPOS features.
+type tShapePlan struct {
+	substStages     []tShapeStage
+	postSubstStages []tShapeStage
+	posStage        tShapeStage
+}
+
+func shapePlanFor(script tShapeScript) *tShapePlan {
+	switch script {
+	case kShapeScriptArabic:
+		return &tShapePlan{
+			substStages: []tShapeStage{
+				globalShapeStage("ccmp", "locl"),
+				{{"isol", kShapeMaskIsol}},
+				{{"fina", kShapeMaskFina}},
+				{{"medi", kShapeMaskMedi}},
+				{{"init", kShapeMaskInit}},
+				globalShapeStage("rlig"),
+				globalShapeStage("calt", "liga", "clig", "mset"),
+			},
+			posStage: globalShapeStage("kern", "mark", "mkmk"),
+		}
+	case kShapeScriptDevanagari:
+		return &tShapePlan{
+			substStages: []tShapeStage{
+				globalShapeStage("ccmp", "locl"),
+				globalShapeStage("nukt"),
+				globalShapeStage("akhn"),
+				{{"rphf", kShapeMaskRphf}},
+				globalShapeStage("rkrf"),
+				{{"pref", kShapeMaskPostBase}},
+				{{"blwf", kShapeMaskPostBase}},
+				{{"abvf", kShapeMaskPostBase}},
+				{{"half", kShapeMaskHalf}},
+				{{"pstf", kShapeMaskPostBase}},
+				globalShapeStage("vatu"),
+				globalShapeStage("cjct"),
+			},
+			postSubstStages: []tShapeStage{
+				globalShapeStage("pres", "abvs", "blws", "psts", "haln"),
+				globalShapeStage("calt", "liga", "clig"),
+			},
+			posStage: globalShapeStage("kern", "dist", "abvm", "blwm", "mark", "mkmk"),
+		}
+	}
+	return &tShapePlan{
+		substStages: []tShapeStage{
+			globalShapeStage("ccmp", "locl", "rlig", "calt", "liga", "clig"),
+		},
+		posStage: globalShapeStage("kern", "mark", "mkmk"),
+	}
+}
+
+/** tShapeInfo
+is a glyph in the shape buffer with what the shaper knows about it. Until
+GPOS, positions are in font units with y up. */
+type tShapeInfo struct {
+	char       Unichar
+	id         GlyphID
+	cluster    int // byte offset of the first character the glyph came from.
+	mask       uint32
+	glyphClass int
+
+	category    int // script specific classification of char.
+	syllable    int
+	substituted bool
+	ligated     bool
+
+	xAdvance, yAdvance Scalar
+	xOffset, yOffset   Scalar
+
+	attachTo           int // index of the glyph a mark is attached to, or -1.
+	attachDx, attachDy Scalar
+}
+
+/** tShapeBuffer
+holds the glyphs of a run in logical order while lookups edit them. */
+type tShapeBuffer struct {
+	info []tShapeInfo
+}
+
+// Classify the glyph at i by the GDEF table, or by its character if the
+// font has no glyph classes.
+func (buf *tShapeBuffer) classify(i int, gdef *tOTGDEF) {
+	var info = &buf.info[i]
+	if gdef != nil && gdef.glyphClassDef != nil {
+		info.glyphClass = gdef.glyphClass(info.id)
+	} else if unicode.Is(unicode.Mn, rune(info.char)) {
+		info.glyphClass = kOTGlyphClassMark
+	} else if info.ligated {
+		info.glyphClass = kOTGlyphClassLigature
+	} else {
+		info.glyphClass = kOTGlyphClassBase
+	}
+}
+
+func (buf *tShapeBuffer) substitute(i int, id GlyphID, gdef *tOTGDEF) {
+	buf.info[i].id = id
+	buf.info[i].substituted = true
+	buf.classify(i, gdef)
+}
+
+// Replace the glyph at i with glyphs, which inherit its properties.
+func (buf *tShapeBuffer) replace(i int, glyphs []GlyphID, gdef *tOTGDEF) {
+	var info = buf.info[i]
+	var replacement = make([]tShapeInfo, len(glyphs))
+	for k, id := range glyphs {
+		replacement[k] = info
+		replacement[k].id = id
+		replacement[k].substituted = true
+	}
+	var tail = append(replacement, buf.info[i+1:]...)
+	buf.info = append(buf.info[:i], tail...)
+	for k := range glyphs {
+		buf.classify(i+k, gdef)
+	}
+}
+
+// Replace the glyphs at positions with the ligature id. Glyphs skipped
+// between the components stay where they are.
+func (buf *tShapeBuffer) ligate(positions []int, id GlyphID, gdef *tOTGDEF) {
+	var first = positions[0]
+	buf.info[first].id = id
+	buf.info[first].substituted = true
+	buf.info[first].ligated = true
+	for k := len(positions) - 1; k > 0; k-- {
+		var p = positions[k]
+		if buf.info[p].cluster < buf.info[first].cluster {
+			buf.info[first].cluster = buf.info[p].cluster
+		}
+		buf.info = append(buf.info[:p], buf.info[p+1:]...)
+	}
+	buf.classify(first, gdef)
+}
+
+func (buf *tShapeBuffer) adjust(i int, value tOTValue) {
+	var info = &buf.info[i]
+	info.xOffset += Scalar(value.xPlacement)
+	info.yOffset += Scalar(value.yPlacement)
+	info.xAdvance += Scalar(value.xAdvance)
+	info.yAdvance += Scalar(value.yAdvance)
+}
+
+// Attach the mark at i to the glyph at base so their anchors meet.
+func (buf *tShapeBuffer) attach(i, base int, markAnchor, baseAnchor []byte) {
+	var mx, my = otAnchor(markAnchor)
+	var bx, by = otAnchor(baseAnchor)
+	var info = &buf.info[i]
+	info.attachTo = base
+	info.attachDx = Scalar(bx - mx)
+	info.attachDy = Scalar(by - my)
+}
+
+/** Shaper
+turns text into positioned glyphs with the OpenType layout tables (GSUB
+and GPOS) of a typeface: ligatures, contextual forms, Arabic joining,
+Devanagari conjuncts and reordering, kerning and mark positioning. Fonts
+without layout tables get their nominal glyphs and advances. */
+type Shaper struct {
+	typeface *Typeface
+	upem     int
+	gsub     *tOTLayoutTable
+	gpos     *tOTLayoutTable
+	gdef     *tOTGDEF
+}
+
+// NewShaper returns a shaper for typeface, or the default typeface if it is
+// nil.
+func NewShaper(typeface *Typeface) *Shaper {
+	if typeface == nil {
+		typeface = TypefaceDefault()
+	}
+	return &Shaper{
+		typeface: typeface,
+		upem:     typeface.UnitsPerEm(),
+		gsub:     parseOTLayoutTable(typeface.TableData(SetFourByteTag('G', 'S', 'U', 'B')), true),
+		gpos:     parseOTLayoutTable(typeface.TableData(SetFourByteTag('G', 'P', 'O', 'S')), false),
+		gdef:     parseOTGDEF(typeface.TableData(SetFourByteTag('G', 'D', 'E', 'F'))),
+	}
+}
+
+// Good returns whether the typeface has the font data needed for shaping.
+func (shaper *Shaper) Good() bool {
+	return shaper.upem > 0
+}
+
+/** Shape
+Shape the UTF-8 text with the size, scale and flags of paint and add the
+glyphs to builder as a single fully positioned run, with the baseline
+starting at point. Right to left text is laid out leftwards from its end,
+so the run still covers the width returned, starting at point.X.
+Returns the advance width of the text. */
+func (shaper *Shaper) Shape(builder *TextBlobBuilder, paint *Paint, text string,
+	leftToRight bool, point Point) Scalar {
+	if !shaper.Good() || len(text) == 0 {
+		return 0
+	}
+
+	// advances are measured unhinted at one unit per font unit.
+	var unitPaint = paint.Clone()
+	unitPaint.SetTypeface(shaper.typeface)
+	unitPaint.SetTextEncoding(KPaintTextEncodingGlyphID)
+	unitPaint.SetTextSize(Scalar(shaper.upem))
+	unitPaint.SetTextScaleX(1)
+	unitPaint.SetTextSkewX(0)
+	unitPaint.SetVerticalText(false)
+	unitPaint.SetLinearText(true)
+	unitPaint.SetHinting(KPaintHintingNo)
+	var cache = unitPaint.detachCache(nil, nil)
+
+	var buf tShapeBuffer
+	for _, run := range shapeItemize(text) {
+		var runBuf = shaper.shapeRun(cache, text, run)
+		var base = len(buf.info)
+		for _, info := range runBuf.info {
+			if info.attachTo >= 0 {
+				info.attachTo += base
+			}
+			buf.info = append(buf.info, info)
+		}
+	}
+
+	var scale = paint.TextSize() / Scalar(shaper.upem)
+	var scaleX = scale * paint.TextScaleX()
+
+	var width Scalar
+	for _, info := range buf.info {
+		width += info.xAdvance
+	}
+	var origins = make([]Point, len(buf.info))
+	var pen Scalar
+	if !leftToRight {
+		pen = width
+	}
+	for i, info := range buf.info {
+		if !leftToRight {
+			pen -= info.xAdvance
+		}
+		if info.attachTo >= 0 && info.attachTo < i {
+			var base = origins[info.attachTo]
+			origins[i] = Point{base.X + info.attachDx + info.xOffset, base.Y + info.attachDy + info.yOffset}
+		} else {
+			origins[i] = Point{pen + info.xOffset, info.yOffset}
+		}
+		if leftToRight {
+			pen += info.xAdvance
+		}
+	}
+
+	var runPaint = paint.Clone()
+	runPaint.SetTypeface(shaper.typeface)
+	runPaint.SetTextAlign(KPaintAlignLeft)
+	var n = len(buf.info)
+	var buffer = builder.AllocRunPos(runPaint, n, nil)
+	for i, info := range buf.info {
+		// right to left runs are stored in visual order.
+		var k = i
+		if !leftToRight {
+			k = n - 1 - i
+		}
+		buffer.Glyphs[k] = info.id
+		buffer.Pos[2*k] = point.X + origins[i].X*scaleX
+		buffer.Pos[2*k+1] = point.Y - origins[i].Y*scale
+	}
+	return width * scaleX
+}
+
+// tShapeItem is a run of text in one script.
+type tShapeItem struct {
+	start, end int // byte offsets.
+	script     tShapeScript
+}
+
+// Split text into runs of one script. Common characters (spaces,
+// punctuation, marks) join the run they are in.
+func shapeItemize(text string) []tShapeItem {
+	var items []tShapeItem
+	for i, r := range text {
+		var script = shapeScriptOf(Unichar(r))
+		var n = len(items)
+		switch {
+		case n == 0:
+			items = append(items, tShapeItem{i, len(text), script})
+		case script == kShapeScriptCommon || script == items[n-1].script:
+		case items[n-1].script == kShapeScriptCommon:
+			items[n-1].script = script
+		default:
+			items[n-1].end = i
+			items = append(items, tShapeItem{i, len(text), script})
+		}
+	}
+	return items
+}
+
+func (shaper *Shaper) shapeRun(cache *GlyphCache, text string, item tShapeItem) *tShapeBuffer {
+	var buf = &tShapeBuffer{}
+	for i, r := range text[item.start:item.end] {
+		buf.info = append(buf.info, tShapeInfo{
+			char:     Unichar(r),
+			id:       cache.UnicharToGlyph(Unichar(r)),
+			cluster:  item.start + i,
+			mask:     kShapeMaskGlobal,
+			attachTo: -1,
+		})
+	}
+	for i := range buf.info {
+		buf.classify(i, shaper.gdef)
+	}
+
+	switch item.script {
+	case kShapeScriptArabic:
+		arabicSetupMasks(buf)
+	case kShapeScriptDevanagari:
+		indicInitialReorder(buf)
+	}
+
+	var scriptTags = append(append([]FontTableTag{}, gShapeScriptTags[item.script]...), gShapeFallbackScriptTags...)
+	var plan = shapePlanFor(item.script)
+	var gsub = &tOTApplyContext{table: shaper.gsub, gdef: shaper.gdef, buf: buf}
+	var gsubLangSys = shaper.gsub.langSys(scriptTags)
+	for _, stage := range plan.substStages {
+		gsub.applyStage(gsubLangSys, stage)
+	}
+	if item.script == kShapeScriptDevanagari {
+		indicFinalReorder(buf)
+	}
+	for _, stage := range plan.postSubstStages {
+		gsub.applyStage(gsubLangSys, stage)
+	}
+
+	for i := range buf.info {
+		var info = &buf.info[i]
+		if info.glyphClass == kOTGlyphClassMark {
+			info.xAdvance = 0
+		} else {
+			info.xAdvance = cache.GlyphIDMetrics(info.id).AdvanceX
+		}
+	}
+
+	var gpos = &tOTApplyContext{table: shaper.gpos, gdef: shaper.gdef, buf: buf}
+	gpos.applyStage(shaper.gpos.langSys(scriptTags), plan.posStage)
+	return buf
+}
+
+// Apply the lookups of the features of stage in lookup order, each to the
+// glyphs enabled by its feature's mask.
+func (c *tOTApplyContext) applyStage(langSys []byte, stage tShapeStage) {
+	if c.table == nil || langSys == nil {
+		return
+	}
+	var masks = make(map[int]uint32)
+	var order []int
+	for _, feature := range stage {
+		for _, index := range c.table.featureLookups(langSys, shapeFeatureTag(feature.tag)) {
+			if _, ok := masks[index]; !ok {
+				order = append(order, index)
+			}
+			masks[index] |= feature.mask
+		}
+	}
+	sort.Ints(order)
+	for _, index := range order {
+		c.applyLookup(index, masks[index])
+	}
+}
@@ -0,0 +1,91 @@
+package ggk
+
+import "unicode"
+
+// Arabic joining types.
+const (
+	kArabicJoiningNone        = iota // U: doesn't join.
+	kArabicJoiningRight              // R: joins the character before it.
+	kArabicJoiningDual               // D: joins on both sides.
+	kArabicJoiningCausing            // C: makes its neighbours join, has no forms itself.
+	kArabicJoiningTransparent        // T: is skipped when joining.
+)
+
+// Characters of the Arabic block that join only to the character before
+// them. Ranges are inclusive.
+var gArabicRightJoining = [][2]Unichar{
+	{0x0622, 0x0625}, {0x0627, 0x0627}, {0x0629, 0x0629}, {0x062F, 0x0632},
+	{0x0648, 0x0648}, {0x0671, 0x0673}, {0x0675, 0x0677}, {0x0688, 0x0699},
+	{0x06C0, 0x06C0}, {0x06C3, 0x06CB}, {0x06CD, 0x06CD}, {0x06CF, 0x06CF},
+	{0x06D2, 0x06D3}, {0x06D5, 0x06D5}, {0x06EE, 0x06EF},
+}
+
+// Characters of the Arabic block that join on both sides.
+var gArabicDualJoining = [][2]Unichar{
+	{0x0620, 0x0620}, {0x0626, 0x0626}, {0x0628, 0x0628}, {0x062A, 0x062E},
+	{0x0633, 0x063F}, {0x0641, 0x0647}, {0x0649, 0x064A}, {0x066E, 0x066F},
+	{0x0678, 0x0687}, {0x069A, 0x06BF}, {0x06C1, 0x06C2}, {0x06CC, 0x06CC},
+	{0x06CE, 0x06CE}, {0x06D0, 0x06D1}, {0x06FA, 0x06FC}, {0x06FF, 0x06FF},
+	{0x0750, 0x077F},
+}
+
+func inUnicharRanges(c Unichar, ranges [][2]Unichar) bool {
+	for _, r := range ranges {
+		if c >= r[0] && c <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func arabicJoiningType(c Unichar) int {
+	switch {
+	case c == 0x0640 || c == 0x200D: // tatweel, zero width joiner
+		return kArabicJoiningCausing
+	case unicode.Is(unicode.Mn, rune(c)) || unicode.Is(unicode.Me, rune(c)) || c == 0x200B:
+		return kArabicJoiningTransparent
+	case inUnicharRanges(c, gArabicRightJoining):
+		return kArabicJoiningRight
+	case inUnicharRanges(c, gArabicDualJoining):
+		return kArabicJoiningDual
+	}
+	return kArabicJoiningNone
+}
+
+// Mark each glyph of buf with the isol, fina, medi or init feature for the
+// form it takes given how it joins its neighbours. Transparent characters
+// (marks) don't break the joining of the characters around them.
+func arabicSetupMasks(buf *tShapeBuffer) {
+	var forms = make([]uint32, len(buf.info))
+	var prev = -1
+	var prevType = kArabicJoiningNone
+	for i := range buf.info {
+		var joiningType = arabicJoiningType(buf.info[i].char)
+		if joiningType == kArabicJoiningTransparent {
+			continue
+		}
+		var joinsPrev = prev >= 0 &&
+			(prevType == kArabicJoiningDual || prevType == kArabicJoiningCausing) &&
+			joiningType != kArabicJoiningNone
+		if joinsPrev {
+			switch forms[prev] {
+			case kShapeMaskIsol:
+				forms[prev] = kShapeMaskInit
+			case kShapeMaskFina:
+				forms[prev] = kShapeMaskMedi
+			}
+		}
+		switch joiningType {
+		case kArabicJoiningRight, kArabicJoiningDual:
+			if joinsPrev {
+				forms[i] = kShapeMaskFina
+			} else {
+				forms[i] = kShapeMaskIsol
+			}
+		}
+		prev, prevType = i, joiningType
+	}
+	for i := range buf.info {
+		buf.info[i].mask |= forms[i]
+	}
+}
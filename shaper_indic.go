@@ -0,0 +1,198 @@
+package ggk
+
+// Categories of Devanagari characters.
+const (
+	kIndicCategoryOther = iota
+	kIndicCategoryConsonant
+	kIndicCategoryVowel
+	kIndicCategoryNukta
+	kIndicCategoryHalant
+	kIndicCategoryMatra
+	kIndicCategoryModifier
+	kIndicCategoryZWJ
+	kIndicCategoryZWNJ
+)
+
+const (
+	kDevanagariRa            = 0x0930
+	kDevanagariHalant        = 0x094D
+	kDevanagariSignI         = 0x093F
+	kDevanagariSignPrishthaE = 0x094E
+)
+
+func indicCategory(c Unichar) int {
+	switch {
+	case c >= 0x0915 && c <= 0x0939, c >= 0x0958 && c <= 0x095F, c >= 0x0978 && c <= 0x097F:
+		return kIndicCategoryConsonant
+	case c >= 0x0904 && c <= 0x0914, c >= 0x0960 && c <= 0x0961, c >= 0x0972 && c <= 0x0977:
+		return kIndicCategoryVowel
+	case c == 0x093C:
+		return kIndicCategoryNukta
+	case c == kDevanagariHalant:
+		return kIndicCategoryHalant
+	case c >= 0x093A && c <= 0x093B, c >= 0x093E && c <= 0x094C, c >= 0x094E && c <= 0x094F,
+		c >= 0x0955 && c <= 0x0957, c >= 0x0962 && c <= 0x0963:
+		return kIndicCategoryMatra
+	case c >= 0x0900 && c <= 0x0903:
+		return kIndicCategoryModifier
+	case c == 0x200D:
+		return kIndicCategoryZWJ
+	case c == 0x200C:
+		return kIndicCategoryZWNJ
+	}
+	return kIndicCategoryOther
+}
+
+func isIndicPreBaseMatra(c Unichar) bool {
+	return c == kDevanagariSignI || c == kDevanagariSignPrishthaE
+}
+
+// Return the end of the syllable starting at start, and for consonant
+// syllables the position of the base consonant (otherwise -1).
+//
+//	consonant syllable: (C N? H (ZWJ|ZWNJ)?)* C N? (M N?)* H? SM*
+//	vowel syllable:     V N? (M N?)* SM*
+func indicFindSyllable(buf *tShapeBuffer, start int) (end, base int) {
+	var n = len(buf.info)
+	var category = func(i int) int {
+		if i < n {
+			return buf.info[i].category
+		}
+		return kIndicCategoryOther
+	}
+	var i = start
+	base = -1
+	switch category(i) {
+	case kIndicCategoryConsonant:
+		for {
+			base = i
+			i++
+			if category(i) == kIndicCategoryNukta {
+				i++
+			}
+			if category(i) != kIndicCategoryHalant {
+				break
+			}
+			var j = i + 1
+			if c := category(j); c == kIndicCategoryZWJ || c == kIndicCategoryZWNJ {
+				j++
+			}
+			if category(j) != kIndicCategoryConsonant {
+				// a trailing halant ends the syllable.
+				i++
+				return indicSkipModifiers(buf, i), base
+			}
+			i = j
+		}
+	case kIndicCategoryVowel:
+		i++
+		if category(i) == kIndicCategoryNukta {
+			i++
+		}
+	default:
+		return start + 1, -1
+	}
+	for category(i) == kIndicCategoryMatra {
+		i++
+		if category(i) == kIndicCategoryNukta {
+			i++
+		}
+	}
+	if base >= 0 && category(i) == kIndicCategoryHalant {
+		i++
+	}
+	return indicSkipModifiers(buf, i), base
+}
+
+func indicSkipModifiers(buf *tShapeBuffer, i int) int {
+	for i < len(buf.info) && buf.info[i].category == kIndicCategoryModifier {
+		i++
+	}
+	return i
+}
+
+/** indicInitialReorder
+Split buf into syllables and prepare each consonant syllable for the
+basic features: an initial RA + halant before another consonant is marked
+to become a reph, the consonants before the base to become half forms,
+those after it below, above or post-base forms, and the pre-base matra
+moves to the start of the syllable, where it is drawn. */
+func indicInitialReorder(buf *tShapeBuffer) {
+	for i := range buf.info {
+		buf.info[i].category = indicCategory(buf.info[i].char)
+	}
+	var syllable = 0
+	for start := 0; start < len(buf.info); {
+		var end, base = indicFindSyllable(buf, start)
+		syllable++
+		for i := start; i < end; i++ {
+			buf.info[i].syllable = syllable
+		}
+		if base >= 0 {
+			indicReorderSyllable(buf, start, end, base)
+		}
+		start = end
+	}
+}
+
+func indicReorderSyllable(buf *tShapeBuffer, start, end, base int) {
+	var info = buf.info
+	var hasReph = base > start+1 && info[start].char == kDevanagariRa &&
+		info[start+1].category == kIndicCategoryHalant &&
+		info[start+2].category != kIndicCategoryZWJ
+	for i := start; i < end; i++ {
+		switch {
+		case hasReph && i < start+2:
+			info[i].mask |= kShapeMaskRphf
+		case i < base:
+			info[i].mask |= kShapeMaskHalf
+		case i > base:
+			info[i].mask |= kShapeMaskPostBase
+		}
+	}
+	for i := base + 1; i < end; i++ {
+		if isIndicPreBaseMatra(info[i].char) {
+			var matra = info[i]
+			copy(info[start+1:i+1], info[start:i])
+			info[start] = matra
+		}
+	}
+}
+
+/** indicFinalReorder
+Once the basic features have formed the reph, move it from the start of
+its syllable to before the first post-base matra or modifier, or to the
+end of the syllable, as it is drawn over the last consonant. */
+func indicFinalReorder(buf *tShapeBuffer) {
+	var info = buf.info
+	for start := 0; start < len(info); {
+		var end = start + 1
+		for end < len(info) && info[end].syllable == info[start].syllable {
+			end++
+		}
+		var reph = -1
+		for i := start; i < end; i++ {
+			if info[i].mask&kShapeMaskRphf != 0 {
+				if info[i].substituted && info[i].category == kIndicCategoryConsonant &&
+					(i+1 >= end || info[i+1].category != kIndicCategoryHalant) {
+					reph = i
+				}
+				break
+			}
+		}
+		if reph >= 0 {
+			var to = end - 1
+			for i := reph + 1; i < end; i++ {
+				var c = info[i].category
+				if (c == kIndicCategoryMatra && !isIndicPreBaseMatra(info[i].char)) || c == kIndicCategoryModifier {
+					to = i - 1
+					break
+				}
+			}
+			var glyph = info[reph]
+			copy(info[reph:to], info[reph+1:to+1])
+			info[to] = glyph
+		}
+		start = end
+	}
+}
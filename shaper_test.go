@@ -0,0 +1,236 @@
+package ggk
+
+import "testing"
+
+// The glyphs of the shaping test font, with glyph IDs 1 to 10. Every glyph
+// is a small box, only the advances matter.
+var gShapeTestGlyphs = []tTestGlyph{
+	{char: 'f', advance: 300},
+	{char: 'i', advance: 250},
+	{char: 0xE000, advance: 500}, // fi ligature
+	{char: 'A', advance: 600},
+	{char: 'V', advance: 600},
+	{char: 0x0628, advance: 700}, // beh, isolated
+	{char: 0xE001, advance: 400}, // beh, initial
+	{char: 0xE002, advance: 400}, // beh, medial
+	{char: 0xE003, advance: 500}, // beh, final
+	{char: 0x064E, advance: 200}, // fatha
+}
+
+func otTestCoverage(glyphs ...int) []byte {
+	var b = putU16(putU16(nil, 1), len(glyphs))
+	for _, g := range glyphs {
+		b = putU16(b, g)
+	}
+	return b
+}
+
+// otTestLayout assembles a GSUB or GPOS table with a DFLT script whose
+// default language system enables features, the i-th using the i-th of
+// the lookups. A lookup is its type followed by its single subtable.
+func otTestLayout(features []string, lookups [][]byte) []byte {
+	var n = len(features)
+	var scripts = append(putU16(nil, 1), "DFLT"...)
+	scripts = putU16(putU16(putU16(scripts, 8), 4), 0)
+	scripts = putU16(putU16(putU16(scripts, 0), 0xffff), n)
+	var featureList = putU16(nil, n)
+	for i, tag := range features {
+		featureList = putU16(append(featureList, tag...), 2+6*n+6*i)
+	}
+	for i := range features {
+		featureList = putU16(putU16(putU16(featureList, 0), 1), i)
+		scripts = putU16(scripts, i)
+	}
+	var lookupList = putU16(nil, len(lookups))
+	var lookupData []byte
+	for _, lookup := range lookups {
+		lookupList = putU16(lookupList, 2+2*len(lookups)+len(lookupData))
+		lookupData = putU16(putU16(putU16(putU16(lookupData, int(lookup[1])), 0), 1), 8)
+		lookupData = append(lookupData, lookup[2:]...)
+	}
+	lookupList = append(lookupList, lookupData...)
+
+	var table = putU32(nil, 0x00010000)
+	table = putU16(table, 10)
+	table = putU16(table, 10+len(scripts))
+	table = putU16(table, 10+len(scripts)+len(featureList))
+	table = append(table, scripts...)
+	table = append(table, featureList...)
+	return append(table, lookupList...)
+}
+
+func otTestLookup(lookupType int, subtable ...[]byte) []byte {
+	var b = putU16(nil, lookupType)
+	for _, part := range subtable {
+		b = append(b, part...)
+	}
+	return b
+}
+
+func newShapeTestTypeface(t *testing.T) *Typeface {
+	var glyphs = make([]tTestGlyph, len(gShapeTestGlyphs))
+	for i, g := range gShapeTestGlyphs {
+		g.vAdvance = 1000
+		g.contours = [][][2]int{{{0, 0}, {0, 100}, {100, 100}, {100, 0}}}
+		glyphs[i] = g
+	}
+
+	var gsub = otTestLayout([]string{"liga", "init", "medi", "fina"}, [][]byte{
+		// f i -> fi
+		otTestLookup(4, putU16(putU16(putU16(putU16(nil, 1), 8), 1), 14),
+			otTestCoverage(1), putU16(putU16(nil, 1), 4), putU16(putU16(putU16(nil, 3), 2), 2)),
+		// beh -> initial beh
+		otTestLookup(1, putU16(putU16(putU16(putU16(nil, 2), 8), 1), 7), otTestCoverage(6)),
+		// beh -> medial beh
+		otTestLookup(1, putU16(putU16(putU16(nil, 1), 6), 2), otTestCoverage(6)),
+		// beh -> final beh
+		otTestLookup(1, putU16(putU16(putU16(nil, 1), 6), 3), otTestCoverage(6)),
+	})
+
+	var baseArray = putU16(nil, 4)
+	for i := 0; i < 4; i++ {
+		baseArray = putU16(baseArray, 10+6*i)
+	}
+	for i := 0; i < 4; i++ {
+		baseArray = putU16(putU16(putU16(baseArray, 1), 350), 600)
+	}
+	var gpos = otTestLayout([]string{"kern", "mark"}, [][]byte{
+		// A V: -80 on the advance of A.
+		otTestLookup(2, putU16(putU16(putU16(putU16(putU16(putU16(nil, 1), 12), 4), 0), 1), 18),
+			otTestCoverage(4), putU16(putU16(putU16(nil, 1), 5), 0xffff-80+1)),
+		// fatha on the forms of beh.
+		otTestLookup(4, putU16(putU16(putU16(putU16(putU16(putU16(nil, 1), 12), 18), 1), 30), 42),
+			otTestCoverage(10), otTestCoverage(6, 7, 8, 9),
+			putU16(putU16(putU16(nil, 1), 0), 6), putU16(putU16(putU16(nil, 1), 100), 0),
+			baseArray),
+	})
+
+	var typeface, err = NewTypefaceFromData(makeTestTrueTypeFont(glyphs,
+		tTestTable{"GSUB", gsub}, tTestTable{"GPOS", gpos}))
+	if err != nil {
+		t.Fatalf("NewTypefaceFromData() failed: %v", err)
+	}
+	return typeface
+}
+
+func TestShaper(t *testing.T) {
+	var shaper = NewShaper(newShapeTestTypeface(t))
+	if !shaper.Good() {
+		t.Fatalf("Good() want true")
+	}
+	var paint = NewPaint()
+	paint.SetTextSize(1000) // one pixel per font unit.
+
+	var tests = []struct {
+		name        string
+		text        string
+		leftToRight bool
+		glyphs      []GlyphID
+		pos         []Scalar
+		width       Scalar
+	}{
+		{"ligature", "fi", true, []GlyphID{3}, []Scalar{0, 0}, 500},
+		{"no ligature", "if", true, []GlyphID{2, 1}, []Scalar{0, 0, 250, 0}, 550},
+		{"kerning", "AV", true, []GlyphID{4, 5}, []Scalar{0, 0, 520, 0}, 1120},
+		{"isolated", "ب", false, []GlyphID{6}, []Scalar{0, 0}, 700},
+		{"initial and final", "بب", false, []GlyphID{9, 7}, []Scalar{0, 0, 500, 0}, 900},
+		{"medial", "ببب", false, []GlyphID{9, 8, 7},
+			[]Scalar{0, 0, 500, 0, 900, 0}, 1300},
+		{"mark", "بَ", false, []GlyphID{10, 6}, []Scalar{250, -600, 0, 0}, 700},
+		{"mark is transparent", "بَب", false, []GlyphID{9, 10, 7},
+			[]Scalar{0, 0, 750, -600, 500, 0}, 900},
+	}
+	for _, test := range tests {
+		var builder = NewTextBlobBuilder()
+		var width = shaper.Shape(builder, paint, test.text, test.leftToRight, Point{0, 0})
+		if width != test.width {
+			t.Errorf("%v width want %v got %v", test.name, test.width, width)
+		}
+		var it = NewTextBlobRunIterator(builder.Make())
+		var glyphs, pos = it.Glyphs(), it.Pos()
+		if len(glyphs) != len(test.glyphs) {
+			t.Errorf("%v glyphs want %v got %v", test.name, test.glyphs, glyphs)
+			continue
+		}
+		for i := range glyphs {
+			if glyphs[i] != test.glyphs[i] {
+				t.Errorf("%v glyphs want %v got %v", test.name, test.glyphs, glyphs)
+				break
+			}
+		}
+		for i := range pos {
+			if ScalarAbs(pos[i]-test.pos[i]) > 1e-3 {
+				t.Errorf("%v pos want %v got %v", test.name, test.pos, pos)
+				break
+			}
+		}
+	}
+}
+
+func TestShaperOffset(t *testing.T) {
+	var shaper = NewShaper(newShapeTestTypeface(t))
+	var paint = NewPaint()
+	paint.SetTextSize(10)
+	paint.SetTextScaleX(2)
+
+	var builder = NewTextBlobBuilder()
+	var width = shaper.Shape(builder, paint, "AV", true, Point{5, 7})
+	if ScalarAbs(width-22.4) > 1e-3 {
+		t.Errorf("width want 22.4 got %v", width)
+	}
+	var pos = NewTextBlobRunIterator(builder.Make()).Pos()
+	var want = []Scalar{5, 7, 15.4, 7}
+	for i := range want {
+		if ScalarAbs(pos[i]-want[i]) > 1e-3 {
+			t.Errorf("pos want %v got %v", want, pos)
+			break
+		}
+	}
+}
+
+func TestIndicReorder(t *testing.T) {
+	var tests = []struct {
+		name  string
+		text  string
+		chars []Unichar
+		masks []uint32
+	}{
+		{"pre-base matra", "कि", []Unichar{0x093F, 0x0915},
+			[]uint32{kShapeMaskPostBase, 0}},
+		{"half form", "स्त", []Unichar{0x0938, 0x094D, 0x0924},
+			[]uint32{kShapeMaskHalf, kShapeMaskHalf, 0}},
+		{"reph", "र्क", []Unichar{0x0930, 0x094D, 0x0915},
+			[]uint32{kShapeMaskRphf, kShapeMaskRphf, 0}},
+		{"no reph alone", "र्", []Unichar{0x0930, 0x094D},
+			[]uint32{0, kShapeMaskPostBase}},
+	}
+	for _, test := range tests {
+		var buf = &tShapeBuffer{}
+		for _, r := range test.text {
+			buf.info = append(buf.info, tShapeInfo{char: Unichar(r)})
+		}
+		indicInitialReorder(buf)
+		for i := range buf.info {
+			if buf.info[i].char != test.chars[i] || buf.info[i].mask != test.masks[i] {
+				t.Errorf("%v glyph %v want %x mask %x got %x mask %x", test.name, i,
+					test.chars[i], test.masks[i], buf.info[i].char, buf.info[i].mask)
+			}
+		}
+	}
+
+	// once RA + halant ligate into a reph, it moves after the base and
+	// before the matra that follows.
+	var buf = &tShapeBuffer{}
+	for _, r := range "र्का" {
+		buf.info = append(buf.info, tShapeInfo{char: Unichar(r), id: GlyphID(r)})
+	}
+	indicInitialReorder(buf)
+	buf.ligate([]int{0, 1}, 1, nil)
+	indicFinalReorder(buf)
+	var want = []GlyphID{0x0915, 1, 0x093E}
+	for i := range want {
+		if buf.info[i].id != want[i] {
+			t.Errorf("final reorder want %x got %x at %v", want[i], buf.info[i].id, i)
+		}
+	}
+}
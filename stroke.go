@@ -0,0 +1,239 @@
+package ggk
+
+// The ratio of the distance of the control points of a cubic from the ends
+// of the quarter circle it draws, to the radius.
+const kStrokeCircleCubic Scalar = 0.552284749
+
+/** tStrokeContour
+is a contour of a path flattened into points, without repeated points. */
+type tStrokeContour struct {
+	pts    []Point
+	closed bool
+	// the contour had lines or curves, even if they were of zero length.
+	hasSegments bool
+}
+
+func (contour *tStrokeContour) add(pt Point) {
+	if n := len(contour.pts); n > 0 && contour.pts[n-1] == pt {
+		return
+	}
+	contour.pts = append(contour.pts, pt)
+}
+
+/** flattenStrokeContours
+Return the contours of path, with curves flattened into lines. resScale is
+how much bigger than the path the lines are drawn. */
+func flattenStrokeContours(path *Path, resScale Scalar) []*tStrokeContour {
+	if resScale <= 0 {
+		resScale = 1
+	}
+	var contours []*tStrokeContour
+	var contour *tStrokeContour
+	var iter = NewPathIter(path, false)
+	var pts [4]Point
+	for {
+		var verb = iter.Next(pts[:])
+		switch verb {
+		case KPathVerbMove:
+			contour = &tStrokeContour{}
+			contour.add(pts[0])
+			contours = append(contours, contour)
+		case KPathVerbLine:
+			contour.add(pts[1])
+			contour.hasSegments = true
+		case KPathVerbQuad:
+			var count = quadOrCubicSubdivisions(resScale*(ScalarAbs(pts[0].X-2*pts[1].X+pts[2].X)+
+				ScalarAbs(pts[0].Y-2*pts[1].Y+pts[2].Y)), resScale*PointDistance(pts[0], pts[2]))
+			for i := 1; i <= count; i++ {
+				var pt, _ = EvalQuadAt(pts[:3], Scalar(i)/Scalar(count))
+				contour.add(pt)
+			}
+			contour.hasSegments = true
+		case KPathVerbCubic:
+			var d1 = ScalarAbs(pts[0].X-2*pts[1].X+pts[2].X) + ScalarAbs(pts[0].Y-2*pts[1].Y+pts[2].Y)
+			var d2 = ScalarAbs(pts[1].X-2*pts[2].X+pts[3].X) + ScalarAbs(pts[1].Y-2*pts[2].Y+pts[3].Y)
+			var count = quadOrCubicSubdivisions(resScale*ScalarMax(d1, d2), resScale*PointDistance(pts[0], pts[3]))
+			for i := 1; i <= count; i++ {
+				var pt, _ = EvalCubicAt(pts[:4], Scalar(i)/Scalar(count))
+				contour.add(pt)
+			}
+			contour.hasSegments = true
+		case KPathVerbClose:
+			contour.closed = true
+			contour.hasSegments = true
+		case KPathVerbDone:
+			return contours
+		}
+	}
+}
+
+/** tStroker
+outlines lines with a width, joining them and capping their ends as a
+stroke rec says. The outline is a union of pieces: a quad along each line,
+and the joins and caps around its ends. Every piece winds the same way, so
+filling them all with the winding rule fills the union. */
+type tStroker struct {
+	dst    *Path
+	radius Scalar
+	rec    *StrokeRec
+}
+
+// Add the polygon pts to the outline, reversed if it winds the other way.
+func (stroker *tStroker) addPoly(pts ...Point) {
+	var area Scalar
+	for i := range pts {
+		var next = pts[(i+1)%len(pts)]
+		area += PointCross(pts[i], next)
+	}
+	if area < 0 {
+		for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+			pts[i], pts[j] = pts[j], pts[i]
+		}
+	}
+	stroker.dst.AddPoly(pts, true)
+}
+
+// Add the circle of the stroke around center to the outline.
+func (stroker *tStroker) addCircle(center Point) {
+	var r = stroker.radius
+	var k = r * kStrokeCircleCubic
+	var cx, cy = center.X, center.Y
+	var dst = stroker.dst
+	dst.MoveTo(cx+r, cy)
+	dst.CubicTo(cx+r, cy+k, cx+k, cy+r, cx, cy+r)
+	dst.CubicTo(cx-k, cy+r, cx-r, cy+k, cx-r, cy)
+	dst.CubicTo(cx-r, cy-k, cx-k, cy-r, cx, cy-r)
+	dst.CubicTo(cx+k, cy-r, cx+r, cy-k, cx+r, cy)
+	dst.Close()
+}
+
+// Return the unit direction from a to b.
+func strokeDirection(a, b Point) Point {
+	var dir = Point{b.X - a.X, b.Y - a.Y}
+	dir.Normalize()
+	return dir
+}
+
+// Return the offset of the stroke from the line of unit direction dir, on
+// its left side.
+func (stroker *tStroker) normal(dir Point) Point {
+	return Point{-dir.Y * stroker.radius, dir.X * stroker.radius}
+}
+
+// Add the quad of the line from a to b.
+func (stroker *tStroker) line(a, b Point) {
+	var n = stroker.normal(strokeDirection(a, b))
+	stroker.addPoly(Point{a.X + n.X, a.Y + n.Y}, Point{b.X + n.X, b.Y + n.Y},
+		Point{b.X - n.X, b.Y - n.Y}, Point{a.X - n.X, a.Y - n.Y})
+}
+
+/** join
+Add the join at pt between a line coming in along the unit direction in
+and one going out along out. Only the outer side of the turn needs filling,
+the quads of the lines already cover the inner one. */
+func (stroker *tStroker) join(pt, in, out Point) {
+	var cross = PointCross(in, out)
+	if ScalarNearlyZero(cross, KScalarNearlyZero) && PointDot(in, out) > 0 {
+		return
+	}
+	if stroker.rec.Join() == KPaintJoinRound {
+		stroker.addCircle(pt)
+		return
+	}
+
+	var a, b = stroker.normal(in), stroker.normal(out)
+	if cross > 0 {
+		a.Negate()
+		b.Negate()
+	}
+	var pa, pb = Point{pt.X + a.X, pt.Y + a.Y}, Point{pt.X + b.X, pt.Y + b.Y}
+	if stroker.rec.Join() == KPaintJoinMiter {
+		// the miter reaches 1 / cos(theta / 2) of the radius from pt, theta
+		// being the angle between the normals.
+		var mid = Point{a.X + b.X, a.Y + b.Y}
+		if mid.Normalize() {
+			var cosHalf = PointDot(mid, a) / stroker.radius
+			if cosHalf > 0 && 1/cosHalf <= stroker.rec.MiterLimit() {
+				mid.Scale(stroker.radius / cosHalf)
+				stroker.addPoly(pt, pa, Point{pt.X + mid.X, pt.Y + mid.Y}, pb)
+				return
+			}
+		}
+	}
+	stroker.addPoly(pt, pa, pb)
+}
+
+// Add the cap at the end pt of a line going out along the unit direction
+// dir.
+func (stroker *tStroker) cap(pt, dir Point) {
+	switch stroker.rec.Cap() {
+	case KPaintCapRound:
+		stroker.addCircle(pt)
+	case KPaintCapSquare:
+		var n = stroker.normal(dir)
+		var e = Point{dir.X * stroker.radius, dir.Y * stroker.radius}
+		stroker.addPoly(Point{pt.X + n.X, pt.Y + n.Y}, Point{pt.X + n.X + e.X, pt.Y + n.Y + e.Y},
+			Point{pt.X - n.X + e.X, pt.Y - n.Y + e.Y}, Point{pt.X - n.X, pt.Y - n.Y})
+	}
+}
+
+func (stroker *tStroker) contour(contour *tStrokeContour) {
+	var pts = contour.pts
+	if len(pts) > 1 && contour.closed && pts[0] == pts[len(pts)-1] {
+		pts = pts[:len(pts)-1]
+	}
+	if len(pts) == 1 {
+		// a zero length contour is drawn as its caps, without rotation.
+		if !contour.hasSegments {
+			return
+		}
+		switch stroker.rec.Cap() {
+		case KPaintCapRound:
+			stroker.addCircle(pts[0])
+		case KPaintCapSquare:
+			var pt, r = pts[0], stroker.radius
+			stroker.addPoly(Point{pt.X - r, pt.Y - r}, Point{pt.X + r, pt.Y - r}, Point{pt.X + r, pt.Y + r},
+				Point{pt.X - r, pt.Y + r})
+		}
+		return
+	}
+
+	var n = len(pts)
+	var segments = n - 1
+	if contour.closed {
+		segments = n
+	}
+	for i := 0; i < segments; i++ {
+		stroker.line(pts[i], pts[(i+1)%n])
+	}
+	for i := 1; i < n-1; i++ {
+		stroker.join(pts[i], strokeDirection(pts[i-1], pts[i]), strokeDirection(pts[i], pts[i+1]))
+	}
+	if contour.closed {
+		stroker.join(pts[n-1], strokeDirection(pts[n-2], pts[n-1]), strokeDirection(pts[n-1], pts[0]))
+		stroker.join(pts[0], strokeDirection(pts[n-1], pts[0]), strokeDirection(pts[0], pts[1]))
+	} else {
+		stroker.cap(pts[0], strokeDirection(pts[1], pts[0]))
+		stroker.cap(pts[n-1], strokeDirection(pts[n-2], pts[n-1]))
+	}
+}
+
+/** strokePath
+Return the outline of src stroked as rec says, which is filled with the
+winding rule, or its inverse if src is an inverse fill. The outline of a
+stroke and fill also covers the contours of src, each filled on its own so
+that holes are filled. rec must be a stroke of a positive width. resScale
+is how much bigger than the path the outline is drawn. */
+func strokePath(src *Path, rec *StrokeRec, resScale Scalar) *Path {
+	var stroker = &tStroker{dst: NewPath(), radius: ScalarHalf(rec.Width()), rec: rec}
+	for _, contour := range flattenStrokeContours(src, resScale) {
+		stroker.contour(contour)
+		if rec.Style() == KStrokeRecStyleStrokeAndFill && len(contour.pts) > 2 {
+			stroker.addPoly(append([]Point(nil), contour.pts...)...)
+		}
+	}
+	if src.IsInverseFillType() {
+		stroker.dst.SetFillType(KPathFillTypeInverseWinding)
+	}
+	return stroker.dst
+}
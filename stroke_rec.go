@@ -0,0 +1,141 @@
+package ggk
+
+type StrokeRecInitStyle int
+
+const (
+	KStrokeRecInitStyleHairline StrokeRecInitStyle = iota
+	KStrokeRecInitStyleFill
+)
+
+type StrokeRecStyle int
+
+const (
+	KStrokeRecStyleHairline StrokeRecStyle = iota
+	KStrokeRecStyleFill
+	KStrokeRecStyleStroke
+	KStrokeRecStyleStrokeAndFill
+)
+
+const (
+	kStrokeRecFillStyleWidth    Scalar = -1 // the width of a StrokeRec that fills
+	kStrokeRecDefaultMiterLimit Scalar = 4
+)
+
+/** StrokeRec
+describes how a path is drawn after its path effect: filled, as a hairline
+or stroked with a width, cap, join and miter limit. Path effects read it to
+decide what they do and may change it, like an effect making lines into
+outlines of a width. */
+type StrokeRec struct {
+	width         Scalar
+	miterLimit    Scalar
+	cap           PaintCap
+	join          PaintJoin
+	strokeAndFill bool
+}
+
+func NewStrokeRec(style StrokeRecInitStyle) *StrokeRec {
+	var rec = &StrokeRec{
+		width:      kStrokeRecFillStyleWidth,
+		miterLimit: kStrokeRecDefaultMiterLimit,
+		cap:        KPaintCapDefault,
+		join:       KPaintJoinDefault,
+	}
+	if style == KStrokeRecInitStyleHairline {
+		rec.width = 0
+	}
+	return rec
+}
+
+// NewStrokeRecFromPaint returns how paint draws paths.
+func NewStrokeRecFromPaint(paint *Paint) *StrokeRec {
+	var rec = NewStrokeRec(KStrokeRecInitStyleFill)
+	switch paint.Style() {
+	case KPaintStyleStroke:
+		rec.SetStrokeStyle(paint.StrokeWidth(), false)
+	case KPaintStyleStrokeAndFill:
+		rec.SetStrokeStyle(paint.StrokeWidth(), true)
+	}
+	rec.miterLimit, rec.cap, rec.join = paint.StrokeMiter(), paint.StrokeCap(), paint.StrokeJoin()
+	return rec
+}
+
+func (rec *StrokeRec) Style() StrokeRecStyle {
+	if rec.width < 0 {
+		return KStrokeRecStyleFill
+	}
+	if rec.width == 0 {
+		return KStrokeRecStyleHairline
+	}
+	if rec.strokeAndFill {
+		return KStrokeRecStyleStrokeAndFill
+	}
+	return KStrokeRecStyleStroke
+}
+
+func (rec *StrokeRec) Width() Scalar {
+	return rec.width
+}
+
+func (rec *StrokeRec) MiterLimit() Scalar {
+	return rec.miterLimit
+}
+
+func (rec *StrokeRec) Cap() PaintCap {
+	return rec.cap
+}
+
+func (rec *StrokeRec) Join() PaintJoin {
+	return rec.join
+}
+
+func (rec *StrokeRec) IsHairlineStyle() bool {
+	return rec.Style() == KStrokeRecStyleHairline
+}
+
+func (rec *StrokeRec) IsFillStyle() bool {
+	return rec.Style() == KStrokeRecStyleFill
+}
+
+func (rec *StrokeRec) SetFillStyle() {
+	rec.width, rec.strokeAndFill = kStrokeRecFillStyleWidth, false
+}
+
+func (rec *StrokeRec) SetHairlineStyle() {
+	rec.width, rec.strokeAndFill = 0, false
+}
+
+/** SetStrokeStyle
+Stroke with width, also filling if strokeAndFill is true. A width of 0 is
+a hairline, which is never filled. */
+func (rec *StrokeRec) SetStrokeStyle(width Scalar, strokeAndFill bool) {
+	if width == 0 {
+		strokeAndFill = false
+	}
+	rec.width, rec.strokeAndFill = width, strokeAndFill
+}
+
+// SetStrokeParams sets the cap, join and miter limit of strokes.
+func (rec *StrokeRec) SetStrokeParams(cap PaintCap, join PaintJoin, miterLimit Scalar) {
+	rec.cap, rec.join, rec.miterLimit = cap, join, miterLimit
+}
+
+/** InflationRadius
+Return how far drawing the path with this rec may reach outside the bounds
+of the path: nothing when filling, a pixel for hairlines, and half the
+width scaled for the miter join or square cap when stroking. */
+func (rec *StrokeRec) InflationRadius() Scalar {
+	if rec.width < 0 {
+		return 0
+	} else if rec.width == 0 {
+		return KScalar1
+	}
+	var multiplier = KScalar1
+	if rec.join == KPaintJoinMiter {
+		multiplier = ScalarMax(multiplier, rec.miterLimit)
+	}
+	if rec.cap == KPaintCapSquare {
+		multiplier = ScalarMax(multiplier, KScalarSqrt2)
+	}
+	return rec.width / 2 * multiplier
+}
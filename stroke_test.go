@@ -0,0 +1,88 @@
+package ggk
+
+import "testing"
+
+func TestStrokePath(t *testing.T) {
+	// an open corner going right along y = 10, then down along x = 20.
+	var corner = NewPath()
+	corner.MoveTo(4, 10)
+	corner.LineTo(20, 10)
+	corner.LineTo(20, 26)
+	var dot = NewPath()
+	dot.MoveTo(10, 10)
+	dot.LineTo(10, 10)
+	var square = newTestRectPath(MakeRect(8, 8, 12, 12))
+
+	var red = PackARGB32(0xff, 0xff, 0, 0)
+	var tests = []struct {
+		name  string
+		path  *Path
+		style PaintStyle
+		width Scalar
+		cap   PaintCap
+		join  PaintJoin
+		miter Scalar
+		x, y  int
+		want  uint32
+	}{
+		{"line", corner, KPaintStyleStroke, 4, KPaintCapButt, KPaintJoinMiter, 4, 10, 11, red},
+		{"outside the width", corner, KPaintStyleStroke, 4, KPaintCapButt, KPaintJoinMiter, 4, 10, 13, 0},
+		{"inside the corner", corner, KPaintStyleStroke, 4, KPaintCapButt, KPaintJoinMiter, 4, 15, 15, 0},
+		{"butt cap", corner, KPaintStyleStroke, 4, KPaintCapButt, KPaintJoinMiter, 4, 3, 10, 0},
+		{"square cap", corner, KPaintStyleStroke, 4, KPaintCapSquare, KPaintJoinMiter, 4, 3, 10, red},
+		{"round cap", corner, KPaintStyleStroke, 4, KPaintCapRound, KPaintJoinMiter, 4, 3, 10, red},
+		{"square cap corner", corner, KPaintStyleStroke, 8, KPaintCapSquare, KPaintJoinMiter, 4, 0, 6, red},
+		{"round cap corner", corner, KPaintStyleStroke, 8, KPaintCapRound, KPaintJoinMiter, 4, 0, 6, 0},
+		{"miter join", corner, KPaintStyleStroke, 8, KPaintCapButt, KPaintJoinMiter, 4, 23, 6, red},
+		{"miter limit", corner, KPaintStyleStroke, 8, KPaintCapButt, KPaintJoinMiter, 1, 23, 6, 0},
+		{"bevel join", corner, KPaintStyleStroke, 8, KPaintCapButt, KPaintJoinBevel, 4, 23, 6, 0},
+		{"bevel join edge", corner, KPaintStyleStroke, 8, KPaintCapButt, KPaintJoinBevel, 4, 20, 7, red},
+		{"round join", corner, KPaintStyleStroke, 8, KPaintCapButt, KPaintJoinRound, 4, 23, 6, 0},
+		{"round join edge", corner, KPaintStyleStroke, 8, KPaintCapButt, KPaintJoinRound, 4, 21, 7, red},
+		{"zero length butt", dot, KPaintStyleStroke, 4, KPaintCapButt, KPaintJoinMiter, 4, 10, 10, 0},
+		{"zero length square", dot, KPaintStyleStroke, 4, KPaintCapSquare, KPaintJoinMiter, 4, 8, 8, red},
+		{"closed", square, KPaintStyleStroke, 4, KPaintCapButt, KPaintJoinMiter, 4, 6, 6, red},
+		{"closed inside", square, KPaintStyleStroke, 4, KPaintCapButt, KPaintJoinMiter, 4, 14, 14, 0},
+		{"stroke and fill", square, KPaintStyleStrokeAndFill, 4, KPaintCapButt, KPaintJoinMiter, 4, 14, 14, red},
+		{"stroke and fill outside", square, KPaintStyleStrokeAndFill, 4, KPaintCapButt, KPaintJoinMiter, 4, 6, 6,
+			red},
+		{"hairline", corner, KPaintStyleStroke, 0, KPaintCapButt, KPaintJoinMiter, 4, 10, 9, PackARGB32(0x80, 0x80,
+			0, 0)},
+		{"hairline width", corner, KPaintStyleStroke, 0, KPaintCapButt, KPaintJoinMiter, 4, 10, 11, 0},
+	}
+	for _, test := range tests {
+		var paint = newTestPaint(KColorRed)
+		paint.SetStyle(test.style)
+		paint.SetStrokeWidth(test.width)
+		paint.SetStrokeCap(test.cap)
+		paint.SetStrokeJoin(test.join)
+		paint.SetStrokeMiter(test.miter)
+		var canvas, pixels = newTestPictureCanvas(32, 32)
+		canvas.DrawPath(test.path, paint)
+		if got := pixels.Pixel32(test.x, test.y); got != test.want {
+			t.Errorf("%v at (%v, %v) want %#x got %#x", test.name, test.x, test.y, test.want, got)
+		}
+	}
+}
+
+func TestPaintStrokeParams(t *testing.T) {
+	var paint = NewPaint()
+	if paint.StrokeWidth() != 0 || paint.StrokeMiter() != 4 || paint.StrokeCap() != KPaintCapButt ||
+		paint.StrokeJoin() != KPaintJoinMiter {
+		t.Errorf("NewPaint() want a hairline with miter joins of limit 4 and butt caps")
+	}
+	paint.SetStrokeWidth(3)
+	paint.SetStrokeMiter(2)
+	paint.SetStrokeCap(KPaintCapRound)
+	paint.SetStrokeJoin(KPaintJoinBevel)
+	// invalid values are ignored.
+	paint.SetStrokeWidth(-1)
+	paint.SetStrokeMiter(-1)
+	paint.SetStrokeCap(KPaintCapCount)
+	paint.SetStrokeJoin(KPaintJoinCount)
+	if paint.StrokeWidth() != 3 || paint.StrokeMiter() != 2 || paint.StrokeCap() != KPaintCapRound ||
+		paint.StrokeJoin() != KPaintJoinBevel {
+		t.Errorf("want width 3, miter 2, round caps and bevel joins got %v, %v, %v, %v", paint.StrokeWidth(),
+			paint.StrokeMiter(), paint.StrokeCap(), paint.StrokeJoin())
+	}
+}
@@ -6,13 +6,35 @@ package ggk
 type PixelGeometry int
 
 const (
-	KPixelGeometryUnknown = iota
+	KPixelGeometryUnknown = PixelGeometry(iota)
 	KPixelGeometryRGBH
 	KPixelGeometryBGRH
 	KPixelGeometryRGBV
 	KPixelGeometryBGRV
 )
 
+// PixelGeometryIsRGB returns true if the subpixels are ordered red, green, blue.
+func PixelGeometryIsRGB(geo PixelGeometry) bool {
+	return geo == KPixelGeometryRGBH || geo == KPixelGeometryRGBV
+}
+
+// PixelGeometryIsBGR returns true if the subpixels are ordered blue, green, red.
+func PixelGeometryIsBGR(geo PixelGeometry) bool {
+	return geo == KPixelGeometryBGRH || geo == KPixelGeometryBGRV
+}
+
+// PixelGeometryIsH returns true if the subpixels are arranged horizontally,
+// as vertical strips side by side.
+func PixelGeometryIsH(geo PixelGeometry) bool {
+	return geo == KPixelGeometryRGBH || geo == KPixelGeometryBGRH
+}
+
+// PixelGeometryIsV returns true if the subpixels are stacked vertically, as
+// horizontal strips one above the other.
+func PixelGeometryIsV(geo PixelGeometry) bool {
+	return geo == KPixelGeometryRGBV || geo == KPixelGeometryBGRV
+}
+
 type SurfacePropsFlags int
 
 const (
@@ -50,6 +72,21 @@ func NewSurfaceProps(flags SurfacePropsFlags, initType SurfacePropsInitType) *Su
 	return props
 }
 
+func NewSurfacePropsGeometry(flags SurfacePropsFlags, pixelGeometry PixelGeometry) *SurfaceProps {
+	return &SurfaceProps{
+		flags:         flags,
+		pixelGeometry: pixelGeometry,
+	}
+}
+
+func (props *SurfaceProps) Flags() SurfacePropsFlags {
+	return props.flags
+}
+
+func (props *SurfaceProps) PixelGeometry() PixelGeometry {
+	return props.pixelGeometry
+}
+
 func (props *SurfaceProps) OutstandingImageSnapshot() *BaseSurface {
 	toimpl()
 	return nil
@@ -60,7 +97,7 @@ func (props *SurfaceProps) AboutToDraw(mode SurfacePropsContentChangeMode) {
 }
 
 func computeDefaultGeometry() PixelGeometry {
-	var order = FontLCDConfigSubpixelOrientation()
+	var order = FontLCDConfigSubpixelOrder()
 	if order == KLCDOrderNone {
 		return KPixelGeometryUnknown
 	} else {
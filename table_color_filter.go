@@ -0,0 +1,62 @@
+package ggk
+
+/** tTableColorFilter
+looks up each unpremultiplied component of a color, as a byte, in the
+table of its channel. A nil table leaves its channel as it is. */
+type tTableColorFilter struct {
+	tableA *[256]uint8
+	tableR *[256]uint8
+	tableG *[256]uint8
+	tableB *[256]uint8
+}
+
+// NewTableColorFilter returns a filter looking up all four components of
+// colors in table.
+func NewTableColorFilter(table *[256]uint8) *ColorFilter {
+	return NewTableARGBColorFilter(table, table, table, table)
+}
+
+/** NewTableARGBColorFilter
+Return a filter looking up each component of colors in the table of its
+channel, or nil if all the tables are nil. */
+func NewTableARGBColorFilter(tableA, tableR, tableG, tableB *[256]uint8) *ColorFilter {
+	if tableA == nil && tableR == nil && tableG == nil && tableB == nil {
+		return nil
+	}
+	return NewColorFilter(&tTableColorFilter{tableA, tableR, tableG, tableB})
+}
+
+// Return c looked up in table, or c if table is nil.
+func lookupColorTable(table *[256]uint8, c float32) float32 {
+	if table == nil {
+		return c
+	}
+	return float32(table[unitToByte(c)]) / 255
+}
+
+func (impl *tTableColorFilter) OnFilterColor4f(color Color4f) Color4f {
+	return Color4f{
+		lookupColorTable(impl.tableR, color.R),
+		lookupColorTable(impl.tableG, color.G),
+		lookupColorTable(impl.tableB, color.B),
+		lookupColorTable(impl.tableA, color.A),
+	}
+}
+
+func (impl *tTableColorFilter) OnAppendStages(pipeline *RasterPipeline) bool {
+	pipeline.AppendStage(unpremul, nil)
+	pipeline.AppendStage(colorTableStage, impl)
+	pipeline.AppendStage(premul, nil)
+	return true
+}
+
+// tColorTableStage looks up the unpremultiplied source color in its
+// *tTableColorFilter context.
+type tColorTableStage struct{}
+
+var colorTableStage = &tColorTableStage{}
+
+func (fn *tColorTableStage) Run(pixel *RasterPipelinePixel, context interface{}) {
+	var color = context.(*tTableColorFilter).OnFilterColor4f(Color4f{pixel.R, pixel.G, pixel.B, pixel.A})
+	pixel.R, pixel.G, pixel.B, pixel.A = color.R, color.G, color.B, color.A
+}
@@ -1,5 +1,306 @@
 package ggk
 
+import "sync/atomic"
+
+/** TextBlobPositioning
+How the glyphs of a text blob run are positioned.
+KTextBlobPositioningDefault    -> the glyphs are laid out by their advances from the run offset.
+KTextBlobPositioningHorizontal -> each glyph has its own x, the run offset gives the y.
+KTextBlobPositioningFull       -> each glyph has its own x and y. */
+type TextBlobPositioning int
+
+const (
+	KTextBlobPositioningDefault = TextBlobPositioning(iota)
+	KTextBlobPositioningHorizontal
+	KTextBlobPositioningFull
+)
+
+// Return the number of scalars per glyph position for positioning.
+func textBlobScalarsPerGlyph(positioning TextBlobPositioning) int {
+	return int(positioning)
+}
+
+// The paint flags that affect how glyphs look, and so belong to the font
+// of a run.
+const kTextBlobFontFlagsMask = KPaintFlagAntiAlias | KPaintFlagFakeBoldText |
+	KPaintFlagLinearText | KPaintFlagSubpixelText | KPaintFlagDevKernText |
+	KPaintFlagLCDRenderText | KPaintFlagEmbeddedBitmapText | KPaintFlagAutoHinting |
+	KPaintFlagVerticalText | KPaintFlagGenA8FromLCD
+
+// tTextBlobRunFont holds the text attributes of the paint a run was built
+// with, so the blob draws the same way whatever paint it is drawn with.
+type tTextBlobRunFont struct {
+	typeface *Typeface
+	size     Scalar
+	scaleX   Scalar
+	skewX    Scalar
+	align    PaintAlign
+	hinting  PaintHinting
+	flags    PaintFlags
+}
+
+func newTextBlobRunFont(paint *Paint) tTextBlobRunFont {
+	return tTextBlobRunFont{
+		typeface: paint.Typeface(),
+		size:     paint.TextSize(),
+		scaleX:   paint.TextScaleX(),
+		skewX:    paint.TextSkewX(),
+		align:    paint.TextAlign(),
+		hinting:  paint.Hinting(),
+		flags:    paint.Flags() & kTextBlobFontFlagsMask,
+	}
+}
+
+func (font *tTextBlobRunFont) applyToPaint(paint *Paint) {
+	paint.SetTextEncoding(KPaintTextEncodingGlyphID)
+	paint.SetTypeface(font.typeface)
+	paint.SetTextSize(font.size)
+	paint.SetTextScaleX(font.scaleX)
+	paint.SetTextSkewX(font.skewX)
+	paint.SetTextAlign(font.align)
+	paint.SetHinting(font.hinting)
+	paint.SetFlags(paint.Flags()&^kTextBlobFontFlagsMask | font.flags)
+}
+
+type tTextBlobRun struct {
+	font        tTextBlobRunFont
+	glyphs      []GlyphID
+	pos         []Scalar
+	offset      Point
+	positioning TextBlobPositioning
+	givenBounds bool // the caller gave the bounds of the run.
+}
+
+/** TextBlob
+combines multiple text runs into an immutable structure. */
 type TextBlob struct {
+	runs     []*tTextBlobRun
+	bounds   Rect
+	uniqueID uint32
+}
+
+var gTextBlobUniqueID uint32
+
+// Returns conservative blob bounds.
+func (blob *TextBlob) Bounds() Rect {
+	return blob.bounds
+}
+
+// Return a non-zero, unique value representing the text blob.
+func (blob *TextBlob) UniqueID() uint32 {
+	return blob.uniqueID
+}
+
+/** TextBlobRunIterator
+iterates over the runs of a text blob.
+
+	for it := NewTextBlobRunIterator(blob); !it.Done(); it.Next() {
+		...
+	} */
+type TextBlobRunIterator struct {
+	blob  *TextBlob
+	index int
+}
+
+func NewTextBlobRunIterator(blob *TextBlob) *TextBlobRunIterator {
+	return &TextBlobRunIterator{blob: blob}
+}
+
+func (it *TextBlobRunIterator) Done() bool {
+	return it.index >= len(it.blob.runs)
+}
+
+func (it *TextBlobRunIterator) Next() {
+	it.index++
+}
+
+func (it *TextBlobRunIterator) run() *tTextBlobRun {
+	return it.blob.runs[it.index]
+}
+
+func (it *TextBlobRunIterator) GlyphCount() int {
+	return len(it.run().glyphs)
+}
+
+func (it *TextBlobRunIterator) Glyphs() []GlyphID {
+	return it.run().glyphs
+}
+
+// Return the positions of the glyphs, one scalar per glyph for horizontal
+// positioning, two for full positioning, nil for default positioning.
+func (it *TextBlobRunIterator) Pos() []Scalar {
+	return it.run().pos
+}
+
+func (it *TextBlobRunIterator) Offset() Point {
+	return it.run().offset
+}
+
+func (it *TextBlobRunIterator) Positioning() TextBlobPositioning {
+	return it.run().positioning
+}
+
+// Set the text attributes of paint to those of the run, and its text
+// encoding to glyph IDs.
+func (it *TextBlobRunIterator) ApplyFontToPaint(paint *Paint) {
+	it.run().font.applyToPaint(paint)
+}
+
+/** TextBlobRunBuffer
+holds the glyphs and positions of a run being built. Fill them in before
+allocating the next run or calling Make. */
+type TextBlobRunBuffer struct {
+	Glyphs []GlyphID
+	Pos    []Scalar
+}
+
+/** TextBlobBuilder
+is a helper class for constructing TextBlobs. */
+type TextBlobBuilder struct {
+	runs      []*tTextBlobRun
+	buffers   []*TextBlobRunBuffer
+	bounds    Rect
+	hasBounds bool
+}
+
+func NewTextBlobBuilder() *TextBlobBuilder {
+	return &TextBlobBuilder{}
+}
+
+/** AllocRun
+Allocate a new run of count glyphs, laid out by their advances starting at
+(x, y). If bounds is not nil it is used as the bounds of the run, otherwise
+the bounds are computed from the glyphs. */
+func (builder *TextBlobBuilder) AllocRun(font *Paint, count int, x, y Scalar, bounds *Rect) *TextBlobRunBuffer {
+	return builder.allocRun(font, count, KTextBlobPositioningDefault, Point{x, y}, bounds)
+}
+
+/** AllocRunPosH
+Allocate a new run of count glyphs, each with its own x position and the
+shared y. */
+func (builder *TextBlobBuilder) AllocRunPosH(font *Paint, count int, y Scalar, bounds *Rect) *TextBlobRunBuffer {
+	return builder.allocRun(font, count, KTextBlobPositioningHorizontal, Point{0, y}, bounds)
+}
+
+/** AllocRunPos
+Allocate a new run of count glyphs, each with its own (x, y) position,
+stored as pairs in Pos. */
+func (builder *TextBlobBuilder) AllocRunPos(font *Paint, count int, bounds *Rect) *TextBlobRunBuffer {
+	return builder.allocRun(font, count, KTextBlobPositioningFull, Point{0, 0}, bounds)
+}
+
+func (builder *TextBlobBuilder) allocRun(font *Paint, count int, positioning TextBlobPositioning,
+	offset Point, bounds *Rect) *TextBlobRunBuffer {
+	var run = &tTextBlobRun{
+		font:        newTextBlobRunFont(font),
+		offset:      offset,
+		positioning: positioning,
+		givenBounds: bounds != nil,
+	}
+	var buffer = &TextBlobRunBuffer{
+		Glyphs: make([]GlyphID, count),
+	}
+	if n := textBlobScalarsPerGlyph(positioning); n > 0 {
+		buffer.Pos = make([]Scalar, n*count)
+	}
+	if bounds != nil {
+		builder.joinBounds(*bounds)
+	}
+	builder.runs = append(builder.runs, run)
+	builder.buffers = append(builder.buffers, buffer)
+	return buffer
+}
+
+func (builder *TextBlobBuilder) joinBounds(r Rect) {
+	if r.IsEmpty() {
+		return
+	}
+	if !builder.hasBounds {
+		builder.bounds, builder.hasBounds = r, true
+	} else {
+		builder.bounds.Join(r)
+	}
+}
+
+/** Make
+Returns a blob of the runs allocated so far, or nil if there are none, and
+resets the builder. */
+func (builder *TextBlobBuilder) Make() *TextBlob {
+	var blob = &TextBlob{
+		uniqueID: atomic.AddUint32(&gTextBlobUniqueID, 1),
+	}
+	for i, run := range builder.runs {
+		var buffer = builder.buffers[i]
+		run.glyphs = buffer.Glyphs
+		run.pos = buffer.Pos
+		if len(run.glyphs) == 0 {
+			continue
+		}
+		if !run.givenBounds {
+			builder.joinBounds(run.tightBounds())
+		}
+		blob.runs = append(blob.runs, run)
+	}
+	blob.bounds = builder.bounds
+	*builder = TextBlobBuilder{}
+	if len(blob.runs) == 0 {
+		return nil
+	}
+	return blob
+}
+
+// Return the union of the bounds of the run's glyphs.
+func (run *tTextBlobRun) tightBounds() Rect {
+	var paint = NewPaint()
+	run.font.applyToPaint(paint)
+	var cache = paint.detachCache(nil, nil)
+
+	var alignFactor = textAlignFactor(run.font.align)
+
+	var union Rect
+	var hasBounds = false
+	var pen Point
+	if run.positioning == KTextBlobPositioningDefault && alignFactor != 0 {
+		var stop = measureTextAdvance(cache, run.glyphs)
+		pen.X, pen.Y = -stop.X*alignFactor, -stop.Y*alignFactor
+	}
+	for i, id := range run.glyphs {
+		var glyph = cache.GlyphIDMetrics(id)
+		var origin Point
+		switch run.positioning {
+		case KTextBlobPositioningDefault:
+			origin = pen
+			pen.X += glyph.AdvanceX
+			pen.Y += glyph.AdvanceY
+		case KTextBlobPositioningHorizontal:
+			origin.X = run.pos[i]
+		case KTextBlobPositioningFull:
+			origin.X, origin.Y = run.pos[2*i], run.pos[2*i+1]
+		}
+		if run.positioning != KTextBlobPositioningDefault {
+			origin.X -= glyph.AdvanceX * alignFactor
+			origin.Y -= glyph.AdvanceY * alignFactor
+		}
+		if glyph.IsEmpty() {
+			continue
+		}
+		var r = glyph.Bounds()
+		r.Offset(origin.X, origin.Y)
+		if !hasBounds {
+			union, hasBounds = r, true
+		} else {
+			union.Join(r)
+		}
+	}
+	union.Offset(run.offset.X, run.offset.Y)
+	return union
+}
 
+// Encode glyphs as text in the KPaintTextEncodingGlyphID encoding.
+func glyphsToText(glyphs []GlyphID) string {
+	var text = make([]byte, 2*len(glyphs))
+	for i, id := range glyphs {
+		text[2*i], text[2*i+1] = byte(id), byte(id>>8)
+	}
+	return string(text)
 }
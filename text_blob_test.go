@@ -0,0 +1,127 @@
+package ggk
+
+import "testing"
+
+func TestTextIntercepts(t *testing.T) {
+	var paint = NewPaint()
+	paint.SetTypeface(newTestTypeface(t))
+	paint.SetTextSize(10)
+
+	var builder = NewTextBlobBuilder()
+	var run = builder.AllocRunPosH(paint, 2, 0, nil)
+	copy(run.Glyphs, []GlyphID{1, 2})
+	copy(run.Pos, []Scalar{0, 20})
+	var blob = builder.Make()
+
+	var tests = []struct {
+		name      string
+		intercept func(bounds [2]Scalar, intervals []Scalar) int
+		bounds    [2]Scalar
+		want      []Scalar
+	}{
+		{"text above baseline", func(bounds [2]Scalar, intervals []Scalar) int {
+			return paint.TextIntercepts("lo", 2, 0, 0, bounds, intervals)
+		}, [2]Scalar{-1, -0.5}, []Scalar{1, 2.6, 4.1, 9.1}},
+		{"text descender", func(bounds [2]Scalar, intervals []Scalar) int {
+			return paint.TextIntercepts("lo", 2, 0, 0, bounds, intervals)
+		}, [2]Scalar{0.05, 0.5}, []Scalar{4.1, 9.1}},
+		{"text translated", func(bounds [2]Scalar, intervals []Scalar) int {
+			return paint.TextIntercepts("lo", 2, 10, 20, bounds, intervals)
+		}, [2]Scalar{19, 19.5}, []Scalar{11, 12.6, 14.1, 19.1}},
+		{"text missed", func(bounds [2]Scalar, intervals []Scalar) int {
+			return paint.TextIntercepts("lo", 2, 0, 0, bounds, intervals)
+		}, [2]Scalar{1, 2}, nil},
+		{"pos text", func(bounds [2]Scalar, intervals []Scalar) int {
+			return paint.PosTextIntercepts("lo", 2, []Point{{0, 0}, {20, 5}}, bounds, intervals)
+		}, [2]Scalar{4, 4.5}, []Scalar{20.5, 25.5}},
+		{"blob", func(bounds [2]Scalar, intervals []Scalar) int {
+			return paint.TextBlobIntercepts(blob, bounds, intervals)
+		}, [2]Scalar{-1, -0.5}, []Scalar{1, 2.6, 20.5, 25.5}},
+	}
+	for _, test := range tests {
+		var count = test.intercept(test.bounds, nil)
+		if count != len(test.want) {
+			t.Errorf("%v count want %v got %v", test.name, len(test.want), count)
+			continue
+		}
+		var intervals = make([]Scalar, count)
+		test.intercept(test.bounds, intervals)
+		for i := range intervals {
+			if ScalarAbs(intervals[i]-test.want[i]) > 1e-3 {
+				t.Errorf("%v intervals want %v got %v", test.name, test.want, intervals)
+				break
+			}
+		}
+
+		// a short array gets the intervals that fit, and the full count.
+		if count > 2 {
+			var short = make([]Scalar, 3)
+			if got := test.intercept(test.bounds, short); got != count || short[0] != intervals[0] ||
+				short[1] != intervals[1] || short[2] != 0 {
+				t.Errorf("%v short intervals want count %v and %v got %v and %v", test.name, count,
+					intervals[:2], got, short)
+			}
+		}
+	}
+}
+
+func TestTextBlobBuilder(t *testing.T) {
+	var paint = NewPaint()
+	paint.SetTypeface(newTestTypeface(t))
+	paint.SetTextSize(10)
+	paint.SetHinting(KPaintHintingNo)
+
+	var builder = NewTextBlobBuilder()
+	if builder.Make() != nil {
+		t.Errorf("Make() want nil for an empty builder")
+	}
+	var run = builder.AllocRun(paint, 1, 5, 10, nil)
+	run.Glyphs[0] = 1
+	var pos = builder.AllocRunPos(paint, 1, nil)
+	pos.Glyphs[0] = 2
+	copy(pos.Pos, []Scalar{20, 30})
+	var blob = builder.Make()
+
+	// glyph bounds are rounded out to whole pixels.
+	var want = MakeRectLTRB(6, 3, 26, 31)
+	var bounds = blob.Bounds()
+	if ScalarAbs(bounds.L()-want.L()) > 1 || ScalarAbs(bounds.T()-want.T()) > 1 ||
+		ScalarAbs(bounds.R()-want.R()) > 1 || ScalarAbs(bounds.B()-want.B()) > 1 ||
+		bounds.L() > want.L() || bounds.T() > want.T() || bounds.R() < want.R() || bounds.B() < want.B() {
+		t.Errorf("Bounds() want about %v got %v", want, bounds)
+	}
+	var positionings []TextBlobPositioning
+	for it := NewTextBlobRunIterator(blob); !it.Done(); it.Next() {
+		positionings = append(positionings, it.Positioning())
+	}
+	if len(positionings) != 2 || positionings[0] != KTextBlobPositioningDefault ||
+		positionings[1] != KTextBlobPositioningFull {
+		t.Errorf("runs want default and full positioning got %v", positionings)
+	}
+}
+
+func TestDrawTextBlobWithLooper(t *testing.T) {
+	var paint = newTestPaint(KColorRed)
+	paint.SetTypeface(newTestTypeface(t))
+	paint.SetTextSize(20)
+	paint.SetHinting(KPaintHintingNo)
+	var glyphs = make([]GlyphID, 1)
+	paint.TextToGlyphs("l", 1, glyphs)
+	var builder = NewTextBlobBuilder()
+	var run = builder.AllocRun(paint, 1, 0, 0, nil)
+	run.Glyphs[0] = glyphs[0]
+	var blob = builder.Make()
+
+	// the glyph is left of the canvas, its shadow 40 to the right on it.
+	var looper = NewLayerDrawLooperBuilder()
+	looper.AddLayerXY(40, 0)
+	looper.AddLayer(NewLayerDrawLooperLayerInfo())
+	paint.SetLooper(looper.Detach())
+	var canvas, pixels = newTestPictureCanvas(20, 20)
+	canvas.DrawTextBlob(blob, -30, 18, paint)
+
+	// the stem of 'l' spans 2 to 5.2 along the baseline and 14 above it.
+	if got, want := pixels.Pixel32(13, 10), PackARGB32(0xff, 0xff, 0, 0); got != want {
+		t.Errorf("shadow of a glyph off the canvas want %#x got %#x", want, got)
+	}
+}
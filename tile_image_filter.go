@@ -0,0 +1,61 @@
+package ggk
+
+/** tTileImageFilter
+is the ImageFilterImpl that repeats the src rect of its input across its
+dst rect, both in local coordinates. */
+type tTileImageFilter struct {
+	src Rect
+	dst Rect
+}
+
+// NewTileImageFilter returns a filter that repeats the part of input, or
+// of the source if input is nil, inside src across dst.
+func NewTileImageFilter(src, dst Rect, input *ImageFilter) *ImageFilter {
+	return NewImageFilter(&tTileImageFilter{src: src, dst: dst}, []*ImageFilter{input}, nil)
+}
+
+func (impl *tTileImageFilter) OnFilterImage(filter *ImageFilter, src *Bitmap, ctx *ImageFilterContext) (*Bitmap, Point, bool) {
+	var input, inputOffset, ok = filter.filterInput(0, src, ctx)
+	if !ok {
+		return nil, PointZero, false
+	}
+	var tileBounds = ctx.CTM.MapRect(impl.src).RoundOut()
+	var dstBounds = ctx.CTM.MapRect(impl.dst).RoundOut()
+	if tileBounds.Width <= 0 || tileBounds.Height <= 0 || !dstBounds.Intersect(ctx.ClipBounds) {
+		return nil, PointZero, false
+	}
+
+	var tile = filterPixmap(copyFilterBitmap(input, inputOffset, tileBounds))
+	var dst = newFilterBitmap(dstBounds)
+	var pixels = filterPixmap(dst)
+	var tileWidth, tileHeight = int(tileBounds.Width), int(tileBounds.Height)
+	// the offset of the top left of dst in the tile.
+	var dx, dy = int(dstBounds.Left - tileBounds.Left), int(dstBounds.Top - tileBounds.Top)
+	for y := 0; y < int(dstBounds.Height); y++ {
+		var ty = ((y+dy)%tileHeight + tileHeight) % tileHeight
+		for x := 0; x < int(dstBounds.Width); x++ {
+			var tx = ((x+dx)%tileWidth + tileWidth) % tileWidth
+			pixels.SetPixel32(x, y, tile.Pixel32(tx, ty))
+		}
+	}
+	return dst, Point{dstBounds.Left, dstBounds.Top}, true
+}
+
+func (impl *tTileImageFilter) OnFilterBounds(filter *ImageFilter, src Rect, ctm *Matrix, direction ImageFilterMapDirection) Rect {
+	if direction == KImageFilterMapDirectionReverse {
+		return filter.inputsFilterBounds(ctm.MapRect(impl.src).RoundOut(), ctm, direction)
+	}
+	return ctm.MapRect(impl.dst).RoundOut()
+}
+
+func (impl *tTileImageFilter) OnComputeFastBounds(filter *ImageFilter, src Rect) Rect {
+	return impl.dst
+}
+
+func (impl *tTileImageFilter) OnAffectsTransparentBlack() bool {
+	return false
+}
+
+func (impl *tTileImageFilter) OnAsAColorFilter(filter *ImageFilter) (*ColorFilter, bool) {
+	return nil, false
+}
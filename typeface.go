@@ -1,5 +1,208 @@
 package ggk
 
+import "sync/atomic"
+
+/** TypefaceImpl
+is implemented by each font backend. The typeface forwards every request
+that depends on the font data to its impl. */
+type TypefaceImpl interface {
+	OnCreateScalerContext(rec *ScalerContextRec) ScalerContext
+	OnGetUPEM() int
+	OnGetTableData(tag FontTableTag) []byte
+	OnGetFamilyName() string
+	OnGetFontStyle() FontStyle
+	OnCharsToGlyphs(chars []Unichar, glyphs []GlyphID)
+	OnGetVariationDesignParameters() []FontVariationAxis
+	OnGetVariationDesignPosition() []FontVariationCoordinate
+	OnMakeClone(position []FontVariationCoordinate) TypefaceImpl
+}
+
+/** FontTableTag
+identifies a table of an sfnt font, e.g. 'GSUB'. */
+type FontTableTag uint32
+
+func SetFourByteTag(a, b, c, d byte) FontTableTag {
+	return FontTableTag(a)<<24 | FontTableTag(b)<<16 | FontTableTag(c)<<8 | FontTableTag(d)
+}
+
+func (tag FontTableTag) String() string {
+	return string([]byte{byte(tag >> 24), byte(tag >> 16), byte(tag >> 8), byte(tag)})
+}
+
+/** FontVariationAxis
+is a design axis of a variable font, e.g. the 'wght' axis running from 100
+to 900. Hidden axes are meant to be set by software, not offered to users. */
+type FontVariationAxis struct {
+	Tag               FontTableTag
+	Min, Default, Max Scalar
+	Hidden            bool
+}
+
+/** FontVariationCoordinate
+is the position of a variable font on one of its axes, in the units of the
+axis, e.g. 650 on 'wght' or 85 on 'wdth'. */
+type FontVariationCoordinate struct {
+	Axis  FontTableTag
+	Value Scalar
+}
+
+/** Typeface
+The Typeface class specifies the typeface and intrinsic style of a font.
+This is used in the paint, along with optionally algorithmic settings like
+textSize, textSkewX, textScaleX, kFakeBoldText_Mask, to specify
+how text appears when drawn (and measured). */
 type Typeface struct {
+	Impl TypefaceImpl
+
+	uniqueID uint32
+}
+
+var gTypefaceUniqueID uint32
+var gDefaultTypeface = NewTypeface(nil)
+
+func NewTypeface(impl TypefaceImpl) *Typeface {
+	var typeface = &Typeface{
+		Impl:     impl,
+		uniqueID: atomic.AddUint32(&gTypefaceUniqueID, 1),
+	}
+	return typeface
+}
+
+// TypefaceDefault returns the default typeface, which is used when a paint
+// has no typeface set.
+func TypefaceDefault() *Typeface {
+	return gDefaultTypeface
+}
+
+// UniqueID returns a 32bit value for this typeface, unique for the
+// underlying font data.
+func (typeface *Typeface) UniqueID() uint32 {
+	return typeface.uniqueID
+}
+
+// CreateScalerContext returns a scaler context for the rec. Typefaces
+// without a backend return a context that has no glyphs.
+func (typeface *Typeface) CreateScalerContext(rec *ScalerContextRec) ScalerContext {
+	if typeface.Impl != nil {
+		if ctx := typeface.Impl.OnCreateScalerContext(rec); ctx != nil {
+			return ctx
+		}
+	}
+	return &tEmptyScalerContext{}
+}
+
+// UnitsPerEm returns the units per em of the font, or 0 if it is unknown.
+func (typeface *Typeface) UnitsPerEm() int {
+	if typeface.Impl == nil {
+		return 0
+	}
+	return typeface.Impl.OnGetUPEM()
+}
+
+// TableData returns the contents of the font table with tag, or nil if the
+// font has no such table. The data must not be modified.
+func (typeface *Typeface) TableData(tag FontTableTag) []byte {
+	if typeface.Impl == nil {
+		return nil
+	}
+	return typeface.Impl.OnGetTableData(tag)
+}
+
+// FamilyName returns the family name of the font, e.g. "DejaVu Sans", or
+// "" if it is unknown.
+func (typeface *Typeface) FamilyName() string {
+	if typeface.Impl == nil {
+		return ""
+	}
+	return typeface.Impl.OnGetFamilyName()
+}
+
+// FontStyle returns the weight, width and slant of the font.
+func (typeface *Typeface) FontStyle() FontStyle {
+	if typeface.Impl == nil {
+		return FontStyleNormal()
+	}
+	return typeface.Impl.OnGetFontStyle()
+}
+
+func (typeface *Typeface) IsBold() bool {
+	return typeface.FontStyle().Weight() >= KFontStyleWeightSemiBold
+}
+
+func (typeface *Typeface) IsItalic() bool {
+	return typeface.FontStyle().Slant() != KFontStyleSlantUpright
+}
+
+/** CharsToGlyphs
+Map chars to glyph IDs in glyphs, which must be at least as long, using 0
+for the characters the font has no glyph for. Returns the number of
+leading chars that have a glyph. */
+func (typeface *Typeface) CharsToGlyphs(chars []Unichar, glyphs []GlyphID) int {
+	glyphs = glyphs[:len(chars)]
+	if typeface.Impl == nil {
+		for i := range glyphs {
+			glyphs[i] = 0
+		}
+	} else {
+		typeface.Impl.OnCharsToGlyphs(chars, glyphs)
+	}
+	for i, id := range glyphs {
+		if id == 0 {
+			return i
+		}
+	}
+	return len(glyphs)
+}
+
+// HasChar returns true if the font has a glyph for uni.
+func (typeface *Typeface) HasChar(uni Unichar) bool {
+	var glyph [1]GlyphID
+	return typeface.CharsToGlyphs([]Unichar{uni}, glyph[:]) == 1
+}
+
+/** NewTypefaceFromName
+Return the typeface of the default font manager that best matches
+familyName and style, falling back to the default family when there is no
+such family, and to the default typeface when the manager has no fonts. */
+func NewTypefaceFromName(familyName string, style FontStyle) *Typeface {
+	if typeface := FontMgrDefault().LegacyMakeTypeface(familyName, style); typeface != nil {
+		return typeface
+	}
+	return TypefaceDefault()
+}
+
+/** VariationDesignParameters
+Return the variation axes of the font, or nil if the font is not a
+variable font. */
+func (typeface *Typeface) VariationDesignParameters() []FontVariationAxis {
+	if typeface.Impl == nil {
+		return nil
+	}
+	return typeface.Impl.OnGetVariationDesignParameters()
+}
+
+/** VariationDesignPosition
+Return the position of the typeface on each of the font's variation axes,
+or nil if the font is not a variable font. */
+func (typeface *Typeface) VariationDesignPosition() []FontVariationCoordinate {
+	if typeface.Impl == nil {
+		return nil
+	}
+	return typeface.Impl.OnGetVariationDesignPosition()
+}
 
+/** MakeClone
+Return a typeface for the same font at the variation position, e.g. 650
+on 'wght'. Axes that position leaves out keep the value they have in this
+typeface, values are pinned to the range of their axis, and coordinates
+for axes the font doesn't have are ignored. Returns this typeface if the
+font is not a variable font. */
+func (typeface *Typeface) MakeClone(position ...FontVariationCoordinate) *Typeface {
+	if typeface.Impl == nil || len(position) == 0 {
+		return typeface
+	}
+	if impl := typeface.Impl.OnMakeClone(position); impl != nil {
+		return NewTypeface(impl)
+	}
+	return typeface
 }
@@ -0,0 +1,1003 @@
+package ggk
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"sort"
+	"unicode/utf16"
+)
+
+var ErrTrueTypeInvalid = errors.New("ERROR: invalid or unsupported TrueType font data")
+
+// ErrTrueTypeCFF is returned for OpenType fonts with CFF outlines, which
+// can't be drawn: only TrueType outlines and color bitmaps are supported.
+var ErrTrueTypeCFF = errors.New("ERROR: OpenType fonts with CFF outlines are not supported")
+
+// The versions of the font data: the sfnt versions of TrueType and of CFF
+// flavoured OpenType fonts, and the tag of font collections.
+const (
+	kTrueTypeVersion1      = 0x00010000
+	kTrueTypeVersionTrue   = 0x74727565 // 'true'
+	kTrueTypeVersionCFF    = 0x4F54544F // 'OTTO'
+	kTrueTypeCollectionTag = 0x74746366 // 'ttcf'
+)
+
+// Composite glyphs nested deeper than this are treated as empty.
+const kTrueTypeMaxComponentDepth = 8
+
+func ttU16(b []byte, offset int) int {
+	return int(binary.BigEndian.Uint16(b[offset:]))
+}
+
+func ttI16(b []byte, offset int) int {
+	return int(int16(binary.BigEndian.Uint16(b[offset:])))
+}
+
+func ttU32(b []byte, offset int) int {
+	return int(binary.BigEndian.Uint32(b[offset:]))
+}
+
+// Read a 2.14 fixed point number.
+func ttF2Dot14(b []byte, offset int) Scalar {
+	return Scalar(ttI16(b, offset)) / (1 << 14)
+}
+
+// tGlyphPoint is a point of a glyph outline, in font units until the scaler
+// context maps it to device space.
+type tGlyphPoint struct {
+	X, Y    Scalar
+	OnCurve bool
+}
+
+// tGlyphOutline holds the quadratic contours of a glyph. ends holds the
+// index of the last point of each contour.
+type tGlyphOutline struct {
+	points []tGlyphPoint
+	ends   []int
+}
+
+/** tTrueTypeFont
+is a parsed sfnt font with TrueType (glyf) outlines. Tables are kept as
+slices of the original data and read on demand. */
+type tTrueTypeFont struct {
+	data   []byte
+	tables map[string][]byte
+
+	unitsPerEm       int
+	indexToLocFormat int
+	numGlyphs        int
+	numHMetrics      int
+	numVMetrics      int // 0 if the font has no vertical metrics.
+
+	ascender, descender, lineGap int
+	xMin, yMin, xMax, yMax       int
+	avgCharWidth                 int
+	xHeight, capHeight           int
+
+	underlinePosition, underlineThickness int
+	hasPost                               bool
+
+	cmap       []byte // the best unicode cmap subtable
+	cmapFormat int
+
+	familyName string
+	style      FontStyle
+	axes       []FontVariationAxis // nil if the font is not a variable font.
+}
+
+/** trueTypeFontOffsets
+Return the offsets in data of the fonts it holds: 0 for a single font, or
+those listed by the header of a font collection. */
+func trueTypeFontOffsets(data []byte) ([]int, error) {
+	if len(data) < 12 {
+		return nil, ErrTrueTypeInvalid
+	}
+	if ttU32(data, 0) != kTrueTypeCollectionTag {
+		return []int{0}, nil
+	}
+	var numFonts = ttU32(data, 8)
+	if numFonts <= 0 || len(data) < 12+4*numFonts {
+		return nil, ErrTrueTypeInvalid
+	}
+	var offsets = make([]int, numFonts)
+	for i := range offsets {
+		offsets[i] = ttU32(data, 12+4*i)
+	}
+	return offsets, nil
+}
+
+/** parseTrueTypeFont
+Parse the font whose offset table is at offset in data. The offsets of its
+tables are from the start of data, so fonts of a collection share it. */
+func parseTrueTypeFont(data []byte, offset int) (*tTrueTypeFont, error) {
+	if offset < 0 || len(data) < offset+12 {
+		return nil, ErrTrueTypeInvalid
+	}
+	var header = data[offset:]
+	switch ttU32(header, 0) {
+	case kTrueTypeVersion1, kTrueTypeVersionTrue:
+	case kTrueTypeVersionCFF:
+		return nil, ErrTrueTypeCFF
+	default:
+		return nil, ErrTrueTypeInvalid
+	}
+
+	var font = &tTrueTypeFont{
+		data:   data,
+		tables: make(map[string][]byte),
+	}
+	var numTables = ttU16(header, 4)
+	if len(header) < 12+16*numTables {
+		return nil, ErrTrueTypeInvalid
+	}
+	for i := 0; i < numTables; i++ {
+		var record = header[12+16*i:]
+		var offset, length = ttU32(record, 8), ttU32(record, 12)
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, ErrTrueTypeInvalid
+		}
+		font.tables[string(record[:4])] = data[offset : offset+length]
+	}
+
+	for _, tag := range []string{"head", "maxp", "cmap", "hhea", "hmtx"} {
+		if _, ok := font.tables[tag]; !ok {
+			return nil, ErrTrueTypeInvalid
+		}
+	}
+	// fonts of bitmap glyphs, like emoji fonts, may have no outlines.
+	if !font.hasColorBitmaps() {
+		for _, tag := range []string{"loca", "glyf"} {
+			if _, ok := font.tables[tag]; !ok {
+				return nil, ErrTrueTypeInvalid
+			}
+		}
+	}
+
+	var head = font.tables["head"]
+	var hhea = font.tables["hhea"]
+	if len(head) < 54 || len(font.tables["maxp"]) < 6 || len(hhea) < 36 {
+		return nil, ErrTrueTypeInvalid
+	}
+	font.unitsPerEm = ttU16(head, 18)
+	font.xMin, font.yMin = ttI16(head, 36), ttI16(head, 38)
+	font.xMax, font.yMax = ttI16(head, 40), ttI16(head, 42)
+	font.indexToLocFormat = ttI16(head, 50)
+	font.numGlyphs = ttU16(font.tables["maxp"], 4)
+	font.ascender, font.descender = ttI16(hhea, 4), ttI16(hhea, 6)
+	font.lineGap = ttI16(hhea, 8)
+	font.numHMetrics = ttU16(hhea, 34)
+	if font.unitsPerEm == 0 || font.numHMetrics == 0 || len(font.tables["hmtx"]) < 4*font.numHMetrics {
+		return nil, ErrTrueTypeInvalid
+	}
+
+	if vhea, ok := font.tables["vhea"]; ok && len(vhea) >= 36 {
+		if n := ttU16(vhea, 34); n > 0 && len(font.tables["vmtx"]) >= 4*n {
+			font.numVMetrics = n
+		}
+	}
+
+	if os2, ok := font.tables["OS/2"]; ok && len(os2) >= 4 {
+		font.avgCharWidth = ttI16(os2, 2)
+		if ttU16(os2, 0) >= 2 && len(os2) >= 90 {
+			font.xHeight, font.capHeight = ttI16(os2, 86), ttI16(os2, 88)
+		}
+	}
+	if post, ok := font.tables["post"]; ok && len(post) >= 12 {
+		font.underlinePosition, font.underlineThickness = ttI16(post, 8), ttI16(post, 10)
+		font.hasPost = true
+	}
+
+	if !font.parseCmap() {
+		return nil, ErrTrueTypeInvalid
+	}
+	font.familyName = font.parseFamilyName()
+	font.style = font.parseStyle()
+	font.axes = font.parseVariationAxes()
+	return font, nil
+}
+
+// Name IDs of the family name: the typographic family groups more styles
+// than the legacy one, which is limited to regular, bold and italic.
+const (
+	kTrueTypeNameFamily            = 1
+	kTrueTypeNameTypographicFamily = 16
+)
+
+// Return the family name from the name table, preferring the typographic
+// family, and English names in unicode over Macintosh ones.
+func (font *tTrueTypeFont) parseFamilyName() string {
+	var name = font.tables["name"]
+	if len(name) < 6 {
+		return ""
+	}
+	var count, storage = ttU16(name, 2), ttU16(name, 4)
+	if len(name) < 6+12*count {
+		return ""
+	}
+	for _, nameID := range []int{kTrueTypeNameTypographicFamily, kTrueTypeNameFamily} {
+		var best, bestScore = "", 0
+		for i := 0; i < count; i++ {
+			var record = name[6+12*i:]
+			if ttU16(record, 6) != nameID {
+				continue
+			}
+			var platform, encoding, language = ttU16(record, 0), ttU16(record, 2), ttU16(record, 4)
+			var offset, length = storage + ttU16(record, 10), ttU16(record, 8)
+			if offset+length > len(name) {
+				continue
+			}
+			var score = 0
+			switch {
+			case platform == 3 && (encoding == 1 || encoding == 10):
+				score = 2
+				if language == 0x0409 {
+					score = 4
+				}
+			case platform == 0:
+				score = 3
+			case platform == 1 && encoding == 0 && language == 0:
+				score = 1
+			}
+			if score > bestScore {
+				var data = name[offset : offset+length]
+				if platform == 1 {
+					best = ttDecodeMacRoman(data)
+				} else {
+					best = ttDecodeUTF16(data)
+				}
+				bestScore = score
+			}
+		}
+		if best != "" {
+			return best
+		}
+	}
+	return ""
+}
+
+func ttDecodeUTF16(data []byte) string {
+	var units = make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = uint16(ttU16(data, 2*i))
+	}
+	return string(utf16.Decode(units))
+}
+
+// Decode Mac Roman text, treating the upper half as Latin-1, which is close
+// enough for family names.
+func ttDecodeMacRoman(data []byte) string {
+	var runes = make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// Return the weight, width and slant from the OS/2 table, or from the
+// macStyle of the head table if the font has no OS/2 table.
+func (font *tTrueTypeFont) parseStyle() FontStyle {
+	var weight, width = KFontStyleWeightNormal, KFontStyleWidthNormal
+	var slant = KFontStyleSlantUpright
+	var macStyle = ttU16(font.tables["head"], 44)
+	if macStyle&1 != 0 {
+		weight = KFontStyleWeightBold
+	}
+	if macStyle&2 != 0 {
+		slant = KFontStyleSlantItalic
+	}
+	if os2 := font.tables["OS/2"]; len(os2) >= 8 {
+		if w := ttU16(os2, 4); w > 0 && w < 10 {
+			// some old fonts use 1 to 9.
+			weight = 100 * w
+		} else if w > 0 {
+			weight = w
+		}
+		if w := ttU16(os2, 6); w > 0 {
+			width = w
+		}
+		if len(os2) >= 64 {
+			var fsSelection = ttU16(os2, 62)
+			switch {
+			case fsSelection&(1<<9) != 0:
+				slant = KFontStyleSlantOblique
+			case fsSelection&1 != 0:
+				slant = KFontStyleSlantItalic
+			default:
+				slant = KFontStyleSlantUpright
+			}
+		}
+	}
+	return NewFontStyle(weight, width, slant)
+}
+
+// Pick the unicode cmap subtable, preferring full repertoire (format 12)
+// subtables over BMP only (format 4) ones.
+func (font *tTrueTypeFont) parseCmap() bool {
+	var cmap = font.tables["cmap"]
+	if len(cmap) < 4 {
+		return false
+	}
+	var numTables = ttU16(cmap, 2)
+	if len(cmap) < 4+8*numTables {
+		return false
+	}
+	for i := 0; i < numTables; i++ {
+		var platformID, encodingID = ttU16(cmap, 4+8*i), ttU16(cmap, 6+8*i)
+		var offset = ttU32(cmap, 8+8*i)
+		var unicode = platformID == 0 || (platformID == 3 && (encodingID == 1 || encodingID == 10))
+		if !unicode || offset < 0 || offset+4 > len(cmap) {
+			continue
+		}
+		var format = ttU16(cmap, offset)
+		if format == 12 && offset+16 <= len(cmap) {
+			font.cmap, font.cmapFormat = cmap[offset:], 12
+		} else if format == 4 && font.cmapFormat != 12 && offset+14 <= len(cmap) {
+			font.cmap, font.cmapFormat = cmap[offset:], 4
+		}
+	}
+	return font.cmap != nil
+}
+
+func (font *tTrueTypeFont) glyphIndex(uni Unichar) GlyphID {
+	var c = int(uni)
+	switch font.cmapFormat {
+	case 4:
+		if c > 0xffff {
+			return 0
+		}
+		var segCountX2 = ttU16(font.cmap, 6)
+		var segCount = segCountX2 / 2
+		if len(font.cmap) < 16+4*segCountX2 {
+			return 0
+		}
+		var ends = 14
+		var seg = sort.Search(segCount, func(i int) bool {
+			return ttU16(font.cmap, ends+2*i) >= c
+		})
+		if seg == segCount {
+			return 0
+		}
+		var starts = ends + segCountX2 + 2
+		var deltas = starts + segCountX2
+		var rangeOffsets = deltas + segCountX2
+		var start = ttU16(font.cmap, starts+2*seg)
+		if c < start {
+			return 0
+		}
+		var delta = ttU16(font.cmap, deltas+2*seg)
+		var rangeOffset = ttU16(font.cmap, rangeOffsets+2*seg)
+		if rangeOffset == 0 {
+			return GlyphID((c + delta) & 0xffff)
+		}
+		var offset = rangeOffsets + 2*seg + rangeOffset + 2*(c-start)
+		if offset+2 > len(font.cmap) {
+			return 0
+		}
+		var glyph = ttU16(font.cmap, offset)
+		if glyph == 0 {
+			return 0
+		}
+		return GlyphID((glyph + delta) & 0xffff)
+
+	case 12:
+		var numGroups = ttU32(font.cmap, 12)
+		if numGroups < 0 || len(font.cmap) < 16+12*numGroups {
+			return 0
+		}
+		var group = sort.Search(numGroups, func(i int) bool {
+			return ttU32(font.cmap, 16+12*i+4) >= c
+		})
+		if group == numGroups {
+			return 0
+		}
+		var start = ttU32(font.cmap, 16+12*group)
+		if c < start {
+			return 0
+		}
+		return GlyphID(ttU32(font.cmap, 16+12*group+8) + c - start)
+	}
+	return 0
+}
+
+// Return the advance width and left side bearing of the glyph in font units.
+func (font *tTrueTypeFont) hMetrics(id GlyphID) (advance, lsb int) {
+	var hmtx = font.tables["hmtx"]
+	var i = int(id)
+	if i < font.numHMetrics {
+		return ttU16(hmtx, 4*i), ttI16(hmtx, 4*i+2)
+	}
+	advance = ttU16(hmtx, 4*(font.numHMetrics-1))
+	if offset := 4*font.numHMetrics + 2*(i-font.numHMetrics); offset+2 <= len(hmtx) {
+		lsb = ttI16(hmtx, offset)
+	}
+	return advance, lsb
+}
+
+// Return the advance height of the glyph and its vertical origin, the point
+// the glyph hangs from when text is laid out top to bottom, in font units.
+// Fonts without vertical metrics get the line height as the advance and
+// the ascender as the origin, like FreeType does.
+func (font *tTrueTypeFont) vMetrics(id GlyphID) (advance int, origin Point) {
+	var hAdvance, _ = font.hMetrics(id)
+	origin.X = Scalar(hAdvance) / 2
+	if font.numVMetrics == 0 {
+		origin.Y = Scalar(font.ascender)
+		return font.ascender - font.descender, origin
+	}
+
+	var vmtx = font.tables["vmtx"]
+	var i = int(id)
+	var tsb int
+	if i < font.numVMetrics {
+		advance, tsb = ttU16(vmtx, 4*i), ttI16(vmtx, 4*i+2)
+	} else {
+		advance = ttU16(vmtx, 4*(font.numVMetrics-1))
+		if offset := 4*font.numVMetrics + 2*(i-font.numVMetrics); offset+2 <= len(vmtx) {
+			tsb = ttI16(vmtx, offset)
+		}
+	}
+	// the top side bearing is measured from the top of the glyph's box.
+	var yMax = 0
+	if data := font.glyphData(id); data != nil {
+		yMax = ttI16(data, 8)
+	}
+	origin.Y = Scalar(yMax + tsb)
+	return advance, origin
+}
+
+// Return the glyf table entry of the glyph, or nil if it has no outline.
+func (font *tTrueTypeFont) glyphData(id GlyphID) []byte {
+	if int(id) >= font.numGlyphs {
+		return nil
+	}
+	var loca, glyf = font.tables["loca"], font.tables["glyf"]
+	var start, end int
+	if font.indexToLocFormat == 0 {
+		if 2*int(id)+4 > len(loca) {
+			return nil
+		}
+		start, end = 2*ttU16(loca, 2*int(id)), 2*ttU16(loca, 2*int(id)+2)
+	} else {
+		if 4*int(id)+8 > len(loca) {
+			return nil
+		}
+		start, end = ttU32(loca, 4*int(id)), ttU32(loca, 4*int(id)+4)
+	}
+	if start >= end || end > len(glyf) || end-start < 10 {
+		return nil
+	}
+	return glyf[start:end]
+}
+
+// Append the outline of the glyph, in font units, to outline. coords are
+// the normalized variation coordinates, nil for the default outline.
+func (font *tTrueTypeFont) appendOutline(outline *tGlyphOutline, id GlyphID, coords []Scalar, depth int) {
+	var data = font.glyphData(id)
+	if data == nil || depth > kTrueTypeMaxComponentDepth {
+		return
+	}
+	var numContours = ttI16(data, 0)
+	if numContours >= 0 {
+		font.appendSimpleOutline(outline, id, data, numContours, coords)
+	} else {
+		font.appendCompositeOutline(outline, id, data, coords, depth)
+	}
+}
+
+func (font *tTrueTypeFont) appendSimpleOutline(outline *tGlyphOutline, id GlyphID, data []byte, numContours int, coords []Scalar) {
+	var offset = 10
+	if len(data) < offset+2*numContours+2 {
+		return
+	}
+	var base = len(outline.points)
+	var numPoints = 0
+	var ends = make([]int, numContours)
+	for i := range ends {
+		ends[i] = ttU16(data, offset+2*i)
+		if ends[i] < numPoints-1 {
+			return
+		}
+		numPoints = ends[i] + 1
+	}
+	offset += 2 * numContours
+	offset += 2 + ttU16(data, offset) // skip the instructions.
+
+	// flags are run length encoded.
+	var flags = make([]byte, 0, numPoints)
+	for len(flags) < numPoints {
+		if offset >= len(data) {
+			return
+		}
+		var flag = data[offset]
+		offset++
+		flags = append(flags, flag)
+		if flag&0x08 != 0 {
+			if offset >= len(data) {
+				return
+			}
+			for repeat := int(data[offset]); repeat > 0 && len(flags) < numPoints; repeat-- {
+				flags = append(flags, flag)
+			}
+			offset++
+		}
+	}
+
+	var points = make([]tGlyphPoint, numPoints)
+	var readCoords = func(shortBit, sameBit byte, set func(p *tGlyphPoint, v Scalar)) bool {
+		var v = 0
+		for i, flag := range flags {
+			if flag&shortBit != 0 {
+				if offset >= len(data) {
+					return false
+				}
+				if flag&sameBit != 0 {
+					v += int(data[offset])
+				} else {
+					v -= int(data[offset])
+				}
+				offset++
+			} else if flag&sameBit == 0 {
+				if offset+2 > len(data) {
+					return false
+				}
+				v += ttI16(data, offset)
+				offset += 2
+			}
+			set(&points[i], Scalar(v))
+		}
+		return true
+	}
+	if !readCoords(0x02, 0x10, func(p *tGlyphPoint, v Scalar) { p.X = v }) ||
+		!readCoords(0x04, 0x20, func(p *tGlyphPoint, v Scalar) { p.Y = v }) {
+		return
+	}
+	for i, flag := range flags {
+		points[i].OnCurve = flag&0x01 != 0
+	}
+	if len(coords) > 0 {
+		var varied = append(append([]tGlyphPoint(nil), points...), make([]tGlyphPoint, kGvarPhantomPoints)...)
+		if deltas := font.glyphVariationDeltas(id, coords, varied, ends); deltas != nil {
+			for i := range points {
+				points[i].X += deltas[i].X
+				points[i].Y += deltas[i].Y
+			}
+		}
+	}
+
+	outline.points = append(outline.points, points...)
+	for _, end := range ends {
+		outline.ends = append(outline.ends, base+end)
+	}
+}
+
+/** tGlyphComponent
+is a component of a composite glyph: the outline of glyph, transformed by
+the 2x2 matrix a b c d and then offset by dx dy. */
+type tGlyphComponent struct {
+	glyph      GlyphID
+	dx, dy     Scalar
+	a, b, c, d Scalar
+}
+
+// Return the components of the composite glyph data, stopping at the first
+// one that is cut short.
+func parseGlyphComponents(data []byte) []tGlyphComponent {
+	const (
+		kArgsAreWords   = 0x0001
+		kArgsAreXY      = 0x0002
+		kHaveScale      = 0x0008
+		kMoreComponents = 0x0020
+		kHaveXYScale    = 0x0040
+		kHaveTwoByTwo   = 0x0080
+	)
+
+	var components []tGlyphComponent
+	var offset = 10
+	for {
+		if offset+4 > len(data) {
+			return components
+		}
+		var flags = ttU16(data, offset)
+		var component = tGlyphComponent{glyph: GlyphID(ttU16(data, offset+2)), a: 1, d: 1}
+		offset += 4
+
+		if flags&kArgsAreWords != 0 {
+			if offset+4 > len(data) {
+				return components
+			}
+			component.dx, component.dy = Scalar(ttI16(data, offset)), Scalar(ttI16(data, offset+2))
+			offset += 4
+		} else {
+			if offset+2 > len(data) {
+				return components
+			}
+			component.dx, component.dy = Scalar(int8(data[offset])), Scalar(int8(data[offset+1]))
+			offset += 2
+		}
+		if flags&kArgsAreXY == 0 {
+			// matching points are not supported; place the component as is.
+			component.dx, component.dy = 0, 0
+		}
+
+		switch {
+		case flags&kHaveScale != 0 && offset+2 <= len(data):
+			component.a = ttF2Dot14(data, offset)
+			component.d = component.a
+			offset += 2
+		case flags&kHaveXYScale != 0 && offset+4 <= len(data):
+			component.a, component.d = ttF2Dot14(data, offset), ttF2Dot14(data, offset+2)
+			offset += 4
+		case flags&kHaveTwoByTwo != 0 && offset+8 <= len(data):
+			component.a, component.b = ttF2Dot14(data, offset), ttF2Dot14(data, offset+2)
+			component.c, component.d = ttF2Dot14(data, offset+4), ttF2Dot14(data, offset+6)
+			offset += 8
+		}
+		components = append(components, component)
+
+		if flags&kMoreComponents == 0 {
+			return components
+		}
+	}
+}
+
+func (font *tTrueTypeFont) appendCompositeOutline(outline *tGlyphOutline, id GlyphID, data []byte, coords []Scalar, depth int) {
+	var components = parseGlyphComponents(data)
+	if len(coords) > 0 {
+		// gvar varies the offsets of the components.
+		var offsets = make([]tGlyphPoint, len(components)+kGvarPhantomPoints)
+		if deltas := font.glyphVariationDeltas(id, coords, offsets, nil); deltas != nil {
+			for i := range components {
+				components[i].dx += deltas[i].X
+				components[i].dy += deltas[i].Y
+			}
+		}
+	}
+
+	for _, component := range components {
+		var first = len(outline.points)
+		font.appendOutline(outline, component.glyph, coords, depth+1)
+		var a, b, c, d = component.a, component.b, component.c, component.d
+		for i := first; i < len(outline.points); i++ {
+			var p = &outline.points[i]
+			p.X, p.Y = a*p.X+c*p.Y+component.dx, b*p.X+d*p.Y+component.dy
+		}
+	}
+}
+
+// Append the contours of the outline to path. TrueType contours are
+// quadratic, with an implied on-curve point between two off-curve points.
+func appendOutlineToPath(path *Path, outline *tGlyphOutline) {
+	var start = 0
+	for _, end := range outline.ends {
+		var contour = outline.points[start : end+1]
+		start = end + 1
+		if len(contour) == 0 {
+			continue
+		}
+
+		// start the walk at an on-curve point, making one up if needed.
+		var first = 0
+		for first < len(contour) && !contour[first].OnCurve {
+			first++
+		}
+		var startPt Point
+		if first == len(contour) {
+			first = 0
+			startPt = tGlyphMidPoint(contour[0], contour[len(contour)-1])
+		} else {
+			startPt = Point{contour[first].X, contour[first].Y}
+			first++
+		}
+		path.MoveTo(startPt.X, startPt.Y)
+
+		var ctrl Point
+		var hasCtrl = false
+		for i := 0; i < len(contour); i++ {
+			var p = contour[(first+i)%len(contour)]
+			var pt = Point{p.X, p.Y}
+			if p.OnCurve {
+				if hasCtrl {
+					path.QuadTo(ctrl.X, ctrl.Y, pt.X, pt.Y)
+				} else {
+					path.LineTo(pt.X, pt.Y)
+				}
+				hasCtrl = false
+			} else {
+				if hasCtrl {
+					var mid = tGlyphMidPoint(tGlyphPoint{X: ctrl.X, Y: ctrl.Y}, p)
+					path.QuadTo(ctrl.X, ctrl.Y, mid.X, mid.Y)
+				}
+				ctrl, hasCtrl = pt, true
+			}
+		}
+		if hasCtrl {
+			path.QuadTo(ctrl.X, ctrl.Y, startPt.X, startPt.Y)
+		}
+		path.Close()
+	}
+}
+
+func tGlyphMidPoint(a, b tGlyphPoint) Point {
+	return Point{ScalarHalf(a.X + b.X), ScalarHalf(a.Y + b.Y)}
+}
+
+/** tTrueTypeTypeface
+is the TypefaceImpl for fonts with TrueType outlines. */
+type tTrueTypeTypeface struct {
+	font     *tTrueTypeFont
+	position []FontVariationCoordinate // a coordinate per variation axis.
+	coords   []Scalar                  // normalized position, nil at the default.
+}
+
+// NewTypefaceFromData returns a typeface for the TrueType font in data.
+func NewTypefaceFromData(data []byte) (*Typeface, error) {
+	var offsets, err = trueTypeFontOffsets(data)
+	if err != nil {
+		return nil, err
+	}
+	return newTrueTypeTypefaceAt(data, offsets[0])
+}
+
+func newTrueTypeTypefaceAt(data []byte, offset int) (*Typeface, error) {
+	var font, err = parseTrueTypeFont(data, offset)
+	if err != nil {
+		return nil, err
+	}
+	return NewTypeface(&tTrueTypeTypeface{
+		font:     font,
+		position: font.variationPosition(nil, nil),
+	}), nil
+}
+
+/** NewTypefacesFromData
+Return a typeface for each font of data, which holds a TrueType font or a
+collection of them (.ttc). The fonts of a collection that can't be loaded,
+like those with CFF outlines, are left out; the error is returned only if
+none can be. */
+func NewTypefacesFromData(data []byte) ([]*Typeface, error) {
+	var offsets, err = trueTypeFontOffsets(data)
+	if err != nil {
+		return nil, err
+	}
+	var typefaces []*Typeface
+	for _, offset := range offsets {
+		var typeface *Typeface
+		if typeface, err = newTrueTypeTypefaceAt(data, offset); err == nil {
+			typefaces = append(typefaces, typeface)
+		}
+	}
+	if len(typefaces) == 0 {
+		return nil, err
+	}
+	return typefaces, nil
+}
+
+// NewTypefaceFromFile returns a typeface for the TrueType font file at path,
+// or for the first font of a font collection file.
+func NewTypefaceFromFile(path string) (*Typeface, error) {
+	var data, err = ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewTypefaceFromData(data)
+}
+
+func (impl *tTrueTypeTypeface) OnCreateScalerContext(rec *ScalerContextRec) ScalerContext {
+	return newTrueTypeScalerContext(impl.font, impl.coords, rec)
+}
+
+func (impl *tTrueTypeTypeface) OnGetUPEM() int {
+	return impl.font.unitsPerEm
+}
+
+func (impl *tTrueTypeTypeface) OnGetTableData(tag FontTableTag) []byte {
+	return impl.font.tables[tag.String()]
+}
+
+func (impl *tTrueTypeTypeface) OnGetFamilyName() string {
+	return impl.font.familyName
+}
+
+func (impl *tTrueTypeTypeface) OnGetFontStyle() FontStyle {
+	if impl.coords == nil {
+		return impl.font.style
+	}
+	return variationFontStyle(impl.font.style, impl.position)
+}
+
+func (impl *tTrueTypeTypeface) OnCharsToGlyphs(chars []Unichar, glyphs []GlyphID) {
+	for i, uni := range chars {
+		glyphs[i] = impl.font.glyphIndex(uni)
+	}
+}
+
+func (impl *tTrueTypeTypeface) OnGetVariationDesignParameters() []FontVariationAxis {
+	if impl.font.axes == nil {
+		return nil
+	}
+	return append([]FontVariationAxis(nil), impl.font.axes...)
+}
+
+func (impl *tTrueTypeTypeface) OnGetVariationDesignPosition() []FontVariationCoordinate {
+	if impl.font.axes == nil {
+		return nil
+	}
+	return append([]FontVariationCoordinate(nil), impl.position...)
+}
+
+func (impl *tTrueTypeTypeface) OnMakeClone(position []FontVariationCoordinate) TypefaceImpl {
+	if impl.font.axes == nil {
+		return nil
+	}
+	var clone = &tTrueTypeTypeface{
+		font:     impl.font,
+		position: impl.font.variationPosition(impl.position, position),
+	}
+	clone.coords = impl.font.normalizeVariation(clone.position)
+	return clone
+}
+
+/** tTrueTypeScalerContext
+scales TrueType outlines to the size and transform of a rec. The font's
+instructions are not run: when the rec asks for hinting the outlines are
+grid-fitted by the autohinter instead. */
+type tTrueTypeScalerContext struct {
+	font   *tTrueTypeFont
+	coords []Scalar // normalized variation coordinates.
+	rec    ScalerContextRec
+	matrix *Matrix // font units to device space.
+	hinter *tAutohinter
+}
+
+func newTrueTypeScalerContext(font *tTrueTypeFont, coords []Scalar, rec *ScalerContextRec) *tTrueTypeScalerContext {
+	var ctx = &tTrueTypeScalerContext{
+		font:   font,
+		coords: coords,
+		rec:    *rec,
+	}
+	var unitsPerEm = Scalar(font.unitsPerEm)
+	ctx.matrix = NewMatrix()
+	ctx.matrix.SetScale(1/unitsPerEm, -1/unitsPerEm) // font units are y up.
+	ctx.matrix.PostConcat(rec.Matrix())
+
+	var zones []Scalar
+	for _, height := range []int{0, font.xHeight, font.capHeight} {
+		if height != 0 || len(zones) == 0 {
+			zones = append(zones, ctx.matrix.MapXY(0, Scalar(height)).Y)
+		}
+	}
+	ctx.hinter = newAutohinter(rec, zones)
+	return ctx
+}
+
+func (ctx *tTrueTypeScalerContext) GlyphCount() int {
+	return ctx.font.numGlyphs
+}
+
+func (ctx *tTrueTypeScalerContext) CharToGlyphID(uni Unichar) GlyphID {
+	return ctx.font.glyphIndex(uni)
+}
+
+// Return the glyph's outline in device space, grid-fitted if hinting.
+func (ctx *tTrueTypeScalerContext) deviceOutline(id GlyphID) *tGlyphOutline {
+	var outline = new(tGlyphOutline)
+	ctx.font.appendOutline(outline, id, ctx.coords, 0)
+	for i := range outline.points {
+		var p = &outline.points[i]
+		var pt = ctx.matrix.MapXY(p.X, p.Y)
+		p.X, p.Y = pt.X, pt.Y
+	}
+	if ctx.hinter != nil {
+		ctx.hinter.hint(outline)
+	}
+	if ctx.isVertical() {
+		// hang the glyph from its vertical origin. The outline is hinted
+		// first so the blue zones stay where they are for every glyph.
+		var offset = ctx.verticalOffset(id)
+		for i := range outline.points {
+			outline.points[i].X += offset.X
+			outline.points[i].Y += offset.Y
+		}
+	}
+	return outline
+}
+
+func (ctx *tTrueTypeScalerContext) isVertical() bool {
+	return ctx.rec.Flags&KScalerContextFlagVertical != 0
+}
+
+// Return the device space offset that moves the glyph's vertical origin to
+// the glyph origin. Hinted axes move by whole pixels.
+func (ctx *tTrueTypeScalerContext) verticalOffset(id GlyphID) Point {
+	var _, origin = ctx.font.vMetrics(id)
+	if ctx.coords != nil {
+		origin.X = ctx.font.hAdvance(id, ctx.coords) / 2
+	}
+	var offset = []Point{{-origin.X, -origin.Y}}
+	ctx.matrix.MapVectors(offset, offset)
+	if ctx.hinter != nil && ctx.hinter.hintX {
+		offset[0].X = ScalarRound(offset[0].X)
+	}
+	if ctx.hinter != nil && ctx.hinter.hintY {
+		offset[0].Y = ScalarRound(offset[0].Y)
+	}
+	return offset[0]
+}
+
+func (ctx *tTrueTypeScalerContext) GenerateMetrics(glyph *Glyph) {
+	var linear = ctx.rec.Flags&KScalerContextFlagLinearMetrics != 0
+	if ctx.isVertical() {
+		// font units are y up, so the advance down the column is negative.
+		var vector = []Point{{0, -ctx.font.vAdvance(glyph.ID, ctx.coords)}}
+		ctx.matrix.MapVectors(vector, vector)
+		glyph.AdvanceX, glyph.AdvanceY = vector[0].X, vector[0].Y
+		if ctx.hinter != nil && ctx.hinter.roundAdvances && !linear {
+			glyph.AdvanceY = ScalarRound(glyph.AdvanceY)
+		}
+	} else {
+		var vector = []Point{{ctx.font.hAdvance(glyph.ID, ctx.coords), 0}}
+		ctx.matrix.MapVectors(vector, vector)
+		glyph.AdvanceX, glyph.AdvanceY = vector[0].X, vector[0].Y
+		if ctx.hinter != nil && ctx.hinter.roundAdvances && !linear {
+			glyph.AdvanceX = ScalarRound(glyph.AdvanceX)
+		}
+	}
+
+	glyph.Left, glyph.Top, glyph.Width, glyph.Height = 0, 0, 0, 0
+	if ctx.generateColorMetrics(glyph) {
+		return
+	}
+	var outline = ctx.deviceOutline(glyph.ID)
+	if len(outline.points) == 0 {
+		return
+	}
+	var bounds Rect
+	var pts = make([]Point, len(outline.points))
+	for i, p := range outline.points {
+		pts[i] = Point{p.X, p.Y}
+	}
+	bounds.SetBoundsPoints(pts)
+	glyph.Left, glyph.Top = ScalarFloorToInt(bounds.L()), ScalarFloorToInt(bounds.T())
+	glyph.Width = ScalarCeilToInt(bounds.R()) - glyph.Left
+	glyph.Height = ScalarCeilToInt(bounds.B()) - glyph.Top
+}
+
+func (ctx *tTrueTypeScalerContext) GenerateImage(glyph *Glyph) {
+	// other than color glyphs, images are rendered from the outline.
+	if glyph.MaskFormat == KMaskFormatARGB32 {
+		ctx.generateColorImage(glyph)
+	}
+}
+
+func (ctx *tTrueTypeScalerContext) GeneratePath(glyph *Glyph, path *Path) {
+	path.Reset()
+	appendOutlineToPath(path, ctx.deviceOutline(glyph.ID))
+}
+
+func (ctx *tTrueTypeScalerContext) GenerateFontMetrics(metrics *PaintFontMetrics) {
+	var font = ctx.font
+	var scale = ctx.rec.TextSize / Scalar(font.unitsPerEm)
+	var y = func(v int) Scalar {
+		return -Scalar(v) * scale
+	}
+	*metrics = PaintFontMetrics{
+		Top:          y(font.yMax),
+		Ascent:       y(font.ascender),
+		Descent:      y(font.descender),
+		Bottom:       y(font.yMin),
+		Leading:      Scalar(font.lineGap) * scale,
+		AvgCharWidth: Scalar(font.avgCharWidth) * scale,
+		MaxCharWidth: Scalar(font.xMax-font.xMin) * scale,
+		XMin:         Scalar(font.xMin) * scale,
+		XMax:         Scalar(font.xMax) * scale,
+		XHeight:      Scalar(font.xHeight) * scale,
+		CapHeight:    Scalar(font.capHeight) * scale,
+	}
+	if font.hasPost {
+		metrics.Flags |= KPaintFontMetricsFlagUnderlineThinknessIsValid |
+			KPaintFontMetricsFlagUnderlinePositionIsValid
+		metrics.UnderlineThickness = Scalar(font.underlineThickness) * scale
+		metrics.UnderlinePosition = y(font.underlinePosition)
+	}
+}
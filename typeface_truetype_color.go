@@ -0,0 +1,419 @@
+package ggk
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"sort"
+)
+
+/** tColorBitmap
+is a color bitmap glyph of a CBDT or sbix strike. left and top place the
+bitmap's top left corner relative to the glyph origin, and width and
+height are its size, all in pixels of the strike's ppem, y down. */
+type tColorBitmap struct {
+	ppem          int
+	left, top     int
+	width, height int
+	png           []byte
+}
+
+/** tColorLayer
+is a layer of a COLR glyph: the outline of glyph id filled with color, or
+with the foreground color. */
+type tColorLayer struct {
+	id         GlyphID
+	color      Color
+	foreground bool
+}
+
+// COLR layers with this palette index are filled with the foreground color.
+const kColorForegroundPaletteIndex = 0xffff
+
+func otU8(b []byte, offset int) int {
+	if offset < 0 || offset >= len(b) {
+		return 0
+	}
+	return int(b[offset])
+}
+
+func otI8(b []byte, offset int) int {
+	return int(int8(otU8(b, offset)))
+}
+
+// Return the indices of the strikes with ppems, in the order they are
+// tried for text of ppem: the smallest strike at least as large first,
+// then the smaller strikes from the largest, as scaling up looks worse
+// than scaling down.
+func colorStrikeOrder(ppems []int, ppem Scalar) []int {
+	var order = make([]int, len(ppems))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		var a, b = ppems[order[i]], ppems[order[j]]
+		var aBig, bBig = Scalar(a) >= ppem, Scalar(b) >= ppem
+		if aBig != bBig {
+			return aBig
+		}
+		if aBig {
+			return a < b
+		}
+		return a > b
+	})
+	return order
+}
+
+// Return true if the font has strikes of color bitmaps.
+func (font *tTrueTypeFont) hasColorBitmaps() bool {
+	var _, hasCBDT = font.tables["CBDT"]
+	var _, hasCBLC = font.tables["CBLC"]
+	var _, hasSbix = font.tables["sbix"]
+	return (hasCBDT && hasCBLC) || hasSbix
+}
+
+// Return the bitmap of glyph id from the CBDT or sbix strike that best
+// suits text of ppem pixels per em, or nil if the font has none.
+func (font *tTrueTypeFont) colorBitmap(id GlyphID, ppem Scalar) *tColorBitmap {
+	if bitmap := font.cbdtBitmap(id, ppem); bitmap != nil {
+		return bitmap
+	}
+	return font.sbixBitmap(id, ppem)
+}
+
+func (font *tTrueTypeFont) cbdtBitmap(id GlyphID, ppem Scalar) *tColorBitmap {
+	var cblc, cbdt = font.tables["CBLC"], font.tables["CBDT"]
+	var numSizes = otU32(cblc, 4)
+	if len(cbdt) < 4 || numSizes <= 0 || len(cblc) < 8+48*numSizes {
+		return nil
+	}
+	var ppems = make([]int, numSizes)
+	for i := range ppems {
+		ppems[i] = int(cblc[8+48*i+45])
+	}
+	for _, i := range colorStrikeOrder(ppems, ppem) {
+		var size = cblc[8+48*i:]
+		if int(id) < ttU16(size, 40) || int(id) > ttU16(size, 42) {
+			continue
+		}
+		if bitmap := cbdtStrikeBitmap(cblc, cbdt, size, id); bitmap != nil {
+			bitmap.ppem = ppems[i]
+			return bitmap
+		}
+	}
+	return nil
+}
+
+// Find glyph id in the index subtables of the CBLC bitmap size record
+// size, and read its image from cbdt.
+func cbdtStrikeBitmap(cblc, cbdt, size []byte, id GlyphID) *tColorBitmap {
+	var arrayOffset, numSubtables = ttU32(size, 0), ttU32(size, 8)
+	for j := 0; j < numSubtables; j++ {
+		var entry = arrayOffset + 8*j
+		var first, last = otU16(cblc, entry), otU16(cblc, entry+2)
+		if int(id) < first || int(id) > last {
+			continue
+		}
+		var sub = otSub(cblc, arrayOffset+otU32(cblc, entry+4))
+		var indexFormat, imageFormat = otU16(sub, 0), otU16(sub, 2)
+		var imageOffset = otU32(sub, 4)
+		var index = int(id) - first
+		var bigMetrics []byte // the metrics shared by all glyphs, if any.
+		var start, end int
+		switch indexFormat {
+		case 1:
+			start, end = otU32(sub, 8+4*index), otU32(sub, 12+4*index)
+		case 3:
+			start, end = otU16(sub, 8+2*index), otU16(sub, 10+2*index)
+		case 2:
+			var imageSize = otU32(sub, 8)
+			start, end = index*imageSize, (index+1)*imageSize
+			bigMetrics = otSub(sub, 12)
+		case 4, 5:
+			// sparse: a sorted list of the glyphs that have images.
+			var numGlyphs, list, stride = otU32(sub, 8), 12, 4
+			if indexFormat == 5 {
+				numGlyphs, list, stride = otU32(sub, 20), 24, 2
+			}
+			var k = sort.Search(numGlyphs, func(k int) bool {
+				return otU16(sub, list+stride*k) >= int(id)
+			})
+			if k == numGlyphs || otU16(sub, list+stride*k) != int(id) {
+				return nil
+			}
+			if indexFormat == 4 {
+				start, end = otU16(sub, list+4*k+2), otU16(sub, list+4*k+6)
+			} else {
+				var imageSize = otU32(sub, 8)
+				start, end = k*imageSize, (k+1)*imageSize
+				bigMetrics = otSub(sub, 12)
+			}
+		default:
+			return nil
+		}
+		start, end = imageOffset+start, imageOffset+end
+		if start >= end || start < 0 || end > len(cbdt) {
+			return nil
+		}
+		return cbdtImage(cbdt[start:end], imageFormat, bigMetrics)
+	}
+	return nil
+}
+
+// Read a CBDT glyph image with PNG data, whose metrics are in data itself
+// or, for format 19, in bigMetrics.
+func cbdtImage(data []byte, imageFormat int, bigMetrics []byte) *tColorBitmap {
+	var metrics []byte
+	var pngOffset int
+	switch imageFormat {
+	case 17: // small metrics.
+		metrics, pngOffset = data, 9
+	case 18: // big metrics.
+		metrics, pngOffset = data, 12
+	case 19:
+		metrics, pngOffset = bigMetrics, 4
+	default:
+		return nil
+	}
+	if len(metrics) < 5 || pngOffset > len(data) {
+		return nil
+	}
+	var length = otU32(data, pngOffset-4)
+	if pngOffset+length > len(data) {
+		return nil
+	}
+	return &tColorBitmap{
+		height: otU8(metrics, 0),
+		width:  otU8(metrics, 1),
+		left:   otI8(metrics, 2),
+		top:    -otI8(metrics, 3),
+		png:    data[pngOffset : pngOffset+length],
+	}
+}
+
+func (font *tTrueTypeFont) sbixBitmap(id GlyphID, ppem Scalar) *tColorBitmap {
+	var sbix = font.tables["sbix"]
+	var numStrikes = otU32(sbix, 4)
+	if numStrikes <= 0 || len(sbix) < 8+4*numStrikes || int(id) >= font.numGlyphs {
+		return nil
+	}
+	var ppems = make([]int, numStrikes)
+	for i := range ppems {
+		ppems[i] = otU16(sbix, ttU32(sbix, 8+4*i))
+	}
+	for _, i := range colorStrikeOrder(ppems, ppem) {
+		var strike = otSub(sbix, ttU32(sbix, 8+4*i))
+		// a 'dupe' glyph uses the data of another glyph, once.
+		for glyph, dupes := int(id), 0; dupes < 2; dupes++ {
+			var start, end = otU32(strike, 4+4*glyph), otU32(strike, 8+4*glyph)
+			if start+8 > end || end > len(strike) {
+				break
+			}
+			var data = strike[start:end]
+			switch otTag(data, 4) {
+			case SetFourByteTag('p', 'n', 'g', ' '):
+				var config, err = png.DecodeConfig(bytes.NewReader(data[8:]))
+				if err != nil {
+					return nil
+				}
+				return &tColorBitmap{
+					ppem:   ppems[i],
+					left:   otI16(data, 0),
+					top:    -otI16(data, 2) - config.Height,
+					width:  config.Width,
+					height: config.Height,
+					png:    data[8:],
+				}
+			case SetFourByteTag('d', 'u', 'p', 'e'):
+				glyph = otU16(data, 8)
+				continue
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// Return the layers of the COLR glyph id, colored from the first CPAL
+// palette, or nil if id has no color layers.
+func (font *tTrueTypeFont) colorLayers(id GlyphID) []tColorLayer {
+	var colr, cpal = font.tables["COLR"], font.tables["CPAL"]
+	var numBaseGlyphs = otU16(colr, 2)
+	var baseGlyphs, layers = otU32(colr, 4), otU32(colr, 8)
+	var numLayers = otU16(colr, 12)
+	var k = sort.Search(numBaseGlyphs, func(k int) bool {
+		return otU16(colr, baseGlyphs+6*k) >= int(id)
+	})
+	if k == numBaseGlyphs || otU16(colr, baseGlyphs+6*k) != int(id) {
+		return nil
+	}
+	var first, count = otU16(colr, baseGlyphs+6*k+2), otU16(colr, baseGlyphs+6*k+4)
+	if first+count > numLayers {
+		return nil
+	}
+
+	var numEntries, numColors = otU16(cpal, 2), otU16(cpal, 6)
+	var colors, firstColor = otU32(cpal, 8), otU16(cpal, 12)
+	var result = make([]tColorLayer, count)
+	for i := range result {
+		var record = layers + 4*(first+i)
+		var layer = &result[i]
+		layer.id = GlyphID(otU16(colr, record))
+		var paletteIndex = otU16(colr, record+2)
+		if paletteIndex == kColorForegroundPaletteIndex || paletteIndex >= numEntries ||
+			firstColor+paletteIndex >= numColors {
+			layer.foreground = true
+			continue
+		}
+		// color records are blue, green, red, alpha.
+		var c = colors + 4*(firstColor+paletteIndex)
+		layer.color = ColorWithARGB(uint8(otU8(cpal, c+3)), uint8(otU8(cpal, c+2)),
+			uint8(otU8(cpal, c+1)), uint8(otU8(cpal, c)))
+	}
+	return result
+}
+
+// Return the size in device pixels of the em of the rec, which picks the
+// bitmap strike.
+func (ctx *tTrueTypeScalerContext) devicePPEM() Scalar {
+	var rec = &ctx.rec
+	return rec.TextSize * ScalarMax(
+		ScalarAbs(rec.Post2x2[0][0])+ScalarAbs(rec.Post2x2[1][0]),
+		ScalarAbs(rec.Post2x2[0][1])+ScalarAbs(rec.Post2x2[1][1]))
+}
+
+// Return the transform from the pixels of bitmap, drawn for glyph id, to
+// device space.
+func (ctx *tTrueTypeScalerContext) bitmapMatrix(bitmap *tColorBitmap, id GlyphID) *Matrix {
+	var matrix = NewMatrix()
+	matrix.SetTranslate(Scalar(bitmap.left), Scalar(bitmap.top))
+	matrix.PostScale(1/Scalar(bitmap.ppem), 1/Scalar(bitmap.ppem))
+	matrix.PostConcat(ctx.rec.Matrix())
+	if ctx.isVertical() {
+		var offset = ctx.verticalOffset(id)
+		matrix.PostTranslate(offset.X, offset.Y)
+	}
+	return matrix
+}
+
+// Set the bounds of a color glyph, returning false if id is drawn from its
+// outline.
+func (ctx *tTrueTypeScalerContext) generateColorMetrics(glyph *Glyph) bool {
+	if layers := ctx.font.colorLayers(glyph.ID); layers != nil {
+		var bounds Rect
+		for _, layer := range layers {
+			var path = NewPath()
+			appendOutlineToPath(path, ctx.deviceOutline(layer.id))
+			if !path.IsEmpty() {
+				bounds.Join(path.Bounds())
+			}
+		}
+		ctx.setColorBounds(glyph, bounds)
+		return true
+	}
+	if bitmap := ctx.font.colorBitmap(glyph.ID, ctx.devicePPEM()); bitmap != nil {
+		var matrix = ctx.bitmapMatrix(bitmap, glyph.ID)
+		ctx.setColorBounds(glyph, matrix.MapRect(MakeRect(0, 0, Scalar(bitmap.width), Scalar(bitmap.height))))
+		return true
+	}
+	return false
+}
+
+func (ctx *tTrueTypeScalerContext) setColorBounds(glyph *Glyph, bounds Rect) {
+	glyph.MaskFormat = KMaskFormatARGB32
+	if bounds.Width <= 0 || bounds.Height <= 0 {
+		return
+	}
+	glyph.Left, glyph.Top = ScalarFloorToInt(bounds.L()), ScalarFloorToInt(bounds.T())
+	glyph.Width = ScalarCeilToInt(bounds.R()) - glyph.Left
+	glyph.Height = ScalarCeilToInt(bounds.B()) - glyph.Top
+}
+
+/** generateColorImage
+Render a color glyph into its premultiplied 32-bit image: the COLR layers
+are filled one over the other, and a bitmap is resampled to the device
+size and transform. */
+func (ctx *tTrueTypeScalerContext) generateColorImage(glyph *Glyph) {
+	var image = make([]byte, glyph.RowBytes()*glyph.Height)
+	var pixmap = NewPixmap()
+	pixmap.Reset(NewImageInfoN32Premul(Scalar(glyph.Width), Scalar(glyph.Height), nil),
+		image, glyph.RowBytes(), nil)
+	if layers := ctx.font.colorLayers(glyph.ID); layers != nil {
+		var matrix = NewMatrix()
+		matrix.SetTranslate(-Scalar(glyph.Left), -Scalar(glyph.Top))
+		var coverage = make([]byte, glyph.Width*glyph.Height)
+		for _, layer := range layers {
+			var path = NewPath()
+			appendOutlineToPath(path, ctx.deviceOutline(layer.id))
+			for i := range coverage {
+				coverage[i] = 0
+			}
+			scanFillPathToA8(path, matrix, coverage, glyph.Width, glyph.Height, glyph.Width)
+			var color = layer.color
+			if layer.foreground {
+				color = ctx.rec.ForegroundColor
+			}
+			fillColorCoverage(pixmap, coverage, color)
+		}
+	} else if bitmap := ctx.font.colorBitmap(glyph.ID, ctx.devicePPEM()); bitmap != nil {
+		var src, err = png.Decode(bytes.NewReader(bitmap.png))
+		if err != nil {
+			return
+		}
+		var matrix = ctx.bitmapMatrix(bitmap, glyph.ID)
+		matrix.PostTranslate(-Scalar(glyph.Left), -Scalar(glyph.Top))
+		if inverse, ok := matrix.Invert(); ok {
+			resampleColorBitmap(pixmap, src, bitmap, inverse)
+		}
+	}
+	glyph.Image = image
+}
+
+// Blend color into dst, weighted by coverage, which has a byte per pixel
+// of dst.
+func fillColorCoverage(dst *Pixmap, coverage []byte, color Color) {
+	var a, r, g, b = color.ARGB()
+	var src = PackARGB32(uint32(a), uint32(MulDiv255Round(r, a)),
+		uint32(MulDiv255Round(g, a)), uint32(MulDiv255Round(b, a)))
+	var width, height = int(dst.Width()), int(dst.Height())
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if c := coverage[y*width+x]; c != 0 {
+				var s = AlphaMulQ(src, Alpha255To256(uint32(c)))
+				dst.SetPixel32(x, y, PMSrcOver(s, dst.Pixel32(x, y)))
+			}
+		}
+	}
+}
+
+// Fill dst with src, which has the size of bitmap, mapping the center of
+// each dst pixel through inverse into src and filtering bilinearly.
+func resampleColorBitmap(dst *Pixmap, src image.Image, bitmap *tColorBitmap, inverse *Matrix) {
+	var bounds = src.Bounds()
+	var pixel = func(x, y int) [4]Scalar {
+		if x < 0 || y < 0 || x >= bitmap.width || y >= bitmap.height {
+			return [4]Scalar{}
+		}
+		// RGBA is premultiplied, 16 bits per channel.
+		var r, g, b, a = src.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+		return [4]Scalar{Scalar(a), Scalar(r), Scalar(g), Scalar(b)}
+	}
+	var width, height = int(dst.Width()), int(dst.Height())
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var p = inverse.MapXY(Scalar(x)+KScalarHalf, Scalar(y)+KScalarHalf)
+			var sx, sy = p.X - KScalarHalf, p.Y - KScalarHalf
+			var x0, y0 = ScalarFloorToInt(sx), ScalarFloorToInt(sy)
+			var fx, fy = sx - Scalar(x0), sy - Scalar(y0)
+			var p00, p10 = pixel(x0, y0), pixel(x0+1, y0)
+			var p01, p11 = pixel(x0, y0+1), pixel(x0+1, y0+1)
+			var c [4]uint32
+			for i := range c {
+				var top = p00[i] + (p10[i]-p00[i])*fx
+				var bottom = p01[i] + (p11[i]-p01[i])*fx
+				c[i] = uint32(ScalarRoundToInt((top + (bottom-top)*fy) / 257))
+			}
+			dst.SetPixel32(x, y, PackARGB32(c[0], c[1], c[2], c[3]))
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package ggk
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// The glyphs of the color test fonts: a base glyph, and the two layers
+// that make it up in COLR, the left and the right half of an em box.
+var gColorTestGlyphs = []tTestGlyph{
+	{char: 'a', advance: 1000, vAdvance: 1000, contours: [][][2]int{
+		{{0, 0}, {0, 1000}, {1000, 1000}, {1000, 0}},
+	}},
+	{char: 0xE000, advance: 1000, vAdvance: 1000, contours: [][][2]int{
+		{{0, 0}, {0, 1000}, {500, 1000}, {500, 0}},
+	}},
+	{char: 0xE001, advance: 1000, vAdvance: 1000, contours: [][][2]int{
+		{{500, 0}, {500, 1000}, {1000, 1000}, {1000, 0}},
+	}},
+}
+
+func makeTestPNG(t *testing.T, size int, c color.Color) []byte {
+	var img = image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode() failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// Return the color image of the glyph of 'a' at size, and the glyph.
+func colorTestGlyph(t *testing.T, typeface *Typeface, size Scalar) (*Glyph, *Pixmap) {
+	var rec = &ScalerContextRec{TextSize: size, PreScaleX: 1, MaskFormat: KMaskFormatA8,
+		ForegroundColor: KColorBlack}
+	rec.Post2x2[0][0], rec.Post2x2[1][1] = 1, 1
+	var cache = FindGlyphCache(typeface, rec)
+	var glyph = cache.UnicharMetrics('a')
+	if glyph.MaskFormat != KMaskFormatARGB32 {
+		t.Fatalf("MaskFormat want ARGB32 got %v", glyph.MaskFormat)
+	}
+	var pixmap = NewPixmap()
+	pixmap.Reset(NewImageInfoN32Premul(Scalar(glyph.Width), Scalar(glyph.Height), nil),
+		cache.FindImage(glyph), glyph.RowBytes(), nil)
+	return glyph, pixmap
+}
+
+func TestColorGlyphCOLR(t *testing.T) {
+	// glyph 1 is glyph 2 in palette entry 1 under glyph 3 in the foreground.
+	var colr = putU16(putU16(nil, 0), 1)
+	colr = putU16(putU32(putU32(colr, 14), 20), 2)
+	colr = putU16(putU16(putU16(colr, 1), 0), 2)
+	colr = putU16(putU16(colr, 2), 1)
+	colr = putU16(putU16(colr, 3), kColorForegroundPaletteIndex)
+	var cpal = putU16(putU16(putU16(putU16(nil, 0), 2), 1), 2)
+	cpal = putU16(putU32(cpal, 14), 0)
+	cpal = append(cpal, 0, 0, 0xff, 0xff, 0xff, 0, 0, 0xff) // red, blue.
+
+	var typeface, err = NewTypefaceFromData(makeTestTrueTypeFont(gColorTestGlyphs,
+		tTestTable{"COLR", colr}, tTestTable{"CPAL", cpal}))
+	if err != nil {
+		t.Fatalf("NewTypefaceFromData() failed: %v", err)
+	}
+	// the em box is 10 pixels, plus one for rounding out.
+	var glyph, pixmap = colorTestGlyph(t, typeface, 10)
+	if glyph.Left != 0 || glyph.Top < -11 || glyph.Width < 10 || glyph.Width > 11 {
+		t.Errorf("Bounds() want about %v got %v", MakeRect(0, -10, 10, 10), glyph.Bounds())
+	}
+	var tests = []struct {
+		x, y int
+		want uint32
+	}{
+		{2, 5, PackARGB32(0xff, 0, 0, 0xff)},
+		{7, 5, PackARGB32(0xff, 0, 0, 0)},
+		{glyph.Width - 1, 5, 0},
+	}
+	for _, test := range tests {
+		if got := pixmap.Pixel32(test.x, test.y); got != test.want {
+			t.Errorf("pixel (%v, %v) want %#x got %#x", test.x, test.y, test.want, got)
+		}
+	}
+
+	// the foreground layer is drawn in the color of the paint.
+	var canvas, pixels = newTestPictureCanvas(20, 40)
+	var paint = NewPaint()
+	paint.SetTypeface(typeface)
+	paint.SetTextSize(10)
+	paint.SetHinting(KPaintHintingNo)
+	for i, color := range []Color{KColorGreen, KColorRed} {
+		paint.SetColor(color)
+		canvas.DrawText("a", 0, Scalar(10+20*i), paint)
+		var y = 5 + 20*i
+		if got, want := pixels.Pixel32(2, y), PackARGB32(0xff, 0, 0, 0xff); got != want {
+			t.Errorf("color %#x palette layer want %#x got %#x", color, want, got)
+		}
+		if got, want := pixels.Pixel32(7, y), premulPixel32(color); got != want {
+			t.Errorf("color %#x foreground layer want %#x got %#x", color, want, got)
+		}
+	}
+}
+
+func TestColorGlyphCBDT(t *testing.T) {
+	// strikes at 10 and 20 ppem, each with a square PNG for glyph 1 that
+	// sits on the baseline.
+	var strikes = []struct {
+		ppem  int
+		color color.Color
+	}{
+		{10, color.NRGBA{0xff, 0, 0, 0xff}},
+		{20, color.NRGBA{0, 0, 0xff, 0xff}},
+	}
+	var cbdt = putU32(nil, 0x00030000)
+	var cblc = putU32(putU32(nil, 0x00030000), len(strikes))
+	var subtables []byte
+	for _, strike := range strikes {
+		var data = makeTestPNG(t, strike.ppem, strike.color)
+		var size = putU32(nil, 8+48*len(strikes)+len(subtables))
+		size = putU32(putU32(putU32(size, 24), 1), 0)
+		size = append(size, make([]byte, 24)...)
+		size = putU16(putU16(size, 1), 1)
+		size = append(size, byte(strike.ppem), byte(strike.ppem), 32, 1)
+		cblc = append(cblc, size...)
+
+		// an index subtable array of one, pointing to a format 1 subtable.
+		subtables = putU32(putU16(putU16(subtables, 1), 1), 8)
+		subtables = putU32(putU16(putU16(subtables, 1), 17), len(cbdt))
+		subtables = putU32(putU32(subtables, 0), 9+len(data))
+		cbdt = append(cbdt, byte(strike.ppem), byte(strike.ppem), 0, byte(strike.ppem), byte(strike.ppem))
+		cbdt = append(putU32(cbdt, len(data)), data...)
+	}
+	cblc = append(cblc, subtables...)
+
+	// a bitmap font needs no outlines.
+	var noOutlines = []tTestTable{{"glyf", nil}, {"loca", nil}}
+	if _, err := NewTypefaceFromData(makeTestTrueTypeFont(gColorTestGlyphs, noOutlines...)); err != ErrTrueTypeInvalid {
+		t.Errorf("NewTypefaceFromData() without outlines or bitmaps want %v got %v", ErrTrueTypeInvalid, err)
+	}
+	var typeface, err = NewTypefaceFromData(makeTestTrueTypeFont(gColorTestGlyphs,
+		append(noOutlines, tTestTable{"CBDT", cbdt}, tTestTable{"CBLC", cblc})...))
+	if err != nil {
+		t.Fatalf("NewTypefaceFromData() failed: %v", err)
+	}
+	var tests = []struct {
+		size   Scalar
+		bounds Rect
+		want   uint32
+	}{
+		{10, MakeRect(0, -10, 10, 10), PackARGB32(0xff, 0xff, 0, 0)},
+		{8, MakeRect(0, -8, 8, 8), PackARGB32(0xff, 0xff, 0, 0)},
+		{15, MakeRect(0, -15, 15, 15), PackARGB32(0xff, 0, 0, 0xff)},
+		{40, MakeRect(0, -40, 40, 40), PackARGB32(0xff, 0, 0, 0xff)},
+	}
+	for _, test := range tests {
+		var glyph, pixmap = colorTestGlyph(t, typeface, test.size)
+		if glyph.Bounds() != test.bounds {
+			t.Errorf("size %v Bounds() want %v got %v", test.size, test.bounds, glyph.Bounds())
+		}
+		var center = int(test.size) / 2
+		if got := pixmap.Pixel32(center, center); got != test.want {
+			t.Errorf("size %v center pixel want %#x got %#x", test.size, test.want, got)
+		}
+	}
+}
+
+func TestColorGlyphSbix(t *testing.T) {
+	// one strike at 10 ppem: glyph 1 is a green square raised by 2 pixels,
+	// glyph 2 a dupe of it.
+	var data = makeTestPNG(t, 10, color.NRGBA{0, 0xff, 0, 0xff})
+	var glyph1 = append(putU32(putU16(putU16(nil, 0), 2), 0x706e6720), data...) // 'png '
+	var glyph2 = putU16(putU32(putU16(putU16(nil, 0), 0), 0x64757065), 1)       // 'dupe'
+	var strike = putU16(putU16(nil, 10), 72)
+	var offset = 4 + 4*5
+	for _, size := range []int{0, len(glyph1), len(glyph2), 0} {
+		strike = putU32(strike, offset)
+		offset += size
+	}
+	strike = putU32(strike, offset)
+	strike = append(append(strike, glyph1...), glyph2...)
+	var sbix = append(putU32(putU32(putU16(putU16(nil, 1), 1), 1), 12), strike...)
+
+	var glyphs = append([]tTestGlyph{}, gColorTestGlyphs...)
+	glyphs[1].char = 'b'
+	var typeface, err = NewTypefaceFromData(makeTestTrueTypeFont(glyphs, tTestTable{"sbix", sbix}))
+	if err != nil {
+		t.Fatalf("NewTypefaceFromData() failed: %v", err)
+	}
+	var glyph, pixmap = colorTestGlyph(t, typeface, 10)
+	if glyph.Bounds() != MakeRect(0, -12, 10, 10) {
+		t.Errorf("Bounds() want %v got %v", MakeRect(0, -12, 10, 10), glyph.Bounds())
+	}
+	if got, want := pixmap.Pixel32(5, 5), PackARGB32(0xff, 0, 0xff, 0); got != want {
+		t.Errorf("center pixel want %#x got %#x", want, got)
+	}
+
+	var rec = &ScalerContextRec{TextSize: 10, PreScaleX: 1, MaskFormat: KMaskFormatA8}
+	rec.Post2x2[0][0], rec.Post2x2[1][1] = 1, 1
+	var dupe = FindGlyphCache(typeface, rec).UnicharMetrics('b')
+	if dupe.MaskFormat != KMaskFormatARGB32 || dupe.Bounds() != glyph.Bounds() {
+		t.Errorf("dupe glyph want the bounds %v got %v", glyph.Bounds(), dupe.Bounds())
+	}
+
+	// glyph 3 has no data in the strike, so it is drawn from its outline.
+	if other := FindGlyphCache(typeface, rec).GlyphIDMetrics(3); other.MaskFormat != KMaskFormatA8 {
+		t.Errorf("glyph without a bitmap want an A8 mask got %v", other.MaskFormat)
+	}
+}
+
+func TestColorStrikeOrder(t *testing.T) {
+	var got = colorStrikeOrder([]int{20, 64, 10, 32}, 24)
+	var want = []int{3, 1, 0, 2}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("colorStrikeOrder() want %v got %v", want, got)
+			break
+		}
+	}
+}
@@ -0,0 +1,423 @@
+package ggk
+
+import (
+	"encoding/binary"
+	"sort"
+	"testing"
+)
+
+// tTestGlyph describes a glyph of a font built by makeTestTrueTypeFont. The
+// contours hold on-curve points in font units. Every glyph has a top side
+// bearing of 100 units.
+type tTestGlyph struct {
+	char     Unichar
+	advance  int
+	vAdvance int
+	contours [][][2]int
+}
+
+// The glyphs of the test font: a stem, and a box with a counter.
+var gTestGlyphs = []tTestGlyph{
+	{char: 'l', advance: 360, vAdvance: 1000, contours: [][][2]int{
+		{{100, 0}, {100, 700}, {260, 700}, {260, 0}},
+	}},
+	{char: 'o', advance: 600, vAdvance: 800, contours: [][][2]int{
+		{{50, -10}, {50, 510}, {550, 510}, {550, -10}},
+		{{130, 70}, {470, 70}, {470, 430}, {130, 430}},
+	}},
+}
+
+type tTestTable struct {
+	tag  string
+	data []byte
+}
+
+func putU16(b []byte, v int) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func putU32(b []byte, v int) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// makeTestTrueTypeFont builds a 1000 unit per em TrueType font holding an
+// empty .notdef glyph followed by glyphs, and any extra tables, which
+// replace the default table with the same tag, or remove it if their data
+// is nil.
+func makeTestTrueTypeFont(glyphs []tTestGlyph, extra ...tTestTable) []byte {
+	var numGlyphs = len(glyphs) + 1
+
+	var glyf, loca, hmtx, vmtx []byte
+	loca = putU32(putU32(loca, 0), 0) // .notdef is empty.
+	hmtx = putU16(putU16(hmtx, 500), 0)
+	vmtx = putU16(putU16(vmtx, 1000), 0)
+	for _, g := range glyphs {
+		var xMin, yMin, xMax, yMax = 1 << 15, 1 << 15, -1 << 15, -1 << 15
+		var numPoints = 0
+		for _, contour := range g.contours {
+			for _, p := range contour {
+				xMin, xMax = minInt(xMin, p[0]), maxInt(xMax, p[0])
+				yMin, yMax = minInt(yMin, p[1]), maxInt(yMax, p[1])
+			}
+			numPoints += len(contour)
+		}
+		var data []byte
+		data = putU16(data, len(g.contours))
+		data = putU16(putU16(data, xMin), yMin)
+		data = putU16(putU16(data, xMax), yMax)
+		var end = -1
+		for _, contour := range g.contours {
+			end += len(contour)
+			data = putU16(data, end)
+		}
+		data = putU16(data, 0) // no instructions.
+		for i := 0; i < numPoints; i++ {
+			data = append(data, 0x01) // on curve, 16 bit deltas.
+		}
+		for axis := 0; axis < 2; axis++ {
+			var last = 0
+			for _, contour := range g.contours {
+				for _, p := range contour {
+					data = putU16(data, p[axis]-last)
+					last = p[axis]
+				}
+			}
+		}
+		glyf = append(glyf, data...)
+		loca = putU32(loca, len(glyf))
+		hmtx = putU16(putU16(hmtx, g.advance), xMin)
+		vmtx = putU16(putU16(vmtx, g.vAdvance), 100)
+	}
+
+	var head = make([]byte, 54)
+	binary.BigEndian.PutUint32(head[0:], 0x00010000)
+	binary.BigEndian.PutUint32(head[12:], 0x5f0f3cf5)
+	binary.BigEndian.PutUint16(head[18:], 1000)
+	binary.BigEndian.PutUint16(head[36:], uint16(0))
+	binary.BigEndian.PutUint16(head[38:], uint16(0xffff-200+1)) // -200
+	binary.BigEndian.PutUint16(head[40:], 600)
+	binary.BigEndian.PutUint16(head[42:], 800)
+	binary.BigEndian.PutUint16(head[50:], 1) // long loca.
+
+	var hhea = make([]byte, 36)
+	binary.BigEndian.PutUint32(hhea[0:], 0x00010000)
+	binary.BigEndian.PutUint16(hhea[4:], 800)
+	binary.BigEndian.PutUint16(hhea[6:], uint16(0xffff-200+1)) // -200
+	binary.BigEndian.PutUint16(hhea[8:], 100)
+	binary.BigEndian.PutUint16(hhea[34:], uint16(numGlyphs))
+
+	var vhea = make([]byte, 36)
+	binary.BigEndian.PutUint32(vhea[0:], 0x00011000)
+	binary.BigEndian.PutUint16(vhea[4:], 500)
+	binary.BigEndian.PutUint16(vhea[6:], uint16(0xffff-500+1)) // -500
+	binary.BigEndian.PutUint16(vhea[34:], uint16(numGlyphs))
+
+	var maxp = putU16(putU32(nil, 0x00005000), numGlyphs)
+
+	var os2 = make([]byte, 96)
+	binary.BigEndian.PutUint16(os2[0:], 2)
+	binary.BigEndian.PutUint16(os2[2:], 480)
+	binary.BigEndian.PutUint16(os2[86:], 500)
+	binary.BigEndian.PutUint16(os2[88:], 700)
+
+	var post = make([]byte, 32)
+	binary.BigEndian.PutUint32(post[0:], 0x00030000)
+	binary.BigEndian.PutUint16(post[8:], uint16(0xffff-100+1)) // -100
+	binary.BigEndian.PutUint16(post[10:], 50)
+
+	// cmap format 4 with a segment per glyph.
+	var chars = make([]int, len(glyphs))
+	for i := range glyphs {
+		chars[i] = i
+	}
+	sort.Slice(chars, func(i, j int) bool { return glyphs[chars[i]].char < glyphs[chars[j]].char })
+	var segCount = len(glyphs) + 1
+	var subtable []byte
+	subtable = putU16(subtable, 4)
+	subtable = putU16(subtable, 16+8*segCount)
+	subtable = putU16(subtable, 0)
+	subtable = putU16(subtable, 2*segCount)
+	subtable = putU16(putU16(putU16(subtable, 0), 0), 0)
+	for _, i := range chars {
+		subtable = putU16(subtable, int(glyphs[i].char))
+	}
+	subtable = putU16(subtable, 0xffff)
+	subtable = putU16(subtable, 0) // reserved pad.
+	for _, i := range chars {
+		subtable = putU16(subtable, int(glyphs[i].char))
+	}
+	subtable = putU16(subtable, 0xffff)
+	for _, i := range chars {
+		subtable = putU16(subtable, (i+1-int(glyphs[i].char))&0xffff)
+	}
+	subtable = putU16(subtable, 1)
+	for i := 0; i < segCount; i++ {
+		subtable = putU16(subtable, 0)
+	}
+	var cmap = putU16(putU16(putU16(putU16(nil, 0), 1), 3), 1)
+	cmap = append(putU32(cmap, 12), subtable...)
+
+	var tables = []tTestTable{
+		{"OS/2", os2}, {"cmap", cmap}, {"glyf", glyf}, {"head", head},
+		{"hhea", hhea}, {"hmtx", hmtx}, {"loca", loca}, {"maxp", maxp},
+		{"post", post}, {"vhea", vhea}, {"vmtx", vmtx},
+	}
+	for _, table := range extra {
+		var replaced = false
+		for i := range tables {
+			if tables[i].tag == table.tag {
+				tables[i], replaced = table, true
+			}
+		}
+		if !replaced {
+			tables = append(tables, table)
+		}
+	}
+	var kept = tables[:0]
+	for _, table := range tables {
+		if table.data != nil {
+			kept = append(kept, table)
+		}
+	}
+	tables = kept
+	sort.Slice(tables, func(i, j int) bool { return tables[i].tag < tables[j].tag })
+	var font []byte
+	font = putU32(font, 0x00010000)
+	font = putU16(font, len(tables))
+	font = putU16(putU16(putU16(font, 0), 0), 0)
+	var offset = len(font) + 16*len(tables)
+	for _, table := range tables {
+		font = append(font, table.tag...)
+		font = putU32(font, 0)
+		font = putU32(font, offset)
+		font = putU32(font, len(table.data))
+		offset += (len(table.data) + 3) &^ 3
+	}
+	for _, table := range tables {
+		font = append(font, table.data...)
+		for len(font)%4 != 0 {
+			font = append(font, 0)
+		}
+	}
+	return font
+}
+
+/** makeTestTrueTypeCollection
+Return a font collection (.ttc) of fonts built by makeTestTrueTypeFont. The
+offsets of their tables are moved to where each font lands. */
+func makeTestTrueTypeCollection(fonts ...[]byte) []byte {
+	var collection []byte
+	collection = putU32(collection, kTrueTypeCollectionTag)
+	collection = putU32(collection, 0x00010000)
+	collection = putU32(collection, len(fonts))
+	var base = len(collection) + 4*len(fonts)
+	for _, font := range fonts {
+		collection = putU32(collection, base)
+		base += len(font)
+	}
+	for _, font := range fonts {
+		var font = append([]byte(nil), font...)
+		var base = len(collection)
+		for i := 0; i < ttU16(font, 4); i++ {
+			var record = 12 + 16*i
+			binary.BigEndian.PutUint32(font[record+8:], uint32(ttU32(font, record+8)+base))
+		}
+		collection = append(collection, font...)
+	}
+	return collection
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func newTestTypeface(t *testing.T) *Typeface {
+	var typeface, err = NewTypefaceFromData(makeTestTrueTypeFont(gTestGlyphs))
+	if err != nil {
+		t.Fatalf("NewTypefaceFromData() failed: %v", err)
+	}
+	return typeface
+}
+
+func TestTrueTypeTypeface(t *testing.T) {
+	if _, err := NewTypefaceFromData([]byte("not a font")); err != ErrTrueTypeInvalid {
+		t.Errorf("NewTypefaceFromData(garbage) want %v got %v", ErrTrueTypeInvalid, err)
+	}
+
+	var paint = NewPaint()
+	paint.SetTypeface(newTestTypeface(t))
+	paint.SetTextSize(10)
+	paint.SetHinting(KPaintHintingNo)
+	var cache = paint.detachCache(nil, nil)
+
+	var tests = []struct {
+		char    Unichar
+		id      GlyphID
+		advance Scalar
+	}{
+		{'l', 1, 3.6},
+		{'o', 2, 6},
+		{'x', 0, 5},
+	}
+	for _, test := range tests {
+		var glyph = cache.UnicharMetrics(test.char)
+		if glyph.ID != test.id || ScalarAbs(glyph.AdvanceX-test.advance) > 1e-4 {
+			t.Errorf("UnicharMetrics(%c) want id %v advance %v got id %v advance %v",
+				test.char, test.id, test.advance, glyph.ID, glyph.AdvanceX)
+		}
+	}
+
+	var metrics PaintFontMetrics
+	var spacing = paint.FontMetrics(&metrics, 0)
+	if metrics.Ascent != -8 || metrics.Descent != 2 || metrics.XHeight != 5 || spacing != 11 {
+		t.Errorf("FontMetrics() want ascent -8 descent 2 xHeight 5 spacing 11 got %v, %v", metrics, spacing)
+	}
+}
+
+func TestTrueTypeCollection(t *testing.T) {
+	var fontNamed = func(family string) []byte {
+		return makeTestTrueTypeFont(gTestGlyphs, tTestTable{"name",
+			makeTestNameTable(tTestNameRecord{3, 1, 0x0409, kTrueTypeNameFamily, family})})
+	}
+	var cff = fontNamed("Test CFF")
+	binary.BigEndian.PutUint32(cff, kTrueTypeVersionCFF)
+	if _, err := NewTypefaceFromData(cff); err != ErrTrueTypeCFF {
+		t.Errorf("NewTypefaceFromData(CFF) want %v got %v", ErrTrueTypeCFF, err)
+	}
+
+	var data = makeTestTrueTypeCollection(fontNamed("Test A"), cff, fontNamed("Test B"))
+	var typefaces, err = NewTypefacesFromData(data)
+	if err != nil {
+		t.Fatalf("NewTypefacesFromData() failed: %v", err)
+	}
+	if len(typefaces) != 2 || typefaces[0].FamilyName() != "Test A" || typefaces[1].FamilyName() != "Test B" {
+		t.Fatalf("NewTypefacesFromData() want Test A and Test B got %v typefaces", len(typefaces))
+	}
+	var glyphs [1]GlyphID
+	if typefaces[1].CharsToGlyphs([]Unichar{'o'}, glyphs[:]); glyphs[0] != 2 {
+		t.Errorf("CharsToGlyphs(o) of the second font want 2 got %v", glyphs[0])
+	}
+	var typeface *Typeface
+	if typeface, err = NewTypefaceFromData(data); err != nil || typeface.FamilyName() != "Test A" {
+		t.Errorf("NewTypefaceFromData() of a collection want Test A got %v", err)
+	}
+	if _, err = NewTypefacesFromData(makeTestTrueTypeCollection(cff)); err != ErrTrueTypeCFF {
+		t.Errorf("NewTypefacesFromData() of CFF fonts want %v got %v", ErrTrueTypeCFF, err)
+	}
+}
+
+func TestVerticalText(t *testing.T) {
+	var paint = NewPaint()
+	paint.SetTypeface(newTestTypeface(t))
+	paint.SetTextSize(10)
+	paint.SetHinting(KPaintHintingNo)
+	paint.SetVerticalText(true)
+
+	var bounds Rect
+	var height = paint.MeasureText("lo", 2, &bounds)
+	if ScalarAbs(height-18) > 1e-4 {
+		t.Errorf("MeasureText() want 18 got %v", height)
+	}
+	// 'l' hangs 1px below its origin, 'o' 1px below the origin 10px down.
+	var want = MakeRectLTRB(-3, 1, 3, 17)
+	if bounds != want {
+		t.Errorf("MeasureText() bounds want %v got %v", want, bounds)
+	}
+
+	var widths = make([]Scalar, 2)
+	paint.TextWidths("lo", 2, widths, nil)
+	if ScalarAbs(widths[0]-10) > 1e-4 || ScalarAbs(widths[1]-8) > 1e-4 {
+		t.Errorf("TextWidths() want [10 8] got %v", widths)
+	}
+
+	var cache = paint.detachCache(nil, nil)
+	var glyph = cache.UnicharMetrics('l')
+	if glyph.AdvanceX != 0 || ScalarAbs(glyph.AdvanceY-10) > 1e-4 || glyph.Left != -1 || glyph.Width != 2 || glyph.Top != 1 {
+		t.Errorf("vertical 'l' want advance (0, 10) left -1 top 1 width 2 got advance (%v, %v) left %v top %v width %v",
+			glyph.AdvanceX, glyph.AdvanceY, glyph.Left, glyph.Top, glyph.Width)
+	}
+}
+
+func TestAutohint(t *testing.T) {
+	var typeface = newTestTypeface(t)
+	var tests = []struct {
+		hinting    PaintHinting
+		lcd        bool
+		autohinted bool
+		hintX      bool
+		hintY      bool
+		round      bool // the advances are rounded.
+	}{
+		{KPaintHintingNo, false, false, false, false, false},
+		{KPaintHintingNo, false, true, false, false, false},
+		{KPaintHintingSlight, false, false, false, true, false},
+		{KPaintHintingNormal, false, false, false, true, false},
+		{KPaintHintingNormal, false, true, true, true, false},
+		{KPaintHintingNormal, true, false, false, true, false},
+		{KPaintHintingNormal, true, true, false, true, false},
+		{KPaintHintingFull, false, false, true, true, true},
+		{KPaintHintingFull, true, false, true, true, true},
+	}
+	for _, test := range tests {
+		var paint = NewPaint()
+		paint.SetTypeface(typeface)
+		paint.SetTextSize(13)
+		paint.SetAnitAlias(true)
+		paint.SetHinting(test.hinting)
+		paint.SetLCDRenderText(test.lcd)
+		paint.SetAutohinted(test.autohinted)
+		var props = NewSurfacePropsGeometry(0, KPixelGeometryRGBH)
+		var cache = paint.detachCache(props, nil)
+
+		for _, char := range []Unichar{'l', 'o'} {
+			var path = cache.FindPath(cache.UnicharMetrics(char))
+			var snappedX, snappedY = true, true
+			for _, pt := range path.Points() {
+				snappedX = snappedX && pt.X == ScalarRound(pt.X)
+				snappedY = snappedY && pt.Y == ScalarRound(pt.Y)
+			}
+			if snappedX != test.hintX || snappedY != test.hintY {
+				t.Errorf("hinting %v lcd %v autohinted %v %c want snapped x %v y %v got %v %v in %v",
+					test.hinting, test.lcd, test.autohinted, char, test.hintX, test.hintY, snappedX, snappedY, path.Points())
+			}
+		}
+
+		// 'l' advances 4.68 pixels at 13.
+		var glyph = cache.UnicharMetrics('l')
+		if rounded := glyph.AdvanceX == ScalarRound(glyph.AdvanceX); rounded != test.round {
+			t.Errorf("hinting %v lcd %v advance want rounded %v got %v", test.hinting, test.lcd, test.round,
+				glyph.AdvanceX)
+		}
+	}
+}
+
+func TestGlyphCacheSharing(t *testing.T) {
+	var paint = NewPaint()
+	paint.SetTypeface(newTestTypeface(t))
+	paint.SetTextSize(13)
+
+	var normal = paint.detachCache(nil, nil)
+	if paint.detachCache(nil, nil) != normal {
+		t.Errorf("detachCache() want the same cache for the same paint")
+	}
+	paint.SetHinting(KPaintHintingNo)
+	var unhinted = paint.detachCache(nil, nil)
+	if unhinted == normal {
+		t.Errorf("detachCache() want different caches for hinted and unhinted glyphs")
+	}
+	paint.SetHinting(KPaintHintingNormal)
+	paint.SetLinearText(true)
+	if cache := paint.detachCache(nil, nil); cache == normal || cache.Rec().Hinting != KPaintHintingNo {
+		t.Errorf("detachCache() want linear text unhinted")
+	}
+}
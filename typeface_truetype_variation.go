@@ -0,0 +1,615 @@
+package ggk
+
+import "math"
+
+// The tags of the registered variation axes.
+const (
+	KFontVariationAxisWeight      = FontTableTag('w'<<24 | 'g'<<16 | 'h'<<8 | 't')
+	KFontVariationAxisWidth       = FontTableTag('w'<<24 | 'd'<<16 | 't'<<8 | 'h')
+	KFontVariationAxisSlant       = FontTableTag('s'<<24 | 'l'<<16 | 'n'<<8 | 't')
+	KFontVariationAxisItalic      = FontTableTag('i'<<24 | 't'<<16 | 'a'<<8 | 'l')
+	KFontVariationAxisOpticalSize = FontTableTag('o'<<24 | 'p'<<16 | 's'<<8 | 'z')
+)
+
+const (
+	kFvarAxisHidden = 0x0001
+
+	kGvarLongOffsets = 0x0001
+
+	// tuple variation counts and headers.
+	kTupleSharedPointNumbers  = 0x8000
+	kTupleCountMask           = 0x0fff
+	kTupleEmbeddedPeak        = 0x8000
+	kTupleIntermediateRegion  = 0x4000
+	kTuplePrivatePointNumbers = 0x2000
+	kTupleIndexMask           = 0x0fff
+)
+
+// gvar varies four phantom points after the points of a glyph: the
+// horizontal origin and advance, and the vertical origin and advance.
+const kGvarPhantomPoints = 4
+
+// Read a 16.16 fixed point number.
+func ttFixed(b []byte, offset int) Scalar {
+	return Scalar(int32(ttU32(b, offset))) / (1 << 16)
+}
+
+func otF2Dot14(b []byte, offset int) Scalar {
+	return Scalar(otI16(b, offset)) / (1 << 14)
+}
+
+// Return the variation axes of the fvar table, or nil if the font is not a
+// variable font.
+func (font *tTrueTypeFont) parseVariationAxes() []FontVariationAxis {
+	var fvar = font.tables["fvar"]
+	if len(fvar) < 16 || ttU16(fvar, 0) != 1 {
+		return nil
+	}
+	var offset, count, size = ttU16(fvar, 4), ttU16(fvar, 8), ttU16(fvar, 10)
+	if size < 20 || offset+count*size > len(fvar) {
+		return nil
+	}
+	var axes = make([]FontVariationAxis, count)
+	for i := range axes {
+		var record = fvar[offset+i*size:]
+		var axis = FontVariationAxis{
+			Tag:     FontTableTag(ttU32(record, 0)),
+			Min:     ttFixed(record, 4),
+			Default: ttFixed(record, 8),
+			Max:     ttFixed(record, 12),
+			Hidden:  ttU16(record, 16)&kFvarAxisHidden != 0,
+		}
+		// an axis that doesn't hold its default is pinned to it.
+		axis.Min, axis.Max = ScalarMin(axis.Min, axis.Default), ScalarMax(axis.Max, axis.Default)
+		axes[i] = axis
+	}
+	return axes
+}
+
+/** variationPosition
+Return the position of the font on each of its axes, starting from base
+and moving to the coordinates of position, pinned to the range of their
+axis. Coordinates for axes the font doesn't have are ignored. */
+func (font *tTrueTypeFont) variationPosition(base, position []FontVariationCoordinate) []FontVariationCoordinate {
+	var result = make([]FontVariationCoordinate, len(font.axes))
+	for i, axis := range font.axes {
+		result[i] = FontVariationCoordinate{Axis: axis.Tag, Value: axis.Default}
+		if i < len(base) {
+			result[i].Value = base[i].Value
+		}
+		for _, coord := range position {
+			if coord.Axis == axis.Tag {
+				result[i].Value = ScalarPin(coord.Value, axis.Min, axis.Max)
+			}
+		}
+	}
+	return result
+}
+
+/** normalizeVariation
+Return the normalized coordinates of position, which has a coordinate for
+each axis: -1 at the minimum of the axis, 0 at the default and 1 at the
+maximum, mapped through avar and rounded to 2.14 like the tables they are
+looked up in. Returns nil at the default of every axis. */
+func (font *tTrueTypeFont) normalizeVariation(position []FontVariationCoordinate) []Scalar {
+	var coords = make([]Scalar, len(font.axes))
+	for i, axis := range font.axes {
+		var v = position[i].Value
+		switch {
+		case v < axis.Default:
+			coords[i] = (v - axis.Default) / (axis.Default - axis.Min)
+		case v > axis.Default:
+			coords[i] = (v - axis.Default) / (axis.Max - axis.Default)
+		}
+	}
+	font.applyAvar(coords)
+
+	var isDefault = true
+	for i, v := range coords {
+		coords[i] = Scalar(math.Floor(float64(ScalarPin(v, -1, 1))*(1<<14)+0.5)) / (1 << 14)
+		isDefault = isDefault && coords[i] == 0
+	}
+	if isDefault {
+		return nil
+	}
+	return coords
+}
+
+// Map the normalized coordinates through the segment maps of the avar table.
+func (font *tTrueTypeFont) applyAvar(coords []Scalar) {
+	var avar = font.tables["avar"]
+	if len(avar) < 8 || ttU16(avar, 0) != 1 || ttU16(avar, 6) != len(coords) {
+		return
+	}
+	var offset = 8
+	for i := range coords {
+		var count = otU16(avar, offset)
+		offset += 2
+		if offset+4*count > len(avar) {
+			return
+		}
+		coords[i] = avarMap(avar[offset:offset+4*count], coords[i])
+		offset += 4 * count
+	}
+}
+
+// Map v through the avar segment map of (from, to) pairs, linearly between
+// the pairs around it.
+func avarMap(segments []byte, v Scalar) Scalar {
+	var prevFrom, prevTo Scalar
+	for i := 0; i+4 <= len(segments); i += 4 {
+		var from, to = ttF2Dot14(segments, i), ttF2Dot14(segments, i+2)
+		if v == from {
+			return to
+		}
+		if v < from {
+			if i == 0 || from == prevFrom {
+				return v
+			}
+			return prevTo + (to-prevTo)*(v-prevFrom)/(from-prevFrom)
+		}
+		prevFrom, prevTo = from, to
+	}
+	return v
+}
+
+/** regionAxisScalar
+Return the factor of one axis of a variation region at the normalized
+coordinate v: 1 at the peak, falling linearly to 0 at the start and end of
+the region. Axes with a peak of 0, and invalid regions, don't limit the
+region. */
+func regionAxisScalar(v, start, peak, end Scalar) Scalar {
+	switch {
+	case peak == 0 || v == peak || start > peak || peak > end || (start < 0 && end > 0):
+		return 1
+	case v <= start || v >= end:
+		return 0
+	case v < peak:
+		return (v - start) / (peak - start)
+	default:
+		return (end - v) / (end - peak)
+	}
+}
+
+/** glyphVariationDeltas
+Return the gvar deltas of the glyph at the normalized coordinates coords,
+one per point of points, which are the points of the glyph followed by its
+phantom points, in font units. For a simple glyph ends are the contour
+ends the deltas of the points a tuple leaves out are inferred along; for
+a composite glyph points are its component offsets and ends is nil.
+Returns nil if the glyph has no variations. */
+func (font *tTrueTypeFont) glyphVariationDeltas(id GlyphID, coords []Scalar, points []tGlyphPoint, ends []int) []Point {
+	var gvar = font.tables["gvar"]
+	var axisCount = len(coords)
+	if axisCount == 0 || len(gvar) < 20 || ttU16(gvar, 0) != 1 || ttU16(gvar, 4) != axisCount {
+		return nil
+	}
+	var sharedCount, sharedTuples = ttU16(gvar, 6), otSub(gvar, ttU32(gvar, 8))
+	if len(sharedTuples) < 2*axisCount*sharedCount {
+		sharedCount = 0
+	}
+	var glyphCount, flags, dataOffset = ttU16(gvar, 12), ttU16(gvar, 14), ttU32(gvar, 16)
+	if int(id) >= glyphCount {
+		return nil
+	}
+	var start, end int
+	if flags&kGvarLongOffsets != 0 {
+		start, end = otU32(gvar, 20+4*int(id)), otU32(gvar, 24+4*int(id))
+	} else {
+		start, end = 2*otU16(gvar, 20+2*int(id)), 2*otU16(gvar, 22+2*int(id))
+	}
+	start, end = dataOffset+start, dataOffset+end
+	if start+4 > end || end > len(gvar) {
+		return nil
+	}
+	var data = gvar[start:end]
+
+	var tupleCount = ttU16(data, 0)
+	var serialized = ttU16(data, 2)
+	var sharedPoints []int
+	if tupleCount&kTupleSharedPointNumbers != 0 {
+		var ok bool
+		if sharedPoints, serialized, ok = readPackedPoints(data, serialized); !ok {
+			return nil
+		}
+	}
+
+	var deltas = make([]Point, len(points))
+	var tupleDeltas = make([]Point, len(points))
+	var touched = make([]bool, len(points))
+	var header = 4
+	for t := 0; t < tupleCount&kTupleCountMask; t++ {
+		var size, index = otU16(data, header), otU16(data, header+2)
+		header += 4
+		var peak, startTuple, endTuple []byte
+		if index&kTupleEmbeddedPeak != 0 {
+			peak = otSub(data, header)
+			header += 2 * axisCount
+		} else if index&kTupleIndexMask < sharedCount {
+			peak = sharedTuples[2*axisCount*(index&kTupleIndexMask):]
+		}
+		if index&kTupleIntermediateRegion != 0 {
+			startTuple, endTuple = otSub(data, header), otSub(data, header+2*axisCount)
+			header += 4 * axisCount
+		}
+		if header > len(data) || serialized+size > len(data) {
+			break
+		}
+		var tuple = data[serialized : serialized+size]
+		serialized += size
+		if peak == nil {
+			continue
+		}
+
+		var scalar Scalar = 1
+		for i, v := range coords {
+			var p = otF2Dot14(peak, 2*i)
+			var s, e = ScalarMin(p, 0), ScalarMax(p, 0)
+			if startTuple != nil {
+				s, e = otF2Dot14(startTuple, 2*i), otF2Dot14(endTuple, 2*i)
+			}
+			scalar *= regionAxisScalar(v, s, p, e)
+		}
+		if scalar == 0 {
+			continue
+		}
+
+		var pointNumbers, offset = sharedPoints, 0
+		if index&kTuplePrivatePointNumbers != 0 {
+			var ok bool
+			if pointNumbers, offset, ok = readPackedPoints(tuple, 0); !ok {
+				continue
+			}
+		}
+		var count = len(pointNumbers)
+		if pointNumbers == nil {
+			count = len(points)
+		}
+		var xs, ys []Scalar
+		var ok bool
+		if xs, offset, ok = readPackedDeltas(tuple, offset, count); !ok {
+			continue
+		}
+		if ys, _, ok = readPackedDeltas(tuple, offset, count); !ok {
+			continue
+		}
+
+		if pointNumbers == nil {
+			for i := range deltas {
+				deltas[i].X += scalar * xs[i]
+				deltas[i].Y += scalar * ys[i]
+			}
+			continue
+		}
+		for i := range tupleDeltas {
+			tupleDeltas[i], touched[i] = Point{}, false
+		}
+		for i, n := range pointNumbers {
+			if n < len(points) {
+				tupleDeltas[n], touched[n] = Point{xs[i], ys[i]}, true
+			}
+		}
+		if ends != nil {
+			inferDeltas(points, ends, tupleDeltas, touched)
+		}
+		for i := range deltas {
+			deltas[i].X += scalar * tupleDeltas[i].X
+			deltas[i].Y += scalar * tupleDeltas[i].Y
+		}
+	}
+	return deltas
+}
+
+// Read the packed point numbers at offset of data. Returns nil points for
+// all the points of the glyph, and the offset past the numbers.
+func readPackedPoints(data []byte, offset int) (points []int, next int, ok bool) {
+	if offset >= len(data) {
+		return nil, offset, false
+	}
+	var count = int(data[offset])
+	offset++
+	if count&0x80 != 0 {
+		if offset >= len(data) {
+			return nil, offset, false
+		}
+		count = (count&0x7f)<<8 | int(data[offset])
+		offset++
+	}
+	if count == 0 {
+		return nil, offset, true
+	}
+
+	// runs of byte or word increments to the previous point number.
+	points = make([]int, 0, count)
+	var n = 0
+	for len(points) < count {
+		if offset >= len(data) {
+			return nil, offset, false
+		}
+		var control = int(data[offset])
+		offset++
+		for run := control&0x7f + 1; run > 0 && len(points) < count; run-- {
+			if control&0x80 != 0 {
+				if offset+2 > len(data) {
+					return nil, offset, false
+				}
+				n += ttU16(data, offset)
+				offset += 2
+			} else {
+				if offset >= len(data) {
+					return nil, offset, false
+				}
+				n += int(data[offset])
+				offset++
+			}
+			points = append(points, n)
+		}
+	}
+	return points, offset, true
+}
+
+// Read count packed deltas at offset of data, and return the offset past
+// them.
+func readPackedDeltas(data []byte, offset, count int) (deltas []Scalar, next int, ok bool) {
+	deltas = make([]Scalar, 0, count)
+	for len(deltas) < count {
+		if offset >= len(data) {
+			return nil, offset, false
+		}
+		var control = int(data[offset])
+		offset++
+		for run := control&0x3f + 1; run > 0 && len(deltas) < count; run-- {
+			switch {
+			case control&0x80 != 0: // zeroes
+				deltas = append(deltas, 0)
+			case control&0x40 != 0: // words
+				if offset+2 > len(data) {
+					return nil, offset, false
+				}
+				deltas = append(deltas, Scalar(ttI16(data, offset)))
+				offset += 2
+			default:
+				if offset >= len(data) {
+					return nil, offset, false
+				}
+				deltas = append(deltas, Scalar(int8(data[offset])))
+				offset++
+			}
+		}
+	}
+	return deltas, offset, true
+}
+
+/** inferDeltas
+Set the deltas of the points of each contour that are not touched from
+the touched points before and after them along the contour, separately in
+x and y: interpolated if the point lies between them, otherwise the delta
+of the one it is beyond. A contour with no touched point doesn't move. */
+func inferDeltas(points []tGlyphPoint, ends []int, deltas []Point, touched []bool) {
+	var start = 0
+	for _, end := range ends {
+		if end >= len(points) || end < start {
+			return
+		}
+		var n = end - start + 1
+		var first = -1
+		for i := start; i <= end; i++ {
+			if touched[i] {
+				first = i
+				break
+			}
+		}
+		if first >= 0 {
+			var prev = first
+			for k := 1; k <= n; k++ {
+				var next = start + (first-start+k)%n
+				if !touched[next] {
+					continue
+				}
+				for i := start + (prev-start+1)%n; i != next; i = start + (i-start+1)%n {
+					deltas[i].X = inferDelta(points[i].X, points[prev].X, points[next].X, deltas[prev].X, deltas[next].X)
+					deltas[i].Y = inferDelta(points[i].Y, points[prev].Y, points[next].Y, deltas[prev].Y, deltas[next].Y)
+				}
+				prev = next
+			}
+		}
+		start = end + 1
+	}
+}
+
+func inferDelta(c, c1, c2, d1, d2 Scalar) Scalar {
+	if c1 > c2 {
+		c1, c2, d1, d2 = c2, c1, d2, d1
+	}
+	switch {
+	case c <= c1:
+		return d1
+	case c >= c2:
+		return d2
+	default:
+		return d1 + (c-c1)*(d2-d1)/(c2-c1)
+	}
+}
+
+// Return the number of points of the glyph that gvar varies before its
+// phantom points: the points of a simple glyph, or the components of a
+// composite one.
+func (font *tTrueTypeFont) glyphPointCount(id GlyphID) int {
+	var data = font.glyphData(id)
+	if data == nil {
+		return 0
+	}
+	if numContours := ttI16(data, 0); numContours > 0 {
+		return otU16(data, 10+2*(numContours-1)) + 1
+	} else if numContours < 0 {
+		return len(parseGlyphComponents(data))
+	}
+	return 0
+}
+
+// Return the gvar deltas of the glyph's phantom points at coords.
+func (font *tTrueTypeFont) phantomDeltas(id GlyphID, coords []Scalar) []Point {
+	var n = font.glyphPointCount(id)
+	var deltas = font.glyphVariationDeltas(id, coords, make([]tGlyphPoint, n+kGvarPhantomPoints), nil)
+	if deltas == nil {
+		return make([]Point, kGvarPhantomPoints)
+	}
+	return deltas[n:]
+}
+
+/** hAdvance
+Return the advance width of the glyph at the normalized coordinates in
+font units, varied by HVAR, or by the glyph's phantom points in gvar if
+the font has no HVAR. */
+func (font *tTrueTypeFont) hAdvance(id GlyphID, coords []Scalar) Scalar {
+	var advance, _ = font.hMetrics(id)
+	if len(coords) == 0 {
+		return Scalar(advance)
+	}
+	if hvar, ok := font.tables["HVAR"]; ok {
+		return Scalar(advance) + metricsVariationDelta(hvar, id, coords)
+	}
+	var deltas = font.phantomDeltas(id, coords)
+	return Scalar(advance) + deltas[1].X - deltas[0].X
+}
+
+// Return the advance height of the glyph at the normalized coordinates in
+// font units, varied by VVAR, or by the glyph's phantom points in gvar.
+func (font *tTrueTypeFont) vAdvance(id GlyphID, coords []Scalar) Scalar {
+	var advance, _ = font.vMetrics(id)
+	if len(coords) == 0 || font.numVMetrics == 0 {
+		return Scalar(advance)
+	}
+	if vvar, ok := font.tables["VVAR"]; ok {
+		return Scalar(advance) + metricsVariationDelta(vvar, id, coords)
+	}
+	var deltas = font.phantomDeltas(id, coords)
+	return Scalar(advance) + deltas[2].Y - deltas[3].Y
+}
+
+// Return the advance delta of the glyph in the HVAR or VVAR table, which
+// share the layout of their item variation store and advance mapping.
+func metricsVariationDelta(table []byte, id GlyphID, coords []Scalar) Scalar {
+	var store = otSub(table, otU32(table, 4))
+	var outer, inner = 0, int(id)
+	if mapping := otSub(table, otU32(table, 8)); mapping != nil {
+		outer, inner = deltaSetIndex(mapping, inner)
+	}
+	return itemVariationDelta(store, outer, inner, coords)
+}
+
+// Return the outer and inner index of the delta set that index maps to in
+// a DeltaSetIndexMap. Indices past the end of the map use its last entry.
+func deltaSetIndex(mapping []byte, index int) (outer, inner int) {
+	var format, entryFormat = otU8(mapping, 0), otU8(mapping, 1)
+	var count, offset = otU16(mapping, 2), 4
+	if format == 1 {
+		count, offset = otU32(mapping, 2), 6
+	}
+	if count == 0 {
+		return 0, index
+	}
+	if index >= count {
+		index = count - 1
+	}
+	var size = (entryFormat>>4)&0x03 + 1
+	var innerBits = uint(entryFormat&0x0f + 1)
+	var entry = 0
+	for i := 0; i < size; i++ {
+		entry = entry<<8 | otU8(mapping, offset+index*size+i)
+	}
+	return entry >> innerBits, entry & (1<<innerBits - 1)
+}
+
+/** itemVariationDelta
+Return the delta of the item inner of the item variation data outer in an
+ItemVariationStore at the normalized coordinates: the sum of the deltas
+of each region the item varies in, scaled by the region's scalar. */
+func itemVariationDelta(store []byte, outer, inner int, coords []Scalar) Scalar {
+	if store == nil || otU16(store, 0) != 1 || outer >= otU16(store, 6) {
+		return 0
+	}
+	var regions = otSub(store, otU32(store, 2))
+	var item = otSub(store, otU32(store, 8+4*outer))
+	if regions == nil || item == nil || inner >= otU16(item, 0) {
+		return 0
+	}
+	var wordCount, regionCount = otU16(item, 2), otU16(item, 4)
+	var wordSize, byteSize = 2, 1
+	if wordCount&0x8000 != 0 {
+		wordSize, byteSize = 4, 2
+	}
+	wordCount &= 0x7fff
+	var row = 6 + 2*regionCount + inner*(wordCount*wordSize+(regionCount-wordCount)*byteSize)
+
+	var axisCount, totalRegions = otU16(regions, 0), otU16(regions, 2)
+	var delta Scalar
+	for i := 0; i < regionCount; i++ {
+		var value int
+		if i < wordCount {
+			if wordSize == 4 {
+				value = int(int32(otU32(item, row+4*i)))
+			} else {
+				value = otI16(item, row+2*i)
+			}
+		} else {
+			var offset = row + wordCount*wordSize + (i-wordCount)*byteSize
+			if byteSize == 2 {
+				value = otI16(item, offset)
+			} else {
+				value = otI8(item, offset)
+			}
+		}
+		var region = otU16(item, 6+2*i)
+		if value == 0 || region >= totalRegions {
+			continue
+		}
+		var scalar Scalar = 1
+		for axis := 0; axis < axisCount && scalar != 0; axis++ {
+			var record = 4 + 6*(region*axisCount+axis)
+			var v Scalar
+			if axis < len(coords) {
+				v = coords[axis]
+			}
+			scalar *= regionAxisScalar(v, otF2Dot14(regions, record), otF2Dot14(regions, record+2), otF2Dot14(regions, record+4))
+		}
+		delta += scalar * Scalar(value)
+	}
+	return delta
+}
+
+// The percentages of the normal width of the width classes, which the
+// 'wdth' axis is in.
+var gFontWidthPercents = [...]Scalar{50, 62.5, 75, 87.5, 100, 112.5, 125, 150, 200}
+
+// Return the style of the font at the variation position: the 'wght',
+// 'wdth', 'slnt' and 'ital' axes override the style of the OS/2 table.
+func variationFontStyle(style FontStyle, position []FontVariationCoordinate) FontStyle {
+	var weight, width, slant = style.Weight(), style.Width(), style.Slant()
+	for _, coord := range position {
+		switch coord.Axis {
+		case KFontVariationAxisWeight:
+			weight = ScalarRoundToInt(coord.Value)
+		case KFontVariationAxisWidth:
+			width = KFontStyleWidthUltraCondensed
+			for i, percent := range gFontWidthPercents {
+				if ScalarAbs(coord.Value-percent) < ScalarAbs(coord.Value-gFontWidthPercents[width-1]) {
+					width = i + 1
+				}
+			}
+		case KFontVariationAxisItalic:
+			if coord.Value >= 0.5 {
+				slant = KFontStyleSlantItalic
+			} else if slant == KFontStyleSlantItalic {
+				slant = KFontStyleSlantUpright
+			}
+		case KFontVariationAxisSlant:
+			if coord.Value != 0 && slant == KFontStyleSlantUpright {
+				slant = KFontStyleSlantOblique
+			} else if coord.Value == 0 && slant == KFontStyleSlantOblique {
+				slant = KFontStyleSlantUpright
+			}
+		}
+	}
+	return NewFontStyle(weight, width, slant)
+}
@@ -0,0 +1,205 @@
+package ggk
+
+import "testing"
+
+func putF2Dot14(b []byte, v float64) []byte {
+	return putU16(b, int(v*(1<<14))&0xffff)
+}
+
+// makeTestVariationTables returns the fvar, avar and gvar tables of a
+// variable font of gTestGlyphs with a 'wght' axis from 100 to 900 around
+// 400 and a 'wdth' axis from 75 to 125 around 100. avar maps 'wght' 650
+// to 0.75 of the way to 900. In gvar, heavier weights move the right side
+// of 'l' and its advance right by up to 100, and narrower widths move the
+// top left and top right points of 'l' left by 20 and 40, and the points
+// that are left out along with them.
+func makeTestVariationTables() []tTestTable {
+	var fvar = putU16(putU16(putU32(nil, 0x00010000), 16), 2)
+	fvar = putU16(putU16(putU16(putU16(fvar, 2), 20), 0), 0)
+	for _, axis := range []struct {
+		tag                string
+		min, def, max, flg int
+	}{
+		{"wght", 100, 400, 900, 0},
+		{"wdth", 75, 100, 125, kFvarAxisHidden},
+	} {
+		fvar = append(fvar, axis.tag...)
+		fvar = putU32(putU32(putU32(fvar, axis.min<<16), axis.def<<16), axis.max<<16)
+		fvar = putU16(putU16(fvar, axis.flg), 256)
+	}
+
+	var avar = putU16(putU16(putU32(nil, 0x00010000), 0), 2)
+	avar = putU16(avar, 4)
+	for _, v := range []float64{-1, -1, 0, 0, 0.5, 0.75, 1, 1} {
+		avar = putF2Dot14(avar, v)
+	}
+	avar = putU16(avar, 3)
+	for _, v := range []float64{-1, -1, 0, 0, 1, 1} {
+		avar = putF2Dot14(avar, v)
+	}
+
+	// 'l' has a tuple at the shared peak 'wght' 1 with deltas for all its
+	// points and phantom points, and one at the embedded peak 'wdth' -1
+	// with deltas for points 1 and 2.
+	var glyph = putU16(putU16(nil, 2), 16)
+	var heavy = []byte{0x07, 0, 0, 100, 100, 0, 100, 0, 0, 0x87}
+	var narrow = []byte{0x02, 0x01, 1, 1, 0x01, byte(0x100 - 20), byte(0x100 - 40), 0x81}
+	glyph = putU16(putU16(glyph, len(heavy)), 0)
+	glyph = putU16(putU16(glyph, len(narrow)), kTupleEmbeddedPeak|kTuplePrivatePointNumbers)
+	glyph = putF2Dot14(putF2Dot14(glyph, 0), -1)
+	glyph = append(append(glyph, heavy...), narrow...)
+
+	var gvar = putU16(putU16(putU32(nil, 0x00010000), 2), 1)
+	gvar = putU16(putU16(putU32(gvar, 36), 3), kGvarLongOffsets)
+	gvar = putU32(gvar, 40)
+	gvar = putU32(putU32(putU32(putU32(gvar, 0), 0), len(glyph)), len(glyph))
+	gvar = putF2Dot14(putF2Dot14(gvar, 1), 0)
+	gvar = append(gvar, glyph...)
+
+	return []tTestTable{{"fvar", fvar}, {"avar", avar}, {"gvar", gvar}}
+}
+
+// makeTestHVAR returns an HVAR table that widens 'l' by up to 50 and 'o' by
+// up to 30 at heavier weights.
+func makeTestHVAR() []byte {
+	var hvar = putU32(putU32(putU32(putU32(putU32(nil, 0x00010000), 20), 0), 0), 0)
+	hvar = putU32(putU16(putU32(putU16(hvar, 1), 12), 1), 28)
+	hvar = putU16(putU16(hvar, 2), 1)
+	hvar = putF2Dot14(putF2Dot14(putF2Dot14(hvar, 0), 1), 1)
+	hvar = putF2Dot14(putF2Dot14(putF2Dot14(hvar, 0), 0), 0)
+	hvar = putU16(putU16(putU16(putU16(hvar, 3), 0), 1), 0)
+	return append(hvar, 0, 50, 30)
+}
+
+func newTestVariableTypeface(t *testing.T, extra ...tTestTable) *Typeface {
+	var tables = append(makeTestVariationTables(), extra...)
+	var typeface, err = NewTypefaceFromData(makeTestTrueTypeFont(gTestGlyphs, tables...))
+	if err != nil {
+		t.Fatalf("NewTypefaceFromData() failed: %v", err)
+	}
+	return typeface
+}
+
+func TestVariationDesignParameters(t *testing.T) {
+	var typeface = newTestVariableTypeface(t)
+	var axes = typeface.VariationDesignParameters()
+	var want = []FontVariationAxis{
+		{KFontVariationAxisWeight, 100, 400, 900, false},
+		{KFontVariationAxisWidth, 75, 100, 125, true},
+	}
+	if len(axes) != len(want) || axes[0] != want[0] || axes[1] != want[1] {
+		t.Errorf("VariationDesignParameters() want %v got %v", want, axes)
+	}
+
+	var tests = []struct {
+		position []FontVariationCoordinate
+		want     [2]Scalar
+		style    FontStyle
+	}{
+		{nil, [2]Scalar{400, 100}, FontStyleNormal()},
+		{[]FontVariationCoordinate{{KFontVariationAxisWeight, 650}}, [2]Scalar{650, 100},
+			NewFontStyle(650, KFontStyleWidthNormal, KFontStyleSlantUpright)},
+		{[]FontVariationCoordinate{{KFontVariationAxisWidth, 85}, {KFontVariationAxisWeight, 2000}}, [2]Scalar{900, 85},
+			NewFontStyle(900, KFontStyleWidthSemiCondensed, KFontStyleSlantUpright)},
+		{[]FontVariationCoordinate{{KFontVariationAxisOpticalSize, 12}}, [2]Scalar{400, 100}, FontStyleNormal()},
+	}
+	for _, test := range tests {
+		var clone = typeface.MakeClone(test.position...)
+		var position = clone.VariationDesignPosition()
+		if len(position) != 2 || position[0].Value != test.want[0] || position[1].Value != test.want[1] {
+			t.Errorf("MakeClone(%v) want position %v got %v", test.position, test.want, position)
+		}
+		if clone.FontStyle() != test.style {
+			t.Errorf("MakeClone(%v) want style %v got %v", test.position, test.style, clone.FontStyle())
+		}
+		if test.position != nil && clone.UniqueID() == typeface.UniqueID() {
+			t.Errorf("MakeClone(%v) want a new unique ID", test.position)
+		}
+	}
+
+	// clones start from the position of the typeface they are made from.
+	var clone = typeface.MakeClone(FontVariationCoordinate{KFontVariationAxisWeight, 700})
+	clone = clone.MakeClone(FontVariationCoordinate{KFontVariationAxisWidth, 125})
+	if position := clone.VariationDesignPosition(); position[0].Value != 700 || position[1].Value != 125 {
+		t.Errorf("MakeClone() of a clone want position [700 125] got %v", position)
+	}
+
+	var plain = newTestTypeface(t)
+	if plain.VariationDesignParameters() != nil || plain.MakeClone(FontVariationCoordinate{KFontVariationAxisWeight, 700}) != plain {
+		t.Errorf("a font without fvar want no axes and MakeClone() to return the typeface")
+	}
+}
+
+func TestVariationOutline(t *testing.T) {
+	var typeface = newTestVariableTypeface(t)
+	var hvarTypeface = newTestVariableTypeface(t, tTestTable{"HVAR", makeTestHVAR()})
+	var tests = []struct {
+		typeface *Typeface
+		position []FontVariationCoordinate
+		left     [2]Scalar // of the bottom and top points of 'l'.
+		right    [2]Scalar
+		advance  Scalar
+		advanceO Scalar
+	}{
+		{typeface, nil, [2]Scalar{100, 100}, [2]Scalar{260, 260}, 360, 600},
+		{typeface, []FontVariationCoordinate{{KFontVariationAxisWeight, 900}}, [2]Scalar{100, 100}, [2]Scalar{360, 360}, 460, 600},
+		{typeface, []FontVariationCoordinate{{KFontVariationAxisWeight, 650}}, [2]Scalar{100, 100}, [2]Scalar{335, 335}, 435, 600},
+		{typeface, []FontVariationCoordinate{{KFontVariationAxisWeight, 250}}, [2]Scalar{100, 100}, [2]Scalar{260, 260}, 360, 600},
+		{typeface, []FontVariationCoordinate{{KFontVariationAxisWidth, 75}}, [2]Scalar{80, 80}, [2]Scalar{220, 220}, 360, 600},
+		{typeface, []FontVariationCoordinate{{KFontVariationAxisWidth, 87.5}}, [2]Scalar{90, 90}, [2]Scalar{240, 240}, 360, 600},
+		{typeface, []FontVariationCoordinate{{KFontVariationAxisWeight, 900}, {KFontVariationAxisWidth, 75}}, [2]Scalar{80, 80}, [2]Scalar{320, 320}, 460, 600},
+		{hvarTypeface, []FontVariationCoordinate{{KFontVariationAxisWeight, 900}}, [2]Scalar{100, 100}, [2]Scalar{360, 360}, 410, 630},
+		{hvarTypeface, []FontVariationCoordinate{{KFontVariationAxisWeight, 650}}, [2]Scalar{100, 100}, [2]Scalar{335, 335}, 397.5, 622.5},
+	}
+	for _, test := range tests {
+		var paint = NewPaint()
+		paint.SetTypeface(test.typeface.MakeClone(test.position...))
+		paint.SetTextSize(1000)
+		paint.SetHinting(KPaintHintingNo)
+
+		var cache = paint.detachCache(nil, nil)
+		var points = cache.FindPath(cache.UnicharMetrics('l')).Points()
+		var left, right = [2]Scalar{1e9, 1e9}, [2]Scalar{-1e9, -1e9}
+		for _, pt := range points {
+			var i = 0
+			if pt.Y < -350 {
+				i = 1
+			}
+			left[i], right[i] = ScalarMin(left[i], pt.X), ScalarMax(right[i], pt.X)
+		}
+		if left != test.left || right != test.right {
+			t.Errorf("%v 'l' want left %v right %v got %v %v", test.position, test.left, test.right, left, right)
+		}
+
+		var widths = make([]Scalar, 2)
+		paint.TextWidths("lo", 2, widths, nil)
+		if ScalarAbs(widths[0]-test.advance) > 0.01 || ScalarAbs(widths[1]-test.advanceO) > 0.01 {
+			t.Errorf("%v TextWidths() want [%v %v] got %v", test.position, test.advance, test.advanceO, widths)
+		}
+		if got := paint.MeasureText("lo", 2, nil); ScalarAbs(got-test.advance-test.advanceO) > 0.01 {
+			t.Errorf("%v MeasureText() want %v got %v", test.position, test.advance+test.advanceO, got)
+		}
+	}
+}
+
+func TestPackedPointsAndDeltas(t *testing.T) {
+	// a run of two byte numbers and a run of one word number.
+	var points, next, ok = readPackedPoints([]byte{3, 0x01, 2, 3, 0x80, 0x01, 0x00}, 0)
+	if !ok || next != 7 || len(points) != 3 || points[0] != 2 || points[1] != 5 || points[2] != 261 {
+		t.Errorf("readPackedPoints() want [2 5 261] at 7 got %v at %v, %v", points, next, ok)
+	}
+	if points, _, ok = readPackedPoints([]byte{0}, 0); !ok || points != nil {
+		t.Errorf("readPackedPoints() of all points want nil got %v, %v", points, ok)
+	}
+	if _, _, ok = readPackedPoints([]byte{2, 0x01, 2}, 0); ok {
+		t.Errorf("readPackedPoints() of cut short data want failure")
+	}
+
+	var deltas []Scalar
+	deltas, next, ok = readPackedDeltas([]byte{0x81, 0x40, 0xff, 0x00, 0x00, 0xfe}, 0, 4)
+	var want = []Scalar{0, 0, -256, -2}
+	if !ok || next != 6 || len(deltas) != 4 || deltas[0] != want[0] || deltas[1] != want[1] ||
+		deltas[2] != want[2] || deltas[3] != want[3] {
+		t.Errorf("readPackedDeltas() want %v at 6 got %v at %v, %v", want, deltas, next, ok)
+	}
+}
@@ -3,11 +3,46 @@ package ggk
 type XfermodeMode int
 
 const (
-	KXfermodeModeSrcOver XfermodeMode = iota //!< [Sa + Da * (1 - Sa), Sc + Dc * (1 - Sa)]
-	KXfermodeModeDst
-	KXfermodeModeSrc
-	KXfermodeModeClear
+	KXfermodeModeClear    XfermodeMode = iota //!< [0, 0]
+	KXfermodeModeSrc                          //!< [Sa, Sc]
+	KXfermodeModeDst                          //!< [Da, Dc]
+	KXfermodeModeSrcOver                      //!< [Sa + Da * (1 - Sa), Sc + Dc * (1 - Sa)]
+	KXfermodeModeDstOver                      //!< [Da + Sa * (1 - Da), Dc + Sc * (1 - Da)]
+	KXfermodeModeSrcIn                        //!< [Sa * Da, Sc * Da]
+	KXfermodeModeDstIn                        //!< [Da * Sa, Dc * Sa]
+	KXfermodeModeSrcOut                       //!< [Sa * (1 - Da), Sc * (1 - Da)]
+	KXfermodeModeDstOut                       //!< [Da * (1 - Sa), Dc * (1 - Sa)]
+	KXfermodeModeSrcATop                      //!< [Da, Sc * Da + Dc * (1 - Sa)]
+	KXfermodeModeDstATop                      //!< [Sa, Dc * Sa + Sc * (1 - Da)]
+	KXfermodeModeXor                          //!< [Sa + Da - 2 * Sa * Da, Sc * (1 - Da) + Dc * (1 - Sa)]
+	KXfermodeModePlus                         //!< [Sa + Da, Sc + Dc]
+	KXfermodeModeModulate                     //!< [Sa * Da, Sc * Dc]
 
+	// KXfermodeModeScreen is the last mode representable as the coefficient
+	// of src and dst.
+	KXfermodeModeScreen //!< [Sa + Da - Sa * Da, Sc + Dc - Sc * Dc]
+
+	// The separable blend modes, [Sa + Da - Sa * Da, B(Sc, Dc)].
+	KXfermodeModeOverlay
+	KXfermodeModeDarken
+	KXfermodeModeLighten
+	KXfermodeModeColorDodge
+	KXfermodeModeColorBurn
+	KXfermodeModeHardLight
+	KXfermodeModeSoftLight
+	KXfermodeModeDifference
+	KXfermodeModeExclusion
+	KXfermodeModeMultiply
+
+	// The non-separable blend modes, which blend the hue, saturation and
+	// luminosity of the colors.
+	KXfermodeModeHue
+	KXfermodeModeSaturation
+	KXfermodeModeColor
+	KXfermodeModeLuminosity
+
+	KXfermodeModeLastSeparable = KXfermodeModeMultiply
+	KXfermodeModeLast          = KXfermodeModeLuminosity
 )
 
 // Xfermode
@@ -21,27 +56,37 @@ const (
 // All subclasses are required to be reentrant-safe : it must be legal to share
 // the same instance between several threads.
 type Xfermode struct {
-	// empty
+	mode XfermodeMode
 }
 
 func NewXfermode() *Xfermode {
-	toimpl()
-	return &Xfermode{}
+	return &Xfermode{mode: KXfermodeModeSrcOver}
 }
 
+/** NewXfermodeWithMode
+Return the xfermode of mode, or nil for KXfermodeModeSrcOver, which is what
+a nil xfermode draws with. */
 func NewXfermodeWithMode(mode XfermodeMode) *Xfermode {
-	toimpl()
-	return nil
+	if mode == KXfermodeModeSrcOver {
+		return nil
+	}
+	return &Xfermode{mode: mode}
 }
 
+// XfermodeIsMode returns true if xfer draws with mode. A nil xfer draws with
+// KXfermodeModeSrcOver.
 func XfermodeIsMode(xfer *Xfermode, mode XfermodeMode) bool {
-	toimpl()
-	return false
+	var xferMode, ok = XfermodeAsMode(xfer)
+	return ok && xferMode == mode
 }
 
+// XfermodeAsMode returns the mode xfer draws with. A nil xfer draws with
+// KXfermodeModeSrcOver.
 func XfermodeAsMode(xfer *Xfermode) (XfermodeMode, bool) {
-	toimpl()
-	return KXfermodeModeSrcOver, false
+	if xfer == nil {
+		return KXfermodeModeSrcOver, true
+	}
+	return xfer.mode, true
 }
 
 func (xfermode *Xfermode) AppendStages(pipeline *RasterPipeline) bool {
@@ -0,0 +1,207 @@
+package ggk
+
+import "math"
+
+/** blendPM4f
+Return the premultiplied src blended with the premultiplied dst in mode.
+The result is not pinned, so it can be out of 0..1 for modes like plus. */
+func blendPM4f(mode XfermodeMode, s, d PM4f) PM4f {
+	switch mode {
+	case KXfermodeModeClear:
+		return PM4f{}
+	case KXfermodeModeSrc:
+		return s
+	case KXfermodeModeDst:
+		return d
+	case KXfermodeModeSrcOver:
+		return blendCoefficients(s, d, 1, 1-s.A)
+	case KXfermodeModeDstOver:
+		return blendCoefficients(s, d, 1-d.A, 1)
+	case KXfermodeModeSrcIn:
+		return blendCoefficients(s, d, d.A, 0)
+	case KXfermodeModeDstIn:
+		return blendCoefficients(s, d, 0, s.A)
+	case KXfermodeModeSrcOut:
+		return blendCoefficients(s, d, 1-d.A, 0)
+	case KXfermodeModeDstOut:
+		return blendCoefficients(s, d, 0, 1-s.A)
+	case KXfermodeModeSrcATop:
+		var color = blendCoefficients(s, d, d.A, 1-s.A)
+		color.A = d.A
+		return color
+	case KXfermodeModeDstATop:
+		var color = blendCoefficients(s, d, 1-d.A, s.A)
+		color.A = s.A
+		return color
+	case KXfermodeModeXor:
+		return blendCoefficients(s, d, 1-d.A, 1-s.A)
+	case KXfermodeModePlus:
+		return PM4f{
+			float32(math.Min(float64(s.R+d.R), 1)),
+			float32(math.Min(float64(s.G+d.G), 1)),
+			float32(math.Min(float64(s.B+d.B), 1)),
+			float32(math.Min(float64(s.A+d.A), 1)),
+		}
+	case KXfermodeModeModulate:
+		return PM4f{s.R * d.R, s.G * d.G, s.B * d.B, s.A * d.A}
+	case KXfermodeModeScreen:
+		return PM4f{s.R + d.R - s.R*d.R, s.G + d.G - s.G*d.G, s.B + d.B - s.B*d.B, s.A + d.A - s.A*d.A}
+	}
+	var a = s.A + d.A - s.A*d.A
+	if mode <= KXfermodeModeLastSeparable {
+		return PM4f{
+			blendSeparable(mode, s.R, d.R, s.A, d.A),
+			blendSeparable(mode, s.G, d.G, s.A, d.A),
+			blendSeparable(mode, s.B, d.B, s.A, d.A),
+			a,
+		}
+	}
+	if mode > KXfermodeModeLast {
+		return s
+	}
+
+	// blend the unpremultiplied colors where both are there.
+	var color = blendCoefficients(s, d, 1-d.A, 1-s.A)
+	if s.A > 0 && d.A > 0 {
+		var cs, cb = s.Unpremul(), d.Unpremul()
+		var b Color4f
+		switch mode {
+		case KXfermodeModeHue:
+			b = setLum(setSat(cs, sat(cb)), lum(cb))
+		case KXfermodeModeSaturation:
+			b = setLum(setSat(cb, sat(cs)), lum(cb))
+		case KXfermodeModeColor:
+			b = setLum(cs, lum(cb))
+		case KXfermodeModeLuminosity:
+			b = setLum(cb, lum(cs))
+		}
+		var sada = s.A * d.A
+		color.R += sada * b.R
+		color.G += sada * b.G
+		color.B += sada * b.B
+	}
+	color.A = a
+	return color
+}
+
+// Return src * sc + dst * dc.
+func blendCoefficients(s, d PM4f, sc, dc float32) PM4f {
+	return PM4f{s.R*sc + d.R*dc, s.G*sc + d.G*dc, s.B*sc + d.B*dc, s.A*sc + d.A*dc}
+}
+
+// Return the premultiplied component sc blended with dc in the separable
+// mode, with sa and da the alphas of the colors.
+func blendSeparable(mode XfermodeMode, sc, dc, sa, da float32) float32 {
+	var rest = sc*(1-da) + dc*(1-sa)
+	switch mode {
+	case KXfermodeModeOverlay:
+		if 2*dc <= da {
+			return 2*sc*dc + rest
+		}
+		return sa*da - 2*(da-dc)*(sa-sc) + rest
+	case KXfermodeModeDarken:
+		return sc + dc - float32(math.Max(float64(sc*da), float64(dc*sa)))
+	case KXfermodeModeLighten:
+		return sc + dc - float32(math.Min(float64(sc*da), float64(dc*sa)))
+	case KXfermodeModeColorDodge:
+		if dc == 0 {
+			return sc * (1 - da)
+		}
+		if sa == sc {
+			return sa*da + rest
+		}
+		return sa*float32(math.Min(float64(da), float64(dc*sa/(sa-sc)))) + rest
+	case KXfermodeModeColorBurn:
+		if dc == da {
+			return sa*da + rest
+		}
+		if sc == 0 {
+			return dc * (1 - sa)
+		}
+		return sa*(da-float32(math.Min(float64(da), float64((da-dc)*sa/sc)))) + rest
+	case KXfermodeModeHardLight:
+		if 2*sc <= sa {
+			return 2*sc*dc + rest
+		}
+		return sa*da - 2*(da-dc)*(sa-sc) + rest
+	case KXfermodeModeSoftLight:
+		var m float32
+		if da > 0 {
+			m = dc / da
+		}
+		if 2*sc <= sa {
+			return dc*(sa+(2*sc-sa)*(1-m)) + rest
+		}
+		var tmp float32
+		if 4*dc <= da {
+			var m4 = 4 * m
+			tmp = m4*(m4+1)*(m-1) + 7*m
+		} else {
+			tmp = float32(math.Sqrt(float64(m))) - m
+		}
+		return dc*sa + da*(2*sc-sa)*tmp + rest
+	case KXfermodeModeDifference:
+		return sc + dc - 2*float32(math.Min(float64(sc*da), float64(dc*sa)))
+	case KXfermodeModeExclusion:
+		return sc + dc - 2*sc*dc
+	case KXfermodeModeMultiply:
+		return sc*dc + rest
+	}
+	return sc
+}
+
+// Return the luminosity of the color.
+func lum(c Color4f) float32 {
+	return 0.3*c.R + 0.59*c.G + 0.11*c.B
+}
+
+// Return c with its luminosity set to l, keeping its components in 0..1.
+func setLum(c Color4f, l float32) Color4f {
+	var d = l - lum(c)
+	c.R, c.G, c.B = c.R+d, c.G+d, c.B+d
+
+	// clip the color back into range around its luminosity.
+	l = lum(c)
+	var n = float32(math.Min(float64(c.R), math.Min(float64(c.G), float64(c.B))))
+	var x = float32(math.Max(float64(c.R), math.Max(float64(c.G), float64(c.B))))
+	if n < 0 && l != n {
+		c.R = l + (c.R-l)*l/(l-n)
+		c.G = l + (c.G-l)*l/(l-n)
+		c.B = l + (c.B-l)*l/(l-n)
+	}
+	if x > 1 && x != l {
+		c.R = l + (c.R-l)*(1-l)/(x-l)
+		c.G = l + (c.G-l)*(1-l)/(x-l)
+		c.B = l + (c.B-l)*(1-l)/(x-l)
+	}
+	return c
+}
+
+// Return the saturation of the color.
+func sat(c Color4f) float32 {
+	return float32(math.Max(float64(c.R), math.Max(float64(c.G), float64(c.B))) -
+		math.Min(float64(c.R), math.Min(float64(c.G), float64(c.B))))
+}
+
+// Return c with its saturation set to s, keeping the order of its
+// components.
+func setSat(c Color4f, s float32) Color4f {
+	var components = []*float32{&c.R, &c.G, &c.B}
+	// sort the components into min, mid and max.
+	for i := 0; i < 2; i++ {
+		for j := i + 1; j < 3; j++ {
+			if *components[j] < *components[i] {
+				components[i], components[j] = components[j], components[i]
+			}
+		}
+	}
+	var min, mid, max = components[0], components[1], components[2]
+	if *max > *min {
+		*mid = (*mid - *min) * s / (*max - *min)
+		*max = s
+	} else {
+		*mid, *max = 0, 0
+	}
+	*min = 0
+	return c
+}